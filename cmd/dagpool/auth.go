@@ -6,6 +6,7 @@ import (
 	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/dpuser/upolicy"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
+	"strconv"
 )
 
 var authCmd = &cli.Command{
@@ -50,7 +51,7 @@ var createUser = &cli.Command{
 		},
 		&cli.Uint64Flag{
 			Name:  "capacity",
-			Usage: "set the capacity",
+			Usage: "set the capacity, no limit if not set, 0 suspends the writes of the user",
 		},
 		&cli.StringFlag{
 			Name:  "policy",
@@ -74,7 +75,11 @@ var createUser = &cli.Command{
 		if password == "" {
 			return xerrors.Errorf("you must give the password")
 		}
-		capacity := cctx.Uint64("capacity")
+		var capacity *uint64
+		if cctx.IsSet("capacity") {
+			c := cctx.Uint64("capacity")
+			capacity = &c
+		}
 
 		policy := cctx.String("policy")
 		if !upolicy.CheckValid(policy) {
@@ -142,7 +147,11 @@ var queryUser = &cli.Command{
 			log.Errorf("get user err:%v", err)
 			return err
 		}
-		fmt.Printf("username:%v policy:%v capacity:%v\n", reply.Username, reply.Policy, reply.Capacity)
+		capacity := "no limit"
+		if reply.Capacity != nil {
+			capacity = strconv.FormatUint(*reply.Capacity, 10)
+		}
+		fmt.Printf("username:%v policy:%v capacity:%v\n", reply.Username, reply.Policy, capacity)
 		return nil
 	},
 }
@@ -178,7 +187,7 @@ var updateUser = &cli.Command{
 		},
 		&cli.Uint64Flag{
 			Name:  "new-capacity",
-			Usage: "set the new capacity",
+			Usage: "set the new capacity, unchanged if not set, 0 suspends the writes of the user",
 		},
 		&cli.StringFlag{
 			Name:  "new-policy",
@@ -201,7 +210,11 @@ var updateUser = &cli.Command{
 		if password == "" {
 			return xerrors.Errorf("you must give the password")
 		}
-		capacity := cctx.Uint64("new-capacity")
+		var capacity *uint64
+		if cctx.IsSet("new-capacity") {
+			c := cctx.Uint64("new-capacity")
+			capacity = &c
+		}
 
 		policy := cctx.String("new-policy")
 		if !upolicy.CheckValid(policy) {
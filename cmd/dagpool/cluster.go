@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/dustin/go-humanize"
 	"github.com/filedag-project/filedag-storage/dag/config"
 	"github.com/filedag-project/filedag-storage/dag/pool/client"
 	"github.com/filedag-project/filedag-storage/dag/slotsmgr"
@@ -12,6 +13,7 @@ import (
 	"io/ioutil"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var clusterCmd = &cli.Command{
@@ -22,6 +24,7 @@ var clusterCmd = &cli.Command{
 		addDagNode,
 		getDagNode,
 		removeDagNode,
+		drainDagNode,
 		balanceSlots,
 		migrateSlots,
 		repair,
@@ -71,14 +74,31 @@ var status = &cli.Command{
 			}
 			fmt.Printf("  name: %s\n  slots: %s (%d slots)\n",
 				status.Node.Name, slotsInfo, slots)
+			if status.Drained {
+				fmt.Printf("  drain: drained, it's safe to remove the dagnode\n")
+			} else if status.Draining {
+				fmt.Printf("  drain: draining\n")
+			}
 			if cctx.Bool("detail") {
+				fmt.Printf("  heartbeat_interval: %v\n  heartbeat_timeout: %v\n  heartbeat_max_misses: %d\n",
+					time.Duration(status.HeartbeatInterval)*time.Millisecond, time.Duration(status.HeartbeatTimeout)*time.Millisecond,
+					status.HeartbeatMaxMisses)
 				fmt.Printf("  erasure_set:\n    nodes:\n")
 				for idx, nd := range status.Node.Nodes {
 					st := "fail"
 					if nd.State != nil && *nd.State {
 						st = "ok"
 					}
-					fmt.Printf("      set_index: %d, rpc_address: %s, state: %s\n", idx, nd.RpcAddress, st)
+					lastSeen := "never"
+					if nd.LastSeen != nil {
+						seen := time.Unix(0, *nd.LastSeen*int64(time.Millisecond))
+						lastSeen = fmt.Sprintf("%s (%v ago)", seen.Format(time.RFC3339), time.Since(seen).Round(time.Second))
+					}
+					space := "unknown"
+					if nd.TotalSpace != nil && nd.FreeSpace != nil {
+						space = fmt.Sprintf("%s free of %s", humanize.IBytes(*nd.FreeSpace), humanize.IBytes(*nd.TotalSpace))
+					}
+					fmt.Printf("      set_index: %d, rpc_address: %s, state: %s, last_seen: %s, space: %s\n", idx, nd.RpcAddress, st, lastSeen, space)
 				}
 				fmt.Printf("    data_blocks: %d\n    parity_blocks: %d\n",
 					status.Node.DataBlocks, status.Node.ParityBlocks)
@@ -225,6 +245,37 @@ var removeDagNode = &cli.Command{
 	},
 }
 
+var drainDagNode = &cli.Command{
+	Name:      "drain",
+	Usage:     "Drain a dagnode before the maintenance, its slots are migrated to the other dagnodes",
+	ArgsUsage: "dagnode_name",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "address",
+			Usage: "the address of dagpool server",
+			Value: "127.0.0.1:50001",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		addr := cctx.String("address")
+		if cctx.NArg() != 1 {
+			return errors.New("a dagnode name is required")
+		}
+
+		cli, err := client.NewPoolClusterClient(addr)
+		if err != nil {
+			return err
+		}
+		defer cli.Close(cctx.Context)
+
+		if err = cli.DrainDagNode(cctx.Context, cctx.Args().First()); err != nil {
+			return err
+		}
+		fmt.Printf("the dagnode is draining, check the cluster status until it's drained\n")
+		return nil
+	},
+}
+
 var balanceSlots = &cli.Command{
 	Name:  "balance",
 	Usage: "Balance slots of the dag pool cluster",
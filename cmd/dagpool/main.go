@@ -13,6 +13,7 @@ func main() {
 		startCmd,
 		authCmd,
 		clusterCmd,
+		validateConfigCmd,
 	}
 	app := &cli.App{
 		Name:                 "dagpool",
@@ -2,15 +2,21 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/filedag-project/filedag-storage/dag/config"
 	"github.com/filedag-project/filedag-storage/dag/pool/poolservice"
 	"github.com/filedag-project/filedag-storage/dag/pool/server"
 	"github.com/filedag-project/filedag-storage/dag/proto"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
 	logging "github.com/ipfs/go-log/v2"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/urfave/cli/v2"
 	"google.golang.org/grpc"
+	"io/ioutil"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"path"
@@ -38,6 +44,11 @@ var startCmd = &cli.Command{
 			Usage: "directory to store data in",
 			Value: "./dp-data",
 		},
+		&cli.StringFlag{
+			Name:  "meta-backend",
+			Usage: "set the backend of the metadata, leveldb or memory, the memory loses the metadata on exit",
+			Value: metadb.BackendLevelDB,
+		},
 		&cli.StringFlag{
 			Name:    "root-user",
 			Usage:   "set root user",
@@ -55,18 +66,73 @@ var startCmd = &cli.Command{
 			Usage: "set GC period, such as 1.5h or 2h45m",
 			Value: "1h",
 		},
+		&cli.DurationFlag{
+			Name:  "heartbeat-interval",
+			Usage: "set the interval of the health checks of the datanodes",
+			Value: config.DefaultHeartbeatInterval,
+		},
+		&cli.DurationFlag{
+			Name:  "heartbeat-timeout",
+			Usage: "set the deadline of a health check of a datanode, it must not exceed the interval",
+			Value: config.DefaultHeartbeatTimeout,
+		},
+		&cli.IntFlag{
+			Name:  "heartbeat-max-misses",
+			Usage: "set the consecutive failed health checks before a datanode is marked down",
+			Value: config.DefaultHeartbeatMaxMisses,
+		},
+		&cli.IntFlag{
+			Name:  "breaker-failure-threshold",
+			Usage: "set the consecutive failed rpcs before the circuit breaker of a datanode opens",
+			Value: config.DefaultBreakerFailureThreshold,
+		},
+		&cli.DurationFlag{
+			Name:  "breaker-open-timeout",
+			Usage: "set how long the circuit breaker of a datanode stays open before an rpc probes it",
+			Value: config.DefaultBreakerOpenTimeout,
+		},
+		&cli.IntFlag{
+			Name:  "retry-budget-percent",
+			Usage: "set the retries of the unavailable datanodes as a percentage of the rpcs, 0 disables the retries",
+			Value: config.DefaultRetryBudgetPercent,
+		},
+		&cli.IntFlag{
+			Name:  "repair-rate",
+			Usage: "set the repair writes per second of a dagnode",
+			Value: config.DefaultRepairRate,
+		},
+		&cli.IntFlag{
+			Name:  "repair-node-rate",
+			Usage: "set the repair writes per second to a datanode",
+			Value: config.DefaultRepairPerNodeRate,
+		},
+		&cli.StringFlag{
+			Name:  "slot-placement",
+			Usage: "set how the balance spreads the slots over the dagnodes, empty for evenly or free_space for in proportion to their free space",
+		},
+		&cli.StringFlag{
+			Name:  "metrics-listen",
+			Usage: "set the listen address of the prometheus metrics, the metrics are disabled if it's empty",
+		},
+		&cli.StringSliceFlag{
+			Name:  "dagnode-config",
+			Usage: "the dagnode config files applied at startup, they are reloaded on SIGHUP",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		cfg, err := loadPoolConfig(cctx)
 		if err != nil {
 			return err
 		}
-		startDagPoolServer(cctx.Context, cfg)
+		if metricsListen := cctx.String("metrics-listen"); metricsListen != "" {
+			startMetricsServer(metricsListen)
+		}
+		startDagPoolServer(cctx.Context, cfg, cctx.StringSlice("dagnode-config"))
 		return nil
 	},
 }
 
-func startDagPoolServer(ctx context.Context, cfg config.PoolConfig) {
+func startDagPoolServer(ctx context.Context, cfg config.PoolConfig, dagNodeConfigPaths []string) {
 	log.Infof("dagpool start...")
 	log.Infof("listen %s", cfg.Listen)
 	// listen port
@@ -83,6 +149,19 @@ func startDagPoolServer(ctx context.Context, cfg config.PoolConfig) {
 	}
 	defer service.Close()
 
+	reload := func() error {
+		nodeConfigs, err := loadDagNodeConfigs(dagNodeConfigPaths)
+		if err != nil {
+			return err
+		}
+		return service.ReloadDagNodes(nodeConfigs)
+	}
+	if len(dagNodeConfigPaths) != 0 {
+		if err = reload(); err != nil {
+			log.Fatalf("load dagnode configs err:%v", err)
+		}
+	}
+
 	proto.RegisterDagPoolServer(s, &server.DagPoolServer{DagPool: service})
 	proto.RegisterDagPoolClusterServer(s, &server.DagPoolClusterServer{Cluster: service})
 	go func() {
@@ -98,7 +177,24 @@ func startDagPoolServer(ctx context.Context, cfg config.PoolConfig) {
 	// kill -2 is syscall.SIGINT
 	// kill -9 is syscall.SIGKILL but can't be catch, so don't need add it
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+loop:
+	for {
+		select {
+		case <-hup:
+			if len(dagNodeConfigPaths) == 0 {
+				log.Warn("no dagnode config to reload")
+				continue
+			}
+			log.Info("Reload dagnode configs ...")
+			if err = reload(); err != nil {
+				log.Errorf("reload dagnode configs err:%v", err)
+			}
+		case <-quit:
+			break loop
+		}
+	}
 
 	log.Info("Shutdown Server ...")
 
@@ -107,6 +203,33 @@ func startDagPoolServer(ctx context.Context, cfg config.PoolConfig) {
 	log.Info("Server exit")
 }
 
+func startMetricsServer(listen string) {
+	log.Infof("start metrics at http://%v/metrics", listen)
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		if err := http.ListenAndServe(listen, metricsMux); err != nil {
+			log.Errorf("Listen And Serve metrics err%v", err)
+		}
+	}()
+}
+
+func loadDagNodeConfigs(paths []string) ([]config.DagNodeConfig, error) {
+	nodeConfigs := make([]config.DagNodeConfig, 0, len(paths))
+	for _, path := range paths {
+		var nc config.DagNodeConfig
+		cfgBytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(cfgBytes, &nc); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		nodeConfigs = append(nodeConfigs, nc)
+	}
+	return nodeConfigs, nil
+}
+
 func loadPoolConfig(cctx *cli.Context) (config.PoolConfig, error) {
 	var cfg config.PoolConfig
 	cfg.Listen = cctx.String("listen")
@@ -115,6 +238,7 @@ func loadPoolConfig(cctx *cli.Context) (config.PoolConfig, error) {
 		return config.PoolConfig{}, err
 	}
 	cfg.LeveldbPath = path.Join(datadir, "leveldb")
+	cfg.MetaBackend = cctx.String("meta-backend")
 	cfg.RootUser = cctx.String("root-user")
 	if cfg.RootUser == "" {
 		return config.PoolConfig{}, errors.New("root param is invalid")
@@ -126,5 +250,32 @@ func loadPoolConfig(cctx *cli.Context) (config.PoolConfig, error) {
 		return config.PoolConfig{}, err
 	}
 	cfg.GcPeriod = gcPer
+	cfg.Heartbeat = config.HeartbeatConfig{
+		Interval:  cctx.Duration("heartbeat-interval"),
+		Timeout:   cctx.Duration("heartbeat-timeout"),
+		MaxMisses: cctx.Int("heartbeat-max-misses"),
+	}
+	if err = cfg.Heartbeat.Validate(); err != nil {
+		return config.PoolConfig{}, err
+	}
+	cfg.Breaker = config.BreakerConfig{
+		FailureThreshold:   cctx.Int("breaker-failure-threshold"),
+		OpenTimeout:        cctx.Duration("breaker-open-timeout"),
+		RetryBudgetPercent: cctx.Int("retry-budget-percent"),
+	}
+	if err = cfg.Breaker.Validate(); err != nil {
+		return config.PoolConfig{}, err
+	}
+	cfg.Repair = config.RepairConfig{
+		Rate:        cctx.Int("repair-rate"),
+		PerNodeRate: cctx.Int("repair-node-rate"),
+	}
+	if err = cfg.Repair.Validate(); err != nil {
+		return config.PoolConfig{}, err
+	}
+	cfg.SlotPlacement = cctx.String("slot-placement")
+	if err = config.ValidateSlotPlacement(cfg.SlotPlacement); err != nil {
+		return config.PoolConfig{}, err
+	}
 	return cfg, nil
 }
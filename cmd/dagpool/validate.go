@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/filedag-project/filedag-storage/dag/config"
+	"github.com/filedag-project/filedag-storage/dag/node/datanode"
+	"github.com/urfave/cli/v2"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+const healthCheckService = "grpc.health.v1.Health"
+
+var validateConfigCmd = &cli.Command{
+	Name:      "validate-config",
+	Usage:     "Check the dagnode configs without starting the dag pool",
+	ArgsUsage: "dagnode_config_path [dagnode_config_path2] ... [dagnode_config_pathN]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "datadir",
+			Usage: "the data directory of the dag pool, checked to be writable if set",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "the format type of dagnode config file",
+			Value: "json",
+		},
+		&cli.BoolFlag{
+			Name:  "skip-reachability",
+			Usage: "do not check the datanodes are reachable",
+		},
+		&cli.StringFlag{
+			Name:  "timeout",
+			Usage: "set the timeout of the datanode health checks",
+			Value: "5s",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.String("format") != "json" {
+			return errors.New("not support this format")
+		}
+		if cctx.NArg() == 0 {
+			return errors.New("at least one dagnode configuration file path is required")
+		}
+		timeout, err := time.ParseDuration(cctx.String("timeout"))
+		if err != nil {
+			return err
+		}
+		problems := validateConfigs(cctx.Context, validateOptions{
+			paths:          cctx.Args().Slice(),
+			datadir:        cctx.String("datadir"),
+			checkReachable: !cctx.Bool("skip-reachability"),
+			timeout:        timeout,
+		})
+		for _, problem := range problems {
+			fmt.Printf("Error: %v\n", problem)
+		}
+		if len(problems) != 0 {
+			return cli.Exit(fmt.Sprintf("found %d problems in the configs", len(problems)), 1)
+		}
+		fmt.Printf("configs are valid: %d dagnodes\n", cctx.NArg())
+		return nil
+	},
+}
+
+type validateOptions struct {
+	paths          []string
+	datadir        string
+	checkReachable bool
+	timeout        time.Duration
+}
+
+// validateConfigs loads the dagnode configs and returns all the problems found in them
+func validateConfigs(ctx context.Context, opts validateOptions) []error {
+	var problems []error
+	if opts.datadir != "" {
+		if err := checkDirWritable(opts.datadir); err != nil {
+			problems = append(problems, fmt.Errorf("datadir %s is not writable: %v", opts.datadir, err))
+		}
+	}
+
+	names := make(map[string]string)
+	addrs := make(map[string]string)
+	var reachable []string
+	for _, path := range opts.paths {
+		var nc config.DagNodeConfig
+		cfgBytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			problems = append(problems, err)
+			continue
+		}
+		if err = json.Unmarshal(cfgBytes, &nc); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %v", path, err))
+			continue
+		}
+		if err = nc.Validate(); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %v", path, err))
+			continue
+		}
+		if other, ok := names[nc.Name]; ok {
+			problems = append(problems, fmt.Errorf("%s: the dagnode name %s is also used by %s", path, nc.Name, other))
+			continue
+		}
+		names[nc.Name] = path
+		for _, addr := range nc.Nodes {
+			if other, ok := addrs[addr]; ok {
+				problems = append(problems, fmt.Errorf("%s: the datanode %s is also used by %s", path, addr, other))
+				continue
+			}
+			addrs[addr] = path
+			reachable = append(reachable, addr)
+		}
+	}
+
+	if opts.checkReachable {
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, addr := range reachable {
+			wg.Add(1)
+			go func(addr string) {
+				defer wg.Done()
+				if err := checkDataNode(ctx, addr, opts.timeout); err != nil {
+					mu.Lock()
+					problems = append(problems, fmt.Errorf("%s: the datanode %s is unreachable: %v", addrs[addr], addr, err))
+					mu.Unlock()
+				}
+			}(addr)
+		}
+		wg.Wait()
+	}
+	return problems
+}
+
+// checkDirWritable makes sure a file can be created in the directory
+func checkDirWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, ".validate-")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkDataNode checks the health of the datanode
+func checkDataNode(ctx context.Context, addr string, timeout time.Duration) error {
+	cli, err := datanode.NewClient(addr)
+	if err != nil {
+		return err
+	}
+	defer cli.Conn.Close()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	check, err := cli.HeartClient.Check(ctx, &healthpb.HealthCheckRequest{Service: healthCheckService})
+	if err != nil {
+		return err
+	}
+	if check.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("the status is %v", check.Status)
+	}
+	return nil
+}
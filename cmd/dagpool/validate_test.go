@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/filedag-project/filedag-storage/dag/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startHealthServer(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen err:%v", err)
+	}
+	s := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus(healthCheckService, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, hs)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis.Addr().String()
+}
+
+func unusedAddress(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen err:%v", err)
+	}
+	defer lis.Close()
+	return lis.Addr().String()
+}
+
+func writeNodeConfig(t *testing.T, dir string, nc interface{}) string {
+	data, err := json.Marshal(nc)
+	if err != nil {
+		t.Fatalf("marshal err:%v", err)
+	}
+	f, err := ioutil.TempFile(dir, "node_config_*.json")
+	if err != nil {
+		t.Fatalf("create config err:%v", err)
+	}
+	defer f.Close()
+	if _, err = f.Write(data); err != nil {
+		t.Fatalf("write config err:%v", err)
+	}
+	return f.Name()
+}
+
+func TestValidateConfigs(t *testing.T) {
+	dir := t.TempDir()
+	addr1, addr2, addr3 := startHealthServer(t), startHealthServer(t), startHealthServer(t)
+	addr4, addr5 := startHealthServer(t), startHealthServer(t)
+	down := unusedAddress(t)
+	// a directory can't be created under a regular file
+	notDir := filepath.Join(dir, "not-dir")
+	if err := ioutil.WriteFile(notDir, nil, 0644); err != nil {
+		t.Fatalf("write file err:%v", err)
+	}
+
+	valid := writeNodeConfig(t, dir, config.DagNodeConfig{
+		Name:         "dag_node1",
+		Nodes:        []string{addr1, addr2, addr3},
+		DataBlocks:   2,
+		ParityBlocks: 1,
+	})
+	malformed := filepath.Join(dir, "malformed.json")
+	if err := ioutil.WriteFile(malformed, []byte(`{"name": "dag_node1",`), 0644); err != nil {
+		t.Fatalf("write config err:%v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		paths    []string
+		datadir  string
+		problems []string
+	}{
+		{
+			name:    "valid",
+			paths:   []string{valid},
+			datadir: filepath.Join(dir, "dp-data"),
+		},
+		{
+			name:     "missing file",
+			paths:    []string{filepath.Join(dir, "missing.json")},
+			problems: []string{"no such file"},
+		},
+		{
+			name:     "malformed json",
+			paths:    []string{malformed},
+			problems: []string{"unexpected end of JSON input"},
+		},
+		{
+			name: "mismatched erasure params",
+			paths: []string{writeNodeConfig(t, dir, config.DagNodeConfig{
+				Name:         "dag_node2",
+				Nodes:        []string{addr1, addr2, addr3},
+				DataBlocks:   3,
+				ParityBlocks: 1,
+			})},
+			problems: []string{"the number of nodes(3) must equal"},
+		},
+		{
+			name: "no parity",
+			paths: []string{writeNodeConfig(t, dir, config.DagNodeConfig{
+				Name:       "dag_node2",
+				Nodes:      []string{addr1},
+				DataBlocks: 1,
+			})},
+			problems: []string{"must be greater than zero"},
+		},
+		{
+			name: "unknown write concern",
+			paths: []string{writeNodeConfig(t, dir, config.DagNodeConfig{
+				Name:         "dag_node2",
+				Nodes:        []string{addr1, addr2, addr3},
+				DataBlocks:   2,
+				ParityBlocks: 1,
+				WriteConcern: "most",
+			})},
+			problems: []string{"unknown write concern"},
+		},
+		{
+			name: "duplicate dagnode name",
+			paths: []string{valid, writeNodeConfig(t, dir, config.DagNodeConfig{
+				Name:         "dag_node1",
+				Nodes:        []string{addr1, addr2, addr3},
+				DataBlocks:   2,
+				ParityBlocks: 1,
+			})},
+			problems: []string{"the dagnode name dag_node1 is also used by"},
+		},
+		{
+			name: "datanode used by two dagnodes",
+			paths: []string{valid, writeNodeConfig(t, dir, config.DagNodeConfig{
+				Name:         "dag_node2",
+				Nodes:        []string{addr4, addr5, addr3},
+				DataBlocks:   2,
+				ParityBlocks: 1,
+			})},
+			problems: []string{"the datanode " + addr3 + " is also used by"},
+		},
+		{
+			name: "unreachable datanode",
+			paths: []string{writeNodeConfig(t, dir, config.DagNodeConfig{
+				Name:         "dag_node2",
+				Nodes:        []string{addr1, addr2, down},
+				DataBlocks:   2,
+				ParityBlocks: 1,
+			})},
+			problems: []string{"the datanode " + down + " is unreachable"},
+		},
+		{
+			name:     "datadir not writable",
+			paths:    []string{valid},
+			datadir:  filepath.Join(notDir, "dp-data"),
+			problems: []string{"is not writable"},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			problems := validateConfigs(context.TODO(), validateOptions{
+				paths:          testCase.paths,
+				datadir:        testCase.datadir,
+				checkReachable: true,
+				timeout:        time.Second,
+			})
+			if len(problems) != len(testCase.problems) {
+				t.Fatalf("expected %d problems, but instead found %v", len(testCase.problems), problems)
+			}
+			for i, problem := range problems {
+				if !strings.Contains(problem.Error(), testCase.problems[i]) {
+					t.Fatalf("expected the problem %q, but instead found %q", testCase.problems[i], problem)
+				}
+			}
+		})
+	}
+}
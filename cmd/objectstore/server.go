@@ -5,9 +5,12 @@ import (
 	"errors"
 	"fmt"
 	dagpoolcli "github.com/filedag-project/filedag-storage/dag/pool/client"
+	"github.com/filedag-project/filedag-storage/objectservice/consts"
 	"github.com/filedag-project/filedag-storage/objectservice/iam"
 	"github.com/filedag-project/filedag-storage/objectservice/iam/auth"
 	"github.com/filedag-project/filedag-storage/objectservice/iamapi"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb/memdb"
 	"github.com/filedag-project/filedag-storage/objectservice/s3api"
 	"github.com/filedag-project/filedag-storage/objectservice/store"
 	"github.com/filedag-project/filedag-storage/objectservice/uleveldb"
@@ -15,12 +18,14 @@ import (
 	"github.com/gorilla/mux"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/ipfs/go-merkledag"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/urfave/cli/v2"
 	"net/http"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 )
 
 const (
@@ -55,24 +60,77 @@ func startServer(cctx *cli.Context) {
 			"Root user length should be at least 3, and password length at least 8 characters")
 	}
 
-	db, err := uleveldb.OpenDb(datadir)
-	if err != nil {
-		return
+	var db metadb.DB
+	switch backend := cctx.String("meta-backend"); backend {
+	case metadb.BackendLevelDB:
+		ldb, err := uleveldb.OpenDb(datadir)
+		if err != nil {
+			return
+		}
+		ldb.SetBreaker(cctx.Duration("db-timeout"), cctx.Int("db-max-failures"), cctx.Duration("db-cooldown"))
+		db = ldb
+	case metadb.BackendMemory:
+		db = memdb.New()
+	default:
+		log.Fatalf("unknown metadata backend %q", backend)
 	}
 	defer db.Close()
 	router := mux.NewRouter()
-	poolClient, err := dagpoolcli.NewPoolClient(poolAddr, poolUser, poolPassword, true)
+	poolClient, err := dagpoolcli.NewPoolClient(poolAddr, poolUser, poolPassword, true,
+		dagpoolcli.WithCompression(cctx.Bool("pool-compression")))
 	if err != nil {
 		log.Fatalf("connect dagpool server err: %v", err)
 	}
 	defer poolClient.Close(context.TODO())
-	dagServ := merkledag.NewDAGService(dagpoolcli.NewBlockService(poolClient))
+	// every s3 request reaches the pool as the pool user of the gateway, it must be allowed to read and write
+	if cctx.Bool("pool-access-check") {
+		if err = dagpoolcli.CheckAccess(cctx.Context, poolClient); errors.Is(err, dagpoolcli.ErrAccessDenied) {
+			log.Fatalf("the pool user %s of the gateway isn't allowed to read and write the pool, it needs the read-write policy: %v", poolUser, err)
+		} else if err != nil {
+			log.Warnf("check the access of the pool user %s failed: %v", poolUser, err)
+		}
+	}
+	dagServ := dagpoolcli.NewPrefetchDAGService(merkledag.NewDAGService(dagpoolcli.NewBlockService(poolClient)), cctx.Int("read-concurrency"))
 	storageSys := store.NewStorageSys(cctx.Context, dagServ, db)
 	authSys := iam.NewAuthSys(db, cred)
 	bmSys := store.NewBucketMetadataSys(db)
 	storageSys.SetNewBucketNSLock(bmSys.NewNSLock)
 	storageSys.SetHasBucket(bmSys.HasBucket)
+	storageSys.SetOverwriteInterval(bmSys.GetOverwriteInterval)
+	storageSys.SetCaseInsensitive(bmSys.IsCaseInsensitive)
+	storageSys.SetVersioning(bmSys.GetBucketVersioning)
+	storageSys.SetVerifyChecksum(cctx.Bool("verify-checksum"))
+	storageSys.SetObjectInfoCacheTTL(cctx.Duration("object-info-cache-ttl"))
+	storageSys.SetTrashRetention(cctx.Duration("trash-retention"))
+	storageSys.SetStaleUploadExpiry(cctx.Duration("stale-upload-expiry"))
+	if err = storageSys.SetMaxParts(cctx.Int("max-parts")); err != nil {
+		log.Fatalf("invalid max parts: %v", err)
+	}
+	authSys.SetSignatureDebug(cctx.Bool("signature-debug"))
+	if err = authSys.SetPayloadHashPolicy(iam.PayloadHashPolicy(cctx.String("payload-hash-policy"))); err != nil {
+		log.Fatalf("invalid payload hash policy: %v", err)
+	}
+	if err = authSys.SetAnonymousPrincipal(cctx.Context, cctx.String("anonymous-principal")); err != nil {
+		log.Fatalf("invalid anonymous principal: %v", err)
+	}
+	authSys.SetObjectACL(storageSys.GetObjectVersionACL)
 	bmSys.SetEmptyBucket(storageSys.EmptyBucket)
+	bmSys.SetRegion(cctx.String("region"))
+	if err = bmSys.SetDefaultBucketLimit(cctx.Int("max-buckets-per-user")); err != nil {
+		log.Fatalf("invalid max buckets per user: %v", err)
+	}
+	usageCtx, stopUsageFlush := context.WithCancel(cctx.Context)
+	usageFlushed := make(chan struct{})
+	go func() {
+		bmSys.RunUsageFlush(usageCtx, cctx.Duration("usage-flush-interval"))
+		close(usageFlushed)
+	}()
+	inventoryCtx, stopInventory := context.WithCancel(cctx.Context)
+	defer stopInventory()
+	go store.NewInventorySys(bmSys, storageSys).Run(inventoryCtx, cctx.Duration("inventory-check-interval"))
+	lifecycleCtx, stopLifecycle := context.WithCancel(cctx.Context)
+	defer stopLifecycle()
+	go store.NewLifecycleSys(bmSys, storageSys).Run(lifecycleCtx, cctx.Duration("lifecycle-check-interval"))
 
 	cleanData := func(accessKey string) {
 		ctx := context.Background()
@@ -89,10 +147,22 @@ func startServer(cctx *cli.Context) {
 				log.Errorf("DeleteBucket error: %v", err)
 			}
 		}
+		if err = bmSys.ResetUserBucketLimit(ctx, accessKey); err != nil {
+			log.Errorf("ResetUserBucketLimit error: %v", err)
+		}
+	}
+	handler := s3api.CorsHandler(router, bmSys)
+	// the admin routes go first, the object routes of the s3 api would match them
+	iamapi.NewIamApiServer(router, authSys, storageSys, bmSys, cleanData)
+	var s3Opts []s3api.S3ServerOption
+	if cctx.Bool("head-delete-marker-not-found") {
+		s3Opts = append(s3Opts, s3api.WithHeadDeleteMarkerNotFound())
+	}
+	s3api.NewS3Server(router, authSys, bmSys, storageSys, s3Opts...)
+	if maxRequests := cctx.Int("max-requests"); maxRequests > 0 {
+		limiter := s3api.NewBucketRequestLimiter(maxRequests, cctx.Duration("request-wait-timeout"), bmSys.GetBucketRequestWeight)
+		router.Use(limiter.Handler)
 	}
-	handler := s3api.CorsHandler(router)
-	s3api.NewS3Server(router, authSys, bmSys, storageSys)
-	iamapi.NewIamApiServer(router, authSys, cleanData)
 
 	if strings.HasPrefix(listen, ":") {
 		for _, ip := range utils.MustGetLocalIP4().ToSlice() {
@@ -101,11 +171,44 @@ func startServer(cctx *cli.Context) {
 	} else {
 		log.Infof("start sever at http://%v", listen)
 	}
+	server := s3api.NewHTTPServer(listen, handler, s3api.ServerConfig{
+		ReadHeaderTimeout: cctx.Duration("read-header-timeout"),
+		ReadTimeout:       cctx.Duration("read-timeout"),
+		WriteTimeout:      cctx.Duration("write-timeout"),
+		IdleTimeout:       cctx.Duration("idle-timeout"),
+	})
 	go func() {
-		if err = http.ListenAndServe(listen, handler); err != nil {
+		if err = server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Errorf("Listen And Serve err%v", err)
 		}
 	}()
+	var websiteServer *http.Server
+	if websiteListen := cctx.String("website-listen"); websiteListen != "" {
+		log.Infof("start website at http://%v", websiteListen)
+		websiteRouter := mux.NewRouter()
+		s3api.NewWebsiteServer(websiteRouter, authSys, bmSys, storageSys)
+		websiteServer = s3api.NewHTTPServer(websiteListen, s3api.CorsHandler(websiteRouter, bmSys), s3api.ServerConfig{
+			ReadHeaderTimeout: cctx.Duration("read-header-timeout"),
+			ReadTimeout:       cctx.Duration("read-timeout"),
+			WriteTimeout:      cctx.Duration("write-timeout"),
+			IdleTimeout:       cctx.Duration("idle-timeout"),
+		})
+		go func() {
+			if err := websiteServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Errorf("Listen And Serve website err%v", err)
+			}
+		}()
+	}
+	if metricsListen := cctx.String("metrics-listen"); metricsListen != "" {
+		log.Infof("start metrics at http://%v/metrics", metricsListen)
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", promhttp.Handler())
+		go func() {
+			if err := http.ListenAndServe(metricsListen, metricsMux); err != nil {
+				log.Errorf("Listen And Serve metrics err%v", err)
+			}
+		}()
+	}
 
 	// Wait for interrupt signal to gracefully shutdown the server.
 	quit := make(chan os.Signal, 1)
@@ -115,6 +218,16 @@ func startServer(cctx *cli.Context) {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Info("Shutdown Server ...")
+	if err = server.Close(); err != nil {
+		log.Errorf("Close server err:%v", err)
+	}
+	if websiteServer != nil {
+		if err = websiteServer.Close(); err != nil {
+			log.Errorf("Close website server err:%v", err)
+		}
+	}
+	stopUsageFlush()
+	<-usageFlushed
 	log.Info("Server exit")
 }
 
@@ -127,23 +240,160 @@ var startCmd = &cli.Command{
 			Usage: "set server listen",
 			Value: ":9985",
 		},
+		&cli.StringFlag{
+			Name:  "metrics-listen",
+			Usage: "set the listen address of the prometheus metrics, the metrics are disabled if it's empty",
+		},
+		&cli.StringFlag{
+			Name:  "website-listen",
+			Usage: "set the listen address of the bucket websites, e.g. http://host/bucket/, the websites are disabled if it's empty",
+		},
 		&cli.StringFlag{
 			Name:  "datadir",
 			Usage: "directory to store data in",
 			Value: "./store-data",
 		},
+		&cli.StringFlag{
+			Name:  "meta-backend",
+			Usage: "set the backend of the metadata, leveldb or memory, the memory loses the metadata on exit",
+			Value: metadb.BackendLevelDB,
+		},
+		&cli.DurationFlag{
+			Name:  "db-timeout",
+			Usage: "set the timeout of a metadata db operation, 0 means no timeout, a timed out write isn't cancelled and may still be applied",
+			Value: 5 * time.Second,
+		},
+		&cli.IntFlag{
+			Name:  "db-max-failures",
+			Usage: "set the number of consecutive metadata db failures to refuse requests",
+			Value: 5,
+		},
+		&cli.DurationFlag{
+			Name:  "db-cooldown",
+			Usage: "set how long to refuse requests after the metadata db failed",
+			Value: 10 * time.Second,
+		},
+		&cli.DurationFlag{
+			Name:  "read-header-timeout",
+			Usage: "set the timeout of reading the request headers, 0 means no timeout",
+			Value: s3api.DefaultServerConfig().ReadHeaderTimeout,
+		},
+		&cli.DurationFlag{
+			Name:  "read-timeout",
+			Usage: "set the timeout of reading a whole request, it limits the uploads, 0 means no timeout",
+			Value: s3api.DefaultServerConfig().ReadTimeout,
+		},
+		&cli.DurationFlag{
+			Name:  "write-timeout",
+			Usage: "set the timeout of writing a response, it limits the downloads, 0 means no timeout",
+			Value: s3api.DefaultServerConfig().WriteTimeout,
+		},
+		&cli.DurationFlag{
+			Name:  "idle-timeout",
+			Usage: "set the timeout of an idle keep-alive connection, 0 means no timeout",
+			Value: s3api.DefaultServerConfig().IdleTimeout,
+		},
+		&cli.IntFlag{
+			Name:  "read-concurrency",
+			Usage: "set the number of dag nodes of an object fetched in parallel when it's read, 1 fetches them one by one",
+			Value: dagpoolcli.DefaultPrefetchConcurrency,
+		},
+		&cli.IntFlag{
+			Name:  "max-requests",
+			Usage: "set the max number of the concurrent bucket requests, they are shared among the buckets by their weights, 0 means no limit",
+		},
+		&cli.DurationFlag{
+			Name:  "request-wait-timeout",
+			Usage: "set how long a bucket request waits for a slot before it's answered with SlowDown",
+			Value: s3api.DefaultRequestWaitTimeout,
+		},
+		&cli.DurationFlag{
+			Name:  "usage-flush-interval",
+			Usage: "set the interval the request counters of the buckets are persisted in",
+			Value: store.DefaultUsageFlushInterval,
+		},
+		&cli.DurationFlag{
+			Name:  "inventory-check-interval",
+			Usage: "set the interval the inventory reports of the buckets are checked in, a report is generated when it's due on its schedule",
+			Value: store.DefaultInventoryCheckInterval,
+		},
+		&cli.DurationFlag{
+			Name:  "lifecycle-check-interval",
+			Usage: "set the interval the objects expired by the lifecycle rules of the buckets are looked for and deleted in",
+			Value: store.DefaultLifecycleCheckInterval,
+		},
+		&cli.BoolFlag{
+			Name:  "verify-checksum",
+			Usage: "verify the sha256 checksum of an object after it's read to the end",
+		},
+		&cli.DurationFlag{
+			Name:  "object-info-cache-ttl",
+			Usage: "set how long the object metadata read from the db is cached, 0 disables the cache",
+			Value: 2 * time.Second,
+		},
+		&cli.IntFlag{
+			Name:  "max-parts",
+			Usage: "set the max part number and number of parts of a multipart upload, at most 10000",
+			Value: consts.MaxPartID,
+		},
+		&cli.DurationFlag{
+			Name:  "trash-retention",
+			Usage: "keep the deleted objects in the trash for the retention, they can be restored by /admin/v1/undelete-object before it passes, 0 deletes them right away",
+		},
+		&cli.DurationFlag{
+			Name:  "stale-upload-expiry",
+			Usage: "abort the multipart uploads which are not completed within the expiry and remove their parts, 0 keeps them until they are aborted",
+		},
+		&cli.IntFlag{
+			Name:  "max-buckets-per-user",
+			Usage: "set the number of buckets a user can create unless /admin/v1/bucket-limit sets the limit of the user, -1 is unlimited",
+			Value: store.DefaultMaxBuckets,
+		},
+		&cli.StringFlag{
+			Name:  "region",
+			Usage: "set the region of the server returned as the location of the buckets, the buckets can only be created in it, an empty region accepts any location",
+		},
+		&cli.StringFlag{
+			Name: "payload-hash-policy",
+			Usage: "set how the payloads of the signed requests are verified against their x-amz-content-sha256: " +
+				"always (default) rejects UNSIGNED-PAYLOAD unless the request is presigned, never-for-unsigned accepts UNSIGNED-PAYLOAD without hashing it, " +
+				"when-present also accepts a missing hash. A payload which isn't hashed is only protected by TLS or a trusted network",
+			Value: string(iam.PayloadHashAlways),
+		},
+		&cli.StringFlag{
+			Name:  "anonymous-principal",
+			Usage: "set the principal the anonymous requests are evaluated by the bucket policies and logged as, no user can be created with the name",
+			Value: auth.DefaultAnonymousPrincipal,
+		},
+		&cli.BoolFlag{
+			Name:  "head-delete-marker-not-found",
+			Usage: "answer the HEAD of a delete marker by its version ID with 404 instead of the 405 of S3, for the clients which take 405 for a failure",
+		},
+		&cli.BoolFlag{
+			Name:  "signature-debug",
+			Usage: "return the canonical request and string to sign of a mismatched signature to anyone by /admin/v1/verify-signature, for the development only",
+		},
 		&cli.StringFlag{
 			Name:  "pool-addr",
 			Usage: "set the pool rpc address you want connect",
 		},
 		&cli.StringFlag{
 			Name:  "pool-user",
-			Usage: "set pool user",
+			Usage: "set the pool user the gateway serves all the s3 requests as, it needs the read-write policy",
 		},
 		&cli.StringFlag{
 			Name:  "pool-password",
 			Usage: "set pool password",
 		},
+		&cli.BoolFlag{
+			Name:  "pool-access-check",
+			Usage: "check at startup that the pool user can read and write the pool, the gateway exits if it's denied",
+			Value: true,
+		},
+		&cli.BoolFlag{
+			Name:  "pool-compression",
+			Usage: "compress the blocks transferred with the pool in gzip, it saves the bandwidth of the compressible content at the cost of cpu",
+		},
 		&cli.StringFlag{
 			Name:    "root-user",
 			Usage:   "set root filedag root user",
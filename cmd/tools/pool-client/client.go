@@ -139,6 +139,70 @@ var getBlock = &cli.Command{
 		return nil
 	},
 }
+var hasBlock = &cli.Command{
+	Name:  "hasblock",
+	Usage: "check whether dagpool has a block, the block is not read eg.dagpool-client hasblock --addr=127.0.0.1:50001 --client-user=dagpool --client-pass=dagpool --cid=QmZikYuqANVBRWcbb1zHAHEXzX6CsWbPz2mqRCoy92Jcge ",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "addr",
+			Usage: "the addr of dagpool server eg.127.0.0.1:50001",
+		},
+		&cli.StringFlag{
+			Name:  "client-user",
+			Usage: "the client user ",
+		},
+		&cli.StringFlag{
+			Name:  "client-pass",
+			Usage: "the client pass ",
+		},
+		&cli.StringFlag{
+			Name:  "cid",
+			Usage: "the block cid that you want check",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		var addr, clientuser, clientpass, cid string
+		if cctx.String("addr") != "" {
+			addr = cctx.String("addr")
+		} else {
+			log.Errorf("you must give the addr")
+			return xerrors.Errorf("you must give the addr")
+		}
+		if cctx.String("client-user") != "" {
+			clientuser = cctx.String("client-user")
+		} else {
+			log.Errorf("you must give the client user")
+			return xerrors.Errorf("you must give the client user")
+		}
+		if cctx.String("client-pass") != "" {
+			clientpass = cctx.String("client-pass")
+		} else {
+			log.Errorf("you must give the client pass")
+			return xerrors.Errorf("you must give the client pass")
+		}
+		if cctx.String("cid") != "" {
+			cid = cctx.String("cid")
+		} else {
+			log.Errorf("you must give the cid")
+			return xerrors.Errorf("you must give the cid")
+		}
+		poolClient, err := client.NewPoolClient(addr, clientuser, clientpass, true)
+		if err != nil {
+			log.Errorf("NewPoolClient err:%v", err)
+			return err
+		}
+		has, err := poolClient.DPClient.Has(cctx.Context, &proto.HasReq{
+			Cid:  cid,
+			User: poolClient.User,
+		})
+		if err != nil {
+			log.Errorf("has block err:%v", err)
+			return err
+		}
+		log.Infof("has block %v: %v", cid, has.Has)
+		return nil
+	},
+}
 var removeBlock = &cli.Command{
 	Name:  "removeblock",
 	Usage: "remove a block from dagpool eg.dagpool-client removeblock --addr=127.0.0.1:50001 --client-user=dagpool --client-pass=dagpool --cid=QmZikYuqANVBRWcbb1zHAHEXzX6CsWbPz2mqRCoy92Jcge",
@@ -203,3 +267,126 @@ var removeBlock = &cli.Command{
 		return nil
 	},
 }
+var pinBlocks = &cli.Command{
+	Name:  "pinblocks",
+	Usage: "pin a batch of blocks of dagpool eg.dagpool-client pinblocks --addr=127.0.0.1:50001 --client-user=dagpool --client-pass=dagpool --cids=QmZikYuqANVBRWcbb1zHAHEXzX6CsWbPz2mqRCoy92Jcge,QmTp2hEo8eXRp6wg7jXv1BLCMh5a4F3B7buAUZNZUu772j",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "addr",
+			Usage: "the addr of dagpool server eg.127.0.0.1:50001",
+		},
+		&cli.StringFlag{
+			Name:  "client-user",
+			Usage: "the client user ",
+		},
+		&cli.StringFlag{
+			Name:  "client-pass",
+			Usage: "the client pass ",
+		},
+		&cli.StringSliceFlag{
+			Name:  "cids",
+			Usage: "the block cids that you want pin",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		addr, clientuser, clientpass, cids, err := getBatchPinArgs(cctx)
+		if err != nil {
+			return err
+		}
+		poolClient, err := client.NewPoolClient(addr, clientuser, clientpass, true)
+		if err != nil {
+			log.Errorf("NewPoolClient err:%v", err)
+			return err
+		}
+		reply, err := poolClient.DPClient.Pin(cctx.Context, &proto.PinReq{
+			Cids: cids,
+			User: poolClient.User,
+		})
+		if err != nil {
+			log.Errorf("pin blocks err:%v", err)
+			return err
+		}
+		printPinResults("pin", reply.Results)
+		return nil
+	},
+}
+var unpinBlocks = &cli.Command{
+	Name:  "unpinblocks",
+	Usage: "unpin a batch of blocks of dagpool eg.dagpool-client unpinblocks --addr=127.0.0.1:50001 --client-user=dagpool --client-pass=dagpool --cids=QmZikYuqANVBRWcbb1zHAHEXzX6CsWbPz2mqRCoy92Jcge,QmTp2hEo8eXRp6wg7jXv1BLCMh5a4F3B7buAUZNZUu772j",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "addr",
+			Usage: "the addr of dagpool server eg.127.0.0.1:50001",
+		},
+		&cli.StringFlag{
+			Name:  "client-user",
+			Usage: "the client user ",
+		},
+		&cli.StringFlag{
+			Name:  "client-pass",
+			Usage: "the client pass ",
+		},
+		&cli.StringSliceFlag{
+			Name:  "cids",
+			Usage: "the block cids that you want unpin",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		addr, clientuser, clientpass, cids, err := getBatchPinArgs(cctx)
+		if err != nil {
+			return err
+		}
+		poolClient, err := client.NewPoolClient(addr, clientuser, clientpass, true)
+		if err != nil {
+			log.Errorf("NewPoolClient err:%v", err)
+			return err
+		}
+		reply, err := poolClient.DPClient.Unpin(cctx.Context, &proto.UnpinReq{
+			Cids: cids,
+			User: poolClient.User,
+		})
+		if err != nil {
+			log.Errorf("unpin blocks err:%v", err)
+			return err
+		}
+		printPinResults("unpin", reply.Results)
+		return nil
+	},
+}
+
+func getBatchPinArgs(cctx *cli.Context) (addr, clientuser, clientpass string, cids []string, err error) {
+	if cctx.String("addr") != "" {
+		addr = cctx.String("addr")
+	} else {
+		log.Errorf("you must give the addr")
+		return "", "", "", nil, xerrors.Errorf("you must give the addr")
+	}
+	if cctx.String("client-user") != "" {
+		clientuser = cctx.String("client-user")
+	} else {
+		log.Errorf("you must give the client user")
+		return "", "", "", nil, xerrors.Errorf("you must give the client user")
+	}
+	if cctx.String("client-pass") != "" {
+		clientpass = cctx.String("client-pass")
+	} else {
+		log.Errorf("you must give the client pass")
+		return "", "", "", nil, xerrors.Errorf("you must give the client pass")
+	}
+	cids = cctx.StringSlice("cids")
+	if len(cids) == 0 {
+		log.Errorf("you must give the cids")
+		return "", "", "", nil, xerrors.Errorf("you must give the cids")
+	}
+	return addr, clientuser, clientpass, cids, nil
+}
+
+func printPinResults(op string, results []*proto.PinResult) {
+	for _, result := range results {
+		if result.Error != "" {
+			log.Errorf("%s block %v err:%v", op, result.Cid, result.Error)
+			continue
+		}
+		log.Infof("%s block success cid:%v", op, result.Cid)
+	}
+}
@@ -11,7 +11,10 @@ func main() {
 	local := []*cli.Command{
 		addBlock,
 		getBlock,
+		hasBlock,
 		removeBlock,
+		pinBlocks,
+		unpinBlocks,
 	}
 	app := &cli.App{
 		Name:                 "dagpool-client",
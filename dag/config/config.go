@@ -1,17 +1,170 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"github.com/filedag-project/filedag-storage/dag/slotsmgr"
+	"net"
 	"time"
 )
 
+// MaxShards is the maximum number of data and parity shards of a dag node
+const MaxShards = 256
+
 //PoolConfig is the configuration for the dag pool
 type PoolConfig struct {
 	Listen       string        `json:"listen"`
 	LeveldbPath  string        `json:"leveldb_path"`
+	MetaBackend  string        `json:"meta_backend"` // leveldb by default
 	RootUser     string        `json:"root_user"`
 	RootPassword string        `json:"root_password"`
 	GcPeriod     time.Duration `json:"gc_period"`
+	// Heartbeat the health checks of the datanodes of all the dag nodes
+	Heartbeat HeartbeatConfig `json:"heartbeat"`
+	// Breaker the retries and the circuit breakers of the rpcs to the datanodes
+	Breaker BreakerConfig `json:"breaker"`
+	// Repair the rate of the repair writes to the datanodes
+	Repair RepairConfig `json:"repair"`
+	// SlotPlacement how the balance spreads the hash slots over the dag nodes
+	SlotPlacement string `json:"slot_placement,omitempty"`
+}
+
+// The slot placements of the dag pool, how many hash slots each dag node gets from the balance.
+// The blocks are written to the dag nodes owning the slots of their keys, and read from them the same way.
+const (
+	// SlotPlacementEven gives each dag node the same number of slots
+	SlotPlacementEven = ""
+	// SlotPlacementFreeSpace gives each dag node a number of slots in proportion to its free space,
+	// so that the emptier dag nodes take more of the writes
+	SlotPlacementFreeSpace = "free_space"
+)
+
+//ValidateSlotPlacement checks the slot placement of the dag pool
+func ValidateSlotPlacement(placement string) error {
+	switch placement {
+	case SlotPlacementEven, SlotPlacementFreeSpace:
+		return nil
+	default:
+		return fmt.Errorf("unknown slot placement %q of dag pool", placement)
+	}
+}
+
+// The default health checks of the datanodes, they suit a datacenter network
+const (
+	DefaultHeartbeatInterval  = 30 * time.Second
+	DefaultHeartbeatTimeout   = 15 * time.Second
+	DefaultHeartbeatMaxMisses = 2
+)
+
+//HeartbeatConfig is the configuration for the health checks of the datanodes.
+//A datanode is marked down after MaxMisses consecutive failed checks, so it takes
+//about Interval*(MaxMisses-1)+Timeout to detect a datanode that stops responding.
+//Some sensible values:
+//  - LAN with fast failover: interval 5s, timeout 2s, max misses 3
+//  - datacenter (default): interval 30s, timeout 15s, max misses 2
+//  - high latency links or WAN: interval 30s, timeout 25s, max misses 4
+type HeartbeatConfig struct {
+	Interval  time.Duration `json:"interval"`   // the interval of the health checks
+	Timeout   time.Duration `json:"timeout"`    // the deadline of a health check
+	MaxMisses int           `json:"max_misses"` // the consecutive failed checks before a datanode is down
+}
+
+//DefaultHeartbeatConfig returns the default health checks of the datanodes
+func DefaultHeartbeatConfig() HeartbeatConfig {
+	return HeartbeatConfig{
+		Interval:  DefaultHeartbeatInterval,
+		Timeout:   DefaultHeartbeatTimeout,
+		MaxMisses: DefaultHeartbeatMaxMisses,
+	}
+}
+
+//Validate checks the interval, the timeout and the max misses of the heartbeat config
+func (cfg *HeartbeatConfig) Validate() error {
+	if cfg.Interval <= 0 || cfg.Timeout <= 0 {
+		return fmt.Errorf("the heartbeat interval(%v) and timeout(%v) must be greater than zero", cfg.Interval, cfg.Timeout)
+	}
+	if cfg.Timeout > cfg.Interval {
+		return fmt.Errorf("the heartbeat timeout(%v) must not exceed the interval(%v)", cfg.Timeout, cfg.Interval)
+	}
+	if cfg.MaxMisses <= 0 {
+		return fmt.Errorf("the heartbeat max misses(%d) must be greater than zero", cfg.MaxMisses)
+	}
+	return nil
+}
+
+// The default retries and circuit breakers of the rpcs to the datanodes
+const (
+	DefaultBreakerFailureThreshold = 5
+	DefaultBreakerOpenTimeout      = 10 * time.Second
+	DefaultRetryBudgetPercent      = 10
+)
+
+//BreakerConfig is the configuration for the retries and the circuit breakers of the rpcs to the datanodes.
+//An unavailable datanode is retried as long as the retries stay within RetryBudgetPercent percent
+//of the rpcs, so that a flaky datanode doesn't multiply the load. The breaker of a datanode opens
+//after FailureThreshold consecutive failed rpcs, its rpcs fail at once and the reads are reconstructed
+//from the other shards. After OpenTimeout a single rpc probes the datanode, the breaker is closed
+//if it succeeds, or open again for another OpenTimeout.
+type BreakerConfig struct {
+	FailureThreshold   int           `json:"failure_threshold"`    // the consecutive failed rpcs before the breaker opens
+	OpenTimeout        time.Duration `json:"open_timeout"`         // how long the breaker stays open before a probe
+	RetryBudgetPercent int           `json:"retry_budget_percent"` // the retries as a percentage of the rpcs, 0 disables the retries
+}
+
+//DefaultBreakerConfig returns the default retries and circuit breakers of the rpcs to the datanodes
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold:   DefaultBreakerFailureThreshold,
+		OpenTimeout:        DefaultBreakerOpenTimeout,
+		RetryBudgetPercent: DefaultRetryBudgetPercent,
+	}
+}
+
+//Validate checks the failure threshold, the open timeout and the retry budget of the breaker config
+func (cfg *BreakerConfig) Validate() error {
+	if cfg.FailureThreshold <= 0 {
+		return fmt.Errorf("the breaker failure threshold(%d) must be greater than zero", cfg.FailureThreshold)
+	}
+	if cfg.OpenTimeout <= 0 {
+		return fmt.Errorf("the breaker open timeout(%v) must be greater than zero", cfg.OpenTimeout)
+	}
+	if cfg.RetryBudgetPercent < 0 || cfg.RetryBudgetPercent > 100 {
+		return fmt.Errorf("the retry budget percent(%d) must be between 0 and 100", cfg.RetryBudgetPercent)
+	}
+	return nil
+}
+
+// The default rate of the repair writes, a datanode coming back after a long outage is repaired
+// at 20 shards per second at most, and the repairs of a dag node write 100 shards per second at most
+const (
+	DefaultRepairRate        = 100
+	DefaultRepairPerNodeRate = 20
+)
+
+//RepairConfig is the configuration for the rate of the repair writes to the datanodes.
+//The shards which a read finds missing or a put leaves pending are repaired in the background,
+//after a datanode recovers many reads can repair it at once. A repair write waits until both the
+//rate of the datanode and the rate of the dag node allow it, so that the repairs don't saturate
+//the recovering datanode or compete with the live traffic.
+type RepairConfig struct {
+	Rate        int `json:"rate"`          // the repair writes per second of the dag node
+	PerNodeRate int `json:"per_node_rate"` // the repair writes per second to one datanode
+}
+
+//DefaultRepairConfig returns the default rate of the repair writes
+func DefaultRepairConfig() RepairConfig {
+	return RepairConfig{
+		Rate:        DefaultRepairRate,
+		PerNodeRate: DefaultRepairPerNodeRate,
+	}
+}
+
+//Validate checks the rates of the repair config
+func (cfg *RepairConfig) Validate() error {
+	if cfg.Rate <= 0 || cfg.PerNodeRate <= 0 {
+		return fmt.Errorf("the repair rate(%d) and per node rate(%d) must be greater than zero", cfg.Rate, cfg.PerNodeRate)
+	}
+	return nil
 }
 
 //ClusterConfig is the configuration for a cluster
@@ -20,15 +173,109 @@ type ClusterConfig struct {
 	Cluster []DagNodeInfo `json:"cluster"`
 }
 
+// The write concerns of a dag node, the number of shards written before a put succeeds
+const (
+	// WriteConcernDefault data shards, plus one if there are as many parity shards
+	WriteConcernDefault = ""
+	// WriteConcernAll all the data and parity shards
+	WriteConcernAll = "all"
+	// WriteConcernData the data shards
+	WriteConcernData = "data"
+	// WriteConcernDataPlusOne the data shards plus one
+	WriteConcernDataPlusOne = "data+1"
+)
+
 //DagNodeConfig is the configuration for a dag node
 type DagNodeConfig struct {
 	Name         string   `json:"name"`
 	Nodes        []string `json:"nodes"`         // rpc address list of datanodes
 	DataBlocks   int      `json:"data_blocks"`   // Number of data shards
 	ParityBlocks int      `json:"parity_blocks"` // Number of parity shards
+	// WriteConcern the shards written before a put succeeds, the other shards are completed in the background
+	WriteConcern string `json:"write_concern,omitempty"`
+	// ReadPreference the datanodes the shards of a block are read from
+	ReadPreference ReadPreferenceConfig `json:"read_preference"`
+}
+
+// The read preferences of a dag node, which datanodes the shards of a block are read from
+const (
+	// ReadPreferenceAll reads the shards from all the datanodes at once, the first data shards read are decoded
+	ReadPreferenceAll = ""
+	// ReadPreferenceNearest reads as many shards as the data blocks from the local datanodes and the datanodes
+	// with the lowest recent latency first, another datanode is read when a read fails or hasn't finished within
+	// the hedge delay
+	ReadPreferenceNearest = "nearest"
+)
+
+//ReadPreferenceConfig is the configuration for which datanodes the shards of a block are read from
+type ReadPreferenceConfig struct {
+	Mode       string        `json:"mode,omitempty"`
+	LocalNodes []string      `json:"local_nodes,omitempty"` // the rpc addresses of the datanodes in the local zone, they're read first
+	HedgeDelay time.Duration `json:"hedge_delay,omitempty"` // read another datanode when the reads take longer, 0 waits for a failure
+}
+
+//Validate checks the mode, the local nodes and the hedge delay of the read preference of the datanodes
+func (cfg *ReadPreferenceConfig) Validate(nodes []string) error {
+	switch cfg.Mode {
+	case ReadPreferenceAll, ReadPreferenceNearest:
+	default:
+		return fmt.Errorf("unknown read preference %q of dag node", cfg.Mode)
+	}
+	for _, local := range cfg.LocalNodes {
+		found := false
+		for _, addr := range nodes {
+			if addr == local {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("the local datanode %q of the read preference is not a datanode of the dag node", local)
+		}
+	}
+	if cfg.HedgeDelay < 0 {
+		return fmt.Errorf("the read hedge delay(%v) must not be negative", cfg.HedgeDelay)
+	}
+	return nil
+}
+
+//Validate checks the name, the erasure parameters, the datanode addresses
+//and the write concern of the dag node config
+func (cfg *DagNodeConfig) Validate() error {
+	if cfg.Name == "" {
+		return errors.New("the dag node name is required")
+	}
+	if cfg.DataBlocks <= 0 || cfg.ParityBlocks <= 0 {
+		return fmt.Errorf("data_blocks(%d) and parity_blocks(%d) must be greater than zero", cfg.DataBlocks, cfg.ParityBlocks)
+	}
+	if cfg.DataBlocks+cfg.ParityBlocks > MaxShards {
+		return fmt.Errorf("data_blocks(%d) plus parity_blocks(%d) must not exceed %d", cfg.DataBlocks, cfg.ParityBlocks, MaxShards)
+	}
+	if len(cfg.Nodes) != cfg.DataBlocks+cfg.ParityBlocks {
+		return fmt.Errorf("the number of nodes(%d) must equal data_blocks(%d) plus parity_blocks(%d)",
+			len(cfg.Nodes), cfg.DataBlocks, cfg.ParityBlocks)
+	}
+	seen := make(map[string]struct{}, len(cfg.Nodes))
+	for _, addr := range cfg.Nodes {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return fmt.Errorf("invalid datanode address %q: %v", addr, err)
+		}
+		if _, ok := seen[addr]; ok {
+			return fmt.Errorf("the datanode address %q is duplicated", addr)
+		}
+		seen[addr] = struct{}{}
+	}
+	switch cfg.WriteConcern {
+	case WriteConcernDefault, WriteConcernAll, WriteConcernData, WriteConcernDataPlusOne:
+	default:
+		return fmt.Errorf("unknown write concern %q of dag node", cfg.WriteConcern)
+	}
+	return cfg.ReadPreference.Validate(cfg.Nodes)
 }
 
 type DagNodeInfo struct {
 	Config    DagNodeConfig       `json:"config"`
 	SlotPairs []slotsmgr.SlotPair `json:"slot_pairs"`
+	// Draining the dag node gets no slot, its slots are migrated to the other dag nodes
+	Draining bool `json:"draining,omitempty"`
 }
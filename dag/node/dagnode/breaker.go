@@ -0,0 +1,265 @@
+package dagnode
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/dag/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync"
+	"time"
+)
+
+// The states of a circuit breaker, they are the values of the breaker state metric
+const (
+	breakerClosed = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+const (
+	// maxRetriesPerCall the retries of a single rpc, the budget bounds the retries of all the rpcs
+	maxRetriesPerCall = 2
+	// maxRetryTokens the retries saved up by a quiet period, so that a burst can't use them all at once
+	maxRetryTokens = 10
+)
+
+var (
+	breakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "filedag",
+		Subsystem: "dagnode",
+		Name:      "datanode_breaker_state",
+		Help:      "The circuit breaker state of the datanode, 0 closed, 1 open, 2 half-open",
+	}, []string{"dagnode", "datanode"})
+	breakerTrips = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "filedag",
+		Subsystem: "dagnode",
+		Name:      "datanode_breaker_trips_total",
+		Help:      "The number of times the circuit breaker of the datanode opened",
+	}, []string{"dagnode", "datanode"})
+	rpcRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "filedag",
+		Subsystem: "dagnode",
+		Name:      "datanode_rpc_retries_total",
+		Help:      "The number of the retried rpcs to the datanodes of the dag node",
+	}, []string{"dagnode"})
+)
+
+func init() {
+	prometheus.MustRegister(breakerState, breakerTrips, rpcRetries)
+}
+
+// circuitBreaker short-circuits the rpcs to a datanode which keeps failing,
+// a nil breaker lets every rpc through
+type circuitBreaker struct {
+	mu        sync.Mutex
+	state     int
+	failures  int
+	openedAt  time.Time
+	threshold int
+	timeout   time.Duration
+	now       func() time.Time
+	// gauge and trips the metrics of the datanode
+	gauge prometheus.Gauge
+	trips prometheus.Counter
+}
+
+func newCircuitBreaker(cfg config.BreakerConfig, dagNode, dataNode string) *circuitBreaker {
+	cb := &circuitBreaker{
+		threshold: cfg.FailureThreshold,
+		timeout:   cfg.OpenTimeout,
+		now:       time.Now,
+		gauge:     breakerState.WithLabelValues(dagNode, dataNode),
+		trips:     breakerTrips.WithLabelValues(dagNode, dataNode),
+	}
+	cb.gauge.Set(breakerClosed)
+	return cb
+}
+
+// allow reports whether an rpc may be sent, once the breaker has been open for the timeout
+// a single rpc is let through to probe the datanode
+func (cb *circuitBreaker) allow() bool {
+	if cb == nil {
+		return true
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	switch cb.state {
+	case breakerOpen:
+		if cb.now().Sub(cb.openedAt) < cb.timeout {
+			return false
+		}
+		cb.setState(breakerHalfOpen)
+		return true
+	case breakerHalfOpen:
+		// the probe is in flight
+		return false
+	}
+	return true
+}
+
+// record counts the result of an rpc which was allowed
+func (cb *circuitBreaker) record(failed bool) {
+	if cb == nil {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if !failed {
+		cb.failures = 0
+		cb.setState(breakerClosed)
+		return
+	}
+	cb.failures++
+	if cb.state == breakerHalfOpen || cb.failures >= cb.threshold {
+		if cb.state != breakerOpen {
+			cb.trips.Inc()
+		}
+		cb.openedAt = cb.now()
+		cb.setState(breakerOpen)
+	}
+}
+
+// release gives back a probe whose result is unknown, e.g. the caller gave up,
+// the next rpc probes the datanode again
+func (cb *circuitBreaker) release() {
+	if cb == nil {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state == breakerHalfOpen {
+		cb.setState(breakerOpen)
+	}
+}
+
+// currentState returns the state of the breaker, 0 closed, 1 open, 2 half-open
+func (cb *circuitBreaker) currentState() int {
+	if cb == nil {
+		return breakerClosed
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+func (cb *circuitBreaker) setState(state int) {
+	if cb.state == state {
+		return
+	}
+	cb.state = state
+	cb.gauge.Set(float64(state))
+}
+
+// retryBudget allows the retries as long as they are within a percentage of the rpcs,
+// every rpc earns percent/100 of a retry, a nil budget allows no retries
+type retryBudget struct {
+	mu     sync.Mutex
+	ratio  float64
+	tokens float64
+}
+
+func newRetryBudget(percent int) *retryBudget {
+	return &retryBudget{ratio: float64(percent) / 100}
+}
+
+// deposit is called for every rpc
+func (rb *retryBudget) deposit() {
+	if rb == nil {
+		return
+	}
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.tokens += rb.ratio
+	if rb.tokens > maxRetryTokens {
+		rb.tokens = maxRetryTokens
+	}
+}
+
+// withdraw reports whether a retry is within the budget
+func (rb *retryBudget) withdraw() bool {
+	if rb == nil {
+		return false
+	}
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	if rb.tokens < 1 {
+		return false
+	}
+	rb.tokens--
+	return true
+}
+
+// isNodeFailure reports whether the error of an rpc means the datanode failed,
+// rather than the request, e.g. a missing key
+func isNodeFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal:
+		return true
+	}
+	return false
+}
+
+// isRetryable reports whether the rpc can be sent again, an unavailable datanode hasn't run it
+func isRetryable(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && status.Code(err) == codes.Unavailable
+}
+
+// call sends an rpc to the datanode through its circuit breaker, the unavailable datanode
+// is retried within the retry budget. errBreakerOpen is returned if the breaker is open.
+func (d *DagNode) call(ctx context.Context, sn *StorageNode, rpc func(ctx context.Context) error) error {
+	if !sn.breaker.allow() {
+		return errBreakerOpen
+	}
+	d.retryBudget.deposit()
+	err := rpc(ctx)
+	for i := 0; i < maxRetriesPerCall && err != nil && isRetryable(ctx, err) && d.retryBudget.withdraw(); i++ {
+		rpcRetries.WithLabelValues(d.config.Name).Inc()
+		err = rpc(ctx)
+	}
+	if err != nil && ctx.Err() != nil {
+		// the caller gave up, it tells nothing about the datanode
+		sn.breaker.release()
+		return err
+	}
+	sn.breaker.record(isNodeFailure(err))
+	return err
+}
+
+// GetDataNodeBreakerState returns the circuit breaker state of the datanode, 0 closed, 1 open, 2 half-open
+func (d *DagNode) GetDataNodeBreakerState(setIndex int) int {
+	if setIndex < 0 || setIndex >= len(d.Nodes) {
+		log.Fatalf("input setIndex %v is illegal, size of set is %v", setIndex, len(d.Nodes))
+	}
+	return d.Nodes[setIndex].breaker.currentState()
+}
+
+// GetBreaker returns the config of the retries and the circuit breakers of the datanodes
+func (d *DagNode) GetBreaker() config.BreakerConfig {
+	return d.breakerConfig
+}
+
+// SetBreaker changes the retries and the circuit breakers of the datanodes, it must be called before the DagNode is used
+func (d *DagNode) SetBreaker(cfg config.BreakerConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	d.breakerConfig = cfg
+	d.retryBudget = newRetryBudget(cfg.RetryBudgetPercent)
+	for _, sn := range d.Nodes {
+		sn.breaker = newCircuitBreaker(cfg, d.config.Name, sn.RpcAddress)
+	}
+	return nil
+}
+
+// deleteBreakerMetrics removes the metrics of the closed DagNode
+func (d *DagNode) deleteBreakerMetrics() {
+	for _, sn := range d.Nodes {
+		breakerState.DeleteLabelValues(d.config.Name, sn.RpcAddress)
+		breakerTrips.DeleteLabelValues(d.config.Name, sn.RpcAddress)
+	}
+	rpcRetries.DeleteLabelValues(d.config.Name)
+}
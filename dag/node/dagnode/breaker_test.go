@@ -0,0 +1,159 @@
+package dagnode
+
+import (
+	"bytes"
+	"context"
+	"github.com/filedag-project/filedag-storage/dag/config"
+	"github.com/filedag-project/filedag-storage/dag/node/datanode"
+	"github.com/filedag-project/filedag-storage/dag/proto"
+	blocks "github.com/ipfs/go-block-format"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyDatanode fails the rpcs with Unavailable while failing is set, or the number of rpcs set in failures
+type flakyDatanode struct {
+	proto.DataNodeClient
+	failing  int32
+	failures int32
+	calls    int32
+	// metaCalls the GetMeta rpcs, the Get rpcs may be canceled once the read quorum is met
+	metaCalls int32
+}
+
+func (f *flakyDatanode) fail() error {
+	atomic.AddInt32(&f.calls, 1)
+	if atomic.LoadInt32(&f.failing) == 1 || atomic.AddInt32(&f.failures, -1) >= 0 {
+		return status.Error(codes.Unavailable, "connection refused")
+	}
+	return nil
+}
+
+func (f *flakyDatanode) Get(ctx context.Context, in *proto.GetRequest, opts ...grpc.CallOption) (*proto.GetResponse, error) {
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+	return f.DataNodeClient.Get(ctx, in, opts...)
+}
+
+func (f *flakyDatanode) GetMeta(ctx context.Context, in *proto.GetMetaRequest, opts ...grpc.CallOption) (*proto.GetMetaResponse, error) {
+	atomic.AddInt32(&f.metaCalls, 1)
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+	return f.DataNodeClient.GetMeta(ctx, in, opts...)
+}
+
+func newFlakyDagNode(t *testing.T, cfg config.BreakerConfig) (*DagNode, *flakyDatanode) {
+	var clients []*StorageNode
+	flaky := &flakyDatanode{DataNodeClient: newDatanode(t, 2, 1, 2)}
+	for i := 0; i < 3; i++ {
+		cli := &datanode.Client{
+			DataClient: newDatanode(t, 2, 1, i),
+			RpcAddress: t.Name() + string(rune('0'+i)),
+		}
+		if i == 2 {
+			cli.DataClient = flaky
+		}
+		clients = append(clients, &StorageNode{Client: cli})
+	}
+	d := &DagNode{
+		Nodes: clients,
+		config: config.DagNodeConfig{
+			Name:         t.Name(),
+			DataBlocks:   2,
+			ParityBlocks: 1,
+		},
+		repairQueue: make(chan func(ctx context.Context), 10),
+	}
+	if err := d.SetBreaker(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(d.deleteBreakerMetrics)
+	return d, flaky
+}
+
+func TestDagNode_CircuitBreaker(t *testing.T) {
+	d, flaky := newFlakyDagNode(t, config.BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute})
+	now := time.Now()
+	d.Nodes[2].breaker.now = func() time.Time { return now }
+	block := blocks.NewBlock([]byte("123456"))
+	get := func() {
+		t.Helper()
+		b, err := d.Get(context.TODO(), block.Cid())
+		if err != nil {
+			t.Fatalf("get err: %v", err)
+		}
+		if !bytes.Equal(block.RawData(), b.RawData()) {
+			t.Fatal("the block from dagnode is not equal the origin block")
+		}
+	}
+
+	// the reads are reconstructed while the datanode fails, it trips the breaker
+	atomic.StoreInt32(&flaky.failing, 1)
+	get()
+	if state := d.GetDataNodeBreakerState(2); state != breakerClosed {
+		t.Fatalf("expected the breaker closed below the threshold, but instead found %d", state)
+	}
+	get()
+	if state := d.GetDataNodeBreakerState(2); state != breakerOpen {
+		t.Fatalf("expected the breaker open, but instead found %d", state)
+	}
+	// the open breaker short-circuits the rpcs
+	calls := atomic.LoadInt32(&flaky.calls)
+	get()
+	if got := atomic.LoadInt32(&flaky.calls); got != calls {
+		t.Fatalf("expected no rpc to the datanode while the breaker is open, but instead found %d", got-calls)
+	}
+
+	// a failed probe opens the breaker again
+	now = now.Add(time.Minute)
+	get()
+	if got := atomic.LoadInt32(&flaky.calls); got != calls+1 {
+		t.Fatalf("expected a single probe, but instead found %d rpcs", got-calls)
+	}
+	if state := d.GetDataNodeBreakerState(2); state != breakerOpen {
+		t.Fatalf("expected the breaker open after the failed probe, but instead found %d", state)
+	}
+
+	// the datanode recovers, the probe closes the breaker
+	atomic.StoreInt32(&flaky.failing, 0)
+	now = now.Add(time.Minute)
+	get()
+	if state := d.GetDataNodeBreakerState(2); state != breakerClosed {
+		t.Fatalf("expected the breaker closed after the successful probe, but instead found %d", state)
+	}
+	calls = atomic.LoadInt32(&flaky.calls)
+	get()
+	if got := atomic.LoadInt32(&flaky.calls); got <= calls {
+		t.Fatalf("expected the rpcs to the datanode to resume")
+	}
+}
+
+func TestDagNode_RetryBudget(t *testing.T) {
+	block := blocks.NewBlock([]byte("123456"))
+
+	// every rpc earns a retry, the unavailable datanode is retried
+	d, flaky := newFlakyDagNode(t, config.BreakerConfig{FailureThreshold: 5, OpenTimeout: time.Minute, RetryBudgetPercent: 100})
+	atomic.StoreInt32(&flaky.failures, 1)
+	if _, err := d.Get(context.TODO(), block.Cid()); err != nil {
+		t.Fatalf("get err: %v", err)
+	}
+	if calls := atomic.LoadInt32(&flaky.metaCalls); calls != 2 {
+		t.Fatalf("expected GetMeta to be retried once, but instead found %d rpcs", calls)
+	}
+
+	// an rpc earns a tenth of a retry, the first failures aren't retried
+	d, flaky = newFlakyDagNode(t, config.BreakerConfig{FailureThreshold: 5, OpenTimeout: time.Minute, RetryBudgetPercent: 10})
+	atomic.StoreInt32(&flaky.failing, 1)
+	if _, err := d.Get(context.TODO(), block.Cid()); err != nil {
+		t.Fatalf("get err: %v", err)
+	}
+	if calls := atomic.LoadInt32(&flaky.metaCalls); calls != 1 {
+		t.Fatalf("expected GetMeta not to be retried, but instead found %d rpcs", calls)
+	}
+}
@@ -111,7 +111,10 @@ func (d *DagNode) RepairDataNode(ctx context.Context, fromNodeIndex int, repairN
 	}
 }
 
-// repairBlock repairs shards of one erasure set
+// repairBlock repairs shards of one erasure set.
+// The shards of the nodes which are down are skipped, they are repaired by RepairDataNode
+// once the nodes are back.
+// The writes wait for the repair rates of the DagNode and the datanodes.
 func (d *DagNode) repairBlock(ctx context.Context, key string, blockSize int32, shards [][]byte, repairIndexes []int) error {
 	for _, repairNodeIndex := range repairIndexes {
 		if repairNodeIndex >= len(d.Nodes) {
@@ -127,7 +130,7 @@ func (d *DagNode) repairBlock(ctx context.Context, key string, blockSize int32,
 		}
 	}
 	if availableShards < entryReadQuorum {
-		return errors.New("repair index greater than max index of nodes")
+		return errors.New("not enough shards to repair the block")
 	}
 
 	ctx, cancel := context.WithCancel(ctx)
@@ -147,21 +150,32 @@ func (d *DagNode) repairBlock(ctx context.Context, key string, blockSize int32,
 	meta := Meta{
 		BlockSize: blockSize,
 	}
+	var metaBuf bytes.Buffer
+	if err = binary.Write(&metaBuf, binary.LittleEndian, meta); err != nil {
+		log.Errorf("binary.Write failed: %v", err)
+		return err
+	}
+	var lastErr error
 	for _, index := range repairIndexes {
-		var metaBuf bytes.Buffer
-		if err = binary.Write(&metaBuf, binary.LittleEndian, meta); err != nil {
-			log.Errorf("binary.Write failed: %v", err)
+		node := d.Nodes[index]
+		if !node.State {
+			log.Warnw("data node is down, skip repairing the shard", "datanode", node.RpcAddress, "key", key, "shardIndex", index)
 			continue
 		}
-		if _, err = d.Nodes[index].Client.DataClient.Put(ctx, &proto.AddRequest{
+		if err = d.waitRepair(ctx, node); err != nil {
+			log.Errorw("repair block shard canceled waiting for the repair rate", "datanode", node.RpcAddress, "key", key, "shardIndex", index, "error", err)
+			return err
+		}
+		if _, err = node.Client.DataClient.Put(ctx, &proto.AddRequest{
 			Key:  key,
 			Meta: metaBuf.Bytes(),
 			Data: shards[index],
 		}); err != nil {
-			log.Errorf("data node put failed: %v", err)
-			return err
+			log.Errorw("repair block shard failed", "datanode", node.RpcAddress, "key", key, "shardIndex", index, "error", err)
+			lastErr = err
+			continue
 		}
 		log.Infow("repair block shard success", "key", key, "shardIndex", index)
 	}
-	return nil
+	return lastErr
 }
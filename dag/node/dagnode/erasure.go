@@ -1,6 +1,7 @@
 package dagnode
 
 import (
+	"errors"
 	"github.com/klauspost/reedsolomon"
 	"sync"
 )
@@ -66,20 +67,35 @@ func (e *Erasure) EncodeData(data []byte) ([][]byte, error) {
 
 // DecodeDataBlocks decodes the given erasure-coded data.
 // It only decodes the data blocks but does not verify them.
-// It returns an error if the decoding failed.
+// A shard which isn't the size of the shards is missing, it returns errErasureTooFewShards
+// if the shards left can't reconstruct the data blocks.
 func (e *Erasure) DecodeDataBlocks(data [][]byte) error {
-	isZero := 0
-	for _, b := range data {
-		if len(b) == 0 {
-			isZero++
-			break
+	if e.blockSize == 0 {
+		// payload is 0 bytes.
+		return nil
+	}
+	shardSize := int(e.ShardSize())
+	missing := 0
+	for i, b := range data {
+		if len(b) != shardSize {
+			data[i] = nil
+			missing++
 		}
 	}
-	if isZero == 0 || isZero == len(data) {
-		// If all are zero, payload is 0 bytes.
+	if missing == 0 {
 		return nil
 	}
-	return e.encoder().ReconstructData(data)
+	if len(data)-missing < e.dataBlocks {
+		return errErasureTooFewShards
+	}
+	if err := e.encoder().ReconstructData(data); err != nil {
+		log.Errorf("encoder reconstruct data err:%v", err)
+		if errors.Is(err, reedsolomon.ErrTooFewShards) {
+			return errErasureTooFewShards
+		}
+		return err
+	}
+	return nil
 }
 
 // DecodeDataAndParityBlocks decodes the given erasure-coded data and verifies it.
@@ -0,0 +1,72 @@
+package dagnode
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestErasure_DecodeDataBlocks(t *testing.T) {
+	const dataBlocks, parityBlocks = 3, 2
+	content := []byte("the content of the block decoded by the erasure")
+	enc, err := NewErasure(dataBlocks, parityBlocks, int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := func() [][]byte {
+		shards, err := enc.EncodeData(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return shards
+	}
+	join := func(shards [][]byte) []byte {
+		return bytes.Join(shards[:dataBlocks], nil)[:len(content)]
+	}
+
+	testCases := []struct {
+		name  string
+		lose  func(shards [][]byte)
+		error error
+	}{
+		{name: "no shard lost", lose: func(shards [][]byte) {}},
+		{name: "parity shards lost", lose: func(shards [][]byte) {
+			shards[0], shards[4] = nil, nil
+		}},
+		{name: "truncated shard", lose: func(shards [][]byte) {
+			shards[1] = shards[1][:1]
+		}},
+		{name: "more shards lost than the parity shards", lose: func(shards [][]byte) {
+			shards[0], shards[2], shards[3] = nil, nil, nil
+		}, error: errErasureTooFewShards},
+		{name: "truncated shard and parity shards lost", lose: func(shards [][]byte) {
+			shards[0], shards[4] = nil, nil
+			shards[1] = shards[1][:len(shards[1])-1]
+		}, error: errErasureTooFewShards},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			shards := encode()
+			testCase.lose(shards)
+			err := enc.DecodeDataBlocks(shards)
+			if err != testCase.error {
+				t.Fatalf("expected the error %v, but instead found %v", testCase.error, err)
+			}
+			if err == nil && !bytes.Equal(join(shards), content) {
+				t.Fatalf("the decoded content %q is not the origin content", join(shards))
+			}
+		})
+	}
+
+	// the shards of an empty block are empty
+	enc, err = NewErasure(dataBlocks, parityBlocks, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shards, err := enc.EncodeData(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = enc.DecodeDataBlocks(shards); err != nil {
+		t.Fatalf("decode the empty block: %v", err)
+	}
+}
@@ -14,12 +14,19 @@ var errErasureReadQuorum = errors.New("Read failed. Insufficient number of nodes
 // errErasureWriteQuorum - did not meet write quorum.
 var errErasureWriteQuorum = errors.New("Write failed. Insufficient number of nodes online")
 
+// errErasureTooFewShards - the shards read can't reconstruct the block.
+var errErasureTooFewShards = errors.New("Read failed. Insufficient shards to reconstruct the block")
+
 // errNodeAccessDenied - we don't have write permissions on node.
 var errNodeAccessDenied = errors.New("node access denied")
 
+// errBreakerOpen - the circuit breaker of the node is open, the rpc isn't sent.
+var errBreakerOpen = errors.New("the circuit breaker of the node is open")
+
 // Collection of basic errors.
 var baseErrs = []error{
 	errNodeNotFound,
+	errBreakerOpen,
 }
 
 var baseIgnoredErrs = baseErrs
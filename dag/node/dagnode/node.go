@@ -20,7 +20,10 @@ import (
 	"google.golang.org/grpc/codes"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -32,7 +35,25 @@ var log = logging.Logger("dag-node")
 
 type StorageNode struct {
 	*datanode.Client
-	State bool // true: means the data node is health
+	State    bool  // true: means the data node is health
+	lastSeen int64 // the unix nano time of the last successful health check
+	misses   int   // the consecutive failed health checks
+	breaker  *circuitBreaker
+	// readLatency the recent latency of the shard reads in nanoseconds, an exponentially weighted moving average
+	readLatency int64
+	// space the disk space of the datanode reported at the last heartbeat
+	space atomic.Value
+	// repairLimiter the rate of the repair writes to the datanode
+	repairLimiter *rateLimiter
+}
+
+//LastSeen returns the time of the last successful health check, it's zero if there is none
+func (sn *StorageNode) LastSeen() time.Time {
+	ns := atomic.LoadInt64(&sn.lastSeen)
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
 }
 
 //DagNode Implemented the Blockstore interface
@@ -43,6 +64,16 @@ type DagNode struct {
 	config      config.DagNodeConfig
 	repairQueue chan func(ctx context.Context)
 	stopCh      chan struct{}
+	// heartbeat the health checks of the datanodes
+	heartbeat config.HeartbeatConfig
+	// writeConcern the write concern of the config, it can be changed at runtime
+	writeConcern atomic.Value
+	// breakerConfig the retries and the circuit breakers of the rpcs to the datanodes
+	breakerConfig config.BreakerConfig
+	retryBudget   *retryBudget
+	// repairConfig the rate of the repair writes to the datanodes
+	repairConfig  config.RepairConfig
+	repairLimiter *rateLimiter
 }
 
 type Meta struct {
@@ -51,9 +82,8 @@ type Meta struct {
 
 //NewDagNode creates a new DagNode
 func NewDagNode(cfg config.DagNodeConfig) (*DagNode, error) {
-	numNodes := len(cfg.Nodes)
-	if numNodes != cfg.DataBlocks+cfg.ParityBlocks || numNodes == 0 {
-		return nil, errors.New("dag node config is incorrect")
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 	clients := make([]*StorageNode, 0, cfg.DataBlocks+cfg.ParityBlocks)
 	for _, c := range cfg.Nodes {
@@ -63,17 +93,39 @@ func NewDagNode(cfg config.DagNodeConfig) (*DagNode, error) {
 		}
 		clients = append(clients, &StorageNode{Client: dateNode})
 	}
-	return &DagNode{
+	d := &DagNode{
 		Nodes:       clients,
 		slots:       slotsmgr.NewSlotsManager(),
 		config:      cfg,
 		repairQueue: make(chan func(ctx context.Context), 10000),
 		stopCh:      make(chan struct{}),
-	}, nil
+		heartbeat:   config.DefaultHeartbeatConfig(),
+	}
+	d.writeConcern.Store(cfg.WriteConcern)
+	if err := d.SetBreaker(config.DefaultBreakerConfig()); err != nil {
+		return nil, err
+	}
+	if err := d.SetRepair(config.DefaultRepairConfig()); err != nil {
+		return nil, err
+	}
+	return d, nil
 }
 
 func (d *DagNode) GetConfig() *config.DagNodeConfig {
-	return &d.config
+	cfg := d.config
+	cfg.WriteConcern = d.getWriteConcern()
+	return &cfg
+}
+
+//SetWriteConcern changes the write concern of the DagNode, the puts in flight keep the previous one
+func (d *DagNode) SetWriteConcern(writeConcern string) error {
+	cfg := d.config
+	cfg.WriteConcern = writeConcern
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	d.writeConcern.Store(writeConcern)
+	return nil
 }
 
 func (d *DagNode) GetDataNodeState(setIndex int) bool {
@@ -83,6 +135,28 @@ func (d *DagNode) GetDataNodeState(setIndex int) bool {
 	return d.Nodes[setIndex].State
 }
 
+//GetDataNodeLastSeen returns the time of the last successful health check of the datanode
+func (d *DagNode) GetDataNodeLastSeen(setIndex int) time.Time {
+	if setIndex < 0 || setIndex >= len(d.Nodes) {
+		log.Fatalf("input setIndex %v is illegal, size of set is %v", setIndex, len(d.Nodes))
+	}
+	return d.Nodes[setIndex].LastSeen()
+}
+
+//GetHeartbeat returns the config of the health checks of the datanodes
+func (d *DagNode) GetHeartbeat() config.HeartbeatConfig {
+	return d.heartbeat
+}
+
+//SetHeartbeat changes the health checks of the datanodes, it must be called before RunHeartbeatCheck
+func (d *DagNode) SetHeartbeat(heartbeat config.HeartbeatConfig) error {
+	if err := heartbeat.Validate(); err != nil {
+		return err
+	}
+	d.heartbeat = heartbeat
+	return nil
+}
+
 // AddSlot Set the slot bit and return the old value
 func (d *DagNode) AddSlot(slot uint64) bool {
 	old, err := d.slots.Set(slot, true)
@@ -127,17 +201,31 @@ func (d *DagNode) GetNumSlots() int {
 func (d *DagNode) RunHeartbeatCheck(ctx context.Context) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	ticker := time.NewTicker(30 * time.Second)
+	ticker := time.NewTicker(d.heartbeat.Interval)
 	defer ticker.Stop()
 	healthCheckAll := func() {
 		wg := sync.WaitGroup{}
-		checkCtx, checkCancel := context.WithTimeout(ctx, 15*time.Second)
+		checkCtx, checkCancel := context.WithTimeout(ctx, d.heartbeat.Timeout)
 		defer checkCancel()
 		for _, node := range d.Nodes {
 			wg.Add(1)
 			go func(sn *StorageNode) {
 				defer wg.Done()
-				sn.State = d.healthCheck(checkCtx, sn.Client)
+				if d.healthCheck(checkCtx, sn.Client) {
+					sn.misses = 0
+					sn.State = true
+					atomic.StoreInt64(&sn.lastSeen, time.Now().UnixNano())
+					sn.refreshSpace(checkCtx)
+					return
+				}
+				// a single missed check isn't enough to mark the datanode down
+				sn.misses++
+				if sn.misses >= d.heartbeat.MaxMisses {
+					if sn.State {
+						log.Warnw("the datanode is down", "rpc_address", sn.RpcAddress, "misses", sn.misses)
+					}
+					sn.State = false
+				}
 			}(node)
 		}
 		wg.Wait()
@@ -195,10 +283,14 @@ func (d *DagNode) DeleteBlock(ctx context.Context, cid cid.Cid) (err error) {
 	taskCtx := context.Background()
 	task := paralleltask.NewParallelTask(taskCtx, entryWriteQuorum, len(d.Nodes)-entryWriteQuorum+1, false)
 	for _, snode := range d.Nodes {
+		sn := snode
 		node := snode.Client
 		task.Goroutine(func(ctx context.Context) error {
-			var err error
-			if _, err = node.DataClient.Delete(ctx, &proto.DeleteRequest{Key: keyCode}); err != nil {
+			err := d.call(ctx, sn, func(ctx context.Context) error {
+				_, err := node.DataClient.Delete(ctx, &proto.DeleteRequest{Key: keyCode})
+				return err
+			})
+			if err != nil {
 				log.Errorw("delete error", "datanode", node.RpcAddress, "key", keyCode, "error", err)
 			}
 			return err
@@ -229,41 +321,13 @@ func (d *DagNode) Get(ctx context.Context, cid cid.Cid) (blocks.Block, error) {
 
 	shards := make([][]byte, len(onlineNodes))
 	repairIndexes := make([]bool, len(onlineNodes))
-	needRepair := false
-	task := paralleltask.NewParallelTask(ctx, entryReadQuorum, len(onlineNodes)-entryReadQuorum+1, true)
 	for i, snode := range onlineNodes {
-		index := i
-		tnode := snode
-		task.Goroutine(func(ctx context.Context) error {
-			// is offline node or have no block?
-			if tnode == nil {
-				// is it online?
-				if d.Nodes[index].State {
-					// repair shard
-					repairIndexes[index] = true
-					needRepair = true
-				}
-				return errors.New("offline node")
-			}
-			node := tnode.Client
-			var err error
-			res, err := node.DataClient.Get(ctx, &proto.GetRequest{Key: keyCode})
-			if err != nil {
-				log.Errorw("get error", "datanode", node.RpcAddress, "key", keyCode, "error", err)
-				if st, ok := status.FromError(err); ok && st.Code() != codes.Canceled {
-					// repair shard
-					repairIndexes[index] = true
-					needRepair = true
-				}
-			} else {
-				shards[index] = res.Data
-				return nil
-			}
-			return err
-		})
-
+		// the node has no block but it's online, repair the shard
+		if snode == nil && d.Nodes[i].State {
+			repairIndexes[i] = true
+		}
 	}
-	if err = task.Wait(); err != nil {
+	if err = d.readShards(ctx, keyCode, onlineNodes, entryReadQuorum, shards, repairIndexes); err != nil {
 		log.Errorf("task error: %v", err)
 		return nil, err
 	}
@@ -279,19 +343,22 @@ func (d *DagNode) Get(ctx context.Context, cid cid.Cid) (blocks.Block, error) {
 		return nil, err
 	}
 
-	// need repair shards?
-	if needRepair {
-		indexes := make([]int, 0)
-		for i, ok := range repairIndexes {
-			if ok {
-				indexes = append(indexes, i)
-			}
+	// need repair shards? the repair runs in the background, it doesn't delay the read
+	indexes := make([]int, 0)
+	for i, ok := range repairIndexes {
+		if ok {
+			indexes = append(indexes, i)
 		}
+	}
+	if len(indexes) > 0 {
+		// the repair decodes the parity shards, give it its own slice
+		repairShards := make([][]byte, len(shards))
+		copy(repairShards, shards)
 		repairFunc := func(ctx context.Context) {
 			repairCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 			defer cancel()
-			if err := d.repairBlock(repairCtx, keyCode, size, shards, indexes); err != nil {
-				log.Errorw("repair block failed", "key", keyCode, "blockSize", size, "indexes", indexes)
+			if err := d.repairBlock(repairCtx, keyCode, size, repairShards, indexes); err != nil {
+				log.Errorw("repair block failed", "key", keyCode, "blockSize", size, "indexes", indexes, "error", err)
 			}
 		}
 		select {
@@ -327,7 +394,7 @@ func (d *DagNode) GetSize(ctx context.Context, cid cid.Cid) (int, error) {
 
 func (d *DagNode) getMetaInfo(ctx context.Context, cid cid.Cid) (meta Meta, metas []Meta, onlineNodes []*StorageNode, err error) {
 	var errs []error
-	metas, errs = readAllMeta(ctx, d.Nodes, cid.String())
+	metas, errs = d.readAllMeta(ctx, d.Nodes, cid.String())
 	entryReadQuorum, _ := d.entryQuorum()
 	reducedErr := reduceQuorumErrs(ctx, errs, entryOpIgnoredErrs, entryReadQuorum, errErasureReadQuorum)
 	if reducedErr != nil {
@@ -378,27 +445,71 @@ func (d *DagNode) Put(ctx context.Context, block blocks.Block) (err error) {
 		return err
 	}
 
-	_, entryWriteQuorum := d.entryQuorum()
+	writeQuorum := d.putWriteQuorum()
 	taskCtx := context.Background()
-	task := paralleltask.NewParallelTask(taskCtx, entryWriteQuorum, len(d.Nodes)-entryWriteQuorum+1, false)
+	task := paralleltask.NewParallelTask(taskCtx, writeQuorum, len(d.Nodes)-writeQuorum+1, false)
+	// the shards which are not written yet when the put succeeds keep being written
+	var wg sync.WaitGroup
+	errs := make([]error, len(d.Nodes))
 	for i, snode := range d.Nodes {
 		index := i
+		sn := snode
 		node := snode.Client
+		wg.Add(1)
 		task.Goroutine(func(ctx context.Context) error {
-			var err error
-			if _, err = node.DataClient.Put(ctx, &proto.AddRequest{
-				Key:  keyCode,
-				Meta: metaBuf.Bytes(),
-				Data: shards[index],
-			}); err != nil {
+			defer wg.Done()
+			err := d.call(ctx, sn, func(ctx context.Context) error {
+				_, err := node.DataClient.Put(ctx, &proto.AddRequest{
+					Key:  keyCode,
+					Meta: metaBuf.Bytes(),
+					Data: shards[index],
+				})
+				return err
+			})
+			if err != nil {
 				log.Errorw("put error", "datanode", node.RpcAddress, "key", keyCode, "error", err)
 			}
+			errs[index] = err
 			return err
 		})
 	}
 	// If the specified number of successes is met, the write succeeds,
 	// or if the specified number of failures is met, the write fails
-	return task.Wait()
+	if err = task.Wait(); err != nil {
+		return err
+	}
+	if writeQuorum < len(d.Nodes) {
+		go d.completePendingShards(keyCode, int32(blockDataSize), shards, errs, &wg)
+	}
+	return nil
+}
+
+// completePendingShards waits for the shard writes which were pending when the put succeeded,
+// and queues the repair of the failed ones
+func (d *DagNode) completePendingShards(key string, blockSize int32, shards [][]byte, errs []error, wg *sync.WaitGroup) {
+	wg.Wait()
+	indexes := make([]int, 0)
+	for i, err := range errs {
+		if err != nil {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		return
+	}
+	log.Warnw("shards are pending after put", "key", key, "indexes", indexes)
+	repairFunc := func(ctx context.Context) {
+		repairCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+		if err := d.repairBlock(repairCtx, key, blockSize, shards, indexes); err != nil {
+			log.Errorw("repair block failed", "key", key, "blockSize", blockSize, "indexes", indexes, "error", err)
+		}
+	}
+	select {
+	case d.repairQueue <- repairFunc:
+	default:
+		log.Warn("repair queue is full, discard this task")
+	}
 }
 
 //PutMany adds the given blocks to the DagNode
@@ -409,9 +520,105 @@ func (d *DagNode) PutMany(ctx context.Context, blocks []blocks.Block) (err error
 	return err
 }
 
-//AllKeysChan returns a channel that will yield every key in the dag
+// maxListedKeys the max number of keys AllKeysChan remembers to yield each block once,
+// the keys listed beyond it are looked up on the datanodes listed before instead
+var maxListedKeys = 1 << 20
+
+//AllKeysChan returns a channel that will yield every key in the dag.
+//The datanodes store the shards by the cid string, the keys they list are the cids of the blocks.
+//A block has shards on all the datanodes but at most ParityBlocks of them, so ParityBlocks+1
+//datanodes list every block, the datanodes which fail to list are skipped.
 func (d *DagNode) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
-	panic("implement me")
+	out := make(chan cid.Cid)
+	go func() {
+		defer close(out)
+		// every block is listed by several datanodes, yield it once
+		seen := &listedKeys{keys: make(map[string]struct{})}
+		listed := 0
+		for _, sn := range d.Nodes {
+			if listed > d.config.ParityBlocks {
+				return
+			}
+			if err := d.listKeys(ctx, sn, seen, out); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				log.Warnw("list keys error", "datanode", sn.RpcAddress, "error", err)
+				continue
+			}
+			seen.nodes = append(seen.nodes, sn)
+			listed++
+		}
+		if listed <= d.config.ParityBlocks {
+			log.Errorw("too few datanodes are listed, some keys may be missing", "listed", listed, "parityBlocks", d.config.ParityBlocks)
+		}
+	}()
+	return out, nil
+}
+
+// listedKeys the keys yielded by a listing, at most maxListedKeys of them are kept in memory
+type listedKeys struct {
+	keys map[string]struct{}
+	// nodes the datanodes fully listed before the one being listed, a key which isn't kept
+	// is yielded again only if it was yielded by a datanode which failed halfway
+	nodes []*StorageNode
+}
+
+// contains returns whether the key is yielded already. Once the keys kept are full the key
+// which isn't kept is looked up on the datanodes listed before, they have yielded it if they have it.
+func (l *listedKeys) contains(ctx context.Context, key string) bool {
+	if _, ok := l.keys[key]; ok {
+		return true
+	}
+	if len(l.keys) < maxListedKeys {
+		return false
+	}
+	for _, sn := range l.nodes {
+		if _, err := sn.DataClient.GetMeta(ctx, &proto.GetMetaRequest{Key: key}); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// add records the yielded key if the keys kept aren't full
+func (l *listedKeys) add(key string) {
+	if len(l.keys) < maxListedKeys {
+		l.keys[key] = struct{}{}
+	}
+}
+
+// listKeys yields the cids of the keys of the datanode which are not seen yet
+func (d *DagNode) listKeys(ctx context.Context, sn *StorageNode, seen *listedKeys, out chan<- cid.Cid) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := sn.DataClient.AllKeysChan(ctx, &emptypb.Empty{})
+	if err != nil {
+		return err
+	}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if seen.contains(ctx, resp.Key) {
+			continue
+		}
+		c, err := cid.Decode(resp.Key)
+		if err != nil {
+			log.Errorw("decode cid error", "datanode", sn.RpcAddress, "key", resp.Key, "error", err)
+			continue
+		}
+		seen.add(resp.Key)
+		select {
+		case out <- c:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
 }
 
 //HashOnRead tells the dag node to calculate the hash of the block
@@ -423,8 +630,29 @@ func (d *DagNode) Close() {
 	for _, nd := range d.Nodes {
 		nd.Conn.Close()
 	}
+	d.deleteBreakerMetrics()
 	close(d.stopCh)
-	close(d.repairQueue)
+}
+
+func (d *DagNode) getWriteConcern() string {
+	if wc, ok := d.writeConcern.Load().(string); ok {
+		return wc
+	}
+	return d.config.WriteConcern
+}
+
+// putWriteQuorum returns the number of shards written before a put succeeds
+func (d *DagNode) putWriteQuorum() int {
+	switch d.getWriteConcern() {
+	case config.WriteConcernAll:
+		return d.config.DataBlocks + d.config.ParityBlocks
+	case config.WriteConcernData:
+		return d.config.DataBlocks
+	case config.WriteConcernDataPlusOne:
+		return d.config.DataBlocks + 1
+	}
+	_, entryWriteQuorum := d.entryQuorum()
+	return entryWriteQuorum
 }
 
 // Returns per entry readQuorum and writeQuorum
@@ -441,7 +669,7 @@ func (d *DagNode) entryQuorum() (entryReadQuorum, entryWriteQuorum int) {
 
 // Reads all metadata as a Meta slice.
 // Returns error slice indicating the failed metadata reads.
-func readAllMeta(ctx context.Context, nodes []*StorageNode, key string) ([]Meta, []error) {
+func (d *DagNode) readAllMeta(ctx context.Context, nodes []*StorageNode, key string) ([]Meta, []error) {
 	metadataArray := make([]Meta, len(nodes))
 	errs := make([]error, len(nodes))
 	// Read meta in parallel across nodes.
@@ -456,7 +684,11 @@ func readAllMeta(ctx context.Context, nodes []*StorageNode, key string) ([]Meta,
 				errs[index] = errNodeNotFound
 				return
 			}
-			resp, err := nodes[index].Client.DataClient.GetMeta(ctx, &proto.GetMetaRequest{Key: key})
+			var resp *proto.GetMetaResponse
+			err := d.call(ctx, nodes[index], func(ctx context.Context) (err error) {
+				resp, err = nodes[index].Client.DataClient.GetMeta(ctx, &proto.GetMetaRequest{Key: key})
+				return err
+			})
 			if err != nil {
 				if st, ok := status.FromError(err); ok && st.Code() == codes.Unknown {
 					errs[index] = errors.New(st.Message())
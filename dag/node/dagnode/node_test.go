@@ -11,8 +11,20 @@ import (
 	"github.com/filedag-project/filedag-storage/dag/proto"
 	"github.com/golang/mock/gomock"
 	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"io"
+	"net"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestDagNode(t *testing.T) {
@@ -64,6 +76,195 @@ func TestDagNode(t *testing.T) {
 	}
 }
 
+func TestDagNode_GetReadRepair(t *testing.T) {
+	const missingIndex = 2
+	putCh := make(chan *proto.AddRequest, 1)
+	var clients []*StorageNode
+	for i := 0; i < 3; i++ {
+		cli := &datanode.Client{
+			DataClient: newDatanode(t, 2, 1, i),
+		}
+		if i == missingIndex {
+			cli.DataClient = newMissingDatanode(t, putCh)
+		}
+		clients = append(clients, &StorageNode{Client: cli, State: true})
+	}
+	var d = DagNode{
+		Nodes: clients,
+		config: config.DagNodeConfig{
+			DataBlocks:   2,
+			ParityBlocks: 1,
+		},
+		repairQueue: make(chan func(ctx context.Context), 10),
+		stopCh:      make(chan struct{}),
+	}
+	defer close(d.stopCh)
+	go d.RunRepairTask(context.TODO())
+
+	content := "123456"
+	block := blocks.NewBlock([]byte(content))
+	get, err := d.Get(context.TODO(), block.Cid())
+	if err != nil {
+		t.Fatalf("get err: %v", err)
+	}
+	if !bytes.Equal(block.RawData(), get.RawData()) {
+		t.Fatal("the block from dagnode is not equal the origin block")
+	}
+
+	enc, err := NewErasure(2, 1, int64(len(content)))
+	if err != nil {
+		t.Fatalf("NewErasure failed: %v", err)
+	}
+	shards, err := enc.EncodeData(block.RawData())
+	if err != nil {
+		t.Fatalf("EncodeData failed: %v", err)
+	}
+	select {
+	case req := <-putCh:
+		if req.Key != block.Cid().String() {
+			t.Fatalf("the repaired key %s is not the block %s", req.Key, block.Cid())
+		}
+		if !bytes.Equal(req.Data, shards[missingIndex]) {
+			t.Fatal("the repaired shard is not equal the origin shard")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the missing shard is not repaired")
+	}
+}
+
+func TestDagNode_GetTooFewShards(t *testing.T) {
+	var clients []*StorageNode
+	for i := 0; i < 3; i++ {
+		cli := &datanode.Client{
+			DataClient: newDatanode(t, 2, 1, i),
+		}
+		// the datanodes keep the meta of the block but lose the shards
+		if i > 0 {
+			cli.DataClient = newLostShardDatanode(t, i == 1)
+		}
+		clients = append(clients, &StorageNode{Client: cli})
+	}
+	var d = DagNode{
+		Nodes: clients,
+		config: config.DagNodeConfig{
+			DataBlocks:   2,
+			ParityBlocks: 1,
+		},
+	}
+	block := blocks.NewBlock([]byte("123456"))
+	get, err := d.Get(context.TODO(), block.Cid())
+	if err != errErasureTooFewShards {
+		t.Fatalf("expected the error %v, but instead found %v", errErasureTooFewShards, err)
+	}
+	if get != nil {
+		t.Fatalf("expected no block, but instead found %q", get.RawData())
+	}
+}
+
+func TestDagNode_PutWriteConcern(t *testing.T) {
+	testCases := []struct {
+		writeConcern string
+		downNodes    int
+		expectedErr  bool
+	}{
+		// 2 data shards and 2 parity shards, the default quorum is 3 shards
+		{writeConcern: config.WriteConcernDefault, downNodes: 1, expectedErr: false},
+		{writeConcern: config.WriteConcernDefault, downNodes: 2, expectedErr: true},
+		{writeConcern: config.WriteConcernData, downNodes: 1, expectedErr: false},
+		{writeConcern: config.WriteConcernData, downNodes: 2, expectedErr: false},
+		{writeConcern: config.WriteConcernData, downNodes: 3, expectedErr: true},
+		{writeConcern: config.WriteConcernDataPlusOne, downNodes: 1, expectedErr: false},
+		{writeConcern: config.WriteConcernDataPlusOne, downNodes: 2, expectedErr: true},
+		{writeConcern: config.WriteConcernAll, downNodes: 0, expectedErr: false},
+		{writeConcern: config.WriteConcernAll, downNodes: 1, expectedErr: true},
+	}
+	for i, testCase := range testCases {
+		var clients []*StorageNode
+		for j := 0; j < 4; j++ {
+			cli := &datanode.Client{
+				DataClient: newDatanode(t, 2, 2, j),
+			}
+			if j < testCase.downNodes {
+				cli.DataClient = newDownDatanode(t)
+			}
+			clients = append(clients, &StorageNode{Client: cli})
+		}
+		var d = DagNode{
+			Nodes: clients,
+			config: config.DagNodeConfig{
+				DataBlocks:   2,
+				ParityBlocks: 2,
+				WriteConcern: testCase.writeConcern,
+			},
+			repairQueue: make(chan func(ctx context.Context), 10),
+		}
+		block := blocks.NewBlock([]byte("123456"))
+		err := d.Put(context.TODO(), block)
+		if (err != nil) != testCase.expectedErr {
+			t.Fatalf("Case %d: expected error %v, but instead found %v", i+1, testCase.expectedErr, err)
+		}
+		if err != nil || testCase.downNodes == 0 {
+			continue
+		}
+		// the shards of the down nodes are pending for the background repair
+		select {
+		case <-d.repairQueue:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Case %d: the pending shards are not queued to repair", i+1)
+		}
+	}
+}
+
+// newDownDatanode returns a data node which is down
+func newDownDatanode(t *testing.T) *mocks.MockDataNodeClient {
+	ctrl := gomock.NewController(t)
+	m := mocks.NewMockDataNodeClient(ctrl)
+	var ctx = reflect.TypeOf((*context.Context)(nil)).Elem()
+	m.EXPECT().Put(gomock.AssignableToTypeOf(ctx), gomock.AssignableToTypeOf(&proto.AddRequest{})).AnyTimes().
+		Return(nil, status.Error(codes.Unavailable, "connection refused"))
+	return m
+}
+
+// newMissingDatanode returns a data node which has lost the shard, the repaired shard is sent to putCh
+func newMissingDatanode(t *testing.T, putCh chan<- *proto.AddRequest) *mocks.MockDataNodeClient {
+	ctrl := gomock.NewController(t)
+	m := mocks.NewMockDataNodeClient(ctrl)
+	var ctx = reflect.TypeOf((*context.Context)(nil)).Elem()
+	notFound := status.Error(codes.NotFound, "not found")
+	m.EXPECT().Put(gomock.AssignableToTypeOf(ctx), gomock.AssignableToTypeOf(&proto.AddRequest{})).AnyTimes().
+		DoAndReturn(func(_ context.Context, req *proto.AddRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+			putCh <- req
+			return &emptypb.Empty{}, nil
+		})
+	m.EXPECT().Get(gomock.AssignableToTypeOf(ctx), gomock.AssignableToTypeOf(&proto.GetRequest{})).AnyTimes().Return(nil, notFound)
+	m.EXPECT().GetMeta(gomock.AssignableToTypeOf(ctx), gomock.AssignableToTypeOf(&proto.GetMetaRequest{})).AnyTimes().Return(nil, notFound)
+	return m
+}
+
+// newLostShardDatanode returns a data node which has the meta of the block but has lost its shard,
+// the shard is empty or truncated
+func newLostShardDatanode(t *testing.T, truncated bool) *mocks.MockDataNodeClient {
+	meta := Meta{
+		BlockSize: int32(len("123456")),
+	}
+	var metaBuf bytes.Buffer
+	if err := binary.Write(&metaBuf, binary.LittleEndian, meta); err != nil {
+		t.Fatalf("binary.Write failed: %v", err)
+	}
+	var data []byte
+	if truncated {
+		data = []byte("1")
+	}
+	ctrl := gomock.NewController(t)
+	m := mocks.NewMockDataNodeClient(ctrl)
+	var ctx = reflect.TypeOf((*context.Context)(nil)).Elem()
+	m.EXPECT().Get(gomock.AssignableToTypeOf(ctx), gomock.AssignableToTypeOf(&proto.GetRequest{})).AnyTimes().
+		Return(&proto.GetResponse{Data: data, Meta: metaBuf.Bytes()}, nil)
+	m.EXPECT().GetMeta(gomock.AssignableToTypeOf(ctx), gomock.AssignableToTypeOf(&proto.GetMetaRequest{})).AnyTimes().
+		Return(&proto.GetMetaResponse{Meta: metaBuf.Bytes()}, nil)
+	return m
+}
+
 func newDatanode(t *testing.T, dataBlocks, parityBlocks int, index int) *mocks.MockDataNodeClient {
 	content := "123456"
 	block := blocks.NewBlock([]byte(content))
@@ -95,3 +296,310 @@ func newDatanode(t *testing.T, dataBlocks, parityBlocks int, index int) *mocks.M
 		Return(&proto.SizeResponse{Size: int64(datanode.HeaderSize + 4 + len(block.RawData()))}, nil)
 	return m
 }
+
+func startHealthServer(t *testing.T) (string, *grpc.Server) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(healthCheckService, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, healthServer)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis.Addr().String(), s
+}
+
+func TestDagNode_RunHeartbeatCheck(t *testing.T) {
+	addr0, _ := startHealthServer(t)
+	addr1, server1 := startHealthServer(t)
+	d, err := NewDagNode(config.DagNodeConfig{
+		Name:         "dagnode",
+		Nodes:        []string{addr0, addr1},
+		DataBlocks:   1,
+		ParityBlocks: 1,
+		WriteConcern: config.WriteConcernAll,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if err = d.SetHeartbeat(config.HeartbeatConfig{Interval: 50 * time.Millisecond, Timeout: 50 * time.Millisecond, MaxMisses: 1}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	go d.RunHeartbeatCheck(ctx)
+
+	waitFor := func(cond func() bool, msg string) {
+		for i := 0; i < 100; i++ {
+			if cond() {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatal(msg)
+	}
+	waitFor(func() bool {
+		return !d.GetDataNodeLastSeen(0).IsZero() && !d.GetDataNodeLastSeen(1).IsZero()
+	}, "expected both datanodes to be seen")
+	if !d.GetDataNodeState(0) || !d.GetDataNodeState(1) {
+		t.Fatalf("expected both datanodes to be up")
+	}
+
+	server1.Stop()
+	waitFor(func() bool { return !d.GetDataNodeState(1) }, "expected the stopped datanode to be down")
+	lastSeen := d.GetDataNodeLastSeen(1)
+	time.Sleep(3 * d.heartbeat.Interval)
+	if !d.GetDataNodeLastSeen(1).Equal(lastSeen) {
+		t.Fatalf("expected the last seen of the stopped datanode not to advance")
+	}
+	if !d.GetDataNodeState(0) || !d.GetDataNodeLastSeen(0).After(lastSeen) {
+		t.Fatalf("expected the other datanode to stay up")
+	}
+}
+
+// flakyHealthServer fails the number of health checks set in failures
+type flakyHealthServer struct {
+	healthpb.UnimplementedHealthServer
+	failures int32
+	checks   int32
+}
+
+func (s *flakyHealthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	atomic.AddInt32(&s.checks, 1)
+	if atomic.AddInt32(&s.failures, -1) >= 0 {
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}, nil
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func TestDagNode_HeartbeatMaxMisses(t *testing.T) {
+	addr0, _ := startHealthServer(t)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	flaky := &flakyHealthServer{}
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, flaky)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	d, err := NewDagNode(config.DagNodeConfig{
+		Name:         "dagnode",
+		Nodes:        []string{addr0, lis.Addr().String()},
+		DataBlocks:   1,
+		ParityBlocks: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if err = d.SetHeartbeat(config.HeartbeatConfig{Interval: 30 * time.Millisecond, Timeout: 30 * time.Millisecond, MaxMisses: 3}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	go d.RunHeartbeatCheck(ctx)
+
+	// watchState polls the state of the flaky datanode until it has been checked n more times
+	watchState := func(n int32) (everDown bool) {
+		until := atomic.LoadInt32(&flaky.checks) + n
+		for i := 0; i < 500 && atomic.LoadInt32(&flaky.checks) < until; i++ {
+			if !d.GetDataNodeState(1) {
+				everDown = true
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		if atomic.LoadInt32(&flaky.checks) < until {
+			t.Fatalf("expected the datanode to be checked %d more times", n)
+		}
+		return everDown
+	}
+	watchState(2)
+	if !d.GetDataNodeState(1) {
+		t.Fatalf("expected the datanode to be up")
+	}
+
+	// fewer missed checks than the threshold are a blip
+	atomic.StoreInt32(&flaky.failures, 2)
+	if watchState(4) {
+		t.Fatalf("expected the datanode not to be marked down by 2 missed checks")
+	}
+
+	// the datanode is down after 3 consecutive missed checks
+	atomic.StoreInt32(&flaky.failures, 100)
+	if watchState(2) {
+		t.Fatalf("expected the datanode not to be marked down before 3 missed checks")
+	}
+	watchState(2)
+	if d.GetDataNodeState(1) {
+		t.Fatalf("expected the datanode to be down after 3 missed checks")
+	}
+
+	// it's up again after a successful check
+	atomic.StoreInt32(&flaky.failures, 0)
+	watchState(2)
+	if !d.GetDataNodeState(1) {
+		t.Fatalf("expected the datanode to be up again")
+	}
+}
+
+// memDatanode is a data node which keeps the shards in memory, it's unavailable while down is set
+type memDatanode struct {
+	proto.DataNodeClient
+	mu     sync.Mutex
+	shards map[string]*proto.AddRequest
+	down   int32
+}
+
+func newMemDatanode() *memDatanode {
+	return &memDatanode{shards: make(map[string]*proto.AddRequest)}
+}
+
+func (m *memDatanode) len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.shards)
+}
+
+func (m *memDatanode) Put(ctx context.Context, in *proto.AddRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shards[in.Key] = in
+	return &emptypb.Empty{}, nil
+}
+
+func (m *memDatanode) Delete(ctx context.Context, in *proto.DeleteRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.shards, in.Key)
+	return &emptypb.Empty{}, nil
+}
+
+func (m *memDatanode) Get(ctx context.Context, in *proto.GetRequest, opts ...grpc.CallOption) (*proto.GetResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	shard, ok := m.shards[in.Key]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	return &proto.GetResponse{Meta: shard.Meta, Data: shard.Data}, nil
+}
+
+func (m *memDatanode) GetMeta(ctx context.Context, in *proto.GetMetaRequest, opts ...grpc.CallOption) (*proto.GetMetaResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	shard, ok := m.shards[in.Key]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	return &proto.GetMetaResponse{Meta: shard.Meta}, nil
+}
+
+func (m *memDatanode) AllKeysChan(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (proto.DataNode_AllKeysChanClient, error) {
+	if atomic.LoadInt32(&m.down) == 1 {
+		return nil, status.Error(codes.Unavailable, "connection refused")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.shards))
+	for key := range m.shards {
+		keys = append(keys, key)
+	}
+	return &memKeysStream{keys: keys}, nil
+}
+
+type memKeysStream struct {
+	grpc.ClientStream
+	keys []string
+}
+
+func (s *memKeysStream) Recv() (*proto.AllKeysChanResponse, error) {
+	if len(s.keys) == 0 {
+		return nil, io.EOF
+	}
+	key := s.keys[0]
+	s.keys = s.keys[1:]
+	return &proto.AllKeysChanResponse{Key: key}, nil
+}
+
+func TestDagNode_AllKeysChan(t *testing.T) {
+	t.Run("all keys kept", testDagNodeAllKeysChan)
+	// the keys beyond the ones kept are looked up on the datanodes listed before
+	t.Run("few keys kept", func(t *testing.T) {
+		defer func(max int) { maxListedKeys = max }(maxListedKeys)
+		maxListedKeys = 5
+		testDagNodeAllKeysChan(t)
+	})
+}
+
+func testDagNodeAllKeysChan(t *testing.T) {
+	datanodes := make([]*memDatanode, 3)
+	var clients []*StorageNode
+	for i := range datanodes {
+		datanodes[i] = newMemDatanode()
+		clients = append(clients, &StorageNode{Client: &datanode.Client{DataClient: datanodes[i]}})
+	}
+	var d = DagNode{
+		Nodes: clients,
+		config: config.DagNodeConfig{
+			DataBlocks:   2,
+			ParityBlocks: 1,
+			WriteConcern: config.WriteConcernAll,
+		},
+		repairQueue: make(chan func(ctx context.Context), 10),
+	}
+	ctx := context.TODO()
+	expected := make(map[cid.Cid]blocks.Block)
+	for i := 0; i < 20; i++ {
+		block := blocks.NewBlock([]byte(fmt.Sprintf("block %d", i)))
+		if err := d.Put(ctx, block); err != nil {
+			t.Fatalf("put err: %v", err)
+		}
+		expected[block.Cid()] = block
+	}
+	deleted := blocks.NewBlock([]byte("block 0"))
+	if err := d.DeleteBlock(ctx, deleted.Cid()); err != nil {
+		t.Fatalf("delete err: %v", err)
+	}
+	delete(expected, deleted.Cid())
+	// the delete succeeds once the write quorum of the shards is deleted, wait for the last one
+	for i := 0; i < 100; i++ {
+		if datanodes[0].len()+datanodes[1].len()+datanodes[2].len() == 3*len(expected) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// the other datanodes list every block
+	atomic.StoreInt32(&datanodes[0].down, 1)
+
+	ch, err := d.AllKeysChan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed := make(map[cid.Cid]struct{})
+	for c := range ch {
+		if _, ok := listed[c]; ok {
+			t.Fatalf("the key %v is listed twice", c)
+		}
+		listed[c] = struct{}{}
+		block, ok := expected[c]
+		if !ok {
+			t.Fatalf("the key %v is not put", c)
+		}
+		// the listed key resolves to the block
+		got, err := d.Get(ctx, c)
+		if err != nil {
+			t.Fatalf("get %v err: %v", c, err)
+		}
+		if !bytes.Equal(block.RawData(), got.RawData()) {
+			t.Fatalf("the block of %v is not equal the origin block", c)
+		}
+	}
+	if len(listed) != len(expected) {
+		t.Fatalf("expected %d keys, but instead found %d", len(expected), len(listed))
+	}
+}
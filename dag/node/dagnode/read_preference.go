@@ -0,0 +1,215 @@
+package dagnode
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/dag/config"
+	"github.com/filedag-project/filedag-storage/dag/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// readLatencyWeight the weight of the latest read in the recent read latency of a datanode, in tenths
+	readLatencyWeight = 3
+	// readFailurePenalty the least latency a failed read counts for, so that a datanode which fails fast isn't preferred
+	readFailurePenalty = time.Second
+)
+
+// recordReadLatency adds the latency of a read to the recent read latency of the datanode
+func (sn *StorageNode) recordReadLatency(latency time.Duration) {
+	for {
+		old := atomic.LoadInt64(&sn.readLatency)
+		recent := int64(latency)
+		if old != 0 {
+			recent = old + (int64(latency)-old)*readLatencyWeight/10
+		}
+		if atomic.CompareAndSwapInt64(&sn.readLatency, old, recent) {
+			return
+		}
+	}
+}
+
+// recordUnfinishedRead raises the recent read latency of the datanode to the time a read was waited for,
+// the read was canceled once the other datanodes met the read quorum, so it took at least that long
+func (sn *StorageNode) recordUnfinishedRead(waited time.Duration) {
+	for {
+		old := atomic.LoadInt64(&sn.readLatency)
+		if int64(waited) <= old {
+			return
+		}
+		if atomic.CompareAndSwapInt64(&sn.readLatency, old, int64(waited)) {
+			return
+		}
+	}
+}
+
+// ReadLatency returns the recent read latency of the datanode, it's zero if it has never been read
+func (sn *StorageNode) ReadLatency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&sn.readLatency))
+}
+
+// GetDataNodeReadLatency returns the recent read latency of the datanode
+func (d *DagNode) GetDataNodeReadLatency(setIndex int) time.Duration {
+	if setIndex < 0 || setIndex >= len(d.Nodes) {
+		log.Fatalf("input setIndex %v is illegal, size of set is %v", setIndex, len(d.Nodes))
+	}
+	return d.Nodes[setIndex].ReadLatency()
+}
+
+// GetReadPreference returns the datanodes the shards of a block are read from
+func (d *DagNode) GetReadPreference() config.ReadPreferenceConfig {
+	return d.config.ReadPreference
+}
+
+// SetReadPreference changes the datanodes the shards of a block are read from, it must be called before the DagNode is used
+func (d *DagNode) SetReadPreference(cfg config.ReadPreferenceConfig) error {
+	if err := cfg.Validate(d.config.Nodes); err != nil {
+		return err
+	}
+	d.config.ReadPreference = cfg
+	return nil
+}
+
+// readOrder returns the indexes of the online datanodes in the order they are read.
+// The nearest preference puts the local datanodes first, then the ones with the lowest recent latency,
+// a datanode which has never been read comes first so that it's measured. The ties are kept in the
+// order of the shards, the data shards don't need to be decoded.
+func (d *DagNode) readOrder(onlineNodes []*StorageNode) []int {
+	order := make([]int, 0, len(onlineNodes))
+	for i, sn := range onlineNodes {
+		if sn != nil {
+			order = append(order, i)
+		}
+	}
+	pref := d.config.ReadPreference
+	if pref.Mode != config.ReadPreferenceNearest {
+		return order
+	}
+	local := make(map[string]bool, len(pref.LocalNodes))
+	for _, addr := range pref.LocalNodes {
+		local[addr] = true
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := onlineNodes[order[i]], onlineNodes[order[j]]
+		if local[a.RpcAddress] != local[b.RpcAddress] {
+			return local[a.RpcAddress]
+		}
+		return a.ReadLatency() < b.ReadLatency()
+	})
+	return order
+}
+
+type shardRead struct {
+	index int
+	data  []byte
+	err   error
+}
+
+// readShards reads the shards of the block from the online datanodes until as many as the read quorum are read.
+// The datanodes are read in the read order, all at once by default. The nearest preference reads as many as
+// the read quorum first, the next one is read when a read fails or, with a hedge delay, when no read finished
+// within the delay. The shards which failed to be read from an online datanode are set in repairIndexes.
+func (d *DagNode) readShards(ctx context.Context, key string, onlineNodes []*StorageNode, readQuorum int,
+	shards [][]byte, repairIndexes []bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	order := d.readOrder(onlineNodes)
+	if len(order) < readQuorum {
+		return errErasureReadQuorum
+	}
+	pref := d.config.ReadPreference
+	first := len(order)
+	if pref.Mode == config.ReadPreferenceNearest {
+		first = readQuorum
+	}
+	// the reads which finish after the read quorum don't block, they are dropped
+	results := make(chan shardRead, len(order))
+	started := make([]time.Time, len(onlineNodes))
+	finished := make([]bool, len(onlineNodes))
+	next := 0
+	readNext := func() {
+		index := order[next]
+		next++
+		sn := onlineNodes[index]
+		started[index] = time.Now()
+		go func() {
+			var res *proto.GetResponse
+			err := d.call(ctx, sn, func(ctx context.Context) (err error) {
+				res, err = sn.Client.DataClient.Get(ctx, &proto.GetRequest{Key: key})
+				return err
+			})
+			if err != nil {
+				results <- shardRead{index: index, err: err}
+				return
+			}
+			results <- shardRead{index: index, data: res.Data}
+		}()
+	}
+	for next < first {
+		readNext()
+	}
+	var hedge <-chan time.Time
+	if pref.Mode == config.ReadPreferenceNearest && pref.HedgeDelay > 0 {
+		timer := time.NewTimer(pref.HedgeDelay)
+		defer timer.Stop()
+		hedge = timer.C
+	}
+
+	read, inflight := 0, next
+	var lastErr error
+	defer func() {
+		// the datanodes still being read took at least that long
+		for _, index := range order[:next] {
+			if !finished[index] {
+				onlineNodes[index].recordUnfinishedRead(time.Since(started[index]))
+			}
+		}
+	}()
+	for read < readQuorum {
+		if read+inflight+len(order)-next < readQuorum {
+			return lastErr
+		}
+		select {
+		case res := <-results:
+			inflight--
+			finished[res.index] = true
+			sn := onlineNodes[res.index]
+			latency := time.Since(started[res.index])
+			if res.err != nil {
+				lastErr = res.err
+				log.Errorw("get error", "datanode", sn.RpcAddress, "key", key, "error", res.err)
+				if st, ok := status.FromError(res.err); ok && st.Code() != codes.Canceled {
+					// repair shard
+					repairIndexes[res.index] = true
+				}
+				if ctx.Err() == nil {
+					if latency < readFailurePenalty {
+						latency = readFailurePenalty
+					}
+					sn.recordReadLatency(latency)
+				}
+				if next < len(order) {
+					readNext()
+					inflight++
+				}
+				continue
+			}
+			sn.recordReadLatency(latency)
+			shards[res.index] = res.data
+			read++
+		case <-hedge:
+			if next < len(order) {
+				readNext()
+				inflight++
+			}
+			hedge = time.After(pref.HedgeDelay)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
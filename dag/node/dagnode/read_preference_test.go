@@ -0,0 +1,157 @@
+package dagnode
+
+import (
+	"bytes"
+	"context"
+	"github.com/filedag-project/filedag-storage/dag/config"
+	"github.com/filedag-project/filedag-storage/dag/node/datanode"
+	"github.com/filedag-project/filedag-storage/dag/proto"
+	blocks "github.com/ipfs/go-block-format"
+	"google.golang.org/grpc"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowDatanode delays the Get rpcs and counts them
+type slowDatanode struct {
+	proto.DataNodeClient
+	delay time.Duration
+	gets  int32
+}
+
+func (s *slowDatanode) Get(ctx context.Context, in *proto.GetRequest, opts ...grpc.CallOption) (*proto.GetResponse, error) {
+	atomic.AddInt32(&s.gets, 1)
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return s.DataNodeClient.Get(ctx, in, opts...)
+}
+
+func newReadPreferenceDagNode(t *testing.T, delays []time.Duration, pref config.ReadPreferenceConfig) (*DagNode, []*slowDatanode) {
+	var clients []*StorageNode
+	var datanodes []*slowDatanode
+	var addrs []string
+	for i, delay := range delays {
+		dn := &slowDatanode{DataNodeClient: newDatanode(t, 2, 1, i), delay: delay}
+		cli := &datanode.Client{
+			DataClient: dn,
+			RpcAddress: "127.0.0.1:" + string(rune('1'+i)),
+		}
+		clients = append(clients, &StorageNode{Client: cli, State: true})
+		datanodes = append(datanodes, dn)
+		addrs = append(addrs, cli.RpcAddress)
+	}
+	d := &DagNode{
+		Nodes: clients,
+		config: config.DagNodeConfig{
+			Name:         t.Name(),
+			Nodes:        addrs,
+			DataBlocks:   2,
+			ParityBlocks: 1,
+		},
+		repairQueue: make(chan func(ctx context.Context), 10),
+	}
+	if err := d.SetReadPreference(pref); err != nil {
+		t.Fatal(err)
+	}
+	return d, datanodes
+}
+
+func TestDagNode_ReadPreferenceSlowNode(t *testing.T) {
+	const slowIndex = 0
+	d, datanodes := newReadPreferenceDagNode(t, []time.Duration{100 * time.Millisecond, 0, 0},
+		config.ReadPreferenceConfig{Mode: config.ReadPreferenceNearest})
+	block := blocks.NewBlock([]byte("123456"))
+	get := func() {
+		b, err := d.Get(context.TODO(), block.Cid())
+		if err != nil {
+			t.Fatalf("get err: %v", err)
+		}
+		if !bytes.Equal(block.RawData(), b.RawData()) {
+			t.Fatal("the block from dagnode is not equal the origin block")
+		}
+	}
+
+	// no datanode has been read, the data shards are read first
+	get()
+	if n := atomic.LoadInt32(&datanodes[slowIndex].gets); n != 1 {
+		t.Fatalf("expected the slow datanode to be read once, but it was read %d times", n)
+	}
+	if atomic.LoadInt32(&datanodes[2].gets) != 0 {
+		t.Fatal("expected the parity shard not to be read")
+	}
+	if d.GetDataNodeReadLatency(slowIndex) < 100*time.Millisecond {
+		t.Fatalf("expected the read latency of the slow datanode to be tracked, but it's %v", d.GetDataNodeReadLatency(slowIndex))
+	}
+
+	// the slow datanode is deprioritized, the shards are read from the other ones
+	for i := 0; i < 5; i++ {
+		get()
+	}
+	if n := atomic.LoadInt32(&datanodes[slowIndex].gets); n != 1 {
+		t.Fatalf("expected the slow datanode not to be read again, but it was read %d times", n)
+	}
+	order := d.readOrder(d.Nodes)
+	if order[len(order)-1] != slowIndex {
+		t.Fatalf("expected the slow datanode to be read last, but the read order is %v", order)
+	}
+
+	// the local datanodes are read first, even the slow one
+	if err := d.SetReadPreference(config.ReadPreferenceConfig{
+		Mode:       config.ReadPreferenceNearest,
+		LocalNodes: []string{d.Nodes[slowIndex].RpcAddress},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if order = d.readOrder(d.Nodes); order[0] != slowIndex {
+		t.Fatalf("expected the local datanode to be read first, but the read order is %v", order)
+	}
+}
+
+func TestDagNode_ReadPreferenceHedge(t *testing.T) {
+	d, datanodes := newReadPreferenceDagNode(t, []time.Duration{time.Minute, 0, 0},
+		config.ReadPreferenceConfig{Mode: config.ReadPreferenceNearest, HedgeDelay: 20 * time.Millisecond})
+	block := blocks.NewBlock([]byte("123456"))
+	start := time.Now()
+	b, err := d.Get(context.TODO(), block.Cid())
+	if err != nil {
+		t.Fatalf("get err: %v", err)
+	}
+	if !bytes.Equal(block.RawData(), b.RawData()) {
+		t.Fatal("the block from dagnode is not equal the origin block")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("expected the hedged read not to wait for the stuck datanode, but it took %v", elapsed)
+	}
+	if atomic.LoadInt32(&datanodes[2].gets) != 1 {
+		t.Fatal("expected the parity shard to be read when the data shard is late")
+	}
+	// the canceled read counts as slow
+	if d.GetDataNodeReadLatency(0) < 20*time.Millisecond {
+		t.Fatalf("expected the stuck datanode to count as slow, but its read latency is %v", d.GetDataNodeReadLatency(0))
+	}
+	if order := d.readOrder(d.Nodes); order[len(order)-1] != 0 {
+		t.Fatalf("expected the stuck datanode to be read last, but the read order is %v", order)
+	}
+}
+
+func TestReadPreferenceConfig_Validate(t *testing.T) {
+	nodes := []string{"127.0.0.1:1", "127.0.0.1:2"}
+	for _, tc := range []struct {
+		cfg   config.ReadPreferenceConfig
+		valid bool
+	}{
+		{cfg: config.ReadPreferenceConfig{}, valid: true},
+		{cfg: config.ReadPreferenceConfig{Mode: config.ReadPreferenceNearest, LocalNodes: nodes[:1], HedgeDelay: time.Millisecond}, valid: true},
+		{cfg: config.ReadPreferenceConfig{Mode: "fastest"}},
+		{cfg: config.ReadPreferenceConfig{Mode: config.ReadPreferenceNearest, LocalNodes: []string{"127.0.0.1:3"}}},
+		{cfg: config.ReadPreferenceConfig{Mode: config.ReadPreferenceNearest, HedgeDelay: -time.Second}},
+	} {
+		if err := tc.cfg.Validate(nodes); (err == nil) != tc.valid {
+			t.Fatalf("%+v: expected valid %v, but got err %v", tc.cfg, tc.valid, err)
+		}
+	}
+}
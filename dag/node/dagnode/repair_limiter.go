@@ -0,0 +1,82 @@
+package dagnode
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/dag/config"
+	"sync"
+	"time"
+)
+
+// rateLimiter paces the repair writes at a rate per second, the writes are spaced evenly
+// rather than sent in bursts, a nil limiter doesn't limit them
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(rate int) *rateLimiter {
+	return &rateLimiter{interval: time.Second / time.Duration(rate)}
+}
+
+// wait blocks until the rate allows a write, it returns the error of the context if it's done first.
+// The turn of a write which isn't sent is given back, the writes after it aren't delayed by it.
+func (rl *rateLimiter) wait(ctx context.Context) error {
+	if rl == nil {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	rl.mu.Lock()
+	now := time.Now()
+	at := rl.next
+	if at.Before(now) {
+		at = now
+	}
+	rl.next = at.Add(rl.interval)
+	rl.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		rl.mu.Lock()
+		rl.next = rl.next.Add(-rl.interval)
+		rl.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// waitRepair blocks until the repair of a shard can be written to the datanode,
+// the datanode is waited for first so that it doesn't hold a turn of the dag node
+func (d *DagNode) waitRepair(ctx context.Context, sn *StorageNode) error {
+	if err := sn.repairLimiter.wait(ctx); err != nil {
+		return err
+	}
+	return d.repairLimiter.wait(ctx)
+}
+
+// GetRepair returns the config of the rate of the repair writes
+func (d *DagNode) GetRepair() config.RepairConfig {
+	return d.repairConfig
+}
+
+// SetRepair changes the rate of the repair writes, it must be called before the DagNode is used
+func (d *DagNode) SetRepair(cfg config.RepairConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	d.repairConfig = cfg
+	d.repairLimiter = newRateLimiter(cfg.Rate)
+	for _, sn := range d.Nodes {
+		sn.repairLimiter = newRateLimiter(cfg.PerNodeRate)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package dagnode
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/dag/config"
+	"github.com/filedag-project/filedag-storage/dag/node/datanode"
+	"github.com/filedag-project/filedag-storage/dag/node/datanode/mocks"
+	"github.com/filedag-project/filedag-storage/dag/proto"
+	"github.com/golang/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newRepairDatanode returns a data node which records the time of the repair writes
+func newRepairDatanode(t *testing.T, mu *sync.Mutex, puts *[]time.Time) *mocks.MockDataNodeClient {
+	ctrl := gomock.NewController(t)
+	m := mocks.NewMockDataNodeClient(ctrl)
+	var ctx = reflect.TypeOf((*context.Context)(nil)).Elem()
+	m.EXPECT().Put(gomock.AssignableToTypeOf(ctx), gomock.AssignableToTypeOf(&proto.AddRequest{})).AnyTimes().
+		DoAndReturn(func(_ context.Context, req *proto.AddRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			*puts = append(*puts, time.Now())
+			return &emptypb.Empty{}, nil
+		})
+	return m
+}
+
+func TestDagNode_RepairRate(t *testing.T) {
+	content := []byte("123456")
+	enc, err := NewErasure(2, 1, int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	shards, err := enc.EncodeData(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		name    string
+		repair  config.RepairConfig
+		indexes func(i int) []int
+		// the repair writes are spaced by the interval
+		repairs  int
+		interval time.Duration
+	}{
+		{
+			name:    "per node rate",
+			repair:  config.RepairConfig{Rate: 1000, PerNodeRate: 20},
+			indexes: func(i int) []int { return []int{0} },
+			repairs: 6, interval: 50 * time.Millisecond,
+		},
+		{
+			name:    "rate of the dag node",
+			repair:  config.RepairConfig{Rate: 40, PerNodeRate: 1000},
+			indexes: func(i int) []int { return []int{i % 3} },
+			repairs: 12, interval: 25 * time.Millisecond,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var mu sync.Mutex
+			puts := make([][]time.Time, 3)
+			var clients []*StorageNode
+			for i := 0; i < 3; i++ {
+				cli := &datanode.Client{
+					DataClient: newRepairDatanode(t, &mu, &puts[i]),
+				}
+				clients = append(clients, &StorageNode{Client: cli, State: true})
+			}
+			var d = DagNode{
+				Nodes: clients,
+				config: config.DagNodeConfig{
+					DataBlocks:   2,
+					ParityBlocks: 1,
+				},
+			}
+			if err := d.SetRepair(testCase.repair); err != nil {
+				t.Fatal(err)
+			}
+
+			start := time.Now()
+			var wg sync.WaitGroup
+			for i := 0; i < testCase.repairs; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					repairShards := make([][]byte, len(shards))
+					copy(repairShards, shards)
+					if err := d.repairBlock(context.TODO(), "key", int32(len(content)), repairShards, testCase.indexes(i)); err != nil {
+						t.Errorf("repair block: %v", err)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			var last time.Time
+			total := 0
+			for _, nodePuts := range puts {
+				total += len(nodePuts)
+				for _, put := range nodePuts {
+					if put.After(last) {
+						last = put
+					}
+				}
+			}
+			if total != testCase.repairs {
+				t.Fatalf("expected %d repair writes, but instead found %d", testCase.repairs, total)
+			}
+			// the first write goes at once, each of the others waits for the interval
+			if elapsed, min := last.Sub(start), time.Duration(testCase.repairs-1)*testCase.interval; elapsed < min {
+				t.Fatalf("the %d repair writes took %v, they must be throttled to at least %v", testCase.repairs, elapsed, min)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_Wait(t *testing.T) {
+	// a nil limiter doesn't limit the writes
+	var rl *rateLimiter
+	if err := rl.wait(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	rl = newRateLimiter(1)
+	if err := rl.wait(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := rl.wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected the error %v, but instead found %v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("the wait must end with the context, it took %v", elapsed)
+	}
+	// the turn of the canceled wait is given back
+	rl.mu.Lock()
+	next := rl.next
+	rl.mu.Unlock()
+	if expected := start.Add(time.Second); next.After(expected) {
+		t.Fatalf("expected the next turn at %v at the latest, but instead found %v", expected, next)
+	}
+	// a done context doesn't take a turn
+	canceled, cancelNow := context.WithCancel(context.TODO())
+	cancelNow()
+	if err := rl.wait(canceled); err != context.Canceled {
+		t.Fatalf("expected the error %v, but instead found %v", context.Canceled, err)
+	}
+	rl.mu.Lock()
+	if !rl.next.Equal(next) {
+		t.Fatalf("expected the next turn at %v, but instead found %v", next, rl.next)
+	}
+	rl.mu.Unlock()
+
+	d := DagNode{}
+	if err := d.SetRepair(config.RepairConfig{Rate: 10}); err == nil {
+		t.Fatal("expected the repair config without a per node rate to be rejected")
+	}
+}
@@ -0,0 +1,58 @@
+package dagnode
+
+import (
+	"context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// diskSpace the total and the free space of the disk of a datanode in bytes
+type diskSpace struct {
+	total uint64
+	free  uint64
+}
+
+// refreshSpace asks the datanode for its disk space, the last known space is kept if it fails
+func (sn *StorageNode) refreshSpace(ctx context.Context) {
+	reply, err := sn.DataClient.Stat(ctx, &emptypb.Empty{})
+	if err != nil {
+		// the datanodes of an older version don't report their space
+		if status.Code(err) != codes.Unimplemented {
+			log.Warnw("failed to stat the datanode", "rpc_address", sn.RpcAddress, "error", err)
+		}
+		return
+	}
+	sn.space.Store(diskSpace{total: reply.Total, free: reply.Free})
+}
+
+// Space returns the total and the free space of the disk of the datanode as of the last heartbeat,
+// ok is false if the datanode has never reported them
+func (sn *StorageNode) Space() (total, free uint64, ok bool) {
+	space, ok := sn.space.Load().(diskSpace)
+	return space.total, space.free, ok
+}
+
+// GetDataNodeSpace returns the total and the free space of the disk of the datanode
+func (d *DagNode) GetDataNodeSpace(setIndex int) (total, free uint64, ok bool) {
+	if setIndex < 0 || setIndex >= len(d.Nodes) {
+		log.Fatalf("input setIndex %v is illegal, size of set is %v", setIndex, len(d.Nodes))
+	}
+	return d.Nodes[setIndex].Space()
+}
+
+// FreeSpace returns the space for the data of the blocks left in the DagNode, ok is false if a datanode
+// has never reported its space. A block is stored as a shard of the same size on each datanode,
+// so the datanode with the least free space is full first and limits the DagNode.
+func (d *DagNode) FreeSpace() (free uint64, ok bool) {
+	for i, sn := range d.Nodes {
+		_, nodeFree, known := sn.Space()
+		if !known {
+			return 0, false
+		}
+		if i == 0 || nodeFree < free {
+			free = nodeFree
+		}
+	}
+	return free * uint64(d.config.DataBlocks), true
+}
@@ -0,0 +1,93 @@
+package dagnode
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/dag/config"
+	"github.com/filedag-project/filedag-storage/dag/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"net"
+	"testing"
+	"time"
+)
+
+// statDataNode is a datanode which only reports its disk space
+type statDataNode struct {
+	proto.UnimplementedDataNodeServer
+	total, free uint64
+}
+
+func (s *statDataNode) Stat(ctx context.Context, _ *emptypb.Empty) (*proto.StatResponse, error) {
+	return &proto.StatResponse{Total: s.total, Free: s.free}, nil
+}
+
+func startStatServer(t *testing.T, total, free uint64) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	proto.RegisterDataNodeServer(s, &statDataNode{total: total, free: free})
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(healthCheckService, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, healthServer)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis.Addr().String()
+}
+
+func TestDagNode_FreeSpace(t *testing.T) {
+	runDagNode := func(nodes []string) *DagNode {
+		d, err := NewDagNode(config.DagNodeConfig{
+			Name:         "dagnode",
+			Nodes:        nodes,
+			DataBlocks:   2,
+			ParityBlocks: 1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(d.Close)
+		if err = d.SetHeartbeat(config.HeartbeatConfig{Interval: 50 * time.Millisecond, Timeout: 50 * time.Millisecond, MaxMisses: 1}); err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.TODO())
+		t.Cleanup(cancel)
+		go d.RunHeartbeatCheck(ctx)
+		for i := 0; i < 100; i++ {
+			if !d.GetDataNodeLastSeen(len(nodes) - 1).IsZero() {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		return d
+	}
+
+	d := runDagNode([]string{startStatServer(t, 1000, 100), startStatServer(t, 1000, 40), startStatServer(t, 500, 70)})
+	for i := 0; i < 100; i++ {
+		if _, ok := d.FreeSpace(); ok {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if total, free, ok := d.GetDataNodeSpace(2); !ok || total != 500 || free != 70 {
+		t.Fatalf("expected the datanode space 500/70, but instead found %d/%d, ok: %v", total, free, ok)
+	}
+	// the datanode with the least free space is full first
+	if free, ok := d.FreeSpace(); !ok || free != 80 {
+		t.Fatalf("expected the free space 80, but instead found %d, ok: %v", free, ok)
+	}
+
+	// a datanode which doesn't report its space leaves the free space unknown
+	addr, _ := startHealthServer(t)
+	d = runDagNode([]string{startStatServer(t, 1000, 100), startStatServer(t, 1000, 40), addr})
+	time.Sleep(3 * d.heartbeat.Interval)
+	if _, _, ok := d.GetDataNodeSpace(2); ok {
+		t.Fatalf("expected the space of the datanode to be unknown")
+	}
+	if _, ok := d.FreeSpace(); ok {
+		t.Fatalf("expected the free space of the dag node to be unknown")
+	}
+}
@@ -176,3 +176,23 @@ func (mr *MockDataNodeClientMockRecorder) Size(arg0, arg1 interface{}, arg2 ...i
 	varargs := append([]interface{}{arg0, arg1}, arg2...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Size", reflect.TypeOf((*MockDataNodeClient)(nil).Size), varargs...)
 }
+
+// Stat mocks base method.
+func (m *MockDataNodeClient) Stat(arg0 context.Context, arg1 *emptypb.Empty, arg2 ...grpc.CallOption) (*proto.StatResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Stat", varargs...)
+	ret0, _ := ret[0].(*proto.StatResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stat indicates an expected call of Stat.
+func (mr *MockDataNodeClientMockRecorder) Stat(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stat", reflect.TypeOf((*MockDataNodeClient)(nil).Stat), varargs...)
+}
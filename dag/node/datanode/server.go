@@ -10,6 +10,7 @@ import (
 	"github.com/filedag-project/filedag-storage/kv/mutcask"
 	"github.com/howeyc/crc16"
 	logging "github.com/ipfs/go-log/v2"
+	"github.com/shirou/gopsutil/disk"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/health"
@@ -43,6 +44,8 @@ const (
 type server struct {
 	proto.UnimplementedDataNodeServer
 	kvdb kv.KVDB
+	// dataDir the directory of the kv, its disk usage is reported by Stat
+	dataDir string
 }
 
 const healthCheckService = "grpc.health.v1.Health"
@@ -168,6 +171,18 @@ func (s *server) AllKeysChan(_ *emptypb.Empty, server proto.DataNode_AllKeysChan
 	return nil
 }
 
+//Stat returns the total and the free space of the disk of the data directory
+func (s *server) Stat(ctx context.Context, _ *emptypb.Empty) (*proto.StatResponse, error) {
+	usage, err := disk.UsageWithContext(ctx, s.dataDir)
+	if err != nil {
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+	return &proto.StatResponse{
+		Total: usage.Total,
+		Free:  usage.Free,
+	}, nil
+}
+
 //func (s *server) Check(ctx context.Context, in *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
 //	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
 //}
@@ -213,7 +228,7 @@ func StartDataNodeServer(listen string, kvType KVType, dataDir string) {
 	}
 	defer kvdb.Close()
 
-	proto.RegisterDataNodeServer(s, &server{kvdb: kvdb})
+	proto.RegisterDataNodeServer(s, &server{kvdb: kvdb, dataDir: dataDir})
 	if err != nil {
 		return
 	}
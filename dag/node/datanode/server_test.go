@@ -7,6 +7,7 @@ import (
 	"github.com/filedag-project/filedag-storage/kv/mutcask"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
 	"testing"
 )
 
@@ -178,3 +179,19 @@ func TestServer_Size(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_Stat(t *testing.T) {
+	ser := server{dataDir: t.TempDir()}
+	res, err := ser.Stat(context.Background(), &emptypb.Empty{})
+	if err != nil {
+		t.Fatalf("Stat err:%v", err)
+	}
+	if res.Total == 0 || res.Free > res.Total {
+		t.Errorf("expected the free space %d within the total space %d", res.Free, res.Total)
+	}
+
+	ser = server{dataDir: t.TempDir() + "/missing"}
+	if _, err = ser.Stat(context.Background(), &emptypb.Empty{}); status.Code(err) != codes.Unknown {
+		t.Errorf("expected the error code %v, got %v", codes.Unknown, err)
+	}
+}
@@ -170,3 +170,62 @@ func CreateLinkInfo(ctx context.Context, dagServ ipld.DAGService, c cid.Cid) (Li
 		FileSize: ftn.FileSize(),
 	}, nil
 }
+
+//AppendNode builds the root of a unixfs file which is the file of root followed by the file of appended,
+//and adds it to the DAGService. The DAGs of root and appended are reused, only the new root is added.
+//When the links of root are reused instead of root itself, the returned replaced is true and
+//the root node is no longer referenced by the new root.
+func AppendNode(ctx context.Context, dagServ ipld.DAGService, cidBuilder cid.Builder, root, appended cid.Cid) (node ipld.Node, replaced bool, err error) {
+	rootNode, err := dagServ.Get(ctx, root)
+	if err != nil {
+		return nil, false, err
+	}
+	pn, ok := rootNode.(*dag.ProtoNode)
+	if !ok {
+		return nil, false, errors.New(fmt.Sprintf("node %s is not ProtoNode", root.String()))
+	}
+	rootFile, err := ft.FSNodeFromBytes(pn.Data())
+	if err != nil {
+		return nil, false, err
+	}
+	appendedInfo, err := CreateLinkInfo(ctx, dagServ, appended)
+	if err != nil {
+		return nil, false, err
+	}
+
+	nd := ft.EmptyFileNode()
+	nd.SetCidBuilder(cidBuilder)
+	od, err := NewUnixfsNodeFromDag(nd)
+	if err != nil {
+		return nil, false, err
+	}
+	links := pn.Links()
+	if len(links) > 0 && len(rootFile.Data()) == 0 && len(links) < unixfsLinksPerLevel {
+		// the root only links the data, link the same blocks from the new root
+		for i, link := range links {
+			if err = od.AddChild(link, rootFile.BlockSize(i)); err != nil {
+				return nil, false, err
+			}
+		}
+		replaced = true
+	} else {
+		rootLink, err := ipld.MakeLink(rootNode)
+		if err != nil {
+			return nil, false, err
+		}
+		if err = od.AddChild(rootLink, rootFile.FileSize()); err != nil {
+			return nil, false, err
+		}
+	}
+	if err = od.AddChild(appendedInfo.Link, appendedInfo.FileSize); err != nil {
+		return nil, false, err
+	}
+	node, err = od.Commit()
+	if err != nil {
+		return nil, false, err
+	}
+	if err = dagServ.Add(ctx, node); err != nil {
+		return nil, false, err
+	}
+	return node, replaced, nil
+}
@@ -0,0 +1,29 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	blocks "github.com/ipfs/go-block-format"
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
+	"golang.org/x/xerrors"
+)
+
+// accessProbe the content of the block written and read back by CheckAccess
+var accessProbe = []byte("filedag-storage pool access check")
+
+// CheckAccess checks the pool user of the client is allowed to write and read the blocks, a probe block is
+// added, read back and removed. ErrAccessDenied is returned if the pool denies any of the requests.
+func CheckAccess(ctx context.Context, bs blockstore.Blockstore) error {
+	blk := blocks.NewBlock(accessProbe)
+	if err := bs.Put(ctx, blk); err != nil {
+		return err
+	}
+	got, err := bs.Get(ctx, blk.Cid())
+	if err == nil && !bytes.Equal(got.RawData(), accessProbe) {
+		err = xerrors.Errorf("the probe block %s read back is corrupted", blk.Cid())
+	}
+	if e := bs.DeleteBlock(ctx, blk.Cid()); e != nil && err == nil {
+		err = e
+	}
+	return err
+}
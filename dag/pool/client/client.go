@@ -12,11 +12,17 @@ import (
 	logging "github.com/ipfs/go-log/v2"
 	"golang.org/x/xerrors"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"strings"
 )
 
 var log = logging.Logger("pool-client")
 
+// ErrAccessDenied the pool user of the client isn't allowed the request on the blocks,
+// its password is wrong or its policy doesn't permit the request
+var ErrAccessDenied = xerrors.New("dag pool access denied")
+
 var _ blockstore.Blockstore = (*dagPoolClient)(nil)
 var _ PoolClient = (*dagPoolClient)(nil)
 
@@ -34,6 +40,8 @@ type dagPoolClient struct {
 	Conn      *grpc.ClientConn
 	User      *proto.PoolUser
 	enablePin bool
+	// compression enables the gzip compression of the blocks transferred with the pool
+	compression bool
 }
 
 func NewBlockService(blkstore blockstore.Blockstore) blockservice.BlockService {
@@ -41,14 +49,14 @@ func NewBlockService(blkstore blockstore.Blockstore) blockservice.BlockService {
 }
 
 //NewPoolClient new a dagPoolClient
-func NewPoolClient(addr, user, password string, enablePin bool) (*dagPoolClient, error) {
+func NewPoolClient(addr, user, password string, enablePin bool, opts ...Option) (*dagPoolClient, error) {
 	conn, err := grpc.Dial(addr, grpc.WithInsecure())
 	if err != nil {
 		log.Errorf("did not connect: %v", err)
 		return nil, err
 	}
 	c := proto.NewDagPoolClient(conn)
-	return &dagPoolClient{
+	p := &dagPoolClient{
 		DPClient: c,
 		Conn:     conn,
 		User: &proto.PoolUser{
@@ -56,7 +64,21 @@ func NewPoolClient(addr, user, password string, enablePin bool) (*dagPoolClient,
 			Password: password,
 		},
 		enablePin: enablePin,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
+// poolError returns the error of a request on the blocks, ErrAccessDenied if the pool denied the request
+// to the pool user of the client
+func (p *dagPoolClient) poolError(err error) error {
+	if st, ok := status.FromError(err); ok && st.Code() == codes.PermissionDenied {
+		log.Errorf("the dag pool denied the request of pool user %s, check its password and policy: %v", p.User.User, st.Message())
+		return xerrors.Errorf("pool user %s: %w", p.User.User, ErrAccessDenied)
+	}
+	return err
 }
 
 //Close  the client
@@ -72,23 +94,22 @@ func (p *dagPoolClient) DeleteBlock(ctx context.Context, cid cid.Cid) error {
 		Unpin: p.enablePin,
 	})
 	if err != nil {
-		return err
+		return p.poolError(err)
 	}
 	log.Debugf("delete sucess %v ", reply.Message)
 	return err
 }
 
-//Has returns if the blockstore has a block with the given key
+//Has returns if the blockstore has a block with the given key, the block isn't read
 func (p *dagPoolClient) Has(ctx context.Context, cid cid.Cid) (bool, error) {
-	_, err := p.GetSize(ctx, cid)
+	reply, err := p.DPClient.Has(ctx, &proto.HasReq{
+		Cid:  cid.String(),
+		User: p.User,
+	})
 	if err != nil {
-		if xerrors.Is(err, format.ErrNotFound{Cid: cid}) {
-			return false, nil
-		}
-		return false, err
+		return false, p.poolError(err)
 	}
-
-	return true, nil
+	return reply.Has, nil
 }
 
 //Get the block with the given key, or nil if not found
@@ -97,12 +118,12 @@ func (p *dagPoolClient) Get(ctx context.Context, cid cid.Cid) (blocks.Block, err
 	get, err := p.DPClient.Get(ctx, &proto.GetReq{
 		Cid:  cid.String(),
 		User: p.User,
-	})
+	}, p.getCallOptions()...)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			return nil, format.ErrNotFound{Cid: cid}
 		}
-		return nil, err
+		return nil, p.poolError(err)
 	}
 	return blocks.NewBlock(get.Block), nil
 }
@@ -117,7 +138,7 @@ func (p *dagPoolClient) GetSize(ctx context.Context, cid cid.Cid) (int, error) {
 		if strings.Contains(err.Error(), "not found") {
 			return 0, format.ErrNotFound{Cid: cid}
 		}
-		return 0, err
+		return 0, p.poolError(err)
 	}
 	return int(reply.Size), nil
 }
@@ -128,9 +149,9 @@ func (p *dagPoolClient) Put(ctx context.Context, blk blocks.Block) error {
 		Block: blk.RawData(),
 		User:  p.User,
 		Pin:   p.enablePin,
-	})
+	}, p.addCallOptions(blk.RawData())...)
 	if err != nil {
-		return err
+		return p.poolError(err)
 	}
 	return nil
 }
@@ -160,8 +181,40 @@ func (p *dagPoolClient) RemoveMany(ctx context.Context, cids []cid.Cid) error {
 	return xerrors.Errorf("implement me")
 }
 
-//AddUser add a user
-func (p *dagPoolClient) AddUser(ctx context.Context, username string, password string, capacity uint64, policy string) error {
+//Pin pin the blocks in a batch
+func (p *dagPoolClient) Pin(ctx context.Context, cids []cid.Cid) (*proto.PinReply, error) {
+	keys := make([]string, len(cids))
+	for i, c := range cids {
+		keys[i] = c.String()
+	}
+	reply, err := p.DPClient.Pin(ctx, &proto.PinReq{
+		Cids: keys,
+		User: p.User,
+	})
+	if err != nil {
+		return nil, p.poolError(err)
+	}
+	return reply, nil
+}
+
+//Unpin unpin the blocks in a batch
+func (p *dagPoolClient) Unpin(ctx context.Context, cids []cid.Cid) (*proto.UnpinReply, error) {
+	keys := make([]string, len(cids))
+	for i, c := range cids {
+		keys[i] = c.String()
+	}
+	reply, err := p.DPClient.Unpin(ctx, &proto.UnpinReq{
+		Cids: keys,
+		User: p.User,
+	})
+	if err != nil {
+		return nil, p.poolError(err)
+	}
+	return reply, nil
+}
+
+//AddUser add a user, a nil capacity means no limit
+func (p *dagPoolClient) AddUser(ctx context.Context, username string, password string, capacity *uint64, policy string) error {
 	_, err := p.DPClient.AddUser(ctx, &proto.AddUserReq{
 		Username: username,
 		Password: password,
@@ -181,8 +234,8 @@ func (p *dagPoolClient) QueryUser(ctx context.Context, username string) (*proto.
 	return reply, err
 }
 
-//UpdateUser update user
-func (p *dagPoolClient) UpdateUser(ctx context.Context, username string, newPassword string, newCapacity uint64, newPolicy string) error {
+//UpdateUser update user, a nil newCapacity leaves the capacity unchanged
+func (p *dagPoolClient) UpdateUser(ctx context.Context, username string, newPassword string, newCapacity *uint64, newPolicy string) error {
 	_, err := p.DPClient.UpdateUser(ctx, &proto.UpdateUserReq{
 		Username:    username,
 		NewPassword: newPassword,
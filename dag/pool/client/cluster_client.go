@@ -122,3 +122,14 @@ func (cli *dagPoolClusterClient) RepairDataNode(ctx context.Context, dagNodeName
 	}
 	return nil
 }
+
+func (cli *dagPoolClusterClient) DrainDagNode(ctx context.Context, dagNodeName string) error {
+	_, err := cli.DPClusterClient.DrainDagNode(ctx, &proto.DrainDagNodeReq{Name: dagNodeName})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.Unknown {
+			return errors.New(st.Message())
+		}
+		return err
+	}
+	return nil
+}
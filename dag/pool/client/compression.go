@@ -0,0 +1,70 @@
+package client
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding/gzip"
+	"math"
+)
+
+const (
+	// minCompressSize is the size under which a block is sent raw, the gzip header outweighs the saving
+	minCompressSize = 512
+	// entropySampleSize is the number of bytes at the head of a block sampled for its entropy
+	entropySampleSize = 4096
+	// maxCompressEntropy is the entropy in bits per byte above which a block is taken as compressed already,
+	// like the chunks of the archives, the images or the videos, gzip would only spend cpu on it
+	maxCompressEntropy = 7.5
+)
+
+// Option configures the dag pool client
+type Option func(p *dagPoolClient)
+
+// WithCompression enables the gzip compression of the blocks transferred between the client and the pool.
+// A block added to the pool is compressed unless it's small or compressed already, a block read from the pool
+// is compressed by the pool whatever its content as the pool replies in the compression of the request.
+// The compression saves the bandwidth of the compressible content at the cost of the cpu of both sides.
+func WithCompression(enabled bool) Option {
+	return func(p *dagPoolClient) {
+		p.compression = enabled
+	}
+}
+
+// addCallOptions returns the call options sending the block to the pool
+func (p *dagPoolClient) addCallOptions(data []byte) []grpc.CallOption {
+	if !p.compression || !compressible(data) {
+		return nil
+	}
+	return []grpc.CallOption{grpc.UseCompressor(gzip.Name)}
+}
+
+// getCallOptions returns the call options reading a block from the pool, the request is compressed
+// so that the pool compresses the block in its reply
+func (p *dagPoolClient) getCallOptions() []grpc.CallOption {
+	if !p.compression {
+		return nil
+	}
+	return []grpc.CallOption{grpc.UseCompressor(gzip.Name)}
+}
+
+// compressible reports whether gzip is worth it for the data, it estimates the entropy of the head of the data
+func compressible(data []byte) bool {
+	if len(data) < minCompressSize {
+		return false
+	}
+	if len(data) > entropySampleSize {
+		data = data[:entropySampleSize]
+	}
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+	entropy := 0.0
+	for _, n := range counts {
+		if n == 0 {
+			continue
+		}
+		p := float64(n) / float64(len(data))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy <= maxCompressEntropy
+}
@@ -0,0 +1,197 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/filedag-project/filedag-storage/dag/pool/mocks"
+	"github.com/filedag-project/filedag-storage/dag/pool/server"
+	"github.com/filedag-project/filedag-storage/dag/proto"
+	"github.com/golang/mock/gomock"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	format "github.com/ipfs/go-ipld-format"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
+	"net"
+	"sync"
+	"testing"
+)
+
+type methodKey struct{}
+
+// wireStats records the payload and wire lengths of the messages of the pool per method
+type wireStats struct {
+	mu     sync.Mutex
+	length map[string]int
+	wire   map[string]int
+}
+
+func (w *wireStats) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.length = make(map[string]int)
+	w.wire = make(map[string]int)
+}
+
+func (w *wireStats) get(method string) (length, wire int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.length[method], w.wire[method]
+}
+
+func (w *wireStats) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, methodKey{}, info.FullMethodName)
+}
+
+func (w *wireStats) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	method, _ := ctx.Value(methodKey{}).(string)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch p := s.(type) {
+	case *stats.InPayload:
+		w.length[method] += p.Length
+		w.wire[method] += p.WireLength
+	case *stats.OutPayload:
+		w.length[method] += p.Length
+		w.wire[method] += p.WireLength
+	}
+}
+
+func (w *wireStats) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (w *wireStats) HandleConn(context.Context, stats.ConnStats) {}
+
+// startPoolServer serves a pool keeping the blocks in memory, it returns the address and the stats of the server
+func startPoolServer(t testing.TB) (string, *wireStats) {
+	ctrl := gomock.NewController(t)
+	m := mocks.NewMockDagPool(ctrl)
+	var mu sync.Mutex
+	blks := make(map[cid.Cid]blocks.Block)
+	m.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(ctx context.Context, blk blocks.Block, user, password string, pin bool) (bool, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			_, ok := blks[blk.Cid()]
+			blks[blk.Cid()] = blk
+			return ok, nil
+		})
+	m.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(ctx context.Context, c cid.Cid, user, password string) (blocks.Block, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			blk, ok := blks[c]
+			if !ok {
+				return nil, format.ErrNotFound{Cid: c}
+			}
+			return blk, nil
+		})
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ws := &wireStats{}
+	ws.reset()
+	s := grpc.NewServer(grpc.StatsHandler(ws))
+	proto.RegisterDagPoolServer(s, &server.DagPoolServer{DagPool: m})
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis.Addr().String(), ws
+}
+
+// textData returns the content compressible like the logs or the documents
+func textData(size int) []byte {
+	var buf bytes.Buffer
+	for i := 0; buf.Len() < size; i++ {
+		fmt.Fprintf(&buf, "%d INFO object stored bucket=testbucket object=logs/%d.txt size=%d\n", i, i%97, i*31%4096)
+	}
+	return buf.Bytes()[:size]
+}
+
+func TestCompressible(t *testing.T) {
+	require.True(t, compressible(textData(64<<10)))
+	require.False(t, compressible(randomData(64<<10)))
+	require.False(t, compressible(textData(minCompressSize-1)))
+}
+
+func TestPoolClient_Compression(t *testing.T) {
+	addr, ws := startPoolServer(t)
+	ctx := context.Background()
+	const (
+		addMethod = "/proto.DagPool/Add"
+		getMethod = "/proto.DagPool/Get"
+	)
+	for _, enabled := range []bool{false, true} {
+		p, err := NewPoolClient(addr, "user", "password", false, WithCompression(enabled))
+		require.NoError(t, err)
+		for _, tc := range []struct {
+			name     string
+			data     []byte
+			compress bool
+		}{
+			{name: "text", data: textData(64 << 10), compress: enabled},
+			{name: "random", data: randomData(64 << 10)},
+			{name: "small", data: textData(minCompressSize - 1)},
+		} {
+			blk := blocks.NewBlock(tc.data)
+			ws.reset()
+			require.NoError(t, p.Put(ctx, blk))
+			length, wire := ws.get(addMethod)
+			if tc.compress {
+				require.Less(t, wire, length/4, "%s block compressed %v", tc.name, enabled)
+			} else {
+				require.Greater(t, wire, length, "%s block compressed %v", tc.name, enabled)
+			}
+
+			got, err := p.Get(ctx, blk.Cid())
+			require.NoError(t, err)
+			require.Equal(t, tc.data, got.RawData())
+			if length, wire = ws.get(getMethod); enabled && tc.name == "text" {
+				require.Less(t, wire, length/4, "%s block compressed %v", tc.name, enabled)
+			} else if !enabled {
+				require.Greater(t, wire, length, "%s block compressed %v", tc.name, enabled)
+			}
+		}
+		p.Close(ctx)
+	}
+}
+
+func BenchmarkPoolClient_Compression(b *testing.B) {
+	addr, ws := startPoolServer(b)
+	ctx := context.Background()
+	for _, data := range []struct {
+		name string
+		data []byte
+	}{
+		{name: "text", data: textData(256 << 10)},
+		{name: "random", data: randomData(256 << 10)},
+	} {
+		blk := blocks.NewBlock(data.data)
+		for _, enabled := range []bool{false, true} {
+			b.Run(fmt.Sprintf("%s/compression=%v", data.name, enabled), func(b *testing.B) {
+				p, err := NewPoolClient(addr, "user", "password", false, WithCompression(enabled))
+				if err != nil {
+					b.Fatal(err)
+				}
+				defer p.Close(ctx)
+				ws.reset()
+				b.SetBytes(2 * int64(len(data.data)))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if err = p.Put(ctx, blk); err != nil {
+						b.Fatal(err)
+					}
+					if _, err = p.Get(ctx, blk.Cid()); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.StopTimer()
+				_, addWire := ws.get("/proto.DagPool/Add")
+				_, getWire := ws.get("/proto.DagPool/Get")
+				b.ReportMetric(float64(addWire+getWire)/float64(b.N), "wire-B/op")
+			})
+		}
+	}
+}
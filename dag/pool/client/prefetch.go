@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"sync"
+)
+
+// DefaultPrefetchConcurrency the number of nodes fetched in parallel by default,
+// the unixfs reader asks for at most 10 child nodes at a time.
+const DefaultPrefetchConcurrency = 8
+
+// prefetchDAGService fetches the nodes of GetMany in parallel, the dag service of NewBlockService
+// gets them one by one, so that the latency of the pool is paid for every block of an object.
+type prefetchDAGService struct {
+	ipld.DAGService
+	concurrency int
+}
+
+// NewPrefetchDAGService returns the dag service which fetches up to concurrency nodes in parallel
+// when several nodes are requested at once, like the unixfs reader does for the children of a node.
+// A concurrency below 2 disables the prefetch.
+func NewPrefetchDAGService(dagServ ipld.DAGService, concurrency int) ipld.DAGService {
+	if concurrency < 2 {
+		return dagServ
+	}
+	return &prefetchDAGService{
+		DAGService:  dagServ,
+		concurrency: concurrency,
+	}
+}
+
+// GetMany returns the nodes in the order they are fetched
+func (p *prefetchDAGService) GetMany(ctx context.Context, ks []cid.Cid) <-chan *ipld.NodeOption {
+	set := cid.NewSet()
+	keys := make(chan cid.Cid, len(ks))
+	for _, c := range ks {
+		if set.Visit(c) {
+			keys <- c
+		}
+	}
+	close(keys)
+
+	out := make(chan *ipld.NodeOption, set.Len())
+	workers := p.concurrency
+	if set.Len() < workers {
+		workers = set.Len()
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range keys {
+				if ctx.Err() != nil {
+					out <- &ipld.NodeOption{Err: ctx.Err()}
+					return
+				}
+				nd, err := p.Get(ctx, c)
+				out <- &ipld.NodeOption{Node: nd, Err: err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/ipfs/go-merkledag"
+	mdtest "github.com/ipfs/go-merkledag/test"
+	ufsio "github.com/ipfs/go-unixfs/io"
+	"io/ioutil"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// latencyBlockstore simulates the round trip of the pool for every block read
+type latencyBlockstore struct {
+	blockstore.Blockstore
+	latency  time.Duration
+	inflight int32
+	peak     int32
+}
+
+func (l *latencyBlockstore) Get(ctx context.Context, c cid.Cid) (blocks.Block, error) {
+	n := atomic.AddInt32(&l.inflight, 1)
+	defer atomic.AddInt32(&l.inflight, -1)
+	for {
+		peak := atomic.LoadInt32(&l.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(&l.peak, peak, n) {
+			break
+		}
+	}
+	time.Sleep(l.latency)
+	return l.Blockstore.Get(ctx, c)
+}
+
+// randomData returns the content whose chunks are all different, the same chunks are a single node
+func randomData(size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(1)).Read(data)
+	return data
+}
+
+func newLatencyDAGService(t testing.TB, data []byte, latency time.Duration, concurrency int) (ipld.DAGService, ipld.Node, *latencyBlockstore) {
+	bstore := &latencyBlockstore{Blockstore: mdtest.Bserv().Blockstore()}
+	cidBuilder, _ := merkledag.PrefixForCidVersion(0)
+	root, err := BalanceNode(bytes.NewReader(data), merkledag.NewDAGService(NewBlockService(bstore)), cidBuilder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bstore.latency = latency
+	return NewPrefetchDAGService(merkledag.NewDAGService(NewBlockService(bstore)), concurrency), root, bstore
+}
+
+func TestNewPrefetchDAGService(t *testing.T) {
+	data := randomData(16 * int(unixfsChunkSize))
+	dagServ, root, bstore := newLatencyDAGService(t, data, time.Millisecond, 4)
+	reader, err := ufsio.NewDagReader(context.TODO(), root, dagServ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("expected %d bytes of content, but instead found %d bytes", len(data), len(got))
+	}
+	if peak := atomic.LoadInt32(&bstore.peak); peak < 2 || peak > 4 {
+		t.Fatalf("expected 2 to 4 nodes fetched in parallel, but instead found %d", peak)
+	}
+}
+
+func BenchmarkPrefetchDAGService(b *testing.B) {
+	data := randomData(32 * int(unixfsChunkSize))
+	for _, bc := range []struct {
+		name        string
+		concurrency int
+	}{
+		{name: "serial", concurrency: 1},
+		{name: "prefetch", concurrency: DefaultPrefetchConcurrency},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			dagServ, root, _ := newLatencyDAGService(b, data, 2*time.Millisecond, bc.concurrency)
+			b.SetBytes(int64(len(data)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				reader, err := ufsio.NewDagReader(context.TODO(), root, dagServ)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err = ioutil.ReadAll(reader); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/ipfs/go-merkledag"
+)
+
+// ReferenceDAG references every block of the dag once more by adding it again, the dag is shared by one
+// more owner then and RemoveDAG must be called once more to release it. If the dag can't be referenced
+// the blocks already added are removed again.
+func ReferenceDAG(ctx context.Context, dagServ ipld.DAGService, root cid.Cid) error {
+	list := make([]cid.Cid, 0, 32)
+	visit := func(c cid.Cid) bool {
+		list = append(list, c)
+		return true
+	}
+	err := merkledag.Walk(
+		ctx, merkledag.GetLinksWithDAG(dagServ), root,
+		visit,
+		merkledag.Concurrent(),
+	)
+	if err != nil {
+		return err
+	}
+	for i, c := range list {
+		node, err := dagServ.Get(ctx, c)
+		if err == nil {
+			err = dagServ.Add(ctx, node)
+		}
+		if err != nil {
+			for _, added := range list[:i] {
+				if e := dagServ.Remove(ctx, added); e != nil {
+					log.Errorf("remove block failed, error: %v", e)
+				}
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -38,11 +38,12 @@ func (m *MockDagPool) EXPECT() *MockDagPoolMockRecorder {
 }
 
 // Add mocks base method.
-func (m *MockDagPool) Add(arg0 context.Context, arg1 blocks.Block, arg2, arg3 string, arg4 bool) error {
+func (m *MockDagPool) Add(arg0 context.Context, arg1 blocks.Block, arg2, arg3 string, arg4 bool) (bool, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Add", arg0, arg1, arg2, arg3, arg4)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Add indicates an expected call of Add.
@@ -109,12 +110,28 @@ func (mr *MockDagPoolMockRecorder) GetSize(arg0, arg1, arg2, arg3 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSize", reflect.TypeOf((*MockDagPool)(nil).GetSize), arg0, arg1, arg2, arg3)
 }
 
+// Has mocks base method.
+func (m *MockDagPool) Has(arg0 context.Context, arg1 cid.Cid, arg2, arg3 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Has", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Has indicates an expected call of Has.
+func (mr *MockDagPoolMockRecorder) Has(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Has", reflect.TypeOf((*MockDagPool)(nil).Has), arg0, arg1, arg2, arg3)
+}
+
 // Pin mocks base method.
-func (m *MockDagPool) Pin(arg0 context.Context, arg1 cid.Cid, arg2, arg3 string) error {
+func (m *MockDagPool) Pin(arg0 context.Context, arg1 []cid.Cid, arg2, arg3 string) ([]error, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Pin", arg0, arg1, arg2, arg3)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].([]error)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Pin indicates an expected call of Pin.
@@ -166,18 +183,19 @@ func (mr *MockDagPoolMockRecorder) RemoveUser(arg0, arg1, arg2 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUser", reflect.TypeOf((*MockDagPool)(nil).RemoveUser), arg0, arg1, arg2)
 }
 
-// UnPin mocks base method.
-func (m *MockDagPool) UnPin(arg0 context.Context, arg1 cid.Cid, arg2, arg3 string) error {
+// Unpin mocks base method.
+func (m *MockDagPool) Unpin(arg0 context.Context, arg1 []cid.Cid, arg2, arg3 string) ([]error, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UnPin", arg0, arg1, arg2, arg3)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "Unpin", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]error)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// UnPin indicates an expected call of UnPin.
-func (mr *MockDagPoolMockRecorder) UnPin(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+// Unpin indicates an expected call of Unpin.
+func (mr *MockDagPoolMockRecorder) Unpin(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnPin", reflect.TypeOf((*MockDagPool)(nil).UnPin), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unpin", reflect.TypeOf((*MockDagPool)(nil).Unpin), arg0, arg1, arg2, arg3)
 }
 
 // UpdateUser mocks base method.
@@ -16,10 +16,14 @@ import (
 
 // DagPool is an interface that defines the basic operations of a dag pool
 type DagPool interface {
-	Add(ctx context.Context, block blocks.Block, user string, password string, pin bool) error
+	// Add stores the block, exists reports whether it was already in the pool so that nothing new was stored
+	Add(ctx context.Context, block blocks.Block, user string, password string, pin bool) (exists bool, err error)
 	Get(ctx context.Context, c cid.Cid, user string, password string) (blocks.Block, error)
 	GetSize(ctx context.Context, c cid.Cid, user string, password string) (int, error)
+	Has(ctx context.Context, c cid.Cid, user string, password string) (bool, error)
 	Remove(ctx context.Context, c cid.Cid, user string, password string, unpin bool) error
+	Pin(ctx context.Context, cids []cid.Cid, user string, password string) ([]error, error)
+	Unpin(ctx context.Context, cids []cid.Cid, user string, password string) ([]error, error)
 	AddUser(newUser dpuser.DagPoolUser, user string, password string) error
 	RemoveUser(rmUser string, user string, password string) error
 	QueryUser(qUser string, user string, password string) (*dpuser.DagPoolUser, error)
@@ -36,4 +40,5 @@ type Cluster interface {
 	BalanceSlots() error
 	Status() (*proto.StatusReply, error)
 	RepairDataNode(ctx context.Context, dagNodeName string, fromNodeIndex int, repairNodeIndex int) error
+	DrainDagNode(dagNodeName string) error
 }
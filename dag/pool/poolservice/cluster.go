@@ -8,9 +8,9 @@ import (
 	"github.com/filedag-project/filedag-storage/dag/node/dagnode"
 	"github.com/filedag-project/filedag-storage/dag/proto"
 	"github.com/filedag-project/filedag-storage/dag/slotsmgr"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
 	"github.com/ipfs/go-cid"
 	format "github.com/ipfs/go-ipld-format"
-	"github.com/syndtr/goleveldb/leveldb"
 	"sort"
 	"time"
 )
@@ -18,6 +18,7 @@ import (
 var ErrDagNodeNotFound = errors.New("this dag node does not exist")
 var ErrDagNodeRemove = errors.New("this dag node still has slots and cannot be removed")
 var ErrClusterMigrating = errors.New("the cluster is migrating")
+var ErrNoDrainTarget = errors.New("there is no other dag node to take the slots")
 
 type MigrateSlot struct {
 	From      string
@@ -34,6 +35,18 @@ func (d *dagPoolService) startNewDagNode(nodeConfig *config.DagNodeConfig) (*dag
 		log.Errorf("new dagnode err:%v", err)
 		return nil, err
 	}
+	if err = dagNode.SetHeartbeat(d.heartbeat); err != nil {
+		dagNode.Close()
+		return nil, err
+	}
+	if err = dagNode.SetBreaker(d.breaker); err != nil {
+		dagNode.Close()
+		return nil, err
+	}
+	if err = dagNode.SetRepair(d.repair); err != nil {
+		dagNode.Close()
+		return nil, err
+	}
 	go dagNode.RunHeartbeatCheck(d.parentCtx)
 	go dagNode.RunRepairTask(d.parentCtx)
 	d.dagNodesMap[nodeConfig.Name] = dagNode
@@ -71,6 +84,107 @@ func (d *dagPoolService) AddDagNode(nodeConfig *config.DagNodeConfig) error {
 	return nil
 }
 
+// ReloadDagNodes applies the dag node configs to the running cluster.
+// The new dag nodes are added and the write concern of the existing ones is updated,
+// the datanodes and the erasure params of an existing dag node can't be changed by a reload.
+// Nothing is applied if a config is rejected. The dag nodes missing in the configs are kept.
+func (d *dagPoolService) ReloadDagNodes(nodeConfigs []config.DagNodeConfig) error {
+	d.dagNodesLock.Lock()
+	defer d.dagNodesLock.Unlock()
+
+	names := make(map[string]struct{}, len(nodeConfigs))
+	var toAdd, toUpdate []config.DagNodeConfig
+	for _, nc := range nodeConfigs {
+		if err := nc.Validate(); err != nil {
+			return fmt.Errorf("dagnode %v: %v", nc.Name, err)
+		}
+		if _, ok := names[nc.Name]; ok {
+			return fmt.Errorf("dagnode %v: %w", nc.Name, ErrDagNodeAlreadyExist)
+		}
+		names[nc.Name] = struct{}{}
+
+		node, ok := d.dagNodesMap[nc.Name]
+		if !ok {
+			toAdd = append(toAdd, nc)
+			continue
+		}
+		old := node.GetConfig()
+		if old.DataBlocks != nc.DataBlocks || old.ParityBlocks != nc.ParityBlocks || !equalNodes(old.Nodes, nc.Nodes) {
+			return fmt.Errorf("dagnode %v: the datanodes and the erasure params can't be changed by a reload", nc.Name)
+		}
+		if old.WriteConcern != nc.WriteConcern {
+			toUpdate = append(toUpdate, nc)
+		}
+	}
+	for name := range d.dagNodesMap {
+		if _, ok := names[name]; !ok {
+			log.Warnw("the dag node is not in the reloaded configs, it's kept", "name", name)
+		}
+	}
+	if len(toAdd) == 0 && len(toUpdate) == 0 {
+		return nil
+	}
+
+	cfg, err := d.loadConfig()
+	if err != nil {
+		return err
+	}
+	var added []string
+	var updated []*config.DagNodeConfig
+	rollback := func() {
+		for _, name := range added {
+			d.dagNodesMap[name].Close()
+			delete(d.dagNodesMap, name)
+		}
+		for _, old := range updated {
+			if errR := d.dagNodesMap[old.Name].SetWriteConcern(old.WriteConcern); errR != nil {
+				log.Warnw("rollback write concern error", "name", old.Name, "error", errR)
+			}
+		}
+	}
+	for i := range toAdd {
+		if _, err = d.startNewDagNode(&toAdd[i]); err != nil {
+			rollback()
+			return err
+		}
+		added = append(added, toAdd[i].Name)
+		cfg.Cluster = append(cfg.Cluster, config.DagNodeInfo{Config: toAdd[i]})
+	}
+	for _, nc := range toUpdate {
+		node := d.dagNodesMap[nc.Name]
+		old := node.GetConfig()
+		if err = node.SetWriteConcern(nc.WriteConcern); err != nil {
+			rollback()
+			return err
+		}
+		updated = append(updated, old)
+		for i := range cfg.Cluster {
+			if cfg.Cluster[i].Config.Name == nc.Name {
+				cfg.Cluster[i].Config.WriteConcern = nc.WriteConcern
+			}
+		}
+	}
+	cfg.Version += 1
+	if err = d.saveConfig(cfg); err != nil {
+		rollback()
+		return err
+	}
+	log.Infow("reload dag nodes", "added", len(toAdd), "updated", len(toUpdate))
+	return nil
+}
+
+func equalNodes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func (d *dagPoolService) GetDagNode(dagNodeName string) (*config.DagNodeConfig, error) {
 	d.dagNodesLock.RLock()
 	defer d.dagNodesLock.RUnlock()
@@ -117,6 +231,7 @@ func (d *dagPoolService) RemoveDagNode(dagNodeName string) (*config.DagNodeConfi
 
 		dagNodeCfg := nd.GetConfig()
 		delete(d.dagNodesMap, dagNodeName)
+		delete(d.drainingNodes, dagNodeName)
 		// close dagnode
 		nd.Close()
 		return dagNodeCfg, nil
@@ -185,13 +300,7 @@ func (d *dagPoolService) migrateSlotsByName(fromDagNodeName, toDagNodeName strin
 
 	// update local config
 	cfg.Version += 1
-	cfg.Cluster = nil
-	for _, node := range d.dagNodesMap {
-		dagNode := config.DagNodeInfo{}
-		dagNode.Config = *node.GetConfig()
-		dagNode.SlotPairs = node.GetSlotPairs()
-		cfg.Cluster = append(cfg.Cluster, dagNode)
-	}
+	cfg.Cluster = d.clusterNodes()
 	if err = d.saveConfig(cfg); err != nil {
 		// rollback
 		rollback()
@@ -202,6 +311,152 @@ func (d *dagPoolService) migrateSlotsByName(fromDagNodeName, toDagNodeName strin
 	return nil
 }
 
+// clusterNodes returns the persisted info of all the dag nodes in order of name
+func (d *dagPoolService) clusterNodes() []config.DagNodeInfo {
+	var nameList []string
+	for name := range d.dagNodesMap {
+		nameList = append(nameList, name)
+	}
+	sort.Strings(nameList)
+	nodes := make([]config.DagNodeInfo, 0, len(nameList))
+	for _, name := range nameList {
+		node := d.dagNodesMap[name]
+		nodes = append(nodes, config.DagNodeInfo{
+			Config:    *node.GetConfig(),
+			SlotPairs: node.GetSlotPairs(),
+			Draining:  d.isDraining(name),
+		})
+	}
+	return nodes
+}
+
+// activeDagNodes returns the names of the dag nodes which are not draining in order
+func (d *dagPoolService) activeDagNodes() []string {
+	var nameList []string
+	for name := range d.dagNodesMap {
+		if !d.isDraining(name) {
+			nameList = append(nameList, name)
+		}
+	}
+	sort.Strings(nameList)
+	return nameList
+}
+
+// DrainDagNode marks the dag node draining and migrates its slots to the other dag nodes,
+// the dag node gets no slot from the balance. The blocks of a slot are still read from
+// the dag node until they are migrated, the dag node is drained and it's safe to remove it
+// once it has no slot and no slot is migrating from it.
+func (d *dagPoolService) DrainDagNode(dagNodeName string) error {
+	d.dagNodesLock.Lock()
+	defer d.dagNodesLock.Unlock()
+
+	if d.state != StateOk {
+		if d.state == StateMigrating {
+			return ErrClusterMigrating
+		}
+		return ErrClusterAvailable
+	}
+	node, ok := d.dagNodesMap[dagNodeName]
+	if !ok {
+		return ErrDagNodeNotFound
+	}
+	var targets []string
+	for _, name := range d.activeDagNodes() {
+		if name != dagNodeName {
+			targets = append(targets, name)
+		}
+	}
+	if len(targets) == 0 {
+		return ErrNoDrainTarget
+	}
+
+	// persist the draining state before the slots are migrated
+	cfg, err := d.loadConfig()
+	if err != nil {
+		return err
+	}
+	_, draining := d.drainingNodes[dagNodeName]
+	d.drainingNodes[dagNodeName] = struct{}{}
+	cfg.Version += 1
+	cfg.Cluster = d.clusterNodes()
+	if err = d.saveConfig(cfg); err != nil {
+		// rollback
+		if !draining {
+			delete(d.drainingNodes, dagNodeName)
+		}
+		return err
+	}
+
+	// spread the slots over the other dag nodes in ranges, so that they own about the same number of slots
+	var slots []uint64
+	for _, pair := range node.GetSlotPairs() {
+		for slot := pair.Start; slot <= pair.End; slot++ {
+			slots = append(slots, slot)
+		}
+	}
+	numSlots := len(slots)
+	total := numSlots
+	for _, name := range targets {
+		total += d.dagNodesMap[name].GetNumSlots()
+	}
+	expected := (total + len(targets) - 1) / len(targets)
+	var migrateSlots []*MigrateSlot
+	for i, name := range targets {
+		quota := expected - d.dagNodesMap[name].GetNumSlots()
+		if i == len(targets)-1 || quota > len(slots) {
+			quota = len(slots)
+		}
+		if quota <= 0 {
+			continue
+		}
+		slotsTmp := slotsmgr.NewSlotsManager()
+		for _, slot := range slots[:quota] {
+			slotsTmp.Set(slot, true)
+		}
+		slots = slots[quota:]
+		migrateSlots = append(migrateSlots, &MigrateSlot{
+			From:      dagNodeName,
+			To:        name,
+			SlotPairs: slotsTmp.ToSlotPair(),
+		})
+	}
+
+	migrated := false
+	for _, migrate := range migrateSlots {
+		if err = d.migrateSlotsByName(migrate.From, migrate.To, migrate.SlotPairs); err != nil {
+			break
+		}
+		migrated = true
+	}
+	if migrated {
+		// start to migrate data
+		select {
+		case d.migratingCh <- struct{}{}:
+		default:
+		}
+	}
+	log.Infow("drain dag node", "name", dagNodeName, "slots", numSlots, "migrations", len(migrateSlots), "error", err)
+	return err
+}
+
+func (d *dagPoolService) isDraining(dagNodeName string) bool {
+	_, ok := d.drainingNodes[dagNodeName]
+	return ok
+}
+
+// isDrained reports whether the draining dag node has no slot and no slot is migrating from it
+func (d *dagPoolService) isDrained(node *dagnode.DagNode) bool {
+	if !d.isDraining(node.GetConfig().Name) || node.GetNumSlots() != 0 {
+		return false
+	}
+	for _, from := range d.importingSlotsFrom {
+		if from == node {
+			return false
+		}
+	}
+	return true
+}
+
 func (d *dagPoolService) migrateSlotsByNode(fromNode, toNode *dagnode.DagNode, pairs []slotsmgr.SlotPair) {
 	for _, pair := range pairs {
 		for slot := pair.Start; slot <= pair.End; slot++ {
@@ -300,6 +555,70 @@ func (d *dagPoolService) migrateSlotsDataTask(ctx context.Context) {
 	}
 }
 
+// slotBalanceTolerance the number of slots a dag node may be off the slots it should own before the balance moves
+// the slots, 1% of the slots, so that the small changes of the free space don't migrate the data back and forth
+const slotBalanceTolerance = slotsmgr.ClusterSlots / 100
+
+// expectedSlots returns the number of slots each of the dag nodes should own, in the order of the names.
+// The slots are split evenly unless the placement is by the free space, then each dag node gets the slots
+// in proportion to its free space, so that the writes spread over the slots by the hashes of the keys fill
+// the emptier dag nodes faster. The split is even if the free space of a dag node is still unknown.
+func (d *dagPoolService) expectedSlots(nameList []string) []int {
+	nodesNum := len(nameList)
+	pieces := make([]int, nodesNum)
+	if d.slotPlacement == config.SlotPlacementFreeSpace {
+		frees := make([]float64, nodesNum)
+		var sum float64
+		known := true
+		for i, name := range nameList {
+			free, ok := d.dagNodesMap[name].FreeSpace()
+			if !ok {
+				log.Warnw("the free space of the dag node is unknown, the slots are split evenly", "dagnode", name)
+				known = false
+				break
+			}
+			frees[i] = float64(free)
+			sum += frees[i]
+		}
+		if known && sum > 0 {
+			// the slots left by the rounding down go to the largest remainders
+			assigned := 0
+			remainders := make([]float64, nodesNum)
+			for i := range nameList {
+				share := frees[i] / sum * slotsmgr.ClusterSlots
+				pieces[i] = int(share)
+				if assigned+pieces[i] > slotsmgr.ClusterSlots {
+					pieces[i] = slotsmgr.ClusterSlots - assigned
+				}
+				remainders[i] = share - float64(pieces[i])
+				assigned += pieces[i]
+			}
+			order := make([]int, nodesNum)
+			for i := range order {
+				order[i] = i
+			}
+			sort.SliceStable(order, func(a, b int) bool {
+				return remainders[order[a]] > remainders[order[b]]
+			})
+			for i := 0; assigned < slotsmgr.ClusterSlots; i++ {
+				pieces[order[i%nodesNum]]++
+				assigned++
+			}
+			return pieces
+		}
+	}
+	piece := slotsmgr.ClusterSlots / nodesNum
+	remain := slotsmgr.ClusterSlots - piece*nodesNum
+	for i := range pieces {
+		pieces[i] = piece
+		if remain > 0 {
+			pieces[i]++
+			remain--
+		}
+	}
+	return pieces
+}
+
 // initSlots Perform the slots allocation for the first time
 func (d *dagPoolService) initSlots() error {
 	cfg, err := d.loadConfig()
@@ -307,27 +626,17 @@ func (d *dagPoolService) initSlots() error {
 		return err
 	}
 
-	// calculate number of slots each dagnode
-	nodesNum := len(d.dagNodesMap)
+	// calculate number of slots each dagnode, the draining dagnodes get no slot
+	nameList := d.activeDagNodes()
+	nodesNum := len(nameList)
 	if nodesNum == 0 {
 		return errors.New("please add the dagnodes first")
 	}
-	piece := slotsmgr.ClusterSlots / nodesNum
-	remind := slotsmgr.ClusterSlots - piece*nodesNum
+	pieces := d.expectedSlots(nameList)
 
-	var nameList []string
-	for name := range d.dagNodesMap {
-		nameList = append(nameList, name)
-	}
-	sort.Strings(nameList)
 	curIndex := 0
-	cfg.Cluster = nil
 	for i := 0; i < nodesNum; i++ {
-		curPiece := piece
-		if remind > 0 {
-			curPiece += 1
-			remind--
-		}
+		curPiece := pieces[i]
 
 		node := d.dagNodesMap[nameList[i]]
 		for start := curIndex; start <= curIndex+curPiece-1; start++ {
@@ -339,14 +648,10 @@ func (d *dagPoolService) initSlots() error {
 			d.slots[start] = node
 		}
 
-		dagNode := config.DagNodeInfo{}
-		dagNode.Config = *node.GetConfig()
-		dagNode.SlotPairs = node.GetSlotPairs()
-		cfg.Cluster = append(cfg.Cluster, dagNode)
-
 		curIndex += curPiece
 	}
 	// save config
+	cfg.Cluster = d.clusterNodes()
 	cfg.Version += 1
 	if err = d.saveConfig(cfg); err != nil {
 		// rollback
@@ -380,19 +685,13 @@ func (d *dagPoolService) BalanceSlots() error {
 		return ErrClusterMigrating
 	}
 
-	// calculate number of slots each dagnode
-	nodesNum := len(d.dagNodesMap)
+	// calculate number of slots each dagnode, the draining dagnodes get no slot
+	nameList := d.activeDagNodes()
+	nodesNum := len(nameList)
 	if nodesNum == 0 {
 		return errors.New("please add the dagnodes first")
 	}
-	piece := slotsmgr.ClusterSlots / nodesNum
-	remain := slotsmgr.ClusterSlots - piece*nodesNum
-
-	var nameList []string
-	for name := range d.dagNodesMap {
-		nameList = append(nameList, name)
-	}
-	sort.Strings(nameList)
+	pieces := d.expectedSlots(nameList)
 
 	// check the slots
 	slotsTmp := slotsmgr.NewSlotsManager()
@@ -420,6 +719,11 @@ func (d *dagPoolService) BalanceSlots() error {
 		}
 	}
 
+	// the placement is kept while the dag nodes are close to the slots they should own
+	if !d.offBalance(nameList, pieces) {
+		return nil
+	}
+
 	type MigrateInfo struct {
 		DagNodeName string
 		NumSlots    int
@@ -427,11 +731,7 @@ func (d *dagPoolService) BalanceSlots() error {
 	availableList := make([]MigrateInfo, 0)
 	requireList := make([]MigrateInfo, 0)
 	for i := 0; i < nodesNum; i++ {
-		expectedPiece := piece
-		if remain > 0 {
-			expectedPiece += 1
-			remain--
-		}
+		expectedPiece := pieces[i]
 		node := d.dagNodesMap[nameList[i]]
 		numSlots := node.GetNumSlots()
 		// Is it necessary to adjust?
@@ -451,6 +751,21 @@ func (d *dagPoolService) BalanceSlots() error {
 		}
 	}
 
+	// the draining dagnodes give all their slots
+	var drainingList []string
+	for name := range d.drainingNodes {
+		drainingList = append(drainingList, name)
+	}
+	sort.Strings(drainingList)
+	for _, name := range drainingList {
+		if numSlots := d.dagNodesMap[name].GetNumSlots(); numSlots > 0 {
+			availableList = append(availableList, MigrateInfo{
+				DagNodeName: name,
+				NumSlots:    numSlots,
+			})
+		}
+	}
+
 	// calculate migrate slots
 	var migrateSlots []*MigrateSlot
 	var available MigrateInfo
@@ -531,6 +846,23 @@ func (d *dagPoolService) BalanceSlots() error {
 	return err
 }
 
+// offBalance reports whether a draining dag node still owns slots or a dag node of the names owns more or less
+// than slotBalanceTolerance slots off the expected pieces, in the order of the names
+func (d *dagPoolService) offBalance(nameList []string, pieces []int) bool {
+	for name := range d.drainingNodes {
+		if d.dagNodesMap[name].GetNumSlots() > 0 {
+			return true
+		}
+	}
+	for i, name := range nameList {
+		diff := d.dagNodesMap[name].GetNumSlots() - pieces[i]
+		if diff > slotBalanceTolerance || diff < -slotBalanceTolerance {
+			return true
+		}
+	}
+	return false
+}
+
 func (d *dagPoolService) Status() (*proto.StatusReply, error) {
 	d.dagNodesLock.RLock()
 	defer d.dagNodesLock.RUnlock()
@@ -553,11 +885,21 @@ func (d *dagPoolService) Status() (*proto.StatusReply, error) {
 		dataNodes := make([]*proto.DataNodeInfo, 0, len(cfg.Nodes))
 		for idx, nd := range cfg.Nodes {
 			state := node.GetDataNodeState(idx)
-			dataNodes = append(dataNodes, &proto.DataNodeInfo{
+			info := &proto.DataNodeInfo{
 				RpcAddress: nd,
 				State:      &state,
-			})
+			}
+			if lastSeen := node.GetDataNodeLastSeen(idx); !lastSeen.IsZero() {
+				ms := lastSeen.UnixNano() / int64(time.Millisecond)
+				info.LastSeen = &ms
+			}
+			if total, free, ok := node.GetDataNodeSpace(idx); ok {
+				info.TotalSpace = &total
+				info.FreeSpace = &free
+			}
+			dataNodes = append(dataNodes, info)
 		}
+		heartbeat := node.GetHeartbeat()
 		st := &proto.DagNodeStatus{
 			Node: &proto.DagNodeInfo{
 				Name:         cfg.Name,
@@ -565,7 +907,12 @@ func (d *dagPoolService) Status() (*proto.StatusReply, error) {
 				DataBlocks:   int32(cfg.DataBlocks),
 				ParityBlocks: int32(cfg.ParityBlocks),
 			},
-			Pairs: newPairs,
+			Pairs:              newPairs,
+			HeartbeatInterval:  heartbeat.Interval.Milliseconds(),
+			HeartbeatTimeout:   heartbeat.Timeout.Milliseconds(),
+			HeartbeatMaxMisses: int32(heartbeat.MaxMisses),
+			Draining:           d.isDraining(cfg.Name),
+			Drained:            d.isDrained(node),
 		}
 		list = append(list, st)
 	}
@@ -578,7 +925,7 @@ func (d *dagPoolService) Status() (*proto.StatusReply, error) {
 func (d *dagPoolService) loadConfig() (*config.ClusterConfig, error) {
 	var cfg config.ClusterConfig
 	if err := d.db.Get(clusterConfig, &cfg); err != nil {
-		if err != leveldb.ErrNotFound {
+		if err != metadb.ErrNotFound {
 			return nil, fmt.Errorf("load cluster config failed, error: %v", err)
 		}
 	}
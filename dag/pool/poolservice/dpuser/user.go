@@ -2,24 +2,36 @@ package dpuser
 
 import (
 	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/dpuser/upolicy"
-	"github.com/filedag-project/filedag-storage/objectservice/uleveldb"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
+	"golang.org/x/xerrors"
 )
 
 //IdentityUserSys identity user sys
 type IdentityUserSys struct {
-	DB           *uleveldb.ULevelDB
+	DB           metadb.DB
 	rootUser     string
 	rootPassword string
+	cache        *userCache
 }
 
 const dagPoolUser = "dagPoolUser/"
 
+//ErrWriteSuspended is the error when the capacity of the user is zero
+var ErrWriteSuspended = xerrors.New("the writes of the user are suspended, the capacity is zero")
+
 //DagPoolUser DagPool User
 type DagPoolUser struct {
 	Username string
 	Password string
 	Policy   upolicy.DagPoolPolicy
-	Capacity uint64
+	// Capacity nil means no limit, zero suspends the writes of the user.
+	// The users stored with the old uint64 Capacity, which was never enforced, have no limit.
+	Capacity *uint64 `msgpack:"WriteCapacity,omitempty"`
+}
+
+//WriteSuspended returns true if the capacity of the user is explicitly set to zero
+func (u *DagPoolUser) WriteSuspended() bool {
+	return u.Capacity != nil && *u.Capacity == 0
 }
 
 //CheckAdmin check user admin policy
@@ -49,6 +61,7 @@ func (i *IdentityUserSys) CheckUser(user, pass string) bool {
 
 // AddUser add user
 func (i *IdentityUserSys) AddUser(user DagPoolUser) error {
+	defer i.cache.invalidate(user.Username)
 	err := i.DB.Put(dagPoolUser+user.Username, user)
 	if err != nil {
 		return err
@@ -58,6 +71,7 @@ func (i *IdentityUserSys) AddUser(user DagPoolUser) error {
 
 // RemoveUser remove user
 func (i *IdentityUserSys) RemoveUser(username string) error {
+	defer i.cache.invalidate(username)
 	err := i.DB.Delete(dagPoolUser + username)
 	if err != nil {
 		return err
@@ -65,18 +79,24 @@ func (i *IdentityUserSys) RemoveUser(username string) error {
 	return nil
 }
 
-// QueryUser query user
+// QueryUser query user, the user is loaded from the cache if it was queried recently
 func (i *IdentityUserSys) QueryUser(username string) (*DagPoolUser, error) {
+	cached, gen, ok := i.cache.get(username)
+	if ok {
+		return cached, nil
+	}
 	var u DagPoolUser
 	err := i.DB.Get(dagPoolUser+username, &u)
 	if err != nil {
 		return nil, err
 	}
+	i.cache.set(u, gen)
 	return &u, nil
 }
 
 // UpdateUser Update user
 func (i *IdentityUserSys) UpdateUser(u DagPoolUser) error {
+	defer i.cache.invalidate(u.Username)
 	err := i.DB.Put(dagPoolUser+u.Username, u)
 	if err != nil {
 		return err
@@ -103,10 +123,11 @@ func (i *IdentityUserSys) CheckUserPolicy(username, pass string, policy upolicy.
 }
 
 //NewIdentityUserSys new identity user sys
-func NewIdentityUserSys(db *uleveldb.ULevelDB, rootUser, rootPassword string) (*IdentityUserSys, error) {
+func NewIdentityUserSys(db metadb.DB, rootUser, rootPassword string) (*IdentityUserSys, error) {
 	return &IdentityUserSys{
 		DB:           db,
 		rootUser:     rootUser,
 		rootPassword: rootPassword,
+		cache:        newUserCache(),
 	}, nil
 }
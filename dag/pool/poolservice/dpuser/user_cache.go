@@ -0,0 +1,81 @@
+package dpuser
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// userCacheTTL how long a loaded user is reused, the blocks of one object are checked in a burst
+	userCacheTTL = 10 * time.Second
+	// maxCachedUsers the max number of users kept at the same time
+	maxCachedUsers = 4096
+)
+
+type cachedUser struct {
+	user    DagPoolUser
+	expires time.Time
+}
+
+// userCache keeps the recently loaded users, so that checking the user of each block
+// doesn't read the db. The entry of a user is removed when the user is added, updated or removed.
+type userCache struct {
+	mu    sync.RWMutex
+	users map[string]*cachedUser
+	// gen is increased by each invalidation, a user loaded before an invalidation is not cached
+	gen uint64
+}
+
+func newUserCache() *userCache {
+	return &userCache{users: make(map[string]*cachedUser)}
+}
+
+// get returns a copy of the cached user, or false if it's not cached or expired.
+// The generation is passed to set when the user is loaded from the db.
+func (c *userCache) get(username string) (*DagPoolUser, uint64, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	cu, ok := c.users[username]
+	if !ok || time.Now().After(cu.expires) {
+		return nil, c.gen, false
+	}
+	u := cu.user
+	return &u, c.gen, true
+}
+
+// set caches the user loaded at the generation gen, it's not cached when an invalidation
+// happened since or too many users are cached
+func (c *userCache) set(u DagPoolUser, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	if len(c.users) >= maxCachedUsers {
+		c.removeExpired()
+		if len(c.users) >= maxCachedUsers {
+			return
+		}
+	}
+	c.users[u.Username] = &cachedUser{
+		user:    u,
+		expires: time.Now().Add(userCacheTTL),
+	}
+}
+
+// invalidate removes the cached user
+func (c *userCache) invalidate(username string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.users, username)
+	c.gen++
+}
+
+func (c *userCache) removeExpired() {
+	now := time.Now()
+	for username, cu := range c.users {
+		if now.After(cu.expires) {
+			delete(c.users, username)
+		}
+	}
+}
@@ -25,7 +25,6 @@ func TestIdentityUserSys_AddUser(t *testing.T) {
 		Username: "test",
 		Password: "test123",
 		Policy:   upolicy.ReadWrite,
-		Capacity: 0,
 	})
 	if err != nil {
 		t.Fatalf("AddUser %v", err)
@@ -43,7 +42,6 @@ func TestIdentityUserSys_QueryUser(t *testing.T) {
 		Username: "test",
 		Password: "test123",
 		Policy:   upolicy.ReadWrite,
-		Capacity: 0,
 	})
 	if err != nil {
 		t.Fatalf("AddUser %v", err)
@@ -71,7 +69,6 @@ func TestIdentityUserSys_RemoveUser(t *testing.T) {
 		Username: "test",
 		Password: "test123",
 		Policy:   upolicy.ReadWrite,
-		Capacity: 0,
 	})
 	if err != nil {
 		t.Fatalf("AddUser %v", err)
@@ -105,7 +102,6 @@ func TestIdentityUserSys_UpdateUser(t *testing.T) {
 		Username: "test",
 		Password: "test123",
 		Policy:   upolicy.ReadWrite,
-		Capacity: 0,
 	})
 	if err != nil {
 		t.Fatalf("AddUser %v", err)
@@ -121,7 +117,6 @@ func TestIdentityUserSys_UpdateUser(t *testing.T) {
 		Username: "test",
 		Password: "test456",
 		Policy:   upolicy.ReadOnly,
-		Capacity: 0,
 	})
 	if err != nil {
 		t.Fatalf("UpdateUser %v", err)
@@ -149,7 +144,6 @@ func TestIdentityUserSys_CheckUserPolicy(t *testing.T) {
 		Username: "test",
 		Password: "test123",
 		Policy:   upolicy.ReadWrite,
-		Capacity: 0,
 	})
 	if err != nil {
 		t.Fatalf("AddUser %v", err)
@@ -177,7 +171,6 @@ func TestIdentityUserSys_CheckUserPolicy(t *testing.T) {
 		Username: "test",
 		Password: "test123",
 		Policy:   upolicy.WriteOnly,
-		Capacity: 0,
 	})
 	if err != nil {
 		t.Fatalf("AddUser %v", err)
@@ -204,7 +197,6 @@ func TestIdentityUserSys_CheckUserPolicy(t *testing.T) {
 		Username: "test",
 		Password: "test123",
 		Policy:   upolicy.ReadOnly,
-		Capacity: 0,
 	})
 	if err != nil {
 		t.Fatalf("AddUser %v", err)
@@ -228,3 +220,105 @@ func TestIdentityUserSys_CheckUserPolicy(t *testing.T) {
 	}
 
 }
+
+func TestIdentityUserSys_QueryLegacyUser(t *testing.T) {
+	sys, err := newTestIdentityUserSys(t)
+	if err != nil {
+		t.Fatalf("newTestIdentityUserSys %v", err)
+		return
+	}
+	// the users stored before the capacity could be suspended
+	legacy := struct {
+		Username string
+		Password string
+		Policy   upolicy.DagPoolPolicy
+		Capacity uint64
+	}{
+		Username: "test",
+		Password: "test123",
+		Policy:   upolicy.ReadWrite,
+	}
+	if err = sys.DB.Put(dagPoolUser+legacy.Username, legacy); err != nil {
+		t.Fatalf("Put %v", err)
+		return
+	}
+	user, err := sys.QueryUser("test")
+	if err != nil {
+		t.Fatalf("QueryUser %v", err)
+		return
+	}
+	if user.Capacity != nil || user.WriteSuspended() {
+		t.Fatalf("the legacy user should have no limit")
+	}
+}
+
+func TestIdentityUserSys_CheckUserPolicyCache(t *testing.T) {
+	sys, err := newTestIdentityUserSys(t)
+	if err != nil {
+		t.Fatalf("newTestIdentityUserSys %v", err)
+		return
+	}
+	err = sys.AddUser(DagPoolUser{
+		Username: "test",
+		Password: "test123",
+		Policy:   upolicy.ReadWrite,
+	})
+	if err != nil {
+		t.Fatalf("AddUser %v", err)
+		return
+	}
+	if !sys.CheckUserPolicy("test", "test123", upolicy.WriteOnly) {
+		t.Fatalf("the user should be allowed to write")
+	}
+	if _, _, ok := sys.cache.get("test"); !ok {
+		t.Fatalf("the user should be cached")
+	}
+	err = sys.UpdateUser(DagPoolUser{
+		Username: "test",
+		Password: "test456",
+		Policy:   upolicy.ReadOnly,
+	})
+	if err != nil {
+		t.Fatalf("UpdateUser %v", err)
+		return
+	}
+	if sys.CheckUserPolicy("test", "test456", upolicy.WriteOnly) {
+		t.Fatalf("the updated policy should deny the writes")
+	}
+	if sys.CheckUserPolicy("test", "test123", upolicy.ReadOnly) {
+		t.Fatalf("the old password should be denied")
+	}
+	if !sys.CheckUserPolicy("test", "test456", upolicy.ReadOnly) {
+		t.Fatalf("the user should be allowed to read")
+	}
+	if err = sys.RemoveUser("test"); err != nil {
+		t.Fatalf("RemoveUser %v", err)
+		return
+	}
+	if sys.CheckUserPolicy("test", "test456", upolicy.ReadOnly) {
+		t.Fatalf("the removed user should be denied")
+	}
+}
+
+func BenchmarkIdentityUserSys_CheckUserPolicy(b *testing.B) {
+	db, _ := uleveldb.OpenDb(b.TempDir())
+	defer db.Close()
+	sys, err := NewIdentityUserSys(db, "pool", "pool123")
+	if err != nil {
+		b.Fatalf("NewIdentityUserSys %v", err)
+	}
+	err = sys.AddUser(DagPoolUser{
+		Username: "test",
+		Password: "test123",
+		Policy:   upolicy.ReadWrite,
+	})
+	if err != nil {
+		b.Fatalf("AddUser %v", err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !sys.CheckUserPolicy("test", "test123", upolicy.WriteOnly) {
+			b.Fatalf("the user should be allowed to write")
+		}
+	}
+}
@@ -2,10 +2,18 @@ package poolservice
 
 import (
 	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
 	"github.com/ipfs/go-cid"
 	"time"
 )
 
+const (
+	// gcCursorKey the last cache key evaluated by an interrupted GC, the next GC resumes after it
+	gcCursorKey = "gc/cursor"
+	// gcCursorSaveInterval the keys evaluated between the saves of the cursor during a GC
+	gcCursorSaveInterval = 1000
+)
+
 //GC is a goroutine to do GC
 func (d *dagPoolService) GC(ctx context.Context) {
 	timer := time.NewTimer(d.gcPeriod)
@@ -50,41 +58,100 @@ func (d *dagPoolService) GC(ctx context.Context) {
 }
 
 func (d *dagPoolService) runGC(ctx context.Context) error {
-	keys, err := d.cacheSet.AllKeysChan(ctx)
+	// an interrupted GC resumes after the last key it evaluated instead of rescanning the cache set.
+	// Every key is checked again when it's reached, a key pinned during the pause is kept, and the
+	// keys added before the cursor during the pause are left to the next pass.
+	cursor, err := d.getGCCursor()
+	if err != nil {
+		return err
+	}
+	if cursor != "" {
+		log.Infow("resume GC", "cursor", cursor)
+	}
+	keys, err := d.cacheSet.AllKeysChan(ctx, cursor)
 	if err != nil {
 		return err
 	}
 
+	evaluated := 0
 	for key := range keys {
-		// is pinned?
-		if has, err := d.refCounter.Has(key); err != nil {
+		if err = d.collectGarbage(ctx, key); err != nil {
+			if e := d.setGCCursor(cursor); e != nil {
+				log.Errorw("save GC cursor error", "cursor", cursor, "error", e)
+			}
 			return err
-		} else if has {
-			continue
 		}
-
-		blkCid, err := cid.Decode(key)
-		if err != nil {
-			log.Warnw("decode cid error", "cid", key, "error", err)
-			continue
-		}
-		if err = d.cacheSet.Remove(key); err != nil {
-			log.Warnw("remove cache key error", "cid", key, "error", err)
-			continue
+		// the key whose deletion is interrupted is evaluated again
+		if ctx.Err() != nil {
+			break
 		}
-		log.Infow("delete block", "cid", key)
-		if err = d.deleteBlock(ctx, blkCid); err != nil {
-			if err := d.cacheSet.Add(key); err != nil {
-				log.Errorw("rollback cache key error", "cid", key, "error", err)
+		cursor = key
+		evaluated++
+		// the cursor is saved now and then, a GC stopped by a restart resumes near where it stopped
+		if evaluated%gcCursorSaveInterval == 0 {
+			if err = d.setGCCursor(cursor); err != nil {
+				return err
 			}
+		}
+	}
+	if ctx.Err() != nil {
+		log.Infow("GC interrupted", "cursor", cursor)
+		return d.setGCCursor(cursor)
+	}
+	// the pass is complete, the next GC starts from the first key
+	return d.db.Delete(gcCursorKey)
+}
 
-			log.Warnw("delete block data error", "cid", key, "error", err)
-			continue
+// collectGarbage deletes the block of the key if it isn't pinned, only the errors of the
+// reference counter stop the GC
+func (d *dagPoolService) collectGarbage(ctx context.Context, key string) error {
+	// is pinned?
+	if has, err := d.refCounter.Has(key); err != nil {
+		return err
+	} else if has {
+		return nil
+	}
+
+	blkCid, err := cid.Decode(key)
+	if err != nil {
+		log.Warnw("decode cid error", "cid", key, "error", err)
+		return nil
+	}
+	if err = d.cacheSet.Remove(key); err != nil {
+		log.Warnw("remove cache key error", "cid", key, "error", err)
+		return nil
+	}
+	log.Infow("delete block", "cid", key)
+	if err = d.deleteBlock(ctx, blkCid); err != nil {
+		if err := d.cacheSet.Add(key); err != nil {
+			log.Errorw("rollback cache key error", "cid", key, "error", err)
 		}
+
+		log.Warnw("delete block data error", "cid", key, "error", err)
 	}
 	return nil
 }
 
+// getGCCursor returns the last key evaluated by the interrupted GC, empty if the last GC completed
+func (d *dagPoolService) getGCCursor() (string, error) {
+	var cursor string
+	if err := d.db.Get(gcCursorKey, &cursor); err != nil {
+		if err == metadb.ErrNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	return cursor, nil
+}
+
+// setGCCursor saves the last key evaluated by the GC
+func (d *dagPoolService) setGCCursor(cursor string) error {
+	if cursor == "" {
+		return nil
+	}
+	return d.db.Put(gcCursorKey, cursor)
+}
+
 func (d *dagPoolService) InterruptGC() {
 	d.gcControl.WaitInterrupt()
 }
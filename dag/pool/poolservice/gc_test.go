@@ -3,9 +3,13 @@ package poolservice
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"github.com/filedag-project/filedag-storage/dag/config"
 	"github.com/filedag-project/filedag-storage/objectservice/utils"
 	blocks "github.com/ipfs/go-block-format"
 	"github.com/ipfs/go-cid"
+	"sort"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -58,11 +62,11 @@ func Test_Gc(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			//these are operations
 			interruptGC = false
-			err := service.Add(context.TODO(), tc.theBlockDataAdded, user, pass, tc.isPinWhenAddFile)
+			_, err := service.Add(context.TODO(), tc.theBlockDataAdded, user, pass, tc.isPinWhenAddFile)
 			if err != nil {
 				t.Fatalf("add block err:%v", err)
 			}
-			err = service.Add(context.TODO(), tc.theBlockDataAddedToInterruptGC, user, pass, tc.isPinWhenAddFile)
+			_, err = service.Add(context.TODO(), tc.theBlockDataAddedToInterruptGC, user, pass, tc.isPinWhenAddFile)
 			if err != nil {
 				t.Fatalf("add block err:%v", err)
 			}
@@ -125,7 +129,7 @@ func (d *dagPoolService) GCTest(ctx context.Context) {
 
 //IExactly the same logic as runGC, just increase the deletion time to test the GC interruption problem
 func (d *dagPoolService) runGCTest(ctx context.Context) error {
-	keys, err := d.cacheSet.AllKeysChan(ctx)
+	keys, err := d.cacheSet.AllKeysChan(ctx, "")
 	if err != nil {
 		return err
 	}
@@ -165,3 +169,138 @@ func (d *dagPoolService) runGCTest(ctx context.Context) error {
 	}
 	return nil
 }
+
+func TestDagPoolService_ResumeGC(t *testing.T) {
+	cfg := config.PoolConfig{
+		LeveldbPath:  t.TempDir(),
+		RootUser:     "dagpool",
+		RootPassword: "dagpool",
+		GcPeriod:     time.Hour,
+	}
+	service, err := NewDagPoolService(context.TODO(), cfg)
+	if err != nil {
+		t.Fatalf("NewDagPoolService err:%v", err)
+	}
+	defer service.Close()
+	var dataNodes []*memDataNode
+	var addrs []string
+	for i := 0; i < 3; i++ {
+		addr, dn, _ := startMemDataNode(t)
+		addrs = append(addrs, addr)
+		dataNodes = append(dataNodes, dn)
+	}
+	err = service.AddDagNode(&config.DagNodeConfig{
+		Name:         "dagnode1",
+		Nodes:        addrs,
+		DataBlocks:   2,
+		ParityBlocks: 1,
+	})
+	if err != nil {
+		t.Fatalf("AddDagNode err:%v", err)
+	}
+	if err = service.BalanceSlots(); err != nil {
+		t.Fatalf("BalanceSlots err:%v", err)
+	}
+
+	ctx, cancelGC := context.WithCancel(context.TODO())
+	defer cancelGC()
+	// a pin waits for the GC loop to be interrupted, the runs of the test are called directly
+	go service.GC(ctx)
+	addBlock := func(blk blocks.Block, pin bool) {
+		if _, err := service.Add(ctx, blk, cfg.RootUser, cfg.RootPassword, pin); err != nil {
+			t.Fatalf("Add err:%v", err)
+		}
+	}
+	cached := func(key string) bool {
+		has, err := service.cacheSet.Has(key)
+		if err != nil {
+			t.Fatalf("Has err:%v", err)
+		}
+		return has
+	}
+	var keys []string
+	for i := 0; i < 20; i++ {
+		blk := blocks.NewBlock([]byte(fmt.Sprintf("unpinned block %d", i)))
+		addBlock(blk, false)
+		keys = append(keys, blk.Cid().String())
+	}
+	sort.Strings(keys)
+
+	// the GC is interrupted once the data of a few blocks is deleted
+	gcCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var deletes int32
+	for _, dn := range dataNodes {
+		dn.setOnDelete(func() {
+			if atomic.AddInt32(&deletes, 1) == 10 {
+				cancel()
+			}
+		})
+	}
+	if err = service.runGC(gcCtx); err != nil {
+		t.Fatalf("runGC err:%v", err)
+	}
+	for _, dn := range dataNodes {
+		dn.setOnDelete(nil)
+	}
+	cursor, err := service.getGCCursor()
+	if err != nil {
+		t.Fatalf("getGCCursor err:%v", err)
+	}
+	if cursor == "" || cursor == keys[len(keys)-1] {
+		t.Fatalf("the interrupted GC must save its cursor, found %q", cursor)
+	}
+	for _, key := range keys {
+		if key <= cursor && cached(key) {
+			t.Fatalf("the key %v before the cursor %v must be collected", key, cursor)
+		}
+	}
+	if !cached(keys[len(keys)-1]) {
+		t.Fatalf("the keys after the cursor %v must be left to the resumed GC", cursor)
+	}
+
+	// the keys change during the pause: a key before the cursor is added and a key after it is pinned
+	var early blocks.Block
+	for i := 0; early == nil; i++ {
+		blk := blocks.NewBlock([]byte(fmt.Sprintf("block added during the pause %d", i)))
+		if blk.Cid().String() < cursor {
+			early = blk
+		}
+	}
+	addBlock(early, false)
+	pinned := keys[len(keys)-1]
+	pinnedCid, _ := cid.Decode(pinned)
+	if _, err = service.Pin(ctx, []cid.Cid{pinnedCid}, cfg.RootUser, cfg.RootPassword); err != nil {
+		t.Fatalf("Pin err:%v", err)
+	}
+
+	// the resumed GC only evaluates the keys after the cursor
+	if err = service.runGC(ctx); err != nil {
+		t.Fatalf("runGC err:%v", err)
+	}
+	if cursor, err = service.getGCCursor(); err != nil || cursor != "" {
+		t.Fatalf("the completed GC must clear its cursor, found %q %v", cursor, err)
+	}
+	if !cached(early.Cid().String()) {
+		t.Fatalf("the key added before the cursor is left to the next pass")
+	}
+	for _, key := range keys {
+		if key != pinned && cached(key) {
+			t.Fatalf("the key %v must be collected", key)
+		}
+	}
+	if has, err := service.refCounter.Has(pinned); err != nil || !has {
+		t.Fatalf("the key pinned during the pause must be kept, %v", err)
+	}
+	if _, err = service.Get(ctx, pinnedCid, cfg.RootUser, cfg.RootPassword); err != nil {
+		t.Fatalf("the block pinned during the pause must be readable, %v", err)
+	}
+
+	// the next pass starts from the first key
+	if err = service.runGC(ctx); err != nil {
+		t.Fatalf("runGC err:%v", err)
+	}
+	if cached(early.Cid().String()) {
+		t.Fatalf("the key added before the cursor must be collected by the next pass")
+	}
+}
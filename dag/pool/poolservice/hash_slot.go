@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/filedag-project/filedag-storage/dag/config"
 	"github.com/filedag-project/filedag-storage/dag/node/dagnode"
 	"github.com/filedag-project/filedag-storage/dag/slotsmgr"
 	"github.com/howeyc/crc16"
@@ -26,12 +27,18 @@ func (d *dagPoolService) clusterInit() error {
 	if err != nil {
 		return err
 	}
+	if err = checkClusterConfig(cfg); err != nil {
+		return err
+	}
 
 	for _, dagNodeConfig := range cfg.Cluster {
 		dagNode, err := d.startNewDagNode(&dagNodeConfig.Config)
 		if err != nil {
 			return err
 		}
+		if dagNodeConfig.Draining {
+			d.drainingNodes[dagNodeConfig.Config.Name] = struct{}{}
+		}
 		for _, pair := range dagNodeConfig.SlotPairs {
 			for idx := pair.Start; idx <= pair.End; idx++ {
 				if err := d.addSlot(dagNode, idx); err != nil {
@@ -48,6 +55,10 @@ func (d *dagPoolService) clusterInit() error {
 	}
 	for entry := range ch {
 		if dagNode, ok := d.dagNodesMap[entry.Value]; ok {
+			if d.slots[entry.Slot] == dagNode {
+				log.Warnw("the slot is migrating from the dag node which owns it, skip the migration", "slot", entry.Slot, "dagnode", entry.Value)
+				continue
+			}
 			d.importingSlotsFrom[entry.Slot] = dagNode
 			d.state = StateMigrating
 		} else {
@@ -55,6 +66,10 @@ func (d *dagPoolService) clusterInit() error {
 		}
 	}
 
+	for name, node := range d.dagNodesMap {
+		_, draining := d.drainingNodes[name]
+		log.Infow("restore dag node", "name", name, "version", cfg.Version, "slots", node.GetNumSlots(), "draining", draining)
+	}
 	if !d.checkAllSlots() {
 		d.state = StateFail
 		log.Warn("please allocate all the slots")
@@ -70,6 +85,32 @@ func (d *dagPoolService) clusterInit() error {
 	return nil
 }
 
+// checkClusterConfig makes sure the persisted cluster config is consistent
+// before the dag nodes are started and the slots are assigned to them.
+func checkClusterConfig(cfg *config.ClusterConfig) error {
+	names := make(map[string]struct{}, len(cfg.Cluster))
+	owners := make(map[uint64]string)
+	for _, info := range cfg.Cluster {
+		name := info.Config.Name
+		if _, ok := names[name]; ok {
+			return fmt.Errorf("the persisted cluster config has a duplicate dag node: %v", name)
+		}
+		names[name] = struct{}{}
+		for _, pair := range info.SlotPairs {
+			if pair.Start > pair.End || pair.End >= slotsmgr.ClusterSlots {
+				return fmt.Errorf("the persisted slots %v of the dag node %v are out of range", pair, name)
+			}
+			for slot := pair.Start; slot <= pair.End; slot++ {
+				if owner, ok := owners[slot]; ok {
+					return fmt.Errorf("the persisted slot %d is owned by both %v and %v", slot, owner, name)
+				}
+				owners[slot] = name
+			}
+		}
+	}
+	return nil
+}
+
 func (d *dagPoolService) checkAllSlots() bool {
 	for _, node := range d.slots {
 		if node == nil {
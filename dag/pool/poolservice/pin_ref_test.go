@@ -114,13 +114,13 @@ func TestPinAndReference(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			//Depending on the number of times for each operation, we perform the corresponding operation
 			for i := 0; i < tc.theNumberOfTimesAFileWasAddedByPin; i++ {
-				err := service.Add(ctx, tc.theBlockDataWeAdded, user, pass, true)
+				_, err := service.Add(ctx, tc.theBlockDataWeAdded, user, pass, true)
 				if err != nil {
 					t.Fatal(err)
 				}
 			}
 			for i := 0; i < tc.theNumberOfTimesAFileWasAddedWithoutPin; i++ {
-				err := service.Add(ctx, tc.theBlockDataWeAdded, user, pass, false)
+				_, err := service.Add(ctx, tc.theBlockDataWeAdded, user, pass, false)
 				if err != nil {
 					t.Fatal(err)
 				}
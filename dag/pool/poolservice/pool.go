@@ -11,6 +11,8 @@ import (
 	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/slotkeyrepo"
 	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/slotmigraterepo"
 	"github.com/filedag-project/filedag-storage/dag/slotsmgr"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb/memdb"
 	"github.com/filedag-project/filedag-storage/objectservice/uleveldb"
 	blocks "github.com/ipfs/go-block-format"
 	"github.com/ipfs/go-cid"
@@ -53,13 +55,15 @@ type dagPoolService struct {
 
 	dagNodesMap  map[string]*dagnode.DagNode
 	dagNodesLock sync.RWMutex
+	// drainingNodes the dag nodes whose slots are migrated to the other dag nodes
+	drainingNodes map[string]struct{}
 
 	state       ClusterState
 	parentCtx   context.Context
 	migratingCh chan struct{}
 
 	iam *dpuser.IdentityUserSys
-	db  *uleveldb.ULevelDB
+	db  metadb.DB
 
 	refCounter      *reference.RefCounter
 	cacheSet        *reference.CacheSet
@@ -68,11 +72,49 @@ type dagPoolService struct {
 
 	gcControl *GcControl
 	gcPeriod  time.Duration
+	// heartbeat the health checks of the datanodes of the dag nodes
+	heartbeat config.HeartbeatConfig
+	// breaker the retries and the circuit breakers of the rpcs to the datanodes of the dag nodes
+	breaker config.BreakerConfig
+	// repair the rate of the repair writes to the datanodes of the dag nodes
+	repair config.RepairConfig
+	// slotPlacement how the balance spreads the slots over the dag nodes
+	slotPlacement string
 }
 
 // NewDagPoolService constructs a new DAGPool (using the default implementation).
 func NewDagPoolService(ctx context.Context, cfg config.PoolConfig) (*dagPoolService, error) {
-	db, err := uleveldb.OpenDb(cfg.LeveldbPath)
+	if cfg.Heartbeat == (config.HeartbeatConfig{}) {
+		cfg.Heartbeat = config.DefaultHeartbeatConfig()
+	}
+	if err := cfg.Heartbeat.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Breaker == (config.BreakerConfig{}) {
+		cfg.Breaker = config.DefaultBreakerConfig()
+	}
+	if err := cfg.Breaker.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Repair == (config.RepairConfig{}) {
+		cfg.Repair = config.DefaultRepairConfig()
+	}
+	if err := cfg.Repair.Validate(); err != nil {
+		return nil, err
+	}
+	if err := config.ValidateSlotPlacement(cfg.SlotPlacement); err != nil {
+		return nil, err
+	}
+	var db metadb.DB
+	var err error
+	switch cfg.MetaBackend {
+	case "", metadb.BackendLevelDB:
+		db, err = uleveldb.OpenDb(cfg.LeveldbPath)
+	case metadb.BackendMemory:
+		db = memdb.New()
+	default:
+		err = xerrors.Errorf("unknown metadata backend %q", cfg.MetaBackend)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -85,6 +127,7 @@ func NewDagPoolService(ctx context.Context, cfg config.PoolConfig) (*dagPoolServ
 
 	serv := &dagPoolService{
 		dagNodesMap:     make(map[string]*dagnode.DagNode),
+		drainingNodes:   make(map[string]struct{}),
 		parentCtx:       ctx,
 		migratingCh:     make(chan struct{}),
 		iam:             i,
@@ -95,11 +138,16 @@ func NewDagPoolService(ctx context.Context, cfg config.PoolConfig) (*dagPoolServ
 		slotMigrateRepo: slotmigraterepo.NewSlotMigrateRepo(db),
 		gcControl:       NewGcControl(),
 		gcPeriod:        cfg.GcPeriod,
+		heartbeat:       cfg.Heartbeat,
+		breaker:         cfg.Breaker,
+		repair:          cfg.Repair,
+		slotPlacement:   cfg.SlotPlacement,
 	}
 	// process migrating task
 	go serv.migrateSlotsDataTask(ctx)
 
 	if err = serv.clusterInit(); err != nil {
+		serv.Close()
 		return nil, err
 	}
 
@@ -107,28 +155,47 @@ func NewDagPoolService(ctx context.Context, cfg config.PoolConfig) (*dagPoolServ
 }
 
 // Add adds a node to the dagPoolService, storing the block in the BlockService
-func (d *dagPoolService) Add(ctx context.Context, block blocks.Block, user string, password string, pin bool) error {
+func (d *dagPoolService) Add(ctx context.Context, block blocks.Block, user string, password string, pin bool) (bool, error) {
 	if !d.iam.CheckUserPolicy(user, password, upolicy.WriteOnly) {
-		return upolicy.AccessDenied
+		return false, upolicy.AccessDenied
+	}
+	if !d.iam.IsAdmin(user) {
+		if u, err := d.iam.QueryUser(user); err != nil {
+			return false, err
+		} else if u.WriteSuspended() {
+			return false, dpuser.ErrWriteSuspended
+		}
 	}
 
 	key := block.Cid().String()
+	exists := true
 	addBlock := func() error {
+		exists = false
 		return d.putBlock(ctx, block)
 	}
 
 	if pin {
 		d.InterruptGC()
-		return d.refCounter.IncrOrCreate(key, addBlock)
+		err := d.refCounter.IncrOrCreate(key, func() error {
+			// the unpinned block is stored again, but it was already in the pool
+			cached, err := d.cacheSet.Has(key)
+			if err != nil {
+				return err
+			}
+			err = addBlock()
+			exists = cached
+			return err
+		})
+		return exists, err
 	}
 
-	if has, _ := d.Has(key); !has {
+	if has, _ := d.has(key); !has {
 		if err := addBlock(); err != nil {
-			return err
+			return false, err
 		}
-		return d.cacheSet.Add(key)
+		return false, d.cacheSet.Add(key)
 	}
-	return nil
+	return true, nil
 }
 
 // Get retrieves a node from the dagPoolService, fetching the block in the BlockService
@@ -141,7 +208,7 @@ func (d *dagPoolService) Get(ctx context.Context, c cid.Cid, user string, passwo
 	defer cancel()
 
 	key := c.String()
-	if has, err := d.Has(key); err != nil {
+	if has, err := d.has(key); err != nil {
 		return nil, err
 	} else if !has {
 		return nil, format.ErrNotFound{Cid: c}
@@ -162,6 +229,41 @@ func (d *dagPoolService) Remove(ctx context.Context, c cid.Cid, user string, pas
 	return nil
 }
 
+//Pin pin the blocks in a batch, the result of each block is returned in order
+func (d *dagPoolService) Pin(ctx context.Context, cids []cid.Cid, user string, password string) ([]error, error) {
+	if !d.iam.CheckUserPolicy(user, password, upolicy.WriteOnly) {
+		return nil, upolicy.AccessDenied
+	}
+
+	keys := make([]string, len(cids))
+	for i, c := range cids {
+		keys[i] = c.String()
+	}
+	d.InterruptGC()
+	return d.refCounter.IncrBatch(keys, func(key string) error {
+		if has, err := d.has(key); err != nil {
+			return err
+		} else if !has {
+			c, _ := cid.Decode(key)
+			return format.ErrNotFound{Cid: c}
+		}
+		return nil
+	})
+}
+
+//Unpin unpin the blocks in a batch, the result of each block is returned in order
+func (d *dagPoolService) Unpin(ctx context.Context, cids []cid.Cid, user string, password string) ([]error, error) {
+	if !d.iam.CheckUserPolicy(user, password, upolicy.WriteOnly) {
+		return nil, upolicy.AccessDenied
+	}
+
+	keys := make([]string, len(cids))
+	for i, c := range cids {
+		keys[i] = c.String()
+	}
+	return d.refCounter.DecrBatch(keys)
+}
+
 //GetSize get the block size
 func (d *dagPoolService) GetSize(ctx context.Context, c cid.Cid, user string, password string) (int, error) {
 	if !d.iam.CheckUserPolicy(user, password, upolicy.ReadOnly) {
@@ -169,7 +271,7 @@ func (d *dagPoolService) GetSize(ctx context.Context, c cid.Cid, user string, pa
 	}
 
 	key := c.String()
-	if has, err := d.Has(key); err != nil {
+	if has, err := d.has(key); err != nil {
 		return 0, err
 	} else if !has {
 		return 0, format.ErrNotFound{Cid: c}
@@ -180,6 +282,15 @@ func (d *dagPoolService) GetSize(ctx context.Context, c cid.Cid, user string, pa
 	return d.readBlockSize(ctx, c)
 }
 
+//Has returns true if the pool has the block, it's answered by the references of the pool
+//without reading the block from the dag nodes
+func (d *dagPoolService) Has(ctx context.Context, c cid.Cid, user string, password string) (bool, error) {
+	if !d.iam.CheckUserPolicy(user, password, upolicy.ReadOnly) {
+		return false, upolicy.AccessDenied
+	}
+	return d.has(c.String())
+}
+
 //AddUser add a user
 func (d *dagPoolService) AddUser(newUser dpuser.DagPoolUser, user string, password string) error {
 	if !d.iam.CheckAdmin(user, password) {
@@ -238,7 +349,8 @@ func (d *dagPoolService) UpdateUser(uUser dpuser.DagPoolUser, user string, passw
 	if uUser.Policy != "" {
 		u.Policy = uUser.Policy
 	}
-	if uUser.Capacity != 0 {
+	// nil leaves the capacity unchanged, zero is a valid capacity
+	if uUser.Capacity != nil {
 		u.Capacity = uUser.Capacity
 	}
 	return d.iam.UpdateUser(*u)
@@ -262,7 +374,8 @@ func (d *dagPoolService) Close() error {
 	return d.db.Close()
 }
 
-func (d *dagPoolService) Has(key string) (bool, error) {
+// has returns true if the block is pinned or cached by the pool
+func (d *dagPoolService) has(key string) (bool, error) {
 	// is pinned?
 	if has, err := d.refCounter.Has(key); err != nil {
 		return false, err
@@ -0,0 +1,754 @@
+package poolservice
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/filedag-project/filedag-storage/dag/config"
+	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/dpuser"
+	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/dpuser/upolicy"
+	"github.com/filedag-project/filedag-storage/dag/proto"
+	"github.com/filedag-project/filedag-storage/dag/slotsmgr"
+	"github.com/filedag-project/filedag-storage/kv"
+	blocks "github.com/ipfs/go-block-format"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDagPoolService_AddWithZeroCapacity(t *testing.T) {
+	root, rootPass := "dagpool", "dagpool"
+	user, pass := "tenant", "tenant123"
+	cfg := config.PoolConfig{
+		LeveldbPath:  t.TempDir(),
+		RootUser:     root,
+		RootPassword: rootPass,
+		GcPeriod:     time.Second * 5,
+	}
+	// there is no dag node, the writes which pass the user checks fail with ErrClusterAvailable
+	service, err := NewDagPoolService(context.TODO(), cfg)
+	if err != nil {
+		t.Fatalf("NewDagPoolService err:%v", err)
+	}
+	defer service.Close()
+	if err = service.AddUser(dpuser.DagPoolUser{Username: user, Password: pass, Policy: upolicy.ReadWrite}, root, rootPass); err != nil {
+		t.Fatalf("AddUser err:%v", err)
+	}
+
+	zero, hundred := uint64(0), uint64(100)
+	testCases := []struct {
+		name        string
+		newPassword string
+		newCapacity *uint64
+		expectedErr error
+	}{
+		{
+			name:        "no limit",
+			expectedErr: ErrClusterAvailable,
+		},
+		{
+			name:        "set the capacity to zero",
+			newCapacity: &zero,
+			expectedErr: dpuser.ErrWriteSuspended,
+		},
+		{
+			name:        "update the password leaves the capacity unchanged",
+			newPassword: "tenant456",
+			expectedErr: dpuser.ErrWriteSuspended,
+		},
+		{
+			name:        "resume the writes",
+			newCapacity: &hundred,
+			expectedErr: ErrClusterAvailable,
+		},
+	}
+	block := blocks.NewBlock([]byte("123456"))
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if testCase.newPassword != "" || testCase.newCapacity != nil {
+				err := service.UpdateUser(dpuser.DagPoolUser{
+					Username: user,
+					Password: testCase.newPassword,
+					Capacity: testCase.newCapacity,
+				}, root, rootPass)
+				if err != nil {
+					t.Fatalf("UpdateUser err:%v", err)
+				}
+				if testCase.newPassword != "" {
+					pass = testCase.newPassword
+				}
+			}
+			if _, err := service.Add(context.TODO(), block, user, pass, false); err != testCase.expectedErr {
+				t.Fatalf("expected the error %v, but instead found %v", testCase.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestDagPoolService_Has(t *testing.T) {
+	root, rootPass := "dagpool", "dagpool"
+	user, pass := "tenant", "tenant123"
+	cfg := config.PoolConfig{
+		LeveldbPath:  t.TempDir(),
+		RootUser:     root,
+		RootPassword: rootPass,
+		GcPeriod:     time.Second * 5,
+	}
+	// there is no dag node, Has answers by the references without reading the block
+	service, err := NewDagPoolService(context.TODO(), cfg)
+	if err != nil {
+		t.Fatalf("NewDagPoolService err:%v", err)
+	}
+	defer service.Close()
+	if err = service.AddUser(dpuser.DagPoolUser{Username: user, Password: pass, Policy: upolicy.ReadOnly}, root, rootPass); err != nil {
+		t.Fatalf("AddUser err:%v", err)
+	}
+
+	cached := blocks.NewBlock([]byte("cached"))
+	pinned := blocks.NewBlock([]byte("pinned"))
+	missing := blocks.NewBlock([]byte("missing"))
+	if err = service.cacheSet.Add(cached.Cid().String()); err != nil {
+		t.Fatalf("cacheSet.Add err:%v", err)
+	}
+	if err = service.refCounter.Incr(pinned.Cid().String()); err != nil {
+		t.Fatalf("refCounter.Incr err:%v", err)
+	}
+	if _, err = service.Get(context.TODO(), cached.Cid(), user, pass); err == nil {
+		t.Fatalf("expected the block can't be read without dag nodes")
+	}
+
+	testCases := []struct {
+		name        string
+		cid         blocks.Block
+		password    string
+		expected    bool
+		expectedErr error
+	}{
+		{name: "cached", cid: cached, password: pass, expected: true},
+		{name: "pinned", cid: pinned, password: pass, expected: true},
+		{name: "missing", cid: missing, password: pass},
+		{name: "wrong password", cid: cached, password: "wrong", expectedErr: upolicy.AccessDenied},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			has, err := service.Has(context.TODO(), testCase.cid.Cid(), user, testCase.password)
+			if err != testCase.expectedErr {
+				t.Fatalf("expected the error %v, but instead found %v", testCase.expectedErr, err)
+			}
+			if has != testCase.expected {
+				t.Fatalf("expected %v, but instead found %v", testCase.expected, has)
+			}
+		})
+	}
+}
+
+func TestDagPoolService_AddExists(t *testing.T) {
+	cfg := config.PoolConfig{
+		LeveldbPath:  t.TempDir(),
+		RootUser:     "dagpool",
+		RootPassword: "dagpool",
+		GcPeriod:     time.Hour,
+	}
+	service, err := NewDagPoolService(context.TODO(), cfg)
+	if err != nil {
+		t.Fatalf("NewDagPoolService err:%v", err)
+	}
+	defer service.Close()
+	var dataNodes []*memDataNode
+	var addrs []string
+	for i := 0; i < 3; i++ {
+		addr, dn, _ := startMemDataNode(t)
+		addrs = append(addrs, addr)
+		dataNodes = append(dataNodes, dn)
+	}
+	err = service.AddDagNode(&config.DagNodeConfig{
+		Name:         "dagnode1",
+		Nodes:        addrs,
+		DataBlocks:   2,
+		ParityBlocks: 1,
+	})
+	if err != nil {
+		t.Fatalf("AddDagNode err:%v", err)
+	}
+	if err = service.BalanceSlots(); err != nil {
+		t.Fatalf("BalanceSlots err:%v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	// a pinned add waits for the GC to be interrupted
+	go service.GC(ctx)
+	for _, pin := range []bool{false, true} {
+		blk := blocks.NewBlock([]byte(fmt.Sprintf("block data pin %v", pin)))
+		exists, err := service.Add(ctx, blk, cfg.RootUser, cfg.RootPassword, pin)
+		if err != nil {
+			t.Fatalf("Add err:%v", err)
+		}
+		if exists {
+			t.Fatalf("pin %v: expected the new block to be stored, but it's reported already present", pin)
+		}
+		stored := dataNodes[0].count()
+		exists, err = service.Add(ctx, blk, cfg.RootUser, cfg.RootPassword, pin)
+		if err != nil {
+			t.Fatalf("Add err:%v", err)
+		}
+		if !exists {
+			t.Fatalf("pin %v: expected the re-added block to be reported already present", pin)
+		}
+		if dataNodes[0].count() != stored {
+			t.Fatalf("pin %v: expected the re-added block not to be stored again", pin)
+		}
+	}
+
+	// pinning a cached block stores nothing new
+	blk := blocks.NewBlock([]byte("block data pinned later"))
+	if exists, err := service.Add(ctx, blk, cfg.RootUser, cfg.RootPassword, false); err != nil || exists {
+		t.Fatalf("expected the new block to be stored, but got exists %v, err %v", exists, err)
+	}
+	if exists, err := service.Add(ctx, blk, cfg.RootUser, cfg.RootPassword, true); err != nil || !exists {
+		t.Fatalf("expected the pinned cached block to be reported already present, but got exists %v, err %v", exists, err)
+	}
+}
+
+func TestDagPoolService_RestoreSlots(t *testing.T) {
+	cfg := config.PoolConfig{
+		LeveldbPath:  t.TempDir(),
+		RootUser:     "dagpool",
+		RootPassword: "dagpool",
+		GcPeriod:     time.Second * 5,
+	}
+	service, err := NewDagPoolService(context.TODO(), cfg)
+	if err != nil {
+		t.Fatalf("NewDagPoolService err:%v", err)
+	}
+	// the datanodes are not running, the connections are established lazily
+	for _, name := range []string{"dagnode1", "dagnode2"} {
+		err = service.AddDagNode(&config.DagNodeConfig{
+			Name:         name,
+			Nodes:        []string{"127.0.0.1:19001", "127.0.0.1:19002", "127.0.0.1:19003"},
+			DataBlocks:   2,
+			ParityBlocks: 1,
+		})
+		if err != nil {
+			t.Fatalf("AddDagNode err:%v", err)
+		}
+	}
+	if err = service.BalanceSlots(); err != nil {
+		t.Fatalf("BalanceSlots err:%v", err)
+	}
+	if service.state != StateOk {
+		t.Fatalf("expected the state %v, but instead found %v", StateOk, service.state)
+	}
+	owners := make([]string, slotsmgr.ClusterSlots)
+	for slot, node := range service.slots {
+		owners[slot] = node.GetConfig().Name
+	}
+	if err = service.Close(); err != nil {
+		t.Fatalf("Close err:%v", err)
+	}
+
+	// restart
+	service, err = NewDagPoolService(context.TODO(), cfg)
+	if err != nil {
+		t.Fatalf("NewDagPoolService err:%v", err)
+	}
+	if service.state != StateOk {
+		t.Fatalf("expected the state %v, but instead found %v", StateOk, service.state)
+	}
+	if len(service.dagNodesMap) != 2 {
+		t.Fatalf("expected 2 dag nodes, but instead found %v", len(service.dagNodesMap))
+	}
+	for slot, node := range service.slots {
+		if node == nil || node.GetConfig().Name != owners[slot] {
+			t.Fatalf("the slot %v is not owned by %v after restart", slot, owners[slot])
+		}
+	}
+
+	// persist a slot owned by both dag nodes
+	clusterCfg, err := service.loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig err:%v", err)
+	}
+	clusterCfg.Cluster[0].SlotPairs = append(clusterCfg.Cluster[0].SlotPairs, clusterCfg.Cluster[1].SlotPairs[0])
+	if err = service.saveConfig(clusterCfg); err != nil {
+		t.Fatalf("saveConfig err:%v", err)
+	}
+	if err = service.Close(); err != nil {
+		t.Fatalf("Close err:%v", err)
+	}
+	if _, err = NewDagPoolService(context.TODO(), cfg); err == nil || !strings.Contains(err.Error(), "owned by both") {
+		t.Fatalf("expected an error for the inconsistent slots, but instead found %v", err)
+	}
+}
+
+func TestDagPoolService_ReloadDagNodes(t *testing.T) {
+	cfg := config.PoolConfig{
+		LeveldbPath:  t.TempDir(),
+		RootUser:     "dagpool",
+		RootPassword: "dagpool",
+		GcPeriod:     time.Second * 5,
+	}
+	service, err := NewDagPoolService(context.TODO(), cfg)
+	if err != nil {
+		t.Fatalf("NewDagPoolService err:%v", err)
+	}
+	defer service.Close()
+	node1 := config.DagNodeConfig{
+		Name:         "dagnode1",
+		Nodes:        []string{"127.0.0.1:19001", "127.0.0.1:19002", "127.0.0.1:19003"},
+		DataBlocks:   2,
+		ParityBlocks: 1,
+	}
+	node2 := config.DagNodeConfig{
+		Name:         "dagnode2",
+		Nodes:        []string{"127.0.0.1:19004", "127.0.0.1:19005", "127.0.0.1:19006"},
+		DataBlocks:   2,
+		ParityBlocks: 1,
+	}
+	if err = service.AddDagNode(&node1); err != nil {
+		t.Fatalf("AddDagNode err:%v", err)
+	}
+	dagNode1 := service.dagNodesMap[node1.Name]
+
+	// add a dag node and change the write concern of the existing one
+	node1.WriteConcern = config.WriteConcernAll
+	if err = service.ReloadDagNodes([]config.DagNodeConfig{node1, node2}); err != nil {
+		t.Fatalf("ReloadDagNodes err:%v", err)
+	}
+	if _, err = service.GetDagNode(node2.Name); err != nil {
+		t.Fatalf("GetDagNode err:%v", err)
+	}
+	if service.dagNodesMap[node1.Name] != dagNode1 {
+		t.Fatalf("the existing dag node should not be restarted")
+	}
+	if wc := dagNode1.GetConfig().WriteConcern; wc != config.WriteConcernAll {
+		t.Fatalf("expected the write concern %q, but instead found %q", config.WriteConcernAll, wc)
+	}
+	if err = service.BalanceSlots(); err != nil {
+		t.Fatalf("BalanceSlots err:%v", err)
+	}
+	if n := service.dagNodesMap[node2.Name].GetNumSlots(); n == 0 {
+		t.Fatalf("the reloaded dag node should own slots")
+	}
+
+	// the erasure params can't be changed
+	node3 := config.DagNodeConfig{
+		Name:         "dagnode3",
+		Nodes:        []string{"127.0.0.1:19007", "127.0.0.1:19008", "127.0.0.1:19009"},
+		DataBlocks:   2,
+		ParityBlocks: 1,
+	}
+	node2.Nodes = append(node2.Nodes, "127.0.0.1:19010")
+	node2.ParityBlocks = 2
+	if err = service.ReloadDagNodes([]config.DagNodeConfig{node1, node2, node3}); err == nil {
+		t.Fatalf("expected an error for changing the erasure params")
+	}
+	if _, err = service.GetDagNode(node3.Name); err != ErrDagNodeNotFound {
+		t.Fatalf("nothing should be applied by a rejected reload, err:%v", err)
+	}
+
+	// the reloaded configs are persisted
+	clusterCfg, err := service.loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig err:%v", err)
+	}
+	if len(clusterCfg.Cluster) != 2 {
+		t.Fatalf("expected 2 dag nodes in the cluster config, but instead found %v", len(clusterCfg.Cluster))
+	}
+	for _, info := range clusterCfg.Cluster {
+		if info.Config.Name == node1.Name && info.Config.WriteConcern != config.WriteConcernAll {
+			t.Fatalf("expected the persisted write concern %q, but instead found %q", config.WriteConcernAll, info.Config.WriteConcern)
+		}
+	}
+}
+
+// memDataNode is a datanode which keeps the shards in memory
+type memDataNode struct {
+	proto.UnimplementedDataNodeServer
+	lock    sync.Mutex
+	entries map[string]*proto.AddRequest
+	// onDelete is called on every delete if it's set
+	onDelete func()
+	// space is reported by Stat if it's set
+	space *proto.StatResponse
+}
+
+func (m *memDataNode) Put(ctx context.Context, in *proto.AddRequest) (*emptypb.Empty, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.entries[in.Key] = &proto.AddRequest{Key: in.Key, Meta: append([]byte{}, in.Meta...), Data: append([]byte{}, in.Data...)}
+	return &emptypb.Empty{}, nil
+}
+
+func (m *memDataNode) get(key string) (*proto.AddRequest, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, status.Error(codes.Unknown, kv.ErrNotFound.Error())
+	}
+	return entry, nil
+}
+
+func (m *memDataNode) Get(ctx context.Context, in *proto.GetRequest) (*proto.GetResponse, error) {
+	entry, err := m.get(in.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.GetResponse{Meta: entry.Meta, Data: entry.Data}, nil
+}
+
+func (m *memDataNode) GetMeta(ctx context.Context, in *proto.GetMetaRequest) (*proto.GetMetaResponse, error) {
+	entry, err := m.get(in.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.GetMetaResponse{Meta: entry.Meta}, nil
+}
+
+func (m *memDataNode) Delete(ctx context.Context, in *proto.DeleteRequest) (*emptypb.Empty, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.entries, in.Key)
+	if m.onDelete != nil {
+		m.onDelete()
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (m *memDataNode) setOnDelete(onDelete func()) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.onDelete = onDelete
+}
+
+func (m *memDataNode) Stat(ctx context.Context, in *emptypb.Empty) (*proto.StatResponse, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.space == nil {
+		return m.UnimplementedDataNodeServer.Stat(ctx, in)
+	}
+	return &proto.StatResponse{Total: m.space.Total, Free: m.space.Free}, nil
+}
+
+func (m *memDataNode) setSpace(total, free uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.space = &proto.StatResponse{Total: total, Free: free}
+}
+
+func (m *memDataNode) count() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.entries)
+}
+
+func startMemDataNode(t *testing.T) (string, *memDataNode, *grpc.Server) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dn := &memDataNode{entries: make(map[string]*proto.AddRequest)}
+	s := grpc.NewServer()
+	proto.RegisterDataNodeServer(s, dn)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("grpc.health.v1.Health", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, healthServer)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis.Addr().String(), dn, s
+}
+
+func TestDagPoolService_DrainDagNode(t *testing.T) {
+	cfg := config.PoolConfig{
+		LeveldbPath:  t.TempDir(),
+		RootUser:     "dagpool",
+		RootPassword: "dagpool",
+		GcPeriod:     time.Hour,
+	}
+	service, err := NewDagPoolService(context.TODO(), cfg)
+	if err != nil {
+		t.Fatalf("NewDagPoolService err:%v", err)
+	}
+	defer service.Close()
+	var drainedDataNodes []*memDataNode
+	var drainedServers []*grpc.Server
+	for _, name := range []string{"dagnode1", "dagnode2", "dagnode3"} {
+		var addrs []string
+		for i := 0; i < 3; i++ {
+			addr, dn, s := startMemDataNode(t)
+			addrs = append(addrs, addr)
+			if name == "dagnode1" {
+				drainedDataNodes = append(drainedDataNodes, dn)
+				drainedServers = append(drainedServers, s)
+			}
+		}
+		err = service.AddDagNode(&config.DagNodeConfig{
+			Name:         name,
+			Nodes:        addrs,
+			DataBlocks:   2,
+			ParityBlocks: 1,
+		})
+		if err != nil {
+			t.Fatalf("AddDagNode err:%v", err)
+		}
+	}
+	if err = service.BalanceSlots(); err != nil {
+		t.Fatalf("BalanceSlots err:%v", err)
+	}
+
+	ctx := context.TODO()
+	var blks []blocks.Block
+	for i := 0; i < 200; i++ {
+		blk := blocks.NewBlock([]byte(fmt.Sprintf("block data %d", i)))
+		if _, err = service.Add(ctx, blk, cfg.RootUser, cfg.RootPassword, false); err != nil {
+			t.Fatalf("Add err:%v", err)
+		}
+		blks = append(blks, blk)
+	}
+	if drainedDataNodes[0].count() == 0 {
+		t.Fatalf("expected the dag node to be drained to own blocks")
+	}
+	checkBlocks := func() {
+		for _, blk := range blks {
+			b, err := service.Get(ctx, blk.Cid(), cfg.RootUser, cfg.RootPassword)
+			if err != nil {
+				t.Fatalf("Get %v err:%v", blk.Cid(), err)
+			}
+			if !bytes.Equal(b.RawData(), blk.RawData()) {
+				t.Fatalf("the data of %v is changed", blk.Cid())
+			}
+		}
+	}
+
+	if err = service.DrainDagNode("dagnode4"); err != ErrDagNodeNotFound {
+		t.Fatalf("expected the error %v, but instead found %v", ErrDagNodeNotFound, err)
+	}
+	if err = service.DrainDagNode("dagnode1"); err != nil {
+		t.Fatalf("DrainDagNode err:%v", err)
+	}
+	// the blocks are read during the migration
+	checkBlocks()
+
+	drainStatus := func() (draining, drained bool) {
+		reply, err := service.Status()
+		if err != nil {
+			t.Fatalf("Status err:%v", err)
+		}
+		for _, st := range reply.Statuses {
+			if st.Node.Name == "dagnode1" {
+				return st.Draining, st.Drained
+			}
+		}
+		t.Fatalf("the dag node is not in the status")
+		return false, false
+	}
+	for i := 0; i < 100; i++ {
+		if _, drained := drainStatus(); drained {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if draining, drained := drainStatus(); !draining || !drained {
+		t.Fatalf("expected the dag node to be drained, draining: %v, drained: %v", draining, drained)
+	}
+	if service.state != StateOk {
+		t.Fatalf("expected the state %v, but instead found %v", StateOk, service.state)
+	}
+	for _, name := range []string{"dagnode2", "dagnode3"} {
+		if n := service.dagNodesMap[name].GetNumSlots(); n != slotsmgr.ClusterSlots/2 {
+			t.Fatalf("expected %v to own %d slots, but instead found %d", name, slotsmgr.ClusterSlots/2, n)
+		}
+	}
+	// the draining dag node gets no slot from the balance
+	if err = service.BalanceSlots(); err != nil {
+		t.Fatalf("BalanceSlots err:%v", err)
+	}
+	if n := service.dagNodesMap["dagnode1"].GetNumSlots(); n != 0 {
+		t.Fatalf("expected the drained dag node to own no slot, but instead found %d", n)
+	}
+	for _, dn := range drainedDataNodes {
+		if n := dn.count(); n != 0 {
+			t.Fatalf("expected the drained datanodes to be empty, but instead found %d shards", n)
+		}
+	}
+
+	// remove the dag node and power down its datanodes
+	if _, err = service.RemoveDagNode("dagnode1"); err != nil {
+		t.Fatalf("RemoveDagNode err:%v", err)
+	}
+	for _, s := range drainedServers {
+		s.Stop()
+	}
+	checkBlocks()
+}
+
+func TestDagPoolService_FreeSpaceSlotPlacement(t *testing.T) {
+	cfg := config.PoolConfig{
+		LeveldbPath:   t.TempDir(),
+		RootUser:      "dagpool",
+		RootPassword:  "dagpool",
+		GcPeriod:      time.Hour,
+		Heartbeat:     config.HeartbeatConfig{Interval: 50 * time.Millisecond, Timeout: 50 * time.Millisecond, MaxMisses: 1},
+		SlotPlacement: config.SlotPlacementFreeSpace,
+	}
+	service, err := NewDagPoolService(context.TODO(), cfg)
+	if err != nil {
+		t.Fatalf("NewDagPoolService err:%v", err)
+	}
+	defer service.Close()
+	const gib = 1 << 30
+	dataNodes := make(map[string][]*memDataNode)
+	// dagnode1 has three times the free space of dagnode2
+	for name, free := range map[string]uint64{"dagnode1": 30 * gib, "dagnode2": 10 * gib} {
+		var addrs []string
+		for i := 0; i < 3; i++ {
+			addr, dn, _ := startMemDataNode(t)
+			dn.setSpace(100*gib, free)
+			addrs = append(addrs, addr)
+			dataNodes[name] = append(dataNodes[name], dn)
+		}
+		err = service.AddDagNode(&config.DagNodeConfig{
+			Name:         name,
+			Nodes:        addrs,
+			DataBlocks:   2,
+			ParityBlocks: 1,
+		})
+		if err != nil {
+			t.Fatalf("AddDagNode err:%v", err)
+		}
+	}
+	// waitForFreeSpace waits for the heartbeats to report the free space of the dag nodes
+	waitForFreeSpace := func(name string, expected uint64) {
+		for i := 0; i < 100; i++ {
+			if free, ok := service.dagNodesMap[name].FreeSpace(); ok && free == expected {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("expected the free space of %v to be reported", name)
+	}
+	waitForFreeSpace("dagnode1", 60*gib)
+	waitForFreeSpace("dagnode2", 20*gib)
+	if err = service.BalanceSlots(); err != nil {
+		t.Fatalf("BalanceSlots err:%v", err)
+	}
+	checkSlots := func(expected map[string]int) {
+		for name, n := range expected {
+			if got := service.dagNodesMap[name].GetNumSlots(); got != n {
+				t.Fatalf("expected %v to own %d slots, but instead found %d", name, n, got)
+			}
+		}
+	}
+	checkSlots(map[string]int{"dagnode1": slotsmgr.ClusterSlots * 3 / 4, "dagnode2": slotsmgr.ClusterSlots / 4})
+
+	ctx := context.TODO()
+	var blks []blocks.Block
+	for i := 0; i < 400; i++ {
+		blk := blocks.NewBlock([]byte(fmt.Sprintf("block data %d", i)))
+		if _, err = service.Add(ctx, blk, cfg.RootUser, cfg.RootPassword, false); err != nil {
+			t.Fatalf("Add err:%v", err)
+		}
+		blks = append(blks, blk)
+	}
+	// the emptier dag node takes most of the writes
+	written1, written2 := dataNodes["dagnode1"][0].count(), dataNodes["dagnode2"][0].count()
+	if written1+written2 != len(blks) {
+		t.Fatalf("expected %d blocks to be written, but instead found %d", len(blks), written1+written2)
+	}
+	if written1 < 2*written2 {
+		t.Fatalf("expected the emptier dag node to take most of the writes, but instead found %d and %d", written1, written2)
+	}
+
+	// a slight change of the free space migrates nothing
+	for _, dn := range dataNodes["dagnode1"] {
+		dn.setSpace(100*gib, 29*gib)
+	}
+	waitForFreeSpace("dagnode1", 58*gib)
+	if err = service.BalanceSlots(); err != nil {
+		t.Fatalf("BalanceSlots err:%v", err)
+	}
+	checkSlots(map[string]int{"dagnode1": slotsmgr.ClusterSlots * 3 / 4, "dagnode2": slotsmgr.ClusterSlots / 4})
+	if service.state != StateOk {
+		t.Fatalf("expected the state %v, but instead found %v", StateOk, service.state)
+	}
+
+	// the slots follow the free space at the next balance, the blocks are found during and after the migration
+	for _, dn := range dataNodes["dagnode1"] {
+		dn.setSpace(100*gib, 10*gib)
+	}
+	waitForFreeSpace("dagnode1", 20*gib)
+	if err = service.BalanceSlots(); err != nil {
+		t.Fatalf("BalanceSlots err:%v", err)
+	}
+	checkSlots(map[string]int{"dagnode1": slotsmgr.ClusterSlots / 2, "dagnode2": slotsmgr.ClusterSlots / 2})
+	checkBlocks := func() {
+		for _, blk := range blks {
+			b, err := service.Get(ctx, blk.Cid(), cfg.RootUser, cfg.RootPassword)
+			if err != nil {
+				t.Fatalf("Get %v err:%v", blk.Cid(), err)
+			}
+			if !bytes.Equal(b.RawData(), blk.RawData()) {
+				t.Fatalf("the data of %v is changed", blk.Cid())
+			}
+		}
+	}
+	checkBlocks()
+	for i := 0; i < 100 && service.state != StateOk; i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if service.state != StateOk {
+		t.Fatalf("expected the state %v, but instead found %v", StateOk, service.state)
+	}
+	checkBlocks()
+}
+
+func TestDagPoolService_ExpectedSlots(t *testing.T) {
+	cfg := config.PoolConfig{
+		LeveldbPath:   t.TempDir(),
+		RootUser:      "dagpool",
+		RootPassword:  "dagpool",
+		GcPeriod:      time.Hour,
+		SlotPlacement: config.SlotPlacementFreeSpace,
+	}
+	service, err := NewDagPoolService(context.TODO(), cfg)
+	if err != nil {
+		t.Fatalf("NewDagPoolService err:%v", err)
+	}
+	defer service.Close()
+	var names []string
+	for _, name := range []string{"dagnode1", "dagnode2", "dagnode3"} {
+		var addrs []string
+		for i := 0; i < 2; i++ {
+			addr, _, _ := startMemDataNode(t)
+			addrs = append(addrs, addr)
+		}
+		if err = service.AddDagNode(&config.DagNodeConfig{Name: name, Nodes: addrs, DataBlocks: 1, ParityBlocks: 1}); err != nil {
+			t.Fatalf("AddDagNode err:%v", err)
+		}
+		names = append(names, name)
+	}
+	// the datanodes don't report their space, the slots are split evenly
+	pieces := service.expectedSlots(names)
+	if !reflect.DeepEqual(pieces, []int{5462, 5461, 5461}) {
+		t.Fatalf("expected the slots to be split evenly, but instead found %v", pieces)
+	}
+
+	if _, err = NewDagPoolService(context.TODO(), config.PoolConfig{
+		LeveldbPath:   t.TempDir(),
+		RootUser:      "dagpool",
+		RootPassword:  "dagpool",
+		SlotPlacement: "random",
+	}); err == nil {
+		t.Fatalf("expected the unknown slot placement to be rejected")
+	}
+}
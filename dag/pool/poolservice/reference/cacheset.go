@@ -2,18 +2,17 @@ package reference
 
 import (
 	"context"
-	"github.com/filedag-project/filedag-storage/objectservice/uleveldb"
-	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
 	"strings"
 )
 
 const CachePrefix = "cache/"
 
 type CacheSet struct {
-	db *uleveldb.ULevelDB
+	db metadb.DB
 }
 
-func NewCacheSet(db *uleveldb.ULevelDB) *CacheSet {
+func NewCacheSet(db metadb.DB) *CacheSet {
 	return &CacheSet{db: db}
 }
 
@@ -26,7 +25,7 @@ func (s *CacheSet) Has(key string) (bool, error) {
 	var exist bool
 	err := s.db.Get(CachePrefix+key, &exist)
 	if err != nil {
-		if err == leveldb.ErrNotFound {
+		if err == metadb.ErrNotFound {
 			return false, nil
 		}
 		return false, err
@@ -34,8 +33,12 @@ func (s *CacheSet) Has(key string) (bool, error) {
 	return true, nil
 }
 
-func (s *CacheSet) AllKeysChan(ctx context.Context) (<-chan string, error) {
-	all, err := s.db.ReadAllChan(ctx, CachePrefix, "")
+// AllKeysChan reads the keys in order, only the keys after the seekKey if it isn't empty
+func (s *CacheSet) AllKeysChan(ctx context.Context, seekKey string) (<-chan string, error) {
+	if seekKey != "" {
+		seekKey = CachePrefix + seekKey
+	}
+	all, err := s.db.ReadAllChan(ctx, CachePrefix, seekKey)
 	if err != nil {
 		return nil, err
 	}
@@ -3,8 +3,7 @@ package reference
 import (
 	"context"
 	"errors"
-	"github.com/filedag-project/filedag-storage/objectservice/uleveldb"
-	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
 	"golang.org/x/xerrors"
 	"strings"
 	"sync"
@@ -14,11 +13,11 @@ const RefPrefix = "ref/"
 
 type RefCounter struct {
 	mut      sync.Mutex
-	db       *uleveldb.ULevelDB
+	db       metadb.DB
 	cacheSet *CacheSet
 }
 
-func NewRefCounter(db *uleveldb.ULevelDB, cacheSet *CacheSet) *RefCounter {
+func NewRefCounter(db metadb.DB, cacheSet *CacheSet) *RefCounter {
 	return &RefCounter{
 		db:       db,
 		cacheSet: cacheSet,
@@ -34,7 +33,7 @@ func (rc *RefCounter) IncrOrCreate(key string, createFunc func() error) error {
 	defer rc.mut.Unlock()
 	var count int64
 	err := rc.db.Get(RefPrefix+key, &count)
-	if xerrors.Is(err, leveldb.ErrNotFound) {
+	if xerrors.Is(err, metadb.ErrNotFound) {
 		if createFunc != nil {
 			if err = createFunc(); err != nil {
 				return err
@@ -57,7 +56,7 @@ func (rc *RefCounter) Has(key string) (bool, error) {
 	var count int64
 	err := rc.db.Get(RefPrefix+key, &count)
 	if err != nil {
-		if xerrors.Is(err, leveldb.ErrNotFound) {
+		if xerrors.Is(err, metadb.ErrNotFound) {
 			return false, nil
 		}
 		return false, err
@@ -70,10 +69,10 @@ func (rc *RefCounter) Decr(key string) error {
 	defer rc.mut.Unlock()
 	var count int64
 	err := rc.db.Get(RefPrefix+key, &count)
-	if err != nil && !xerrors.Is(err, leveldb.ErrNotFound) {
+	if err != nil && !xerrors.Is(err, metadb.ErrNotFound) {
 		return err
 	}
-	if xerrors.Is(err, leveldb.ErrNotFound) || count == 0 {
+	if xerrors.Is(err, metadb.ErrNotFound) || count == 0 {
 		return errors.New("reference count of key is zero")
 	}
 	count--
@@ -125,7 +124,7 @@ func (rc *RefCounter) Remove(key string, force bool) error {
 	var count int64
 	err := rc.db.Get(RefPrefix+key, &count)
 	if err != nil {
-		if xerrors.Is(err, leveldb.ErrNotFound) {
+		if xerrors.Is(err, metadb.ErrNotFound) {
 			return nil
 		}
 		return err
@@ -140,6 +139,86 @@ func (rc *RefCounter) Remove(key string, force bool) error {
 	return rc.db.Delete(RefPrefix + key)
 }
 
+// IncrBatch increases the reference counter of keys and writes them to db in a single batch.
+// errs[i] is the result of keys[i], the key is skipped if check returns an error.
+func (rc *RefCounter) IncrBatch(keys []string, check func(key string) error) (errs []error, err error) {
+	rc.mut.Lock()
+	defer rc.mut.Unlock()
+	errs = make([]error, len(keys))
+	counts := make(map[string]int64)
+	for i, key := range keys {
+		if check != nil {
+			if errs[i] = check(key); errs[i] != nil {
+				continue
+			}
+		}
+		count, ok := counts[key]
+		if !ok {
+			if count, errs[i] = rc.get(key); errs[i] != nil {
+				continue
+			}
+		}
+		counts[key] = count + 1
+	}
+	return errs, rc.writeBatch(counts)
+}
+
+// DecrBatch decreases the reference counter of keys and writes them to db in a single batch.
+// errs[i] is the result of keys[i].
+func (rc *RefCounter) DecrBatch(keys []string) (errs []error, err error) {
+	rc.mut.Lock()
+	defer rc.mut.Unlock()
+	errs = make([]error, len(keys))
+	counts := make(map[string]int64)
+	for i, key := range keys {
+		count, ok := counts[key]
+		if !ok {
+			if count, errs[i] = rc.get(key); errs[i] != nil {
+				continue
+			}
+		}
+		if count == 0 {
+			errs[i] = errors.New("reference count of key is zero")
+			continue
+		}
+		counts[key] = count - 1
+	}
+	return errs, rc.writeBatch(counts)
+}
+
+// get returns the reference count of key, zero if not found
+func (rc *RefCounter) get(key string) (int64, error) {
+	var count int64
+	err := rc.db.Get(RefPrefix+key, &count)
+	if err != nil && !xerrors.Is(err, metadb.ErrNotFound) {
+		return 0, err
+	}
+	return count, nil
+}
+
+// writeBatch writes the reference counts to db atomically,
+// the key of zero count is moved to cache.
+func (rc *RefCounter) writeBatch(counts map[string]int64) error {
+	batch := rc.db.NewBatch()
+	for key, count := range counts {
+		if count == 0 {
+			exist := true
+			if err := batch.Put(CachePrefix+key, &exist); err != nil {
+				return err
+			}
+			batch.Delete(RefPrefix + key)
+			continue
+		}
+		if err := batch.Put(RefPrefix+key, count); err != nil {
+			return err
+		}
+	}
+	if batch.Len() == 0 {
+		return nil
+	}
+	return rc.db.Write(batch)
+}
+
 func (rc *RefCounter) moveToCache(key string) error {
 	return rc.cacheSet.Add(key)
 }
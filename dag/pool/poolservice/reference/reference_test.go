@@ -34,11 +34,19 @@ func TestCacheSet(t *testing.T) {
 	require.NoError(t, err)
 	require.False(t, has)
 
-	keysCh, err := cset.AllKeysChan(context.TODO())
+	keysCh, err := cset.AllKeysChan(context.TODO(), "")
 	require.NoError(t, err)
 	for key := range keysCh {
 		require.Contains(t, testKeys, key)
 	}
+	// the keys are read in order after the seek key, which needn't exist
+	keysCh, err = cset.AllKeysChan(context.TODO(), "b")
+	require.NoError(t, err)
+	var keys []string
+	for key := range keysCh {
+		keys = append(keys, key)
+	}
+	require.Equal(t, []string{"b354646rt23sdsfddfx", "cffdf"}, keys)
 	for _, key := range testKeys {
 		err = cset.Remove(key)
 		require.NoError(t, err)
@@ -122,10 +130,54 @@ func TestRefCounter(t *testing.T) {
 	err = counter.Remove(extKey, true)
 	require.NoError(t, err)
 
-	keysCh2, err := cset.AllKeysChan(context.TODO())
+	keysCh2, err := cset.AllKeysChan(context.TODO(), "")
 	require.NoError(t, err)
 	testKeys = append(testKeys, extKey)
 	for key := range keysCh2 {
 		require.Contains(t, testKeys, key)
 	}
 }
+
+func TestRefCounterBatch(t *testing.T) {
+	db, err := uleveldb.OpenDb(t.TempDir())
+	require.NoError(t, err)
+	cset := NewCacheSet(db)
+	counter := NewRefCounter(db, cset)
+	notFound := errors.New("not found")
+	check := func(key string) error {
+		if key == "missing" {
+			return notFound
+		}
+		return nil
+	}
+
+	errs, err := counter.IncrBatch([]string{"a", "b", "missing", "a"}, check)
+	require.NoError(t, err)
+	require.Equal(t, []error{nil, nil, notFound, nil}, errs)
+	for key, expect := range map[string]int64{"a": 2, "b": 1} {
+		num, err := counter.Get(key)
+		require.NoError(t, err)
+		require.Equal(t, expect, num, "key=%s", key)
+	}
+	has, err := counter.Has("missing")
+	require.NoError(t, err)
+	require.False(t, has)
+
+	errs, err = counter.DecrBatch([]string{"a", "b", "b", "missing"})
+	require.NoError(t, err)
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[1])
+	require.Error(t, errs[2])
+	require.Error(t, errs[3])
+
+	num, err := counter.Get("a")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), num)
+	// the key of zero reference is moved to cache
+	has, err = counter.Has("b")
+	require.NoError(t, err)
+	require.False(t, has)
+	has, err = cset.Has("b")
+	require.NoError(t, err)
+	require.True(t, has)
+}
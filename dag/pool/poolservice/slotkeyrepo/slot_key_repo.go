@@ -3,8 +3,7 @@ package slotkeyrepo
 import (
 	"context"
 	"fmt"
-	"github.com/filedag-project/filedag-storage/objectservice/uleveldb"
-	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
 	"strings"
 )
 
@@ -12,10 +11,10 @@ const SlotPrefix = "slot/"
 
 // SlotKeyRepo saves information about the slot and cid key mapping.
 type SlotKeyRepo struct {
-	db *uleveldb.ULevelDB
+	db metadb.DB
 }
 
-func NewSlotKeyRepo(db *uleveldb.ULevelDB) *SlotKeyRepo {
+func NewSlotKeyRepo(db metadb.DB) *SlotKeyRepo {
 	return &SlotKeyRepo{db: db}
 }
 
@@ -32,7 +31,7 @@ func (s *SlotKeyRepo) Has(slot uint16, key string) (bool, error) {
 	var val string
 	err := s.db.Get(fmt.Sprintf("%s%v/%s", SlotPrefix, slot, key), &val)
 	if err != nil {
-		if err == leveldb.ErrNotFound {
+		if err == metadb.ErrNotFound {
 			return false, nil
 		}
 		return false, err
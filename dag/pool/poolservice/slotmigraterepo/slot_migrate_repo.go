@@ -3,8 +3,7 @@ package slotmigraterepo
 import (
 	"context"
 	"fmt"
-	"github.com/filedag-project/filedag-storage/objectservice/uleveldb"
-	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
 	"strconv"
 	"strings"
 )
@@ -13,10 +12,10 @@ const SlotMigratePrefix = "migrate/"
 
 // SlotMigrateRepo saves information about the slot to be transferred.
 type SlotMigrateRepo struct {
-	db *uleveldb.ULevelDB
+	db metadb.DB
 }
 
-func NewSlotMigrateRepo(db *uleveldb.ULevelDB) *SlotMigrateRepo {
+func NewSlotMigrateRepo(db metadb.DB) *SlotMigrateRepo {
 	return &SlotMigrateRepo{db: db}
 }
 
@@ -33,7 +32,7 @@ func (s *SlotMigrateRepo) Has(slot uint16) (bool, error) {
 	var val string
 	err := s.db.Get(fmt.Sprintf("%s%v", SlotMigratePrefix, slot), &val)
 	if err != nil {
-		if err == leveldb.ErrNotFound {
+		if err == metadb.ErrNotFound {
 			return false, nil
 		}
 		return false, err
@@ -71,3 +71,10 @@ func (s *DagPoolClusterServer) RepairDataNode(ctx context.Context, req *proto.Re
 	}
 	return &emptypb.Empty{}, nil
 }
+
+func (s *DagPoolClusterServer) DrainDagNode(ctx context.Context, req *proto.DrainDagNodeReq) (*emptypb.Empty, error) {
+	if err := s.Cluster.DrainDagNode(req.Name); err != nil {
+		return nil, status.Errorf(codes.Unknown, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -11,6 +11,11 @@ import (
 	"github.com/ipfs/go-cid"
 	logging "github.com/ipfs/go-log/v2"
 	"golang.org/x/xerrors"
+	"google.golang.org/grpc/codes"
+	// blank import is used to register the gzip compressor, the pool decompresses the gzip requests of the
+	// clients enabling the compression and replies to them in gzip
+	_ "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/status"
 )
 
 var log = logging.Logger("dag-pool-server")
@@ -25,11 +30,11 @@ type DagPoolServer struct {
 //Add is used to add a block to the dag pool server
 func (s *DagPoolServer) Add(ctx context.Context, in *proto.AddReq) (*proto.AddReply, error) {
 	data := blocks.NewBlock(in.GetBlock())
-	err := s.DagPool.Add(ctx, data, in.User.User, in.User.Password, in.Pin)
+	exists, err := s.DagPool.Add(ctx, data, in.User.User, in.User.Password, in.Pin)
 	if err != nil {
-		return &proto.AddReply{Cid: cid.Undef.String()}, err
+		return &proto.AddReply{Cid: cid.Undef.String()}, statusError(err)
 	}
-	return &proto.AddReply{Cid: data.Cid().String()}, nil
+	return &proto.AddReply{Cid: data.Cid().String(), Exists: exists}, nil
 }
 
 //Get is used to get a block from the dag pool server
@@ -40,7 +45,7 @@ func (s *DagPoolServer) Get(ctx context.Context, in *proto.GetReq) (*proto.GetRe
 	}
 	get, err := s.DagPool.Get(ctx, cid, in.User.User, in.User.Password)
 	if err != nil {
-		return &proto.GetReply{Block: nil}, err
+		return &proto.GetReply{Block: nil}, statusError(err)
 	}
 	return &proto.GetReply{Block: get.RawData()}, nil
 }
@@ -53,11 +58,24 @@ func (s *DagPoolServer) GetSize(ctx context.Context, in *proto.GetSizeReq) (*pro
 	}
 	size, err := s.DagPool.GetSize(ctx, cid, in.User.User, in.User.Password)
 	if err != nil {
-		return &proto.GetSizeReply{Size: 0}, err
+		return &proto.GetSizeReply{Size: 0}, statusError(err)
 	}
 	return &proto.GetSizeReply{Size: int32(size)}, nil
 }
 
+//Has is used to check if the dag pool server has the block, the block isn't transferred
+func (s *DagPoolServer) Has(ctx context.Context, in *proto.HasReq) (*proto.HasReply, error) {
+	cid, err := cid.Decode(in.Cid)
+	if err != nil {
+		return &proto.HasReply{Has: false}, err
+	}
+	has, err := s.DagPool.Has(ctx, cid, in.User.User, in.User.Password)
+	if err != nil {
+		return &proto.HasReply{Has: false}, statusError(err)
+	}
+	return &proto.HasReply{Has: has}, nil
+}
+
 //Remove is used to remove a block from the dag pool server
 func (s *DagPoolServer) Remove(ctx context.Context, in *proto.RemoveReq) (*proto.RemoveReply, error) {
 	c, err := cid.Decode(in.Cid)
@@ -66,11 +84,72 @@ func (s *DagPoolServer) Remove(ctx context.Context, in *proto.RemoveReq) (*proto
 	}
 	err = s.DagPool.Remove(ctx, c, in.User.User, in.User.Password, in.Unpin)
 	if err != nil {
-		return &proto.RemoveReply{Message: ""}, err
+		return &proto.RemoveReply{Message: ""}, statusError(err)
 	}
 	return &proto.RemoveReply{Message: c.String()}, nil
 }
 
+//Pin is used to pin a batch of blocks of the dag pool server
+func (s *DagPoolServer) Pin(ctx context.Context, in *proto.PinReq) (*proto.PinReply, error) {
+	results, cids := decodePinCids(in.Cids)
+	errs, err := s.DagPool.Pin(ctx, cids, in.User.User, in.User.Password)
+	if err != nil {
+		return &proto.PinReply{}, statusError(err)
+	}
+	fillPinResults(results, errs)
+	return &proto.PinReply{Results: results}, nil
+}
+
+//Unpin is used to unpin a batch of blocks of the dag pool server
+func (s *DagPoolServer) Unpin(ctx context.Context, in *proto.UnpinReq) (*proto.UnpinReply, error) {
+	results, cids := decodePinCids(in.Cids)
+	errs, err := s.DagPool.Unpin(ctx, cids, in.User.User, in.User.Password)
+	if err != nil {
+		return &proto.UnpinReply{}, statusError(err)
+	}
+	fillPinResults(results, errs)
+	return &proto.UnpinReply{Results: results}, nil
+}
+
+// statusError returns the error of a request on the blocks, PermissionDenied if the user
+// isn't allowed the request, so that the client tells it apart from the failures of the pool
+func statusError(err error) error {
+	if xerrors.Is(err, upolicy.AccessDenied) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return err
+}
+
+// decodePinCids decodes the cids of the batch, the result of an invalid cid is filled in directly
+func decodePinCids(keys []string) ([]*proto.PinResult, []cid.Cid) {
+	results := make([]*proto.PinResult, len(keys))
+	cids := make([]cid.Cid, 0, len(keys))
+	for i, key := range keys {
+		results[i] = &proto.PinResult{Cid: key}
+		c, err := cid.Decode(key)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		cids = append(cids, c)
+	}
+	return results, cids
+}
+
+// fillPinResults fills the errors of the valid cids into the results in order
+func fillPinResults(results []*proto.PinResult, errs []error) {
+	i := 0
+	for _, result := range results {
+		if result.Error != "" {
+			continue
+		}
+		if i < len(errs) && errs[i] != nil {
+			result.Error = errs[i].Error()
+		}
+		i++
+	}
+}
+
 //AddUser is used to add a user to the dag pool server
 func (s *DagPoolServer) AddUser(ctx context.Context, in *proto.AddUserReq) (*proto.AddUserReply, error) {
 	if !upolicy.CheckValid(in.Policy) {
@@ -4,9 +4,15 @@ import (
 	"context"
 	"fmt"
 	"github.com/filedag-project/filedag-storage/dag/pool/mocks"
+	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/dpuser/upolicy"
 	"github.com/filedag-project/filedag-storage/dag/proto"
 	"github.com/golang/mock/gomock"
+	"github.com/ipfs/go-cid"
+	format "github.com/ipfs/go-ipld-format"
 	"github.com/ipfs/go-merkledag"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"testing"
 )
 
@@ -15,7 +21,7 @@ func NewMockDagPoolServer(t *testing.T) (*DagPoolServer, func()) {
 	ctrl := gomock.NewController(t)
 	m := mocks.NewMockDagPool(ctrl)
 	node := merkledag.NodeWithData([]byte("\b\u0002\u0012\a1234567\u0018\a"))
-	m.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	m.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
 	m.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(node, nil).AnyTimes()
 	return &DagPoolServer{DagPool: m}, ctrl.Finish
 }
@@ -33,3 +39,99 @@ func TestDagPoolServer(t *testing.T) {
 	}
 	fmt.Println(add.Cid)
 }
+
+func TestDagPoolServer_AddExists(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := mocks.NewMockDagPool(ctrl)
+	ser := &DagPoolServer{DagPool: m}
+	user1 := &proto.PoolUser{User: "user1", Password: "password1"}
+
+	blk := merkledag.NodeWithData([]byte("1234"))
+	gomock.InOrder(
+		m.EXPECT().Add(gomock.Any(), gomock.Any(), user1.User, user1.Password, false).Return(false, nil),
+		m.EXPECT().Add(gomock.Any(), gomock.Any(), user1.User, user1.Password, false).Return(true, nil),
+	)
+	reply, err := ser.Add(context.Background(), &proto.AddReq{Block: blk.RawData(), User: user1})
+	require.NoError(t, err)
+	require.False(t, reply.Exists)
+	reply, err = ser.Add(context.Background(), &proto.AddReq{Block: blk.RawData(), User: user1})
+	require.NoError(t, err)
+	require.True(t, reply.Exists)
+}
+
+func TestDagPoolServer_Pin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := mocks.NewMockDagPool(ctrl)
+	ser := &DagPoolServer{DagPool: m}
+	user1 := &proto.PoolUser{User: "user1", Password: "password1"}
+
+	blk1 := merkledag.NodeWithData([]byte("1234"))
+	blk2 := merkledag.NodeWithData([]byte("5678"))
+	notFound := format.ErrNotFound{Cid: blk2.Cid()}
+	m.EXPECT().Pin(gomock.Any(), []cid.Cid{blk1.Cid(), blk2.Cid()}, user1.User, user1.Password).
+		Return([]error{nil, notFound}, nil)
+	reply, err := ser.Pin(context.Background(), &proto.PinReq{
+		Cids: []string{blk1.Cid().String(), "invalid", blk2.Cid().String()},
+		User: user1,
+	})
+	require.NoError(t, err)
+	require.Len(t, reply.Results, 3)
+	require.Equal(t, blk1.Cid().String(), reply.Results[0].Cid)
+	require.Empty(t, reply.Results[0].Error)
+	require.Equal(t, "invalid", reply.Results[1].Cid)
+	require.NotEmpty(t, reply.Results[1].Error)
+	require.Equal(t, blk2.Cid().String(), reply.Results[2].Cid)
+	require.Equal(t, notFound.Error(), reply.Results[2].Error)
+
+	m.EXPECT().Unpin(gomock.Any(), []cid.Cid{blk1.Cid()}, user1.User, user1.Password).
+		Return([]error{nil}, nil)
+	unpinReply, err := ser.Unpin(context.Background(), &proto.UnpinReq{
+		Cids: []string{blk1.Cid().String()},
+		User: user1,
+	})
+	require.NoError(t, err)
+	require.Len(t, unpinReply.Results, 1)
+	require.Empty(t, unpinReply.Results[0].Error)
+}
+
+func TestDagPoolServer_Has(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	// only Has is expected, a probe must not get the block
+	m := mocks.NewMockDagPool(ctrl)
+	ser := &DagPoolServer{DagPool: m}
+	user1 := &proto.PoolUser{User: "user1", Password: "password1"}
+
+	blk1 := merkledag.NodeWithData([]byte("1234"))
+	blk2 := merkledag.NodeWithData([]byte("5678"))
+	m.EXPECT().Has(gomock.Any(), blk1.Cid(), user1.User, user1.Password).Return(true, nil)
+	m.EXPECT().Has(gomock.Any(), blk2.Cid(), user1.User, user1.Password).Return(false, nil)
+	reply, err := ser.Has(context.Background(), &proto.HasReq{Cid: blk1.Cid().String(), User: user1})
+	require.NoError(t, err)
+	require.True(t, reply.Has)
+	reply, err = ser.Has(context.Background(), &proto.HasReq{Cid: blk2.Cid().String(), User: user1})
+	require.NoError(t, err)
+	require.False(t, reply.Has)
+
+	_, err = ser.Has(context.Background(), &proto.HasReq{Cid: "invalid", User: user1})
+	require.Error(t, err)
+}
+
+func TestDagPoolServer_AccessDenied(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := mocks.NewMockDagPool(ctrl)
+	ser := &DagPoolServer{DagPool: m}
+	user1 := &proto.PoolUser{User: "user1", Password: "wrong"}
+
+	blk := merkledag.NodeWithData([]byte("1234"))
+	m.EXPECT().Add(gomock.Any(), gomock.Any(), user1.User, user1.Password, true).Return(false, upolicy.AccessDenied)
+	m.EXPECT().Get(gomock.Any(), blk.Cid(), user1.User, user1.Password).Return(nil, format.ErrNotFound{Cid: blk.Cid()})
+	// the denied request fails with PermissionDenied, the other failures are kept
+	_, err := ser.Add(context.Background(), &proto.AddReq{Block: blk.RawData(), User: user1, Pin: true})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = ser.Get(context.Background(), &proto.GetReq{Cid: blk.Cid().String(), User: user1})
+	require.NotEqual(t, codes.PermissionDenied, status.Code(err))
+}
@@ -145,6 +145,8 @@ type AddReply struct {
 	unknownFields protoimpl.UnknownFields
 
 	Cid string `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	// the block was already in the pool, nothing new was stored
+	Exists bool `protobuf:"varint,2,opt,name=exists,proto3" json:"exists,omitempty"`
 }
 
 func (x *AddReply) Reset() {
@@ -186,6 +188,13 @@ func (x *AddReply) GetCid() string {
 	return ""
 }
 
+func (x *AddReply) GetExists() bool {
+	if x != nil {
+		return x.Exists
+	}
+	return false
+}
+
 type GetReq struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -390,6 +399,108 @@ func (x *GetSizeReply) GetSize() int32 {
 	return 0
 }
 
+type HasReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Cid  string    `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	User *PoolUser `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+}
+
+func (x *HasReq) Reset() {
+	*x = HasReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dagpool_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HasReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HasReq) ProtoMessage() {}
+
+func (x *HasReq) ProtoReflect() protoreflect.Message {
+	mi := &file_dagpool_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HasReq.ProtoReflect.Descriptor instead.
+func (*HasReq) Descriptor() ([]byte, []int) {
+	return file_dagpool_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *HasReq) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+func (x *HasReq) GetUser() *PoolUser {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+type HasReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Has bool `protobuf:"varint,1,opt,name=has,proto3" json:"has,omitempty"`
+}
+
+func (x *HasReply) Reset() {
+	*x = HasReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dagpool_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HasReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HasReply) ProtoMessage() {}
+
+func (x *HasReply) ProtoReflect() protoreflect.Message {
+	mi := &file_dagpool_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HasReply.ProtoReflect.Descriptor instead.
+func (*HasReply) Descriptor() ([]byte, []int) {
+	return file_dagpool_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *HasReply) GetHas() bool {
+	if x != nil {
+		return x.Has
+	}
+	return false
+}
+
 type RemoveReq struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -403,7 +514,7 @@ type RemoveReq struct {
 func (x *RemoveReq) Reset() {
 	*x = RemoveReq{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dagpool_proto_msgTypes[7]
+		mi := &file_dagpool_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -416,7 +527,7 @@ func (x *RemoveReq) String() string {
 func (*RemoveReq) ProtoMessage() {}
 
 func (x *RemoveReq) ProtoReflect() protoreflect.Message {
-	mi := &file_dagpool_proto_msgTypes[7]
+	mi := &file_dagpool_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -429,7 +540,7 @@ func (x *RemoveReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RemoveReq.ProtoReflect.Descriptor instead.
 func (*RemoveReq) Descriptor() ([]byte, []int) {
-	return file_dagpool_proto_rawDescGZIP(), []int{7}
+	return file_dagpool_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *RemoveReq) GetCid() string {
@@ -464,7 +575,7 @@ type RemoveReply struct {
 func (x *RemoveReply) Reset() {
 	*x = RemoveReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dagpool_proto_msgTypes[8]
+		mi := &file_dagpool_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -477,7 +588,7 @@ func (x *RemoveReply) String() string {
 func (*RemoveReply) ProtoMessage() {}
 
 func (x *RemoveReply) ProtoReflect() protoreflect.Message {
-	mi := &file_dagpool_proto_msgTypes[8]
+	mi := &file_dagpool_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -490,7 +601,7 @@ func (x *RemoveReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RemoveReply.ProtoReflect.Descriptor instead.
 func (*RemoveReply) Descriptor() ([]byte, []int) {
-	return file_dagpool_proto_rawDescGZIP(), []int{8}
+	return file_dagpool_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *RemoveReply) GetMessage() string {
@@ -500,6 +611,266 @@ func (x *RemoveReply) GetMessage() string {
 	return ""
 }
 
+type PinReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Cids []string  `protobuf:"bytes,1,rep,name=cids,proto3" json:"cids,omitempty"`
+	User *PoolUser `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+}
+
+func (x *PinReq) Reset() {
+	*x = PinReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dagpool_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PinReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PinReq) ProtoMessage() {}
+
+func (x *PinReq) ProtoReflect() protoreflect.Message {
+	mi := &file_dagpool_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PinReq.ProtoReflect.Descriptor instead.
+func (*PinReq) Descriptor() ([]byte, []int) {
+	return file_dagpool_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *PinReq) GetCids() []string {
+	if x != nil {
+		return x.Cids
+	}
+	return nil
+}
+
+func (x *PinReq) GetUser() *PoolUser {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+type PinResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Cid string `protobuf:"bytes,1,opt,name=cid,proto3" json:"cid,omitempty"`
+	// error is empty if the cid was handled successfully
+	Error string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *PinResult) Reset() {
+	*x = PinResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dagpool_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PinResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PinResult) ProtoMessage() {}
+
+func (x *PinResult) ProtoReflect() protoreflect.Message {
+	mi := &file_dagpool_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PinResult.ProtoReflect.Descriptor instead.
+func (*PinResult) Descriptor() ([]byte, []int) {
+	return file_dagpool_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *PinResult) GetCid() string {
+	if x != nil {
+		return x.Cid
+	}
+	return ""
+}
+
+func (x *PinResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type PinReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Results []*PinResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+}
+
+func (x *PinReply) Reset() {
+	*x = PinReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dagpool_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PinReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PinReply) ProtoMessage() {}
+
+func (x *PinReply) ProtoReflect() protoreflect.Message {
+	mi := &file_dagpool_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PinReply.ProtoReflect.Descriptor instead.
+func (*PinReply) Descriptor() ([]byte, []int) {
+	return file_dagpool_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *PinReply) GetResults() []*PinResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type UnpinReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Cids []string  `protobuf:"bytes,1,rep,name=cids,proto3" json:"cids,omitempty"`
+	User *PoolUser `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+}
+
+func (x *UnpinReq) Reset() {
+	*x = UnpinReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dagpool_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UnpinReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnpinReq) ProtoMessage() {}
+
+func (x *UnpinReq) ProtoReflect() protoreflect.Message {
+	mi := &file_dagpool_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnpinReq.ProtoReflect.Descriptor instead.
+func (*UnpinReq) Descriptor() ([]byte, []int) {
+	return file_dagpool_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *UnpinReq) GetCids() []string {
+	if x != nil {
+		return x.Cids
+	}
+	return nil
+}
+
+func (x *UnpinReq) GetUser() *PoolUser {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+type UnpinReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Results []*PinResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+}
+
+func (x *UnpinReply) Reset() {
+	*x = UnpinReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dagpool_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UnpinReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnpinReply) ProtoMessage() {}
+
+func (x *UnpinReply) ProtoReflect() protoreflect.Message {
+	mi := &file_dagpool_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnpinReply.ProtoReflect.Descriptor instead.
+func (*UnpinReply) Descriptor() ([]byte, []int) {
+	return file_dagpool_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *UnpinReply) GetResults() []*PinResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
 type AddUserReq struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -509,13 +880,14 @@ type AddUserReq struct {
 	Username string    `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
 	Password string    `protobuf:"bytes,4,opt,name=password,proto3" json:"password,omitempty"`
 	Policy   string    `protobuf:"bytes,5,opt,name=policy,proto3" json:"policy,omitempty"`
-	Capacity uint64    `protobuf:"varint,6,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	// no limit if not set, zero suspends the writes of the user
+	Capacity *uint64 `protobuf:"varint,6,opt,name=capacity,proto3,oneof" json:"capacity,omitempty"`
 }
 
 func (x *AddUserReq) Reset() {
 	*x = AddUserReq{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dagpool_proto_msgTypes[9]
+		mi := &file_dagpool_proto_msgTypes[16]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -528,7 +900,7 @@ func (x *AddUserReq) String() string {
 func (*AddUserReq) ProtoMessage() {}
 
 func (x *AddUserReq) ProtoReflect() protoreflect.Message {
-	mi := &file_dagpool_proto_msgTypes[9]
+	mi := &file_dagpool_proto_msgTypes[16]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -541,7 +913,7 @@ func (x *AddUserReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddUserReq.ProtoReflect.Descriptor instead.
 func (*AddUserReq) Descriptor() ([]byte, []int) {
-	return file_dagpool_proto_rawDescGZIP(), []int{9}
+	return file_dagpool_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *AddUserReq) GetUser() *PoolUser {
@@ -573,8 +945,8 @@ func (x *AddUserReq) GetPolicy() string {
 }
 
 func (x *AddUserReq) GetCapacity() uint64 {
-	if x != nil {
-		return x.Capacity
+	if x != nil && x.Capacity != nil {
+		return *x.Capacity
 	}
 	return 0
 }
@@ -590,7 +962,7 @@ type AddUserReply struct {
 func (x *AddUserReply) Reset() {
 	*x = AddUserReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dagpool_proto_msgTypes[10]
+		mi := &file_dagpool_proto_msgTypes[17]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -603,7 +975,7 @@ func (x *AddUserReply) String() string {
 func (*AddUserReply) ProtoMessage() {}
 
 func (x *AddUserReply) ProtoReflect() protoreflect.Message {
-	mi := &file_dagpool_proto_msgTypes[10]
+	mi := &file_dagpool_proto_msgTypes[17]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -616,7 +988,7 @@ func (x *AddUserReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddUserReply.ProtoReflect.Descriptor instead.
 func (*AddUserReply) Descriptor() ([]byte, []int) {
-	return file_dagpool_proto_rawDescGZIP(), []int{10}
+	return file_dagpool_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *AddUserReply) GetMessage() string {
@@ -638,7 +1010,7 @@ type RemoveUserReq struct {
 func (x *RemoveUserReq) Reset() {
 	*x = RemoveUserReq{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dagpool_proto_msgTypes[11]
+		mi := &file_dagpool_proto_msgTypes[18]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -651,7 +1023,7 @@ func (x *RemoveUserReq) String() string {
 func (*RemoveUserReq) ProtoMessage() {}
 
 func (x *RemoveUserReq) ProtoReflect() protoreflect.Message {
-	mi := &file_dagpool_proto_msgTypes[11]
+	mi := &file_dagpool_proto_msgTypes[18]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -664,7 +1036,7 @@ func (x *RemoveUserReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RemoveUserReq.ProtoReflect.Descriptor instead.
 func (*RemoveUserReq) Descriptor() ([]byte, []int) {
-	return file_dagpool_proto_rawDescGZIP(), []int{11}
+	return file_dagpool_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *RemoveUserReq) GetUser() *PoolUser {
@@ -692,7 +1064,7 @@ type RemoveUserReply struct {
 func (x *RemoveUserReply) Reset() {
 	*x = RemoveUserReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dagpool_proto_msgTypes[12]
+		mi := &file_dagpool_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -705,7 +1077,7 @@ func (x *RemoveUserReply) String() string {
 func (*RemoveUserReply) ProtoMessage() {}
 
 func (x *RemoveUserReply) ProtoReflect() protoreflect.Message {
-	mi := &file_dagpool_proto_msgTypes[12]
+	mi := &file_dagpool_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -718,7 +1090,7 @@ func (x *RemoveUserReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RemoveUserReply.ProtoReflect.Descriptor instead.
 func (*RemoveUserReply) Descriptor() ([]byte, []int) {
-	return file_dagpool_proto_rawDescGZIP(), []int{12}
+	return file_dagpool_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *RemoveUserReply) GetMessage() string {
@@ -740,7 +1112,7 @@ type QueryUserReq struct {
 func (x *QueryUserReq) Reset() {
 	*x = QueryUserReq{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dagpool_proto_msgTypes[13]
+		mi := &file_dagpool_proto_msgTypes[20]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -753,7 +1125,7 @@ func (x *QueryUserReq) String() string {
 func (*QueryUserReq) ProtoMessage() {}
 
 func (x *QueryUserReq) ProtoReflect() protoreflect.Message {
-	mi := &file_dagpool_proto_msgTypes[13]
+	mi := &file_dagpool_proto_msgTypes[20]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -766,7 +1138,7 @@ func (x *QueryUserReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use QueryUserReq.ProtoReflect.Descriptor instead.
 func (*QueryUserReq) Descriptor() ([]byte, []int) {
-	return file_dagpool_proto_rawDescGZIP(), []int{13}
+	return file_dagpool_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *QueryUserReq) GetUser() *PoolUser {
@@ -790,13 +1162,14 @@ type QueryUserReply struct {
 
 	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
 	Policy   string `protobuf:"bytes,3,opt,name=policy,proto3" json:"policy,omitempty"`
-	Capacity uint64 `protobuf:"varint,4,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	// not set means no limit
+	Capacity *uint64 `protobuf:"varint,4,opt,name=capacity,proto3,oneof" json:"capacity,omitempty"`
 }
 
 func (x *QueryUserReply) Reset() {
 	*x = QueryUserReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dagpool_proto_msgTypes[14]
+		mi := &file_dagpool_proto_msgTypes[21]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -809,7 +1182,7 @@ func (x *QueryUserReply) String() string {
 func (*QueryUserReply) ProtoMessage() {}
 
 func (x *QueryUserReply) ProtoReflect() protoreflect.Message {
-	mi := &file_dagpool_proto_msgTypes[14]
+	mi := &file_dagpool_proto_msgTypes[21]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -822,7 +1195,7 @@ func (x *QueryUserReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use QueryUserReply.ProtoReflect.Descriptor instead.
 func (*QueryUserReply) Descriptor() ([]byte, []int) {
-	return file_dagpool_proto_rawDescGZIP(), []int{14}
+	return file_dagpool_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *QueryUserReply) GetUsername() string {
@@ -840,8 +1213,8 @@ func (x *QueryUserReply) GetPolicy() string {
 }
 
 func (x *QueryUserReply) GetCapacity() uint64 {
-	if x != nil {
-		return x.Capacity
+	if x != nil && x.Capacity != nil {
+		return *x.Capacity
 	}
 	return 0
 }
@@ -855,13 +1228,14 @@ type UpdateUserReq struct {
 	Username    string    `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
 	NewPassword string    `protobuf:"bytes,4,opt,name=newPassword,proto3" json:"newPassword,omitempty"`
 	NewPolicy   string    `protobuf:"bytes,5,opt,name=newPolicy,proto3" json:"newPolicy,omitempty"`
-	NewCapacity uint64    `protobuf:"varint,6,opt,name=newCapacity,proto3" json:"newCapacity,omitempty"`
+	// unchanged if not set, zero suspends the writes of the user
+	NewCapacity *uint64 `protobuf:"varint,6,opt,name=newCapacity,proto3,oneof" json:"newCapacity,omitempty"`
 }
 
 func (x *UpdateUserReq) Reset() {
 	*x = UpdateUserReq{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dagpool_proto_msgTypes[15]
+		mi := &file_dagpool_proto_msgTypes[22]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -874,7 +1248,7 @@ func (x *UpdateUserReq) String() string {
 func (*UpdateUserReq) ProtoMessage() {}
 
 func (x *UpdateUserReq) ProtoReflect() protoreflect.Message {
-	mi := &file_dagpool_proto_msgTypes[15]
+	mi := &file_dagpool_proto_msgTypes[22]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -887,7 +1261,7 @@ func (x *UpdateUserReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateUserReq.ProtoReflect.Descriptor instead.
 func (*UpdateUserReq) Descriptor() ([]byte, []int) {
-	return file_dagpool_proto_rawDescGZIP(), []int{15}
+	return file_dagpool_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *UpdateUserReq) GetUser() *PoolUser {
@@ -919,8 +1293,8 @@ func (x *UpdateUserReq) GetNewPolicy() string {
 }
 
 func (x *UpdateUserReq) GetNewCapacity() uint64 {
-	if x != nil {
-		return x.NewCapacity
+	if x != nil && x.NewCapacity != nil {
+		return *x.NewCapacity
 	}
 	return 0
 }
@@ -936,7 +1310,7 @@ type UpdateUserReply struct {
 func (x *UpdateUserReply) Reset() {
 	*x = UpdateUserReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dagpool_proto_msgTypes[16]
+		mi := &file_dagpool_proto_msgTypes[23]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -949,7 +1323,7 @@ func (x *UpdateUserReply) String() string {
 func (*UpdateUserReply) ProtoMessage() {}
 
 func (x *UpdateUserReply) ProtoReflect() protoreflect.Message {
-	mi := &file_dagpool_proto_msgTypes[16]
+	mi := &file_dagpool_proto_msgTypes[23]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -962,7 +1336,7 @@ func (x *UpdateUserReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateUserReply.ProtoReflect.Descriptor instead.
 func (*UpdateUserReply) Descriptor() ([]byte, []int) {
-	return file_dagpool_proto_rawDescGZIP(), []int{16}
+	return file_dagpool_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *UpdateUserReply) GetMessage() string {
@@ -979,12 +1353,17 @@ type DataNodeInfo struct {
 
 	RpcAddress string `protobuf:"bytes,1,opt,name=rpcAddress,proto3" json:"rpcAddress,omitempty"`
 	State      *bool  `protobuf:"varint,2,opt,name=state,proto3,oneof" json:"state,omitempty"`
+	// the unix milliseconds of the last successful heartbeat, not set if there is none
+	LastSeen *int64 `protobuf:"varint,3,opt,name=lastSeen,proto3,oneof" json:"lastSeen,omitempty"`
+	// the total and the free space of the datanode in bytes, not set if they're unknown
+	TotalSpace *uint64 `protobuf:"varint,4,opt,name=totalSpace,proto3,oneof" json:"totalSpace,omitempty"`
+	FreeSpace  *uint64 `protobuf:"varint,5,opt,name=freeSpace,proto3,oneof" json:"freeSpace,omitempty"`
 }
 
 func (x *DataNodeInfo) Reset() {
 	*x = DataNodeInfo{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dagpool_proto_msgTypes[17]
+		mi := &file_dagpool_proto_msgTypes[24]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -997,7 +1376,7 @@ func (x *DataNodeInfo) String() string {
 func (*DataNodeInfo) ProtoMessage() {}
 
 func (x *DataNodeInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_dagpool_proto_msgTypes[17]
+	mi := &file_dagpool_proto_msgTypes[24]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1010,7 +1389,7 @@ func (x *DataNodeInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DataNodeInfo.ProtoReflect.Descriptor instead.
 func (*DataNodeInfo) Descriptor() ([]byte, []int) {
-	return file_dagpool_proto_rawDescGZIP(), []int{17}
+	return file_dagpool_proto_rawDescGZIP(), []int{24}
 }
 
 func (x *DataNodeInfo) GetRpcAddress() string {
@@ -1027,6 +1406,27 @@ func (x *DataNodeInfo) GetState() bool {
 	return false
 }
 
+func (x *DataNodeInfo) GetLastSeen() int64 {
+	if x != nil && x.LastSeen != nil {
+		return *x.LastSeen
+	}
+	return 0
+}
+
+func (x *DataNodeInfo) GetTotalSpace() uint64 {
+	if x != nil && x.TotalSpace != nil {
+		return *x.TotalSpace
+	}
+	return 0
+}
+
+func (x *DataNodeInfo) GetFreeSpace() uint64 {
+	if x != nil && x.FreeSpace != nil {
+		return *x.FreeSpace
+	}
+	return 0
+}
+
 type DagNodeInfo struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1041,7 +1441,7 @@ type DagNodeInfo struct {
 func (x *DagNodeInfo) Reset() {
 	*x = DagNodeInfo{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dagpool_proto_msgTypes[18]
+		mi := &file_dagpool_proto_msgTypes[25]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1054,7 +1454,7 @@ func (x *DagNodeInfo) String() string {
 func (*DagNodeInfo) ProtoMessage() {}
 
 func (x *DagNodeInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_dagpool_proto_msgTypes[18]
+	mi := &file_dagpool_proto_msgTypes[25]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1067,7 +1467,7 @@ func (x *DagNodeInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DagNodeInfo.ProtoReflect.Descriptor instead.
 func (*DagNodeInfo) Descriptor() ([]byte, []int) {
-	return file_dagpool_proto_rawDescGZIP(), []int{18}
+	return file_dagpool_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *DagNodeInfo) GetName() string {
@@ -1109,7 +1509,7 @@ type GetDagNodeReq struct {
 func (x *GetDagNodeReq) Reset() {
 	*x = GetDagNodeReq{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dagpool_proto_msgTypes[19]
+		mi := &file_dagpool_proto_msgTypes[26]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1122,7 +1522,7 @@ func (x *GetDagNodeReq) String() string {
 func (*GetDagNodeReq) ProtoMessage() {}
 
 func (x *GetDagNodeReq) ProtoReflect() protoreflect.Message {
-	mi := &file_dagpool_proto_msgTypes[19]
+	mi := &file_dagpool_proto_msgTypes[26]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1135,7 +1535,7 @@ func (x *GetDagNodeReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetDagNodeReq.ProtoReflect.Descriptor instead.
 func (*GetDagNodeReq) Descriptor() ([]byte, []int) {
-	return file_dagpool_proto_rawDescGZIP(), []int{19}
+	return file_dagpool_proto_rawDescGZIP(), []int{26}
 }
 
 func (x *GetDagNodeReq) GetName() string {
@@ -1156,7 +1556,7 @@ type RemoveDagNodeReq struct {
 func (x *RemoveDagNodeReq) Reset() {
 	*x = RemoveDagNodeReq{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dagpool_proto_msgTypes[20]
+		mi := &file_dagpool_proto_msgTypes[27]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1169,7 +1569,7 @@ func (x *RemoveDagNodeReq) String() string {
 func (*RemoveDagNodeReq) ProtoMessage() {}
 
 func (x *RemoveDagNodeReq) ProtoReflect() protoreflect.Message {
-	mi := &file_dagpool_proto_msgTypes[20]
+	mi := &file_dagpool_proto_msgTypes[27]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1182,7 +1582,7 @@ func (x *RemoveDagNodeReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RemoveDagNodeReq.ProtoReflect.Descriptor instead.
 func (*RemoveDagNodeReq) Descriptor() ([]byte, []int) {
-	return file_dagpool_proto_rawDescGZIP(), []int{20}
+	return file_dagpool_proto_rawDescGZIP(), []int{27}
 }
 
 func (x *RemoveDagNodeReq) GetName() string {
@@ -1204,7 +1604,7 @@ type SlotPair struct {
 func (x *SlotPair) Reset() {
 	*x = SlotPair{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dagpool_proto_msgTypes[21]
+		mi := &file_dagpool_proto_msgTypes[28]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1217,7 +1617,7 @@ func (x *SlotPair) String() string {
 func (*SlotPair) ProtoMessage() {}
 
 func (x *SlotPair) ProtoReflect() protoreflect.Message {
-	mi := &file_dagpool_proto_msgTypes[21]
+	mi := &file_dagpool_proto_msgTypes[28]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1230,7 +1630,7 @@ func (x *SlotPair) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SlotPair.ProtoReflect.Descriptor instead.
 func (*SlotPair) Descriptor() ([]byte, []int) {
-	return file_dagpool_proto_rawDescGZIP(), []int{21}
+	return file_dagpool_proto_rawDescGZIP(), []int{28}
 }
 
 func (x *SlotPair) GetStart() uint32 {
@@ -1260,7 +1660,7 @@ type MigrateSlotsReq struct {
 func (x *MigrateSlotsReq) Reset() {
 	*x = MigrateSlotsReq{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dagpool_proto_msgTypes[22]
+		mi := &file_dagpool_proto_msgTypes[29]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1273,7 +1673,7 @@ func (x *MigrateSlotsReq) String() string {
 func (*MigrateSlotsReq) ProtoMessage() {}
 
 func (x *MigrateSlotsReq) ProtoReflect() protoreflect.Message {
-	mi := &file_dagpool_proto_msgTypes[22]
+	mi := &file_dagpool_proto_msgTypes[29]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1286,7 +1686,7 @@ func (x *MigrateSlotsReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MigrateSlotsReq.ProtoReflect.Descriptor instead.
 func (*MigrateSlotsReq) Descriptor() ([]byte, []int) {
-	return file_dagpool_proto_rawDescGZIP(), []int{22}
+	return file_dagpool_proto_rawDescGZIP(), []int{29}
 }
 
 func (x *MigrateSlotsReq) GetFromDagNodeName() string {
@@ -1317,12 +1717,21 @@ type DagNodeStatus struct {
 
 	Node  *DagNodeInfo `protobuf:"bytes,1,opt,name=node,proto3" json:"node,omitempty"`
 	Pairs []*SlotPair  `protobuf:"bytes,2,rep,name=pairs,proto3" json:"pairs,omitempty"`
+	// the heartbeat interval and timeout of the datanodes in milliseconds
+	HeartbeatInterval int64 `protobuf:"varint,3,opt,name=heartbeatInterval,proto3" json:"heartbeatInterval,omitempty"`
+	HeartbeatTimeout  int64 `protobuf:"varint,4,opt,name=heartbeatTimeout,proto3" json:"heartbeatTimeout,omitempty"`
+	// the consecutive failed heartbeats before a datanode is down
+	HeartbeatMaxMisses int32 `protobuf:"varint,5,opt,name=heartbeatMaxMisses,proto3" json:"heartbeatMaxMisses,omitempty"`
+	// the slots of the dag node are being migrated to the other dag nodes
+	Draining bool `protobuf:"varint,6,opt,name=draining,proto3" json:"draining,omitempty"`
+	// the dag node is drained, it has no slot and it's safe to remove it
+	Drained bool `protobuf:"varint,7,opt,name=drained,proto3" json:"drained,omitempty"`
 }
 
 func (x *DagNodeStatus) Reset() {
 	*x = DagNodeStatus{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dagpool_proto_msgTypes[23]
+		mi := &file_dagpool_proto_msgTypes[30]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1335,7 +1744,7 @@ func (x *DagNodeStatus) String() string {
 func (*DagNodeStatus) ProtoMessage() {}
 
 func (x *DagNodeStatus) ProtoReflect() protoreflect.Message {
-	mi := &file_dagpool_proto_msgTypes[23]
+	mi := &file_dagpool_proto_msgTypes[30]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1348,7 +1757,7 @@ func (x *DagNodeStatus) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DagNodeStatus.ProtoReflect.Descriptor instead.
 func (*DagNodeStatus) Descriptor() ([]byte, []int) {
-	return file_dagpool_proto_rawDescGZIP(), []int{23}
+	return file_dagpool_proto_rawDescGZIP(), []int{30}
 }
 
 func (x *DagNodeStatus) GetNode() *DagNodeInfo {
@@ -1365,6 +1774,41 @@ func (x *DagNodeStatus) GetPairs() []*SlotPair {
 	return nil
 }
 
+func (x *DagNodeStatus) GetHeartbeatInterval() int64 {
+	if x != nil {
+		return x.HeartbeatInterval
+	}
+	return 0
+}
+
+func (x *DagNodeStatus) GetHeartbeatTimeout() int64 {
+	if x != nil {
+		return x.HeartbeatTimeout
+	}
+	return 0
+}
+
+func (x *DagNodeStatus) GetHeartbeatMaxMisses() int32 {
+	if x != nil {
+		return x.HeartbeatMaxMisses
+	}
+	return 0
+}
+
+func (x *DagNodeStatus) GetDraining() bool {
+	if x != nil {
+		return x.Draining
+	}
+	return false
+}
+
+func (x *DagNodeStatus) GetDrained() bool {
+	if x != nil {
+		return x.Drained
+	}
+	return false
+}
+
 type StatusReply struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1377,7 +1821,7 @@ type StatusReply struct {
 func (x *StatusReply) Reset() {
 	*x = StatusReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dagpool_proto_msgTypes[24]
+		mi := &file_dagpool_proto_msgTypes[31]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1390,7 +1834,7 @@ func (x *StatusReply) String() string {
 func (*StatusReply) ProtoMessage() {}
 
 func (x *StatusReply) ProtoReflect() protoreflect.Message {
-	mi := &file_dagpool_proto_msgTypes[24]
+	mi := &file_dagpool_proto_msgTypes[31]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1403,7 +1847,7 @@ func (x *StatusReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatusReply.ProtoReflect.Descriptor instead.
 func (*StatusReply) Descriptor() ([]byte, []int) {
-	return file_dagpool_proto_rawDescGZIP(), []int{24}
+	return file_dagpool_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *StatusReply) GetState() string {
@@ -1420,6 +1864,53 @@ func (x *StatusReply) GetStatuses() []*DagNodeStatus {
 	return nil
 }
 
+type DrainDagNodeReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+func (x *DrainDagNodeReq) Reset() {
+	*x = DrainDagNodeReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dagpool_proto_msgTypes[32]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DrainDagNodeReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DrainDagNodeReq) ProtoMessage() {}
+
+func (x *DrainDagNodeReq) ProtoReflect() protoreflect.Message {
+	mi := &file_dagpool_proto_msgTypes[32]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DrainDagNodeReq.ProtoReflect.Descriptor instead.
+func (*DrainDagNodeReq) Descriptor() ([]byte, []int) {
+	return file_dagpool_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *DrainDagNodeReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
 type RepairDataNodeReq struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -1433,7 +1924,7 @@ type RepairDataNodeReq struct {
 func (x *RepairDataNodeReq) Reset() {
 	*x = RepairDataNodeReq{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dagpool_proto_msgTypes[25]
+		mi := &file_dagpool_proto_msgTypes[33]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1446,7 +1937,7 @@ func (x *RepairDataNodeReq) String() string {
 func (*RepairDataNodeReq) ProtoMessage() {}
 
 func (x *RepairDataNodeReq) ProtoReflect() protoreflect.Message {
-	mi := &file_dagpool_proto_msgTypes[25]
+	mi := &file_dagpool_proto_msgTypes[33]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1459,7 +1950,7 @@ func (x *RepairDataNodeReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RepairDataNodeReq.ProtoReflect.Descriptor instead.
 func (*RepairDataNodeReq) Descriptor() ([]byte, []int) {
-	return file_dagpool_proto_rawDescGZIP(), []int{25}
+	return file_dagpool_proto_rawDescGZIP(), []int{33}
 }
 
 func (x *RepairDataNodeReq) GetDagNodeName() string {
@@ -1498,184 +1989,250 @@ var file_dagpool_proto_rawDesc = []byte{
 	0x23, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e,
 	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x6f, 0x6f, 0x6c, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04,
 	0x75, 0x73, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x69, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28,
-	0x08, 0x52, 0x03, 0x70, 0x69, 0x6e, 0x22, 0x1c, 0x0a, 0x08, 0x41, 0x64, 0x64, 0x52, 0x65, 0x70,
+	0x08, 0x52, 0x03, 0x70, 0x69, 0x6e, 0x22, 0x34, 0x0a, 0x08, 0x41, 0x64, 0x64, 0x52, 0x65, 0x70,
 	0x6c, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x03, 0x63, 0x69, 0x64, 0x22, 0x3f, 0x0a, 0x06, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x12, 0x10,
-	0x0a, 0x03, 0x63, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x63, 0x69, 0x64,
-	0x12, 0x23, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x6f, 0x6f, 0x6c, 0x55, 0x73, 0x65, 0x72, 0x52,
-	0x04, 0x75, 0x73, 0x65, 0x72, 0x22, 0x20, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x52, 0x65, 0x70, 0x6c,
-	0x79, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c,
-	0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x22, 0x43, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x53, 0x69,
-	0x7a, 0x65, 0x52, 0x65, 0x71, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x03, 0x63, 0x69, 0x64, 0x12, 0x23, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x6f,
-	0x6f, 0x6c, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x22, 0x22, 0x0a, 0x0c,
-	0x47, 0x65, 0x74, 0x53, 0x69, 0x7a, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x12, 0x0a, 0x04,
-	0x73, 0x69, 0x7a, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65,
-	0x22, 0x58, 0x0a, 0x09, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x52, 0x65, 0x71, 0x12, 0x10, 0x0a,
+	0x03, 0x63, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x78, 0x69, 0x73, 0x74, 0x73, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x65, 0x78, 0x69, 0x73, 0x74, 0x73, 0x22, 0x3f, 0x0a, 0x06,
+	0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x63, 0x69, 0x64, 0x12, 0x23, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50,
+	0x6f, 0x6f, 0x6c, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x22, 0x20, 0x0a,
+	0x08, 0x47, 0x65, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x6c, 0x6f,
+	0x63, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x22,
+	0x43, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x53, 0x69, 0x7a, 0x65, 0x52, 0x65, 0x71, 0x12, 0x10, 0x0a,
 	0x03, 0x63, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x63, 0x69, 0x64, 0x12,
 	0x23, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e,
 	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x6f, 0x6f, 0x6c, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04,
-	0x75, 0x73, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x75, 0x6e, 0x70, 0x69, 0x6e, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x08, 0x52, 0x05, 0x75, 0x6e, 0x70, 0x69, 0x6e, 0x22, 0x27, 0x0a, 0x0b, 0x52, 0x65,
-	0x6d, 0x6f, 0x76, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73,
-	0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73,
-	0x61, 0x67, 0x65, 0x22, 0x9d, 0x01, 0x0a, 0x0a, 0x41, 0x64, 0x64, 0x55, 0x73, 0x65, 0x72, 0x52,
-	0x65, 0x71, 0x12, 0x23, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x6f, 0x6f, 0x6c, 0x55, 0x73, 0x65,
-	0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e,
-	0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e,
-	0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18,
-	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x12,
-	0x16, 0x0a, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63,
-	0x69, 0x74, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63,
-	0x69, 0x74, 0x79, 0x22, 0x28, 0x0a, 0x0c, 0x41, 0x64, 0x64, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65,
-	0x70, 0x6c, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x50, 0x0a,
-	0x0d, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x12, 0x23,
-	0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x6f, 0x6f, 0x6c, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75,
-	0x73, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x22,
-	0x2b, 0x0a, 0x0f, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x70,
-	0x6c, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x4f, 0x0a, 0x0c,
-	0x51, 0x75, 0x65, 0x72, 0x79, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x12, 0x23, 0x0a, 0x04,
-	0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x2e, 0x50, 0x6f, 0x6f, 0x6c, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65,
-	0x72, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x60, 0x0a,
-	0x0e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12,
-	0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x70,
-	0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x6f, 0x6c,
-	0x69, 0x63, 0x79, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x18,
-	0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x22,
-	0xb2, 0x01, 0x0a, 0x0d, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65,
-	0x71, 0x12, 0x23, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x6f, 0x6f, 0x6c, 0x55, 0x73, 0x65, 0x72,
-	0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61,
-	0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61,
-	0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x6e, 0x65, 0x77, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72,
-	0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6e, 0x65, 0x77, 0x50, 0x61, 0x73, 0x73,
-	0x77, 0x6f, 0x72, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x6e, 0x65, 0x77, 0x50, 0x6f, 0x6c, 0x69, 0x63,
-	0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6e, 0x65, 0x77, 0x50, 0x6f, 0x6c, 0x69,
-	0x63, 0x79, 0x12, 0x20, 0x0a, 0x0b, 0x6e, 0x65, 0x77, 0x43, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74,
-	0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x6e, 0x65, 0x77, 0x43, 0x61, 0x70, 0x61,
-	0x63, 0x69, 0x74, 0x79, 0x22, 0x2b, 0x0a, 0x0f, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x55, 0x73,
-	0x65, 0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61,
-	0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
-	0x65, 0x22, 0x53, 0x0a, 0x0c, 0x44, 0x61, 0x74, 0x61, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x66,
+	0x75, 0x73, 0x65, 0x72, 0x22, 0x22, 0x0a, 0x0c, 0x47, 0x65, 0x74, 0x53, 0x69, 0x7a, 0x65, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x22, 0x3f, 0x0a, 0x06, 0x48, 0x61, 0x73, 0x52,
+	0x65, 0x71, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x63, 0x69, 0x64, 0x12, 0x23, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x6f, 0x6f, 0x6c, 0x55,
+	0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x22, 0x1c, 0x0a, 0x08, 0x48, 0x61, 0x73,
+	0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x68, 0x61, 0x73, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x03, 0x68, 0x61, 0x73, 0x22, 0x58, 0x0a, 0x09, 0x52, 0x65, 0x6d, 0x6f, 0x76,
+	0x65, 0x52, 0x65, 0x71, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x63, 0x69, 0x64, 0x12, 0x23, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x6f, 0x6f,
+	0x6c, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x75,
+	0x6e, 0x70, 0x69, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x75, 0x6e, 0x70, 0x69,
+	0x6e, 0x22, 0x27, 0x0a, 0x0b, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79,
+	0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x41, 0x0a, 0x06, 0x50, 0x69,
+	0x6e, 0x52, 0x65, 0x71, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x69, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x04, 0x63, 0x69, 0x64, 0x73, 0x12, 0x23, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50,
+	0x6f, 0x6f, 0x6c, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x22, 0x33, 0x0a,
+	0x09, 0x50, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x63, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05,
+	0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x22, 0x36, 0x0a, 0x08, 0x50, 0x69, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x2a,
+	0x0a, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x10, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x52, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x22, 0x43, 0x0a, 0x08, 0x55, 0x6e,
+	0x70, 0x69, 0x6e, 0x52, 0x65, 0x71, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x69, 0x64, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x63, 0x69, 0x64, 0x73, 0x12, 0x23, 0x0a, 0x04, 0x75, 0x73,
+	0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2e, 0x50, 0x6f, 0x6f, 0x6c, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x22,
+	0x38, 0x0a, 0x0a, 0x55, 0x6e, 0x70, 0x69, 0x6e, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x2a, 0x0a,
+	0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x52, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x22, 0xaf, 0x01, 0x0a, 0x0a, 0x41, 0x64,
+	0x64, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x12, 0x23, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50,
+	0x6f, 0x6f, 0x6c, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1a, 0x0a,
+	0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x61, 0x73,
+	0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x61, 0x73,
+	0x73, 0x77, 0x6f, 0x72, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x1f, 0x0a,
+	0x08, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x48,
+	0x00, 0x52, 0x08, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x88, 0x01, 0x01, 0x42, 0x0b,
+	0x0a, 0x09, 0x5f, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x22, 0x28, 0x0a, 0x0c, 0x41,
+	0x64, 0x64, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x6d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x50, 0x0a, 0x0d, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x55,
+	0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x12, 0x23, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x6f, 0x6f,
+	0x6c, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x75,
+	0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75,
+	0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x2b, 0x0a, 0x0f, 0x52, 0x65, 0x6d, 0x6f, 0x76,
+	0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x22, 0x4f, 0x0a, 0x0c, 0x51, 0x75, 0x65, 0x72, 0x79, 0x55, 0x73, 0x65,
+	0x72, 0x52, 0x65, 0x71, 0x12, 0x23, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x6f, 0x6f, 0x6c, 0x55,
+	0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65,
+	0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65,
+	0x72, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x72, 0x0a, 0x0e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x55, 0x73,
+	0x65, 0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e,
+	0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x1f, 0x0a, 0x08, 0x63,
+	0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x48, 0x00, 0x52,
+	0x08, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x88, 0x01, 0x01, 0x42, 0x0b, 0x0a, 0x09,
+	0x5f, 0x63, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x22, 0xc7, 0x01, 0x0a, 0x0d, 0x55, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x12, 0x23, 0x0a, 0x04, 0x75,
+	0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2e, 0x50, 0x6f, 0x6f, 0x6c, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72,
+	0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b,
+	0x6e, 0x65, 0x77, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0b, 0x6e, 0x65, 0x77, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x12, 0x1c,
+	0x0a, 0x09, 0x6e, 0x65, 0x77, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x6e, 0x65, 0x77, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x25, 0x0a, 0x0b,
+	0x6e, 0x65, 0x77, 0x43, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x04, 0x48, 0x00, 0x52, 0x0b, 0x6e, 0x65, 0x77, 0x43, 0x61, 0x70, 0x61, 0x63, 0x69, 0x74, 0x79,
+	0x88, 0x01, 0x01, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x6e, 0x65, 0x77, 0x43, 0x61, 0x70, 0x61, 0x63,
+	0x69, 0x74, 0x79, 0x22, 0x2b, 0x0a, 0x0f, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x55, 0x73, 0x65,
+	0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x22, 0xe6, 0x01, 0x0a, 0x0c, 0x44, 0x61, 0x74, 0x61, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x66,
 	0x6f, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x70, 0x63, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18,
 	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x72, 0x70, 0x63, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73,
 	0x73, 0x12, 0x19, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08,
-	0x48, 0x00, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x88, 0x01, 0x01, 0x42, 0x08, 0x0a, 0x06,
-	0x5f, 0x73, 0x74, 0x61, 0x74, 0x65, 0x22, 0x90, 0x01, 0x0a, 0x0b, 0x44, 0x61, 0x67, 0x4e, 0x6f,
-	0x64, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x29, 0x0a, 0x05, 0x6e, 0x6f,
-	0x64, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x05,
-	0x6e, 0x6f, 0x64, 0x65, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x64, 0x61, 0x74, 0x61, 0x42, 0x6c, 0x6f,
-	0x63, 0x6b, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x64, 0x61, 0x74, 0x61, 0x42,
-	0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x61, 0x72, 0x69, 0x74, 0x79, 0x42,
-	0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c, 0x70, 0x61, 0x72,
-	0x69, 0x74, 0x79, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x22, 0x23, 0x0a, 0x0d, 0x47, 0x65, 0x74,
-	0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x71, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
-	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x26,
-	0x0a, 0x10, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x52,
-	0x65, 0x71, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x32, 0x0a, 0x08, 0x53, 0x6c, 0x6f, 0x74, 0x50, 0x61,
-	0x69, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x0d, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x65, 0x6e, 0x64, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x65, 0x6e, 0x64, 0x22, 0x88, 0x01, 0x0a, 0x0f, 0x4d,
-	0x69, 0x67, 0x72, 0x61, 0x74, 0x65, 0x53, 0x6c, 0x6f, 0x74, 0x73, 0x52, 0x65, 0x71, 0x12, 0x28,
-	0x0a, 0x0f, 0x66, 0x72, 0x6f, 0x6d, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x4e, 0x61, 0x6d,
-	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x66, 0x72, 0x6f, 0x6d, 0x44, 0x61, 0x67,
-	0x4e, 0x6f, 0x64, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x24, 0x0a, 0x0d, 0x74, 0x6f, 0x44, 0x61,
-	0x67, 0x4e, 0x6f, 0x64, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x0d, 0x74, 0x6f, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x25,
-	0x0a, 0x05, 0x70, 0x61, 0x69, 0x72, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x53, 0x6c, 0x6f, 0x74, 0x50, 0x61, 0x69, 0x72, 0x52, 0x05,
-	0x70, 0x61, 0x69, 0x72, 0x73, 0x22, 0x5e, 0x0a, 0x0d, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65,
-	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x26, 0x0a, 0x04, 0x6e, 0x6f, 0x64, 0x65, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x44, 0x61, 0x67,
-	0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x04, 0x6e, 0x6f, 0x64, 0x65, 0x12, 0x25,
-	0x0a, 0x05, 0x70, 0x61, 0x69, 0x72, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x53, 0x6c, 0x6f, 0x74, 0x50, 0x61, 0x69, 0x72, 0x52, 0x05,
-	0x70, 0x61, 0x69, 0x72, 0x73, 0x22, 0x55, 0x0a, 0x0b, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52,
-	0x65, 0x70, 0x6c, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x12, 0x30, 0x0a, 0x08, 0x73, 0x74,
-	0x61, 0x74, 0x75, 0x73, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x74, 0x61, 0x74,
-	0x75, 0x73, 0x52, 0x08, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x65, 0x73, 0x22, 0x85, 0x01, 0x0a,
-	0x11, 0x52, 0x65, 0x70, 0x61, 0x69, 0x72, 0x44, 0x61, 0x74, 0x61, 0x4e, 0x6f, 0x64, 0x65, 0x52,
-	0x65, 0x71, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x4e, 0x61, 0x6d,
-	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65,
-	0x4e, 0x61, 0x6d, 0x65, 0x12, 0x24, 0x0a, 0x0d, 0x66, 0x72, 0x6f, 0x6d, 0x4e, 0x6f, 0x64, 0x65,
-	0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x66, 0x72, 0x6f,
-	0x6d, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x28, 0x0a, 0x0f, 0x72, 0x65,
-	0x70, 0x61, 0x69, 0x72, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x05, 0x52, 0x0f, 0x72, 0x65, 0x70, 0x61, 0x69, 0x72, 0x4e, 0x6f, 0x64, 0x65, 0x49,
-	0x6e, 0x64, 0x65, 0x78, 0x32, 0xae, 0x03, 0x0a, 0x07, 0x44, 0x61, 0x67, 0x50, 0x6f, 0x6f, 0x6c,
-	0x12, 0x27, 0x0a, 0x03, 0x41, 0x64, 0x64, 0x12, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
-	0x41, 0x64, 0x64, 0x52, 0x65, 0x71, 0x1a, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x41,
-	0x64, 0x64, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x27, 0x0a, 0x03, 0x47, 0x65, 0x74,
-	0x12, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x1a,
-	0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79,
-	0x22, 0x00, 0x12, 0x30, 0x0a, 0x06, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x12, 0x10, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x12,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x52, 0x65, 0x70,
-	0x6c, 0x79, 0x22, 0x00, 0x12, 0x33, 0x0a, 0x07, 0x47, 0x65, 0x74, 0x53, 0x69, 0x7a, 0x65, 0x12,
-	0x11, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x47, 0x65, 0x74, 0x53, 0x69, 0x7a, 0x65, 0x52,
-	0x65, 0x71, 0x1a, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x47, 0x65, 0x74, 0x53, 0x69,
-	0x7a, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x33, 0x0a, 0x07, 0x41, 0x64, 0x64,
-	0x55, 0x73, 0x65, 0x72, 0x12, 0x11, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x41, 0x64, 0x64,
-	0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x1a, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
-	0x41, 0x64, 0x64, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x3c,
-	0x0a, 0x0a, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x55, 0x73, 0x65, 0x72, 0x12, 0x14, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x55, 0x73, 0x65, 0x72, 0x52,
-	0x65, 0x71, 0x1a, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x65, 0x6d, 0x6f, 0x76,
-	0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x39, 0x0a, 0x09,
-	0x51, 0x75, 0x65, 0x72, 0x79, 0x55, 0x73, 0x65, 0x72, 0x12, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x2e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x1a, 0x15,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x55, 0x73, 0x65, 0x72,
-	0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x3c, 0x0a, 0x0a, 0x55, 0x70, 0x64, 0x61, 0x74,
-	0x65, 0x55, 0x73, 0x65, 0x72, 0x12, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x55, 0x70,
-	0x64, 0x61, 0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x1a, 0x16, 0x2e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65,
-	0x70, 0x6c, 0x79, 0x22, 0x00, 0x32, 0xc8, 0x03, 0x0a, 0x0e, 0x44, 0x61, 0x67, 0x50, 0x6f, 0x6f,
-	0x6c, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x12, 0x3a, 0x0a, 0x0a, 0x41, 0x64, 0x64, 0x44,
-	0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x44,
-	0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f,
-	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70,
-	0x74, 0x79, 0x22, 0x00, 0x12, 0x38, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x44, 0x61, 0x67, 0x4e, 0x6f,
-	0x64, 0x65, 0x12, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x61,
-	0x67, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x2e, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x00, 0x12, 0x3e,
-	0x0a, 0x0d, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x12,
-	0x17, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x44, 0x61,
-	0x67, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x2e, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x00, 0x12, 0x40,
-	0x0a, 0x0c, 0x4d, 0x69, 0x67, 0x72, 0x61, 0x74, 0x65, 0x53, 0x6c, 0x6f, 0x74, 0x73, 0x12, 0x16,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x4d, 0x69, 0x67, 0x72, 0x61, 0x74, 0x65, 0x53, 0x6c,
-	0x6f, 0x74, 0x73, 0x52, 0x65, 0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x48, 0x00, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x88, 0x01, 0x01, 0x12, 0x1f, 0x0a, 0x08,
+	0x6c, 0x61, 0x73, 0x74, 0x53, 0x65, 0x65, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x48, 0x01,
+	0x52, 0x08, 0x6c, 0x61, 0x73, 0x74, 0x53, 0x65, 0x65, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x23, 0x0a,
+	0x0a, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x53, 0x70, 0x61, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x04, 0x48, 0x02, 0x52, 0x0a, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x53, 0x70, 0x61, 0x63, 0x65, 0x88,
+	0x01, 0x01, 0x12, 0x21, 0x0a, 0x09, 0x66, 0x72, 0x65, 0x65, 0x53, 0x70, 0x61, 0x63, 0x65, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x04, 0x48, 0x03, 0x52, 0x09, 0x66, 0x72, 0x65, 0x65, 0x53, 0x70, 0x61,
+	0x63, 0x65, 0x88, 0x01, 0x01, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x65, 0x42,
+	0x0b, 0x0a, 0x09, 0x5f, 0x6c, 0x61, 0x73, 0x74, 0x53, 0x65, 0x65, 0x6e, 0x42, 0x0d, 0x0a, 0x0b,
+	0x5f, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x53, 0x70, 0x61, 0x63, 0x65, 0x42, 0x0c, 0x0a, 0x0a, 0x5f,
+	0x66, 0x72, 0x65, 0x65, 0x53, 0x70, 0x61, 0x63, 0x65, 0x22, 0x90, 0x01, 0x0a, 0x0b, 0x44, 0x61,
+	0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x29, 0x0a,
+	0x05, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x66,
+	0x6f, 0x52, 0x05, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x64, 0x61, 0x74, 0x61,
+	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x64, 0x61,
+	0x74, 0x61, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x61, 0x72, 0x69,
+	0x74, 0x79, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c,
+	0x70, 0x61, 0x72, 0x69, 0x74, 0x79, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x22, 0x23, 0x0a, 0x0d,
+	0x47, 0x65, 0x74, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x71, 0x12, 0x12, 0x0a,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x22, 0x26, 0x0a, 0x10, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x44, 0x61, 0x67, 0x4e, 0x6f,
+	0x64, 0x65, 0x52, 0x65, 0x71, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x32, 0x0a, 0x08, 0x53, 0x6c, 0x6f,
+	0x74, 0x50, 0x61, 0x69, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x65,
+	0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x65, 0x6e, 0x64, 0x22, 0x88, 0x01,
+	0x0a, 0x0f, 0x4d, 0x69, 0x67, 0x72, 0x61, 0x74, 0x65, 0x53, 0x6c, 0x6f, 0x74, 0x73, 0x52, 0x65,
+	0x71, 0x12, 0x28, 0x0a, 0x0f, 0x66, 0x72, 0x6f, 0x6d, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65,
+	0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x66, 0x72, 0x6f, 0x6d,
+	0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x24, 0x0a, 0x0d, 0x74,
+	0x6f, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0d, 0x74, 0x6f, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x4e, 0x61, 0x6d,
+	0x65, 0x12, 0x25, 0x0a, 0x05, 0x70, 0x61, 0x69, 0x72, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x53, 0x6c, 0x6f, 0x74, 0x50, 0x61, 0x69,
+	0x72, 0x52, 0x05, 0x70, 0x61, 0x69, 0x72, 0x73, 0x22, 0x9e, 0x02, 0x0a, 0x0d, 0x44, 0x61, 0x67,
+	0x4e, 0x6f, 0x64, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x26, 0x0a, 0x04, 0x6e, 0x6f,
+	0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2e, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x04, 0x6e, 0x6f,
+	0x64, 0x65, 0x12, 0x25, 0x0a, 0x05, 0x70, 0x61, 0x69, 0x72, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x53, 0x6c, 0x6f, 0x74, 0x50, 0x61,
+	0x69, 0x72, 0x52, 0x05, 0x70, 0x61, 0x69, 0x72, 0x73, 0x12, 0x2c, 0x0a, 0x11, 0x68, 0x65, 0x61,
+	0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x11, 0x68, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x49,
+	0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x12, 0x2a, 0x0a, 0x10, 0x68, 0x65, 0x61, 0x72, 0x74,
+	0x62, 0x65, 0x61, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x10, 0x68, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x54, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x12, 0x2e, 0x0a, 0x12, 0x68, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74,
+	0x4d, 0x61, 0x78, 0x4d, 0x69, 0x73, 0x73, 0x65, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x12, 0x68, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x4d, 0x61, 0x78, 0x4d, 0x69, 0x73,
+	0x73, 0x65, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x72, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x64, 0x72, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x12,
+	0x18, 0x0a, 0x07, 0x64, 0x72, 0x61, 0x69, 0x6e, 0x65, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x07, 0x64, 0x72, 0x61, 0x69, 0x6e, 0x65, 0x64, 0x22, 0x55, 0x0a, 0x0b, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x12, 0x30,
+	0x0a, 0x08, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x08, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x65, 0x73,
+	0x22, 0x25, 0x0a, 0x0f, 0x44, 0x72, 0x61, 0x69, 0x6e, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65,
+	0x52, 0x65, 0x71, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x85, 0x01, 0x0a, 0x11, 0x52, 0x65, 0x70, 0x61,
+	0x69, 0x72, 0x44, 0x61, 0x74, 0x61, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x71, 0x12, 0x20, 0x0a,
+	0x0b, 0x64, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0b, 0x64, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12,
+	0x24, 0x0a, 0x0d, 0x66, 0x72, 0x6f, 0x6d, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x66, 0x72, 0x6f, 0x6d, 0x4e, 0x6f, 0x64, 0x65,
+	0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x28, 0x0a, 0x0f, 0x72, 0x65, 0x70, 0x61, 0x69, 0x72, 0x4e,
+	0x6f, 0x64, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0f,
+	0x72, 0x65, 0x70, 0x61, 0x69, 0x72, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x32,
+	0xaf, 0x04, 0x0a, 0x07, 0x44, 0x61, 0x67, 0x50, 0x6f, 0x6f, 0x6c, 0x12, 0x27, 0x0a, 0x03, 0x41,
+	0x64, 0x64, 0x12, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x41, 0x64, 0x64, 0x52, 0x65,
+	0x71, 0x1a, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x41, 0x64, 0x64, 0x52, 0x65, 0x70,
+	0x6c, 0x79, 0x22, 0x00, 0x12, 0x27, 0x0a, 0x03, 0x47, 0x65, 0x74, 0x12, 0x0d, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x71, 0x1a, 0x0f, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x47, 0x65, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x30, 0x0a,
+	0x06, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x12, 0x10, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
+	0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2e, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12,
+	0x33, 0x0a, 0x07, 0x47, 0x65, 0x74, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x11, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x47, 0x65, 0x74, 0x53, 0x69, 0x7a, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x13, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x47, 0x65, 0x74, 0x53, 0x69, 0x7a, 0x65, 0x52, 0x65, 0x70,
+	0x6c, 0x79, 0x22, 0x00, 0x12, 0x27, 0x0a, 0x03, 0x48, 0x61, 0x73, 0x12, 0x0d, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2e, 0x48, 0x61, 0x73, 0x52, 0x65, 0x71, 0x1a, 0x0f, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x48, 0x61, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x27, 0x0a,
+	0x03, 0x50, 0x69, 0x6e, 0x12, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x69, 0x6e,
+	0x52, 0x65, 0x71, 0x1a, 0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x50, 0x69, 0x6e, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x2d, 0x0a, 0x05, 0x55, 0x6e, 0x70, 0x69, 0x6e, 0x12,
+	0x0f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x55, 0x6e, 0x70, 0x69, 0x6e, 0x52, 0x65, 0x71,
+	0x1a, 0x11, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x55, 0x6e, 0x70, 0x69, 0x6e, 0x52, 0x65,
+	0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x33, 0x0a, 0x07, 0x41, 0x64, 0x64, 0x55, 0x73, 0x65, 0x72,
+	0x12, 0x11, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x41, 0x64, 0x64, 0x55, 0x73, 0x65, 0x72,
+	0x52, 0x65, 0x71, 0x1a, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x41, 0x64, 0x64, 0x55,
+	0x73, 0x65, 0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x3c, 0x0a, 0x0a, 0x52, 0x65,
+	0x6d, 0x6f, 0x76, 0x65, 0x55, 0x73, 0x65, 0x72, 0x12, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2e, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x1a, 0x16,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x55, 0x73, 0x65,
+	0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x39, 0x0a, 0x09, 0x51, 0x75, 0x65, 0x72,
+	0x79, 0x55, 0x73, 0x65, 0x72, 0x12, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x51, 0x75,
+	0x65, 0x72, 0x79, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x1a, 0x15, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x51, 0x75, 0x65, 0x72, 0x79, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x22, 0x00, 0x12, 0x3c, 0x0a, 0x0a, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x55, 0x73, 0x65,
+	0x72, 0x12, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x71, 0x1a, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
+	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x55, 0x73, 0x65, 0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22,
+	0x00, 0x32, 0x8a, 0x04, 0x0a, 0x0e, 0x44, 0x61, 0x67, 0x50, 0x6f, 0x6f, 0x6c, 0x43, 0x6c, 0x75,
+	0x73, 0x74, 0x65, 0x72, 0x12, 0x3a, 0x0a, 0x0a, 0x41, 0x64, 0x64, 0x44, 0x61, 0x67, 0x4e, 0x6f,
+	0x64, 0x65, 0x12, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x44, 0x61, 0x67, 0x4e, 0x6f,
+	0x64, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
 	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00,
-	0x12, 0x40, 0x0a, 0x0c, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x53, 0x6c, 0x6f, 0x74, 0x73,
-	0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
-	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
-	0x22, 0x00, 0x12, 0x36, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x16, 0x2e, 0x67,
+	0x12, 0x38, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x14,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64,
+	0x65, 0x52, 0x65, 0x71, 0x1a, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x44, 0x61, 0x67,
+	0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x00, 0x12, 0x3e, 0x0a, 0x0d, 0x52, 0x65,
+	0x6d, 0x6f, 0x76, 0x65, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x17, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64,
+	0x65, 0x52, 0x65, 0x71, 0x1a, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x44, 0x61, 0x67,
+	0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x00, 0x12, 0x40, 0x0a, 0x0c, 0x4d, 0x69,
+	0x67, 0x72, 0x61, 0x74, 0x65, 0x53, 0x6c, 0x6f, 0x74, 0x73, 0x12, 0x16, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x4d, 0x69, 0x67, 0x72, 0x61, 0x74, 0x65, 0x53, 0x6c, 0x6f, 0x74, 0x73, 0x52,
+	0x65, 0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x40, 0x0a, 0x0c,
+	0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x53, 0x6c, 0x6f, 0x74, 0x73, 0x12, 0x16, 0x2e, 0x67,
 	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45,
-	0x6d, 0x70, 0x74, 0x79, 0x1a, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x53, 0x74, 0x61,
-	0x74, 0x75, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x44, 0x0a, 0x0e, 0x52, 0x65,
-	0x70, 0x61, 0x69, 0x72, 0x44, 0x61, 0x74, 0x61, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x18, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x65, 0x70, 0x61, 0x69, 0x72, 0x44, 0x61, 0x74, 0x61, 0x4e,
-	0x6f, 0x64, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00,
-	0x42, 0x0a, 0x5a, 0x08, 0x2e, 0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x33,
+	0x6d, 0x70, 0x74, 0x79, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x36,
+	0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79,
+	0x1a, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x44, 0x0a, 0x0e, 0x52, 0x65, 0x70, 0x61, 0x69, 0x72,
+	0x44, 0x61, 0x74, 0x61, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x18, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2e, 0x52, 0x65, 0x70, 0x61, 0x69, 0x72, 0x44, 0x61, 0x74, 0x61, 0x4e, 0x6f, 0x64, 0x65, 0x52,
+	0x65, 0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x12, 0x40, 0x0a, 0x0c,
+	0x44, 0x72, 0x61, 0x69, 0x6e, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x16, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x44, 0x72, 0x61, 0x69, 0x6e, 0x44, 0x61, 0x67, 0x4e, 0x6f, 0x64,
+	0x65, 0x52, 0x65, 0x71, 0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x22, 0x00, 0x42, 0x0a,
+	0x5a, 0x08, 0x2e, 0x2e, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
 }
 
 var (
@@ -1690,7 +2247,7 @@ func file_dagpool_proto_rawDescGZIP() []byte {
 	return file_dagpool_proto_rawDescData
 }
 
-var file_dagpool_proto_msgTypes = make([]protoimpl.MessageInfo, 26)
+var file_dagpool_proto_msgTypes = make([]protoimpl.MessageInfo, 34)
 var file_dagpool_proto_goTypes = []interface{}{
 	(*PoolUser)(nil),          // 0: proto.PoolUser
 	(*AddReq)(nil),            // 1: proto.AddReq
@@ -1699,76 +2256,97 @@ var file_dagpool_proto_goTypes = []interface{}{
 	(*GetReply)(nil),          // 4: proto.GetReply
 	(*GetSizeReq)(nil),        // 5: proto.GetSizeReq
 	(*GetSizeReply)(nil),      // 6: proto.GetSizeReply
-	(*RemoveReq)(nil),         // 7: proto.RemoveReq
-	(*RemoveReply)(nil),       // 8: proto.RemoveReply
-	(*AddUserReq)(nil),        // 9: proto.AddUserReq
-	(*AddUserReply)(nil),      // 10: proto.AddUserReply
-	(*RemoveUserReq)(nil),     // 11: proto.RemoveUserReq
-	(*RemoveUserReply)(nil),   // 12: proto.RemoveUserReply
-	(*QueryUserReq)(nil),      // 13: proto.QueryUserReq
-	(*QueryUserReply)(nil),    // 14: proto.QueryUserReply
-	(*UpdateUserReq)(nil),     // 15: proto.UpdateUserReq
-	(*UpdateUserReply)(nil),   // 16: proto.UpdateUserReply
-	(*DataNodeInfo)(nil),      // 17: proto.DataNodeInfo
-	(*DagNodeInfo)(nil),       // 18: proto.DagNodeInfo
-	(*GetDagNodeReq)(nil),     // 19: proto.GetDagNodeReq
-	(*RemoveDagNodeReq)(nil),  // 20: proto.RemoveDagNodeReq
-	(*SlotPair)(nil),          // 21: proto.SlotPair
-	(*MigrateSlotsReq)(nil),   // 22: proto.MigrateSlotsReq
-	(*DagNodeStatus)(nil),     // 23: proto.DagNodeStatus
-	(*StatusReply)(nil),       // 24: proto.StatusReply
-	(*RepairDataNodeReq)(nil), // 25: proto.RepairDataNodeReq
-	(*emptypb.Empty)(nil),     // 26: google.protobuf.Empty
+	(*HasReq)(nil),            // 7: proto.HasReq
+	(*HasReply)(nil),          // 8: proto.HasReply
+	(*RemoveReq)(nil),         // 9: proto.RemoveReq
+	(*RemoveReply)(nil),       // 10: proto.RemoveReply
+	(*PinReq)(nil),            // 11: proto.PinReq
+	(*PinResult)(nil),         // 12: proto.PinResult
+	(*PinReply)(nil),          // 13: proto.PinReply
+	(*UnpinReq)(nil),          // 14: proto.UnpinReq
+	(*UnpinReply)(nil),        // 15: proto.UnpinReply
+	(*AddUserReq)(nil),        // 16: proto.AddUserReq
+	(*AddUserReply)(nil),      // 17: proto.AddUserReply
+	(*RemoveUserReq)(nil),     // 18: proto.RemoveUserReq
+	(*RemoveUserReply)(nil),   // 19: proto.RemoveUserReply
+	(*QueryUserReq)(nil),      // 20: proto.QueryUserReq
+	(*QueryUserReply)(nil),    // 21: proto.QueryUserReply
+	(*UpdateUserReq)(nil),     // 22: proto.UpdateUserReq
+	(*UpdateUserReply)(nil),   // 23: proto.UpdateUserReply
+	(*DataNodeInfo)(nil),      // 24: proto.DataNodeInfo
+	(*DagNodeInfo)(nil),       // 25: proto.DagNodeInfo
+	(*GetDagNodeReq)(nil),     // 26: proto.GetDagNodeReq
+	(*RemoveDagNodeReq)(nil),  // 27: proto.RemoveDagNodeReq
+	(*SlotPair)(nil),          // 28: proto.SlotPair
+	(*MigrateSlotsReq)(nil),   // 29: proto.MigrateSlotsReq
+	(*DagNodeStatus)(nil),     // 30: proto.DagNodeStatus
+	(*StatusReply)(nil),       // 31: proto.StatusReply
+	(*DrainDagNodeReq)(nil),   // 32: proto.DrainDagNodeReq
+	(*RepairDataNodeReq)(nil), // 33: proto.RepairDataNodeReq
+	(*emptypb.Empty)(nil),     // 34: google.protobuf.Empty
 }
 var file_dagpool_proto_depIdxs = []int32{
 	0,  // 0: proto.AddReq.user:type_name -> proto.PoolUser
 	0,  // 1: proto.GetReq.user:type_name -> proto.PoolUser
 	0,  // 2: proto.GetSizeReq.user:type_name -> proto.PoolUser
-	0,  // 3: proto.RemoveReq.user:type_name -> proto.PoolUser
-	0,  // 4: proto.AddUserReq.user:type_name -> proto.PoolUser
-	0,  // 5: proto.RemoveUserReq.user:type_name -> proto.PoolUser
-	0,  // 6: proto.QueryUserReq.user:type_name -> proto.PoolUser
-	0,  // 7: proto.UpdateUserReq.user:type_name -> proto.PoolUser
-	17, // 8: proto.DagNodeInfo.nodes:type_name -> proto.DataNodeInfo
-	21, // 9: proto.MigrateSlotsReq.pairs:type_name -> proto.SlotPair
-	18, // 10: proto.DagNodeStatus.node:type_name -> proto.DagNodeInfo
-	21, // 11: proto.DagNodeStatus.pairs:type_name -> proto.SlotPair
-	23, // 12: proto.StatusReply.statuses:type_name -> proto.DagNodeStatus
-	1,  // 13: proto.DagPool.Add:input_type -> proto.AddReq
-	3,  // 14: proto.DagPool.Get:input_type -> proto.GetReq
-	7,  // 15: proto.DagPool.Remove:input_type -> proto.RemoveReq
-	5,  // 16: proto.DagPool.GetSize:input_type -> proto.GetSizeReq
-	9,  // 17: proto.DagPool.AddUser:input_type -> proto.AddUserReq
-	11, // 18: proto.DagPool.RemoveUser:input_type -> proto.RemoveUserReq
-	13, // 19: proto.DagPool.QueryUser:input_type -> proto.QueryUserReq
-	15, // 20: proto.DagPool.UpdateUser:input_type -> proto.UpdateUserReq
-	18, // 21: proto.DagPoolCluster.AddDagNode:input_type -> proto.DagNodeInfo
-	19, // 22: proto.DagPoolCluster.GetDagNode:input_type -> proto.GetDagNodeReq
-	20, // 23: proto.DagPoolCluster.RemoveDagNode:input_type -> proto.RemoveDagNodeReq
-	22, // 24: proto.DagPoolCluster.MigrateSlots:input_type -> proto.MigrateSlotsReq
-	26, // 25: proto.DagPoolCluster.BalanceSlots:input_type -> google.protobuf.Empty
-	26, // 26: proto.DagPoolCluster.Status:input_type -> google.protobuf.Empty
-	25, // 27: proto.DagPoolCluster.RepairDataNode:input_type -> proto.RepairDataNodeReq
-	2,  // 28: proto.DagPool.Add:output_type -> proto.AddReply
-	4,  // 29: proto.DagPool.Get:output_type -> proto.GetReply
-	8,  // 30: proto.DagPool.Remove:output_type -> proto.RemoveReply
-	6,  // 31: proto.DagPool.GetSize:output_type -> proto.GetSizeReply
-	10, // 32: proto.DagPool.AddUser:output_type -> proto.AddUserReply
-	12, // 33: proto.DagPool.RemoveUser:output_type -> proto.RemoveUserReply
-	14, // 34: proto.DagPool.QueryUser:output_type -> proto.QueryUserReply
-	16, // 35: proto.DagPool.UpdateUser:output_type -> proto.UpdateUserReply
-	26, // 36: proto.DagPoolCluster.AddDagNode:output_type -> google.protobuf.Empty
-	18, // 37: proto.DagPoolCluster.GetDagNode:output_type -> proto.DagNodeInfo
-	18, // 38: proto.DagPoolCluster.RemoveDagNode:output_type -> proto.DagNodeInfo
-	26, // 39: proto.DagPoolCluster.MigrateSlots:output_type -> google.protobuf.Empty
-	26, // 40: proto.DagPoolCluster.BalanceSlots:output_type -> google.protobuf.Empty
-	24, // 41: proto.DagPoolCluster.Status:output_type -> proto.StatusReply
-	26, // 42: proto.DagPoolCluster.RepairDataNode:output_type -> google.protobuf.Empty
-	28, // [28:43] is the sub-list for method output_type
-	13, // [13:28] is the sub-list for method input_type
-	13, // [13:13] is the sub-list for extension type_name
-	13, // [13:13] is the sub-list for extension extendee
-	0,  // [0:13] is the sub-list for field type_name
+	0,  // 3: proto.HasReq.user:type_name -> proto.PoolUser
+	0,  // 4: proto.RemoveReq.user:type_name -> proto.PoolUser
+	0,  // 5: proto.PinReq.user:type_name -> proto.PoolUser
+	12, // 6: proto.PinReply.results:type_name -> proto.PinResult
+	0,  // 7: proto.UnpinReq.user:type_name -> proto.PoolUser
+	12, // 8: proto.UnpinReply.results:type_name -> proto.PinResult
+	0,  // 9: proto.AddUserReq.user:type_name -> proto.PoolUser
+	0,  // 10: proto.RemoveUserReq.user:type_name -> proto.PoolUser
+	0,  // 11: proto.QueryUserReq.user:type_name -> proto.PoolUser
+	0,  // 12: proto.UpdateUserReq.user:type_name -> proto.PoolUser
+	24, // 13: proto.DagNodeInfo.nodes:type_name -> proto.DataNodeInfo
+	28, // 14: proto.MigrateSlotsReq.pairs:type_name -> proto.SlotPair
+	25, // 15: proto.DagNodeStatus.node:type_name -> proto.DagNodeInfo
+	28, // 16: proto.DagNodeStatus.pairs:type_name -> proto.SlotPair
+	30, // 17: proto.StatusReply.statuses:type_name -> proto.DagNodeStatus
+	1,  // 18: proto.DagPool.Add:input_type -> proto.AddReq
+	3,  // 19: proto.DagPool.Get:input_type -> proto.GetReq
+	9,  // 20: proto.DagPool.Remove:input_type -> proto.RemoveReq
+	5,  // 21: proto.DagPool.GetSize:input_type -> proto.GetSizeReq
+	7,  // 22: proto.DagPool.Has:input_type -> proto.HasReq
+	11, // 23: proto.DagPool.Pin:input_type -> proto.PinReq
+	14, // 24: proto.DagPool.Unpin:input_type -> proto.UnpinReq
+	16, // 25: proto.DagPool.AddUser:input_type -> proto.AddUserReq
+	18, // 26: proto.DagPool.RemoveUser:input_type -> proto.RemoveUserReq
+	20, // 27: proto.DagPool.QueryUser:input_type -> proto.QueryUserReq
+	22, // 28: proto.DagPool.UpdateUser:input_type -> proto.UpdateUserReq
+	25, // 29: proto.DagPoolCluster.AddDagNode:input_type -> proto.DagNodeInfo
+	26, // 30: proto.DagPoolCluster.GetDagNode:input_type -> proto.GetDagNodeReq
+	27, // 31: proto.DagPoolCluster.RemoveDagNode:input_type -> proto.RemoveDagNodeReq
+	29, // 32: proto.DagPoolCluster.MigrateSlots:input_type -> proto.MigrateSlotsReq
+	34, // 33: proto.DagPoolCluster.BalanceSlots:input_type -> google.protobuf.Empty
+	34, // 34: proto.DagPoolCluster.Status:input_type -> google.protobuf.Empty
+	33, // 35: proto.DagPoolCluster.RepairDataNode:input_type -> proto.RepairDataNodeReq
+	32, // 36: proto.DagPoolCluster.DrainDagNode:input_type -> proto.DrainDagNodeReq
+	2,  // 37: proto.DagPool.Add:output_type -> proto.AddReply
+	4,  // 38: proto.DagPool.Get:output_type -> proto.GetReply
+	10, // 39: proto.DagPool.Remove:output_type -> proto.RemoveReply
+	6,  // 40: proto.DagPool.GetSize:output_type -> proto.GetSizeReply
+	8,  // 41: proto.DagPool.Has:output_type -> proto.HasReply
+	13, // 42: proto.DagPool.Pin:output_type -> proto.PinReply
+	15, // 43: proto.DagPool.Unpin:output_type -> proto.UnpinReply
+	17, // 44: proto.DagPool.AddUser:output_type -> proto.AddUserReply
+	19, // 45: proto.DagPool.RemoveUser:output_type -> proto.RemoveUserReply
+	21, // 46: proto.DagPool.QueryUser:output_type -> proto.QueryUserReply
+	23, // 47: proto.DagPool.UpdateUser:output_type -> proto.UpdateUserReply
+	34, // 48: proto.DagPoolCluster.AddDagNode:output_type -> google.protobuf.Empty
+	25, // 49: proto.DagPoolCluster.GetDagNode:output_type -> proto.DagNodeInfo
+	25, // 50: proto.DagPoolCluster.RemoveDagNode:output_type -> proto.DagNodeInfo
+	34, // 51: proto.DagPoolCluster.MigrateSlots:output_type -> google.protobuf.Empty
+	34, // 52: proto.DagPoolCluster.BalanceSlots:output_type -> google.protobuf.Empty
+	31, // 53: proto.DagPoolCluster.Status:output_type -> proto.StatusReply
+	34, // 54: proto.DagPoolCluster.RepairDataNode:output_type -> google.protobuf.Empty
+	34, // 55: proto.DagPoolCluster.DrainDagNode:output_type -> google.protobuf.Empty
+	37, // [37:56] is the sub-list for method output_type
+	18, // [18:37] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
 }
 
 func init() { file_dagpool_proto_init() }
@@ -1862,7 +2440,7 @@ func file_dagpool_proto_init() {
 			}
 		}
 		file_dagpool_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RemoveReq); i {
+			switch v := v.(*HasReq); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1874,7 +2452,7 @@ func file_dagpool_proto_init() {
 			}
 		}
 		file_dagpool_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RemoveReply); i {
+			switch v := v.(*HasReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1886,7 +2464,7 @@ func file_dagpool_proto_init() {
 			}
 		}
 		file_dagpool_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*AddUserReq); i {
+			switch v := v.(*RemoveReq); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1898,7 +2476,7 @@ func file_dagpool_proto_init() {
 			}
 		}
 		file_dagpool_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*AddUserReply); i {
+			switch v := v.(*RemoveReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1910,7 +2488,7 @@ func file_dagpool_proto_init() {
 			}
 		}
 		file_dagpool_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RemoveUserReq); i {
+			switch v := v.(*PinReq); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1922,7 +2500,7 @@ func file_dagpool_proto_init() {
 			}
 		}
 		file_dagpool_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RemoveUserReply); i {
+			switch v := v.(*PinResult); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1934,7 +2512,7 @@ func file_dagpool_proto_init() {
 			}
 		}
 		file_dagpool_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*QueryUserReq); i {
+			switch v := v.(*PinReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1946,7 +2524,7 @@ func file_dagpool_proto_init() {
 			}
 		}
 		file_dagpool_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*QueryUserReply); i {
+			switch v := v.(*UnpinReq); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1958,7 +2536,7 @@ func file_dagpool_proto_init() {
 			}
 		}
 		file_dagpool_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*UpdateUserReq); i {
+			switch v := v.(*UnpinReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1970,7 +2548,7 @@ func file_dagpool_proto_init() {
 			}
 		}
 		file_dagpool_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*UpdateUserReply); i {
+			switch v := v.(*AddUserReq); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1982,7 +2560,7 @@ func file_dagpool_proto_init() {
 			}
 		}
 		file_dagpool_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DataNodeInfo); i {
+			switch v := v.(*AddUserReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1994,7 +2572,7 @@ func file_dagpool_proto_init() {
 			}
 		}
 		file_dagpool_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DagNodeInfo); i {
+			switch v := v.(*RemoveUserReq); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2006,7 +2584,7 @@ func file_dagpool_proto_init() {
 			}
 		}
 		file_dagpool_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetDagNodeReq); i {
+			switch v := v.(*RemoveUserReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2018,7 +2596,7 @@ func file_dagpool_proto_init() {
 			}
 		}
 		file_dagpool_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RemoveDagNodeReq); i {
+			switch v := v.(*QueryUserReq); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2030,7 +2608,7 @@ func file_dagpool_proto_init() {
 			}
 		}
 		file_dagpool_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SlotPair); i {
+			switch v := v.(*QueryUserReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2042,7 +2620,7 @@ func file_dagpool_proto_init() {
 			}
 		}
 		file_dagpool_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*MigrateSlotsReq); i {
+			switch v := v.(*UpdateUserReq); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2054,7 +2632,7 @@ func file_dagpool_proto_init() {
 			}
 		}
 		file_dagpool_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DagNodeStatus); i {
+			switch v := v.(*UpdateUserReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2066,7 +2644,7 @@ func file_dagpool_proto_init() {
 			}
 		}
 		file_dagpool_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*StatusReply); i {
+			switch v := v.(*DataNodeInfo); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -2078,6 +2656,102 @@ func file_dagpool_proto_init() {
 			}
 		}
 		file_dagpool_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DagNodeInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dagpool_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetDagNodeReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dagpool_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RemoveDagNodeReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dagpool_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SlotPair); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dagpool_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MigrateSlotsReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dagpool_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DagNodeStatus); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dagpool_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StatusReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dagpool_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DrainDagNodeReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dagpool_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*RepairDataNodeReq); i {
 			case 0:
 				return &v.state
@@ -2090,14 +2764,17 @@ func file_dagpool_proto_init() {
 			}
 		}
 	}
-	file_dagpool_proto_msgTypes[17].OneofWrappers = []interface{}{}
+	file_dagpool_proto_msgTypes[16].OneofWrappers = []interface{}{}
+	file_dagpool_proto_msgTypes[21].OneofWrappers = []interface{}{}
+	file_dagpool_proto_msgTypes[22].OneofWrappers = []interface{}{}
+	file_dagpool_proto_msgTypes[24].OneofWrappers = []interface{}{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_dagpool_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   26,
+			NumMessages:   34,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
@@ -27,6 +27,10 @@ type DagPoolClient interface {
 	Get(ctx context.Context, in *GetReq, opts ...grpc.CallOption) (*GetReply, error)
 	Remove(ctx context.Context, in *RemoveReq, opts ...grpc.CallOption) (*RemoveReply, error)
 	GetSize(ctx context.Context, in *GetSizeReq, opts ...grpc.CallOption) (*GetSizeReply, error)
+	// Has reports whether the pool has the block, the block isn't read from the dag nodes
+	Has(ctx context.Context, in *HasReq, opts ...grpc.CallOption) (*HasReply, error)
+	Pin(ctx context.Context, in *PinReq, opts ...grpc.CallOption) (*PinReply, error)
+	Unpin(ctx context.Context, in *UnpinReq, opts ...grpc.CallOption) (*UnpinReply, error)
 	AddUser(ctx context.Context, in *AddUserReq, opts ...grpc.CallOption) (*AddUserReply, error)
 	RemoveUser(ctx context.Context, in *RemoveUserReq, opts ...grpc.CallOption) (*RemoveUserReply, error)
 	QueryUser(ctx context.Context, in *QueryUserReq, opts ...grpc.CallOption) (*QueryUserReply, error)
@@ -77,6 +81,33 @@ func (c *dagPoolClient) GetSize(ctx context.Context, in *GetSizeReq, opts ...grp
 	return out, nil
 }
 
+func (c *dagPoolClient) Has(ctx context.Context, in *HasReq, opts ...grpc.CallOption) (*HasReply, error) {
+	out := new(HasReply)
+	err := c.cc.Invoke(ctx, "/proto.DagPool/Has", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dagPoolClient) Pin(ctx context.Context, in *PinReq, opts ...grpc.CallOption) (*PinReply, error) {
+	out := new(PinReply)
+	err := c.cc.Invoke(ctx, "/proto.DagPool/Pin", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dagPoolClient) Unpin(ctx context.Context, in *UnpinReq, opts ...grpc.CallOption) (*UnpinReply, error) {
+	out := new(UnpinReply)
+	err := c.cc.Invoke(ctx, "/proto.DagPool/Unpin", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *dagPoolClient) AddUser(ctx context.Context, in *AddUserReq, opts ...grpc.CallOption) (*AddUserReply, error) {
 	out := new(AddUserReply)
 	err := c.cc.Invoke(ctx, "/proto.DagPool/AddUser", in, out, opts...)
@@ -121,6 +152,10 @@ type DagPoolServer interface {
 	Get(context.Context, *GetReq) (*GetReply, error)
 	Remove(context.Context, *RemoveReq) (*RemoveReply, error)
 	GetSize(context.Context, *GetSizeReq) (*GetSizeReply, error)
+	// Has reports whether the pool has the block, the block isn't read from the dag nodes
+	Has(context.Context, *HasReq) (*HasReply, error)
+	Pin(context.Context, *PinReq) (*PinReply, error)
+	Unpin(context.Context, *UnpinReq) (*UnpinReply, error)
 	AddUser(context.Context, *AddUserReq) (*AddUserReply, error)
 	RemoveUser(context.Context, *RemoveUserReq) (*RemoveUserReply, error)
 	QueryUser(context.Context, *QueryUserReq) (*QueryUserReply, error)
@@ -144,6 +179,15 @@ func (UnimplementedDagPoolServer) Remove(context.Context, *RemoveReq) (*RemoveRe
 func (UnimplementedDagPoolServer) GetSize(context.Context, *GetSizeReq) (*GetSizeReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetSize not implemented")
 }
+func (UnimplementedDagPoolServer) Has(context.Context, *HasReq) (*HasReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Has not implemented")
+}
+func (UnimplementedDagPoolServer) Pin(context.Context, *PinReq) (*PinReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Pin not implemented")
+}
+func (UnimplementedDagPoolServer) Unpin(context.Context, *UnpinReq) (*UnpinReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unpin not implemented")
+}
 func (UnimplementedDagPoolServer) AddUser(context.Context, *AddUserReq) (*AddUserReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddUser not implemented")
 }
@@ -241,6 +285,60 @@ func _DagPool_GetSize_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DagPool_Has_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HasReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DagPoolServer).Has(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DagPool/Has",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DagPoolServer).Has(ctx, req.(*HasReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DagPool_Pin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PinReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DagPoolServer).Pin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DagPool/Pin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DagPoolServer).Pin(ctx, req.(*PinReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DagPool_Unpin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnpinReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DagPoolServer).Unpin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DagPool/Unpin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DagPoolServer).Unpin(ctx, req.(*UnpinReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _DagPool_AddUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(AddUserReq)
 	if err := dec(in); err != nil {
@@ -336,6 +434,18 @@ var DagPool_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetSize",
 			Handler:    _DagPool_GetSize_Handler,
 		},
+		{
+			MethodName: "Has",
+			Handler:    _DagPool_Has_Handler,
+		},
+		{
+			MethodName: "Pin",
+			Handler:    _DagPool_Pin_Handler,
+		},
+		{
+			MethodName: "Unpin",
+			Handler:    _DagPool_Unpin_Handler,
+		},
 		{
 			MethodName: "AddUser",
 			Handler:    _DagPool_AddUser_Handler,
@@ -368,6 +478,7 @@ type DagPoolClusterClient interface {
 	BalanceSlots(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	Status(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*StatusReply, error)
 	RepairDataNode(ctx context.Context, in *RepairDataNodeReq, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	DrainDagNode(ctx context.Context, in *DrainDagNodeReq, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type dagPoolClusterClient struct {
@@ -441,6 +552,15 @@ func (c *dagPoolClusterClient) RepairDataNode(ctx context.Context, in *RepairDat
 	return out, nil
 }
 
+func (c *dagPoolClusterClient) DrainDagNode(ctx context.Context, in *DrainDagNodeReq, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/proto.DagPoolCluster/DrainDagNode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DagPoolClusterServer is the server API for DagPoolCluster service.
 // All implementations must embed UnimplementedDagPoolClusterServer
 // for forward compatibility
@@ -452,6 +572,7 @@ type DagPoolClusterServer interface {
 	BalanceSlots(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
 	Status(context.Context, *emptypb.Empty) (*StatusReply, error)
 	RepairDataNode(context.Context, *RepairDataNodeReq) (*emptypb.Empty, error)
+	DrainDagNode(context.Context, *DrainDagNodeReq) (*emptypb.Empty, error)
 	mustEmbedUnimplementedDagPoolClusterServer()
 }
 
@@ -480,6 +601,9 @@ func (UnimplementedDagPoolClusterServer) Status(context.Context, *emptypb.Empty)
 func (UnimplementedDagPoolClusterServer) RepairDataNode(context.Context, *RepairDataNodeReq) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RepairDataNode not implemented")
 }
+func (UnimplementedDagPoolClusterServer) DrainDagNode(context.Context, *DrainDagNodeReq) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DrainDagNode not implemented")
+}
 func (UnimplementedDagPoolClusterServer) mustEmbedUnimplementedDagPoolClusterServer() {}
 
 // UnsafeDagPoolClusterServer may be embedded to opt out of forward compatibility for this service.
@@ -619,6 +743,24 @@ func _DagPoolCluster_RepairDataNode_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DagPoolCluster_DrainDagNode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DrainDagNodeReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DagPoolClusterServer).DrainDagNode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DagPoolCluster/DrainDagNode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DagPoolClusterServer).DrainDagNode(ctx, req.(*DrainDagNodeReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // DagPoolCluster_ServiceDesc is the grpc.ServiceDesc for DagPoolCluster service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -654,6 +796,10 @@ var DagPoolCluster_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RepairDataNode",
 			Handler:    _DagPoolCluster_RepairDataNode_Handler,
 		},
+		{
+			MethodName: "DrainDagNode",
+			Handler:    _DagPoolCluster_DrainDagNode_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "dagpool.proto",
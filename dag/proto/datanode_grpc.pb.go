@@ -30,6 +30,7 @@ type DataNodeClient interface {
 	Size(ctx context.Context, in *SizeRequest, opts ...grpc.CallOption) (*SizeResponse, error)
 	DeleteMany(ctx context.Context, in *DeleteManyRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	AllKeysChan(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (DataNode_AllKeysChanClient, error)
+	Stat(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*StatResponse, error)
 }
 
 type dataNodeClient struct {
@@ -126,6 +127,15 @@ func (x *dataNodeAllKeysChanClient) Recv() (*AllKeysChanResponse, error) {
 	return m, nil
 }
 
+func (c *dataNodeClient) Stat(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*StatResponse, error) {
+	out := new(StatResponse)
+	err := c.cc.Invoke(ctx, "/proto.DataNode/Stat", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DataNodeServer is the server API for DataNode service.
 // All implementations must embed UnimplementedDataNodeServer
 // for forward compatibility
@@ -137,6 +147,7 @@ type DataNodeServer interface {
 	Size(context.Context, *SizeRequest) (*SizeResponse, error)
 	DeleteMany(context.Context, *DeleteManyRequest) (*emptypb.Empty, error)
 	AllKeysChan(*emptypb.Empty, DataNode_AllKeysChanServer) error
+	Stat(context.Context, *emptypb.Empty) (*StatResponse, error)
 	mustEmbedUnimplementedDataNodeServer()
 }
 
@@ -165,6 +176,9 @@ func (UnimplementedDataNodeServer) DeleteMany(context.Context, *DeleteManyReques
 func (UnimplementedDataNodeServer) AllKeysChan(*emptypb.Empty, DataNode_AllKeysChanServer) error {
 	return status.Errorf(codes.Unimplemented, "method AllKeysChan not implemented")
 }
+func (UnimplementedDataNodeServer) Stat(context.Context, *emptypb.Empty) (*StatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stat not implemented")
+}
 func (UnimplementedDataNodeServer) mustEmbedUnimplementedDataNodeServer() {}
 
 // UnsafeDataNodeServer may be embedded to opt out of forward compatibility for this service.
@@ -307,6 +321,24 @@ func (x *dataNodeAllKeysChanServer) Send(m *AllKeysChanResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _DataNode_Stat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DataNodeServer).Stat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.DataNode/Stat",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DataNodeServer).Stat(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // DataNode_ServiceDesc is the grpc.ServiceDesc for DataNode service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -338,6 +370,10 @@ var DataNode_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteMany",
 			Handler:    _DataNode_DeleteMany_Handler,
 		},
+		{
+			MethodName: "Stat",
+			Handler:    _DataNode_Stat_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -72,6 +72,10 @@ func run(leveldbPath, port, poolAddr, poolUser, poolPass string) {
 	bmSys := store.NewBucketMetadataSys(db)
 	storageSys.SetNewBucketNSLock(bmSys.NewNSLock)
 	storageSys.SetHasBucket(bmSys.HasBucket)
+	storageSys.SetOverwriteInterval(bmSys.GetOverwriteInterval)
+	storageSys.SetCaseInsensitive(bmSys.IsCaseInsensitive)
+	storageSys.SetVersioning(bmSys.GetBucketVersioning)
+	authSys.SetObjectACL(storageSys.GetObjectVersionACL)
 	bmSys.SetEmptyBucket(storageSys.EmptyBucket)
 	cleanData := func(accessKey string) {
 		ctx := context.Background()
@@ -88,8 +92,11 @@ func run(leveldbPath, port, poolAddr, poolUser, poolPass string) {
 				log.Printf("DeleteBucket error: %v", err)
 			}
 		}
+		if err = bmSys.ResetUserBucketLimit(ctx, accessKey); err != nil {
+			log.Printf("ResetUserBucketLimit error: %v", err)
+		}
 	}
-	iamapi.NewIamApiServer(router, authSys, cleanData)
+	iamapi.NewIamApiServer(router, authSys, storageSys, bmSys, cleanData)
 	s3api.NewS3Server(router, authSys, bmSys, storageSys)
 
 	for _, ip := range utils.MustGetLocalIP4().ToSlice() {
@@ -2,8 +2,10 @@ package apierrors
 
 import (
 	"context"
+	dagpoolcli "github.com/filedag-project/filedag-storage/dag/pool/client"
 	"github.com/filedag-project/filedag-storage/objectservice/lock"
 	"github.com/filedag-project/filedag-storage/objectservice/store"
+	"github.com/filedag-project/filedag-storage/objectservice/uleveldb"
 	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
 	"github.com/filedag-project/filedag-storage/objectservice/utils/s3utils"
 	"golang.org/x/xerrors"
@@ -35,16 +37,69 @@ func ToApiError(ctx context.Context, err error) ErrorCode {
 	switch err.(type) {
 	case lock.OperationTimedOut:
 		errCode = ErrOperationTimedOut
+	case lock.LockNotHeld:
+		errCode = ErrNoSuchLock
+	case lock.LockNotStale:
+		errCode = ErrLockNotStale
+	case uleveldb.OperationTimedOut:
+		// a timed out write may still be applied, the client retries it like a slow down
+		errCode = ErrSlowDown
+	case uleveldb.ServiceUnavailable:
+		errCode = ErrServiceUnavailable
 	case hash.SHA256Mismatch:
 		errCode = ErrContentSHA256Mismatch
 	case hash.BadDigest:
 		errCode = ErrBadDigest
+	case hash.ChecksumMismatch:
+		errCode = ErrBadDigest
+	case hash.InvalidChecksum:
+		errCode = ErrInvalidDigest
 	case store.BucketNotFound:
 		errCode = ErrNoSuchBucket
+	case store.IllegalLocationConstraint:
+		errCode = ErrIllegalLocationConstraint
 	case store.BucketPolicyNotFound:
 		errCode = ErrNoSuchBucketPolicy
 	case store.BucketTaggingNotFound:
 		errCode = ErrBucketTaggingNotFound
+	case store.InvalidTag:
+		errCode = ErrInvalidTag
+	case store.InvalidCannedACL:
+		errCode = ErrInvalidCannedACL
+	case store.BucketLifecycleNotFound:
+		errCode = ErrNoSuchLifecycleConfiguration
+	case store.BucketWebsiteNotFound:
+		errCode = ErrNoSuchWebsiteConfiguration
+	case store.BucketCorsNotFound:
+		errCode = ErrNoSuchCORSConfiguration
+	case store.BucketOverwriteProtectionNotFound:
+		errCode = ErrNoSuchOverwriteProtectionConfiguration
+	case store.ObjectOverwriteTooSoon:
+		errCode = ErrObjectOverwriteTooSoon
+	case store.ObjectUnderLegalHold:
+		errCode = ErrObjectLocked
+	case store.BucketInventoryNotFound:
+		errCode = ErrNoSuchInventoryConfiguration
+	case store.InvalidInventoryConfiguration:
+		errCode = ErrInvalidInventoryConfiguration
+	case store.InvalidRequestWeight:
+		errCode = ErrInvalidRequest
+	case store.TooManyBuckets:
+		errCode = ErrTooManyBuckets
+	case store.InvalidBucketLimit:
+		errCode = ErrInvalidRequest
+	case store.PreConditionFailed:
+		errCode = ErrPreconditionFailed
+	case store.InvalidPartNumber:
+		errCode = ErrInvalidPartNumber
+	case store.TooManyParts:
+		errCode = ErrTooManyParts
+	case store.InvalidVersioningStatus:
+		errCode = ErrMalformedXML
+	case store.AppendVersionedObject:
+		errCode = ErrAppendVersionedObject
+	case store.InvalidContinuationToken:
+		errCode = ErrIncorrectContinuationToken
 	case s3utils.BucketNameInvalid:
 		errCode = ErrInvalidBucketName
 	case s3utils.ObjectNameInvalid:
@@ -63,10 +118,14 @@ func ToApiError(ctx context.Context, err error) ErrorCode {
 		errCode = ErrNoSuchUpload
 	case s3utils.InvalidPart:
 		errCode = ErrInvalidPart
+	case s3utils.InvalidPartOrder:
+		errCode = ErrInvalidPartOrder
 	case s3utils.PartTooSmall:
 		errCode = ErrEntityTooSmall
 	case s3utils.PartTooBig:
 		errCode = ErrEntityTooLarge
+	case s3utils.ObjectTooLarge:
+		errCode = ErrEntityTooLarge
 	case url.EscapeError:
 		errCode = ErrInvalidObjectName
 	default:
@@ -74,6 +133,17 @@ func ToApiError(ctx context.Context, err error) ErrorCode {
 			errCode = ErrNoSuchKey
 		} else if xerrors.Is(err, store.ErrBucketNotEmpty) {
 			errCode = ErrBucketNotEmpty
+		} else if xerrors.Is(err, store.ErrTrashedObjectNotFound) {
+			errCode = ErrNoSuchTrashedObject
+		} else if xerrors.Is(err, store.ErrObjectAlreadyExists) {
+			errCode = ErrObjectAlreadyExists
+		} else if xerrors.Is(err, store.ErrObjectVersionNotFound) {
+			errCode = ErrNoSuchVersion
+		} else if xerrors.Is(err, store.ErrVersionIsDeleteMarker) {
+			errCode = ErrMethodNotAllowed
+		} else if xerrors.Is(err, dagpoolcli.ErrAccessDenied) {
+			// the pool user of the gateway is misconfigured, the request of the client is authorized
+			errCode = ErrInternalError
 		}
 	}
 	return errCode
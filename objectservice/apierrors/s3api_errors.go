@@ -54,12 +54,14 @@ const (
 	ErrInvalidDigest
 	ErrInvalidRange
 	ErrInvalidRangePartNumber
+	ErrInvalidPartNumber
 	ErrInvalidCopyPartRange
 	ErrInvalidCopyPartRangeSource
 	ErrInvalidMaxKeys
 	ErrInvalidEncodingMethod
 	ErrInvalidMaxUploads
 	ErrInvalidMaxParts
+	ErrTooManyParts
 	ErrInvalidPartNumberMarker
 	ErrInvalidRequestBody
 	ErrInvalidCopySource
@@ -80,7 +82,12 @@ const (
 	ErrNoSuchBucketPolicy
 	ErrNoSuchLifecycleConfiguration
 	ErrNoSuchCORSConfiguration
+	ErrCORSAccessForbidden
 	ErrNoSuchWebsiteConfiguration
+	ErrNoSuchOverwriteProtectionConfiguration
+	ErrObjectOverwriteTooSoon
+	ErrNoSuchInventoryConfiguration
+	ErrInvalidInventoryConfiguration
 	ErrReplicationConfigurationNotFoundError
 	ErrReplicationNeedsVersioningError
 	ErrReplicationBucketNeedsVersioningError
@@ -90,6 +97,7 @@ const (
 	ErrInvalidVersionID
 	ErrNoSuchVersion
 	ErrNotImplemented
+	ErrNotImplementedSubresource
 	ErrPreconditionFailed
 	ErrRequestTimeTooSkewed
 	ErrSignatureDoesNotMatch
@@ -108,6 +116,7 @@ const (
 	ErrMissingCredTag
 	ErrCredMalformed
 	ErrInvalidRegion
+	ErrIllegalLocationConstraint
 
 	ErrMissingSignTag
 	ErrMissingSignHeadersTag
@@ -121,6 +130,7 @@ const (
 	ErrBucketAlreadyOwnedByYou
 	ErrInvalidDuration
 	ErrBucketAlreadyExists
+	ErrTooManyBuckets
 	ErrMetadataTooLarge
 	ErrUnsupportedMetadata
 
@@ -137,6 +147,8 @@ const (
 	ErrBucketTaggingNotFound
 	ErrObjectLockInvalidHeaders
 	ErrInvalidTagDirective
+	ErrInvalidTag
+	ErrInvalidCannedACL
 	// Add new error codes here.
 
 	// SSE-S3 related API errors
@@ -158,6 +170,7 @@ const (
 
 	// S3 extended errors.
 	ErrContentSHA256Mismatch
+	ErrUnsignedPayloadNotAllowed
 
 	// Add new extended error codes here.
 	ErrInvalidObjectName
@@ -168,6 +181,16 @@ const (
 	ErrInvalidRequest
 	ErrIncorrectContinuationToken
 	ErrInvalidFormatAccessKey
+	ErrServiceUnavailable
+	ErrPolicyAlreadyExpired
+	ErrNoSuchLogSubsystem
+	ErrInvalidLogLevel
+	ErrNoSuchLock
+	ErrLockNotStale
+	ErrNoSuchTrashedObject
+	ErrObjectAlreadyExists
+	ErrInvalidAttributeName
+	ErrAppendVersionedObject
 
 	// S3 Select Errors
 	ErrEmptyRequestBody
@@ -257,6 +280,7 @@ const (
 	ErrPostPolicyConditionInvalidFormat
 
 	ErrMalformedJSON
+	ErrInvalidRedirectLocation
 )
 
 // error code to APIError structure, these fields carry respective
@@ -302,6 +326,11 @@ var errorCodeResponse = map[ErrorCode]APIError{
 		Description:    "Part number must be an integer between 1 and 10000, inclusive",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrTooManyParts: {
+		Code:           "TooManyParts",
+		Description:    "The part number or the number of parts exceeds the maximum parts of a multipart upload.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrInvalidPartNumberMarker: {
 		Code:           "InvalidArgument",
 		Description:    "Argument partNumberMarker must be an integer.",
@@ -372,6 +401,11 @@ var errorCodeResponse = map[ErrorCode]APIError{
 		Description:    "Cannot specify both Range header and partNumber query parameter",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrInvalidPartNumber: {
+		Code:           "InvalidPartNumber",
+		Description:    "The requested partnumber is not satisfiable",
+		HTTPStatusCode: http.StatusRequestedRangeNotSatisfiable,
+	},
 	ErrMalformedXML: {
 		Code:           "MalformedXML",
 		Description:    "The XML you provided was not well-formed or did not validate against our published schema.",
@@ -457,6 +491,11 @@ var errorCodeResponse = map[ErrorCode]APIError{
 		Description:    "A header you provided implies functionality that is not implemented",
 		HTTPStatusCode: http.StatusNotImplemented,
 	},
+	ErrNotImplementedSubresource: {
+		Code:           "NotImplemented",
+		Description:    "The subresource you requested is not implemented",
+		HTTPStatusCode: http.StatusNotImplemented,
+	},
 	ErrPreconditionFailed: {
 		Code:           "PreconditionFailed",
 		Description:    "At least one of the pre-conditions you specified did not hold",
@@ -522,6 +561,11 @@ var errorCodeResponse = map[ErrorCode]APIError{
 		Description:    "The requested bucket name is not available. The bucket namespace is shared by all users of the system. Please select a different name and try again.",
 		HTTPStatusCode: http.StatusConflict,
 	},
+	ErrTooManyBuckets: {
+		Code:           "TooManyBuckets",
+		Description:    "You have attempted to create more buckets than allowed.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrAllAccessDisabled: {
 		Code:           "AllAccessDisabled",
 		Description:    "All access to this resource has been disabled.",
@@ -542,6 +586,11 @@ var errorCodeResponse = map[ErrorCode]APIError{
 		Description:    "Region does not match.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrIllegalLocationConstraint: {
+		Code:           "IllegalLocationConstraintException",
+		Description:    "The location constraint is incompatible for the region specific endpoint this request was sent to.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrMissingSignTag: {
 		Code:           "AccessDenied",
 		Description:    "Signature header missing Signature field.",
@@ -623,11 +672,36 @@ var errorCodeResponse = map[ErrorCode]APIError{
 		Description:    "The CORS configuration does not exist",
 		HTTPStatusCode: http.StatusNotFound,
 	},
+	ErrCORSAccessForbidden: {
+		Code:           "AccessForbidden",
+		Description:    "CORSResponse: This CORS request is not allowed. The origin, the method or the headers of the request are not allowed by the CORS configuration of the bucket",
+		HTTPStatusCode: http.StatusForbidden,
+	},
 	ErrNoSuchWebsiteConfiguration: {
 		Code:           "NoSuchWebsiteConfiguration",
 		Description:    "The specified bucket does not have a website configuration",
 		HTTPStatusCode: http.StatusNotFound,
 	},
+	ErrNoSuchOverwriteProtectionConfiguration: {
+		Code:           "NoSuchOverwriteProtectionConfiguration",
+		Description:    "The specified bucket does not have an overwrite protection configuration",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrObjectOverwriteTooSoon: {
+		Code:           "ObjectOverwriteTooSoon",
+		Description:    "The object was written too recently to be overwritten, the overwrite protection window of the bucket hasn't elapsed",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrNoSuchInventoryConfiguration: {
+		Code:           "NoSuchConfiguration",
+		Description:    "The specified inventory configuration does not exist",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidInventoryConfiguration: {
+		Code:           "InvalidArgument",
+		Description:    "The destination bucket of the inventory configuration must exist, have the same owner and not be the source bucket",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrReplicationConfigurationNotFoundError: {
 		Code:           "ReplicationConfigurationNotFoundError",
 		Description:    "The replication configuration was not found",
@@ -745,6 +819,16 @@ var errorCodeResponse = map[ErrorCode]APIError{
 		Description:    "Unknown tag directive.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrInvalidTag: {
+		Code:           "InvalidTag",
+		Description:    "The tag provided was not a valid tag.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidCannedACL: {
+		Code:           "InvalidArgument",
+		Description:    "The canned ACL is not supported, it must be private, public-read or public-read-write.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrInvalidEncryptionMethod: {
 		Code:           "InvalidRequest",
 		Description:    "The encryption method specified is not supported",
@@ -767,6 +851,16 @@ var errorCodeResponse = map[ErrorCode]APIError{
 	},
 
 	// S3 extensions.
+	ErrContentSHA256Mismatch: {
+		Code:           "XAmzContentSHA256Mismatch",
+		Description:    "The provided 'x-amz-content-sha256' header does not match what was computed.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrUnsignedPayloadNotAllowed: {
+		Code:           "InvalidRequest",
+		Description:    "The payload must be signed by its 'x-amz-content-sha256', UNSIGNED-PAYLOAD is not accepted.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrInvalidObjectName: {
 		Code:           "InvalidObjectName",
 		Description:    "Object name contains unsupported characters.",
@@ -810,6 +904,56 @@ var errorCodeResponse = map[ErrorCode]APIError{
 		Description:    "The continuation token provided is incorrect",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrServiceUnavailable: {
+		Code:           "ServiceUnavailable",
+		Description:    "The service is unavailable. Please retry.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
+	ErrPolicyAlreadyExpired: {
+		Code:           "AccessDenied",
+		Description:    "Invalid according to Policy: Policy expired.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrNoSuchLogSubsystem: {
+		Code:           "NoSuchLogSubsystem",
+		Description:    "The specified log subsystem does not exist",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidLogLevel: {
+		Code:           "InvalidLogLevel",
+		Description:    "The log level must be one of debug, info, warn, error, dpanic, panic and fatal",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchLock: {
+		Code:           "NoSuchLock",
+		Description:    "The specified resource is not locked",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrLockNotStale: {
+		Code:           "LockNotStale",
+		Description:    "The lock has not been held long enough to be force released",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrNoSuchTrashedObject: {
+		Code:           "NoSuchTrashedObject",
+		Description:    "The specified key is not in the trash, it was never deleted or it was permanently removed",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrObjectAlreadyExists: {
+		Code:           "ObjectAlreadyExists",
+		Description:    "An object with the specified key already exists",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrInvalidAttributeName: {
+		Code:           "InvalidArgument",
+		Description:    "Invalid attribute name specified.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAppendVersionedObject: {
+		Code:           "InvalidBucketState",
+		Description:    "The objects of a bucket whose versioning has been enabled can't be appended to",
+		HTTPStatusCode: http.StatusConflict,
+	},
 	ErrInvalidFormatAccessKey: {
 		Code:           "InvalidAccessKeyId",
 		Description:    "The Access Key Id you provided contains invalid characters.",
@@ -1247,6 +1391,11 @@ var errorCodeResponse = map[ErrorCode]APIError{
 		Description:    "The JSON was not well-formed or did not validate against our published format.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrInvalidRedirectLocation: {
+		Code:           "InvalidRedirectLocation",
+		Description:    "The website redirect location must have a prefix of 'http://' or 'https://' or '/'.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 }
 
 // GetAPIError provides API Error for input API error code.
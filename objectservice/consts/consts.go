@@ -39,14 +39,28 @@ const (
 	IfUnmodifiedSince = "If-Unmodified-Since"
 	IfMatch           = "If-Match"
 	IfNoneMatch       = "If-None-Match"
+	IfRange           = "If-Range"
 
 	// S3 storage class
 	AmzStorageClass = "x-amz-storage-class"
 
+	// S3 object redirect of the static website hosting
+	AmzWebsiteRedirectLocation = "x-amz-website-redirect-location"
+
 	// S3 object version ID
 	AmzVersionID    = "x-amz-version-id"
 	AmzDeleteMarker = "x-amz-delete-marker"
 
+	// S3 object attributes
+	AmzObjectAttributes = "X-Amz-Object-Attributes"
+	AmzChecksumSHA256   = "x-amz-checksum-sha256"
+	AmzChecksumCRC32    = "x-amz-checksum-crc32"
+	AmzMaxParts         = "x-amz-max-parts"
+	AmzPartNumberMarker = "x-amz-part-number-marker"
+
+	// S3 trailer of a chunked body, it names the x-amz-checksum- header sent after the content
+	AmzTrailer = "X-Amz-Trailer"
+
 	// S3 object tagging
 	AmzObjectTagging = "X-Amz-Tagging"
 	AmzTagCount      = "x-amz-tagging-count"
@@ -69,6 +83,7 @@ const (
 	AmzCopySourceVersionID        = "X-Amz-Copy-Source-Version-Id"
 	AmzCopySourceRange            = "X-Amz-Copy-Source-Range"
 	AmzMetadataDirective          = "X-Amz-Metadata-Directive"
+	AmzUserMetadataPrefix         = "X-Amz-Meta-"
 	AmzObjectLockMode             = "X-Amz-Object-Lock-Mode"
 	AmzObjectLockRetainUntilDate  = "X-Amz-Object-Lock-Retain-Until-Date"
 	AmzObjectLockLegalHold        = "X-Amz-Object-Lock-Legal-Hold"
@@ -129,6 +144,8 @@ const (
 	ContentType        = "Content-Type"
 	ContentMD5         = "Content-Md5"
 	ContentEncoding    = "Content-Encoding"
+	AcceptEncoding     = "Accept-Encoding"
+	Vary               = "Vary"
 	Expires            = "Expires"
 	ContentLength      = "Content-Length"
 	ContentLanguage    = "Content-Language"
@@ -146,6 +163,19 @@ const (
 	Range              = "Range"
 )
 
+// CORS related headers
+const (
+	Origin                        = "Origin"
+	AccessControlRequestMethod    = "Access-Control-Request-Method"
+	AccessControlRequestHeaders   = "Access-Control-Request-Headers"
+	AccessControlAllowOrigin      = "Access-Control-Allow-Origin"
+	AccessControlAllowMethods     = "Access-Control-Allow-Methods"
+	AccessControlAllowHeaders     = "Access-Control-Allow-Headers"
+	AccessControlAllowCredentials = "Access-Control-Allow-Credentials"
+	AccessControlExposeHeaders    = "Access-Control-Expose-Headers"
+	AccessControlMaxAge           = "Access-Control-Max-Age"
+)
+
 //object const
 const (
 	MaxObjectSize = 5 * humanize.TiByte
@@ -164,6 +194,10 @@ const (
 	MaxDeleteList  = 1000  // Limit number of objects deleted in a delete call.
 	MaxUploadsList = 10000 // Limit number of uploads in a listUploadsResponse.
 	MaxPartsList   = 10000 // Limit number of parts in a listPartsResponse.
+
+	// Maximum size of the user-defined metadata of an object, the keys with the
+	// x-amz-meta- prefix and the values
+	MaxUserMetadataSize = 2 * humanize.KiByte
 )
 
 // Common http query params S3 API
@@ -0,0 +1,71 @@
+package iam
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/iam/auth"
+	"github.com/filedag-project/filedag-storage/objectservice/iam/s3action"
+	"github.com/filedag-project/filedag-storage/objectservice/store"
+)
+
+// The actions the public canned ACLs grant to everyone, anonymous or not, as per
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/acl-overview.html#permissions
+var (
+	// bucketReadActions are granted by the public-read and public-read-write ACLs of a bucket
+	bucketReadActions = []s3action.Action{
+		s3action.ListBucketAction,
+		s3action.ListBucketVersionsAction,
+		s3action.ListBucketMultipartUploadsAction,
+	}
+	// bucketWriteActions are granted by the public-read-write ACL of a bucket
+	bucketWriteActions = []s3action.Action{
+		s3action.PutObjectAction,
+		s3action.DeleteObjectAction,
+	}
+	// objectReadActions are granted by the public-read and public-read-write ACLs of an object
+	objectReadActions = []s3action.Action{
+		s3action.GetObjectAction,
+		s3action.GetObjectVersionAction,
+	}
+)
+
+// SetObjectACL sets how the canned ACL of an object version is read, the version is the current object
+// if the version ID is empty. The public ACLs of the objects aren't granted until it's set.
+func (s *AuthSys) SetObjectACL(objectACL func(ctx context.Context, bucket, object, versionID string) (string, error)) {
+	s.PolicySys.objectACL = objectACL
+}
+
+// isACLAllowed checks whether the canned ACL of the bucket, or of the read object version for the object reads,
+// grants the action of the policy args to everyone
+func (sys *iPolicySys) isACLAllowed(ctx context.Context, args auth.Args) bool {
+	if args.ObjectName != "" && containsAction(objectReadActions, args.Action) {
+		if sys.objectACL == nil {
+			return false
+		}
+		acl, err := sys.objectACL(ctx, args.BucketName, args.ObjectName, args.VersionID)
+		if err != nil {
+			return false
+		}
+		return acl == store.ACLPublicRead || acl == store.ACLPublicReadWrite
+	}
+
+	acl, err := sys.bmSys.GetBucketACL(ctx, args.BucketName)
+	if err != nil {
+		return false
+	}
+	switch acl {
+	case store.ACLPublicRead:
+		return containsAction(bucketReadActions, args.Action)
+	case store.ACLPublicReadWrite:
+		return containsAction(bucketReadActions, args.Action) || containsAction(bucketWriteActions, args.Action)
+	}
+	return false
+}
+
+func containsAction(actions []s3action.Action, action s3action.Action) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
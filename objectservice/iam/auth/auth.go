@@ -25,6 +25,7 @@ type Args struct {
 	BucketName  string              `json:"bucket"`
 	IsOwner     bool                `json:"owner"`
 	ObjectName  string              `json:"object"`
+	VersionID   string              `json:"versionId"`
 }
 
 // Common errors generated for access and secret key validation.
@@ -42,6 +43,9 @@ const (
 	DefaultAccessKey = "filedagadmin"
 	DefaultSecretKey = "filedagadmin"
 )
+
+// DefaultAnonymousPrincipal the principal the anonymous requests are evaluated and logged as by default
+const DefaultAnonymousPrincipal = "anonymous"
 const (
 	// Minimum length for  access key.
 	accessKeyMinLen = 3
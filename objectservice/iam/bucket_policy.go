@@ -4,32 +4,40 @@ import (
 	"context"
 	"github.com/filedag-project/filedag-storage/objectservice/iam/auth"
 	"github.com/filedag-project/filedag-storage/objectservice/iam/policy"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
 	"github.com/filedag-project/filedag-storage/objectservice/store"
-	"github.com/filedag-project/filedag-storage/objectservice/uleveldb"
 )
 
 // iPolicySys - policy subsystem.
 type iPolicySys struct {
 	bmSys *store.BucketMetadataSys
+	// objectACL returns the canned ACL of the object version, the current object if the version ID is empty
+	objectACL func(ctx context.Context, bucket, object, versionID string) (string, error)
 }
 
 // newIPolicySys  - creates new policy system.
-func newIPolicySys(db *uleveldb.ULevelDB) *iPolicySys {
+func newIPolicySys(db metadb.DB) *iPolicySys {
 	return &iPolicySys{
 		bmSys: store.NewBucketMetadataSys(db),
 	}
 }
 
 // isAllowed - checks given policy args is allowed to continue the Rest API.
+// The canned ACLs grant the request which the bucket policy neither allows nor denies.
 func (sys *iPolicySys) isAllowed(ctx context.Context, args auth.Args) bool {
 	p, err := sys.bmSys.GetPolicyConfig(ctx, args.BucketName)
 	if err == nil {
-		return p.IsAllowed(args)
-	}
-	if _, ok := err.(store.BucketPolicyNotFound); !ok {
+		if p.IsAllowed(args) {
+			return true
+		}
+		if p.IsDenied(args) {
+			return false
+		}
+	} else if _, ok := err.(store.BucketPolicyNotFound); !ok {
 		log.Errorw("can't find bucket policy", "bucket", args.BucketName)
+		return false
 	}
-	return false
+	return sys.isACLAllowed(ctx, args)
 }
 
 // GetPolicy returns stored bucket policy
@@ -8,7 +8,7 @@ import (
 	"github.com/filedag-project/filedag-storage/objectservice/consts"
 	"github.com/filedag-project/filedag-storage/objectservice/iam/auth"
 	"github.com/filedag-project/filedag-storage/objectservice/iam/s3action"
-	"github.com/filedag-project/filedag-storage/objectservice/uleveldb"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
 	"github.com/filedag-project/filedag-storage/objectservice/utils/etag"
 	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
 	"io"
@@ -24,17 +24,37 @@ type AuthSys struct {
 	Iam       *IdentityAMSys
 	PolicySys *iPolicySys
 	AdminCred auth.Credentials
+	// signatureDebug returns the details of the mismatched signatures to everyone, for the development only
+	signatureDebug bool
+	// anonymousPrincipal the account name of the anonymous requests
+	anonymousPrincipal string
+	// payloadHashPolicy how the payloads of the v4 signed requests are verified
+	payloadHashPolicy PayloadHashPolicy
 }
 
 //NewAuthSys new an AuthSys
-func NewAuthSys(db *uleveldb.ULevelDB, adminCred auth.Credentials) *AuthSys {
+func NewAuthSys(db metadb.DB, adminCred auth.Credentials) *AuthSys {
 	return &AuthSys{
 		Iam:       NewIdentityAMSys(db),
 		PolicySys: newIPolicySys(db),
 		AdminCred: adminCred,
+
+		anonymousPrincipal: auth.DefaultAnonymousPrincipal,
+		payloadHashPolicy:  PayloadHashAlways,
 	}
 }
 
+// SetSignatureDebug sets whether the server's canonical request and string to sign of a mismatched
+// signature are returned to anyone by the verify-signature api, otherwise only to the root user.
+func (s *AuthSys) SetSignatureDebug(enable bool) {
+	s.signatureDebug = enable
+}
+
+// SignatureDebug returns whether the details of the mismatched signatures are returned to anyone
+func (s *AuthSys) SignatureDebug() bool {
+	return s.signatureDebug
+}
+
 // CheckRequestAuthTypeCredential Check request auth type verifies the incoming http request
 // - validates the request signature
 // - validates the policy action if anonymous tests bucket policies if any,
@@ -64,6 +84,7 @@ func (s *AuthSys) CheckRequestAuthTypeCredential(ctx context.Context, r *http.Re
 	if s3Err != apierrors.ErrNone {
 		return cred, owner, s3Err
 	}
+	setRequestPrincipal(ctx, s.Principal(cred))
 	// TODO: Why should a temporary user be replaced with the parent user's account?
 	//if cred.IsTemp() {
 	//	cred, _ = s.Iam.GetUser(ctx, cred.ParentUser)
@@ -88,13 +109,14 @@ func (s *AuthSys) CheckRequestAuthTypeCredential(ctx context.Context, r *http.Re
 		owner = false
 	}
 
-	// check bucket policy
+	// check bucket policy, the object reads are checked against the ACL of the read version
 	if s.PolicySys.isAllowed(ctx, auth.Args{
-		AccountName: cred.AccessKey,
+		AccountName: s.Principal(cred),
 		Action:      action,
 		BucketName:  bucketName,
 		IsOwner:     owner,
 		ObjectName:  objectName,
+		VersionID:   r.URL.Query().Get(consts.VersionID),
 	}) {
 		// Request is allowed return the appropriate access key.
 		return cred, owner, apierrors.ErrNone
@@ -103,7 +125,7 @@ func (s *AuthSys) CheckRequestAuthTypeCredential(ctx context.Context, r *http.Re
 		// In AWS S3 s3:ListBucket permission is same as s3:ListBucketVersions permission
 		// verify as a fallback.
 		if s.PolicySys.isAllowed(ctx, auth.Args{
-			AccountName: cred.AccessKey,
+			AccountName: s.Principal(cred),
 			Action:      s3action.ListBucketAction,
 			BucketName:  bucketName,
 			IsOwner:     owner,
@@ -114,10 +136,10 @@ func (s *AuthSys) CheckRequestAuthTypeCredential(ctx context.Context, r *http.Re
 		}
 	}
 
-	// check user policy
+	// check user policy, the anonymous requests have no user policies
 	if bucketName == "" || action == s3action.CreateBucketAction {
-		if s.Iam.IsAllowed(r.Context(), auth.Args{
-			AccountName: cred.AccessKey,
+		if cred.AccessKey != "" && s.Iam.IsAllowed(r.Context(), auth.Args{
+			AccountName: s.Principal(cred),
 			Action:      action,
 			BucketName:  bucketName,
 			Conditions:  getConditions(r, cred.AccessKey),
@@ -167,20 +189,20 @@ func (s *AuthSys) IsReqAuthenticated(ctx context.Context, r *http.Request, regio
 	}
 
 	// Extract either 'X-Amz-Content-Sha256' header or 'X-Amz-Content-Sha256' query parameter (if V4 presigned)
-	// Do not verify 'X-Amz-Content-Sha256' if skipSHA256.
+	// Do not verify 'X-Amz-Content-Sha256' if the payload hash policy skips it.
+	// The signature of an STS request is computed from its payload, which is verified already.
 	var contentSHA256 []byte
-	if skipSHA256 := SkipContentSha256Cksum(r); !skipSHA256 && isRequestPresignedSignatureV4(r) {
-		if sha256Sum, ok := r.Form[consts.AmzContentSha256]; ok && len(sha256Sum) > 0 {
-			contentSHA256, err = hex.DecodeString(sha256Sum[0])
-			if err != nil {
+	if stype != ServiceSTS {
+		sha256hex, s3Err := s.PayloadSha256(r)
+		if s3Err != apierrors.ErrNone {
+			return s3Err
+		}
+		if sha256hex != "" {
+			contentSHA256, err = hex.DecodeString(sha256hex)
+			if err != nil || len(contentSHA256) == 0 {
 				return apierrors.ErrContentSHA256Mismatch
 			}
 		}
-	} else if _, ok := r.Header[consts.AmzContentSha256]; !skipSHA256 && ok {
-		contentSHA256, err = hex.DecodeString(r.Header.Get(consts.AmzContentSha256))
-		if err != nil || len(contentSHA256) == 0 {
-			return apierrors.ErrContentSHA256Mismatch
-		}
 	}
 
 	// Verify 'Content-Md5' and/or 'X-Amz-Content-Sha256' if present.
@@ -310,9 +332,29 @@ func (s *AuthSys) IsPutActionAllowed(ctx context.Context, r *http.Request, actio
 		return apierrors.ErrNone
 	}
 
+	return s.isPutAllowed(ctx, cred, owner, action, bucketName, objectName)
+}
+
+// CheckPostPolicyCredential verifies the V2 or V4 signature of a browser POST upload form,
+// and checks if the signer is allowed to put the object.
+func (s *AuthSys) CheckPostPolicyCredential(ctx context.Context, formValues http.Header, bucketName, objectName string) (cred auth.Credentials, s3Err apierrors.ErrorCode) {
+	if formValues.Get(consts.AmzSignature) != "" {
+		cred, s3Err = s.doesPolicySignatureV4Match(formValues)
+	} else {
+		cred, s3Err = s.doesPolicySignatureV2Match(formValues)
+	}
+	if s3Err != apierrors.ErrNone {
+		return cred, s3Err
+	}
+	owner := cred.AccessKey == s.AdminCred.AccessKey
+	return cred, s.isPutAllowed(ctx, cred, owner, s3action.PutObjectAction, bucketName, objectName)
+}
+
+func (s *AuthSys) isPutAllowed(ctx context.Context, cred auth.Credentials, owner bool, action s3action.Action, bucketName, objectName string) apierrors.ErrorCode {
+	setRequestPrincipal(ctx, s.Principal(cred))
 	// check bucket policy
 	if s.PolicySys.isAllowed(ctx, auth.Args{
-		AccountName: cred.AccessKey,
+		AccountName: s.Principal(cred),
 		Action:      action,
 		BucketName:  bucketName,
 		IsOwner:     owner,
@@ -7,7 +7,7 @@ import (
 	"github.com/filedag-project/filedag-storage/objectservice/iam/auth"
 	"github.com/filedag-project/filedag-storage/objectservice/iam/policy"
 	"github.com/filedag-project/filedag-storage/objectservice/iam/s3action"
-	"github.com/filedag-project/filedag-storage/objectservice/uleveldb"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
 	logging "github.com/ipfs/go-log/v2"
 )
 
@@ -29,7 +29,7 @@ type IdentityAMSys struct {
 }
 
 // NewIdentityAMSys - new an IdentityAM config system
-func NewIdentityAMSys(db *uleveldb.ULevelDB) *IdentityAMSys {
+func NewIdentityAMSys(db metadb.DB) *IdentityAMSys {
 	sys := &IdentityAMSys{}
 	sys.store = &iamStoreSys{newIAMLevelDBStore(db)}
 	// TODO: Is it necessary?
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"github.com/filedag-project/filedag-storage/objectservice/iam/auth"
 	"github.com/filedag-project/filedag-storage/objectservice/iam/policy"
-	"github.com/filedag-project/filedag-storage/objectservice/uleveldb"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
 	"strings"
 )
 
@@ -30,7 +30,7 @@ func getUserPolicyKey(username, policyName string) string {
 
 // iamLevelDBStore implements IAMStorageAPI
 type iamLevelDBStore struct {
-	levelDB *uleveldb.ULevelDB
+	levelDB metadb.DB
 }
 
 func (I *iamLevelDBStore) loadUser(ctx context.Context, user string, m *auth.Credentials) error {
@@ -162,7 +162,7 @@ func (I *iamLevelDBStore) removeUserAllPolicies(ctx context.Context, userName st
 //	panic("implement me")
 //}
 
-func newIAMLevelDBStore(db *uleveldb.ULevelDB) *iamLevelDBStore {
+func newIAMLevelDBStore(db metadb.DB) *iamLevelDBStore {
 	return &iamLevelDBStore{
 		levelDB: db,
 	}
@@ -0,0 +1,58 @@
+package iam
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/iam/auth"
+	"golang.org/x/xerrors"
+)
+
+// SetAnonymousPrincipal sets the principal the anonymous requests are evaluated by the policies and
+// logged as, a policy statement whose principal is the name applies to the anonymous requests only.
+// The name must not be a user, the users of the name can't be created. The anonymous requests are
+// evaluated by the bucket policies and the ACLs only, never by the policies of a user.
+func (s *AuthSys) SetAnonymousPrincipal(ctx context.Context, name string) error {
+	if name == "" {
+		return xerrors.New("the anonymous principal can't be empty")
+	}
+	if name == s.AdminCred.AccessKey {
+		return xerrors.New("the anonymous principal can't be the root user")
+	}
+	if _, ok := s.Iam.GetUser(ctx, name); ok {
+		return xerrors.Errorf("the anonymous principal can't be the user %v", name)
+	}
+	s.anonymousPrincipal = name
+	return nil
+}
+
+// AnonymousPrincipal returns the principal of the anonymous requests
+func (s *AuthSys) AnonymousPrincipal() string {
+	return s.anonymousPrincipal
+}
+
+// Principal returns the principal of the credential, the anonymous principal for an empty access key
+func (s *AuthSys) Principal(cred auth.Credentials) string {
+	if cred.AccessKey == "" {
+		return s.anonymousPrincipal
+	}
+	return cred.AccessKey
+}
+
+type requestIdentityKey struct{}
+
+// RequestIdentity who sent a request, it's filled once the request is authenticated
+type RequestIdentity struct {
+	Principal string
+}
+
+// WithRequestIdentity returns a context which the identity of the request is recorded to
+func WithRequestIdentity(ctx context.Context) (context.Context, *RequestIdentity) {
+	id := &RequestIdentity{}
+	return context.WithValue(ctx, requestIdentityKey{}, id), id
+}
+
+// setRequestPrincipal records the principal of the authenticated request if its context carries an identity
+func setRequestPrincipal(ctx context.Context, principal string) {
+	if id, ok := ctx.Value(requestIdentityKey{}).(*RequestIdentity); ok {
+		id.Principal = principal
+	}
+}
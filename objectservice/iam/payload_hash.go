@@ -0,0 +1,78 @@
+package iam
+
+import (
+	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
+	"github.com/filedag-project/filedag-storage/objectservice/consts"
+	"golang.org/x/xerrors"
+	"net/http"
+)
+
+// PayloadHashPolicy how the payload of a v4 signed request is verified against its x-amz-content-sha256.
+// The signature always covers the declared hash, the payload is verified by hashing it while it's read.
+type PayloadHashPolicy string
+
+const (
+	// PayloadHashAlways verifies every payload, UNSIGNED-PAYLOAD is rejected and a missing
+	// hash is the hash of the empty payload, it's the default. Like S3 does, the presigned
+	// requests are still accepted as UNSIGNED-PAYLOAD, the URL is signed before the payload is known
+	PayloadHashAlways PayloadHashPolicy = "always"
+	// PayloadHashNeverForUnsigned accepts a payload declared as UNSIGNED-PAYLOAD without hashing it,
+	// the other payloads are verified like PayloadHashAlways does
+	PayloadHashNeverForUnsigned PayloadHashPolicy = "never-for-unsigned"
+	// PayloadHashWhenPresent only verifies a payload whose hash is sent, UNSIGNED-PAYLOAD, a missing hash
+	// and the empty hash some broken clients send with a payload are accepted
+	PayloadHashWhenPresent PayloadHashPolicy = "when-present"
+)
+
+// SetPayloadHashPolicy sets how the payloads of the v4 signed requests are verified, PayloadHashAlways by default.
+// The signature of the headers is verified by every policy, but a payload which isn't hashed can be altered
+// on the way without the signature noticing it, the policies other than PayloadHashAlways must only be used
+// where TLS or a trusted network protects the payloads.
+func (s *AuthSys) SetPayloadHashPolicy(policy PayloadHashPolicy) error {
+	switch policy {
+	case PayloadHashAlways, PayloadHashNeverForUnsigned, PayloadHashWhenPresent:
+		s.payloadHashPolicy = policy
+		return nil
+	}
+	return xerrors.Errorf("unknown payload hash policy %q, it's one of %s, %s, %s", policy,
+		PayloadHashAlways, PayloadHashNeverForUnsigned, PayloadHashWhenPresent)
+}
+
+// PayloadSha256 returns the hex sha256 the payload of the v4 signed request must match by the payload
+// hash policy, it's empty if the payload isn't verified.
+func (s *AuthSys) PayloadSha256(r *http.Request) (string, apierrors.ErrorCode) {
+	var (
+		v  []string
+		ok bool
+	)
+	presigned := isRequestPresignedSignatureV4(r)
+	if presigned {
+		v, ok = r.Form[consts.AmzContentSha256]
+		if !ok {
+			v, ok = r.Header[consts.AmzContentSha256]
+		}
+	} else {
+		v, ok = r.Header[consts.AmzContentSha256]
+	}
+
+	switch {
+	// a presigned request without the hash is signed as UNSIGNED-PAYLOAD
+	case ok && v[0] == unsignedPayload, !ok && presigned:
+		// a request without a payload has nothing to verify, and a presigned URL can't sign the payload
+		if s.payloadHashPolicy == PayloadHashAlways && !presigned && r.ContentLength != 0 {
+			return "", apierrors.ErrUnsignedPayloadNotAllowed
+		}
+		return "", apierrors.ErrNone
+	// a signed request without the hash is signed by the hash of the empty payload
+	case !ok:
+		if s.payloadHashPolicy == PayloadHashWhenPresent {
+			return "", apierrors.ErrNone
+		}
+		return consts.EmptySHA256, apierrors.ErrNone
+	case v[0] == consts.EmptySHA256 && r.ContentLength > 0:
+		if s.payloadHashPolicy == PayloadHashWhenPresent {
+			return "", apierrors.ErrNone
+		}
+	}
+	return v[0], apierrors.ErrNone
+}
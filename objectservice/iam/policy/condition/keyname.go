@@ -126,7 +126,7 @@ const (
 )
 
 // AllSupportedKeys - is list of all supported keys.
-var AllSupportedKeys = append([]KeyName{
+var AllSupportedKeys = []KeyName{
 	S3SignatureVersion,
 	S3AuthType,
 	S3XAmzCopySource,
@@ -154,10 +154,10 @@ var AllSupportedKeys = append([]KeyName{
 	AWSUserID,
 	AWSUsername,
 	// Add new supported condition keys.
-})
+}
 
 // CommonKeys - is list of all common condition keys.
-var CommonKeys = append([]KeyName{
+var CommonKeys = []KeyName{
 	S3SignatureVersion,
 	S3AuthType,
 	S3XAmzContentSha256,
@@ -172,4 +172,4 @@ var CommonKeys = append([]KeyName{
 	AWSUserID,
 	AWSUsername,
 	S3ExistingObjectTag,
-})
+}
@@ -83,6 +83,17 @@ func (p Policy) IsAllowed(args auth.Args) bool {
 	return false
 }
 
+// IsDenied - checks whether a deny statement of the policy matches the given policy args,
+// the request is denied whatever else grants it.
+func (p Policy) IsDenied(args auth.Args) bool {
+	for _, statement := range p.Statements {
+		if statement.Effect == Deny && !statement.IsAllowed(args) {
+			return true
+		}
+	}
+	return false
+}
+
 // ParseConfig - parses data in given reader to Policy.
 func ParseConfig(reader io.Reader, bucketName string) (*Policy, error) {
 	var policy Policy
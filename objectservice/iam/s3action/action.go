@@ -74,6 +74,27 @@ const (
 	// GetBucketLifecycleAction - GetBucketLifecycle Rest API action.
 	GetBucketLifecycleAction = "s3:GetLifecycleConfiguration"
 
+	// PutBucketWebsiteAction - PutBucketWebsite Rest API action.
+	PutBucketWebsiteAction = "s3:PutBucketWebsite"
+
+	// GetBucketWebsiteAction - GetBucketWebsite Rest API action.
+	GetBucketWebsiteAction = "s3:GetBucketWebsite"
+
+	// DeleteBucketWebsiteAction - DeleteBucketWebsite Rest API action.
+	DeleteBucketWebsiteAction = "s3:DeleteBucketWebsite"
+
+	// PutBucketCorsAction - PutBucketCors, DeleteBucketCors Rest API action.
+	PutBucketCorsAction = "s3:PutBucketCORS"
+
+	// GetBucketCorsAction - GetBucketCors Rest API action.
+	GetBucketCorsAction = "s3:GetBucketCORS"
+
+	// PutInventoryConfigurationAction - PutBucketInventoryConfiguration, DeleteBucketInventoryConfiguration Rest API action.
+	PutInventoryConfigurationAction = "s3:PutInventoryConfiguration"
+
+	// GetInventoryConfigurationAction - GetBucketInventoryConfiguration, ListBucketInventoryConfigurations Rest API action.
+	GetInventoryConfigurationAction = "s3:GetInventoryConfiguration"
+
 	// PutBucketNotificationAction - PutObjectNotification Rest API action.
 	PutBucketNotificationAction = "s3:PutBucketNotification"
 
@@ -134,6 +155,18 @@ const (
 	// DeleteObjectTaggingAction - Delete Object Tags API action
 	DeleteObjectTaggingAction = "s3:DeleteObjectTagging"
 
+	// GetBucketAclAction - GetBucketAcl REST API action
+	GetBucketAclAction = "s3:GetBucketAcl"
+
+	// PutBucketAclAction - PutBucketAcl REST API action
+	PutBucketAclAction = "s3:PutBucketAcl"
+
+	// GetObjectAclAction - GetObjectAcl REST API action
+	GetObjectAclAction = "s3:GetObjectAcl"
+
+	// PutObjectAclAction - PutObjectAcl REST API action
+	PutObjectAclAction = "s3:PutObjectAcl"
+
 	// PutBucketEncryptionAction - PutBucketEncryption REST API action
 	PutBucketEncryptionAction = "s3:PutEncryptionConfiguration"
 
@@ -192,6 +225,13 @@ var SupportedActions = map[Action]struct{}{
 	ListMultipartUploadPartsAction:         {},
 	PutBucketLifecycleAction:               {},
 	GetBucketLifecycleAction:               {},
+	PutBucketWebsiteAction:                 {},
+	GetBucketWebsiteAction:                 {},
+	DeleteBucketWebsiteAction:              {},
+	PutBucketCorsAction:                    {},
+	GetBucketCorsAction:                    {},
+	PutInventoryConfigurationAction:        {},
+	GetInventoryConfigurationAction:        {},
 	PutBucketNotificationAction:            {},
 	PutBucketPolicyAction:                  {},
 	PutObjectAction:                        {},
@@ -212,6 +252,10 @@ var SupportedActions = map[Action]struct{}{
 	GetObjectTaggingAction:                 {},
 	PutObjectTaggingAction:                 {},
 	DeleteObjectTaggingAction:              {},
+	GetBucketAclAction:                     {},
+	PutBucketAclAction:                     {},
+	GetObjectAclAction:                     {},
+	PutObjectAclAction:                     {},
 	PutBucketEncryptionAction:              {},
 	GetBucketEncryptionAction:              {},
 	PutBucketVersioningAction:              {},
@@ -250,11 +294,13 @@ var supportedObjectActions = map[Action]struct{}{
 	//BypassGovernanceRetentionAction:      {},
 	//PutObjectRetentionAction:             {},
 	//GetObjectRetentionAction:             {},
-	//PutObjectLegalHoldAction:             {},
-	//GetObjectLegalHoldAction:             {},
+	PutObjectLegalHoldAction:  {},
+	GetObjectLegalHoldAction:  {},
 	GetObjectTaggingAction:    {},
 	PutObjectTaggingAction:    {},
 	DeleteObjectTaggingAction: {},
+	GetObjectAclAction:        {},
+	PutObjectAclAction:        {},
 	//GetObjectVersionAction:               {},
 	//GetObjectVersionTaggingAction:        {},
 	//DeleteObjectVersionAction:            {},
@@ -375,6 +421,10 @@ func createActionConditionKeyMap() map[Action]condition.KeySet {
 				condition.S3RequestObjectTag.ToKey(),
 			}, commonKeys...)...),
 		GetObjectTaggingAction: condition.NewKeySet(commonKeys...),
+		GetBucketAclAction:     condition.NewKeySet(commonKeys...),
+		PutBucketAclAction:     condition.NewKeySet(commonKeys...),
+		GetObjectAclAction:     condition.NewKeySet(commonKeys...),
+		PutObjectAclAction:     condition.NewKeySet(commonKeys...),
 		DeleteObjectTaggingAction: condition.NewKeySet(
 			append([]condition.Key{
 				condition.S3RequestObjectTagKeys.ToKey(),
@@ -21,6 +21,7 @@ import (
 	"crypto/subtle"
 	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
 	"github.com/filedag-project/filedag-storage/objectservice/consts"
+	"github.com/filedag-project/filedag-storage/objectservice/iam/auth"
 	"github.com/filedag-project/filedag-storage/objectservice/iam/set"
 	"github.com/filedag-project/filedag-storage/objectservice/utils"
 	"net/http"
@@ -54,6 +55,40 @@ func compareSignatureV4(sig1, sig2 string) bool {
 	return subtle.ConstantTimeCompare([]byte(sig1), []byte(sig2)) == 1
 }
 
+// doesPolicySignatureV4Match - Verify query headers with post policy
+//     - http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-HTTPPOSTConstructPolicy.html
+// returns apierrors.ErrNone if the signature matches.
+func (s *AuthSys) doesPolicySignatureV4Match(formValues http.Header) (auth.Credentials, apierrors.ErrorCode) {
+	// Server region.
+	region := ""
+
+	// Parse credential tag.
+	credHeader, s3Err := parseCredentialHeader("Credential="+formValues.Get(consts.AmzCredential), region, ServiceS3)
+	if s3Err != apierrors.ErrNone {
+		return auth.Credentials{}, s3Err
+	}
+
+	r := &http.Request{Header: formValues}
+	cred, _, s3Err := s.checkKeyValid(r, credHeader.accessKey)
+	if s3Err != apierrors.ErrNone {
+		return cred, s3Err
+	}
+
+	// Get signing key.
+	signingKey := utils.GetSigningKey(cred.SecretKey, credHeader.scope.date, credHeader.scope.region, string(ServiceS3))
+
+	// Get signature.
+	newSignature := utils.GetSignature(signingKey, formValues.Get("Policy"))
+
+	// Verify signature.
+	if !compareSignatureV4(newSignature, formValues.Get(consts.AmzSignature)) {
+		return cred, apierrors.ErrSignatureDoesNotMatch
+	}
+
+	// Success.
+	return cred, apierrors.ErrNone
+}
+
 // doesPresignedSignatureMatch - Verify query headers with presigned signature
 //     - http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-query-string-auth.html
 // returns apierrors.ErrNone if the signature matches.
@@ -184,6 +219,38 @@ func (s *AuthSys) doesPresignedSignatureMatch(hashedPayload string, r *http.Requ
 //     - http://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html
 // returns apierrors.ErrNone if signature matches.
 func (s *AuthSys) doesSignatureMatch(hashedPayload string, r *http.Request, region string, stype serviceType) apierrors.ErrorCode {
+	sig, errCode := s.calculateSignatureV4(hashedPayload, r, region, stype)
+	if errCode != apierrors.ErrNone {
+		return errCode
+	}
+	if !sig.SignatureMatch {
+		return apierrors.ErrSignatureDoesNotMatch
+	}
+	return apierrors.ErrNone
+}
+
+// SignatureV4Debug the values the server computes to verify a signature version '4' request,
+// a client diffs them against its own to find why its signature doesn't match.
+// It never holds the secret key nor the signing key.
+type SignatureV4Debug struct {
+	AccessKey        string `json:"accessKey"`
+	SignedHeaders    string `json:"signedHeaders"`
+	CanonicalRequest string `json:"canonicalRequest"`
+	StringToSign     string `json:"stringToSign"`
+	SignatureMatch   bool   `json:"signatureMatch"`
+}
+
+// DebugSignatureV4 verifies the authorization header of the request and returns the canonical
+// request and the string to sign of the server, SignatureMatch is false if the signature doesn't match.
+func (s *AuthSys) DebugSignatureV4(r *http.Request, region string, stype serviceType) (SignatureV4Debug, apierrors.ErrorCode) {
+	if !IsRequestSignatureV4(r) {
+		return SignatureV4Debug{}, apierrors.ErrSignatureVersionNotSupported
+	}
+	return s.calculateSignatureV4(GetContentSha256Cksum(r, stype), r, region, stype)
+}
+
+// calculateSignatureV4 calculates the signature of the authorization header and compares it with the signature of the request
+func (s *AuthSys) calculateSignatureV4(hashedPayload string, r *http.Request, region string, stype serviceType) (SignatureV4Debug, apierrors.ErrorCode) {
 	// Copy request.
 	req := *r
 
@@ -193,32 +260,32 @@ func (s *AuthSys) doesSignatureMatch(hashedPayload string, r *http.Request, regi
 	// Parse signature version '4' header.
 	signV4Values, err := parseSignV4(v4Auth, region, stype)
 	if err != apierrors.ErrNone {
-		return err
+		return SignatureV4Debug{}, err
 	}
 
 	// Extract all the signed headers along with its values.
 	extractedSignedHeaders, errCode := extractSignedHeaders(signV4Values.SignedHeaders, r)
 	if errCode != apierrors.ErrNone {
-		return errCode
+		return SignatureV4Debug{}, errCode
 	}
 
 	cred, _, s3Err := s.checkKeyValid(r, signV4Values.Credential.accessKey)
 	if s3Err != apierrors.ErrNone {
-		return s3Err
+		return SignatureV4Debug{}, s3Err
 	}
 
 	// Extract date, if not present throw error.
 	var date string
 	if date = req.Header.Get(consts.AmzDate); date == "" {
 		if date = r.Header.Get(consts.Date); date == "" {
-			return apierrors.ErrMissingDateHeader
+			return SignatureV4Debug{}, apierrors.ErrMissingDateHeader
 		}
 	}
 
 	// Parse date header.
 	t, e := time.Parse(iso8601Format, date)
 	if e != nil {
-		return apierrors.ErrAuthorizationHeaderMalformed
+		return SignatureV4Debug{}, apierrors.ErrAuthorizationHeaderMalformed
 	}
 
 	// Query string.
@@ -237,13 +304,14 @@ func (s *AuthSys) doesSignatureMatch(hashedPayload string, r *http.Request, regi
 	// Calculate signature.
 	newSignature := utils.GetSignature(signingKey, stringToSign)
 
-	// Verify if signature match.
-	if !compareSignatureV4(newSignature, signV4Values.Signature) {
-		return apierrors.ErrSignatureDoesNotMatch
-	}
-
-	// Return error none.
-	return apierrors.ErrNone
+	return SignatureV4Debug{
+		AccessKey:        cred.AccessKey,
+		SignedHeaders:    utils.GetSignedHeaders(extractedSignedHeaders),
+		CanonicalRequest: canonicalRequest,
+		StringToSign:     stringToSign,
+		// Verify if signature match.
+		SignatureMatch: compareSignatureV4(newSignature, signV4Values.Signature),
+	}, apierrors.ErrNone
 }
 
 // getScope generate a string of a specific date, an AWS region, and a service.
@@ -0,0 +1,102 @@
+package iamapi
+
+import (
+	"encoding/json"
+	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
+	"github.com/filedag-project/filedag-storage/objectservice/response"
+	"net/http"
+	"strconv"
+)
+
+const (
+	BucketLimitAccessKey = "accessKey"
+	BucketLimitValue     = "limit"
+)
+
+// bucketLimitResponse the number of buckets a user can create and has created
+type bucketLimitResponse struct {
+	AccessKey string `json:"accessKey"`
+	Limit     int    `json:"limit"`
+	Buckets   int    `json:"buckets"`
+}
+
+// GetBucketLimit returns the number of buckets a user can create, -1 if it's unlimited, and the number
+// of buckets the user has. Only the root user can read it.
+func (iamApi *iamApiServer) GetBucketLimit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	_, owner, s3err := iamApi.authSys.CheckRequestAuthTypeCredential(ctx, r, "", "", "")
+	if s3err != apierrors.ErrNone || !owner {
+		response.WriteErrorResponse(w, r, apierrors.ErrAccessDenied)
+		return
+	}
+	accessKey := r.URL.Query().Get(BucketLimitAccessKey)
+	if accessKey == "" {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidQueryParams)
+		return
+	}
+	limit, err := iamApi.bmSys.GetUserBucketLimit(ctx, accessKey)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	buckets, err := iamApi.bmSys.GetAllBucketsOfUser(ctx, accessKey)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	resp, err := json.Marshal(bucketLimitResponse{AccessKey: accessKey, Limit: limit, Buckets: len(buckets)})
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrInternalError)
+		return
+	}
+	response.WriteSuccessResponseJSON(w, resp)
+}
+
+// SetBucketLimit sets the number of buckets a user can create, PutBucket fails with TooManyBuckets once
+// the user has as many. The limit -1 lets the user create any number of buckets. Only the root user can set it.
+func (iamApi *iamApiServer) SetBucketLimit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	cred, owner, s3err := iamApi.authSys.CheckRequestAuthTypeCredential(ctx, r, "", "", "")
+	if s3err != apierrors.ErrNone || !owner {
+		response.WriteErrorResponse(w, r, apierrors.ErrAccessDenied)
+		return
+	}
+	accessKey := r.URL.Query().Get(BucketLimitAccessKey)
+	if accessKey == "" {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidQueryParams)
+		return
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get(BucketLimitValue))
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidRequest)
+		return
+	}
+	if err = iamApi.bmSys.UpdateUserBucketLimit(ctx, accessKey, limit); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	log.Infow("bucket limit set by admin", "accessKey", cred.AccessKey, "user", accessKey, "limit", limit)
+	response.WriteSuccessResponseHeadersOnly(w, r)
+}
+
+// ResetBucketLimit removes the bucket limit of a user, the user gets the default limit of the server.
+// Only the root user can reset it.
+func (iamApi *iamApiServer) ResetBucketLimit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	cred, owner, s3err := iamApi.authSys.CheckRequestAuthTypeCredential(ctx, r, "", "", "")
+	if s3err != apierrors.ErrNone || !owner {
+		response.WriteErrorResponse(w, r, apierrors.ErrAccessDenied)
+		return
+	}
+	accessKey := r.URL.Query().Get(BucketLimitAccessKey)
+	if accessKey == "" {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidQueryParams)
+		return
+	}
+	if err := iamApi.bmSys.ResetUserBucketLimit(ctx, accessKey); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	log.Infow("bucket limit reset by admin", "accessKey", cred.AccessKey, "user", accessKey)
+	response.WriteSuccessResponseHeadersOnly(w, r)
+}
@@ -0,0 +1,81 @@
+package iamapi
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/filedag-project/filedag-storage/objectservice/store"
+	"github.com/filedag-project/filedag-storage/objectservice/utils"
+	"net/http"
+	"testing"
+)
+
+func TestIamApiServer_BucketLimit(t *testing.T) {
+	baseUrl := "http://127.0.0.1:9985/admin/v1"
+	getLimit := func() bucketLimitResponse {
+		req := utils.MustNewSignedV4Request(http.MethodGet, baseUrl+"/bucket-limit?accessKey=limituser", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result := reqTest(req)
+		if result.Code != http.StatusOK {
+			t.Fatalf("Expected the response status to be `%d`, but instead found `%d`", http.StatusOK, result.Code)
+		}
+		var resp bucketLimitResponse
+		if err := json.Unmarshal(result.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	if resp := getLimit(); resp.Limit != store.DefaultMaxBuckets || resp.Buckets != 0 {
+		t.Fatalf("unexpected default limit %+v", resp)
+	}
+
+	testCases := []struct {
+		query string
+		// expected output.
+		expectedRespStatus int // expected response status body.
+		expectedLimit      int
+	}{
+		{query: "?accessKey=limituser&limit=1", expectedRespStatus: http.StatusOK, expectedLimit: 1},
+		{query: "?accessKey=limituser&limit=-1", expectedRespStatus: http.StatusOK, expectedLimit: store.UnlimitedBuckets},
+		{query: "?accessKey=limituser&limit=1", expectedRespStatus: http.StatusOK, expectedLimit: 1},
+		{query: "?accessKey=limituser&limit=-2", expectedRespStatus: http.StatusBadRequest, expectedLimit: 1},
+		{query: "?accessKey=limituser&limit=abc", expectedRespStatus: http.StatusBadRequest, expectedLimit: 1},
+		{query: "?accessKey=&limit=2", expectedRespStatus: http.StatusBadRequest, expectedLimit: 1},
+	}
+	for i, testCase := range testCases {
+		req := utils.MustNewSignedV4Request(http.MethodPost, baseUrl+"/bucket-limit"+testCase.query, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result := reqTest(req)
+		if result.Code != testCase.expectedRespStatus {
+			t.Fatalf("Case %d: Expected the response status to be `%d`, but instead found `%d`", i+1, testCase.expectedRespStatus, result.Code)
+		}
+		if resp := getLimit(); resp.Limit != testCase.expectedLimit {
+			t.Fatalf("Case %d: unexpected limit %d", i+1, resp.Limit)
+		}
+	}
+
+	// the creation beyond the limit is rejected until the limit is raised
+	ctx := context.TODO()
+	if err := testBmSys.CreateBucket(ctx, "limitbucket1", "", "limituser"); err != nil {
+		t.Fatal(err)
+	}
+	if err := testBmSys.CreateBucket(ctx, "limitbucket2", "", "limituser"); err != (store.TooManyBuckets{AccessKey: "limituser", Limit: 1}) {
+		t.Fatalf("the bucket beyond the limit must be rejected, %v", err)
+	}
+	req := utils.MustNewSignedV4Request(http.MethodPost, baseUrl+"/bucket-limit?accessKey=limituser&limit=2", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	if result := reqTest(req); result.Code != http.StatusOK {
+		t.Fatalf("Expected the response status to be `%d`, but instead found `%d`", http.StatusOK, result.Code)
+	}
+	if err := testBmSys.CreateBucket(ctx, "limitbucket2", "", "limituser"); err != nil {
+		t.Fatalf("the bucket must be created after the limit is raised, %v", err)
+	}
+	if resp := getLimit(); resp.Limit != 2 || resp.Buckets != 2 {
+		t.Fatalf("unexpected limit %+v", resp)
+	}
+
+	// the reset gets the default limit back
+	req = utils.MustNewSignedV4Request(http.MethodPost, baseUrl+"/reset-bucket-limit?accessKey=limituser", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	if result := reqTest(req); result.Code != http.StatusOK {
+		t.Fatalf("Expected the response status to be `%d`, but instead found `%d`", http.StatusOK, result.Code)
+	}
+	if resp := getLimit(); resp.Limit != store.DefaultMaxBuckets || resp.Buckets != 2 {
+		t.Fatalf("unexpected limit %+v after the reset", resp)
+	}
+}
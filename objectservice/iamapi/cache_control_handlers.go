@@ -0,0 +1,95 @@
+package iamapi
+
+import (
+	"encoding/json"
+	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
+	"github.com/filedag-project/filedag-storage/objectservice/response"
+	"net/http"
+)
+
+const (
+	CacheControlBucket = "bucket"
+	CacheControlValue  = "cacheControl"
+)
+
+// cacheControlResponse the default Cache-Control of the objects in a bucket
+type cacheControlResponse struct {
+	Bucket       string `json:"bucket"`
+	CacheControl string `json:"cacheControl"`
+}
+
+// GetCacheControl returns the default Cache-Control of the objects in a bucket, empty if it isn't set.
+// Only the root user can read it.
+func (iamApi *iamApiServer) GetCacheControl(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	_, owner, s3err := iamApi.authSys.CheckRequestAuthTypeCredential(ctx, r, "", "", "")
+	if s3err != apierrors.ErrNone || !owner {
+		response.WriteErrorResponse(w, r, apierrors.ErrAccessDenied)
+		return
+	}
+	bucket := r.URL.Query().Get(CacheControlBucket)
+	if bucket == "" {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidBucketName)
+		return
+	}
+	cacheControl, err := iamApi.bmSys.GetBucketCacheControl(ctx, bucket)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	resp, err := json.Marshal(cacheControlResponse{Bucket: bucket, CacheControl: cacheControl})
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrInternalError)
+		return
+	}
+	response.WriteSuccessResponseJSON(w, resp)
+}
+
+// SetCacheControl sets the default Cache-Control of the objects in a bucket, GET and HEAD return it
+// for the objects stored without a Cache-Control of their own. Only the root user can set it.
+func (iamApi *iamApiServer) SetCacheControl(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	cred, owner, s3err := iamApi.authSys.CheckRequestAuthTypeCredential(ctx, r, "", "", "")
+	if s3err != apierrors.ErrNone || !owner {
+		response.WriteErrorResponse(w, r, apierrors.ErrAccessDenied)
+		return
+	}
+	bucket := r.URL.Query().Get(CacheControlBucket)
+	if bucket == "" {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidBucketName)
+		return
+	}
+	cacheControl := r.URL.Query().Get(CacheControlValue)
+	if cacheControl == "" {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidQueryParams)
+		return
+	}
+	if err := iamApi.bmSys.UpdateBucketCacheControl(ctx, bucket, cacheControl); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	log.Infow("cache control set by admin", "accessKey", cred.AccessKey, "bucket", bucket, "cacheControl", cacheControl)
+	response.WriteSuccessResponseHeadersOnly(w, r)
+}
+
+// RemoveCacheControl removes the default Cache-Control of the objects in a bucket.
+// Only the root user can remove it.
+func (iamApi *iamApiServer) RemoveCacheControl(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	cred, owner, s3err := iamApi.authSys.CheckRequestAuthTypeCredential(ctx, r, "", "", "")
+	if s3err != apierrors.ErrNone || !owner {
+		response.WriteErrorResponse(w, r, apierrors.ErrAccessDenied)
+		return
+	}
+	bucket := r.URL.Query().Get(CacheControlBucket)
+	if bucket == "" {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidBucketName)
+		return
+	}
+	if err := iamApi.bmSys.UpdateBucketCacheControl(ctx, bucket, ""); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	log.Infow("cache control removed by admin", "accessKey", cred.AccessKey, "bucket", bucket)
+	response.WriteSuccessResponseHeadersOnly(w, r)
+}
@@ -0,0 +1,76 @@
+package iamapi
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/filedag-project/filedag-storage/objectservice/utils"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestIamApiServer_CacheControl(t *testing.T) {
+	baseUrl := "http://127.0.0.1:9985/admin/v1"
+	if err := testBmSys.CreateBucket(context.TODO(), "cachebucket", "", DefaultTestAccessKey); err != nil {
+		t.Fatal(err)
+	}
+	reqPutUser := utils.MustNewSignedV4Request(http.MethodPost, baseUrl+"/add-user?accessKey=cacheTest1&secretKey=cacheTest1234", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	if result := reqTest(reqPutUser); result.Code != http.StatusOK {
+		t.Fatalf("Expected the response status to be `%d`, but instead found `%d`", http.StatusOK, result.Code)
+	}
+	getCacheControl := func() string {
+		req := utils.MustNewSignedV4Request(http.MethodGet, baseUrl+"/cache-control?bucket=cachebucket", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result := reqTest(req)
+		if result.Code != http.StatusOK {
+			t.Fatalf("Expected the response status to be `%d`, but instead found `%d`", http.StatusOK, result.Code)
+		}
+		var resp cacheControlResponse
+		if err := json.Unmarshal(result.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.CacheControl
+	}
+	if cacheControl := getCacheControl(); cacheControl != "" {
+		t.Fatalf("unexpected default cache control %q", cacheControl)
+	}
+
+	testCases := []struct {
+		path      string
+		query     url.Values
+		accessKey string
+		secretKey string
+		// expected output.
+		expectedRespStatus   int // expected response status body.
+		expectedCacheControl string
+	}{
+		// set
+		{path: "/cache-control", query: url.Values{"bucket": {"cachebucket"}, "cacheControl": {"max-age=60"}},
+			accessKey: DefaultTestAccessKey, secretKey: DefaultTestSecretKey, expectedRespStatus: http.StatusOK, expectedCacheControl: "max-age=60"},
+		// change
+		{path: "/cache-control", query: url.Values{"bucket": {"cachebucket"}, "cacheControl": {"public, max-age=3600"}},
+			accessKey: DefaultTestAccessKey, secretKey: DefaultTestSecretKey, expectedRespStatus: http.StatusOK, expectedCacheControl: "public, max-age=3600"},
+		// only the root user can change it
+		{path: "/cache-control", query: url.Values{"bucket": {"cachebucket"}, "cacheControl": {"no-store"}},
+			accessKey: "cacheTest1", secretKey: "cacheTest1234", expectedRespStatus: http.StatusForbidden, expectedCacheControl: "public, max-age=3600"},
+		{path: "/remove-cache-control", query: url.Values{"bucket": {"cachebucket"}},
+			accessKey: "cacheTest1", secretKey: "cacheTest1234", expectedRespStatus: http.StatusForbidden, expectedCacheControl: "public, max-age=3600"},
+		// the empty value isn't a removal
+		{path: "/cache-control", query: url.Values{"bucket": {"cachebucket"}, "cacheControl": {""}},
+			accessKey: DefaultTestAccessKey, secretKey: DefaultTestSecretKey, expectedRespStatus: http.StatusBadRequest, expectedCacheControl: "public, max-age=3600"},
+		{path: "/cache-control", query: url.Values{"bucket": {"nobucket"}, "cacheControl": {"no-store"}},
+			accessKey: DefaultTestAccessKey, secretKey: DefaultTestSecretKey, expectedRespStatus: http.StatusNotFound, expectedCacheControl: "public, max-age=3600"},
+		// clear
+		{path: "/remove-cache-control", query: url.Values{"bucket": {"cachebucket"}},
+			accessKey: DefaultTestAccessKey, secretKey: DefaultTestSecretKey, expectedRespStatus: http.StatusOK, expectedCacheControl: ""},
+	}
+	for i, testCase := range testCases {
+		req := utils.MustNewSignedV4Request(http.MethodPost, baseUrl+testCase.path+"?"+testCase.query.Encode(), 0, nil, "s3", testCase.accessKey, testCase.secretKey, t)
+		result := reqTest(req)
+		if result.Code != testCase.expectedRespStatus {
+			t.Fatalf("Case %d: Expected the response status to be `%d`, but instead found `%d`", i+1, testCase.expectedRespStatus, result.Code)
+		}
+		if cacheControl := getCacheControl(); cacheControl != testCase.expectedCacheControl {
+			t.Fatalf("Case %d: unexpected cache control %q", i+1, cacheControl)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package iamapi
+
+import (
+	"encoding/json"
+	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
+	"github.com/filedag-project/filedag-storage/objectservice/response"
+	"github.com/ipfs/go-cid"
+	"net/http"
+)
+
+const LookupCid = "cid"
+
+// cidObjectResponse an object whose content is the dag of the looked up cid, the version ID is set
+// for a noncurrent version
+type cidObjectResponse struct {
+	Bucket    string `json:"bucket"`
+	Key       string `json:"key"`
+	VersionID string `json:"versionId,omitempty"`
+	Trashed   bool   `json:"trashed,omitempty"`
+}
+
+// LookupCid lists the objects whose content is the dag of a root cid, the current objects, the noncurrent
+// versions and the objects in the trash, for the takedown of a content stored under several keys.
+// Only the root user can look it up.
+func (iamApi *iamApiServer) LookupCid(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	_, owner, s3err := iamApi.authSys.CheckRequestAuthTypeCredential(ctx, r, "", "", "")
+	if s3err != apierrors.ErrNone || !owner {
+		response.WriteErrorResponse(w, r, apierrors.ErrAccessDenied)
+		return
+	}
+	root, err := cid.Decode(r.URL.Query().Get(LookupCid))
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidRequest)
+		return
+	}
+	refs, err := iamApi.storageSys.ListObjectsByCid(ctx, root.String())
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	objects := make([]cidObjectResponse, 0, len(refs))
+	for _, ref := range refs {
+		objects = append(objects, cidObjectResponse{Bucket: ref.Bucket, Key: ref.Object, VersionID: ref.VersionID, Trashed: ref.Trashed})
+	}
+	resp, err := json.Marshal(objects)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrInternalError)
+		return
+	}
+	response.WriteSuccessResponseJSON(w, resp)
+}
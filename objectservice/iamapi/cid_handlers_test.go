@@ -0,0 +1,51 @@
+package iamapi
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/filedag-project/filedag-storage/objectservice/utils"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestIamApiServer_LookupCid(t *testing.T) {
+	baseUrl := "http://127.0.0.1:9985/admin/v1"
+	ctx := context.TODO()
+	if err := testBmSys.CreateBucket(ctx, "cidbucket", "", DefaultTestAccessKey); err != nil {
+		t.Fatal(err)
+	}
+	var root string
+	for _, object := range []string{"a", "b"} {
+		r, err := hash.NewReader(strings.NewReader("123456"), 6, "", "", 6)
+		if err != nil {
+			t.Fatal(err)
+		}
+		oi, err := testStorageSys.StoreObject(ctx, "cidbucket", object, r, 6, map[string]string{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		root = oi.Cid
+	}
+
+	req := utils.MustNewSignedV4Request(http.MethodGet, baseUrl+"/lookup-cid?cid="+root, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(req)
+	if result.Code != http.StatusOK {
+		t.Fatalf("Expected the response status to be `%d`, but instead found `%d`", http.StatusOK, result.Code)
+	}
+	var objects []cidObjectResponse
+	if err := json.Unmarshal(result.Body.Bytes(), &objects); err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 || objects[0] != (cidObjectResponse{Bucket: "cidbucket", Key: "a"}) || objects[1] != (cidObjectResponse{Bucket: "cidbucket", Key: "b"}) {
+		t.Fatalf("unexpected objects of the cid %+v", objects)
+	}
+
+	// an invalid cid
+	req = utils.MustNewSignedV4Request(http.MethodGet, baseUrl+"/lookup-cid?cid=abc", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(req)
+	if result.Code != http.StatusBadRequest {
+		t.Fatalf("Expected the response status to be `%d`, but instead found `%d`", http.StatusBadRequest, result.Code)
+	}
+}
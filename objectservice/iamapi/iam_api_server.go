@@ -3,6 +3,7 @@ package iamapi
 import (
 	"github.com/filedag-project/filedag-storage/objectservice/iam"
 	"github.com/filedag-project/filedag-storage/objectservice/response"
+	"github.com/filedag-project/filedag-storage/objectservice/store"
 	"github.com/gorilla/mux"
 	logging "github.com/ipfs/go-log/v2"
 	"net/http"
@@ -12,15 +13,19 @@ var log = logging.Logger("iamsever")
 
 //iamApiServer the IamApi Server
 type iamApiServer struct {
-	authSys   *iam.AuthSys
-	cleanData func(accessKey string)
+	authSys    *iam.AuthSys
+	storageSys *store.StorageSys
+	bmSys      *store.BucketMetadataSys
+	cleanData  func(accessKey string)
 }
 
 //NewIamApiServer New iamApiServer
-func NewIamApiServer(router *mux.Router, authSys *iam.AuthSys, cleanData func(accessKey string)) {
+func NewIamApiServer(router *mux.Router, authSys *iam.AuthSys, storageSys *store.StorageSys, bmSys *store.BucketMetadataSys, cleanData func(accessKey string)) {
 	iamApiSer := &iamApiServer{
-		authSys:   authSys,
-		cleanData: cleanData,
+		authSys:    authSys,
+		storageSys: storageSys,
+		bmSys:      bmSys,
+		cleanData:  cleanData,
 	}
 	iamApiSer.registerRouter(router)
 
@@ -47,6 +52,38 @@ func (iamApi *iamApiServer) registerRouter(router *mux.Router) {
 	apiRouter.Methods(http.MethodGet).Path("/list-sub-user-policy").HandlerFunc(iamApi.ListUserPolicies).Queries("userName", "{userName:.*}")
 	apiRouter.Methods(http.MethodPost).Path("/remove-sub-user-policy").HandlerFunc(iamApi.DeleteUserPolicy).Queries("userName", "{userName:.*}", "policyName", "{policyName:.*}")
 
+	//log level
+	apiRouter.Methods(http.MethodGet).Path("/log-level").HandlerFunc(iamApi.GetLogLevels)
+	apiRouter.Methods(http.MethodPost).Path("/log-level").HandlerFunc(iamApi.SetLogLevel).Queries("subsystem", "{subsystem:.*}", "level", "{level:.*}")
+
+	//namespace locks
+	apiRouter.Methods(http.MethodGet).Path("/lock-info").HandlerFunc(iamApi.GetLockInfo).Queries("bucket", "{bucket:.*}")
+	apiRouter.Methods(http.MethodPost).Path("/force-unlock").HandlerFunc(iamApi.ForceUnlock).Queries("bucket", "{bucket:.*}")
+
+	//soft deleted objects
+	apiRouter.Methods(http.MethodGet).Path("/list-trash").HandlerFunc(iamApi.ListTrash).Queries("bucket", "{bucket:.*}")
+	apiRouter.Methods(http.MethodPost).Path("/undelete-object").HandlerFunc(iamApi.UndeleteObject).Queries("bucket", "{bucket:.*}", "object", "{object:.*}")
+
+	//request weights of the buckets
+	apiRouter.Methods(http.MethodGet).Path("/request-weight").HandlerFunc(iamApi.GetRequestWeight).Queries("bucket", "{bucket:.*}")
+	apiRouter.Methods(http.MethodPost).Path("/request-weight").HandlerFunc(iamApi.SetRequestWeight).Queries("bucket", "{bucket:.*}", "weight", "{weight:.*}")
+
+	//default cache control of the buckets
+	apiRouter.Methods(http.MethodGet).Path("/cache-control").HandlerFunc(iamApi.GetCacheControl).Queries("bucket", "{bucket:.*}")
+	apiRouter.Methods(http.MethodPost).Path("/cache-control").HandlerFunc(iamApi.SetCacheControl).Queries("bucket", "{bucket:.*}", "cacheControl", "{cacheControl:.*}")
+	apiRouter.Methods(http.MethodPost).Path("/remove-cache-control").HandlerFunc(iamApi.RemoveCacheControl).Queries("bucket", "{bucket:.*}")
+
+	//bucket limits of the users
+	apiRouter.Methods(http.MethodGet).Path("/bucket-limit").HandlerFunc(iamApi.GetBucketLimit).Queries("accessKey", "{accessKey:.*}")
+	apiRouter.Methods(http.MethodPost).Path("/bucket-limit").HandlerFunc(iamApi.SetBucketLimit).Queries("accessKey", "{accessKey:.*}", "limit", "{limit:.*}")
+	apiRouter.Methods(http.MethodPost).Path("/reset-bucket-limit").HandlerFunc(iamApi.ResetBucketLimit).Queries("accessKey", "{accessKey:.*}")
+
+	//reverse content lookup
+	apiRouter.Methods(http.MethodGet).Path("/lookup-cid").HandlerFunc(iamApi.LookupCid).Queries("cid", "{cid:.*}")
+
+	//signature debug, any method
+	apiRouter.Path("/verify-signature").HandlerFunc(iamApi.VerifySignature)
+
 	//apiRouter.Methods(http.MethodPost).Path("/creat-policy").HandlerFunc(iamApi.CreatePolicy).Queries("policyName", "{policyName:.*}", "policyDocument", "{policyDocument:.*}")
 
 	//apiRouter.Methods(http.MethodPost).Path("/creat-group").HandlerFunc(iamApi.CreatGroup).Queries("groupName", "{groupName:.*}", "version", "{version:.*}")
@@ -0,0 +1,107 @@
+package iamapi
+
+import (
+	"encoding/json"
+	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
+	"github.com/filedag-project/filedag-storage/objectservice/lock"
+	"github.com/filedag-project/filedag-storage/objectservice/response"
+	"net/http"
+	"time"
+)
+
+const (
+	LockBucket  = "bucket"
+	LockObject  = "object"
+	LockMinHeld = "min-held"
+
+	// defaultForceUnlockMinHeld how long a lock must be held before it's taken as leaked,
+	// a live operation gives up on a lock long before
+	defaultForceUnlockMinHeld = 15 * time.Minute
+)
+
+// lockInfoResponse the state of a namespace lock
+type lockInfoResponse struct {
+	lock.LockInfo
+	Locked bool `json:"locked"`
+}
+
+// lockResource returns the bucket and the object of the lock, the bucket lock if the object is empty
+func lockResource(r *http.Request) (bucket, object string, s3err apierrors.ErrorCode) {
+	bucket = r.URL.Query().Get(LockBucket)
+	object = r.URL.Query().Get(LockObject)
+	if bucket == "" {
+		return "", "", apierrors.ErrInvalidBucketName
+	}
+	return bucket, object, apierrors.ErrNone
+}
+
+func writeLockInfo(w http.ResponseWriter, r *http.Request, info lock.LockInfo, locked bool) {
+	resp, err := json.Marshal(lockInfoResponse{LockInfo: info, Locked: locked})
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrInternalError)
+		return
+	}
+	response.WriteSuccessResponseJSON(w, resp)
+}
+
+// GetLockInfo returns the state of the namespace lock of an object, or of the bucket if no object is given.
+// Only the root user can read it.
+func (iamApi *iamApiServer) GetLockInfo(w http.ResponseWriter, r *http.Request) {
+	_, owner, s3err := iamApi.authSys.CheckRequestAuthTypeCredential(r.Context(), r, "", "", "")
+	if s3err != apierrors.ErrNone || !owner {
+		response.WriteErrorResponse(w, r, apierrors.ErrAccessDenied)
+		return
+	}
+	bucket, object, s3err := lockResource(r)
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+	var info lock.LockInfo
+	var locked bool
+	if object == "" {
+		info, locked = iamApi.bmSys.GetBucketLockInfo(bucket)
+	} else {
+		info, locked = iamApi.storageSys.GetObjectLockInfo(bucket, object)
+	}
+	writeLockInfo(w, r, info, locked)
+}
+
+// ForceUnlock releases the namespace lock of an object, or of the bucket if no object is given, which a crashed
+// or hung operation leaked. The lock is only released if it has been held for min-held, 15m by default.
+// Only the root user can release it.
+func (iamApi *iamApiServer) ForceUnlock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	cred, owner, s3err := iamApi.authSys.CheckRequestAuthTypeCredential(ctx, r, "", "", "")
+	if s3err != apierrors.ErrNone || !owner {
+		response.WriteErrorResponse(w, r, apierrors.ErrAccessDenied)
+		return
+	}
+	bucket, object, s3err := lockResource(r)
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+	minHeld := defaultForceUnlockMinHeld
+	if v := r.URL.Query().Get(LockMinHeld); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			response.WriteErrorResponse(w, r, apierrors.ErrInvalidRequest)
+			return
+		}
+		minHeld = d
+	}
+	var info lock.LockInfo
+	var err error
+	if object == "" {
+		info, err = iamApi.bmSys.ForceUnlockBucket(bucket, minHeld)
+	} else {
+		info, err = iamApi.storageSys.ForceUnlockObject(bucket, object, minHeld)
+	}
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	log.Warnw("lock force released by admin", "accessKey", cred.AccessKey, "bucket", bucket, "object", object, "minHeld", minHeld)
+	writeLockInfo(w, r, info, false)
+}
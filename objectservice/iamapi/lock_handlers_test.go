@@ -0,0 +1,103 @@
+package iamapi
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/filedag-project/filedag-storage/objectservice/utils"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIamApiServer_ForceUnlock(t *testing.T) {
+	baseUrl := "http://127.0.0.1:9985/admin/v1"
+	addUrl := baseUrl + "/add-user"
+	reqPutUser := utils.MustNewSignedV4Request(http.MethodPost, addUrl+"?accessKey=lockTest1&secretKey=lockTest1234", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(reqPutUser)
+	if result.Code != http.StatusOK {
+		t.Fatalf("Expected the response status to be `%d`, but instead found `%d`", http.StatusOK, result.Code)
+	}
+	getLockInfo := func() lockInfoResponse {
+		req := utils.MustNewSignedV4Request(http.MethodGet, baseUrl+"/lock-info?bucket=lockbucket&object=obj", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result := reqTest(req)
+		if result.Code != http.StatusOK {
+			t.Fatalf("Expected the response status to be `%d`, but instead found `%d`", http.StatusOK, result.Code)
+		}
+		var info lockInfoResponse
+		if err := json.Unmarshal(result.Body.Bytes(), &info); err != nil {
+			t.Fatal(err)
+		}
+		return info
+	}
+	if info := getLockInfo(); info.Locked {
+		t.Fatalf("the free lock is reported locked: %+v", info)
+	}
+
+	lk := testStorageSys.NewNSLock("lockbucket", "obj")
+	if _, err := lk.GetLock(context.TODO(), time.Second); err != nil {
+		t.Fatal(err)
+	}
+	defer lk.Unlock(nil)
+	if info := getLockInfo(); !info.Locked || !info.WriteLock || info.Holders != 1 {
+		t.Fatalf("unexpected lock info %+v", info)
+	}
+
+	testCases := []struct {
+		query     string
+		accessKey string
+		secretKey string
+		// expected output.
+		expectedRespStatus int // expected response status body.
+	}{
+		// Test case - 1.
+		// Only the root user can release a lock.
+		{
+			query:              "?bucket=lockbucket&object=obj&min-held=0s",
+			accessKey:          "lockTest1",
+			secretKey:          "lockTest1234",
+			expectedRespStatus: http.StatusForbidden,
+		},
+		// Test case - 2.
+		// The lock is held for less than the default min-held.
+		{
+			query:              "?bucket=lockbucket&object=obj",
+			accessKey:          DefaultTestAccessKey,
+			secretKey:          DefaultTestSecretKey,
+			expectedRespStatus: http.StatusConflict,
+		},
+		// Test case - 3.
+		// The min-held is malformed.
+		{
+			query:              "?bucket=lockbucket&object=obj&min-held=abc",
+			accessKey:          DefaultTestAccessKey,
+			secretKey:          DefaultTestSecretKey,
+			expectedRespStatus: http.StatusBadRequest,
+		},
+		// Test case - 4.
+		// The lock of another object isn't held.
+		{
+			query:              "?bucket=lockbucket&object=other&min-held=0s",
+			accessKey:          DefaultTestAccessKey,
+			secretKey:          DefaultTestSecretKey,
+			expectedRespStatus: http.StatusNotFound,
+		},
+		// Test case - 5.
+		// Release the lock.
+		{
+			query:              "?bucket=lockbucket&object=obj&min-held=0s",
+			accessKey:          DefaultTestAccessKey,
+			secretKey:          DefaultTestSecretKey,
+			expectedRespStatus: http.StatusOK,
+		},
+	}
+	for i, testCase := range testCases {
+		req := utils.MustNewSignedV4Request(http.MethodPost, baseUrl+"/force-unlock"+testCase.query, 0, nil, "s3", testCase.accessKey, testCase.secretKey, t)
+		result := reqTest(req)
+		if result.Code != testCase.expectedRespStatus {
+			t.Fatalf("case %v: Expected the response status to be `%d`, but instead found `%d`", i+1, testCase.expectedRespStatus, result.Code)
+		}
+	}
+	if info := getLockInfo(); info.Locked {
+		t.Fatalf("the lock is still held after it was force released: %+v", info)
+	}
+}
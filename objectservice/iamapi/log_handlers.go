@@ -0,0 +1,84 @@
+package iamapi
+
+import (
+	"encoding/json"
+	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
+	"github.com/filedag-project/filedag-storage/objectservice/response"
+	"github.com/gorilla/mux"
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/zap/zapcore"
+	"net/http"
+)
+
+const (
+	LogSubsystem = "subsystem"
+	LogLevel     = "level"
+)
+
+// logLevels the levels from the most verbose one
+var logLevels = []zapcore.Level{
+	zapcore.DebugLevel,
+	zapcore.InfoLevel,
+	zapcore.WarnLevel,
+	zapcore.ErrorLevel,
+	zapcore.DPanicLevel,
+	zapcore.PanicLevel,
+	zapcore.FatalLevel,
+}
+
+// getLogLevel returns the current level of the subsystem
+func getLogLevel(subsystem string) string {
+	core := logging.Logger(subsystem).Desugar().Core()
+	for _, lvl := range logLevels {
+		if core.Enabled(lvl) {
+			return lvl.String()
+		}
+	}
+	return zapcore.FatalLevel.String()
+}
+
+// GetLogLevels returns the log level of each subsystem, only the root user can read them
+func (iamApi *iamApiServer) GetLogLevels(w http.ResponseWriter, r *http.Request) {
+	_, owner, s3err := iamApi.authSys.CheckRequestAuthTypeCredential(r.Context(), r, "", "", "")
+	if s3err != apierrors.ErrNone || !owner {
+		response.WriteErrorResponse(w, r, apierrors.ErrAccessDenied)
+		return
+	}
+	levels := make(map[string]string)
+	for _, subsystem := range logging.GetSubsystems() {
+		levels[subsystem] = getLogLevel(subsystem)
+	}
+	resp, err := json.Marshal(levels)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrInternalError)
+		return
+	}
+	response.WriteSuccessResponseJSON(w, resp)
+}
+
+// SetLogLevel changes the log level of a subsystem at runtime, only the root user can change it.
+// The subsystem "*" changes all subsystems.
+func (iamApi *iamApiServer) SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	_, owner, s3err := iamApi.authSys.CheckRequestAuthTypeCredential(r.Context(), r, "", "", "")
+	if s3err != apierrors.ErrNone || !owner {
+		response.WriteErrorResponse(w, r, apierrors.ErrAccessDenied)
+		return
+	}
+	vars := mux.Vars(r)
+	subsystem := vars[LogSubsystem]
+	level := vars[LogLevel]
+	if _, err := logging.LevelFromString(level); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidLogLevel)
+		return
+	}
+	if err := logging.SetLogLevel(subsystem, level); err != nil {
+		if err == logging.ErrNoSuchLogger {
+			response.WriteErrorResponse(w, r, apierrors.ErrNoSuchLogSubsystem)
+			return
+		}
+		response.WriteErrorResponse(w, r, apierrors.ErrInternalError)
+		return
+	}
+	log.Infow("log level changed", "subsystem", subsystem, "level", level)
+	response.WriteSuccessNoContent(w)
+}
@@ -0,0 +1,110 @@
+package iamapi
+
+import (
+	"encoding/json"
+	"github.com/filedag-project/filedag-storage/objectservice/utils"
+	logging "github.com/ipfs/go-log/v2"
+	"go.uber.org/zap/zapcore"
+	"net/http"
+	"testing"
+)
+
+func TestIamApiServer_SetLogLevel(t *testing.T) {
+	const subsystem = "iamsever"
+	old := getLogLevel(subsystem)
+	defer logging.SetLogLevel(subsystem, old)
+
+	addUrl := "http://127.0.0.1:9985/admin/v1/add-user"
+	reqPutUser := utils.MustNewSignedV4Request(http.MethodPost, addUrl+"?accessKey=logTest1&secretKey=logTest1234", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(reqPutUser)
+	if result.Code != http.StatusOK {
+		t.Fatalf("Expected the response status to be `%d`, but instead found `%d`", http.StatusOK, result.Code)
+	}
+
+	testCases := []struct {
+		subsystem string
+		level     string
+		accessKey string
+		secretKey string
+		// expected output.
+		expectedRespStatus int // expected response status body.
+		expectedLevel      string
+	}{
+		// Test case - 1.
+		// Enable the debug logs.
+		{
+			subsystem:          subsystem,
+			level:              "debug",
+			accessKey:          DefaultTestAccessKey,
+			secretKey:          DefaultTestSecretKey,
+			expectedRespStatus: http.StatusNoContent,
+			expectedLevel:      "debug",
+		},
+		// Test case - 2.
+		// Back to error logs, the level is case-insensitive.
+		{
+			subsystem:          subsystem,
+			level:              "ERROR",
+			accessKey:          DefaultTestAccessKey,
+			secretKey:          DefaultTestSecretKey,
+			expectedRespStatus: http.StatusNoContent,
+			expectedLevel:      "error",
+		},
+		// Test case - 3.
+		// The subsystem does not exist.
+		{
+			subsystem:          "no-such-subsystem",
+			level:              "debug",
+			accessKey:          DefaultTestAccessKey,
+			secretKey:          DefaultTestSecretKey,
+			expectedRespStatus: http.StatusNotFound,
+			expectedLevel:      "error",
+		},
+		// Test case - 4.
+		// Invalid level.
+		{
+			subsystem:          subsystem,
+			level:              "verbose",
+			accessKey:          DefaultTestAccessKey,
+			secretKey:          DefaultTestSecretKey,
+			expectedRespStatus: http.StatusBadRequest,
+			expectedLevel:      "error",
+		},
+		// Test case - 5.
+		// Only the root user can change the levels.
+		{
+			subsystem:          subsystem,
+			level:              "debug",
+			accessKey:          "logTest1",
+			secretKey:          "logTest1234",
+			expectedRespStatus: http.StatusForbidden,
+			expectedLevel:      "error",
+		},
+	}
+	u := "http://127.0.0.1:9985/admin/v1/log-level"
+	for i, testCase := range testCases {
+		reqSet := utils.MustNewSignedV4Request(http.MethodPost, u+"?subsystem="+testCase.subsystem+"&level="+testCase.level, 0, nil, "s3", testCase.accessKey, testCase.secretKey, t)
+		result := reqTest(reqSet)
+		if result.Code != testCase.expectedRespStatus {
+			t.Fatalf("Case %d: Expected the response status to be `%d`, but instead found `%d`", i+1, testCase.expectedRespStatus, result.Code)
+		}
+
+		reqGet := utils.MustNewSignedV4Request(http.MethodGet, u, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result = reqTest(reqGet)
+		if result.Code != http.StatusOK {
+			t.Fatalf("Case %d: Expected the response status to be `%d`, but instead found `%d`", i+1, http.StatusOK, result.Code)
+		}
+		var levels map[string]string
+		if err := json.Unmarshal(result.Body.Bytes(), &levels); err != nil {
+			t.Fatalf("Case %d: Unmarshal the levels failed: %v", i+1, err)
+		}
+		if levels[subsystem] != testCase.expectedLevel {
+			t.Fatalf("Case %d: Expected the level to be `%s`, but instead found `%s`", i+1, testCase.expectedLevel, levels[subsystem])
+		}
+		// the logger itself follows the level
+		debugEnabled := log.Desugar().Core().Enabled(zapcore.DebugLevel)
+		if debugEnabled != (testCase.expectedLevel == "debug") {
+			t.Fatalf("Case %d: Expected the debug logs enabled to be %v", i+1, !debugEnabled)
+		}
+	}
+}
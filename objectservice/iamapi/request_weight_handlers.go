@@ -0,0 +1,75 @@
+package iamapi
+
+import (
+	"encoding/json"
+	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
+	"github.com/filedag-project/filedag-storage/objectservice/response"
+	"net/http"
+	"strconv"
+)
+
+const (
+	RequestWeightBucket = "bucket"
+	RequestWeightValue  = "weight"
+)
+
+// requestWeightResponse the weight of a bucket in the sharing of the concurrent requests
+type requestWeightResponse struct {
+	Bucket string `json:"bucket"`
+	Weight int    `json:"weight"`
+}
+
+// GetRequestWeight returns the weight of a bucket in the sharing of the concurrent requests of the gateway.
+// Only the root user can read it.
+func (iamApi *iamApiServer) GetRequestWeight(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	_, owner, s3err := iamApi.authSys.CheckRequestAuthTypeCredential(ctx, r, "", "", "")
+	if s3err != apierrors.ErrNone || !owner {
+		response.WriteErrorResponse(w, r, apierrors.ErrAccessDenied)
+		return
+	}
+	bucket := r.URL.Query().Get(RequestWeightBucket)
+	if bucket == "" {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidBucketName)
+		return
+	}
+	weight, err := iamApi.bmSys.GetBucketRequestWeight(ctx, bucket)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	resp, err := json.Marshal(requestWeightResponse{Bucket: bucket, Weight: weight})
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrInternalError)
+		return
+	}
+	response.WriteSuccessResponseJSON(w, resp)
+}
+
+// SetRequestWeight sets the weight of a bucket in the sharing of the concurrent requests of the gateway,
+// a bucket of weight 2 gets twice the share of a bucket of the default weight 1. The weight 0 resets it
+// to the default. Only the root user can set it.
+func (iamApi *iamApiServer) SetRequestWeight(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	cred, owner, s3err := iamApi.authSys.CheckRequestAuthTypeCredential(ctx, r, "", "", "")
+	if s3err != apierrors.ErrNone || !owner {
+		response.WriteErrorResponse(w, r, apierrors.ErrAccessDenied)
+		return
+	}
+	bucket := r.URL.Query().Get(RequestWeightBucket)
+	if bucket == "" {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidBucketName)
+		return
+	}
+	weight, err := strconv.Atoi(r.URL.Query().Get(RequestWeightValue))
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidRequest)
+		return
+	}
+	if err = iamApi.bmSys.UpdateBucketRequestWeight(ctx, bucket, weight); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	log.Infow("request weight set by admin", "accessKey", cred.AccessKey, "bucket", bucket, "weight", weight)
+	response.WriteSuccessResponseHeadersOnly(w, r)
+}
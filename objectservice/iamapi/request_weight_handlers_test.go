@@ -0,0 +1,56 @@
+package iamapi
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/filedag-project/filedag-storage/objectservice/utils"
+	"net/http"
+	"testing"
+)
+
+func TestIamApiServer_RequestWeight(t *testing.T) {
+	baseUrl := "http://127.0.0.1:9985/admin/v1"
+	if err := testBmSys.CreateBucket(context.TODO(), "weightbucket", "", DefaultTestAccessKey); err != nil {
+		t.Fatal(err)
+	}
+	getWeight := func() int {
+		req := utils.MustNewSignedV4Request(http.MethodGet, baseUrl+"/request-weight?bucket=weightbucket", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result := reqTest(req)
+		if result.Code != http.StatusOK {
+			t.Fatalf("Expected the response status to be `%d`, but instead found `%d`", http.StatusOK, result.Code)
+		}
+		var resp requestWeightResponse
+		if err := json.Unmarshal(result.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Weight
+	}
+	if weight := getWeight(); weight != 1 {
+		t.Fatalf("unexpected default weight %d", weight)
+	}
+
+	testCases := []struct {
+		query string
+		// expected output.
+		expectedRespStatus int // expected response status body.
+		expectedWeight     int
+	}{
+		{query: "?bucket=weightbucket&weight=5", expectedRespStatus: http.StatusOK, expectedWeight: 5},
+		// out of range
+		{query: "?bucket=weightbucket&weight=101", expectedRespStatus: http.StatusBadRequest, expectedWeight: 5},
+		{query: "?bucket=weightbucket&weight=abc", expectedRespStatus: http.StatusBadRequest, expectedWeight: 5},
+		{query: "?bucket=nobucket&weight=2", expectedRespStatus: http.StatusNotFound, expectedWeight: 5},
+		// 0 resets the default weight
+		{query: "?bucket=weightbucket&weight=0", expectedRespStatus: http.StatusOK, expectedWeight: 1},
+	}
+	for i, testCase := range testCases {
+		req := utils.MustNewSignedV4Request(http.MethodPost, baseUrl+"/request-weight"+testCase.query, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result := reqTest(req)
+		if result.Code != testCase.expectedRespStatus {
+			t.Fatalf("Case %d: Expected the response status to be `%d`, but instead found `%d`", i+1, testCase.expectedRespStatus, result.Code)
+		}
+		if weight := getWeight(); weight != testCase.expectedWeight {
+			t.Fatalf("Case %d: unexpected weight %d", i+1, weight)
+		}
+	}
+}
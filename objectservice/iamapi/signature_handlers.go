@@ -0,0 +1,39 @@
+package iamapi
+
+import (
+	"encoding/json"
+	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
+	"github.com/filedag-project/filedag-storage/objectservice/iam"
+	"github.com/filedag-project/filedag-storage/objectservice/response"
+	"net/http"
+)
+
+// VerifySignature verifies the signature version '4' of the request and returns the canonical request
+// and the string to sign of the server, so that a client can diff them against its own.
+// The request isn't executed, any method and query can be verified.
+// Only the root user gets the details, unless the signature debug is enabled, a mismatched
+// signature can't be authenticated then. The secret key is never returned.
+func (iamApi *iamApiServer) VerifySignature(w http.ResponseWriter, r *http.Request) {
+	sig, s3err := iamApi.authSys.DebugSignatureV4(r, "", iam.ServiceS3)
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+	if !iamApi.authSys.SignatureDebug() {
+		if !sig.SignatureMatch {
+			response.WriteErrorResponse(w, r, apierrors.ErrSignatureDoesNotMatch)
+			return
+		}
+		_, owner, s3err := iamApi.authSys.GetReqAccessKeyV4(r, "", iam.ServiceS3)
+		if s3err != apierrors.ErrNone || !owner {
+			response.WriteErrorResponse(w, r, apierrors.ErrAccessDenied)
+			return
+		}
+	}
+	resp, err := json.Marshal(sig)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrInternalError)
+		return
+	}
+	response.WriteSuccessResponseJSON(w, resp)
+}
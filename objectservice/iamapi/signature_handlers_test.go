@@ -0,0 +1,124 @@
+package iamapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/filedag-project/filedag-storage/objectservice/consts"
+	"github.com/filedag-project/filedag-storage/objectservice/iam"
+	"github.com/filedag-project/filedag-storage/objectservice/utils"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestIamApiServer_VerifySignature(t *testing.T) {
+	defer testAuthSys.SetSignatureDebug(false)
+	verifyUrl := "http://127.0.0.1:9985/admin/v1/verify-signature"
+	addUrl := "http://127.0.0.1:9985/admin/v1/add-user"
+	reqPutUser := utils.MustNewSignedV4Request(http.MethodPost, addUrl+"?accessKey=sigTest1&secretKey=sigTest1234", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	if result := reqTest(reqPutUser); result.Code != http.StatusOK {
+		t.Fatalf("Expected the response status to be `%d`, but instead found `%d`", http.StatusOK, result.Code)
+	}
+	// the canonical request of the client, the query is tampered after signing
+	expectedCanonicalRequest := func(date, query string) string {
+		return "GET\n" +
+			"/admin/v1/verify-signature\n" +
+			query + "\n" +
+			"host:127.0.0.1:9985\n" +
+			"x-amz-content-sha256:" + consts.EmptySHA256 + "\n" +
+			"x-amz-date:" + date + "\n" +
+			"\n" +
+			"host;x-amz-content-sha256;x-amz-date\n" +
+			consts.EmptySHA256
+	}
+
+	testCases := []struct {
+		accessKey     string
+		secretKey     string
+		tamperedQuery string
+		debug         bool
+		// expected output.
+		expectedRespStatus int // expected response status body.
+		expectedMatch      bool
+	}{
+		// Test case - 1.
+		// The root user gets the details of a valid signature.
+		{
+			accessKey:          DefaultTestAccessKey,
+			secretKey:          DefaultTestSecretKey,
+			expectedRespStatus: http.StatusOK,
+			expectedMatch:      true,
+		},
+		// Test case - 2.
+		// A mismatched signature can't be authenticated.
+		{
+			accessKey:          DefaultTestAccessKey,
+			secretKey:          DefaultTestSecretKey,
+			tamperedQuery:      "prefix=b",
+			expectedRespStatus: http.StatusForbidden,
+		},
+		// Test case - 3.
+		// Only the root user gets the details.
+		{
+			accessKey:          "sigTest1",
+			secretKey:          "sigTest1234",
+			expectedRespStatus: http.StatusForbidden,
+		},
+		// Test case - 4.
+		// The details of a mismatched signature are returned by the signature debug.
+		{
+			accessKey:          "sigTest1",
+			secretKey:          "sigTest1234",
+			tamperedQuery:      "prefix=b",
+			debug:              true,
+			expectedRespStatus: http.StatusOK,
+		},
+		// Test case - 5.
+		// The unknown access key is refused even by the signature debug.
+		{
+			accessKey:          "sigTest2",
+			secretKey:          "sigTest1234",
+			debug:              true,
+			expectedRespStatus: http.StatusForbidden,
+		},
+	}
+	for i, testCase := range testCases {
+		testAuthSys.SetSignatureDebug(testCase.debug)
+		req := utils.MustNewSignedV4Request(http.MethodGet, verifyUrl+"?prefix=a", 0, nil, "s3", testCase.accessKey, testCase.secretKey, t)
+		query := "prefix=a"
+		if testCase.tamperedQuery != "" {
+			query = testCase.tamperedQuery
+			req.URL.RawQuery = query
+		}
+		result := reqTest(req)
+		if result.Code != testCase.expectedRespStatus {
+			t.Fatalf("Case %d: Expected the response status to be `%d`, but instead found `%d`", i+1, testCase.expectedRespStatus, result.Code)
+		}
+		if result.Code != http.StatusOK {
+			continue
+		}
+		// the secret key of the root user is its access key in the tests
+		if testCase.secretKey != testCase.accessKey && strings.Contains(result.Body.String(), testCase.secretKey) {
+			t.Fatalf("Case %d: the response leaks the secret key", i+1)
+		}
+		var sig iam.SignatureV4Debug
+		if err := json.Unmarshal(result.Body.Bytes(), &sig); err != nil {
+			t.Fatalf("Case %d: %v", i+1, err)
+		}
+		date := req.Header.Get(consts.AmzDate)
+		canonicalRequest := expectedCanonicalRequest(date, query)
+		if sig.CanonicalRequest != canonicalRequest {
+			t.Fatalf("Case %d: Expected the canonical request %q, but instead found %q", i+1, canonicalRequest, sig.CanonicalRequest)
+		}
+		sum := sha256.Sum256([]byte(canonicalRequest))
+		stringToSign := "AWS4-HMAC-SHA256\n" + date + "\n" + date[:8] + "//s3/aws4_request\n" + hex.EncodeToString(sum[:])
+		if sig.StringToSign != stringToSign {
+			t.Fatalf("Case %d: Expected the string to sign %q, but instead found %q", i+1, stringToSign, sig.StringToSign)
+		}
+		if sig.AccessKey != testCase.accessKey || sig.SignatureMatch != testCase.expectedMatch {
+			t.Fatalf("Case %d: Expected the access key %s and the signature match %v, but instead found %s and %v",
+				i+1, testCase.accessKey, testCase.expectedMatch, sig.AccessKey, sig.SignatureMatch)
+		}
+	}
+}
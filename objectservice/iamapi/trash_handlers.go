@@ -0,0 +1,101 @@
+package iamapi
+
+import (
+	"encoding/json"
+	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
+	"github.com/filedag-project/filedag-storage/objectservice/response"
+	"net/http"
+	"time"
+)
+
+const (
+	TrashBucket = "bucket"
+	TrashObject = "object"
+	TrashPrefix = "prefix"
+)
+
+// trashedObjectResponse a deleted object in the trash
+type trashedObjectResponse struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"last_modified"`
+	// DeletedAt is empty once the object is restored
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// ListTrash lists the deleted objects of a bucket which can still be restored, with an optional key prefix.
+// Only the root user can list them.
+func (iamApi *iamApiServer) ListTrash(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	_, owner, s3err := iamApi.authSys.CheckRequestAuthTypeCredential(ctx, r, "", "", "")
+	if s3err != apierrors.ErrNone || !owner {
+		response.WriteErrorResponse(w, r, apierrors.ErrAccessDenied)
+		return
+	}
+	bucket := r.URL.Query().Get(TrashBucket)
+	if bucket == "" {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidBucketName)
+		return
+	}
+	trashed, err := iamApi.storageSys.ListTrash(ctx, bucket, r.URL.Query().Get(TrashPrefix))
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	objects := make([]trashedObjectResponse, 0, len(trashed))
+	for i := range trashed {
+		t := trashed[i]
+		objects = append(objects, trashedObjectResponse{
+			Key:          t.Object.Name,
+			Size:         t.Object.Size,
+			ETag:         t.Object.ETag,
+			LastModified: t.Object.ModTime,
+			DeletedAt:    &t.DeletedAt,
+		})
+	}
+	resp, err := json.Marshal(objects)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrInternalError)
+		return
+	}
+	response.WriteSuccessResponseJSON(w, resp)
+}
+
+// UndeleteObject restores a deleted object from the trash, it fails if an object was stored in the same key since.
+// Only the root user can restore it.
+func (iamApi *iamApiServer) UndeleteObject(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	cred, owner, s3err := iamApi.authSys.CheckRequestAuthTypeCredential(ctx, r, "", "", "")
+	if s3err != apierrors.ErrNone || !owner {
+		response.WriteErrorResponse(w, r, apierrors.ErrAccessDenied)
+		return
+	}
+	bucket := r.URL.Query().Get(TrashBucket)
+	object := r.URL.Query().Get(TrashObject)
+	if bucket == "" {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidBucketName)
+		return
+	}
+	if object == "" {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidObjectName)
+		return
+	}
+	oi, err := iamApi.storageSys.UndeleteObject(ctx, bucket, object)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	log.Infow("object undeleted by admin", "accessKey", cred.AccessKey, "bucket", bucket, "object", object)
+	resp, err := json.Marshal(trashedObjectResponse{
+		Key:          oi.Name,
+		Size:         oi.Size,
+		ETag:         oi.ETag,
+		LastModified: oi.ModTime,
+	})
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrInternalError)
+		return
+	}
+	response.WriteSuccessResponseJSON(w, resp)
+}
@@ -0,0 +1,80 @@
+package iamapi
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/filedag-project/filedag-storage/objectservice/utils"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIamApiServer_UndeleteObject(t *testing.T) {
+	baseUrl := "http://127.0.0.1:9985/admin/v1"
+	ctx := context.TODO()
+	if err := testBmSys.CreateBucket(ctx, "trashbucket", "", DefaultTestAccessKey); err != nil {
+		t.Fatal(err)
+	}
+	testStorageSys.SetTrashRetention(time.Hour)
+	defer testStorageSys.SetTrashRetention(0)
+	r, err := hash.NewReader(strings.NewReader("123456"), 6, "", "", 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = testStorageSys.StoreObject(ctx, "trashbucket", "obj", r, 6, map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = testStorageSys.DeleteObject(ctx, "trashbucket", "obj", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	req := utils.MustNewSignedV4Request(http.MethodGet, baseUrl+"/list-trash?bucket=trashbucket", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(req)
+	if result.Code != http.StatusOK {
+		t.Fatalf("Expected the response status to be `%d`, but instead found `%d`", http.StatusOK, result.Code)
+	}
+	var trashed []trashedObjectResponse
+	if err = json.Unmarshal(result.Body.Bytes(), &trashed); err != nil {
+		t.Fatal(err)
+	}
+	if len(trashed) != 1 || trashed[0].Key != "obj" || trashed[0].Size != 6 || trashed[0].DeletedAt == nil {
+		t.Fatalf("unexpected trash %+v", trashed)
+	}
+
+	testCases := []struct {
+		query string
+		// expected output.
+		expectedRespStatus int // expected response status body.
+	}{
+		// Test case - 1.
+		// Restore the deleted object.
+		{
+			query:              "?bucket=trashbucket&object=obj",
+			expectedRespStatus: http.StatusOK,
+		},
+		// Test case - 2.
+		// The restored object is no longer in the trash.
+		{
+			query:              "?bucket=trashbucket&object=obj",
+			expectedRespStatus: http.StatusNotFound,
+		},
+		// Test case - 3.
+		// The object is missing.
+		{
+			query:              "?bucket=trashbucket&object=",
+			expectedRespStatus: http.StatusBadRequest,
+		},
+	}
+	for i, testCase := range testCases {
+		req = utils.MustNewSignedV4Request(http.MethodPost, baseUrl+"/undelete-object"+testCase.query, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result = reqTest(req)
+		if result.Code != testCase.expectedRespStatus {
+			t.Fatalf("case %v: Expected the response status to be `%d`, but instead found `%d`", i+1, testCase.expectedRespStatus, result.Code)
+		}
+	}
+	if _, err = testStorageSys.GetObjectInfo(ctx, "trashbucket", "obj", ""); err != nil {
+		t.Fatalf("the undeleted object can't be read: %v", err)
+	}
+}
@@ -44,6 +44,11 @@ func (iamApi *iamApiServer) CreateUser(w http.ResponseWriter, r *http.Request) {
 		response.WriteErrorResponse(w, r, apierrors.ErrInvalidFormatAccessKey)
 		return
 	}
+	// the anonymous requests are evaluated as the anonymous principal, a user of the name would share its permissions
+	if accessKey == iamApi.authSys.AnonymousPrincipal() {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidFormatAccessKey)
+		return
+	}
 	if !auth.IsSecretKeyValid(secretKey) {
 		response.WriteErrorResponse(w, r, apierrors.ErrInvalidQueryParams)
 	}
@@ -1,12 +1,15 @@
 package iamapi
 
 import (
+	"context"
 	"fmt"
 	"github.com/filedag-project/filedag-storage/objectservice/iam"
 	"github.com/filedag-project/filedag-storage/objectservice/iam/auth"
+	"github.com/filedag-project/filedag-storage/objectservice/store"
 	"github.com/filedag-project/filedag-storage/objectservice/uleveldb"
 	"github.com/filedag-project/filedag-storage/objectservice/utils"
 	"github.com/gorilla/mux"
+	mdtest "github.com/ipfs/go-merkledag/test"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
@@ -22,6 +25,9 @@ const (
 
 var w *httptest.ResponseRecorder
 var router = mux.NewRouter()
+var testAuthSys *iam.AuthSys
+var testStorageSys *store.StorageSys
+var testBmSys *store.BucketMetadataSys
 
 func TestMain(m *testing.M) {
 	db, err := uleveldb.OpenDb((&testing.T{}).TempDir())
@@ -35,8 +41,12 @@ func TestMain(m *testing.M) {
 		println(err)
 		return
 	}
-	authSys := iam.NewAuthSys(db, cred)
-	NewIamApiServer(router, authSys, func(accessKey string) {})
+	testAuthSys = iam.NewAuthSys(db, cred)
+	testStorageSys = store.NewStorageSys(context.TODO(), mdtest.Mock(), db)
+	testBmSys = store.NewBucketMetadataSys(db)
+	testStorageSys.SetNewBucketNSLock(testBmSys.NewNSLock)
+	testStorageSys.SetHasBucket(testBmSys.HasBucket)
+	NewIamApiServer(router, testAuthSys, testStorageSys, testBmSys, func(accessKey string) {})
 	//s3api.NewS3Server(router)
 	os.Exit(m.Run())
 }
@@ -3,11 +3,13 @@ package lock
 import (
 	"context"
 	"errors"
+	"fmt"
 	logging "github.com/ipfs/go-log/v2"
 	"path"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -62,12 +64,15 @@ type nsLock struct {
 // NsLockMap - namespace lock map, provides primitives to Lock,
 // Unlock, RLock and RUnlock.
 type NsLockMap struct {
+	// lastOwner the owner token of the last lock instance, every instance owns the locks it takes.
+	// It goes first to be 64-bit aligned for the atomic operations.
+	lastOwner    uint64
 	lockMap      map[string]*nsLock
 	lockMapMutex sync.Mutex
 }
 
-// Lock the namespace resource.
-func (n *NsLockMap) lock(ctx context.Context, volume string, path string, readLock bool, timeout time.Duration) (locked bool) {
+// Lock the namespace resource for the owner.
+func (n *NsLockMap) lock(ctx context.Context, volume string, path string, readLock bool, timeout time.Duration, owner uint64) (locked bool) {
 	resource := PathJoin(volume, path)
 
 	n.lockMapMutex.Lock()
@@ -82,11 +87,7 @@ func (n *NsLockMap) lock(ctx context.Context, volume string, path string, readLo
 	n.lockMapMutex.Unlock()
 
 	// Locking here will block (until timeout).
-	if readLock {
-		locked = nsLk.GetRLock(ctx, timeout)
-	} else {
-		locked = nsLk.GetLock(ctx, timeout)
-	}
+	locked = nsLk.lockLoop(ctx, timeout, !readLock, owner)
 
 	if !locked { // We failed to get the lock
 		// Decrement ref count since we failed to get the lock
@@ -105,8 +106,8 @@ func (n *NsLockMap) lock(ctx context.Context, volume string, path string, readLo
 	return
 }
 
-// Unlock the namespace resource.
-func (n *NsLockMap) unlock(volume string, path string, readLock bool) {
+// Unlock the namespace resource locked by the owner.
+func (n *NsLockMap) unlock(volume string, path string, readLock bool, owner uint64) {
 	resource := PathJoin(volume, path)
 
 	n.lockMapMutex.Lock()
@@ -114,10 +115,10 @@ func (n *NsLockMap) unlock(volume string, path string, readLock bool) {
 	if _, found := n.lockMap[resource]; !found {
 		return
 	}
-	if readLock {
-		n.lockMap[resource].RUnlock()
-	} else {
-		n.lockMap[resource].Unlock()
+	if !n.lockMap[resource].TRWMutex.unlock(!readLock, owner) {
+		// the holder's reference is dropped when the lock is force released
+		log.Errorw("unlock a resource which the owner doesn't hold, the lock may have been force released", "resource", resource, "readLock", readLock)
+		return
 	}
 	n.lockMap[resource].ref--
 	if n.lockMap[resource].ref < 0 {
@@ -129,19 +130,108 @@ func (n *NsLockMap) unlock(volume string, path string, readLock bool) {
 	}
 }
 
+// LockNotHeld - the resource isn't locked.
+type LockNotHeld struct {
+	Resource string
+}
+
+func (e LockNotHeld) Error() string {
+	return "The resource isn't locked: " + e.Resource
+}
+
+// LockNotStale - the lock hasn't been held long enough to be taken as leaked.
+type LockNotStale struct {
+	Resource string
+	HeldFor  time.Duration
+	MinHeld  time.Duration
+}
+
+func (e LockNotStale) Error() string {
+	return fmt.Sprintf("The lock of %s has been held for %v, less than %v", e.Resource, e.HeldFor, e.MinHeld)
+}
+
+// LockInfo the state of the lock of a resource
+type LockInfo struct {
+	Resource  string        `json:"resource"`
+	WriteLock bool          `json:"writeLock"`
+	Holders   int           `json:"holders"`
+	Waiters   int           `json:"waiters"`
+	HeldFor   time.Duration `json:"heldFor"`
+}
+
+func (n *NsLockMap) lockInfo(resource string, nsLk *nsLock) LockInfo {
+	isWriteLock, holders, lockedAt := nsLk.state()
+	info := LockInfo{
+		Resource:  resource,
+		WriteLock: isWriteLock,
+		Holders:   holders,
+		Waiters:   int(nsLk.ref) - holders,
+	}
+	if holders > 0 {
+		info.HeldFor = time.Since(lockedAt)
+	}
+	return info
+}
+
+// GetLockInfo returns the state of the lock of the resource, false if nobody holds or waits for it
+func (n *NsLockMap) GetLockInfo(volume string, path string) (LockInfo, bool) {
+	resource := PathJoin(volume, path)
+
+	n.lockMapMutex.Lock()
+	defer n.lockMapMutex.Unlock()
+	nsLk, found := n.lockMap[resource]
+	if !found {
+		return LockInfo{Resource: resource}, false
+	}
+	return n.lockInfo(resource, nsLk), true
+}
+
+// ForceUnlock releases the lock of the resource whose holders leaked it, so that the waiters and the
+// next operations can take it. It's an emergency recovery: the lock is only released if it has been held
+// for minHeld, as a lock held by a live operation is released under it. A holder which unlocks later is
+// logged and ignored, even if the lock has been taken again by another owner.
+func (n *NsLockMap) ForceUnlock(volume string, path string, minHeld time.Duration) (LockInfo, error) {
+	resource := PathJoin(volume, path)
+
+	n.lockMapMutex.Lock()
+	defer n.lockMapMutex.Unlock()
+	nsLk, found := n.lockMap[resource]
+	if !found {
+		return LockInfo{Resource: resource}, LockNotHeld{Resource: resource}
+	}
+	info := n.lockInfo(resource, nsLk)
+	if info.Holders == 0 {
+		return info, LockNotHeld{Resource: resource}
+	}
+	if info.HeldFor < minHeld {
+		return info, LockNotStale{Resource: resource, HeldFor: info.HeldFor, MinHeld: minHeld}
+	}
+	nsLk.ForceUnlock()
+	// drop the references of the holders, they won't unlock it
+	nsLk.ref -= int32(info.Holders)
+	if nsLk.ref <= 0 {
+		delete(n.lockMap, resource)
+	}
+	log.Warnw("force released the lock", "resource", resource, "writeLock", info.WriteLock,
+		"holders", info.Holders, "waiters", info.Waiters, "heldFor", info.HeldFor)
+	return info, nil
+}
+
 // localLockInstance - frontend/top-level interface for namespace locks.
 type localLockInstance struct {
 	ns     *NsLockMap
 	volume string
 	paths  []string
+	// owner the token of the locks taken by the instance, only they are released by its unlocks
+	owner uint64
 }
 
 // NewNSLock - returns a lock instance for a given volume and
 // path. The returned lockInstance object encapsulates the nsLockMap,
-// volume, path and operation ID.
+// volume, path and the owner token of the locks it takes.
 func (n *NsLockMap) NewNSLock(volume string, paths ...string) RWLocker {
 	sort.Strings(paths)
-	return &localLockInstance{n, volume, paths}
+	return &localLockInstance{n, volume, paths, atomic.AddUint64(&n.lastOwner, 1)}
 }
 
 // GetLock - block until write lock is taken or timeout has occurred.
@@ -149,10 +239,10 @@ func (li *localLockInstance) GetLock(ctx context.Context, timeout time.Duration)
 	const readLock = false
 	success := make([]int, len(li.paths))
 	for i, path := range li.paths {
-		if !li.ns.lock(ctx, li.volume, path, readLock, timeout) {
+		if !li.ns.lock(ctx, li.volume, path, readLock, timeout, li.owner) {
 			for si, sint := range success {
 				if sint == 1 {
-					li.ns.unlock(li.volume, li.paths[si], readLock)
+					li.ns.unlock(li.volume, li.paths[si], readLock, li.owner)
 				}
 			}
 			return LockContext{}, OperationTimedOut{}
@@ -169,7 +259,7 @@ func (li *localLockInstance) Unlock(cancel context.CancelFunc) {
 	}
 	const readLock = false
 	for _, path := range li.paths {
-		li.ns.unlock(li.volume, path, readLock)
+		li.ns.unlock(li.volume, path, readLock, li.owner)
 	}
 }
 
@@ -178,10 +268,10 @@ func (li *localLockInstance) GetRLock(ctx context.Context, timeout time.Duration
 	const readLock = true
 	success := make([]int, len(li.paths))
 	for i, path := range li.paths {
-		if !li.ns.lock(ctx, li.volume, path, readLock, timeout) {
+		if !li.ns.lock(ctx, li.volume, path, readLock, timeout, li.owner) {
 			for si, sint := range success {
 				if sint == 1 {
-					li.ns.unlock(li.volume, li.paths[si], readLock)
+					li.ns.unlock(li.volume, li.paths[si], readLock, li.owner)
 				}
 			}
 			return LockContext{}, OperationTimedOut{}
@@ -198,7 +288,7 @@ func (li *localLockInstance) RUnlock(cancel context.CancelFunc) {
 	}
 	const readLock = true
 	for _, path := range li.paths {
-		li.ns.unlock(li.volume, path, readLock)
+		li.ns.unlock(li.volume, path, readLock, li.owner)
 	}
 }
 
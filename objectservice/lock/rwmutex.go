@@ -9,15 +9,19 @@ import (
 )
 
 // A TRWMutex is a mutual exclusion lock with timeouts.
+// Every lock is taken by an owner, only the owner of a lock can unlock it. The methods
+// which don't tell their owner share the owner 0.
 type TRWMutex struct {
 	isWriteLock bool
 	ref         int
-	mu          sync.Mutex // Mutex to prevent multiple simultaneous locks
+	owners      map[uint64]int // the number of locks held by each owner
+	lockedAt    time.Time      // when the lock was taken after it was free
+	mu          sync.Mutex     // Mutex to prevent multiple simultaneous locks
 }
 
 // NewTRWMutex - initializes a new lsync RW mutex.
 func NewTRWMutex() *TRWMutex {
-	return &TRWMutex{}
+	return &TRWMutex{owners: make(map[uint64]int)}
 }
 
 // Lock holds a write lock on lm.
@@ -26,13 +30,13 @@ func NewTRWMutex() *TRWMutex {
 // blocks until the mutex is available.
 func (m *TRWMutex) Lock() {
 	const isWriteLock = true
-	m.lockLoop(context.Background(), math.MaxInt64, isWriteLock)
+	m.lockLoop(context.Background(), math.MaxInt64, isWriteLock, 0)
 }
 
 // GetLock tries to get a write lock on lm before the timeout occurs.
 func (m *TRWMutex) GetLock(ctx context.Context, timeout time.Duration) (locked bool) {
 	const isWriteLock = true
-	return m.lockLoop(ctx, timeout, isWriteLock)
+	return m.lockLoop(ctx, timeout, isWriteLock, 0)
 }
 
 // RLock holds a read lock on lm.
@@ -41,16 +45,16 @@ func (m *TRWMutex) GetLock(ctx context.Context, timeout time.Duration) (locked b
 // Otherwise the calling go routine blocks until the mutex is available.
 func (m *TRWMutex) RLock() {
 	const isWriteLock = false
-	m.lockLoop(context.Background(), 1<<63-1, isWriteLock)
+	m.lockLoop(context.Background(), 1<<63-1, isWriteLock, 0)
 }
 
 // GetRLock tries to get a read lock on lm before the timeout occurs.
 func (m *TRWMutex) GetRLock(ctx context.Context, timeout time.Duration) (locked bool) {
 	const isWriteLock = false
-	return m.lockLoop(ctx, timeout, isWriteLock)
+	return m.lockLoop(ctx, timeout, isWriteLock, 0)
 }
 
-func (m *TRWMutex) lock(isWriteLock bool) (locked bool) {
+func (m *TRWMutex) lock(isWriteLock bool, owner uint64) (locked bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -66,6 +70,12 @@ func (m *TRWMutex) lock(isWriteLock bool) (locked bool) {
 			locked = true
 		}
 	}
+	if locked {
+		m.owners[owner]++
+		if m.ref == 1 {
+			m.lockedAt = time.Now()
+		}
+	}
 
 	return locked
 }
@@ -78,7 +88,7 @@ const (
 //
 // The call will block until the lock is granted using a built-in
 // timing randomized back-off algorithm to try again until successful
-func (m *TRWMutex) lockLoop(ctx context.Context, timeout time.Duration, isWriteLock bool) (locked bool) {
+func (m *TRWMutex) lockLoop(ctx context.Context, timeout time.Duration, isWriteLock bool, owner uint64) (locked bool) {
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 
 	retryCtx, cancel := context.WithTimeout(ctx, timeout)
@@ -91,7 +101,7 @@ func (m *TRWMutex) lockLoop(ctx context.Context, timeout time.Duration, isWriteL
 			// return false anyways for both situations.
 			return false
 		default:
-			if m.lock(isWriteLock) {
+			if m.lock(isWriteLock, owner) {
 				return true
 			}
 			time.Sleep(time.Duration(r.Float64() * float64(lockRetryInterval)))
@@ -104,7 +114,7 @@ func (m *TRWMutex) lockLoop(ctx context.Context, timeout time.Duration, isWriteL
 // It is a run-time error if lm is not locked on entry to Unlock.
 func (m *TRWMutex) Unlock() {
 	isWriteLock := true
-	success := m.unlock(isWriteLock)
+	success := m.unlock(isWriteLock, 0)
 	if !success {
 		panic("Trying to Unlock() while no Lock() is active")
 	}
@@ -115,16 +125,21 @@ func (m *TRWMutex) Unlock() {
 // It is a run-time error if lm is not locked on entry to RUnlock.
 func (m *TRWMutex) RUnlock() {
 	isWriteLock := false
-	success := m.unlock(isWriteLock)
+	success := m.unlock(isWriteLock, 0)
 	if !success {
 		panic("Trying to RUnlock() while no RLock() is active")
 	}
 }
 
-func (m *TRWMutex) unlock(isWriteLock bool) (unlocked bool) {
+// unlock releases a lock of the owner, nothing is released if the owner holds no lock
+func (m *TRWMutex) unlock(isWriteLock bool, owner uint64) (unlocked bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if m.owners[owner] == 0 {
+		return false
+	}
+
 	// Try to release lock.
 	if isWriteLock {
 		if m.isWriteLock && m.ref == 1 {
@@ -140,15 +155,29 @@ func (m *TRWMutex) unlock(isWriteLock bool) (unlocked bool) {
 			}
 		}
 	}
+	if unlocked {
+		if m.owners[owner]--; m.owners[owner] == 0 {
+			delete(m.owners, owner)
+		}
+	}
 
 	return unlocked
 }
 
-// ForceUnlock will forcefully clear a write or read lock.
+// state returns whether the write lock is held, the number of holders and when the lock was taken
+func (m *TRWMutex) state() (isWriteLock bool, holders int, lockedAt time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.isWriteLock, m.ref, m.lockedAt
+}
+
+// ForceUnlock will forcefully clear a write or read lock, the owners can't unlock it any more.
 func (m *TRWMutex) ForceUnlock() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	m.ref = 0
 	m.isWriteLock = false
+	m.owners = make(map[uint64]int)
 }
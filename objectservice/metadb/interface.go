@@ -0,0 +1,105 @@
+package metadb
+
+import (
+	"context"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/vmihailenco/msgpack/v5"
+	"golang.org/x/xerrors"
+)
+
+// ErrNotFound is returned by Get when the key doesn't exist
+var ErrNotFound = xerrors.New("metadb: key not found")
+
+const (
+	// BackendLevelDB the metadata is stored in leveldb, it's the default
+	BackendLevelDB = "leveldb"
+	// BackendMemory the metadata is kept in memory and lost on exit, for the tests and the development
+	BackendMemory = "memory"
+)
+
+// DB stores the metadata as key-struct, the structs are encoded by msgpack.
+// The keys are read back in their byte order.
+type DB interface {
+	Reader
+	Put(key string, value interface{}) error
+	Get(key string, value interface{}) error
+	Delete(key string) error
+	// NewBatch returns a batch of writes which is applied by Write atomically
+	NewBatch() Batch
+	Write(b Batch) error
+	// GetSnapshot takes a frozen view of the db, it must be released after use
+	GetSnapshot() (Snapshot, error)
+	// Available returns false while the db refuses the operations
+	Available() bool
+	Close() error
+}
+
+// Reader reads the key-structs in the order of the keys
+type Reader interface {
+	// ReadAllChan reads the key-structs of the prefix whose keys are after the seekKey, the seekKey
+	// needn't exist. All the keys of the prefix are read if the seekKey is empty. The chan is closed
+	// at the end or when the ctx is done.
+	ReadAllChan(ctx context.Context, prefix string, seekKey string) (<-chan *Entry, error)
+}
+
+// Snapshot a frozen view of the db, the writes made after the snapshot is taken are not visible through it
+type Snapshot interface {
+	Reader
+	// Release releases the snapshot, it's ok to release a snapshot more than once
+	Release()
+}
+
+// Batch a batch of key-struct writes
+type Batch interface {
+	Put(key string, value interface{}) error
+	Delete(key string)
+	// Len the number of writes in the batch
+	Len() int
+}
+
+// Entry a key-struct read from the db
+type Entry struct {
+	Key   string
+	Value []byte
+}
+
+// UnmarshalValue decodes the struct of the entry
+func (e *Entry) UnmarshalValue(value interface{}) error {
+	return msgpack.Unmarshal(e.Value, value)
+}
+
+// ReadIterator sends the key-structs of the iterator to the chan as Reader.ReadAllChan does,
+// the iterator is released when the chan is closed.
+func ReadIterator(ctx context.Context, iter iterator.Iterator, seekKey string) <-chan *Entry {
+	ch := make(chan *Entry)
+	var ok bool
+	if seekKey != "" {
+		// Seek stops at the first key at or after the seekKey
+		ok = iter.Seek([]byte(seekKey))
+		if ok && string(iter.Key()) == seekKey {
+			ok = iter.Next()
+		}
+	} else {
+		ok = iter.First()
+	}
+	go func() {
+		defer func() {
+			iter.Release()
+			close(ch)
+		}()
+		for ; ok; ok = iter.Next() {
+			key := string(iter.Key())
+			value := make([]byte, len(iter.Value()))
+			copy(value, iter.Value())
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- &Entry{
+				Key:   key,
+				Value: value,
+			}:
+			}
+		}
+	}()
+	return ch
+}
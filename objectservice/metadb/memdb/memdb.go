@@ -0,0 +1,176 @@
+package memdb
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/comparer"
+	"github.com/syndtr/goleveldb/leveldb/memdb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/vmihailenco/msgpack/v5"
+	"golang.org/x/xerrors"
+	"sync"
+)
+
+var _ metadb.DB = (*MemDB)(nil)
+
+// MemDB keeps the key-structs in a sorted in-memory table, nothing is persisted
+type MemDB struct {
+	// mu makes a batch atomic to the snapshots, the table itself is safe for concurrent use
+	mu sync.RWMutex
+	db *memdb.DB
+}
+
+// New returns an empty in-memory db
+func New() *MemDB {
+	return &MemDB{db: memdb.New(comparer.DefaultComparer, 0)}
+}
+
+// Put
+// * @param {string} key
+// * @param {interface{}} value
+func (m *MemDB) Put(key string, value interface{}) error {
+	result, err := msgpack.Marshal(value)
+	if err != nil {
+		return err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.db.Put([]byte(key), result)
+}
+
+// Get
+// * @param {string} key
+// * @param {interface{}} value
+func (m *MemDB) Get(key string, value interface{}) error {
+	get, err := m.db.Get([]byte(key))
+	if err != nil {
+		if err == memdb.ErrNotFound {
+			return metadb.ErrNotFound
+		}
+		return err
+	}
+	return msgpack.Unmarshal(get, value)
+}
+
+// Delete
+// * @param {string} key
+func (m *MemDB) Delete(key string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	err := m.db.Delete([]byte(key))
+	if err == memdb.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+//ReadAllChan read all key value
+func (m *MemDB) ReadAllChan(ctx context.Context, prefix string, seekKey string) (<-chan *metadb.Entry, error) {
+	return metadb.ReadIterator(ctx, m.db.NewIterator(prefixRange(prefix)), seekKey), nil
+}
+
+func prefixRange(prefix string) *util.Range {
+	if prefix == "" {
+		return nil
+	}
+	return util.BytesPrefix([]byte(prefix))
+}
+
+//Batch a batch of key-struct writes, it is applied to db atomically
+type Batch struct {
+	batch leveldb.Batch
+}
+
+// NewBatch new a write batch
+func (m *MemDB) NewBatch() metadb.Batch {
+	return &Batch{}
+}
+
+// Put
+// * @param {string} key
+// * @param {interface{}} value
+func (b *Batch) Put(key string, value interface{}) error {
+	result, err := msgpack.Marshal(value)
+	if err != nil {
+		return err
+	}
+	b.batch.Put([]byte(key), result)
+	return nil
+}
+
+// Delete
+// * @param {string} key
+func (b *Batch) Delete(key string) {
+	b.batch.Delete([]byte(key))
+}
+
+// Len the number of writes in the batch
+func (b *Batch) Len() int {
+	return b.batch.Len()
+}
+
+//Write apply the batch of NewBatch to db
+func (m *MemDB) Write(b metadb.Batch) error {
+	batch, ok := b.(*Batch)
+	if !ok {
+		return xerrors.Errorf("memdb can't write the batch %T", b)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return batch.batch.Replay(replayer{db: m.db})
+}
+
+// replayer applies the writes of a batch to the table
+type replayer struct {
+	db *memdb.DB
+}
+
+func (r replayer) Put(key, value []byte) {
+	// the table copies the key and the value, it never fails
+	_ = r.db.Put(key, value)
+}
+
+func (r replayer) Delete(key []byte) {
+	// a missing key is ErrNotFound
+	_ = r.db.Delete(key)
+}
+
+// GetSnapshot copies the db, the writes are held off while it's copied
+func (m *MemDB) GetSnapshot() (metadb.Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snap := New()
+	iter := m.db.NewIterator(nil)
+	defer iter.Release()
+	for iter.Next() {
+		if err := snap.db.Put(iter.Key(), iter.Value()); err != nil {
+			return nil, err
+		}
+	}
+	return &Snapshot{snap: snap}, nil
+}
+
+// Snapshot a frozen copy of the db
+type Snapshot struct {
+	snap *MemDB
+}
+
+// ReadAllChan same as MemDB.ReadAllChan, but reads the snapshot
+func (s *Snapshot) ReadAllChan(ctx context.Context, prefix string, seekKey string) (<-chan *metadb.Entry, error) {
+	return s.snap.ReadAllChan(ctx, prefix, seekKey)
+}
+
+// Release release the snapshot, it's ok to release a snapshot more than once
+func (s *Snapshot) Release() {}
+
+// Available the db in memory is always available
+func (m *MemDB) Available() bool {
+	return true
+}
+
+//Close db close
+func (m *MemDB) Close() error {
+	m.db.Reset()
+	return nil
+}
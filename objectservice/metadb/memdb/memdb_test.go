@@ -0,0 +1,70 @@
+package memdb
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
+	"testing"
+)
+
+func readKeys(t *testing.T, r metadb.Reader, prefix, seekKey string) []string {
+	all, err := r.ReadAllChan(context.TODO(), prefix, seekKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for entry := range all {
+		var v int
+		if err = entry.UnmarshalValue(&v); err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, entry.Key)
+	}
+	return keys
+}
+
+func TestMemDB(t *testing.T) {
+	db := New()
+	defer db.Close()
+	var v int
+	if err := db.Get("a", &v); err != metadb.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, but instead found %v", err)
+	}
+	for i, key := range []string{"p/b", "p/a", "q/a", "p/c"} {
+		if err := db.Put(key, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Get("p/a", &v); err != nil || v != 1 {
+		t.Fatalf("expected 1, but instead found %v, %v", v, err)
+	}
+	if keys := readKeys(t, db, "p/", ""); len(keys) != 3 || keys[0] != "p/a" || keys[2] != "p/c" {
+		t.Fatalf("expected the keys of p/ in order, but instead found %v", keys)
+	}
+	if keys := readKeys(t, db, "p/", "p/a"); len(keys) != 2 || keys[0] != "p/b" {
+		t.Fatalf("expected the keys after p/a, but instead found %v", keys)
+	}
+	if keys := readKeys(t, db, "p/", "p/aa"); len(keys) != 2 || keys[0] != "p/b" {
+		t.Fatalf("expected the keys after the missing p/aa, but instead found %v", keys)
+	}
+
+	snap, err := db.GetSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snap.Release()
+	batch := db.NewBatch()
+	batch.Put("p/d", 4)
+	batch.Delete("p/a")
+	if err = db.Write(batch); err != nil {
+		t.Fatal(err)
+	}
+	if keys := readKeys(t, db, "p/", ""); len(keys) != 3 || keys[0] != "p/b" || keys[2] != "p/d" {
+		t.Fatalf("expected the batch to be applied, but instead found %v", keys)
+	}
+	if keys := readKeys(t, snap, "p/", ""); len(keys) != 3 || keys[0] != "p/a" || keys[2] != "p/c" {
+		t.Fatalf("expected the snapshot not to see the batch, but instead found %v", keys)
+	}
+	if err = db.Delete("missing"); err != nil {
+		t.Fatal(err)
+	}
+}
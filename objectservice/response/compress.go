@@ -0,0 +1,62 @@
+package response
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/filedag-project/filedag-storage/objectservice/consts"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// gzipMinSize the smallest response which is compressed, the smaller ones are mostly
+// headers and gain little from it
+const gzipMinSize = 1024
+
+// acceptsGzip reports whether the client accepts a gzip response, per the Accept-Encoding of the request
+func acceptsGzip(r *http.Request) bool {
+	for _, header := range r.Header.Values(consts.AcceptEncoding) {
+		for _, coding := range strings.Split(header, ",") {
+			params := strings.Split(coding, ";")
+			name := strings.TrimSpace(params[0])
+			if name != "gzip" && name != "*" {
+				continue
+			}
+			for _, param := range params[1:] {
+				param = strings.TrimSpace(param)
+				if strings.HasPrefix(param, "q=") {
+					if q, err := strconv.ParseFloat(param[2:], 64); err == nil && q == 0 {
+						return false
+					}
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// gzipResponse compresses the response if the client accepts it and it's large enough,
+// the Content-Encoding is set when the compressed response is returned. It's only used
+// for the responses generated by the server, the object bodies are sent as they're stored.
+func gzipResponse(w http.ResponseWriter, r *http.Request, response []byte) []byte {
+	if len(response) < gzipMinSize {
+		return response
+	}
+	w.Header().Add(consts.Vary, consts.AcceptEncoding)
+	if !acceptsGzip(r) {
+		return response
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(response); err != nil {
+		log.Errorf("gzip response err: %v", err)
+		return response
+	}
+	if err := zw.Close(); err != nil {
+		log.Errorf("gzip response err: %v", err)
+		return response
+	}
+	w.Header().Set(consts.ContentEncoding, "gzip")
+	return buf.Bytes()
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
 	"github.com/gorilla/mux"
+	"html"
 	"net/http"
 	"time"
 )
@@ -23,6 +24,21 @@ func WriteErrorResponse(w http.ResponseWriter, r *http.Request, errorCode apierr
 	WriteXMLResponse(w, r, apiError.HTTPStatusCode, errorResponse)
 }
 
+// WriteWebsiteErrorResponse write the ErrorResponse as an html page, the browsers show it
+// to the visitors of a bucket website which has no error document
+func WriteWebsiteErrorResponse(w http.ResponseWriter, r *http.Request, errorCode apierrors.ErrorCode) {
+	apiError := apierrors.GetAPIError(errorCode)
+	status := fmt.Sprintf("%d %s", apiError.HTTPStatusCode, http.StatusText(apiError.HTTPStatusCode))
+	page := fmt.Sprintf("<html>\n<head><title>%s</title></head>\n<body>\n<h1>%s</h1>\n<ul>\n"+
+		"<li>Code: %s</li>\n<li>Message: %s</li>\n</ul>\n</body>\n</html>\n",
+		status, status, html.EscapeString(apiError.Code), html.EscapeString(apiError.Description))
+	if r.Method == http.MethodHead {
+		writeResponse(w, r, apiError.HTTPStatusCode, nil, mimeHTML)
+		return
+	}
+	writeResponse(w, r, apiError.HTTPStatusCode, []byte(page), mimeHTML)
+}
+
 func getRESTErrorResponse(err apierrors.APIError, resource string, bucket, object string) apierrors.RESTErrorResponse {
 	return apierrors.RESTErrorResponse{
 		Code:       err.Code,
@@ -31,10 +31,45 @@ func SetObjectHeaders(w http.ResponseWriter, r *http.Request, objInfo store.Obje
 		w.Header().Set(consts.ContentEncoding, objInfo.ContentEncoding)
 	}
 
+	if objInfo.CacheControl != "" {
+		w.Header().Set(consts.CacheControl, objInfo.CacheControl)
+	}
+
+	if objInfo.ContentDisposition != "" {
+		w.Header().Set(consts.ContentDisposition, objInfo.ContentDisposition)
+	}
+
+	if objInfo.ContentLanguage != "" {
+		w.Header().Set(consts.ContentLanguage, objInfo.ContentLanguage)
+	}
+
 	if !objInfo.Expires.IsZero() {
 		w.Header().Set(consts.Expires, objInfo.Expires.UTC().Format(http.TimeFormat))
 	}
 
+	if objInfo.WebsiteRedirectLocation != "" {
+		w.Header()[consts.AmzWebsiteRedirectLocation] = []string{objInfo.WebsiteRedirectLocation}
+	}
+
+	// Set the user-defined metadata.
+	for k, v := range objInfo.UserDefined {
+		w.Header().Set(k, v)
+	}
+
+	if objInfo.LegalHold {
+		w.Header().Set(consts.AmzObjectLockLegalHold, "ON")
+	}
+
+	// Set the number of tags of the object.
+	if len(objInfo.Tags) > 0 {
+		w.Header()[consts.AmzTagCount] = []string{strconv.Itoa(len(objInfo.Tags))}
+	}
+
+	// Set the parts count of the multipart object.
+	if len(objInfo.Parts) > 0 {
+		w.Header()[consts.AmzMpPartsCount] = []string{strconv.Itoa(len(objInfo.Parts))}
+	}
+
 	// Set content length
 	w.Header().Set(consts.ContentLength, strconv.FormatInt(objInfo.Size, 10))
 
@@ -57,6 +92,7 @@ func SetHeadGetRespHeaders(w http.ResponseWriter, reqParams url.Values) {
 // supportedHeadGetReqParams - supported request parameters for GET and HEAD presigned request.
 var supportedHeadGetReqParams = map[string]string{
 	"response-expires":             consts.Expires,
+	"response-cache-control":       consts.CacheControl,
 	"response-content-type":        consts.ContentType,
 	"response-content-encoding":    consts.ContentEncoding,
 	"response-content-language":    consts.ContentLanguage,
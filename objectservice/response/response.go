@@ -2,7 +2,9 @@ package response
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
@@ -29,6 +31,8 @@ const (
 	mimeJSON mimeType = "application/json"
 	//mimeXML application/xml UTF-8
 	mimeXML mimeType = " application/xml"
+	// mimeHTML the error pages of the website mode
+	mimeHTML mimeType = "text/html; charset=utf-8"
 )
 
 // APIErrorResponse - error response format
@@ -46,9 +50,9 @@ func WriteSuccessResponseXML(w http.ResponseWriter, r *http.Request, response in
 	WriteXMLResponse(w, r, http.StatusOK, response)
 }
 
-//WriteXMLResponse Write XMLResponse
+//WriteXMLResponse Write XMLResponse, a large response is gzip compressed if the client accepts it
 func WriteXMLResponse(w http.ResponseWriter, r *http.Request, statusCode int, response interface{}) {
-	writeResponse(w, r, statusCode, encodeXMLResponse(response), mimeXML)
+	writeResponse(w, r, statusCode, gzipResponse(w, r, encodeXMLResponse(response)), mimeXML)
 }
 
 func writeResponse(w http.ResponseWriter, r *http.Request, statusCode int, response []byte, mType mimeType) {
@@ -74,10 +78,6 @@ func setCommonHeaders(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set(consts.ServerInfo, "FDS")
 	w.Header().Set(consts.AmzRequestID, fmt.Sprintf("%d", time.Now().UnixNano()))
 	w.Header().Set(consts.AcceptRanges, "bytes")
-	if r.Header.Get("Origin") != "" {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-	}
 }
 
 // encodeXMLResponse Encodes the response headers into XML format.
@@ -147,6 +147,24 @@ type ListAllMyBucketsResult struct {
 	Buckets []*s3.Bucket `xml:"Buckets>Bucket"`
 }
 
+// BucketUsageResponse the usage of a bucket over a period, it's not an S3 API
+type BucketUsageResponse struct {
+	Bucket string    `json:"bucket"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	store.BucketUsage
+}
+
+// NewOwner returns the owner of the user, the id is the hex encoded sha256 of the access key
+// like the 64 characters canonical user id of S3, the display name is the access key.
+func NewOwner(accessKey string) *s3.Owner {
+	sum := sha256.Sum256([]byte(accessKey))
+	return &s3.Owner{
+		ID:          aws.String(hex.EncodeToString(sum[:])),
+		DisplayName: aws.String(accessKey),
+	}
+}
+
 //WriteSuccessResponseHeadersOnly write SuccessResponseHeadersOnly
 func WriteSuccessResponseHeadersOnly(w http.ResponseWriter, r *http.Request) {
 	writeResponse(w, r, http.StatusOK, nil, mimeNone)
@@ -161,12 +179,68 @@ type CopyObjectResult struct {
 	ETag         string `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ETag"`
 }
 
+// GetObjectAttributesResponse - format for get object attributes response.
+type GetObjectAttributesResponse struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ GetObjectAttributesResponse" json:"-"`
+
+	ETag         string            `xml:"ETag,omitempty"`
+	Checksum     *ObjectChecksum   `xml:"Checksum,omitempty"`
+	ObjectParts  *ObjectAttrsParts `xml:"ObjectParts,omitempty"`
+	StorageClass string            `xml:"StorageClass,omitempty"`
+	ObjectSize   *int64            `xml:"ObjectSize,omitempty"`
+}
+
+// ObjectChecksum container for the checksum of an object, the checksum of a multipart
+// object is the COMPOSITE checksum of its parts
+type ObjectChecksum struct {
+	ChecksumSHA256 string `xml:"ChecksumSHA256,omitempty"`
+	ChecksumType   string `xml:"ChecksumType,omitempty"`
+}
+
+// ObjectAttrsParts container for the parts of an object in the get object attributes response
+type ObjectAttrsParts struct {
+	PartsCount           int
+	PartNumberMarker     int
+	NextPartNumberMarker int
+	MaxParts             int
+	IsTruncated          bool
+	Parts                []ObjectAttrsPart `xml:"Part"`
+}
+
+// ObjectAttrsPart container for a part of an object in the get object attributes response
+type ObjectAttrsPart struct {
+	PartNumber     int
+	Size           int64
+	ChecksumSHA256 string `xml:"ChecksumSHA256,omitempty"`
+}
+
 // LocationResponse - format for location response.
 type LocationResponse struct {
 	XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ LocationConstraint" json:"-"`
 	Location string   `xml:",chardata"`
 }
 
+// VersioningConfiguration - format for the versioning state of a bucket,
+// Status is empty for a bucket whose versioning has never been enabled.
+type VersioningConfiguration struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ VersioningConfiguration" json:"-"`
+	Status  string   `xml:"Status,omitempty"`
+}
+
+// ObjectLegalHold - format for the legal hold of an object, Status is ON or OFF.
+type ObjectLegalHold struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ LegalHold" json:"-"`
+	Status  string   `xml:"Status"`
+}
+
+// ListInventoryConfigurationsResponse - format for the list of the inventory configurations of a bucket,
+// a bucket has at most 1000 of them so the list is never truncated.
+type ListInventoryConfigurationsResponse struct {
+	XMLName                 xml.Name          `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListInventoryConfigurationsResult" json:"-"`
+	InventoryConfigurations []store.Inventory `xml:"InventoryConfiguration"`
+	IsTruncated             bool              `xml:"IsTruncated"`
+}
+
 // ListObjectsResponse - format for list objects response.
 type ListObjectsResponse struct {
 	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult" json:"-"`
@@ -228,6 +302,55 @@ type ListObjectsV2Response struct {
 	EncodingType string `xml:"EncodingType,omitempty"`
 }
 
+// ListVersionsResponse - format for list object versions response.
+type ListVersionsResponse struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListVersionsResult" json:"-"`
+
+	Name            string
+	Prefix          string
+	KeyMarker       string
+	VersionIDMarker string `xml:"VersionIdMarker"`
+
+	// When the response is truncated, the listing goes on with
+	// NextKeyMarker and NextVersionIdMarker as the markers.
+	NextKeyMarker       string `xml:"NextKeyMarker,omitempty"`
+	NextVersionIDMarker string `xml:"NextVersionIdMarker,omitempty"`
+
+	MaxKeys   int
+	Delimiter string
+	// A flag that indicates whether or not ListObjectVersions returned all of the results
+	// that satisfied the search criteria.
+	IsTruncated bool
+
+	Versions       []ObjectVersion `xml:"Version"`
+	CommonPrefixes []CommonPrefix
+
+	// Encoding type used to encode object keys in the response.
+	EncodingType string `xml:"EncodingType,omitempty"`
+}
+
+// ObjectVersion container for a version of an object, a delete marker
+// is encoded as a DeleteMarker instead of a Version.
+type ObjectVersion struct {
+	Object
+	IsLatest  bool
+	VersionID string `xml:"VersionId"`
+
+	isDeleteMarker bool
+}
+
+// MarshalXML - ObjectVersion marshals into a Version or a DeleteMarker, so that
+// the versions and the delete markers are listed in the order of the versions.
+func (o ObjectVersion) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if o.isDeleteMarker {
+		start.Name.Local = "DeleteMarker"
+	} else {
+		start.Name.Local = "Version"
+	}
+	type objectVersion ObjectVersion
+	return e.EncodeElement(objectVersion(o), start)
+}
+
 // Object container for object metadata
 type Object struct {
 	Key          string
@@ -394,6 +517,60 @@ func GenerateListObjectsV1Response(bucket, prefix, marker, delimiter, encodingTy
 	return data
 }
 
+// GenerateListVersionsResponse Generates a ListObjectVersions response for the said bucket with other enumerated options.
+func GenerateListVersionsResponse(bucket, prefix, keyMarker, versionIDMarker, delimiter, encodingType string, maxKeys int, resp store.ListObjectVersionsInfo) ListVersionsResponse {
+	versions := make([]ObjectVersion, 0, len(resp.Objects))
+	id := consts.DefaultOwnerID
+	name := consts.DisplayName
+	owner := s3.Owner{
+		ID:          &id,
+		DisplayName: &name,
+	}
+	data := ListVersionsResponse{}
+
+	for _, object := range resp.Objects {
+		content := ObjectVersion{}
+		if object.Name == "" {
+			continue
+		}
+		content.Key = utils.S3EncodeName(object.Name, encodingType)
+		content.LastModified = object.ModTime.UTC().Format(consts.Iso8601TimeFormat)
+		if object.ETag != "" {
+			content.ETag = "\"" + object.ETag + "\""
+		}
+		content.Size = object.Size
+		content.Owner = owner
+		content.IsLatest = object.IsLatest
+		content.VersionID = object.VersionID
+		content.isDeleteMarker = object.DeleteMarker
+		if !object.DeleteMarker {
+			content.StorageClass = consts.DefaultStorageClass
+		}
+		versions = append(versions, content)
+	}
+	data.Name = bucket
+	data.Versions = versions
+
+	data.EncodingType = encodingType
+	data.Prefix = utils.S3EncodeName(prefix, encodingType)
+	data.KeyMarker = utils.S3EncodeName(keyMarker, encodingType)
+	data.VersionIDMarker = versionIDMarker
+	data.Delimiter = utils.S3EncodeName(delimiter, encodingType)
+	data.MaxKeys = maxKeys
+	data.NextKeyMarker = utils.S3EncodeName(resp.NextMarker, encodingType)
+	data.NextVersionIDMarker = resp.NextVersionIDMarker
+	data.IsTruncated = resp.IsTruncated
+
+	prefixes := make([]CommonPrefix, 0, len(resp.Prefixes))
+	for _, prefix := range resp.Prefixes {
+		prefixItem := CommonPrefix{}
+		prefixItem.Prefix = utils.S3EncodeName(prefix, encodingType)
+		prefixes = append(prefixes, prefixItem)
+	}
+	data.CommonPrefixes = prefixes
+	return data
+}
+
 // generate multi objects delete response.
 func GenerateMultiDeleteResponse(quiet bool, deletedObjects []datatypes.DeletedObject, errs []DeleteError) DeleteObjectsResponse {
 	deleteResp := DeleteObjectsResponse{}
@@ -413,6 +590,16 @@ type InitiateMultipartUploadResponse struct {
 	UploadID string `xml:"UploadId"`
 }
 
+// PostResponse container for POST object request when success_action_status is set to 201
+type PostResponse struct {
+	XMLName xml.Name `xml:"PostResponse" json:"-"`
+
+	Bucket   string
+	Key      string
+	ETag     string
+	Location string
+}
+
 // CompleteMultipartUploadResponse container for completed multipart upload response
 type CompleteMultipartUploadResponse struct {
 	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CompleteMultipartUploadResult" json:"-"`
@@ -517,7 +704,8 @@ func GenerateCompleteMultpartUploadResponse(bucket, key, location string, oi sto
 		Bucket:   bucket,
 		Key:      key,
 		// AWS S3 quotes the ETag in XML, make sure we are compatible here.
-		ETag: "\"" + oi.ETag + "\"",
+		ETag:           "\"" + oi.ETag + "\"",
+		ChecksumSHA256: oi.CompositeChecksum(),
 	}
 	return c
 }
@@ -553,6 +741,7 @@ func GenerateListPartsResponse(partsInfo store.ListPartsInfo, encodingType strin
 		newPart.ETag = "\"" + part.ETag + "\""
 		newPart.Size = part.Size
 		newPart.LastModified = part.ModTime.UTC().Format(consts.Iso8601TimeFormat)
+		newPart.ChecksumSHA256 = part.ChecksumSHA256
 		resp.Parts[index] = newPart
 	}
 	return resp
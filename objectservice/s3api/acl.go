@@ -0,0 +1,109 @@
+package s3api
+
+import (
+	"encoding/xml"
+	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
+	"github.com/filedag-project/filedag-storage/objectservice/consts"
+	"github.com/filedag-project/filedag-storage/objectservice/response"
+	"github.com/filedag-project/filedag-storage/objectservice/store"
+	"github.com/filedag-project/filedag-storage/objectservice/utils"
+	"io"
+	"net/http"
+)
+
+const (
+	// allUsersURI is the group of everyone, anonymous or not, granted by the public canned ACLs
+	allUsersURI = "http://acs.amazonaws.com/groups/global/AllUsers"
+	// xmlSchemaInstance is the namespace of the xsi:type attribute of the grantees
+	xmlSchemaInstance = "http://www.w3.org/2001/XMLSchema-instance"
+
+	permissionFullControl = "FULL_CONTROL"
+	permissionRead        = "READ"
+	permissionWrite       = "WRITE"
+)
+
+// aclRequest is the AccessControlPolicy of a PUT ACL request
+type aclRequest struct {
+	XMLName xml.Name `xml:"AccessControlPolicy"`
+	Grants  []struct {
+		Grantee struct {
+			ID  string `xml:"ID"`
+			URI string `xml:"URI"`
+		} `xml:"Grantee"`
+		Permission string `xml:"Permission"`
+	} `xml:"AccessControlList>Grant"`
+}
+
+// cannedACLPolicy returns the AccessControlPolicy of the canned ACL, the owner has the full control
+// and everyone is granted the READ and WRITE of the public ACLs. An empty ACL is private.
+func cannedACLPolicy(owner, acl string) response.AccessControlPolicy {
+	var resp response.AccessControlPolicy
+	resp.Owner.ID = owner
+	resp.Owner.DisplayName = owner
+	resp.AccessControlList.Grant = append(resp.AccessControlList.Grant, response.Grant{
+		Grantee: response.Grantee{
+			XMLNS:       xmlSchemaInstance,
+			XMLXSI:      "CanonicalUser",
+			Type:        "CanonicalUser",
+			ID:          owner,
+			DisplayName: owner,
+		},
+		Permission: permissionFullControl,
+	})
+	var permissions []response.Permission
+	switch acl {
+	case store.ACLPublicRead:
+		permissions = []response.Permission{permissionRead}
+	case store.ACLPublicReadWrite:
+		permissions = []response.Permission{permissionRead, permissionWrite}
+	}
+	for _, permission := range permissions {
+		resp.AccessControlList.Grant = append(resp.AccessControlList.Grant, response.Grant{
+			Grantee: response.Grantee{
+				XMLNS:  xmlSchemaInstance,
+				XMLXSI: "Group",
+				Type:   "Group",
+				URI:    allUsersURI,
+			},
+			Permission: permission,
+		})
+	}
+	return resp
+}
+
+// parseCannedACL returns the canned ACL of a PUT ACL request, it's set by the x-amz-acl header or
+// by the AccessControlPolicy of the body. The policy only makes up a canned ACL if it grants the full
+// control to the owner and READ, or READ and WRITE, to everyone; other grants aren't implemented.
+func parseCannedACL(r *http.Request, owner string) (string, apierrors.ErrorCode) {
+	if acl := r.Header.Get(consts.AmzACL); acl != "" {
+		return acl, apierrors.ErrNone
+	}
+	var req aclRequest
+	if err := utils.XmlDecoder(r.Body, &req, r.ContentLength); err != nil {
+		if err == io.EOF {
+			return "", apierrors.ErrMissingSecurityHeader
+		}
+		return "", apierrors.ErrMalformedXML
+	}
+	read, write := false, false
+	for _, grant := range req.Grants {
+		switch {
+		case grant.Grantee.URI == allUsersURI && grant.Permission == permissionRead:
+			read = true
+		case grant.Grantee.URI == allUsersURI && grant.Permission == permissionWrite:
+			write = true
+		case grant.Grantee.URI == "" && grant.Grantee.ID == owner && grant.Permission == permissionFullControl:
+		default:
+			return "", apierrors.ErrNotImplemented
+		}
+	}
+	switch {
+	case read && write:
+		return store.ACLPublicReadWrite, apierrors.ErrNone
+	case read:
+		return store.ACLPublicRead, apierrors.ErrNone
+	case write:
+		return "", apierrors.ErrNotImplemented
+	}
+	return store.ACLPrivate, apierrors.ErrNone
+}
@@ -1,6 +1,7 @@
 package s3api
 
 import (
+	"encoding/json"
 	"encoding/xml"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -15,6 +16,7 @@ import (
 	"io"
 	"net/http"
 	"path"
+	"time"
 )
 
 var log = logging.Logger("server")
@@ -48,10 +50,7 @@ func (s3a *s3ApiServer) ListBucketsHandler(w http.ResponseWriter, r *http.Reques
 	}
 
 	resp := response.ListAllMyBucketsResult{
-		Owner: &s3.Owner{
-			ID:          aws.String(consts.DefaultOwnerID),
-			DisplayName: aws.String(consts.DisplayName),
-		},
+		Owner:   response.NewOwner(cred.AccessKey),
 		Buckets: buckets,
 	}
 
@@ -65,12 +64,13 @@ func (s3a *s3ApiServer) ListBucketsHandler(w http.ResponseWriter, r *http.Reques
 func (s3a *s3ApiServer) GetBucketLocationHandler(w http.ResponseWriter, r *http.Request) {
 	bucket, _, _ := getBucketAndObject(r)
 	ctx := r.Context()
-	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.ListAllMyBucketsAction, bucket, "")
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetBucketLocationAction, bucket, "")
 	if s3err != apierrors.ErrNone {
 		response.WriteErrorResponse(w, r, s3err)
 		return
 	}
-	bucketMetas, err := s3a.bmSys.GetBucketMeta(ctx, bucket)
+	// the location is empty if the server has no region, the clients take it as us-east-1
+	region, err := s3a.bmSys.GetBucketLocation(ctx, bucket)
 	if err != nil {
 		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
@@ -78,7 +78,7 @@ func (s3a *s3ApiServer) GetBucketLocationHandler(w http.ResponseWriter, r *http.
 
 	// Generate response.
 	encodedSuccessResponse := response.LocationResponse{
-		Location: bucketMetas.Region,
+		Location: region,
 	}
 
 	// Write success response.
@@ -90,13 +90,18 @@ func (s3a *s3ApiServer) PutBucketHandler(w http.ResponseWriter, r *http.Request)
 	bucket, _, _ := getBucketAndObject(r)
 	ctx := r.Context()
 	log.Infof("PutBucketHandler %s", bucket)
-	region, _ := parseLocationConstraint(r)
 	// avoid duplicated buckets
 	cred, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.CreateBucketAction, bucket, "")
 	if s3err != apierrors.ErrNone {
 		response.WriteErrorResponse(w, r, s3err)
 		return
 	}
+	// the body was buffered by the authentication, the bucket is created in the server region without a location
+	region, s3err := parseLocationConstraint(r)
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
 
 	if err := s3utils.CheckValidBucketNameStrict(bucket); err != nil {
 		response.WriteErrorResponse(w, r, apierrors.ErrInvalidBucketName)
@@ -168,88 +173,124 @@ func (s3a *s3ApiServer) DeleteBucketHandler(w http.ResponseWriter, r *http.Reque
 func (s3a *s3ApiServer) GetBucketAclHandler(w http.ResponseWriter, r *http.Request) {
 	// collect parameters
 	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
 	log.Infof("GetBucketAclHandler %s", bucket)
-	cred, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(r.Context(), r, s3action.GetBucketPolicyAction, bucket, "")
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetBucketAclAction, bucket, "")
 	if s3err != apierrors.ErrNone {
 		response.WriteErrorResponse(w, r, s3err)
 		return
 	}
-	resp := response.AccessControlPolicy{}
-	id := cred.AccessKey
-	if resp.Owner.DisplayName == "" {
-		resp.Owner.DisplayName = cred.AccessKey
-		resp.Owner.ID = id
-	}
-	resp.AccessControlList.Grant = append(resp.AccessControlList.Grant, response.Grant{
-		Grantee: response.Grantee{
-			ID:          id,
-			DisplayName: cred.AccessKey,
-			Type:        "CanonicalUser",
-			XMLXSI:      "CanonicalUser",
-			XMLNS:       "http://www.w3.org/2001/XMLSchema-instance"},
-		Permission: "FULL_CONTROL", //todo change
-	})
-	response.WriteSuccessResponseXML(w, r, resp)
+	meta, err := s3a.bmSys.GetBucketMeta(ctx, bucket)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	response.WriteSuccessResponseXML(w, r, cannedACLPolicy(meta.Owner, meta.ACL))
 }
 
 // GetBucketCorsHandler Get bucket CORS
 // https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketCors.html
 func (s3a *s3ApiServer) GetBucketCorsHandler(w http.ResponseWriter, r *http.Request) {
-	response.WriteErrorResponse(w, r, apierrors.ErrNoSuchCORSConfiguration)
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("GetBucketCorsHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetBucketCorsAction, bucket, "")
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	cors, err := s3a.bmSys.GetCorsConfig(ctx, bucket)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Write success response.
+	response.WriteSuccessResponseXML(w, r, cors)
 }
 
 // PutBucketCorsHandler Put bucket CORS
 // https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketCors.html
 func (s3a *s3ApiServer) PutBucketCorsHandler(w http.ResponseWriter, r *http.Request) {
-	response.WriteErrorResponse(w, r, apierrors.ErrNotImplemented)
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("PutBucketCorsHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.PutBucketCorsAction, bucket, "")
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	var cors store.Cors
+	if err := utils.XmlDecoder(r.Body, &cors, r.ContentLength); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
+		return
+	}
+	if err := cors.Validate(); err != nil {
+		log.Warnw("PutBucketCorsHandler invalid CORS configuration", "bucket", bucket, "error", err)
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
+		return
+	}
+
+	if err := s3a.bmSys.UpdateBucketCors(ctx, bucket, &cors); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Write success response.
+	response.WriteSuccessResponseHeadersOnly(w, r)
 }
 
 // DeleteBucketCorsHandler Delete bucket CORS
 // https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketCors.html
 func (s3a *s3ApiServer) DeleteBucketCorsHandler(w http.ResponseWriter, r *http.Request) {
-	response.WriteErrorResponse(w, r, http.StatusNoContent)
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("DeleteBucketCorsHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.PutBucketCorsAction, bucket, "")
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	if err := s3a.bmSys.DeleteBucketCors(ctx, bucket); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Write success response.
+	response.WriteSuccessNoContent(w)
 }
 
 // PutBucketAclHandler Put bucket ACL
 // https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketAcl.html
+// Only the canned ACLs private, public-read and public-read-write are supported.
 func (s3a *s3ApiServer) PutBucketAclHandler(w http.ResponseWriter, r *http.Request) {
 	bucket, _, _ := getBucketAndObject(r)
-
-	// Allow putBucketACL if policy action is set, since this is a dummy call
-	// we are simply re-purposing the bucketPolicyAction.
-	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(r.Context(), r, s3action.PutBucketPolicyAction, bucket, "")
+	ctx := r.Context()
+	log.Infof("PutBucketAclHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.PutBucketAclAction, bucket, "")
 	if s3err != apierrors.ErrNone {
 		response.WriteErrorResponse(w, r, s3err)
 		return
 	}
-
-	aclHeader := r.Header.Get(consts.AmzACL)
-	if aclHeader == "" {
-		acl := &response.AccessControlPolicy{}
-		if errc := utils.XmlDecoder(r.Body, acl, r.ContentLength); errc != nil {
-			if errc == io.EOF {
-				response.WriteErrorResponse(w, r, apierrors.ErrMissingSecurityHeader)
-				return
-			}
-			response.WriteErrorResponse(w, r, apierrors.ErrInternalError)
-			return
-		}
-
-		if len(acl.AccessControlList.Grant) == 0 {
-			response.WriteErrorResponse(w, r, apierrors.ErrNotImplemented)
-			return
-		}
-
-		if acl.AccessControlList.Grant[0].Permission != "FULL_CONTROL" {
-			response.WriteErrorResponse(w, r, apierrors.ErrNotImplemented)
-			return
-		}
+	meta, err := s3a.bmSys.GetBucketMeta(ctx, bucket)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
 	}
-
-	if aclHeader != "" && aclHeader != "private" {
-		response.WriteErrorResponse(w, r, apierrors.ErrNotImplemented)
+	acl, s3err := parseCannedACL(r, meta.Owner)
+	if s3err != apierrors.ErrNone {
+		log.Warnw("PutBucketAclHandler unsupported acl", "bucket", bucket, "error", apierrors.GetAPIError(s3err).Code)
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+	if err = s3a.bmSys.UpdateBucketACL(ctx, bucket, acl); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
 	}
+	response.WriteSuccessResponseHeadersOnly(w, r)
 }
 
 // PutBucketTaggingHandler
@@ -257,8 +298,8 @@ func (s3a *s3ApiServer) PutBucketAclHandler(w http.ResponseWriter, r *http.Reque
 func (s3a *s3ApiServer) PutBucketTaggingHandler(w http.ResponseWriter, r *http.Request) {
 	bucket, _, _ := getBucketAndObject(r)
 	ctx := r.Context()
-	log.Infof("DeleteBucketHandler %s", bucket)
-	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.DeleteBucketAction, bucket, "")
+	log.Infof("PutBucketTaggingHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.PutBucketTaggingAction, bucket, "")
 	if s3err != apierrors.ErrNone {
 		response.WriteErrorResponse(w, r, s3err)
 		return
@@ -266,6 +307,10 @@ func (s3a *s3ApiServer) PutBucketTaggingHandler(w http.ResponseWriter, r *http.R
 
 	tags, err := unmarshalXML(io.LimitReader(r.Body, r.ContentLength), false)
 	if err != nil {
+		if _, ok := err.(store.InvalidTag); ok {
+			response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+			return
+		}
 		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
 		return
 	}
@@ -284,8 +329,8 @@ func (s3a *s3ApiServer) PutBucketTaggingHandler(w http.ResponseWriter, r *http.R
 func (s3a *s3ApiServer) GetBucketTaggingHandler(w http.ResponseWriter, r *http.Request) {
 	bucket, _, _ := getBucketAndObject(r)
 	ctx := r.Context()
-	log.Infof("DeleteBucketHandler %s", bucket)
-	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.DeleteBucketAction, bucket, "")
+	log.Infof("GetBucketTaggingHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetBucketTaggingAction, bucket, "")
 	if s3err != apierrors.ErrNone {
 		response.WriteErrorResponse(w, r, s3err)
 		return
@@ -296,14 +341,9 @@ func (s3a *s3ApiServer) GetBucketTaggingHandler(w http.ResponseWriter, r *http.R
 		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
 	}
-	configData, err2 := xml.Marshal(tags)
-	if err2 != nil {
-		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
-		return
-	}
 
 	// Write success response.
-	response.WriteSuccessResponseXML(w, r, configData)
+	response.WriteSuccessResponseXML(w, r, tags)
 }
 
 // DeleteBucketTaggingHandler
@@ -311,8 +351,9 @@ func (s3a *s3ApiServer) GetBucketTaggingHandler(w http.ResponseWriter, r *http.R
 func (s3a *s3ApiServer) DeleteBucketTaggingHandler(w http.ResponseWriter, r *http.Request) {
 	bucket, _, _ := getBucketAndObject(r)
 	ctx := r.Context()
-	log.Infof("DeleteBucketHandler %s", bucket)
-	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.DeleteBucketAction, bucket, "")
+	log.Infof("DeleteBucketTaggingHandler %s", bucket)
+	// DeleteBucketTagging is authorized by the s3:PutBucketTagging permission
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.PutBucketTaggingAction, bucket, "")
 	if s3err != apierrors.ErrNone {
 		response.WriteErrorResponse(w, r, s3err)
 		return
@@ -323,6 +364,450 @@ func (s3a *s3ApiServer) DeleteBucketTaggingHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
+	// Write success response.
+	response.WriteSuccessNoContent(w)
+}
+
+// PutBucketWebsiteHandler
+//https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketWebsite.html
+func (s3a *s3ApiServer) PutBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("PutBucketWebsiteHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.PutBucketWebsiteAction, bucket, "")
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	var website store.Website
+	if err := utils.XmlDecoder(r.Body, &website, r.ContentLength); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
+		return
+	}
+	if err := website.Validate(); err != nil {
+		log.Warnw("PutBucketWebsiteHandler invalid website configuration", "bucket", bucket, "error", err)
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
+		return
+	}
+
+	if err := s3a.bmSys.UpdateBucketWebsite(ctx, bucket, &website); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Write success response.
+	response.WriteSuccessResponseHeadersOnly(w, r)
+}
+
+// GetBucketWebsiteHandler
+//https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketWebsite.html
+func (s3a *s3ApiServer) GetBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("GetBucketWebsiteHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetBucketWebsiteAction, bucket, "")
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	website, err := s3a.bmSys.GetWebsiteConfig(ctx, bucket)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Write success response.
+	response.WriteSuccessResponseXML(w, r, website)
+}
+
+// DeleteBucketWebsiteHandler
+//https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketWebsite.html
+func (s3a *s3ApiServer) DeleteBucketWebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("DeleteBucketWebsiteHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.DeleteBucketWebsiteAction, bucket, "")
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	if err := s3a.bmSys.DeleteBucketWebsite(ctx, bucket); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Write success response.
+	response.WriteSuccessNoContent(w)
+}
+
+// PutBucketLifecycleHandler - PUT Bucket lifecycle configuration
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketLifecycleConfiguration.html
+// Only the Expiration rules are supported, the expired objects are deleted by the lifecycle sweeper.
+func (s3a *s3ApiServer) PutBucketLifecycleHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("PutBucketLifecycleHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.PutBucketLifecycleAction, bucket, "")
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	var lc store.Lifecycle
+	if err := utils.XmlDecoder(r.Body, &lc, r.ContentLength); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
+		return
+	}
+	if err := lc.Validate(); err != nil {
+		log.Warnw("PutBucketLifecycleHandler invalid lifecycle configuration", "bucket", bucket, "error", err)
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
+		return
+	}
+
+	if err := s3a.bmSys.UpdateBucketLifecycle(ctx, bucket, &lc); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Write success response.
+	response.WriteSuccessResponseHeadersOnly(w, r)
+}
+
+// GetBucketLifecycleHandler - GET Bucket lifecycle configuration
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketLifecycleConfiguration.html
+func (s3a *s3ApiServer) GetBucketLifecycleHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("GetBucketLifecycleHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetBucketLifecycleAction, bucket, "")
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	lc, err := s3a.bmSys.GetLifecycleConfig(ctx, bucket)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Write success response.
+	response.WriteSuccessResponseXML(w, r, lc)
+}
+
+// DeleteBucketLifecycleHandler - DELETE Bucket lifecycle configuration
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketLifecycle.html
+func (s3a *s3ApiServer) DeleteBucketLifecycleHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("DeleteBucketLifecycleHandler %s", bucket)
+	// DeleteBucketLifecycle is authorized by the s3:PutLifecycleConfiguration permission
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.PutBucketLifecycleAction, bucket, "")
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	if err := s3a.bmSys.DeleteBucketLifecycle(ctx, bucket); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Write success response.
+	response.WriteSuccessNoContent(w)
+}
+
+// PutBucketOverwriteProtectionHandler - PUT Bucket?overwrite-protection
+// ----------
+// This is not an S3 API, it sets the minimum interval between the overwrites of a key in the bucket,
+// an overwrite within the interval is rejected. The body is like
+// <OverwriteProtectionConfiguration><MinimumIntervalSeconds>60</MinimumIntervalSeconds></OverwriteProtectionConfiguration>,
+// it's governed by the object lock configuration actions.
+func (s3a *s3ApiServer) PutBucketOverwriteProtectionHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("PutBucketOverwriteProtectionHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.PutBucketObjectLockConfigurationAction, bucket, "")
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	var op store.OverwriteProtection
+	if err := utils.XmlDecoder(r.Body, &op, r.ContentLength); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
+		return
+	}
+	if err := op.Validate(); err != nil {
+		log.Warnw("PutBucketOverwriteProtectionHandler invalid overwrite protection configuration", "bucket", bucket, "error", err)
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
+		return
+	}
+
+	if err := s3a.bmSys.UpdateBucketOverwriteProtection(ctx, bucket, &op); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Write success response.
+	response.WriteSuccessResponseHeadersOnly(w, r)
+}
+
+// GetBucketOverwriteProtectionHandler - GET Bucket?overwrite-protection
+// ----------
+// This is not an S3 API, it returns the overwrite protection configuration of the bucket.
+func (s3a *s3ApiServer) GetBucketOverwriteProtectionHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("GetBucketOverwriteProtectionHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetBucketObjectLockConfigurationAction, bucket, "")
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	op, err := s3a.bmSys.GetOverwriteProtectionConfig(ctx, bucket)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Write success response.
+	response.WriteSuccessResponseXML(w, r, op)
+}
+
+// DeleteBucketOverwriteProtectionHandler - DELETE Bucket?overwrite-protection
+// ----------
+// This is not an S3 API, it removes the overwrite protection of the bucket.
+func (s3a *s3ApiServer) DeleteBucketOverwriteProtectionHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("DeleteBucketOverwriteProtectionHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.PutBucketObjectLockConfigurationAction, bucket, "")
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	if err := s3a.bmSys.DeleteBucketOverwriteProtection(ctx, bucket); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Write success response.
+	response.WriteSuccessNoContent(w)
+}
+
+// PutBucketKeyCaseHandler - PUT Bucket?key-case
+// ----------
+// This is not an S3 API, it sets the case sensitivity of the keys in the bucket. The body is like
+// <KeyCaseConfiguration><Mode>CaseInsensitive</Mode></KeyCaseConfiguration>, the keys of a case-insensitive
+// bucket are stored and listed in lower case. The mode only changes while the bucket is empty.
+// Only the root user can set it, no bucket policy grants it.
+func (s3a *s3ApiServer) PutBucketKeyCaseHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("PutBucketKeyCaseHandler %s", bucket)
+	_, owner, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, "", "", "")
+	if s3err != apierrors.ErrNone || !owner {
+		response.WriteErrorResponse(w, r, apierrors.ErrAccessDenied)
+		return
+	}
+
+	var kc store.KeyCase
+	if err := utils.XmlDecoder(r.Body, &kc, r.ContentLength); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
+		return
+	}
+	if err := kc.Validate(); err != nil {
+		log.Warnw("PutBucketKeyCaseHandler invalid key case configuration", "bucket", bucket, "error", err)
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
+		return
+	}
+
+	if err := s3a.bmSys.UpdateBucketKeyCase(ctx, bucket, &kc); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Write success response.
+	response.WriteSuccessResponseHeadersOnly(w, r)
+}
+
+// GetBucketKeyCaseHandler - GET Bucket?key-case
+// ----------
+// This is not an S3 API, it returns the case sensitivity of the keys in the bucket. Only the root user can read it.
+func (s3a *s3ApiServer) GetBucketKeyCaseHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("GetBucketKeyCaseHandler %s", bucket)
+	_, owner, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, "", "", "")
+	if s3err != apierrors.ErrNone || !owner {
+		response.WriteErrorResponse(w, r, apierrors.ErrAccessDenied)
+		return
+	}
+
+	kc, err := s3a.bmSys.GetBucketKeyCase(ctx, bucket)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Write success response.
+	response.WriteSuccessResponseXML(w, r, kc)
+}
+
+// PutBucketInventoryConfigurationHandler - PUT Bucket?inventory&id=
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketInventoryConfiguration.html
+// Only the CSV format is supported, the destination bucket must exist and have the same owner.
+func (s3a *s3ApiServer) PutBucketInventoryConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("PutBucketInventoryConfigurationHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.PutInventoryConfigurationAction, bucket, "")
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	var inv store.Inventory
+	if err := utils.XmlDecoder(r.Body, &inv, r.ContentLength); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
+		return
+	}
+	// the id of the query names the configuration of the body
+	if inv.ID != r.URL.Query().Get("id") {
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
+		return
+	}
+	if err := inv.Validate(); err != nil {
+		log.Warnw("PutBucketInventoryConfigurationHandler invalid inventory configuration", "bucket", bucket, "error", err)
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
+		return
+	}
+
+	if err := s3a.bmSys.PutBucketInventory(ctx, bucket, &inv); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Write success response.
+	response.WriteSuccessResponseHeadersOnly(w, r)
+}
+
+// GetBucketInventoryConfigurationHandler - GET Bucket?inventory&id=
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketInventoryConfiguration.html
+func (s3a *s3ApiServer) GetBucketInventoryConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("GetBucketInventoryConfigurationHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetInventoryConfigurationAction, bucket, "")
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	inv, err := s3a.bmSys.GetBucketInventory(ctx, bucket, r.URL.Query().Get("id"))
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Write success response.
+	response.WriteSuccessResponseXML(w, r, inv)
+}
+
+// ListBucketInventoryConfigurationsHandler - GET Bucket?inventory
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListBucketInventoryConfigurations.html
+func (s3a *s3ApiServer) ListBucketInventoryConfigurationsHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("ListBucketInventoryConfigurationsHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetInventoryConfigurationAction, bucket, "")
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	invs, err := s3a.bmSys.ListBucketInventories(ctx, bucket)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Write success response.
+	response.WriteSuccessResponseXML(w, r, response.ListInventoryConfigurationsResponse{InventoryConfigurations: invs})
+}
+
+// DeleteBucketInventoryConfigurationHandler - DELETE Bucket?inventory&id=
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteBucketInventoryConfiguration.html
+// The reports already written to the destination bucket are kept.
+func (s3a *s3ApiServer) DeleteBucketInventoryConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("DeleteBucketInventoryConfigurationHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.PutInventoryConfigurationAction, bucket, "")
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	if err := s3a.bmSys.DeleteBucketInventory(ctx, bucket, r.URL.Query().Get("id")); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Write success response.
+	response.WriteSuccessNoContent(w)
+}
+
+// GetBucketVersioningHandler
+//https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetBucketVersioning.html
+// The status is empty for a bucket whose versioning has never been enabled.
+func (s3a *s3ApiServer) GetBucketVersioningHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("GetBucketVersioningHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetBucketVersioningAction, bucket, "")
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+	status, err := s3a.bmSys.GetBucketVersioning(ctx, bucket)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Write success response.
+	response.WriteSuccessResponseXML(w, r, response.VersioningConfiguration{Status: status})
+}
+
+// PutBucketVersioningHandler
+//https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketVersioning.html
+// The versioning is either Enabled or Suspended, once enabled it can't be turned off.
+func (s3a *s3ApiServer) PutBucketVersioningHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, _, _ := getBucketAndObject(r)
+	ctx := r.Context()
+	log.Infof("PutBucketVersioningHandler %s", bucket)
+	_, _, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.PutBucketVersioningAction, bucket, "")
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+	var config versioningConfiguration
+	if err := utils.XmlDecoder(r.Body, &config, r.ContentLength); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
+		return
+	}
+	if err := s3a.bmSys.UpdateBucketVersioning(ctx, bucket, config.Status); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
 	// Write success response.
 	response.WriteSuccessResponseHeadersOnly(w, r)
 }
@@ -334,7 +819,7 @@ func parseLocationConstraint(r *http.Request) (location string, s3Error apierror
 	// be created at default region.
 	locationConstraint := createBucketLocationConfiguration{}
 	err := utils.XmlDecoder(r.Body, &locationConstraint, r.ContentLength)
-	if err != nil && r.ContentLength != 0 {
+	if err != nil && err != io.EOF {
 		// Treat all other failures as XML parsing errors.
 		return "", apierrors.ErrMalformedXML
 	} // else for both err as nil or io.EOF
@@ -345,6 +830,13 @@ func parseLocationConstraint(r *http.Request) (location string, s3Error apierror
 	return location, apierrors.ErrNone
 }
 
+// versioningConfiguration container for the versioning configuration of a PutBucketVersioning request,
+// it's parsed with or without the namespace.
+type versioningConfiguration struct {
+	XMLName xml.Name `xml:"VersioningConfiguration"`
+	Status  string   `xml:"Status"`
+}
+
 // createBucketConfiguration container for bucket configuration request from client.
 // Used for parsing the location from the request body for Makebucket.
 type createBucketLocationConfiguration struct {
@@ -376,3 +868,55 @@ func unmarshalXML(reader io.Reader, isObject bool) (*store.Tags, error) {
 
 	return tagging, nil
 }
+
+// GetBucketUsageHandler - GET Bucket?usage
+// ----------
+// This is not an S3 API, it returns the requests and the transferred bytes of the bucket
+// in the hours from the hour of the from query until the to query, both in RFC 3339.
+// The from defaults to the beginning and the to defaults to now. Only the root user can read it.
+func (s3a *s3ApiServer) GetBucketUsageHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bucket, _, _ := getBucketAndObject(r)
+	log.Infof("GetBucketUsageHandler %s", bucket)
+	_, owner, s3err := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, "", "", "")
+	if s3err != apierrors.ErrNone || !owner {
+		response.WriteErrorResponse(w, r, apierrors.ErrAccessDenied)
+		return
+	}
+	if !s3a.bmSys.HasBucket(ctx, bucket) {
+		response.WriteErrorResponse(w, r, apierrors.ErrNoSuchBucket)
+		return
+	}
+	var from time.Time
+	to := time.Now().UTC()
+	var err error
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			response.WriteErrorResponse(w, r, apierrors.ErrInvalidRequest)
+			return
+		}
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			response.WriteErrorResponse(w, r, apierrors.ErrInvalidRequest)
+			return
+		}
+	}
+	usage, err := s3a.bmSys.GetBucketUsage(ctx, bucket, from, to)
+	if err != nil {
+		log.Errorf("GetBucketUsageHandler GetBucketUsage err:%v", err)
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	resp, err := json.Marshal(response.BucketUsageResponse{
+		Bucket:      bucket,
+		From:        from,
+		To:          to,
+		BucketUsage: usage,
+	})
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrInternalError)
+		return
+	}
+	response.WriteSuccessResponseJSON(w, resp)
+}
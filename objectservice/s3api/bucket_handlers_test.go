@@ -3,8 +3,11 @@ package s3api
 import (
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"github.com/filedag-project/filedag-storage/dag/pool/client"
+	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
+	"github.com/filedag-project/filedag-storage/objectservice/consts"
 	"github.com/filedag-project/filedag-storage/objectservice/iam"
 	"github.com/filedag-project/filedag-storage/objectservice/iam/auth"
 	"github.com/filedag-project/filedag-storage/objectservice/iam/policy"
@@ -17,16 +20,30 @@ import (
 	"github.com/ipfs/go-blockservice"
 	offline "github.com/ipfs/go-ipfs-exchange-offline"
 	"github.com/ipfs/go-merkledag"
+	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 var w *httptest.ResponseRecorder
 var router = mux.NewRouter()
 
+// websiteRouter the website endpoint of the test server
+var websiteRouter = mux.NewRouter()
+
+// bmSys the bucket metadata of the test server, for the configurations without an API
+var bmSys *store.BucketMetadataSys
+
+// storageSys the storage of the test server
+var storageSys *store.StorageSys
+
+// authSys the auth of the test server, for the settings without an API
+var authSys *iam.AuthSys
+
 func TestMain(m *testing.M) {
 	db, err := uleveldb.OpenDb((&testing.T{}).TempDir())
 	if err != nil {
@@ -39,14 +56,18 @@ func TestMain(m *testing.M) {
 		println(err)
 		return
 	}
-	authSys := iam.NewAuthSys(db, cred)
+	authSys = iam.NewAuthSys(db, cred)
 	poolCli, done := client.NewMockPoolClient(&testing.T{})
 	defer done()
 	dagServ := merkledag.NewDAGService(blockservice.New(poolCli, offline.Exchange(poolCli)))
-	storageSys := store.NewStorageSys(context.TODO(), dagServ, db)
-	bmSys := store.NewBucketMetadataSys(db)
+	storageSys = store.NewStorageSys(context.TODO(), dagServ, db)
+	bmSys = store.NewBucketMetadataSys(db)
 	storageSys.SetNewBucketNSLock(bmSys.NewNSLock)
 	storageSys.SetHasBucket(bmSys.HasBucket)
+	storageSys.SetOverwriteInterval(bmSys.GetOverwriteInterval)
+	storageSys.SetCaseInsensitive(bmSys.IsCaseInsensitive)
+	storageSys.SetVersioning(bmSys.GetBucketVersioning)
+	authSys.SetObjectACL(storageSys.GetObjectVersionACL)
 	bmSys.SetEmptyBucket(storageSys.EmptyBucket)
 	cleanData := func(accessKey string) {
 		ctx := context.Background()
@@ -64,8 +85,9 @@ func TestMain(m *testing.M) {
 			}
 		}
 	}
-	iamapi.NewIamApiServer(router, authSys, cleanData)
+	iamapi.NewIamApiServer(router, authSys, storageSys, bmSys, cleanData)
 	NewS3Server(router, authSys, bmSys, storageSys)
+	NewWebsiteServer(websiteRouter, authSys, bmSys, storageSys)
 	os.Exit(m.Run())
 }
 func reqTest(r *http.Request) *httptest.ResponseRecorder {
@@ -114,6 +136,44 @@ func TestS3ApiServer_PutBucketHandler(t *testing.T) {
 	}
 
 }
+func TestS3ApiServer_PutBucketLocationConstraint(t *testing.T) {
+	bmSys.SetRegion("us-east-1")
+	defer bmSys.SetRegion("")
+	putBucket := func(bucket, location string) *httptest.ResponseRecorder {
+		body := "<CreateBucketConfiguration><LocationConstraint>" + location + "</LocationConstraint></CreateBucketConfiguration>"
+		req := utils.MustNewSignedV4Request(http.MethodPut, bucket, int64(len(body)), strings.NewReader(body), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		return reqTest(req)
+	}
+	getLocation := func(bucket string) string {
+		req := utils.MustNewSignedV4Request(http.MethodGet, bucket+"?location", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result := reqTest(req)
+		require.Equal(t, http.StatusOK, result.Code, result.Body.String())
+		var resp response.LocationResponse
+		require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &resp))
+		return resp.Location
+	}
+
+	result := putBucket("/testbucketlocation", "us-east-1")
+	require.Equal(t, http.StatusOK, result.Code, result.Body.String())
+	require.Equal(t, "us-east-1", getLocation("/testbucketlocation"))
+
+	// the bucket is created in the server region without a location
+	result = reqTest(utils.MustNewSignedV4Request(http.MethodPut, "/testbucketnolocation", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	require.Equal(t, http.StatusOK, result.Code, result.Body.String())
+	require.Equal(t, "us-east-1", getLocation("/testbucketnolocation"))
+
+	result = putBucket("/testbucketotherlocation", "eu-west-1")
+	require.Equal(t, http.StatusBadRequest, result.Code)
+	require.Contains(t, result.Body.String(), "IllegalLocationConstraintException")
+	require.False(t, bmSys.HasBucket(context.TODO(), "testbucketotherlocation"))
+
+	body := "<CreateBucketConfiguration><LocationConstraint>"
+	req := utils.MustNewSignedV4Request(http.MethodPut, "/testbucketbadlocation", int64(len(body)), strings.NewReader(body), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(req)
+	require.Equal(t, http.StatusBadRequest, result.Code)
+	require.Contains(t, result.Body.String(), "MalformedXML")
+}
+
 func TestS3ApiServer_HeadBucketHandler(t *testing.T) {
 	bucketName := "/testbuckethead"
 	// test cases with inputs and expected result for Bucket.
@@ -234,6 +294,84 @@ func TestS3ApiServer_ListBucketHandler(t *testing.T) {
 	}
 
 }
+func TestS3ApiServer_ListBucketsHandlerOwner(t *testing.T) {
+	userName, secret := "listownertest", "listownertest1234"
+	reqPutUser := utils.MustNewSignedV4Request(http.MethodPost, "/admin/v1/add-user"+"?accessKey="+userName+"&secretKey="+secret, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutUser).Code)
+
+	for _, cred := range []struct{ accessKey, secretKey string }{
+		{DefaultTestAccessKey, DefaultTestSecretKey},
+		{userName, secret},
+	} {
+		req := utils.MustNewSignedV4Request(http.MethodGet, "/", 0, nil, "s3", cred.accessKey, cred.secretKey, t)
+		result := reqTest(req)
+		require.Equal(t, http.StatusOK, result.Code)
+		require.Contains(t, result.Body.String(), `<ListAllMyBucketsResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">`)
+
+		var resp struct {
+			XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListAllMyBucketsResult"`
+			Owner   struct {
+				ID          string
+				DisplayName string
+			}
+		}
+		require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &resp))
+		require.Len(t, resp.Owner.ID, 64)
+		require.Equal(t, cred.accessKey, resp.Owner.DisplayName)
+	}
+}
+
+func TestS3ApiServer_GetBucketUsageHandler(t *testing.T) {
+	bucketName := "/testbucketusage"
+	objectName := bucketName + "/usageobject"
+	content := "usagecontent"
+	start := time.Now().UTC()
+
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+	reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, objectName, int64(len(content)), strings.NewReader(content), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutObject).Code)
+	var bytesOut uint64
+	for i := 0; i < 2; i++ {
+		reqGetObject := utils.MustNewSignedV4Request(http.MethodGet, objectName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result := reqTest(reqGetObject)
+		require.Equal(t, http.StatusOK, result.Code)
+		bytesOut += uint64(result.Body.Len())
+	}
+
+	getUsage := func(query string) response.BucketUsageResponse {
+		req := utils.MustNewSignedV4Request(http.MethodGet, bucketName+"?usage"+query, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result := reqTest(req)
+		require.Equal(t, http.StatusOK, result.Code, result.Body.String())
+		var resp response.BucketUsageResponse
+		require.NoError(t, json.Unmarshal(result.Body.Bytes(), &resp))
+		return resp
+	}
+	usage := getUsage("")
+	require.Equal(t, "testbucketusage", usage.Bucket)
+	require.Equal(t, map[string]uint64{http.MethodPut: 2, http.MethodGet: 2}, usage.Requests)
+	require.Equal(t, uint64(len(content)), usage.BytesIn)
+	require.Equal(t, bytesOut, usage.BytesOut)
+
+	// the previous usage query is counted too
+	usage = getUsage("&from=" + start.Format(time.RFC3339))
+	require.Equal(t, map[string]uint64{http.MethodPut: 2, http.MethodGet: 3}, usage.Requests)
+	usage = getUsage("&from=" + start.Add(2*time.Hour).Format(time.RFC3339))
+	require.Empty(t, usage.Requests)
+	require.Zero(t, usage.BytesIn)
+
+	reqInvalid := utils.MustNewSignedV4Request(http.MethodGet, bucketName+"?usage&from=yesterday", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusBadRequest, reqTest(reqInvalid).Code)
+	reqNoBucket := utils.MustNewSignedV4Request(http.MethodGet, "/testbucketusagenone?usage", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNotFound, reqTest(reqNoBucket).Code)
+
+	userName, secret := "usagetest", "usagetest1234"
+	reqPutUser := utils.MustNewSignedV4Request(http.MethodPost, "/admin/v1/add-user"+"?accessKey="+userName+"&secretKey="+secret, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutUser).Code)
+	reqUser := utils.MustNewSignedV4Request(http.MethodGet, bucketName+"?usage", 0, nil, "s3", userName, secret, t)
+	require.Equal(t, http.StatusForbidden, reqTest(reqUser).Code)
+}
+
 func TestS3ApiServer_DeleteBucketHandler(t *testing.T) {
 	bucketName := "/testbucketdel"
 	// test cases with inputs and expected result for Bucket.
@@ -321,72 +459,99 @@ func TestS3ApiServer_BucketPolicyHandler(t *testing.T) {
 	fmt.Println("del:", reqTest(reqDel).Body.String())
 }
 
-//func TestS3ApiServer_GetBucketLocationHandler(t *testing.T) {
-//	u := "http://127.0.0.1:9985/test22"
-//	//req.Header.Set("Content-Type", "text/plain")
-//	req := testsign.MustNewSignedV4Request(http.MethodHead, u+"?location", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
-//	client := &http.Client{}
-//	res, err := client.Do(req)
-//	if err != nil {
-//		fmt.Println(err)
-//		return
-//	}
-//	defer res.Body.Close()
-//	body, err := ioutil.ReadAll(res.Body)
-//
-//	fmt.Println(res)
-//	fmt.Println(string(body))
-//}
-//
-//func TestS3ApiServer_GetBucketAclHandler(t *testing.T) {
-//	u := "http://127.0.0.1:9985/test"
-//	req := testsign.MustNewSignedV4Request(http.MethodGet, u+"?acl=", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
-//	//req.Header.Set("Content-Type", "text/plain")
-//	client := &http.Client{}
-//	res, err := client.Do(req)
-//	if err != nil {
-//		fmt.Println(err)
-//		return
-//	}
-//	defer res.Body.Close()
-//	body, err := ioutil.ReadAll(res.Body)
-//
-//	fmt.Println(res)
-//	fmt.Println(string(body))
-//}
-//func TestS3ApiServer_PutBucketAclHandler(t *testing.T) {
-//	u := "http://127.0.0.1:9985/test"
-//	a := `<?xml version="1.0" encoding="UTF-8"?>
-//<AccessControlPolicy xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
-//  <Owner>
-//    <ID>*** Owner-Canonical-User-ID ***</ID>
-//    <DisplayName>owner-display-name</DisplayName>
-//  </Owner>
-//  <AccessControlList>
-//    <Grant>
-//      <Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
-//               xsi:type="Canonical User">
-//        <ID>*** Owner-Canonical-User-ID ***</ID>
-//        <DisplayName>display-name</DisplayName>
-//      </Grantee>
-//      <Permission>FULL_CONTROL</Permission>
-//    </Grant>
-//  </AccessControlList>
-//</AccessControlPolicy>`
-//	req := testsign.MustNewSignedV4Request(http.MethodPut, u+"?acl=", int64(len(a)), strings.NewReader(a), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
-//	//req.Header.Set("Content-Type", "text/plain")
-//	client := &http.Client{}
-//	res, err := client.Do(req)
-//	if err != nil {
-//		fmt.Println(err)
-//		return
-//	}
-//	defer res.Body.Close()
-//	body, err := ioutil.ReadAll(res.Body)
-//
-//	fmt.Println(res)
-//	fmt.Println(string(body))
-//}
+func TestS3ApiServer_GetBucketLocationHandler(t *testing.T) {
+	u := "/testbucketgetlocation"
+	require.Equal(t, http.StatusOK, reqTest(utils.MustNewSignedV4Request(http.MethodPut, u, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)).Code)
+	getLocation := func(bucket string) *httptest.ResponseRecorder {
+		return reqTest(utils.MustNewSignedV4Request(http.MethodGet, bucket+"?location", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	}
+
+	// the server has no region, the location is empty
+	result := getLocation(u)
+	require.Equal(t, http.StatusOK, result.Code, result.Body.String())
+	require.Contains(t, result.Body.String(), `<LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/"></LocationConstraint>`)
+
+	// the bucket created before the region of the server was set is in the region of the server
+	bmSys.SetRegion("cn-north-1")
+	defer bmSys.SetRegion("")
+	result = getLocation(u)
+	require.Equal(t, http.StatusOK, result.Code, result.Body.String())
+	var resp response.LocationResponse
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &resp))
+	require.Equal(t, "cn-north-1", resp.Location)
+
+	result = getLocation("/testbucketgetlocationmissing")
+	require.Equal(t, http.StatusNotFound, result.Code)
+	require.Contains(t, result.Body.String(), "NoSuchBucket")
+}
+
+func TestS3ApiServer_BucketAclHandler(t *testing.T) {
+	u := "/testbucketacl"
+	require.Equal(t, http.StatusOK, reqTest(utils.MustNewSignedV4Request(http.MethodPut, u, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)).Code)
+	putACL := func(acl, body string) *httptest.ResponseRecorder {
+		req := utils.MustNewSignedV4Request(http.MethodPut, u+"?acl", int64(len(body)), strings.NewReader(body),
+			"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		if acl != "" {
+			req.Header.Set(consts.AmzACL, acl)
+		}
+		return reqTest(req)
+	}
+	getACL := func() response.AccessControlPolicy {
+		result := reqTest(utils.MustNewSignedV4Request(http.MethodGet, u+"?acl", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+		require.Equal(t, http.StatusOK, result.Code, result.Body.String())
+		var resp response.AccessControlPolicy
+		require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &resp))
+		require.Equal(t, DefaultTestAccessKey, resp.Owner.ID)
+		return resp
+	}
+	permissions := func(resp response.AccessControlPolicy) map[string][]response.Permission {
+		granted := make(map[string][]response.Permission)
+		for _, grant := range resp.AccessControlList.Grant {
+			grantee := grant.Grantee.ID
+			if grant.Grantee.URI != "" {
+				grantee = grant.Grantee.URI
+			}
+			granted[grantee] = append(granted[grantee], grant.Permission)
+		}
+		return granted
+	}
+	requireError := func(result *httptest.ResponseRecorder, code apierrors.ErrorCode) {
+		apiErr := apierrors.GetAPIError(code)
+		require.Equal(t, apiErr.HTTPStatusCode, result.Code)
+		var errResp response.APIErrorResponse
+		require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &errResp))
+		require.Equal(t, apiErr.Code, errResp.Code)
+	}
+
+	// a new bucket is private
+	require.Equal(t, map[string][]response.Permission{DefaultTestAccessKey: {"FULL_CONTROL"}}, permissions(getACL()))
+
+	require.Equal(t, http.StatusOK, putACL("public-read", "").Code)
+	resp := getACL()
+	require.Equal(t, map[string][]response.Permission{DefaultTestAccessKey: {"FULL_CONTROL"}, allUsersURI: {"READ"}}, permissions(resp))
+
+	// the policy got is put back as it is
+	body, err := xml.Marshal(resp)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, putACL("private", "").Code)
+	require.Equal(t, http.StatusOK, putACL("", string(body)).Code)
+	require.Equal(t, resp, getACL())
+
+	require.Equal(t, http.StatusOK, putACL("public-read-write", "").Code)
+	require.Equal(t, map[string][]response.Permission{DefaultTestAccessKey: {"FULL_CONTROL"}, allUsersURI: {"READ", "WRITE"}}, permissions(getACL()))
+
+	// the ACLs other than the supported canned ones are rejected and the stored ACL is kept
+	requireError(putACL("authenticated-read", ""), apierrors.ErrInvalidCannedACL)
+	requireError(putACL("", ""), apierrors.ErrMissingSecurityHeader)
+	requireError(putACL("", "<AccessControlPolicy>"), apierrors.ErrMalformedXML)
+	requireError(putACL("", `<AccessControlPolicy><AccessControlList><Grant><Grantee><ID>111122223333</ID></Grantee><Permission>READ</Permission></Grant></AccessControlList></AccessControlPolicy>`),
+		apierrors.ErrNotImplemented)
+	require.Equal(t, map[string][]response.Permission{DefaultTestAccessKey: {"FULL_CONTROL"}, allUsersURI: {"READ", "WRITE"}}, permissions(getACL()))
+
+	result := reqTest(utils.MustNewSignedV4Request(http.MethodGet, "/testbucketaclmissing?acl", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	require.Equal(t, http.StatusNotFound, result.Code)
+}
+
 /*func TestS3_PutBucketHandler(t *testing.T) {
 	u := "http://127.0.0.1:9985/test22"
 
@@ -404,3 +569,437 @@ func TestS3ApiServer_BucketPolicyHandler(t *testing.T) {
 	fmt.Println(res)
 	fmt.Println(string(body))
 }*/
+
+func TestS3ApiServer_BucketVersioningHandler(t *testing.T) {
+	u := "/testbucketversioning"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, u, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+
+	// the versioning has never been enabled, the status is left out
+	reqGet := utils.MustNewSignedV4Request(http.MethodGet, u+"?versioning", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(reqGet)
+	require.Equal(t, http.StatusOK, result.Code)
+	var versioning response.VersioningConfiguration
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &versioning))
+	require.Equal(t, "VersioningConfiguration", versioning.XMLName.Local)
+	require.Empty(t, versioning.Status)
+
+	putVersioning := func(status string) int {
+		config := `<VersioningConfiguration><Status>` + status + `</Status></VersioningConfiguration>`
+		reqPut := utils.MustNewSignedV4Request(http.MethodPut, u+"?versioning", int64(len(config)), strings.NewReader(config),
+			"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		return reqTest(reqPut).Code
+	}
+	for _, status := range []string{"Enabled", "Suspended"} {
+		require.Equal(t, http.StatusOK, putVersioning(status), status)
+		reqGet = utils.MustNewSignedV4Request(http.MethodGet, u+"?versioning", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result = reqTest(reqGet)
+		require.Equal(t, http.StatusOK, result.Code)
+		require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &versioning))
+		require.Equal(t, status, versioning.Status)
+	}
+	// the versioning can't be turned off once enabled
+	require.Equal(t, http.StatusBadRequest, putVersioning(""))
+	require.Equal(t, http.StatusBadRequest, putVersioning("Disabled"))
+
+	reqGet = utils.MustNewSignedV4Request(http.MethodGet, "/testbucketversioningnone?versioning", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNotFound, reqTest(reqGet).Code)
+}
+
+func TestS3ApiServer_BucketInventoryHandler(t *testing.T) {
+	u := "/testbucketinventory"
+	for _, bucket := range []string{u, u + "reports"} {
+		reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, bucket, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+	}
+	putInventory := func(id, config string) int {
+		reqPut := utils.MustNewSignedV4Request(http.MethodPut, u+"?inventory&id="+id, int64(len(config)), strings.NewReader(config),
+			"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		return reqTest(reqPut).Code
+	}
+	inventoryConfig := func(id, destination, format string) string {
+		return `<InventoryConfiguration><Id>` + id + `</Id><IsEnabled>true</IsEnabled>` +
+			`<Destination><S3BucketDestination><Bucket>arn:aws:s3:::` + destination + `</Bucket><Format>` + format + `</Format></S3BucketDestination></Destination>` +
+			`<IncludedObjectVersions>Current</IncludedObjectVersions><OptionalFields><Field>Size</Field></OptionalFields>` +
+			`<Schedule><Frequency>Weekly</Frequency></Schedule></InventoryConfiguration>`
+	}
+
+	reqGet := utils.MustNewSignedV4Request(http.MethodGet, u+"?inventory&id=report", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNotFound, reqTest(reqGet).Code)
+	// the id of the query must be the one of the configuration
+	require.Equal(t, http.StatusBadRequest, putInventory("other", inventoryConfig("report", "testbucketinventoryreports", "CSV")))
+	require.Equal(t, http.StatusBadRequest, putInventory("report", inventoryConfig("report", "testbucketinventoryreports", "Parquet")))
+	require.Equal(t, http.StatusBadRequest, putInventory("report", inventoryConfig("report", "testbucketinventorynone", "CSV")))
+	require.Equal(t, http.StatusOK, putInventory("report", inventoryConfig("report", "testbucketinventoryreports", "CSV")))
+
+	reqGet = utils.MustNewSignedV4Request(http.MethodGet, u+"?inventory&id=report", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(reqGet)
+	require.Equal(t, http.StatusOK, result.Code)
+	var inv store.Inventory
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &inv))
+	require.Equal(t, "report", inv.ID)
+	require.Equal(t, []string{"Size"}, inv.OptionalFields)
+	require.Equal(t, store.InventoryWeekly, inv.Schedule.Frequency)
+
+	reqList := utils.MustNewSignedV4Request(http.MethodGet, u+"?inventory", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqList)
+	require.Equal(t, http.StatusOK, result.Code)
+	var list response.ListInventoryConfigurationsResponse
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &list))
+	require.Len(t, list.InventoryConfigurations, 1)
+	require.Equal(t, "report", list.InventoryConfigurations[0].ID)
+
+	reqDelete := utils.MustNewSignedV4Request(http.MethodDelete, u+"?inventory&id=report", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNoContent, reqTest(reqDelete).Code)
+	reqGet = utils.MustNewSignedV4Request(http.MethodGet, u+"?inventory&id=report", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNotFound, reqTest(reqGet).Code)
+}
+
+func TestS3ApiServer_BucketOverwriteProtectionHandler(t *testing.T) {
+	u := "/testbucketoverwriteprotection"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, u, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+	putObject := func() int {
+		r1 := "1234567"
+		req := utils.MustNewSignedV4Request(http.MethodPut, u+"/object", int64(len(r1)), strings.NewReader(r1), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		return reqTest(req).Code
+	}
+
+	// the overwrite protection is opt-in
+	reqGet := utils.MustNewSignedV4Request(http.MethodGet, u+"?overwrite-protection", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNotFound, reqTest(reqGet).Code)
+	require.Equal(t, http.StatusOK, putObject())
+	require.Equal(t, http.StatusOK, putObject())
+
+	for _, config := range []string{"<OverwriteProtectionConfiguration/>", "<OverwriteProtectionConfiguration><MinimumIntervalSeconds>86401</MinimumIntervalSeconds></OverwriteProtectionConfiguration>"} {
+		reqPut := utils.MustNewSignedV4Request(http.MethodPut, u+"?overwrite-protection", int64(len(config)), strings.NewReader(config),
+			"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		require.Equal(t, http.StatusBadRequest, reqTest(reqPut).Code, config)
+	}
+	config := "<OverwriteProtectionConfiguration><MinimumIntervalSeconds>60</MinimumIntervalSeconds></OverwriteProtectionConfiguration>"
+	reqPut := utils.MustNewSignedV4Request(http.MethodPut, u+"?overwrite-protection", int64(len(config)), strings.NewReader(config),
+		"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPut).Code)
+	reqGet = utils.MustNewSignedV4Request(http.MethodGet, u+"?overwrite-protection", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(reqGet)
+	require.Equal(t, http.StatusOK, result.Code)
+	var op store.OverwriteProtection
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &op))
+	require.Equal(t, 60, op.MinimumIntervalSeconds)
+
+	// the object was just written
+	require.Equal(t, http.StatusConflict, putObject())
+
+	reqDelete := utils.MustNewSignedV4Request(http.MethodDelete, u+"?overwrite-protection", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNoContent, reqTest(reqDelete).Code)
+	require.Equal(t, http.StatusOK, putObject())
+}
+
+func TestS3ApiServer_BucketKeyCaseHandler(t *testing.T) {
+	putObject := func(u string) int {
+		r1 := "1234567"
+		req := utils.MustNewSignedV4Request(http.MethodPut, u, int64(len(r1)), strings.NewReader(r1), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		return reqTest(req).Code
+	}
+	listKeys := func(bucket string) []string {
+		req := utils.MustNewSignedV4Request(http.MethodGet, "/"+bucket+"?list-type=2", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result := reqTest(req)
+		require.Equal(t, http.StatusOK, result.Code)
+		var list response.ListObjectsV2Response
+		require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &list))
+		var keys []string
+		for _, obj := range list.Contents {
+			keys = append(keys, obj.Key)
+		}
+		return keys
+	}
+	putKeyCase := func(bucket, mode string) *httptest.ResponseRecorder {
+		config := "<KeyCaseConfiguration><Mode>" + mode + "</Mode></KeyCaseConfiguration>"
+		req := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucket+"?key-case", int64(len(config)), strings.NewReader(config),
+			"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		return reqTest(req)
+	}
+
+	// the keys are case-sensitive by default
+	sensitive := "testbucketkeycasesensitive"
+	require.Equal(t, http.StatusOK, reqTest(utils.MustNewSignedV4Request(http.MethodPut, "/"+sensitive, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)).Code)
+	reqGet := utils.MustNewSignedV4Request(http.MethodGet, "/"+sensitive+"?key-case", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(reqGet)
+	require.Equal(t, http.StatusOK, result.Code)
+	var kc store.KeyCase
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &kc))
+	require.Equal(t, store.KeyCaseSensitive, kc.Mode)
+	require.Equal(t, http.StatusOK, putObject("/"+sensitive+"/File.txt"))
+	require.Equal(t, http.StatusOK, putObject("/"+sensitive+"/file.txt"))
+	require.Equal(t, []string{"File.txt", "file.txt"}, listKeys(sensitive))
+	// the mode of a bucket holding objects doesn't change
+	require.Equal(t, http.StatusConflict, putKeyCase(sensitive, store.KeyCaseInsensitive).Code)
+
+	insensitive := "testbucketkeycaseinsensitive"
+	require.Equal(t, http.StatusOK, reqTest(utils.MustNewSignedV4Request(http.MethodPut, "/"+insensitive, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)).Code)
+	require.Equal(t, http.StatusBadRequest, putKeyCase(insensitive, "Insensitive").Code)
+	require.Equal(t, http.StatusOK, putKeyCase(insensitive, store.KeyCaseInsensitive).Code)
+	require.Equal(t, http.StatusOK, putObject("/"+insensitive+"/File.txt"))
+	require.Equal(t, http.StatusOK, putObject("/"+insensitive+"/file.txt"))
+	require.Equal(t, []string{"file.txt"}, listKeys(insensitive))
+	reqHead := utils.MustNewSignedV4Request(http.MethodHead, "/"+insensitive+"/FILE.TXT", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqHead).Code)
+	reqDelete := utils.MustNewSignedV4Request(http.MethodDelete, "/"+insensitive+"/fILE.txt", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNoContent, reqTest(reqDelete).Code)
+	require.Empty(t, listKeys(insensitive))
+
+	// a user granted every action on the bucket by its policy, the versioning included, can't read or set the mode
+	denied := "testbucketkeycasedenied"
+	require.Equal(t, http.StatusOK, reqTest(utils.MustNewSignedV4Request(http.MethodPut, "/"+denied, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)).Code)
+	require.NoError(t, authSys.Iam.AddUser(context.TODO(), "keycaseuser", "keycasesecret"))
+	p := `{"Version":"2008-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["keycaseuser"]},"Action":["s3:*"],` +
+		`"Resource":["arn:aws:s3:::` + denied + `","arn:aws:s3:::` + denied + `/*"]}]}`
+	reqPutPolicy := utils.MustNewSignedV4Request(http.MethodPut, "/"+denied+"?policy", int64(len(p)), strings.NewReader(p), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNoContent, reqTest(reqPutPolicy).Code)
+	result = reqTest(utils.MustNewSignedV4Request(http.MethodGet, "/"+denied+"?versioning", 0, nil, "s3", "keycaseuser", "keycasesecret", t))
+	require.Equal(t, http.StatusOK, result.Code, result.Body.String())
+	config := "<KeyCaseConfiguration><Mode>" + store.KeyCaseInsensitive + "</Mode></KeyCaseConfiguration>"
+	reqPut := utils.MustNewSignedV4Request(http.MethodPut, "/"+denied+"?key-case", int64(len(config)), strings.NewReader(config), "s3", "keycaseuser", "keycasesecret", t)
+	require.Equal(t, http.StatusForbidden, reqTest(reqPut).Code)
+	reqGet = utils.MustNewSignedV4Request(http.MethodGet, "/"+denied+"?key-case", 0, nil, "s3", "keycaseuser", "keycasesecret", t)
+	require.Equal(t, http.StatusForbidden, reqTest(reqGet).Code)
+	deniedKeyCase, err := bmSys.GetBucketKeyCase(context.TODO(), denied)
+	require.NoError(t, err)
+	require.Equal(t, store.KeyCaseSensitive, deniedKeyCase.Mode)
+}
+
+func TestS3ApiServer_BucketTaggingHandler(t *testing.T) {
+	u := "/testbuckettagging"
+	require.Equal(t, http.StatusOK, reqTest(utils.MustNewSignedV4Request(http.MethodPut, u, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)).Code)
+	putTagging := func(body string) *httptest.ResponseRecorder {
+		req := utils.MustNewSignedV4Request(http.MethodPut, u+"?tagging", int64(len(body)), strings.NewReader(body),
+			"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		return reqTest(req)
+	}
+	getTagging := func() *httptest.ResponseRecorder {
+		return reqTest(utils.MustNewSignedV4Request(http.MethodGet, u+"?tagging", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	}
+	requireError := func(result *httptest.ResponseRecorder, code apierrors.ErrorCode) {
+		apiErr := apierrors.GetAPIError(code)
+		require.Equal(t, apiErr.HTTPStatusCode, result.Code)
+		var errResp response.APIErrorResponse
+		require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &errResp))
+		require.Equal(t, apiErr.Code, errResp.Code)
+	}
+
+	requireError(getTagging(), apierrors.ErrBucketTaggingNotFound)
+
+	p := `{"Version":"2008-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["` + DefaultTestAccessKey + `"]},"Action":["s3:*"],"Resource":"arn:aws:s3:::testbuckettagging/*"},` +
+		`{"Effect":"Allow","Principal":{"AWS":["111122223333"]},"Action":["s3:GetObject"],"Resource":"arn:aws:s3:::testbuckettagging/*"}]}`
+	reqPutPolicy := utils.MustNewSignedV4Request(http.MethodPut, u+"?policy", int64(len(p)), strings.NewReader(p), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNoContent, reqTest(reqPutPolicy).Code)
+
+	require.Equal(t, http.StatusOK, putTagging(`<Tagging><TagSet><Tag><Key>project</Key><Value>filedag</Value></Tag><Tag><Key>env</Key><Value>test</Value></Tag></TagSet></Tagging>`).Code)
+	result := getTagging()
+	require.Equal(t, http.StatusOK, result.Code)
+	var tags store.Tags
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &tags))
+	require.Equal(t, map[string]string{"project": "filedag", "env": "test"}, tags.TagSet.TagMap)
+
+	// the tags breaking the limits are rejected and the stored tags are kept
+	requireError(putTagging(`<Tagging><TagSet><Tag><Key>`+strings.Repeat("k", 129)+`</Key><Value>v</Value></Tag></TagSet></Tagging>`), apierrors.ErrInvalidTag)
+	requireError(putTagging(`<Tagging><TagSet><Tag><Key>k</Key><Value>`+strings.Repeat("v", 257)+`</Value></Tag></TagSet></Tagging>`), apierrors.ErrInvalidTag)
+	var tooMany strings.Builder
+	for i := 0; i < 51; i++ {
+		tooMany.WriteString(fmt.Sprintf("<Tag><Key>k%d</Key><Value>v</Value></Tag>", i))
+	}
+	requireError(putTagging("<Tagging><TagSet>"+tooMany.String()+"</TagSet></Tagging>"), apierrors.ErrInvalidTag)
+	requireError(putTagging("<Tagging><TagSet>"), apierrors.ErrMalformedXML)
+	require.Equal(t, http.StatusOK, getTagging().Code)
+
+	reqDel := utils.MustNewSignedV4Request(http.MethodDelete, u+"?tagging", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNoContent, reqTest(reqDel).Code)
+	requireError(getTagging(), apierrors.ErrBucketTaggingNotFound)
+
+	// the policy of the bucket survives the deletion of the tags
+	reqGetPolicy := utils.MustNewSignedV4Request(http.MethodGet, u+"?policy", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqGetPolicy)
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Contains(t, result.Body.String(), "111122223333")
+}
+
+func TestS3ApiServer_BucketLifecycleHandler(t *testing.T) {
+	u := "/testbucketlifecycle"
+	require.Equal(t, http.StatusOK, reqTest(utils.MustNewSignedV4Request(http.MethodPut, u, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)).Code)
+	putLifecycle := func(body string) *httptest.ResponseRecorder {
+		req := utils.MustNewSignedV4Request(http.MethodPut, u+"?lifecycle", int64(len(body)), strings.NewReader(body),
+			"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		return reqTest(req)
+	}
+	getLifecycle := func() *httptest.ResponseRecorder {
+		return reqTest(utils.MustNewSignedV4Request(http.MethodGet, u+"?lifecycle", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	}
+	requireError := func(result *httptest.ResponseRecorder, code apierrors.ErrorCode) {
+		apiErr := apierrors.GetAPIError(code)
+		require.Equal(t, apiErr.HTTPStatusCode, result.Code)
+		var errResp response.APIErrorResponse
+		require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &errResp))
+		require.Equal(t, apiErr.Code, errResp.Code)
+	}
+
+	requireError(getLifecycle(), apierrors.ErrNoSuchLifecycleConfiguration)
+
+	require.Equal(t, http.StatusOK, putLifecycle(`<LifecycleConfiguration>`+
+		`<Rule><ID>logs</ID><Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter><Expiration><Days>7</Days></Expiration></Rule>`+
+		`<Rule><ID>temp</ID><Status>Enabled</Status><Filter><And><Prefix>tmp/</Prefix><Tag><Key>temp</Key><Value>true</Value></Tag></And></Filter>`+
+		`<Expiration><Date>2030-01-01T00:00:00Z</Date></Expiration></Rule>`+
+		`</LifecycleConfiguration>`).Code)
+	result := getLifecycle()
+	require.Equal(t, http.StatusOK, result.Code)
+	var lc store.Lifecycle
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &lc))
+	require.Len(t, lc.Rules, 2)
+	require.Equal(t, 7, lc.Rules[0].Expiration.Days)
+	require.Equal(t, "logs/", lc.Rules[0].Filter.Prefix)
+	require.Equal(t, []store.Tag{{Key: "temp", Value: "true"}}, lc.Rules[1].Filter.And.Tags)
+	require.NoError(t, lc.Validate())
+
+	// the invalid configurations are rejected and the stored one is kept
+	requireError(putLifecycle(`<LifecycleConfiguration><Rule><Status>Enabled</Status></Rule></LifecycleConfiguration>`), apierrors.ErrMalformedXML)
+	requireError(putLifecycle(`<LifecycleConfiguration><Rule><Status>On</Status><Expiration><Days>1</Days></Expiration></Rule></LifecycleConfiguration>`), apierrors.ErrMalformedXML)
+	requireError(putLifecycle(`<LifecycleConfiguration><Rule><Status>Enabled</Status><Expiration><Days>1</Days>`+
+		`<Date>2030-01-01T00:00:00Z</Date></Expiration></Rule></LifecycleConfiguration>`), apierrors.ErrMalformedXML)
+	requireError(putLifecycle(`<LifecycleConfiguration><Rule>`), apierrors.ErrMalformedXML)
+	require.Equal(t, http.StatusOK, getLifecycle().Code)
+
+	reqDel := utils.MustNewSignedV4Request(http.MethodDelete, u+"?lifecycle", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNoContent, reqTest(reqDel).Code)
+	requireError(getLifecycle(), apierrors.ErrNoSuchLifecycleConfiguration)
+}
+
+func TestS3ApiServer_BucketCorsHandler(t *testing.T) {
+	u := "/testbucketcors"
+	require.Equal(t, http.StatusOK, reqTest(utils.MustNewSignedV4Request(http.MethodPut, u, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)).Code)
+	putCors := func(body string) *httptest.ResponseRecorder {
+		req := utils.MustNewSignedV4Request(http.MethodPut, u+"?cors", int64(len(body)), strings.NewReader(body),
+			"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		return reqTest(req)
+	}
+	getCors := func() *httptest.ResponseRecorder {
+		return reqTest(utils.MustNewSignedV4Request(http.MethodGet, u+"?cors", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	}
+	requireError := func(result *httptest.ResponseRecorder, code apierrors.ErrorCode) {
+		apiErr := apierrors.GetAPIError(code)
+		require.Equal(t, apiErr.HTTPStatusCode, result.Code)
+		var errResp response.APIErrorResponse
+		require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &errResp))
+		require.Equal(t, apiErr.Code, errResp.Code)
+	}
+	corsHandler := CorsHandler(router, bmSys)
+	preflight := func(path, origin, method, headers string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set(consts.Origin, origin)
+		req.Header.Set(consts.AccessControlRequestMethod, method)
+		if headers != "" {
+			req.Header.Set(consts.AccessControlRequestHeaders, headers)
+		}
+		result := httptest.NewRecorder()
+		corsHandler.ServeHTTP(result, req)
+		return result
+	}
+
+	requireError(getCors(), apierrors.ErrNoSuchCORSConfiguration)
+
+	// any origin is allowed to access a bucket without a CORS configuration
+	result := preflight(u+"/object", "http://other.org", http.MethodGet, "")
+	require.Equal(t, http.StatusNoContent, result.Code)
+	require.Equal(t, "http://other.org", result.Header().Get(consts.AccessControlAllowOrigin))
+
+	require.Equal(t, http.StatusOK, putCors(`<CORSConfiguration>`+
+		`<CORSRule><ID>site</ID><AllowedOrigin>http://*.example.com</AllowedOrigin><AllowedMethod>GET</AllowedMethod><AllowedMethod>PUT</AllowedMethod>`+
+		`<AllowedHeader>x-amz-*</AllowedHeader><AllowedHeader>Content-Type</AllowedHeader><ExposeHeader>ETag</ExposeHeader><MaxAgeSeconds>3000</MaxAgeSeconds></CORSRule>`+
+		`<CORSRule><AllowedOrigin>*</AllowedOrigin><AllowedMethod>GET</AllowedMethod><AllowedHeader>*</AllowedHeader></CORSRule>`+
+		`</CORSConfiguration>`).Code)
+	result = getCors()
+	require.Equal(t, http.StatusOK, result.Code)
+	var cors store.Cors
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &cors))
+	require.Len(t, cors.CorsRules, 2)
+	require.Equal(t, "site", cors.CorsRules[0].ID)
+	require.Equal(t, []string{"http://*.example.com"}, cors.CorsRules[0].AllowedOrigins)
+	require.Equal(t, 3000, *cors.CorsRules[0].MaxAgeSeconds)
+
+	// the wildcard origin with the allowed headers
+	result = preflight(u+"/object", "http://www.example.com", http.MethodPut, "X-Amz-Meta-Name, content-type")
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, "http://www.example.com", result.Header().Get(consts.AccessControlAllowOrigin))
+	require.Equal(t, "true", result.Header().Get(consts.AccessControlAllowCredentials))
+	require.Equal(t, "GET, PUT", result.Header().Get(consts.AccessControlAllowMethods))
+	require.Equal(t, "X-Amz-Meta-Name, content-type", result.Header().Get(consts.AccessControlAllowHeaders))
+	require.Equal(t, "ETag", result.Header().Get(consts.AccessControlExposeHeaders))
+	require.Equal(t, "3000", result.Header().Get(consts.AccessControlMaxAge))
+
+	// a header the first rule doesn't allow falls to the rule allowing any origin and any header
+	result = preflight(u+"/object", "http://www.example.com", http.MethodGet, "Authorization")
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, "*", result.Header().Get(consts.AccessControlAllowOrigin))
+	require.Empty(t, result.Header().Get(consts.AccessControlAllowCredentials))
+	require.Equal(t, "Authorization", result.Header().Get(consts.AccessControlAllowHeaders))
+	require.Empty(t, result.Header().Get(consts.AccessControlMaxAge))
+
+	// no rule allows the method or the header to the origin
+	requireError(preflight(u+"/object", "http://other.org", http.MethodPut, ""), apierrors.ErrCORSAccessForbidden)
+	requireError(preflight(u+"/object", "http://www.example.com", http.MethodPut, "Authorization"), apierrors.ErrCORSAccessForbidden)
+	requireError(preflight(u+"/object", "https://www.example.com", http.MethodPut, ""), apierrors.ErrCORSAccessForbidden)
+
+	// the actual requests get the allowed origin of the matching rule
+	req := utils.MustNewSignedV4Request(http.MethodGet, u+"?cors", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	req.Header.Set(consts.Origin, "http://www.example.com")
+	result = httptest.NewRecorder()
+	corsHandler.ServeHTTP(result, req)
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, "http://www.example.com", result.Header().Get(consts.AccessControlAllowOrigin))
+	require.Equal(t, "ETag", result.Header().Get(consts.AccessControlExposeHeaders))
+	req = utils.MustNewSignedV4Request(http.MethodDelete, u+"/object", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	req.Header.Set(consts.Origin, "http://www.example.com")
+	result = httptest.NewRecorder()
+	corsHandler.ServeHTTP(result, req)
+	require.Empty(t, result.Header().Get(consts.AccessControlAllowOrigin))
+
+	// the invalid configurations are rejected and the stored one is kept
+	requireError(putCors(`<CORSConfiguration></CORSConfiguration>`), apierrors.ErrMalformedXML)
+	requireError(putCors(`<CORSConfiguration><CORSRule><AllowedOrigin>*</AllowedOrigin><AllowedMethod>PATCH</AllowedMethod></CORSRule></CORSConfiguration>`), apierrors.ErrMalformedXML)
+	requireError(putCors(`<CORSConfiguration><CORSRule><AllowedOrigin>http://*.*.com</AllowedOrigin><AllowedMethod>GET</AllowedMethod></CORSRule></CORSConfiguration>`), apierrors.ErrMalformedXML)
+	requireError(putCors(`<CORSConfiguration><CORSRule><AllowedMethod>GET</AllowedMethod></CORSRule></CORSConfiguration>`), apierrors.ErrMalformedXML)
+	requireError(putCors(`<CORSConfiguration><CORSRule>`), apierrors.ErrMalformedXML)
+	require.Equal(t, http.StatusOK, getCors().Code)
+
+	reqDel := utils.MustNewSignedV4Request(http.MethodDelete, u+"?cors", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNoContent, reqTest(reqDel).Code)
+	requireError(getCors(), apierrors.ErrNoSuchCORSConfiguration)
+	result = preflight(u+"/object", "http://other.org", http.MethodPut, "")
+	require.Equal(t, http.StatusNoContent, result.Code)
+	require.Equal(t, "http://other.org", result.Header().Get(consts.AccessControlAllowOrigin))
+}
+
+func TestS3ApiServer_PutBucketTooManyBuckets(t *testing.T) {
+	ctx := context.TODO()
+	buckets, err := bmSys.GetAllBucketsOfUser(ctx, DefaultTestAccessKey)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, bmSys.ResetUserBucketLimit(ctx, DefaultTestAccessKey))
+	}()
+	putBucket := func(bucket string) *httptest.ResponseRecorder {
+		return reqTest(utils.MustNewSignedV4Request(http.MethodPut, "/"+bucket, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	}
+
+	require.NoError(t, bmSys.UpdateUserBucketLimit(ctx, DefaultTestAccessKey, len(buckets)+1))
+	require.Equal(t, http.StatusOK, putBucket("testbucketlimit1").Code)
+	result := putBucket("testbucketlimit2")
+	apiErr := apierrors.GetAPIError(apierrors.ErrTooManyBuckets)
+	require.Equal(t, apiErr.HTTPStatusCode, result.Code)
+	var errResp response.APIErrorResponse
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &errResp))
+	require.Equal(t, apiErr.Code, errResp.Code)
+	reqHead := utils.MustNewSignedV4Request(http.MethodHead, "/testbucketlimit2", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNotFound, reqTest(reqHead).Code)
+
+	// the bucket is created once the limit is raised
+	require.NoError(t, bmSys.UpdateUserBucketLimit(ctx, DefaultTestAccessKey, len(buckets)+2))
+	require.Equal(t, http.StatusOK, putBucket("testbucketlimit2").Code)
+}
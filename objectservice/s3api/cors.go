@@ -0,0 +1,83 @@
+package s3api
+
+import (
+	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
+	"github.com/filedag-project/filedag-storage/objectservice/consts"
+	"github.com/filedag-project/filedag-storage/objectservice/response"
+	"github.com/filedag-project/filedag-storage/objectservice/store"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// corsBucket returns the bucket of the path of the request, it's empty for the service requests
+func corsBucket(r *http.Request) string {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if i := strings.Index(path, "/"); i >= 0 {
+		path = path[:i]
+	}
+	return path
+}
+
+// isPreflight reports whether the request is a CORS preflight request
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions && r.Header.Get(consts.AccessControlRequestMethod) != ""
+}
+
+// preflightHeaders returns the headers the preflight request asks to send
+func preflightHeaders(r *http.Request) []string {
+	var headers []string
+	for _, value := range r.Header.Values(consts.AccessControlRequestHeaders) {
+		for _, header := range strings.Split(value, ",") {
+			if header = strings.TrimSpace(header); header != "" {
+				headers = append(headers, header)
+			}
+		}
+	}
+	return headers
+}
+
+// writeCorsPreflight answers the preflight request by the CORS configuration of the bucket,
+// it's forbidden if no rule allows the origin, the method and the headers.
+func writeCorsPreflight(w http.ResponseWriter, r *http.Request, cors *store.Cors) {
+	origin := r.Header.Get(consts.Origin)
+	headers := preflightHeaders(r)
+	w.Header().Add(consts.Vary, consts.Origin)
+	w.Header().Add(consts.Vary, consts.AccessControlRequestMethod)
+	w.Header().Add(consts.Vary, consts.AccessControlRequestHeaders)
+	rule := cors.MatchRule(origin, r.Header.Get(consts.AccessControlRequestMethod), headers)
+	if rule == nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrCORSAccessForbidden)
+		return
+	}
+	setCorsHeaders(w, origin, rule)
+	if len(headers) > 0 {
+		w.Header().Set(consts.AccessControlAllowHeaders, strings.Join(headers, ", "))
+	}
+	if rule.MaxAgeSeconds != nil {
+		w.Header().Set(consts.AccessControlMaxAge, strconv.Itoa(*rule.MaxAgeSeconds))
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// setCorsHeaders sets the headers of the rule allowing the origin, the response is shared with
+// any origin if the rule allows them all, otherwise with the origin only and with its credentials.
+func setCorsHeaders(w http.ResponseWriter, origin string, rule *store.CorsRule) {
+	allowAll := false
+	for _, allowed := range rule.AllowedOrigins {
+		if allowed == "*" {
+			allowAll = true
+			break
+		}
+	}
+	if allowAll {
+		w.Header().Set(consts.AccessControlAllowOrigin, "*")
+	} else {
+		w.Header().Set(consts.AccessControlAllowOrigin, origin)
+		w.Header().Set(consts.AccessControlAllowCredentials, "true")
+	}
+	w.Header().Set(consts.AccessControlAllowMethods, strings.Join(rule.AllowedMethods, ", "))
+	if len(rule.ExposeHeaders) > 0 {
+		w.Header().Set(consts.AccessControlExposeHeaders, strings.Join(rule.ExposeHeaders, ", "))
+	}
+}
@@ -3,10 +3,20 @@ package s3api
 import (
 	"context"
 	"errors"
+	"fmt"
+	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
 	"github.com/filedag-project/filedag-storage/objectservice/consts"
 	"github.com/filedag-project/filedag-storage/objectservice/iam"
+	"github.com/filedag-project/filedag-storage/objectservice/store"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/s3utils"
+	"github.com/google/uuid"
+	"io"
 	"net/http"
 	"net/textproto"
+	"net/url"
+	"path"
+	"strconv"
 	"strings"
 )
 
@@ -20,7 +30,9 @@ var supportedHeaders = []string{
 	consts.ContentLength,
 	consts.ContentEncoding,
 	consts.ContentDisposition,
+	consts.ContentLanguage,
 	consts.AmzStorageClass,
+	consts.AmzWebsiteRedirectLocation,
 	consts.AmzObjectTagging,
 	consts.Expires,
 	consts.AmzBucketReplicationStatus,
@@ -34,6 +46,73 @@ var userMetadataKeyPrefixes = []string{
 	"x-amz-meta-",
 }
 
+// sseCustomerHeaders are the headers of SSE-C requests, both of the object
+// itself and of the copy source.
+var sseCustomerHeaders = []string{
+	consts.AmzServerSideEncryptionCustomerAlgorithm,
+	consts.AmzServerSideEncryptionCustomerKey,
+	consts.AmzServerSideEncryptionCustomerKeyMD5,
+	consts.AmzServerSideEncryptionCopyCustomerAlgorithm,
+	consts.AmzServerSideEncryptionCopyCustomerKey,
+	consts.AmzServerSideEncryptionCopyCustomerKeyMD5,
+}
+
+// isChunkedTransfer reports whether the body of the request is sent with Transfer-Encoding: chunked
+// and without a Content-Length, its size is only known once it's read.
+func isChunkedTransfer(r *http.Request) bool {
+	return r.ContentLength == -1 && len(r.TransferEncoding) > 0 && r.TransferEncoding[0] == "chunked"
+}
+
+// maxChunkedObjectSize the limit of an object sent with the chunked transfer encoding, it's
+// consts.MaxObjectSize except in the tests
+var maxChunkedObjectSize int64 = consts.MaxObjectSize
+
+// objectSizeLimiter reads a body of unknown size, it fails with ObjectTooLarge
+// once more than the remaining bytes are read.
+type objectSizeLimiter struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (l *objectSizeLimiter) Read(p []byte) (int, error) {
+	n, err := l.reader.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, s3utils.ObjectTooLarge{}
+	}
+	return n, err
+}
+
+// isValidUserMetadataSize returns false if the user-defined metadata, the keys with
+// the x-amz-meta- prefix and their values, is larger than 2KB.
+func isValidUserMetadataSize(metadata map[string]string) bool {
+	size := 0
+	for k, v := range metadata {
+		if strings.HasPrefix(strings.ToLower(k), strings.ToLower(consts.AmzUserMetadataPrefix)) {
+			size += len(k) + len(v)
+		}
+	}
+	return size <= consts.MaxUserMetadataSize
+}
+
+// isValidWebsiteRedirectLocation returns false if the website redirect location of the metadata
+// is neither a path of the bucket nor an http(s) URL.
+func isValidWebsiteRedirectLocation(metadata map[string]string) bool {
+	location, ok := metadata[strings.ToLower(consts.AmzWebsiteRedirectLocation)]
+	return !ok || strings.HasPrefix(location, "/") ||
+		strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// isSSECustomerRequest returns true if the request carries any SSE-C header.
+func isSSECustomerRequest(header http.Header) bool {
+	for _, h := range sseCustomerHeaders {
+		if _, ok := header[http.CanonicalHeaderKey(h)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 // matches k1 with all keys, returns 'true' if one of them matches
 func equals(k1 string, keys ...string) bool {
 	for _, k2 := range keys {
@@ -132,3 +211,139 @@ func extractMetadataFromMime(ctx context.Context, v textproto.MIMEHeader, m map[
 	}
 	return nil
 }
+
+// getObjectLocation returns the URL of the object as seen by the client
+func getObjectLocation(r *http.Request, bucket, object string) string {
+	u := &url.URL{
+		Scheme: "http",
+		Host:   r.Host,
+		Path:   path.Join(consts.SlashSeparator, bucket, object),
+	}
+	if r.TLS != nil {
+		u.Scheme = "https"
+	}
+	return u.String()
+}
+
+// getVersionID returns the versionId query parameter of an object request, empty if it isn't set.
+// A version ID is either a UUID or the null version ID.
+func getVersionID(r *http.Request) (string, apierrors.ErrorCode) {
+	versionID := r.Form.Get(consts.VersionID)
+	if versionID == "" {
+		return versionID, apierrors.ErrNone
+	}
+	if !isValidVersionID(versionID) {
+		return "", apierrors.ErrInvalidVersionID
+	}
+	return versionID, apierrors.ErrNone
+}
+
+func isValidVersionID(versionID string) bool {
+	if versionID == store.NullVersionID {
+		return true
+	}
+	_, err := uuid.Parse(versionID)
+	return err == nil
+}
+
+// getCopySource returns the source bucket, object and version ID of the x-amz-copy-source header,
+// the escaped path of the source optionally followed by ?versionId=, the version ID is empty to
+// copy the current object.
+func getCopySource(r *http.Request) (bucket, object, versionID string, errCode apierrors.ErrorCode) {
+	cpSrc := r.Header.Get(consts.AmzCopySource)
+	if i := strings.Index(cpSrc, "?"); i >= 0 {
+		query, err := url.ParseQuery(cpSrc[i+1:])
+		if err != nil {
+			return "", "", "", apierrors.ErrInvalidCopySource
+		}
+		if _, ok := query[consts.VersionID]; ok {
+			versionID = query.Get(consts.VersionID)
+			if !isValidVersionID(versionID) {
+				return "", "", "", apierrors.ErrInvalidVersionID
+			}
+		}
+		cpSrc = cpSrc[:i]
+	}
+	cpSrcPath, err := unescapePath(cpSrc)
+	if err != nil {
+		// Save unescaped string as is.
+		cpSrcPath = cpSrc
+	}
+	bucket, object = pathToBucketAndObject(cpSrcPath)
+	// If source object is empty or bucket is empty, reply back invalid copy source.
+	if object == "" || bucket == "" {
+		return "", "", "", apierrors.ErrInvalidCopySource
+	}
+	return bucket, object, versionID, apierrors.ErrNone
+}
+
+// getPartNumber returns the partNumber query parameter of a GET or HEAD object request,
+// 0 if it isn't set. It can't be used together with a Range header.
+func getPartNumber(r *http.Request) (int, apierrors.ErrorCode) {
+	partNumberString := r.Form.Get(consts.PartNumber)
+	if partNumberString == "" {
+		return 0, apierrors.ErrNone
+	}
+	if r.Header.Get(consts.Range) != "" {
+		return 0, apierrors.ErrInvalidRangePartNumber
+	}
+	partNumber, err := strconv.Atoi(partNumberString)
+	if err != nil || partNumber < 1 {
+		return 0, apierrors.ErrInvalidPart
+	}
+	if partNumber > consts.MaxPartID {
+		return 0, apierrors.ErrInvalidMaxParts
+	}
+	return partNumber, apierrors.ErrNone
+}
+
+// setPartHeaders replaces the length of the object in the headers with the part's one,
+// and returns the status of the response to the request of the part
+func setPartHeaders(w http.ResponseWriter, objInfo store.ObjectInfo, part store.ObjectPartInfo) int {
+	w.Header().Set(consts.ContentLength, strconv.FormatInt(part.Size, 10))
+	if part.Size == 0 {
+		// an empty object has no range to report
+		return http.StatusOK
+	}
+	offset := objInfo.PartOffset(part.Number)
+	w.Header().Set(consts.ContentRange, fmt.Sprintf("bytes %d-%d/%d", offset, offset+part.Size-1, objInfo.Size))
+	return http.StatusPartialContent
+}
+
+// contentChecksums the x-amz-checksum- values an upload can be verified by, with their algorithms
+var contentChecksums = []struct {
+	algorithm string
+	header    string
+}{
+	{hash.ChecksumCRC32, consts.AmzChecksumCRC32},
+	{hash.ChecksumSHA256, consts.AmzChecksumSHA256},
+}
+
+// setContentChecksum sets the x-amz-checksum- value of the upload on the reader of its content, the value
+// is a header, or a trailer of the chunked body named by X-Amz-Trailer which is known once the body is read.
+// An upload is verified by one checksum at most.
+func setContentChecksum(r *http.Request, reader *hash.Reader) apierrors.ErrorCode {
+	set := false
+	for _, c := range contentChecksums {
+		var expected func() string
+		if value := r.Header.Get(c.header); value != "" {
+			if hash.CheckChecksum(c.algorithm, value) != nil {
+				return apierrors.ErrInvalidDigest
+			}
+			expected = func() string { return value }
+		} else if strings.EqualFold(r.Header.Get(consts.AmzTrailer), c.header) {
+			header := c.header
+			expected = func() string { return r.Trailer.Get(header) }
+		} else {
+			continue
+		}
+		if set {
+			return apierrors.ErrInvalidRequest
+		}
+		set = true
+		if err := reader.SetChecksum(c.algorithm, expected); err != nil {
+			return apierrors.ErrInternalError
+		}
+	}
+	return apierrors.ErrNone
+}
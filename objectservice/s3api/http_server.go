@@ -0,0 +1,42 @@
+package s3api
+
+import (
+	"net/http"
+	"time"
+)
+
+// ServerConfig the timeouts of the HTTP server of the gateway, zero means no timeout.
+type ServerConfig struct {
+	// ReadHeaderTimeout the time to read the request headers, it ends the stalled connections
+	ReadHeaderTimeout time.Duration
+	// ReadTimeout the time to read the whole request including the body,
+	// it limits the duration of the uploads
+	ReadTimeout time.Duration
+	// WriteTimeout the time from the end of the request headers to the end of the response,
+	// it limits the duration of the downloads
+	WriteTimeout time.Duration
+	// IdleTimeout the time to wait for the next request on a keep-alive connection
+	IdleTimeout time.Duration
+}
+
+// DefaultServerConfig returns the default timeouts, the headers must be sent in time
+// but the bodies of the requests and responses are not limited, so that the long
+// uploads and downloads are not killed.
+func DefaultServerConfig() ServerConfig {
+	return ServerConfig{
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+}
+
+// NewHTTPServer returns the HTTP server of the gateway with the timeouts of the config
+func NewHTTPServer(addr string, handler http.Handler, cfg ServerConfig) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
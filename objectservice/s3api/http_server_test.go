@@ -0,0 +1,44 @@
+package s3api
+
+import (
+	"github.com/stretchr/testify/require"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPServer_ReadHeaderTimeout(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	cfg := DefaultServerConfig()
+	cfg.ReadHeaderTimeout = 200 * time.Millisecond
+	server := NewHTTPServer(lis.Addr().String(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a slow handler isn't limited by the header timeout
+		time.Sleep(2 * cfg.ReadHeaderTimeout)
+		w.WriteHeader(http.StatusOK)
+	}), cfg)
+	go server.Serve(lis)
+	defer server.Close()
+
+	// the headers are never finished
+	conn, err := net.Dial("tcp", lis.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("GET /status HTTP/1.1\r\nHost: localhost\r\n"))
+	require.NoError(t, err)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	start := time.Now()
+	_, err = ioutil.ReadAll(conn)
+	require.NoError(t, err, "the stalled connection should be closed by the server")
+	require.Less(t, time.Since(start), 2*time.Second)
+
+	// a complete request is served
+	resp, err := http.Get("http://" + lis.Addr().String() + "/status")
+	require.NoError(t, err)
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
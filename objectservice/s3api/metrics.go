@@ -0,0 +1,152 @@
+package s3api
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"io"
+	"net/http"
+	"sync"
+)
+
+const (
+	// maxBucketLabels the number of buckets labeled by their name, the other buckets are
+	// labeled by otherBucketLabel so that the cardinality of the metrics is bounded
+	maxBucketLabels  = 100
+	otherBucketLabel = "_other"
+)
+
+// objectBytesBuckets from 1KiB to 1GiB
+var objectBytesBuckets = prometheus.ExponentialBuckets(1024, 4, 11)
+
+// The sizes of the bodies of the successful object requests by operation and bucket,
+// the sums of the histograms rank the buckets by traffic.
+var (
+	objectRequestBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "filedag",
+		Subsystem: "s3",
+		Name:      "object_request_bytes",
+		Help:      "The size of the request body of the object requests",
+		Buckets:   objectBytesBuckets,
+	}, []string{"operation", "bucket"})
+	objectResponseBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "filedag",
+		Subsystem: "s3",
+		Name:      "object_response_bytes",
+		Help:      "The size of the response body of the object requests",
+		Buckets:   objectBytesBuckets,
+	}, []string{"operation", "bucket"})
+)
+
+// The requests by the principal who sent them, the anonymous requests are labeled by the anonymous
+// principal and the requests which fail the authentication by unauthenticatedPrincipal.
+var requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "filedag",
+	Subsystem: "s3",
+	Name:      "requests_total",
+	Help:      "The number of the requests by the principal and the response status",
+}, []string{"principal", "status"})
+
+// The requests of the buckets answered with SlowDown, they waited too long for a slot of the request limiter.
+var requestsThrottled = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "filedag",
+	Subsystem: "s3",
+	Name:      "requests_throttled_total",
+	Help:      "The number of the requests of the bucket which waited too long for a slot",
+}, []string{"bucket"})
+
+func init() {
+	prometheus.MustRegister(objectRequestBytes, objectResponseBytes, requestsTotal, requestsThrottled)
+}
+
+var globalBucketLabels = newBucketLabels(maxBucketLabels)
+
+// globalPrincipalLabels bounds the principal label like the bucket label
+var globalPrincipalLabels = newBucketLabels(maxBucketLabels)
+
+// bucketLabels bounds the bucket label, the first max buckets seen keep their name
+type bucketLabels struct {
+	mu     sync.Mutex
+	max    int
+	labels map[string]struct{}
+}
+
+func newBucketLabels(max int) *bucketLabels {
+	return &bucketLabels{
+		max:    max,
+		labels: make(map[string]struct{}),
+	}
+}
+
+func (b *bucketLabels) label(bucket string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.labels[bucket]; ok {
+		return bucket
+	}
+	if len(b.labels) >= b.max {
+		return otherBucketLabel
+	}
+	b.labels[bucket] = struct{}{}
+	return bucket
+}
+
+// countingReadCloser counts the bytes read from the request body
+type countingReadCloser struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// countingResponseWriter counts the bytes of the response body and keeps the status code
+type countingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	n      int64
+}
+
+func (c *countingResponseWriter) WriteHeader(status int) {
+	if c.status == 0 {
+		c.status = status
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *countingResponseWriter) Write(p []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	n, err := c.ResponseWriter.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Flush keeps the streaming of the responses
+func (c *countingResponseWriter) Flush() {
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// objectMetricsHandler records the sizes of the request and response bodies of the successful requests of the operation
+func objectMetricsHandler(operation string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body := &countingReadCloser{ReadCloser: r.Body}
+		r.Body = body
+		cw := &countingResponseWriter{ResponseWriter: w}
+		h(cw, r)
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		if cw.status < http.StatusOK || cw.status >= http.StatusMultipleChoices {
+			return
+		}
+		label := globalBucketLabels.label(mux.Vars(r)["bucket"])
+		objectRequestBytes.WithLabelValues(operation, label).Observe(float64(body.n))
+		objectResponseBytes.WithLabelValues(operation, label).Observe(float64(cw.n))
+	}
+}
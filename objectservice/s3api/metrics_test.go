@@ -0,0 +1,58 @@
+package s3api
+
+import (
+	"bytes"
+	"github.com/filedag-project/filedag-storage/objectservice/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+// histogramOf returns the sample count and sum of the histogram of the labels
+func histogramOf(t *testing.T, vec *prometheus.HistogramVec, operation, bucket string) (uint64, float64) {
+	m := &dto.Metric{}
+	require.NoError(t, vec.WithLabelValues(operation, bucket).(prometheus.Histogram).Write(m))
+	return m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+}
+
+func TestObjectMetricsHandler(t *testing.T) {
+	bucketName := "testbucketmetrics"
+	objectName := "testobjectmetrics"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+
+	data := bytes.Repeat([]byte("a"), 5000)
+	reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+objectName, int64(len(data)), bytes.NewReader(data), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutObject).Code)
+	count, sum := histogramOf(t, objectRequestBytes, "PutObject", bucketName)
+	require.Equal(t, uint64(1), count)
+	require.Equal(t, float64(len(data)), sum)
+	count, sum = histogramOf(t, objectResponseBytes, "PutObject", bucketName)
+	require.Equal(t, uint64(1), count)
+	require.Equal(t, float64(0), sum)
+
+	reqGetObject := utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/"+objectName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(reqGetObject)
+	require.Equal(t, http.StatusOK, result.Code)
+	// the mocked dag pool of the tests serves the same content for every object
+	require.NotZero(t, result.Body.Len())
+	count, sum = histogramOf(t, objectResponseBytes, "GetObject", bucketName)
+	require.Equal(t, uint64(1), count)
+	require.Equal(t, float64(result.Body.Len()), sum)
+
+	// the failed requests are not observed
+	reqGetObject = utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/nosuchobject", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNotFound, reqTest(reqGetObject).Code)
+	count, _ = histogramOf(t, objectResponseBytes, "GetObject", bucketName)
+	require.Equal(t, uint64(1), count)
+}
+
+func TestBucketLabels(t *testing.T) {
+	labels := newBucketLabels(2)
+	require.Equal(t, "a", labels.label("a"))
+	require.Equal(t, "b", labels.label("b"))
+	require.Equal(t, otherBucketLabel, labels.label("c"))
+	require.Equal(t, "a", labels.label("a"))
+}
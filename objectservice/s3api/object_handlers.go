@@ -2,7 +2,11 @@ package s3api
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"github.com/dustin/go-humanize"
 	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
 	"github.com/filedag-project/filedag-storage/objectservice/consts"
 	"github.com/filedag-project/filedag-storage/objectservice/datatypes"
@@ -14,11 +18,14 @@ import (
 	"github.com/filedag-project/filedag-storage/objectservice/utils/etag"
 	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
 	"github.com/filedag-project/filedag-storage/objectservice/utils/s3utils"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"golang.org/x/xerrors"
 	"io"
 	"net/http"
+	"net/textproto"
 	"net/url"
+	"os"
 	"path"
 	"strconv"
 	"strings"
@@ -63,184 +70,1072 @@ func (s3a *s3ApiServer) PutObjectHandler(w http.ResponseWriter, r *http.Request)
 			}
 		}
 	}
+	// the size of a chunked body without a Content-Length is known once it's stored,
+	// the aws-chunked signed payloads declare their decoded size instead
+	chunked := !iam.IsAuthTypeStreamingSigned(rAuthType) && isChunkedTransfer(r)
+	if size == -1 && !chunked {
+		response.WriteErrorResponse(w, r, apierrors.ErrMissingContentLength)
+		return
+	}
+	if size == 0 {
+		response.WriteErrorResponse(w, r, apierrors.ErrEntityTooSmall)
+		return
+	}
+	// maximum Upload size for objects in a single operation
+	if size > consts.MaxObjectSize {
+		response.WriteErrorResponse(w, r, apierrors.ErrEntityTooLarge)
+		return
+	}
+
+	ctx := r.Context()
+	if err := s3utils.CheckPutObjectArgs(ctx, bucket, object); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Check if put is allowed
+	s3err := s3a.authSys.IsPutActionAllowed(ctx, r, s3action.PutObjectAction, bucket, object)
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	if !s3a.bmSys.HasBucket(ctx, bucket) {
+		response.WriteErrorResponse(w, r, apierrors.ErrNoSuchBucket)
+		return
+	}
+
+	var (
+		md5hex              = clientETag.String()
+		sha256hex           = ""
+		reader    io.Reader = r.Body
+	)
+
+	switch rAuthType {
+	case iam.AuthTypeStreamingSigned:
+		// Initialize stream signature verifier.
+		reader, s3err = iam.NewSignV4ChunkedReader(r, s3a.authSys)
+		if s3err != apierrors.ErrNone {
+			response.WriteErrorResponse(w, r, s3err)
+			return
+		}
+	case iam.AuthTypeSignedV2, iam.AuthTypePresignedV2:
+		s3err = s3a.authSys.IsReqAuthenticatedV2(r)
+		if s3err != apierrors.ErrNone {
+			response.WriteErrorResponse(w, r, s3err)
+			return
+		}
+
+	case iam.AuthTypePresigned, iam.AuthTypeSigned:
+		if s3err = s3a.authSys.ReqSignatureV4Verify(r, "", iam.ServiceS3); s3err != apierrors.ErrNone {
+			response.WriteErrorResponse(w, r, s3err)
+			return
+		}
+		if sha256hex, s3err = s3a.authSys.PayloadSha256(r); s3err != apierrors.ErrNone {
+			response.WriteErrorResponse(w, r, s3err)
+			return
+		}
+	}
+
+	if chunked {
+		reader = &objectSizeLimiter{reader: reader, remaining: maxChunkedObjectSize}
+	}
+	if r.Header.Get(consts.ContentType) == "" {
+		reader = mimeDetect(r, reader)
+	}
+	hashReader, err := hash.NewReader(reader, size, md5hex, sha256hex, size)
+	if err != nil {
+		log.Errorf("PutObjectHandler NewReader err:%v", err)
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	if s3err := setContentChecksum(r, hashReader); s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+	metadata, err := extractMetadata(ctx, r)
+	if err != nil {
+		log.Errorf("PutObjectHandler extractMetadata err:%v", err)
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidRequest)
+		return
+	}
+	if !isValidWebsiteRedirectLocation(metadata) {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidRedirectLocation)
+		return
+	}
+	if !isValidUserMetadataSize(metadata) {
+		response.WriteErrorResponse(w, r, apierrors.ErrMetadataTooLarge)
+		return
+	}
+	var objInfo store.ObjectInfo
+	// If-None-Match: * creates the object only, an existing object is never overwritten
+	if r.Header.Get(consts.IfNoneMatch) == "*" {
+		objInfo, err = s3a.store.StoreObjectIfNotExists(ctx, bucket, object, hashReader, size, metadata)
+	} else {
+		objInfo, err = s3a.store.StoreObject(ctx, bucket, object, hashReader, size, metadata)
+	}
+	if err != nil {
+		log.Errorf("PutObjectHandler StoreObject err:%v", err)
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	setPutObjHeaders(w, objInfo, false)
+	response.WriteSuccessResponseHeadersOnly(w, r)
+}
+
+// AppendObjectHandler - POST Object?append
+// ----------
+// This is not an S3 API, it appends the request body to the object and creates
+// the object if it doesn't exist. Only the appended content is stored, the stored
+// content of the object is reused. The ETag of an appended object is not the MD5
+// of its content, like the ETag of a multipart object.
+func (s3a *s3ApiServer) AppendObjectHandler(w http.ResponseWriter, r *http.Request) {
+	bucket, object, err := getBucketAndObject(r)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(r.Context(), err))
+		return
+	}
+	log.Infof("AppendObjectHandler %s %s", bucket, object)
+	clientETag, err := etag.FromContentMD5(r.Header)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidDigest)
+		return
+	}
+	// if Content-Length is unknown/missing, deny the request
+	size := r.ContentLength
+	rAuthType := iam.GetRequestAuthType(r)
+	if iam.IsAuthTypeStreamingSigned(rAuthType) {
+		if sizeStr, ok := r.Header[consts.AmzDecodedContentLength]; ok {
+			if sizeStr[0] == "" {
+				response.WriteErrorResponse(w, r, apierrors.ErrMissingContentLength)
+				return
+			}
+			size, err = strconv.ParseInt(sizeStr[0], 10, 64)
+			if err != nil {
+				log.Errorf("ParseInt err:%v", err)
+				response.WriteErrorResponse(w, r, apierrors.ErrBadRequest)
+				return
+			}
+		}
+	}
 	if size == -1 {
 		response.WriteErrorResponse(w, r, apierrors.ErrMissingContentLength)
 		return
 	}
-	if size == 0 {
-		response.WriteErrorResponse(w, r, apierrors.ErrEntityTooSmall)
+	if size == 0 {
+		response.WriteErrorResponse(w, r, apierrors.ErrEntityTooSmall)
+		return
+	}
+	// maximum Upload size for objects in a single operation
+	if size > consts.MaxObjectSize {
+		response.WriteErrorResponse(w, r, apierrors.ErrEntityTooLarge)
+		return
+	}
+
+	ctx := r.Context()
+	if err := s3utils.CheckPutObjectArgs(ctx, bucket, object); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Check if put is allowed
+	s3err := s3a.authSys.IsPutActionAllowed(ctx, r, s3action.PutObjectAction, bucket, object)
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	if !s3a.bmSys.HasBucket(ctx, bucket) {
+		response.WriteErrorResponse(w, r, apierrors.ErrNoSuchBucket)
+		return
+	}
+
+	var (
+		md5hex              = clientETag.String()
+		sha256hex           = ""
+		reader    io.Reader = r.Body
+	)
+
+	switch rAuthType {
+	case iam.AuthTypeStreamingSigned:
+		// Initialize stream signature verifier.
+		reader, s3err = iam.NewSignV4ChunkedReader(r, s3a.authSys)
+		if s3err != apierrors.ErrNone {
+			response.WriteErrorResponse(w, r, s3err)
+			return
+		}
+	case iam.AuthTypeSignedV2, iam.AuthTypePresignedV2:
+		s3err = s3a.authSys.IsReqAuthenticatedV2(r)
+		if s3err != apierrors.ErrNone {
+			response.WriteErrorResponse(w, r, s3err)
+			return
+		}
+
+	case iam.AuthTypePresigned, iam.AuthTypeSigned:
+		if s3err = s3a.authSys.ReqSignatureV4Verify(r, "", iam.ServiceS3); s3err != apierrors.ErrNone {
+			response.WriteErrorResponse(w, r, s3err)
+			return
+		}
+		if sha256hex, s3err = s3a.authSys.PayloadSha256(r); s3err != apierrors.ErrNone {
+			response.WriteErrorResponse(w, r, s3err)
+			return
+		}
+	}
+
+	hashReader, err := hash.NewReader(reader, size, md5hex, sha256hex, size)
+	if err != nil {
+		log.Errorf("AppendObjectHandler NewReader err:%v", err)
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	metadata, err := extractMetadata(ctx, r)
+	if err != nil {
+		log.Errorf("AppendObjectHandler extractMetadata err:%v", err)
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidRequest)
+		return
+	}
+	if !isValidWebsiteRedirectLocation(metadata) {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidRedirectLocation)
+		return
+	}
+	if !isValidUserMetadataSize(metadata) {
+		response.WriteErrorResponse(w, r, apierrors.ErrMetadataTooLarge)
+		return
+	}
+	objInfo, err := s3a.store.AppendObject(ctx, bucket, object, hashReader, size, metadata)
+	if err != nil {
+		log.Errorf("AppendObjectHandler AppendObject err:%v", err)
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	setPutObjHeaders(w, objInfo, false)
+	response.WriteSuccessResponseHeadersOnly(w, r)
+}
+
+// TouchObjectHandler - POST Object?touch
+// ----------
+// This is not an S3 API, it sets the last modified time of the object to now
+// without rewriting it, the content, the ETag and the metadata are kept. The
+// lifecycle expiry of the object is counted from the new time.
+func (s3a *s3ApiServer) TouchObjectHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bucket, object, err := getBucketAndObject(r)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	log.Infof("TouchObjectHandler %s %s", bucket, object)
+	if err := s3utils.CheckPutObjectArgs(ctx, bucket, object); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	_, _, s3Error := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.PutObjectAction, bucket, object)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
+		return
+	}
+	if !s3a.bmSys.HasBucket(ctx, bucket) {
+		response.WriteErrorResponse(w, r, apierrors.ErrNoSuchBucket)
+		return
+	}
+	objInfo, err := s3a.store.TouchObject(ctx, bucket, object)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	setPutObjHeaders(w, objInfo, false)
+	w.Header().Set(consts.LastModified, objInfo.ModTime.UTC().Format(http.TimeFormat))
+	s3a.setExpirationHeader(ctx, w, bucket, objInfo)
+	response.WriteSuccessResponseHeadersOnly(w, r)
+}
+
+// maxFormFieldsSize the max size of the fields of a POST upload form
+const maxFormFieldsSize = 1 * humanize.MiByte
+
+// PostPolicyBucketHandler - POST policy
+// ----------
+// This implementation of the POST operation handles object creation with a specified
+// signature policy in multipart/form-data
+// https://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectPOST.html
+func (s3a *s3ApiServer) PostPolicyBucketHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bucket, _, err := getBucketAndObject(r)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	log.Infof("PostPolicyBucketHandler %s", bucket)
+
+	r.Body = http.MaxBytesReader(w, r.Body, consts.MaxObjectSize+maxFormFieldsSize)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		log.Errorf("PostPolicyBucketHandler MultipartReader err:%v", err)
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedPOSTRequest)
+		return
+	}
+	// The fields are read before the file, which is only read after the policy is verified.
+	formValues, filePart, s3err := extractPostPolicyFormValues(reader)
+	if s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+	defer filePart.Close()
+	// The policy conditions on the bucket apply to the bucket of the request.
+	formValues.Set("Bucket", bucket)
+
+	object := strings.ReplaceAll(formValues.Get("Key"), "${filename}", filePart.FileName())
+	if object == "" {
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedPOSTRequest)
+		return
+	}
+	if err = s3utils.CheckPutObjectArgs(ctx, bucket, object); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	// The key is checked against the policy after ${filename} is replaced.
+	formValues.Set("Key", object)
+
+	// Verify the signature of the policy and if the signer may put the object.
+	if _, s3err = s3a.authSys.CheckPostPolicyCredential(ctx, formValues, bucket, object); s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	policyBytes, err := base64.StdEncoding.DecodeString(formValues.Get("Policy"))
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedPOSTRequest)
+		return
+	}
+	postPolicyForm, err := parsePostPolicyForm(string(policyBytes))
+	if err != nil {
+		log.Errorf("PostPolicyBucketHandler parsePostPolicyForm err:%v", err)
+		response.WriteErrorResponse(w, r, apierrors.ErrPostPolicyConditionInvalidFormat)
+		return
+	}
+	if s3err = checkPostPolicy(formValues, postPolicyForm); s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	if !s3a.bmSys.HasBucket(ctx, bucket) {
+		response.WriteErrorResponse(w, r, apierrors.ErrNoSuchBucket)
+		return
+	}
+
+	// Read no more than the policy allows, the size of the file is only known once it's read.
+	lengthRange := postPolicyForm.Conditions.ContentLengthRange
+	maxSize := int64(consts.MaxObjectSize)
+	if lengthRange.Valid && lengthRange.Max < maxSize {
+		maxSize = lengthRange.Max
+	}
+	file, size, err := spoolFormFile(filePart, maxSize)
+	if err != nil {
+		log.Errorf("PostPolicyBucketHandler read file err:%v", err)
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedPOSTRequest)
+		return
+	}
+	defer func() {
+		file.Close()
+		os.Remove(file.Name())
+	}()
+	if size > maxSize {
+		response.WriteErrorResponse(w, r, apierrors.ErrEntityTooLarge)
+		return
+	}
+	if s3err = checkContentLengthRange(size, lengthRange); s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
+
+	hashReader, err := hash.NewReader(file, size, "", "", size)
+	if err != nil {
+		log.Errorf("PostPolicyBucketHandler NewReader err:%v", err)
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	metadata := make(map[string]string)
+	if err = extractMetadataFromMime(ctx, textproto.MIMEHeader(formValues), metadata); err != nil {
+		log.Errorf("PostPolicyBucketHandler extractMetadata err:%v", err)
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidRequest)
+		return
+	}
+	if _, ok := metadata[strings.ToLower(consts.ContentType)]; !ok {
+		metadata[strings.ToLower(consts.ContentType)] = "binary/octet-stream"
+	}
+	if !isValidUserMetadataSize(metadata) {
+		response.WriteErrorResponse(w, r, apierrors.ErrMetadataTooLarge)
+		return
+	}
+	objInfo, err := s3a.store.StoreObject(ctx, bucket, object, hashReader, size, metadata)
+	if err != nil {
+		log.Errorf("PostPolicyBucketHandler StoreObject err:%v", err)
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	setPutObjHeaders(w, objInfo, false)
+	// Decide what http response to send depending on success_action_redirect
+	// and success_action_status of the form.
+	if redirectURL := formValues.Get("success_action_redirect"); redirectURL != "" {
+		u, err := url.Parse(redirectURL)
+		if err == nil {
+			q := u.Query()
+			q.Set("bucket", bucket)
+			q.Set("key", object)
+			q.Set("etag", "\""+objInfo.ETag+"\"")
+			u.RawQuery = q.Encode()
+			http.Redirect(w, r, u.String(), http.StatusSeeOther)
+			return
+		}
+		log.Warnf("PostPolicyBucketHandler invalid success_action_redirect %s", redirectURL)
+	}
+	switch formValues.Get("success_action_status") {
+	case "201":
+		location := getObjectLocation(r, bucket, object)
+		w.Header().Set(consts.Location, location)
+		response.WriteXMLResponse(w, r, http.StatusCreated, response.PostResponse{
+			Bucket:   bucket,
+			Key:      object,
+			ETag:     "\"" + objInfo.ETag + "\"",
+			Location: location,
+		})
+	case "200":
+		response.WriteSuccessResponseHeadersOnly(w, r)
+	default:
+		response.WriteSuccessNoContent(w)
+	}
+}
+
+// GetObjectHandler - GET Object
+// ----------
+// This implementation of the GET operation retrieves object. To use GET,
+// you must have READ access to the object.
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObject.html
+func (s3a *s3ApiServer) GetObjectHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bucket, object, err := getBucketAndObject(r)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	log.Infof("GetObjectHandler %s %s", bucket, object)
+	if err = s3utils.CheckGetObjArgs(ctx, bucket, object); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Check for auth type to return S3 compatible error.
+	// type to return the correct error (NoSuchKey vs AccessDenied)
+	_, _, s3Error := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetObjectAction, bucket, object)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
+		return
+	}
+	if !s3a.bmSys.HasBucket(ctx, bucket) {
+		response.WriteErrorResponse(w, r, apierrors.ErrNoSuchBucket)
+		return
+	}
+	partNumber, s3Error := getPartNumber(r)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
+		return
+	}
+	versionID, s3Error := getVersionID(r)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
+		return
+	}
+
+	// The conditionals are evaluated against the metadata, the dag is only
+	// read when the body is actually sent.
+	var objInfo store.ObjectInfo
+	var ranges []byteRange
+	checkPrecondFn := func(oi store.ObjectInfo) bool {
+		objInfo = oi
+		s3a.setBucketCacheControl(ctx, bucket, &objInfo)
+		s3a.setExpirationHeader(ctx, w, bucket, objInfo)
+		if checkPreconditions(ctx, w, r, objInfo) {
+			return true
+		}
+		if partNumber == 0 {
+			if ranges, s3Error = getObjectRanges(r, objInfo); s3Error != apierrors.ErrNone {
+				w.Header().Set(consts.ContentRange, fmt.Sprintf("bytes */%d", objInfo.Size))
+				response.WriteErrorResponse(w, r, s3Error)
+				return true
+			}
+		}
+		return false
+	}
+	var part store.ObjectPartInfo
+	var reader io.ReadCloser
+	if partNumber > 0 {
+		_, part, reader, err = s3a.store.GetObjectPart(ctx, bucket, object, versionID, partNumber, checkPrecondFn)
+	} else {
+		_, reader, err = s3a.store.GetObject(ctx, bucket, object, versionID, checkPrecondFn)
+	}
+	if err != nil {
+		if _, ok := err.(store.PreConditionFailed); ok {
+			// The response is already written by checkPreconditions.
+			return
+		}
+		log.Errorf("GetObjectHandler GetObject err:%v", err)
+		s3a.setDeleteMarkerHeaders(ctx, w, bucket, object, versionID, err)
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	defer reader.Close()
+	w.Header().Set(consts.AmzServerSideEncryption, consts.AmzEncryptionAES)
+
+	response.SetObjectHeaders(w, r, objInfo)
+	w.Header().Set(consts.ContentLength, strconv.FormatInt(objInfo.Size, 10))
+	response.SetHeadGetRespHeaders(w, r.Form)
+	if partNumber > 0 {
+		w.WriteHeader(setPartHeaders(w, objInfo, part))
+	}
+	if len(ranges) > 0 {
+		contentType := w.Header().Get(consts.ContentType)
+		mw := setRangeHeaders(w, ranges, objInfo.Size)
+		w.WriteHeader(http.StatusPartialContent)
+		if n, err := writeRanges(w, mw, reader, ranges, contentType, objInfo.Size); err != nil {
+			// The status is sent, the response is aborted like the mid-stream failures of the whole object.
+			log.Errorw("GetObjectHandler the read of the ranges failed, abort the response", "bucket", bucket,
+				"object", object, "ranges", len(ranges), "sent", n, "size", objInfo.Size, "error", err)
+			panic(http.ErrAbortHandler)
+		}
+		return
+	}
+	n, err := io.Copy(w, reader)
+	if err != nil {
+		if n == 0 {
+			log.Errorf("GetObjectHandler reader readAll err:%v", err)
+			response.WriteErrorResponse(w, r, apierrors.ErrInternalError)
+			return
+		}
+		// The status and a part of the body are sent, the error can't be reported. The response
+		// is aborted so that the connection is closed instead of being reused after a half body.
+		log.Errorw("GetObjectHandler the read failed mid-stream, abort the response", "bucket", bucket,
+			"object", object, "sent", n, "size", objInfo.Size, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// HeadObjectHandler - HEAD Object
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_HeadObject.html
+// The HEAD operation retrieves metadata from an object without returning the object itself.
+func (s3a *s3ApiServer) HeadObjectHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bucket, object, err := getBucketAndObject(r)
+	if err != nil {
+		response.WriteErrorResponseHeadersOnly(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	log.Infof("HeadObjectHandler %s %s", bucket, object)
+	if err := s3utils.CheckGetObjArgs(ctx, bucket, object); err != nil {
+		response.WriteErrorResponseHeadersOnly(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Check for auth type to return S3 compatible error.
+	// type to return the correct error (NoSuchKey vs AccessDenied)
+	_, _, s3Error := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetObjectAction, bucket, object)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponseHeadersOnly(w, r, s3Error)
+		return
+	}
+	if !s3a.bmSys.HasBucket(ctx, bucket) {
+		response.WriteErrorResponseHeadersOnly(w, r, apierrors.ErrNoSuchBucket)
+		return
+	}
+	partNumber, s3Error := getPartNumber(r)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponseHeadersOnly(w, r, s3Error)
+		return
+	}
+	versionID, s3Error := getVersionID(r)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponseHeadersOnly(w, r, s3Error)
+		return
+	}
+	objInfo, err := s3a.store.GetObjectInfo(ctx, bucket, object, versionID)
+	if err != nil {
+		s3a.setDeleteMarkerHeaders(ctx, w, bucket, object, versionID, err)
+		if s3a.headDeleteMarkerNotFound && xerrors.Is(err, store.ErrVersionIsDeleteMarker) {
+			response.WriteErrorResponseHeadersOnly(w, r, apierrors.ErrNoSuchVersion)
+			return
+		}
+		response.WriteErrorResponseHeadersOnly(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	var part store.ObjectPartInfo
+	if partNumber > 0 {
+		if part, err = objInfo.ObjectPart(partNumber); err != nil {
+			response.WriteErrorResponseHeadersOnly(w, r, apierrors.ToApiError(ctx, err))
+			return
+		}
+	}
+	s3a.setBucketCacheControl(ctx, bucket, &objInfo)
+	s3a.setExpirationHeader(ctx, w, bucket, objInfo)
+	if checkPreconditions(ctx, w, r, objInfo) {
+		return
+	}
+	w.Header().Set(consts.AmzServerSideEncryption, consts.AmzEncryptionAES)
+
+	// Set standard object headers.
+	response.SetObjectHeaders(w, r, objInfo)
+	// Set any additional requested response headers.
+	response.SetHeadGetRespHeaders(w, r.Form)
+	if partNumber > 0 {
+		w.WriteHeader(setPartHeaders(w, objInfo, part))
+		return
+	}
+
+	// Successful response.
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetObjectAttributesHandler - GET Object attributes
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectAttributes.html
+// The checksum is the sha256 of the whole object content, or the composite checksum of the parts
+// of a multipart object, which lists its parts with their checksums.
+func (s3a *s3ApiServer) GetObjectAttributesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bucket, object, err := getBucketAndObject(r)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	log.Infof("GetObjectAttributesHandler %s %s", bucket, object)
+	if err := s3utils.CheckGetObjArgs(ctx, bucket, object); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	// Check for auth type to return S3 compatible error.
+	// type to return the correct error (NoSuchKey vs AccessDenied)
+	_, _, s3Error := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetObjectAction, bucket, object)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
+		return
+	}
+	attributes := make(map[string]bool)
+	for _, header := range r.Header.Values(consts.AmzObjectAttributes) {
+		for _, attr := range strings.Split(header, ",") {
+			switch attr = strings.TrimSpace(attr); attr {
+			case "ETag", "Checksum", "ObjectParts", "StorageClass", "ObjectSize":
+				attributes[attr] = true
+			default:
+				response.WriteErrorResponse(w, r, apierrors.ErrInvalidAttributeName)
+				return
+			}
+		}
+	}
+	if len(attributes) == 0 {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidAttributeName)
+		return
+	}
+	maxParts, partNumberMarker := consts.MaxPartsList, 0
+	if v := r.Header.Get(consts.AmzMaxParts); v != "" {
+		if maxParts, err = strconv.Atoi(v); err != nil || maxParts < 0 {
+			response.WriteErrorResponse(w, r, apierrors.ErrInvalidMaxParts)
+			return
+		}
+	}
+	if v := r.Header.Get(consts.AmzPartNumberMarker); v != "" {
+		if partNumberMarker, err = strconv.Atoi(v); err != nil || partNumberMarker < 0 {
+			response.WriteErrorResponse(w, r, apierrors.ErrInvalidPartNumberMarker)
+			return
+		}
+	}
+	versionID, s3Error := getVersionID(r)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
+		return
+	}
+	if !s3a.bmSys.HasBucket(ctx, bucket) {
+		response.WriteErrorResponse(w, r, apierrors.ErrNoSuchBucket)
+		return
+	}
+	objInfo, err := s3a.store.GetObjectInfo(ctx, bucket, object, versionID)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	resp := response.GetObjectAttributesResponse{}
+	if attributes["ETag"] {
+		resp.ETag = objInfo.ETag
+	}
+	if attributes["Checksum"] {
+		if len(objInfo.Parts) > 0 {
+			if checksum := objInfo.CompositeChecksum(); checksum != "" {
+				resp.Checksum = &response.ObjectChecksum{ChecksumSHA256: checksum, ChecksumType: "COMPOSITE"}
+			}
+		} else if objInfo.ChecksumSHA256 != "" {
+			resp.Checksum = &response.ObjectChecksum{ChecksumSHA256: objInfo.ChecksumSHA256, ChecksumType: "FULL_OBJECT"}
+		}
+	}
+	// only a multipart object has parts
+	if attributes["ObjectParts"] && len(objInfo.Parts) > 0 {
+		resp.ObjectParts = objectAttrsParts(objInfo.Parts, partNumberMarker, maxParts)
+	}
+	if attributes["StorageClass"] {
+		resp.StorageClass = consts.DefaultStorageClass
+	}
+	if attributes["ObjectSize"] {
+		resp.ObjectSize = &objInfo.Size
+	}
+	w.Header().Set(consts.LastModified, objInfo.ModTime.UTC().Format(http.TimeFormat))
+	response.WriteSuccessResponseXML(w, r, resp)
+}
+
+// objectAttrsParts returns the page of at most maxParts parts after the part number marker
+func objectAttrsParts(parts []store.ObjectPartInfo, partNumberMarker, maxParts int) *response.ObjectAttrsParts {
+	resp := &response.ObjectAttrsParts{
+		PartsCount:       len(parts),
+		PartNumberMarker: partNumberMarker,
+		MaxParts:         maxParts,
+		Parts:            make([]response.ObjectAttrsPart, 0),
+	}
+	for _, part := range parts {
+		if part.Number <= partNumberMarker {
+			continue
+		}
+		if len(resp.Parts) == maxParts {
+			resp.IsTruncated = true
+			break
+		}
+		resp.Parts = append(resp.Parts, response.ObjectAttrsPart{
+			PartNumber:     part.Number,
+			Size:           part.Size,
+			ChecksumSHA256: part.ChecksumSHA256,
+		})
+		resp.NextPartNumberMarker = part.Number
+	}
+	return resp
+}
+
+// PutObjectLegalHoldHandler - PUT Object legal hold
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectLegalHold.html
+// An object version under legal hold can't be deleted or overwritten until the hold is turned OFF.
+func (s3a *s3ApiServer) PutObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bucket, object, err := getBucketAndObject(r)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	log.Infof("PutObjectLegalHoldHandler %s %s", bucket, object)
+	if err := s3utils.CheckPutObjectArgs(ctx, bucket, object); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	_, _, s3Error := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.PutObjectLegalHoldAction, bucket, object)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
+		return
+	}
+	versionID, s3Error := getVersionID(r)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
+		return
+	}
+	var legalHold objectLegalHold
+	if err := utils.XmlDecoder(r.Body, &legalHold, r.ContentLength); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
+		return
+	}
+	if legalHold.Status != legalHoldOn && legalHold.Status != legalHoldOff {
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
+		return
+	}
+	if !s3a.bmSys.HasBucket(ctx, bucket) {
+		response.WriteErrorResponse(w, r, apierrors.ErrNoSuchBucket)
+		return
+	}
+	objInfo, err := s3a.store.PutObjectLegalHold(ctx, bucket, object, versionID, legalHold.Status == legalHoldOn)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	if objInfo.VersionID != "" {
+		w.Header()[consts.AmzVersionID] = []string{objInfo.VersionID}
+	}
+	response.WriteSuccessResponseHeadersOnly(w, r)
+}
+
+// GetObjectLegalHoldHandler - GET Object legal hold
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectLegalHold.html
+func (s3a *s3ApiServer) GetObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bucket, object, err := getBucketAndObject(r)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	log.Infof("GetObjectLegalHoldHandler %s %s", bucket, object)
+	if err := s3utils.CheckGetObjArgs(ctx, bucket, object); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	_, _, s3Error := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetObjectLegalHoldAction, bucket, object)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
+		return
+	}
+	versionID, s3Error := getVersionID(r)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
+		return
+	}
+	if !s3a.bmSys.HasBucket(ctx, bucket) {
+		response.WriteErrorResponse(w, r, apierrors.ErrNoSuchBucket)
+		return
+	}
+	objInfo, err := s3a.store.GetObjectInfo(ctx, bucket, object, versionID)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	status := legalHoldOff
+	if objInfo.LegalHold {
+		status = legalHoldOn
+	}
+	response.WriteSuccessResponseXML(w, r, response.ObjectLegalHold{Status: status})
+}
+
+const (
+	legalHoldOn  = "ON"
+	legalHoldOff = "OFF"
+)
+
+// objectLegalHold container for the legal hold of a PutObjectLegalHold request,
+// it's parsed with or without the namespace.
+type objectLegalHold struct {
+	XMLName xml.Name `xml:"LegalHold"`
+	Status  string   `xml:"Status"`
+}
+
+// PutObjectTaggingHandler - PUT Object tagging
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectTagging.html
+// The tags replace the ones of the object version, only its metadata is rewritten.
+func (s3a *s3ApiServer) PutObjectTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bucket, object, err := getBucketAndObject(r)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	log.Infof("PutObjectTaggingHandler %s %s", bucket, object)
+	if err := s3utils.CheckPutObjectArgs(ctx, bucket, object); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	_, _, s3Error := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.PutObjectTaggingAction, bucket, object)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
+		return
+	}
+	versionID, s3Error := getVersionID(r)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
+		return
+	}
+	tags, err := unmarshalXML(io.LimitReader(r.Body, r.ContentLength), true)
+	if err != nil {
+		if _, ok := err.(store.InvalidTag); ok {
+			response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+			return
+		}
+		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
+		return
+	}
+	if !s3a.bmSys.HasBucket(ctx, bucket) {
+		response.WriteErrorResponse(w, r, apierrors.ErrNoSuchBucket)
+		return
+	}
+	objInfo, err := s3a.store.PutObjectTags(ctx, bucket, object, versionID, tags.TagSet.TagMap)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
 	}
-	// maximum Upload size for objects in a single operation
-	if size > consts.MaxObjectSize {
-		response.WriteErrorResponse(w, r, apierrors.ErrEntityTooLarge)
-		return
+	if objInfo.VersionID != "" {
+		w.Header()[consts.AmzVersionID] = []string{objInfo.VersionID}
 	}
+	response.WriteSuccessResponseHeadersOnly(w, r)
+}
 
+// GetObjectTaggingHandler - GET Object tagging
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectTagging.html
+func (s3a *s3ApiServer) GetObjectTaggingHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	if err := s3utils.CheckPutObjectArgs(ctx, bucket, object); err != nil {
+	bucket, object, err := getBucketAndObject(r)
+	if err != nil {
 		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
 	}
-
-	// Check if put is allowed
-	s3err := s3a.authSys.IsPutActionAllowed(ctx, r, s3action.PutObjectAction, bucket, object)
-	if s3err != apierrors.ErrNone {
-		response.WriteErrorResponse(w, r, s3err)
+	log.Infof("GetObjectTaggingHandler %s %s", bucket, object)
+	if err := s3utils.CheckGetObjArgs(ctx, bucket, object); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
 	}
 
+	_, _, s3Error := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetObjectTaggingAction, bucket, object)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
+		return
+	}
+	versionID, s3Error := getVersionID(r)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
+		return
+	}
 	if !s3a.bmSys.HasBucket(ctx, bucket) {
 		response.WriteErrorResponse(w, r, apierrors.ErrNoSuchBucket)
 		return
 	}
-
-	var (
-		md5hex              = clientETag.String()
-		sha256hex           = ""
-		reader    io.Reader = r.Body
-	)
-
-	switch rAuthType {
-	case iam.AuthTypeStreamingSigned:
-		// Initialize stream signature verifier.
-		reader, s3err = iam.NewSignV4ChunkedReader(r, s3a.authSys)
-		if s3err != apierrors.ErrNone {
-			response.WriteErrorResponse(w, r, s3err)
-			return
-		}
-	case iam.AuthTypeSignedV2, iam.AuthTypePresignedV2:
-		s3err = s3a.authSys.IsReqAuthenticatedV2(r)
-		if s3err != apierrors.ErrNone {
-			response.WriteErrorResponse(w, r, s3err)
-			return
-		}
-
-	case iam.AuthTypePresigned, iam.AuthTypeSigned:
-		if s3err = s3a.authSys.ReqSignatureV4Verify(r, "", iam.ServiceS3); s3err != apierrors.ErrNone {
-			response.WriteErrorResponse(w, r, s3err)
-			return
-		}
-		if !iam.SkipContentSha256Cksum(r) {
-			sha256hex = iam.GetContentSha256Cksum(r, iam.ServiceS3)
-		}
+	tags, objVersionID, err := s3a.store.GetObjectTags(ctx, bucket, object, versionID)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
 	}
-
-	if r.Header.Get(consts.ContentType) == "" {
-		reader = mimeDetect(r, reader)
+	if objVersionID != "" {
+		w.Header()[consts.AmzVersionID] = []string{objVersionID}
 	}
-	hashReader, err := hash.NewReader(reader, size, md5hex, sha256hex, size)
+	// an object without tags has an empty TagSet
+	response.WriteSuccessResponseXML(w, r, store.Tags{TagSet: &store.TagSet{TagMap: tags, IsObject: true}})
+}
+
+// DeleteObjectTaggingHandler - DELETE Object tagging
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteObjectTagging.html
+func (s3a *s3ApiServer) DeleteObjectTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bucket, object, err := getBucketAndObject(r)
 	if err != nil {
-		log.Errorf("PutObjectHandler NewReader err:%v", err)
 		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
 	}
-	metadata, err := extractMetadata(ctx, r)
-	if err != nil {
-		log.Errorf("PutObjectHandler extractMetadata err:%v", err)
-		response.WriteErrorResponse(w, r, apierrors.ErrInvalidRequest)
+	log.Infof("DeleteObjectTaggingHandler %s %s", bucket, object)
+	if err := s3utils.CheckDelObjArgs(ctx, bucket, object); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
 	}
-	objInfo, err := s3a.store.StoreObject(ctx, bucket, object, hashReader, size, metadata)
+
+	_, _, s3Error := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.DeleteObjectTaggingAction, bucket, object)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
+		return
+	}
+	versionID, s3Error := getVersionID(r)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
+		return
+	}
+	if !s3a.bmSys.HasBucket(ctx, bucket) {
+		response.WriteErrorResponse(w, r, apierrors.ErrNoSuchBucket)
+		return
+	}
+	objInfo, err := s3a.store.PutObjectTags(ctx, bucket, object, versionID, nil)
 	if err != nil {
-		log.Errorf("PutObjectHandler StoreObject err:%v", err)
 		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
 	}
-	setPutObjHeaders(w, objInfo, false)
-	response.WriteSuccessResponseHeadersOnly(w, r)
+	if objInfo.VersionID != "" {
+		w.Header()[consts.AmzVersionID] = []string{objInfo.VersionID}
+	}
+	response.WriteSuccessNoContent(w)
 }
 
-// GetObjectHandler - GET Object
-// ----------
-// This implementation of the GET operation retrieves object. To use GET,
-// you must have READ access to the object.
-// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObject.html
-func (s3a *s3ApiServer) GetObjectHandler(w http.ResponseWriter, r *http.Request) {
+// PutObjectAclHandler - PUT Object ACL
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectAcl.html
+// Only the canned ACLs private, public-read and public-read-write are supported,
+// the owner of the object is the owner of its bucket.
+func (s3a *s3ApiServer) PutObjectAclHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	bucket, object, err := getBucketAndObject(r)
 	if err != nil {
 		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
 	}
-	log.Infof("GetObjectHandler %s %s", bucket, object)
-	if err = s3utils.CheckGetObjArgs(ctx, bucket, object); err != nil {
+	log.Infof("PutObjectAclHandler %s %s", bucket, object)
+	if err := s3utils.CheckPutObjectArgs(ctx, bucket, object); err != nil {
 		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
 	}
 
-	// Check for auth type to return S3 compatible error.
-	// type to return the correct error (NoSuchKey vs AccessDenied)
-	_, _, s3Error := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetObjectAction, bucket, object)
+	_, _, s3Error := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.PutObjectAclAction, bucket, object)
 	if s3Error != apierrors.ErrNone {
 		response.WriteErrorResponse(w, r, s3Error)
 		return
 	}
-	if !s3a.bmSys.HasBucket(ctx, bucket) {
-		response.WriteErrorResponse(w, r, apierrors.ErrNoSuchBucket)
+	versionID, s3Error := getVersionID(r)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
 		return
 	}
-
-	objInfo, reader, err := s3a.store.GetObject(ctx, bucket, object)
+	meta, err := s3a.bmSys.GetBucketMeta(ctx, bucket)
 	if err != nil {
-		log.Errorf("GetObjectHandler GetObject err:%v", err)
 		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
 	}
-	w.Header().Set(consts.AmzServerSideEncryption, consts.AmzEncryptionAES)
-
-	response.SetObjectHeaders(w, r, objInfo)
-	w.Header().Set(consts.ContentLength, strconv.FormatInt(objInfo.Size, 10))
-	response.SetHeadGetRespHeaders(w, r.Form)
-	_, err = io.Copy(w, reader)
+	acl, s3Error := parseCannedACL(r, meta.Owner)
+	if s3Error != apierrors.ErrNone {
+		log.Warnw("PutObjectAclHandler unsupported acl", "bucket", bucket, "object", object, "error", apierrors.GetAPIError(s3Error).Code)
+		response.WriteErrorResponse(w, r, s3Error)
+		return
+	}
+	objInfo, err := s3a.store.PutObjectACL(ctx, bucket, object, versionID, acl)
 	if err != nil {
-		log.Errorf("GetObjectHandler reader readAll err:%v", err)
-		response.WriteErrorResponse(w, r, apierrors.ErrInternalError)
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
 	}
+	if objInfo.VersionID != "" {
+		w.Header()[consts.AmzVersionID] = []string{objInfo.VersionID}
+	}
+	response.WriteSuccessResponseHeadersOnly(w, r)
 }
 
-// HeadObjectHandler - HEAD Object
-// https://docs.aws.amazon.com/AmazonS3/latest/API/API_HeadObject.html
-// The HEAD operation retrieves metadata from an object without returning the object itself.
-func (s3a *s3ApiServer) HeadObjectHandler(w http.ResponseWriter, r *http.Request) {
+// GetObjectAclHandler - GET Object ACL
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_GetObjectAcl.html
+func (s3a *s3ApiServer) GetObjectAclHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	bucket, object, err := getBucketAndObject(r)
 	if err != nil {
-		response.WriteErrorResponseHeadersOnly(w, r, apierrors.ToApiError(ctx, err))
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
 	}
-	log.Infof("HeadObjectHandler %s %s", bucket, object)
+	log.Infof("GetObjectAclHandler %s %s", bucket, object)
 	if err := s3utils.CheckGetObjArgs(ctx, bucket, object); err != nil {
-		response.WriteErrorResponseHeadersOnly(w, r, apierrors.ToApiError(ctx, err))
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
 	}
 
-	// Check for auth type to return S3 compatible error.
-	// type to return the correct error (NoSuchKey vs AccessDenied)
-	_, _, s3Error := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetObjectAction, bucket, object)
+	_, _, s3Error := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetObjectAclAction, bucket, object)
 	if s3Error != apierrors.ErrNone {
-		response.WriteErrorResponseHeadersOnly(w, r, s3Error)
+		response.WriteErrorResponse(w, r, s3Error)
 		return
 	}
-	if !s3a.bmSys.HasBucket(ctx, bucket) {
-		response.WriteErrorResponseHeadersOnly(w, r, apierrors.ErrNoSuchBucket)
+	versionID, s3Error := getVersionID(r)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
 		return
 	}
-	objInfo, err := s3a.store.GetObjectInfo(ctx, bucket, object)
+	meta, err := s3a.bmSys.GetBucketMeta(ctx, bucket)
 	if err != nil {
-		response.WriteErrorResponseHeadersOnly(w, r, apierrors.ToApiError(ctx, err))
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
 	}
-	w.Header().Set(consts.AmzServerSideEncryption, consts.AmzEncryptionAES)
-
-	// Set standard object headers.
-	response.SetObjectHeaders(w, r, objInfo)
-	// Set any additional requested response headers.
-	response.SetHeadGetRespHeaders(w, r.Form)
-
-	// Successful response.
-	w.WriteHeader(http.StatusOK)
+	acl, objVersionID, err := s3a.store.GetObjectACL(ctx, bucket, object, versionID)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	if objVersionID != "" {
+		w.Header()[consts.AmzVersionID] = []string{objVersionID}
+	}
+	response.WriteSuccessResponseXML(w, r, cannedACLPolicy(meta.Owner, acl))
 }
 
 // DeleteObjectHandler - delete an object
@@ -261,21 +1156,22 @@ func (s3a *s3ApiServer) DeleteObjectHandler(w http.ResponseWriter, r *http.Reque
 
 	// Check for auth type to return S3 compatible error.
 	// type to return the correct error (NoSuchKey vs AccessDenied)
-	_, _, s3Error := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetObjectAction, bucket, object)
+	_, _, s3Error := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.DeleteObjectAction, bucket, object)
 	if s3Error != apierrors.ErrNone {
 		response.WriteErrorResponse(w, r, s3Error)
 		return
 	}
-	if !s3a.bmSys.HasBucket(ctx, bucket) {
-		response.WriteErrorResponse(w, r, apierrors.ErrNoSuchBucket)
+	versionID, s3Error := getVersionID(r)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
 		return
 	}
-	objInfo, err := s3a.store.GetObjectInfo(ctx, bucket, object)
-	if err != nil {
-		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+	if !s3a.bmSys.HasBucket(ctx, bucket) {
+		response.WriteErrorResponse(w, r, apierrors.ErrNoSuchBucket)
 		return
 	}
-	err = s3a.store.DeleteObject(ctx, bucket, object)
+	// the deleted version or the delete marker added
+	objInfo, err := s3a.store.DeleteObject(ctx, bucket, object, versionID)
 	if err != nil {
 		log.Errorf("DeleteObjectHandler DeleteObject  err:%v", err)
 		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
@@ -391,23 +1287,30 @@ func (s3a *s3ApiServer) DeleteMultipleObjectsHandler(w http.ResponseWriter, r *h
 		if errs[i] = s3utils.CheckDelObjArgs(ctx, bucket, obj.ObjectName); errs[i] != nil {
 			continue
 		}
-		errs[i] = s3a.store.DeleteObject(ctx, bucket, obj.ObjectName)
+		if obj.VersionID != "" && obj.VersionID != store.NullVersionID {
+			if _, err := uuid.Parse(obj.VersionID); err != nil {
+				errs[i] = store.ErrObjectVersionNotFound
+				continue
+			}
+		}
+		var objInfo store.ObjectInfo
+		objInfo, errs[i] = s3a.store.DeleteObject(ctx, bucket, obj.ObjectName, obj.VersionID)
 		if errs[i] == nil || xerrors.Is(errs[i], store.ErrObjectNotFound) {
 			dObjects[i] = datatypes.DeletedObject{
 				ObjectName: obj.ObjectName,
+				VersionID:  obj.VersionID,
+			}
+			if objInfo.DeleteMarker {
+				dObjects[i].DeleteMarker = true
+				dObjects[i].DeleteMarkerVersionID = objInfo.VersionID
 			}
 			errs[i] = nil
 		}
 	}
 
+	// Each object reports its own result, the failure of some objects doesn't abort the others.
 	for i := range errs {
-		objToDel := datatypes.ObjectToDelete{
-			ObjectV: datatypes.ObjectV{
-				ObjectName: dObjects[i].ObjectName,
-				VersionID:  dObjects[i].VersionID,
-			},
-		}
-		dindex := objectsToDelete[objToDel]
+		dindex := objectsToDelete[deleteList[i]]
 		if errs[i] == nil {
 			deleteResults[dindex].delInfo = dObjects[i]
 			continue
@@ -428,12 +1331,13 @@ func (s3a *s3ApiServer) DeleteMultipleObjectsHandler(w http.ResponseWriter, r *h
 	for _, deleteResult := range deleteResults {
 		if deleteResult.errInfo.Code != "" {
 			deleteErrors = append(deleteErrors, deleteResult.errInfo)
-		} else {
+		} else if deleteResult.delInfo.ObjectName != "" {
+			// the duplicate objects are reported once
 			deletedObjects = append(deletedObjects, deleteResult.delInfo)
 		}
 	}
 
-	resp := response.GenerateMultiDeleteResponse(deleteObjectsReq.Quiet, dObjects, deleteErrors)
+	resp := response.GenerateMultiDeleteResponse(deleteObjectsReq.Quiet, deletedObjects, deleteErrors)
 
 	// Write success response.
 	response.WriteSuccessResponseXML(w, r, resp)
@@ -468,24 +1372,24 @@ func (s3a *s3ApiServer) CopyObjectHandler(w http.ResponseWriter, r *http.Request
 		response.WriteErrorResponse(w, r, apierrors.ErrNoSuchBucket)
 		return
 	}
-
-	// Copy source path.
-	cpSrcPath, err := url.QueryUnescape(r.Header.Get(consts.AmzCopySource))
-	if err != nil {
-		// Save unescaped string as is.
-		cpSrcPath = r.Header.Get(consts.AmzCopySource)
+	// SSE-C is not supported yet, objects are never stored encrypted with customer keys,
+	// so refuse to decrypt or re-key them instead of copying them as plain text.
+	if isSSECustomerRequest(r.Header) {
+		response.WriteErrorResponse(w, r, apierrors.ErrNotImplemented)
+		return
 	}
-	srcBucket, srcObject := pathToBucketAndObject(cpSrcPath)
-	// If source object is empty or bucket is empty, reply back invalid copy source.
-	if srcObject == "" || srcBucket == "" {
-		response.WriteErrorResponse(w, r, apierrors.ErrInvalidCopySource)
+
+	srcBucket, srcObject, srcVersionID, s3Error := getCopySource(r)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
 		return
 	}
 	if err = s3utils.CheckGetObjArgs(ctx, srcBucket, srcObject); err != nil {
 		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
 	}
-	if srcBucket == dstBucket && srcObject == dstObject {
+	// an object is copied onto itself only to replace its metadata, a version is copied onto the object to restore it
+	if srcBucket == dstBucket && srcObject == dstObject && srcVersionID == "" && !isReplace(r) {
 		response.WriteErrorResponse(w, r, apierrors.ErrInvalidCopyDest)
 		return
 	}
@@ -499,16 +1403,19 @@ func (s3a *s3ApiServer) CopyObjectHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	log.Debugf("CopyObjectHandler %s %s => %s %s", srcBucket, srcObject, dstBucket, dstObject)
-	srcObjInfo, srcReader, err := s3a.store.GetObject(ctx, srcBucket, srcObject)
+	log.Debugf("CopyObjectHandler %s %s %s => %s %s", srcBucket, srcObject, srcVersionID, dstBucket, dstObject)
+	srcObjInfo, err := s3a.store.GetObjectInfo(ctx, srcBucket, srcObject, srcVersionID)
 	if err != nil {
-		log.Errorf("CopyObjectHandler GetObject err:%v", err)
+		log.Errorf("CopyObjectHandler GetObjectInfo err:%v", err)
 		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
 	}
 	metadata := make(map[string]string)
 	metadata[strings.ToLower(consts.ContentType)] = srcObjInfo.ContentType
 	metadata[strings.ToLower(consts.ContentEncoding)] = srcObjInfo.ContentEncoding
+	metadata[strings.ToLower(consts.CacheControl)] = srcObjInfo.CacheControl
+	metadata[strings.ToLower(consts.ContentDisposition)] = srcObjInfo.ContentDisposition
+	metadata[strings.ToLower(consts.ContentLanguage)] = srcObjInfo.ContentLanguage
 	if isReplace(r) {
 		inputMeta, err := extractMetadata(ctx, r)
 		if err != nil {
@@ -518,18 +1425,34 @@ func (s3a *s3ApiServer) CopyObjectHandler(w http.ResponseWriter, r *http.Request
 		for key, val := range inputMeta {
 			metadata[key] = val
 		}
+	} else {
+		for key, val := range srcObjInfo.UserDefined {
+			metadata[key] = val
+		}
 	}
-	hashReader, err := hash.NewReader(srcReader, srcObjInfo.Size, srcObjInfo.ETag, "", srcObjInfo.Size)
-	if err != nil {
-		log.Errorf("PutObjectHandler NewReader err:%v", err)
-		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+	// the website redirect location of the source isn't copied, it's set by the request whatever the directive
+	if location := r.Header.Get(consts.AmzWebsiteRedirectLocation); location != "" {
+		metadata[strings.ToLower(consts.AmzWebsiteRedirectLocation)] = location
+	}
+	if !isValidWebsiteRedirectLocation(metadata) {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidRedirectLocation)
+		return
+	}
+	if !isValidUserMetadataSize(metadata) {
+		response.WriteErrorResponse(w, r, apierrors.ErrMetadataTooLarge)
 		return
 	}
-	obj, err := s3a.store.StoreObject(ctx, dstBucket, dstObject, hashReader, srcObjInfo.Size, metadata)
+	// the copy shares the dag of the source, the content isn't read
+	obj, err := s3a.store.CopyObject(ctx, srcBucket, srcObject, srcVersionID, dstBucket, dstObject, metadata)
 	if err != nil {
 		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
 	}
+	if srcVersionID != "" {
+		w.Header()[consts.AmzCopySourceVersionID] = []string{srcVersionID}
+	} else if srcObjInfo.VersionID != "" {
+		w.Header()[consts.AmzCopySourceVersionID] = []string{srcObjInfo.VersionID}
+	}
 
 	resp := response.CopyObjectResult{
 		ETag:         "\"" + obj.ETag + "\"",
@@ -578,6 +1501,43 @@ func (s3a *s3ApiServer) ListObjectsV1Handler(w http.ResponseWriter, r *http.Requ
 	response.WriteSuccessResponseXML(w, r, resp)
 }
 
+// ListObjectVersionsHandler - GET Bucket?versions
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListObjectVersions.html
+// The versions of a key are listed newest first, including the delete markers.
+func (s3a *s3ApiServer) ListObjectVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bucket, _, _ := getBucketAndObject(r)
+	log.Infof("ListObjectVersionsHandler %s", bucket)
+
+	_, _, s3Error := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.ListBucketVersionsAction, bucket, "")
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
+		return
+	}
+	if !s3a.bmSys.HasBucket(ctx, bucket) {
+		response.WriteErrorResponse(w, r, apierrors.ErrNoSuchBucket)
+		return
+	}
+	prefix, keyMarker, versionIDMarker, delimiter, maxKeys, encodingType, s3Error := getListObjectVersionsArgs(r.Form)
+	if s3Error != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3Error)
+		return
+	}
+	if err := s3utils.CheckListObjsArgs(ctx, bucket, prefix, keyMarker); err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+
+	versions, err := s3a.store.ListObjectVersions(ctx, bucket, prefix, keyMarker, versionIDMarker, delimiter, maxKeys)
+	if err != nil {
+		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	resp := response.GenerateListVersionsResponse(bucket, prefix, keyMarker, versionIDMarker, delimiter, encodingType, maxKeys, versions)
+	// Write success response.
+	response.WriteSuccessResponseXML(w, r, resp)
+}
+
 func (s3a *s3ApiServer) ListObjectsV2Handler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	bucket, object, err := getBucketAndObject(r)
@@ -607,9 +1567,13 @@ func (s3a *s3ApiServer) ListObjectsV2Handler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	marker := token
-	if marker == "" {
-		marker = startAfter
+	marker := startAfter
+	if token != "" {
+		var err error
+		if marker, err = store.ParseContinuationToken(token); err != nil {
+			response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+			return
+		}
 	}
 	if err := s3utils.CheckListObjsArgs(ctx, bucket, prefix, marker); err != nil {
 		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
@@ -642,10 +1606,19 @@ func (s3a *s3ApiServer) ListObjectsV2Handler(w http.ResponseWriter, r *http.Requ
 	response.WriteSuccessResponseXML(w, r, resp)
 }
 
+// getBucketAndObject returns the bucket and the object key of the request.
+//
+// Object keys are percent-decoded exactly once, the same way the signature
+// is calculated, so that the key which is signed, stored and listed is the same:
+//   - the request path is already decoded by net/http, it must not be decoded again,
+//     otherwise keys like "100%" or "a%2520b" break. As in AWS S3 a '+' in the path
+//     is a plus sign, a space is sent as %20.
+//   - X-Amz-Copy-Source is decoded by unescapePath with the same rules as the path.
+//   - the prefix, marker and start-after query values are decoded by url.ParseQuery.
 func getBucketAndObject(r *http.Request) (bucket, object string, err error) {
 	vars := mux.Vars(r)
 	bucket = vars["bucket"]
-	object, err = unescapePath(vars["object"])
+	object = trimLeadingSlash(vars["object"])
 	return
 }
 
@@ -674,6 +1647,20 @@ func setPutObjHeaders(w http.ResponseWriter, objInfo store.ObjectInfo, delete bo
 	}
 }
 
+// setDeleteMarkerHeaders sets the headers of a failed GET or HEAD which ran into a delete marker,
+// the latest version of the object or the requested version
+func (s3a *s3ApiServer) setDeleteMarkerHeaders(ctx context.Context, w http.ResponseWriter, bucket, object, versionID string, err error) {
+	if !xerrors.Is(err, store.ErrObjectNotFound) && !xerrors.Is(err, store.ErrVersionIsDeleteMarker) {
+		return
+	}
+	marker, ok, err := s3a.store.GetDeleteMarker(ctx, bucket, object, versionID)
+	if err != nil || !ok {
+		return
+	}
+	w.Header()[consts.AmzDeleteMarker] = []string{strconv.FormatBool(true)}
+	w.Header()[consts.AmzVersionID] = []string{marker.VersionID}
+}
+
 func pathToBucketAndObject(path string) (bucket, object string) {
 	path = strings.TrimPrefix(path, consts.SlashSeparator)
 	idx := strings.Index(path, consts.SlashSeparator)
@@ -708,6 +1695,41 @@ func getListObjectsV1Args(values url.Values) (prefix, marker, delimiter string,
 	return
 }
 
+// Parse bucket url queries for ListObjectVersions, the version-id-marker is only valid with a key-marker.
+func getListObjectVersionsArgs(values url.Values) (prefix, keyMarker, versionIDMarker, delimiter string, maxkeys int, encodingType string, errCode apierrors.ErrorCode) {
+	errCode = apierrors.ErrNone
+
+	if values.Get("max-keys") != "" {
+		var err error
+		if maxkeys, err = strconv.Atoi(values.Get("max-keys")); err != nil || maxkeys < 0 {
+			errCode = apierrors.ErrInvalidMaxKeys
+			return
+		}
+		if maxkeys > consts.MaxObjectList {
+			maxkeys = consts.MaxObjectList
+		}
+	} else {
+		maxkeys = consts.MaxObjectList
+	}
+
+	prefix = trimLeadingSlash(values.Get("prefix"))
+	keyMarker = trimLeadingSlash(values.Get("key-marker"))
+	versionIDMarker = values.Get("version-id-marker")
+	if versionIDMarker != "" {
+		if keyMarker == "" {
+			errCode = apierrors.ErrInvalidVersionID
+			return
+		}
+		if _, err := uuid.Parse(versionIDMarker); err != nil && versionIDMarker != store.NullVersionID {
+			errCode = apierrors.ErrInvalidVersionID
+			return
+		}
+	}
+	delimiter = values.Get("delimiter")
+	encodingType = values.Get("encoding-type")
+	return
+}
+
 // Parse bucket url queries for ListObjects V2.
 func getListObjectsV2Args(values url.Values) (prefix, token, startAfter, delimiter string, fetchOwner bool, maxkeys int, encodingType string, errCode apierrors.ErrorCode) {
 	errCode = apierrors.ErrNone
@@ -767,7 +1789,6 @@ func trimLeadingSlash(ep string) string {
 
 // Validate all the ListObjects query arguments, returns an APIErrorCode
 // if one of the args do not meet the required conditions.
-//   - delimiter if set should be equal to '/', otherwise the request is rejected.
 //   - marker if set should have a common prefix with 'prefix' param, otherwise
 //     the request is rejected.
 func validateListObjectsArgs(marker, delimiter, encodingType string, maxKeys int) apierrors.ErrorCode {
@@ -786,8 +1807,8 @@ func validateListObjectsArgs(marker, delimiter, encodingType string, maxKeys int
 	return apierrors.ErrNone
 }
 
-// unescapePath is similar to url.PathUnescape or url.QueryUnescape
-// depending on input, additionally also handles situations such as
+// unescapePath decodes an escaped object path like url.PathUnescape,
+// '+' is kept as is, additionally also handles situations such as
 // `//` are normalized as `/`, also removes any `/` prefix before
 // returning.
 func unescapePath(p string) (string, error) {
@@ -0,0 +1,126 @@
+package s3api
+
+import (
+	"context"
+	"fmt"
+	"github.com/filedag-project/filedag-storage/objectservice/consts"
+	"github.com/filedag-project/filedag-storage/objectservice/store"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// checkPreconditions - validates the conditional headers of GET/HEAD object.
+// It writes the response and returns true when the request must not go on.
+//   - If-None-Match: return 304 Not Modified when the ETag matches.
+//   - If-Modified-Since: return 304 Not Modified when the object is not modified since,
+//     ignored when If-None-Match is present as per RFC 7232 section 3.3.
+func checkPreconditions(ctx context.Context, w http.ResponseWriter, r *http.Request, objInfo store.ObjectInfo) bool {
+	// Return false for methods other than GET and HEAD.
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	ifNoneMatchETagHeader := r.Header.Get(consts.IfNoneMatch)
+	if ifNoneMatchETagHeader != "" {
+		if isETagEqual(objInfo.ETag, ifNoneMatchETagHeader) {
+			// If the object ETag matches with the specified ETag.
+			writeNotModified(w, objInfo)
+			return true
+		}
+		return false
+	}
+
+	ifModifiedSinceHeader := r.Header.Get(consts.IfModifiedSince)
+	if ifModifiedSinceHeader != "" {
+		if givenTime, err := http.ParseTime(ifModifiedSinceHeader); err == nil {
+			if !ifModifiedSince(objInfo.ModTime, givenTime) {
+				// If the object is not modified since the specified time.
+				writeNotModified(w, objInfo)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ifModifiedSince returns true if the object was modified after givenTime,
+// the HTTP dates have a one second resolution.
+func ifModifiedSince(objTime time.Time, givenTime time.Time) bool {
+	return objTime.Truncate(time.Second).After(givenTime)
+}
+
+// writeNotModified writes the validators of the object with a 304 status,
+// the body is never sent.
+func writeNotModified(w http.ResponseWriter, objInfo store.ObjectInfo) {
+	// Headers which must be sent with a 304 as per RFC 7232 section 4.1.
+	if objInfo.ETag != "" {
+		w.Header()[consts.ETag] = []string{"\"" + objInfo.ETag + "\""}
+	}
+	if objInfo.CacheControl != "" {
+		w.Header().Set(consts.CacheControl, objInfo.CacheControl)
+	}
+	if !objInfo.Expires.IsZero() {
+		w.Header().Set(consts.Expires, objInfo.Expires.UTC().Format(http.TimeFormat))
+	}
+	w.Header().Set(consts.LastModified, objInfo.ModTime.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// isETagEqual returns true if the object etag matches one of the etags
+// of a comma separated If-Match/If-None-Match header value.
+func isETagEqual(objectETag string, headerETags string) bool {
+	for _, etag := range strings.Split(headerETags, ",") {
+		etag = strings.TrimSpace(etag)
+		if etag == "*" {
+			return true
+		}
+		// Weak comparison, see RFC 7232 section 2.3.2.
+		etag = strings.TrimPrefix(etag, "W/")
+		if canonicalizeETag(etag) == canonicalizeETag(objectETag) {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalizeETag returns ETag with leading and trailing double-quotes removed,
+// if any present
+func canonicalizeETag(etag string) string {
+	return strings.Trim(etag, "\"")
+}
+
+// setBucketCacheControl falls back to the default Cache-Control of the bucket
+// when the object has none of its own.
+func (s3a *s3ApiServer) setBucketCacheControl(ctx context.Context, bucket string, objInfo *store.ObjectInfo) {
+	if objInfo.CacheControl != "" {
+		return
+	}
+	cacheControl, err := s3a.bmSys.GetBucketCacheControl(ctx, bucket)
+	if err != nil {
+		log.Warnf("get bucket %s cache control err:%v", bucket, err)
+		return
+	}
+	objInfo.CacheControl = cacheControl
+}
+
+// setExpirationHeader sets x-amz-expiration when a lifecycle rule of the bucket
+// expires the object, so that the clients know when it's going away.
+func (s3a *s3ApiServer) setExpirationHeader(ctx context.Context, w http.ResponseWriter, bucket string, objInfo store.ObjectInfo) {
+	lc, err := s3a.bmSys.GetLifecycleConfig(ctx, bucket)
+	if err != nil {
+		if _, ok := err.(store.BucketLifecycleNotFound); !ok {
+			log.Warnf("get bucket %s lifecycle err:%v", bucket, err)
+		}
+		return
+	}
+	if lc, err = s3a.store.NormalizeLifecycle(ctx, bucket, lc); err != nil {
+		log.Warnf("normalize bucket %s lifecycle err:%v", bucket, err)
+		return
+	}
+	expiry, ruleID := lc.PredictExpiry(objInfo.Name, objInfo.ModTime, objInfo.Tags)
+	if expiry.IsZero() {
+		return
+	}
+	w.Header().Set(consts.AmzExpiration, fmt.Sprintf(`expiry-date="%s", rule-id="%s"`, expiry.Format(http.TimeFormat), ruleID))
+}
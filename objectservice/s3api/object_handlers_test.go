@@ -2,17 +2,57 @@ package s3api
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/xml"
 	"fmt"
+	dagpoolcli "github.com/filedag-project/filedag-storage/dag/pool/client"
+	"github.com/filedag-project/filedag-storage/dag/pool/mocks"
+	"github.com/filedag-project/filedag-storage/dag/pool/poolservice/dpuser/upolicy"
+	"github.com/filedag-project/filedag-storage/dag/pool/server"
+	"github.com/filedag-project/filedag-storage/dag/proto"
+	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
+	"github.com/filedag-project/filedag-storage/objectservice/consts"
 	"github.com/filedag-project/filedag-storage/objectservice/datatypes"
+	"github.com/filedag-project/filedag-storage/objectservice/iam"
 	"github.com/filedag-project/filedag-storage/objectservice/iam/auth"
+	"github.com/filedag-project/filedag-storage/objectservice/iam/policy"
 	"github.com/filedag-project/filedag-storage/objectservice/response"
+	"github.com/filedag-project/filedag-storage/objectservice/store"
 	"github.com/filedag-project/filedag-storage/objectservice/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/ipfs/go-merkledag"
+	mdtest "github.com/ipfs/go-merkledag/test"
+	ft "github.com/ipfs/go-unixfs"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"hash/crc32"
+	"io"
 	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
 	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 const (
@@ -223,6 +263,186 @@ func TestS3ApiServer_HeadObjectHandler(t *testing.T) {
 	}
 
 }
+
+// noReadDAGService fails the test when a node is read, the requests which must not read the content use it
+type noReadDAGService struct {
+	ipld.DAGService
+	t *testing.T
+}
+
+func (d *noReadDAGService) Get(ctx context.Context, c cid.Cid) (ipld.Node, error) {
+	d.t.Errorf("the node %v is read", c)
+	return nil, ipld.ErrNotFound{Cid: c}
+}
+
+func (d *noReadDAGService) GetMany(ctx context.Context, cids []cid.Cid) <-chan *ipld.NodeOption {
+	d.t.Errorf("the nodes %v are read", cids)
+	out := make(chan *ipld.NodeOption)
+	close(out)
+	return out
+}
+
+func TestS3ApiServer_HeadObjectMetadataOnly(t *testing.T) {
+	bucketName := "testbucketheadmeta"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+	r1 := "1234567"
+	reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/obj", int64(len(r1)), bytes.NewReader([]byte(r1)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	reqPutObject.Header.Set(consts.ContentType, "text/plain")
+	reqPutObject.Header.Set("X-Amz-Meta-Owner", "me")
+	require.Equal(t, http.StatusOK, reqTest(reqPutObject).Code)
+	objInfo, err := storageSys.GetObjectInfo(context.TODO(), bucketName, "obj", "")
+	require.NoError(t, err)
+
+	// the metadata is served from the object info, the dag isn't read
+	dagPool := storageSys.DagPool
+	storageSys.DagPool = &noReadDAGService{DAGService: dagPool, t: t}
+	defer func() {
+		storageSys.DagPool = dagPool
+	}()
+	result := reqTest(utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/obj", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Empty(t, result.Body.String())
+	require.Equal(t, strconv.Itoa(len(r1)), result.Header().Get(consts.ContentLength))
+	require.Equal(t, "text/plain", result.Header().Get(consts.ContentType))
+	require.Equal(t, "\""+objInfo.ETag+"\"", headerValue(result.Header(), consts.ETag))
+	require.Equal(t, objInfo.ModTime.UTC().Format(http.TimeFormat), result.Header().Get(consts.LastModified))
+	require.Equal(t, "me", result.Header().Get("X-Amz-Meta-Owner"))
+
+	// the missing object is NoSuchKey, with the headers only
+	result = reqTest(utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/nosuchobject", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	require.Equal(t, apierrors.GetAPIError(apierrors.ErrNoSuchKey).HTTPStatusCode, result.Code)
+	require.Empty(t, result.Body.String())
+}
+
+func TestS3ApiServer_PutObjectHeadObject(t *testing.T) {
+	bucketName := "testbucketputhead"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+
+	testCases := []struct {
+		objectName  string
+		contentType string
+	}{
+		{objectName: "testobject.json", contentType: "application/json"},
+		{objectName: "testobject.txt", contentType: "text/plain; charset=utf-8"},
+	}
+	r1 := "hello filedag"
+	for i, testCase := range testCases {
+		reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+testCase.objectName, int64(len(r1)), bytes.NewReader([]byte(r1)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		reqPutObject.Header.Set(consts.ContentType, testCase.contentType)
+		putResult := reqTest(reqPutObject)
+		require.Equal(t, http.StatusOK, putResult.Code, "Case %d", i+1)
+
+		req := utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/"+testCase.objectName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result := reqTest(req)
+		require.Equal(t, http.StatusOK, result.Code, "Case %d", i+1)
+		require.Equal(t, strconv.Itoa(len(r1)), result.Header().Get(consts.ContentLength), "Case %d", i+1)
+		require.Equal(t, testCase.contentType, result.Header().Get(consts.ContentType), "Case %d", i+1)
+		// the ETag is set as "ETag" rather than the canonical "Etag"
+		require.NotEmpty(t, putResult.Header()[consts.ETag], "Case %d", i+1)
+		require.Equal(t, putResult.Header()[consts.ETag], result.Header()[consts.ETag], "Case %d", i+1)
+	}
+}
+
+func TestS3ApiServer_PutObjectIfNoneMatch(t *testing.T) {
+	bucketName := "testbucketifnonematch"
+	objectPath := "/" + bucketName + "/testobject"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+
+	putObject := func(content string, ifNoneMatch bool) *httptest.ResponseRecorder {
+		req := utils.MustNewSignedV4Request(http.MethodPut, objectPath, int64(len(content)), strings.NewReader(content), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		if ifNoneMatch {
+			req.Header.Set(consts.IfNoneMatch, "*")
+		}
+		return reqTest(req)
+	}
+	// the ETag is set as "ETag" rather than the canonical "Etag"
+	etag := func(result *httptest.ResponseRecorder) string {
+		return strings.Join(result.Header()[consts.ETag], ",")
+	}
+	headETag := func() string {
+		req := utils.MustNewSignedV4Request(http.MethodHead, objectPath, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result := reqTest(req)
+		require.Equal(t, http.StatusOK, result.Code)
+		return etag(result)
+	}
+
+	first := putObject("first content", true)
+	require.Equal(t, http.StatusOK, first.Code)
+	require.NotEmpty(t, etag(first))
+	second := putObject("second content", true)
+	require.Equal(t, http.StatusPreconditionFailed, second.Code)
+	require.Contains(t, second.Body.String(), "<Code>PreconditionFailed</Code>")
+	require.Equal(t, etag(first), headETag())
+
+	// a PUT without the precondition still overwrites the object
+	third := putObject("third content", false)
+	require.Equal(t, http.StatusOK, third.Code)
+	require.NotEqual(t, etag(first), etag(third))
+	require.Equal(t, etag(third), headETag())
+}
+
+func TestS3ApiServer_WebsiteRedirectLocation(t *testing.T) {
+	bucketName := "testbucketredirect"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+
+	redirect := func(result *httptest.ResponseRecorder) string {
+		return strings.Join(result.Header()[consts.AmzWebsiteRedirectLocation], ",")
+	}
+	objectRedirect := func(objectName string) (string, string) {
+		reqGet := utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/"+objectName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		getResult := reqTest(reqGet)
+		require.Equal(t, http.StatusOK, getResult.Code)
+		reqHead := utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/"+objectName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		headResult := reqTest(reqHead)
+		require.Equal(t, http.StatusOK, headResult.Code)
+		return redirect(getResult), redirect(headResult)
+	}
+	// the mock pool returns this content for every node, so that the copies read it back
+	content := "1234567"
+	for i, testCase := range []struct {
+		location           string
+		expectedRespStatus int
+	}{
+		{location: "/new/index.html", expectedRespStatus: http.StatusOK},
+		{location: "https://example.com/index.html", expectedRespStatus: http.StatusOK},
+		{location: "new/index.html", expectedRespStatus: http.StatusBadRequest},
+	} {
+		objectName := fmt.Sprintf("old%d.html", i)
+		req := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+objectName, int64(len(content)), strings.NewReader(content), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		req.Header.Set(consts.AmzWebsiteRedirectLocation, testCase.location)
+		result := reqTest(req)
+		require.Equal(t, testCase.expectedRespStatus, result.Code, "Case %d", i+1)
+		if testCase.expectedRespStatus != http.StatusOK {
+			require.Contains(t, result.Body.String(), "<Code>InvalidRedirectLocation</Code>", "Case %d", i+1)
+			continue
+		}
+		getLocation, headLocation := objectRedirect(objectName)
+		require.Equal(t, testCase.location, getLocation, "Case %d", i+1)
+		require.Equal(t, testCase.location, headLocation, "Case %d", i+1)
+	}
+
+	// the redirect location isn't copied from the source, it's set by the copy request
+	copyObject := func(dstObject, location string) {
+		req := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+dstObject, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		req.Header.Set(consts.AmzCopySource, utils.EncodePath(bucketName+"/old0.html"))
+		if location != "" {
+			req.Header.Set(consts.AmzWebsiteRedirectLocation, location)
+		}
+		result := reqTest(req)
+		require.Equal(t, http.StatusOK, result.Code, result.Body.String())
+	}
+	copyObject("copy0.html", "")
+	getLocation, _ := objectRedirect("copy0.html")
+	require.Empty(t, getLocation)
+	copyObject("copy1.html", "/copy/index.html")
+	getLocation, _ = objectRedirect("copy1.html")
+	require.Equal(t, "/copy/index.html", getLocation)
+}
+
 func TestS3ApiServer_DeleteObjectHandler(t *testing.T) {
 	bucketName := "testbucketdelo"
 	objectName := "testobjectdelo"
@@ -340,6 +560,56 @@ func TestS3ApiServer_DeleteMultipleObjectsHandler(t *testing.T) {
 	require.Zero(t, len(deleteResp.Errors))
 }
 
+func TestS3ApiServer_DeleteMultipleObjectsPartialFailure(t *testing.T) {
+	bucketName := "testbucketdelobjspartial"
+
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+
+	// half of the objects fail, the others are deleted
+	delObjReq := datatypes.DeleteObjectsRequest{}
+	failed := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		objName := fmt.Sprintf("obj%d", i)
+		if i%2 == 0 {
+			objName = fmt.Sprintf("bad/../obj%d", i)
+			failed[objName] = true
+		} else {
+			data := "1234567"
+			reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+objName, int64(len(data)), bytes.NewReader([]byte(data)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+			require.Equal(t, http.StatusOK, reqTest(reqPutObject).Code)
+		}
+		delObjReq.Objects = append(delObjReq.Objects, datatypes.ObjectToDelete{
+			ObjectV: datatypes.ObjectV{
+				ObjectName: objName,
+			},
+		})
+	}
+	// the duplicate object is reported once
+	delObjReq.Objects = append(delObjReq.Objects, delObjReq.Objects[1])
+
+	deleteReqBytes, err := xml.Marshal(delObjReq)
+	require.NoError(t, err)
+	req := utils.MustNewSignedV4Request(http.MethodPost, "/"+bucketName+"?delete=", int64(len(deleteReqBytes)),
+		bytes.NewReader(deleteReqBytes), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(req)
+	require.Equal(t, http.StatusOK, result.Code)
+	deleteResp := response.DeleteObjectsResponse{}
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &deleteResp))
+
+	require.Len(t, deleteResp.DeletedObjects, 2)
+	for _, obj := range deleteResp.DeletedObjects {
+		require.False(t, failed[obj.ObjectName], "the object %s should fail", obj.ObjectName)
+		reqHeadObject := utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/"+obj.ObjectName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		require.Equal(t, http.StatusNotFound, reqTest(reqHeadObject).Code)
+	}
+	require.Len(t, deleteResp.Errors, 2)
+	for _, delErr := range deleteResp.Errors {
+		require.True(t, failed[delErr.Key], "the object %s should be deleted", delErr.Key)
+		require.Equal(t, "InvalidObjectName", delErr.Code)
+	}
+}
+
 func TestS3ApiServer_CopyObjectHandler(t *testing.T) {
 	bucketName := "testbucketcopy"
 	objectName := "testobjectcopy"
@@ -406,6 +676,17 @@ func TestS3ApiServer_CopyObjectHandler(t *testing.T) {
 			secretKey:          DefaultTestSecretKey,
 			expectedRespStatus: http.StatusBadRequest,
 		},
+		// the copy onto itself replaces the metadata
+		{
+			bucketName:         bucketName,
+			objectName:         objectName,
+			dstbucketName:      bucketName,
+			dstobjectName:      objectName,
+			header:             http.Header{"X-Amz-Metadata-Directive": []string{"REPLACE"}, consts.ContentType: []string{"text/plain"}},
+			accessKey:          DefaultTestAccessKey,
+			secretKey:          DefaultTestSecretKey,
+			expectedRespStatus: http.StatusOK,
+		},
 	}
 	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
 	fmt.Println("putbucket:", reqTest(reqPutBucket).Body.String())
@@ -417,6 +698,9 @@ func TestS3ApiServer_CopyObjectHandler(t *testing.T) {
 	for i, testCase := range testCases {
 		req := utils.MustNewSignedV4Request(http.MethodPut, "/"+testCase.dstbucketName+"/"+testCase.dstobjectName, 0, nil, "s3", testCase.accessKey, testCase.secretKey, t)
 		req.Header.Set("X-Amz-Copy-Source", url.QueryEscape("/"+testCase.bucketName+"/"+testCase.objectName)) // Add test case specific headers to the request.
+		for key, values := range testCase.header {
+			req.Header[key] = values
+		}
 		result := reqTest(req)
 		if result.Code != testCase.expectedRespStatus {
 			t.Fatalf("Case %d: Expected the response status to be `%d`, but instead found `%d`", i+1, testCase.expectedRespStatus, result.Code)
@@ -424,6 +708,18 @@ func TestS3ApiServer_CopyObjectHandler(t *testing.T) {
 		fmt.Printf("Case %d: copy:%v\n", i+1, result.Body.String())
 	}
 
+	// the copy shares the content of the source, the replaced metadata is kept by the source only
+	src := reqTest(utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/"+objectName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	dst := reqTest(utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/1.txt", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	if src.Code != http.StatusOK || dst.Code != http.StatusOK {
+		t.Fatalf("unexpected head status %d and %d", src.Code, dst.Code)
+	}
+	if headerValue(src.Header(), consts.ETag) == "" || headerValue(src.Header(), consts.ETag) != headerValue(dst.Header(), consts.ETag) {
+		t.Fatalf("the copy must have the ETag %v of the source, got %v", headerValue(src.Header(), consts.ETag), headerValue(dst.Header(), consts.ETag))
+	}
+	if headerValue(src.Header(), consts.ContentType) != "text/plain" || headerValue(dst.Header(), consts.ContentType) == "text/plain" {
+		t.Fatalf("unexpected content types %v and %v", headerValue(src.Header(), consts.ContentType), headerValue(dst.Header(), consts.ContentType))
+	}
 }
 func TestS3ApiServer_ListObjectsV2Handler(t *testing.T) {
 	bucketName := "testbucketlist"
@@ -481,6 +777,57 @@ func TestS3ApiServer_ListObjectsV2Handler(t *testing.T) {
 
 }
 
+func TestS3ApiServer_ListObjectsGzip(t *testing.T) {
+	bucketName := "testbucketlistgzip"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+	r1 := "1234567"
+	const objectCount = 100
+	for i := 0; i < objectCount; i++ {
+		reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, fmt.Sprintf("/%s/dir/object-%03d", bucketName, i), int64(len(r1)), bytes.NewReader([]byte(r1)),
+			"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		require.Equal(t, http.StatusOK, reqTest(reqPutObject).Code)
+	}
+
+	// the large listing is compressed
+	req := utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"?list-type=2", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	req.Header.Set(consts.AcceptEncoding, "deflate, gzip;q=0.8")
+	result := reqTest(req)
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, "gzip", result.Header().Get(consts.ContentEncoding))
+	require.Equal(t, strconv.Itoa(result.Body.Len()), result.Header().Get(consts.ContentLength))
+	zr, err := gzip.NewReader(result.Body)
+	require.NoError(t, err)
+	var listing response.ListObjectsV2Response
+	require.NoError(t, xml.NewDecoder(zr).Decode(&listing))
+	require.Len(t, listing.Contents, objectCount)
+
+	// the client doesn't accept it
+	for _, acceptEncoding := range []string{"", "identity", "gzip;q=0"} {
+		req = utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"?list-type=2", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		req.Header.Set(consts.AcceptEncoding, acceptEncoding)
+		result = reqTest(req)
+		require.Equal(t, http.StatusOK, result.Code)
+		require.Empty(t, result.Header().Get(consts.ContentEncoding), acceptEncoding)
+		var plain response.ListObjectsV2Response
+		require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &plain))
+		require.Len(t, plain.Contents, objectCount)
+	}
+
+	// the small listing and the object body aren't compressed
+	req = utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"?list-type=2&max-keys=1", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	req.Header.Set(consts.AcceptEncoding, "gzip")
+	result = reqTest(req)
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Empty(t, result.Header().Get(consts.ContentEncoding))
+	req = utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/dir/object-000", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	req.Header.Set(consts.AcceptEncoding, "gzip")
+	result = reqTest(req)
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Empty(t, result.Header().Get(consts.ContentEncoding))
+	require.Equal(t, r1, result.Body.String())
+}
+
 func TestWholeNoUserAPI(t *testing.T) {
 	bucketName := "testbucketwhole"
 	objectName := "testobjectwhole"
@@ -517,3 +864,1965 @@ func addCustomHeaders(req *http.Request, customHeaders http.Header) {
 		}
 	}
 }
+
+func TestS3ApiServer_ObjectCacheHeaders(t *testing.T) {
+	bucketName := "testbucketcache"
+	objectName := "testobjectcache"
+	defaultObjectName := "testobjectcachedefault"
+	cacheControl := "public, max-age=3600"
+	bucketCacheControl := "max-age=60"
+
+	// the Cache-Control of the create request isn't the default of the bucket, the admin api sets it
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	reqPutBucket.Header.Set(consts.CacheControl, "no-store")
+	result := reqTest(reqPutBucket)
+	require.Equal(t, http.StatusOK, result.Code)
+	defaultCacheControl, err := bmSys.GetBucketCacheControl(context.TODO(), bucketName)
+	require.NoError(t, err)
+	require.Empty(t, defaultCacheControl)
+	require.NoError(t, bmSys.UpdateBucketCacheControl(context.TODO(), bucketName, bucketCacheControl))
+
+	r1 := "1234567"
+	reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+objectName, int64(len(r1)), bytes.NewReader([]byte(r1)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	reqPutObject.Header.Set(consts.CacheControl, cacheControl)
+	result = reqTest(reqPutObject)
+	require.Equal(t, http.StatusOK, result.Code)
+	reqPutObject = utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+defaultObjectName, int64(len(r1)), bytes.NewReader([]byte(r1)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqPutObject)
+	require.Equal(t, http.StatusOK, result.Code)
+
+	// GET and HEAD carry the ETag and the Cache-Control of the object.
+	var etag string
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		req := utils.MustNewSignedV4Request(method, "/"+bucketName+"/"+objectName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result = reqTest(req)
+		require.Equal(t, http.StatusOK, result.Code)
+		require.Equal(t, cacheControl, result.Header().Get(consts.CacheControl))
+		require.Len(t, result.Header()[consts.ETag], 1)
+		etag = result.Header()[consts.ETag][0]
+	}
+
+	// The object without Cache-Control falls back to the bucket default.
+	req := utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/"+defaultObjectName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(req)
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, bucketCacheControl, result.Header().Get(consts.CacheControl))
+
+	// Revalidation with a matching ETag returns 304 without a body.
+	testCases := []struct {
+		method             string
+		ifNoneMatch        string
+		expectedRespStatus int
+	}{
+		{method: http.MethodGet, ifNoneMatch: etag, expectedRespStatus: http.StatusNotModified},
+		{method: http.MethodHead, ifNoneMatch: etag, expectedRespStatus: http.StatusNotModified},
+		{method: http.MethodGet, ifNoneMatch: "W/" + etag, expectedRespStatus: http.StatusNotModified},
+		{method: http.MethodGet, ifNoneMatch: "\"abc\", " + etag, expectedRespStatus: http.StatusNotModified},
+		{method: http.MethodGet, ifNoneMatch: "\"abc\"", expectedRespStatus: http.StatusOK},
+	}
+	for i, testCase := range testCases {
+		req := utils.MustNewSignedV4Request(testCase.method, "/"+bucketName+"/"+objectName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		req.Header.Set(consts.IfNoneMatch, testCase.ifNoneMatch)
+		result := reqTest(req)
+		if result.Code != testCase.expectedRespStatus {
+			t.Fatalf("Case %d: Expected the response status to be `%d`, but instead found `%d`", i+1, testCase.expectedRespStatus, result.Code)
+		}
+		if result.Code == http.StatusNotModified {
+			require.Equal(t, []string{etag}, result.Header()[consts.ETag])
+			require.Equal(t, cacheControl, result.Header().Get(consts.CacheControl))
+			require.Zero(t, result.Body.Len())
+		}
+	}
+}
+
+func TestS3ApiServer_ObjectContentHeaders(t *testing.T) {
+	bucketName := "testbucketcontent"
+	objectName := "testobjectcontent"
+	header := http.Header{}
+	header.Set(consts.ContentDisposition, `attachment; filename="report.pdf"`)
+	header.Set(consts.CacheControl, "no-cache")
+	header.Set(consts.ContentLanguage, "en-US")
+
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+	r1 := "1234567"
+	reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+objectName, int64(len(r1)), bytes.NewReader([]byte(r1)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	addCustomHeaders(reqPutObject, header)
+	require.Equal(t, http.StatusOK, reqTest(reqPutObject).Code)
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		req := utils.MustNewSignedV4Request(method, "/"+bucketName+"/"+objectName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result := reqTest(req)
+		require.Equal(t, http.StatusOK, result.Code)
+		for k := range header {
+			require.Equal(t, header.Get(k), result.Header().Get(k))
+		}
+	}
+
+	// The response-content-disposition query overrides the stored value.
+	override := `attachment; filename="friendly.pdf"`
+	req := utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/"+objectName+"?response-content-disposition="+url.QueryEscape(override), 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(req)
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, override, result.Header().Get(consts.ContentDisposition))
+	require.Equal(t, r1, result.Body.String())
+}
+
+func TestS3ApiServer_CopyObjectHandlerSSEC(t *testing.T) {
+	bucketName := "testbucketcopyssec"
+	objectName := "testobjectcopyssec"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+	r1 := "1234567"
+	reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+objectName, int64(len(r1)), bytes.NewReader([]byte(r1)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutObject).Code)
+
+	for i, h := range []string{consts.AmzServerSideEncryptionCopyCustomerKey, consts.AmzServerSideEncryptionCustomerKey} {
+		req := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/1.txt", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		req.Header.Set(consts.AmzCopySource, url.QueryEscape("/"+bucketName+"/"+objectName))
+		req.Header.Set(h, "MzJieXRlc2xvbmdzZWNyZXRrZXltdXN0cHJvdmlkZWQ=")
+		result := reqTest(req)
+		if result.Code != http.StatusNotImplemented {
+			t.Fatalf("Case %d: Expected the response status to be `%d`, but instead found `%d`", i+1, http.StatusNotImplemented, result.Code)
+		}
+	}
+}
+
+func TestS3ApiServer_CopyObjectVersion(t *testing.T) {
+	bucketName := "testbucketcopyversion"
+	u := "/" + bucketName + "/obj"
+	dagPool := storageSys.DagPool
+	storageSys.DagPool = mdtest.Mock()
+	defer func() {
+		storageSys.DagPool = dagPool
+	}()
+	require.Equal(t, http.StatusOK, reqTest(utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)).Code)
+	config := `<VersioningConfiguration><Status>Enabled</Status></VersioningConfiguration>`
+	reqPut := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"?versioning", int64(len(config)), strings.NewReader(config),
+		"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPut).Code)
+	putObject := func(content string) string {
+		result := reqTest(utils.MustNewSignedV4Request(http.MethodPut, u, int64(len(content)), strings.NewReader(content), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+		require.Equal(t, http.StatusOK, result.Code)
+		return headerValue(result.Header(), consts.AmzVersionID)
+	}
+	copyObject := func(dst, copySource string) *httptest.ResponseRecorder {
+		req := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+dst, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		req.Header.Set(consts.AmzCopySource, copySource)
+		return reqTest(req)
+	}
+	getObject := func(object string) string {
+		result := reqTest(utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/"+object, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+		require.Equal(t, http.StatusOK, result.Code)
+		return result.Body.String()
+	}
+	oldVersion := putObject("old")
+	currentVersion := putObject("current")
+
+	// the noncurrent version is copied, not the current object
+	result := copyObject("copy", utils.EncodePath(bucketName+"/obj")+"?versionId="+oldVersion)
+	require.Equal(t, http.StatusOK, result.Code, result.Body.String())
+	require.Equal(t, oldVersion, result.Header().Get(consts.AmzCopySourceVersionID))
+	require.Equal(t, "old", getObject("copy"))
+	// the current object is copied without a version ID, the header is its version
+	result = copyObject("copy", utils.EncodePath(bucketName+"/obj"))
+	require.Equal(t, http.StatusOK, result.Code, result.Body.String())
+	require.Equal(t, currentVersion, result.Header().Get(consts.AmzCopySourceVersionID))
+	require.Equal(t, "current", getObject("copy"))
+
+	// the noncurrent version copied onto the object restores it as the current object
+	result = copyObject("obj", utils.EncodePath(bucketName+"/obj")+"?versionId="+oldVersion)
+	require.Equal(t, http.StatusOK, result.Code, result.Body.String())
+	require.Equal(t, "old", getObject("obj"))
+
+	result = copyObject("copy", utils.EncodePath(bucketName+"/obj")+"?versionId=nosuchversion")
+	require.Equal(t, http.StatusBadRequest, result.Code, result.Body.String())
+	result = copyObject("copy", utils.EncodePath(bucketName+"/obj")+"?versionId="+uuid.NewString())
+	require.Equal(t, http.StatusNotFound, result.Code, result.Body.String())
+}
+
+func TestS3ApiServer_ObjectExpirationHeader(t *testing.T) {
+	bucketName := "testbucketexpiration"
+	objectName := "logs/testobject"
+	otherObjectName := "testobject"
+
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(reqPutBucket)
+	require.Equal(t, http.StatusOK, result.Code)
+	for _, object := range []string{objectName, otherObjectName} {
+		r1 := "1234567"
+		reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+object, int64(len(r1)), bytes.NewReader([]byte(r1)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result = reqTest(reqPutObject)
+		require.Equal(t, http.StatusOK, result.Code)
+	}
+
+	// No lifecycle, no expiration.
+	req := utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/"+objectName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(req)
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Empty(t, result.Header().Get(consts.AmzExpiration))
+
+	err := bmSys.UpdateBucketLifecycle(context.TODO(), bucketName, &store.Lifecycle{
+		Rules: []store.LifecycleRule{
+			{
+				ID:         "disabled-rule",
+				Status:     "Disabled",
+				Expiration: &store.LifecycleExpiration{Days: 1},
+			},
+			{
+				ID:         "logs-rule",
+				Status:     store.LifecycleEnabled,
+				Filter:     &store.LifecycleFilter{Prefix: "logs/"},
+				Expiration: &store.LifecycleExpiration{Days: 3},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		req := utils.MustNewSignedV4Request(method, "/"+bucketName+"/"+objectName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result = reqTest(req)
+		require.Equal(t, http.StatusOK, result.Code)
+		modTime, err := http.ParseTime(result.Header().Get(consts.LastModified))
+		require.NoError(t, err)
+		expiry := modTime.UTC().AddDate(0, 0, 4).Truncate(24 * time.Hour)
+		expected := fmt.Sprintf(`expiry-date="%s", rule-id="logs-rule"`, expiry.Format(http.TimeFormat))
+		require.Equal(t, expected, result.Header().Get(consts.AmzExpiration))
+	}
+
+	// The object out of the rule prefix doesn't expire.
+	req = utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/"+otherObjectName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(req)
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Empty(t, result.Header().Get(consts.AmzExpiration))
+}
+
+// newPostPolicyRequest returns a browser POST upload request of the form fields and the file,
+// the policy is signed with signature V4.
+func newPostPolicyRequest(t *testing.T, bucketName, policy string, fields map[string]string, content []byte) *http.Request {
+	now := time.Now().UTC()
+	credential := fmt.Sprintf("%s/%s/us-east-1/s3/aws4_request", DefaultTestAccessKey, now.Format("20060102"))
+	encodedPolicy := base64.StdEncoding.EncodeToString([]byte(policy))
+	signingKey := utils.GetSigningKey(DefaultTestSecretKey, now, "us-east-1", "s3")
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	formFields := map[string]string{
+		"policy":           encodedPolicy,
+		"x-amz-algorithm":  "AWS4-HMAC-SHA256",
+		"x-amz-credential": credential,
+		"x-amz-date":       now.Format("20060102T150405Z"),
+		"x-amz-signature":  utils.GetSignature(signingKey, encodedPolicy),
+	}
+	for k, v := range fields {
+		formFields[k] = v
+	}
+	for k, v := range formFields {
+		require.NoError(t, writer.WriteField(k, v))
+	}
+	// The file must be the last field of the form.
+	file, err := writer.CreateFormFile("file", "upload.txt")
+	require.NoError(t, err)
+	_, err = file.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/"+bucketName, body)
+	req.Header.Set(consts.ContentType, writer.FormDataContentType())
+	return req
+}
+
+func TestS3ApiServer_PostPolicyBucketHandler(t *testing.T) {
+	bucketName := "testbucketpostpolicy"
+	content := []byte("1234567")
+
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(reqPutBucket)
+	require.Equal(t, http.StatusOK, result.Code)
+
+	expiration := time.Now().UTC().Add(time.Hour).Format(time.RFC3339)
+	// Each form field but the policy and the signature must be in the conditions.
+	signConditions := `["starts-with", "$x-amz-algorithm", ""], ["starts-with", "$x-amz-credential", ""], ["starts-with", "$x-amz-date", ""]`
+	policy := fmt.Sprintf(`{"expiration": "%s", "conditions": [{"bucket": "%s"}, ["starts-with", "$key", "uploads/"], {"acl": "private"}, ["starts-with", "$success_action_status", ""], ["starts-with", "$success_action_redirect", ""], ["content-length-range", 1, 1024], %s]}`, expiration, bucketName, signConditions)
+	expiredPolicy := fmt.Sprintf(`{"expiration": "%s", "conditions": [{"bucket": "%s"}, ["starts-with", "$key", ""], %s]}`, time.Now().UTC().Add(-time.Hour).Format(time.RFC3339), bucketName, signConditions)
+
+	testCases := []struct {
+		policy             string
+		fields             map[string]string
+		content            []byte
+		expectedObject     string
+		expectedRespStatus int
+		expectedErrCode    string
+	}{
+		// Test case - 1.
+		// Upload with a valid policy.
+		{
+			policy:             policy,
+			fields:             map[string]string{"key": "uploads/object1", "acl": "private"},
+			expectedObject:     "uploads/object1",
+			expectedRespStatus: http.StatusNoContent,
+		},
+		// Test case - 2.
+		// The ${filename} in the key is replaced with the name of the file.
+		{
+			policy:             policy,
+			fields:             map[string]string{"key": "uploads/${filename}", "acl": "private", "success_action_status": "201"},
+			expectedObject:     "uploads/upload.txt",
+			expectedRespStatus: http.StatusCreated,
+		},
+		// Test case - 3.
+		// Redirect to success_action_redirect.
+		{
+			policy:             policy,
+			fields:             map[string]string{"key": "uploads/object3", "acl": "private", "success_action_redirect": "http://localhost/done"},
+			expectedObject:     "uploads/object3",
+			expectedRespStatus: http.StatusSeeOther,
+		},
+		// Test case - 4.
+		// The acl doesn't match the policy.
+		{
+			policy:             policy,
+			fields:             map[string]string{"key": "uploads/object4", "acl": "public-read"},
+			expectedRespStatus: http.StatusForbidden,
+		},
+		// Test case - 5.
+		// The policy is expired.
+		{
+			policy:             expiredPolicy,
+			fields:             map[string]string{"key": "uploads/object5"},
+			expectedRespStatus: http.StatusForbidden,
+		},
+		// Test case - 6.
+		// The form is tampered after signing.
+		{
+			policy:             policy,
+			fields:             map[string]string{"key": "uploads/object6", "acl": "private", "x-amz-signature": "0000"},
+			expectedRespStatus: http.StatusForbidden,
+		},
+		// Test case - 7.
+		// The key doesn't start with the prefix of the policy.
+		{
+			policy:             policy,
+			fields:             map[string]string{"key": "other/object7", "acl": "private"},
+			expectedRespStatus: http.StatusForbidden,
+		},
+		// Test case - 8.
+		// A form field which is not in the policy.
+		{
+			policy:             policy,
+			fields:             map[string]string{"key": "uploads/object8", "acl": "private", "x-amz-meta-owner": "someone"},
+			expectedRespStatus: http.StatusForbidden,
+		},
+		// Test case - 9.
+		// The x-ignore- fields need no condition.
+		{
+			policy:             policy,
+			fields:             map[string]string{"key": "uploads/object9", "acl": "private", "x-ignore-note": "anything"},
+			expectedObject:     "uploads/object9",
+			expectedRespStatus: http.StatusNoContent,
+		},
+		// Test case - 10.
+		// The file is smaller than the content-length-range.
+		{
+			policy:             policy,
+			fields:             map[string]string{"key": "uploads/object10", "acl": "private"},
+			content:            []byte{},
+			expectedRespStatus: http.StatusBadRequest,
+			expectedErrCode:    "EntityTooSmall",
+		},
+		// Test case - 11.
+		// The file is larger than the content-length-range.
+		{
+			policy:             policy,
+			fields:             map[string]string{"key": "uploads/object11", "acl": "private"},
+			content:            bytes.Repeat([]byte("a"), 1025),
+			expectedRespStatus: http.StatusBadRequest,
+			expectedErrCode:    "EntityTooLarge",
+		},
+		// Test case - 12.
+		// The file is exactly the max of the content-length-range.
+		{
+			policy:             policy,
+			fields:             map[string]string{"key": "uploads/object12", "acl": "private"},
+			content:            bytes.Repeat([]byte("a"), 1024),
+			expectedObject:     "uploads/object12",
+			expectedRespStatus: http.StatusNoContent,
+		},
+	}
+	for i, testCase := range testCases {
+		fileContent := content
+		if testCase.content != nil {
+			fileContent = testCase.content
+		}
+		req := newPostPolicyRequest(t, bucketName, testCase.policy, testCase.fields, fileContent)
+		result := reqTest(req)
+		if result.Code != testCase.expectedRespStatus {
+			t.Fatalf("Case %d: Expected the response status to be `%d`, but instead found `%d`: %s", i+1, testCase.expectedRespStatus, result.Code, result.Body.String())
+		}
+		if testCase.expectedErrCode != "" {
+			var errResp apierrors.RESTErrorResponse
+			require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &errResp))
+			if errResp.Code != testCase.expectedErrCode {
+				t.Fatalf("Case %d: Expected the error code to be `%s`, but instead found `%s`", i+1, testCase.expectedErrCode, errResp.Code)
+			}
+		}
+		if testCase.expectedObject == "" {
+			continue
+		}
+		if result.Code == http.StatusCreated {
+			var resp response.PostResponse
+			require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &resp))
+			require.Equal(t, bucketName, resp.Bucket)
+			require.Equal(t, testCase.expectedObject, resp.Key)
+		}
+		reqHeadObject := utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/"+testCase.expectedObject, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result = reqTest(reqHeadObject)
+		require.Equal(t, http.StatusOK, result.Code)
+		require.Equal(t, strconv.Itoa(len(fileContent)), result.Header().Get(consts.ContentLength))
+	}
+}
+
+func TestS3ApiServer_ObjectKeyEncoding(t *testing.T) {
+	bucketName := "testbucketkeyencoding"
+	keys := []string{
+		"key with spaces.txt",
+		"a+b.txt",
+		"unicode/日本語-ключ.txt",
+		"100%.txt",
+		"already%20encoded%2B.txt",
+	}
+
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(reqPutBucket)
+	require.Equal(t, http.StatusOK, result.Code)
+
+	r1 := "1234567"
+	for i, key := range keys {
+		// Clients percent-encode the key once in the path.
+		reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+utils.EncodePath(key), int64(len(r1)), bytes.NewReader([]byte(r1)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result = reqTest(reqPutObject)
+		if result.Code != http.StatusOK {
+			t.Fatalf("Case %d: Expected put %q to be `%d`, but instead found `%d`: %s", i+1, key, http.StatusOK, result.Code, result.Body.String())
+		}
+		reqHeadObject := utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/"+utils.EncodePath(key), 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result = reqTest(reqHeadObject)
+		if result.Code != http.StatusOK {
+			t.Fatalf("Case %d: Expected head %q to be `%d`, but instead found `%d`", i+1, key, http.StatusOK, result.Code)
+		}
+		// The copy source is decoded the same way as the path.
+		reqCopyObject := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+utils.EncodePath("copy/"+key), 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		reqCopyObject.Header.Set(consts.AmzCopySource, utils.EncodePath(bucketName+"/"+key))
+		result = reqTest(reqCopyObject)
+		if result.Code != http.StatusOK {
+			t.Fatalf("Case %d: Expected copy %q to be `%d`, but instead found `%d`: %s", i+1, key, http.StatusOK, result.Code, result.Body.String())
+		}
+	}
+
+	// A '+' sent as is in the path is a plus sign, not a space.
+	reqHeadObject := utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/a+b.txt", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqHeadObject)
+	require.Equal(t, http.StatusOK, result.Code)
+	reqHeadObject = utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/a%20b.txt", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqHeadObject)
+	require.Equal(t, http.StatusNotFound, result.Code)
+
+	// The listing returns the keys as they were put.
+	reqListObjects := utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"?list-type=2", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqListObjects)
+	require.Equal(t, http.StatusOK, result.Code)
+	var listResp response.ListObjectsV2Response
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &listResp))
+	var listed []string
+	for _, content := range listResp.Contents {
+		listed = append(listed, content.Key)
+	}
+	var expected []string
+	for _, key := range keys {
+		expected = append(expected, key, "copy/"+key)
+	}
+	require.ElementsMatch(t, expected, listed)
+}
+
+// countingDAGService counts the reads of the dag
+type countingDAGService struct {
+	ipld.DAGService
+	gets int32
+}
+
+func (c *countingDAGService) Get(ctx context.Context, cid cid.Cid) (ipld.Node, error) {
+	atomic.AddInt32(&c.gets, 1)
+	return c.DAGService.Get(ctx, cid)
+}
+
+func (c *countingDAGService) GetMany(ctx context.Context, cids []cid.Cid) <-chan *ipld.NodeOption {
+	atomic.AddInt32(&c.gets, int32(len(cids)))
+	return c.DAGService.GetMany(ctx, cids)
+}
+
+func TestS3ApiServer_GetObjectNotModifiedSkipsDag(t *testing.T) {
+	bucketName := "testbucketnotmodified"
+	objectName := "testobjectnotmodified"
+
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(reqPutBucket)
+	require.Equal(t, http.StatusOK, result.Code)
+	r1 := "1234567"
+	reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+objectName, int64(len(r1)), bytes.NewReader([]byte(r1)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqPutObject)
+	require.Equal(t, http.StatusOK, result.Code)
+	etag := result.Header()[consts.ETag][0]
+
+	dagPool := &countingDAGService{DAGService: storageSys.DagPool}
+	storageSys.DagPool = dagPool
+	defer func() {
+		storageSys.DagPool = dagPool.DAGService
+	}()
+
+	testCases := []struct {
+		header             string
+		value              string
+		expectedRespStatus int
+		expectedDagGets    bool
+	}{
+		// Test case - 1.
+		// The ETag matches, no body.
+		{header: consts.IfNoneMatch, value: etag, expectedRespStatus: http.StatusNotModified},
+		// Test case - 2.
+		// Not modified since a time in the future, no body.
+		{header: consts.IfModifiedSince, value: time.Now().UTC().Add(time.Hour).Format(http.TimeFormat), expectedRespStatus: http.StatusNotModified},
+		// Test case - 3.
+		// Modified since, the body is read from the dag.
+		{header: consts.IfModifiedSince, value: time.Now().UTC().Add(-time.Hour).Format(http.TimeFormat), expectedRespStatus: http.StatusOK, expectedDagGets: true},
+		// Test case - 4.
+		// The ETag doesn't match, the body is read from the dag.
+		{header: consts.IfNoneMatch, value: "\"abc\"", expectedRespStatus: http.StatusOK, expectedDagGets: true},
+	}
+	for i, testCase := range testCases {
+		atomic.StoreInt32(&dagPool.gets, 0)
+		req := utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/"+objectName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		req.Header.Set(testCase.header, testCase.value)
+		result := reqTest(req)
+		if result.Code != testCase.expectedRespStatus {
+			t.Fatalf("Case %d: Expected the response status to be `%d`, but instead found `%d`", i+1, testCase.expectedRespStatus, result.Code)
+		}
+		gets := atomic.LoadInt32(&dagPool.gets)
+		if testCase.expectedDagGets != (gets > 0) {
+			t.Fatalf("Case %d: Expected dag reads %v, but instead found %d", i+1, testCase.expectedDagGets, gets)
+		}
+	}
+}
+
+func TestS3ApiServer_AppendObjectHandler(t *testing.T) {
+	bucketName := "testbucketappend"
+	objectName := "testobjectappend"
+
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(reqPutBucket)
+	require.Equal(t, http.StatusOK, result.Code)
+
+	r1 := "1234567"
+	// the object is created by the first append
+	reqAppend := utils.MustNewSignedV4Request(http.MethodPost, "/"+bucketName+"/"+objectName+"?append", int64(len(r1)), bytes.NewReader([]byte(r1)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqAppend)
+	require.Equal(t, http.StatusOK, result.Code)
+	reqAppend = utils.MustNewSignedV4Request(http.MethodPost, "/"+bucketName+"/"+objectName+"?append", int64(len(r1)), bytes.NewReader([]byte(r1)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqAppend)
+	require.Equal(t, http.StatusOK, result.Code)
+	require.True(t, strings.HasSuffix(result.Header()[consts.ETag][0], `-2"`))
+
+	reqHeadObject := utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/"+objectName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqHeadObject)
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, strconv.Itoa(2*len(r1)), result.Header().Get(consts.ContentLength))
+
+	// anonymous appends are denied
+	reqAppend = utils.MustNewSignedV4Request(http.MethodPost, "/"+bucketName+"/"+objectName+"?append", int64(len(r1)), bytes.NewReader([]byte(r1)), "s3", "", "", t)
+	result = reqTest(reqAppend)
+	require.Equal(t, http.StatusForbidden, result.Code)
+}
+
+func TestS3ApiServer_GetObjectAttributesHandler(t *testing.T) {
+	bucketName := "testbucketattributes"
+	objectName := "testobjectattributes"
+
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(reqPutBucket)
+	require.Equal(t, http.StatusOK, result.Code)
+
+	r1 := "1234567"
+	reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+objectName, int64(len(r1)), bytes.NewReader([]byte(r1)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqPutObject)
+	require.Equal(t, http.StatusOK, result.Code)
+
+	reqAttributes := utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/"+objectName+"?attributes", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	reqAttributes.Header.Set(consts.AmzObjectAttributes, "ETag,Checksum,ObjectSize")
+	result = reqTest(reqAttributes)
+	require.Equal(t, http.StatusOK, result.Code)
+	var resp response.GetObjectAttributesResponse
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &resp))
+	sum := sha256.Sum256([]byte(r1))
+	require.NotNil(t, resp.Checksum)
+	require.Equal(t, base64.StdEncoding.EncodeToString(sum[:]), resp.Checksum.ChecksumSHA256)
+	require.NotEmpty(t, resp.ETag)
+	require.NotNil(t, resp.ObjectSize)
+	require.Equal(t, int64(len(r1)), *resp.ObjectSize)
+	require.Empty(t, resp.StorageClass)
+
+	// an unknown attribute
+	reqAttributes = utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/"+objectName+"?attributes", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	reqAttributes.Header.Set(consts.AmzObjectAttributes, "ETag,Owner")
+	result = reqTest(reqAttributes)
+	require.Equal(t, http.StatusBadRequest, result.Code)
+}
+
+func TestS3ApiServer_GetObjectAttributesMultipartChecksums(t *testing.T) {
+	bucketName := "testbucketpartchecksums"
+	objectName := "testobjectpartchecksums"
+
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(reqPutBucket)
+	require.Equal(t, http.StatusOK, result.Code)
+
+	reqNewUpload := utils.MustNewSignedV4Request(http.MethodPost, "/"+bucketName+"/"+objectName+"?uploads", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqNewUpload)
+	require.Equal(t, http.StatusOK, result.Code)
+	var initiated response.InitiateMultipartUploadResponse
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &initiated))
+
+	parts := [][]byte{bytes.Repeat([]byte("a"), consts.MinPartSize), []byte("1234567")}
+	var complete datatypes.CompleteMultipartUpload
+	var partChecksums []string
+	composite := sha256.New()
+	for i, part := range parts {
+		reqPutPart := utils.MustNewSignedV4Request(http.MethodPut, fmt.Sprintf("/%s/%s?partNumber=%d&uploadId=%s", bucketName, objectName, i+1, initiated.UploadID),
+			int64(len(part)), bytes.NewReader(part), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result = reqTest(reqPutPart)
+		require.Equal(t, http.StatusOK, result.Code)
+		sum := sha256.Sum256(part)
+		composite.Write(sum[:])
+		partChecksums = append(partChecksums, base64.StdEncoding.EncodeToString(sum[:]))
+		// the part checksum is returned by UploadPart
+		require.Equal(t, partChecksums[i], result.Header().Get(consts.AmzChecksumSHA256))
+		complete.Parts = append(complete.Parts, datatypes.CompletePart{PartNumber: i + 1, ETag: result.Header()[consts.ETag][0]})
+	}
+	expectedComposite := fmt.Sprintf("%s-%d", base64.StdEncoding.EncodeToString(composite.Sum(nil)), len(parts))
+	completeBody, err := xml.Marshal(complete)
+	require.NoError(t, err)
+	reqComplete := utils.MustNewSignedV4Request(http.MethodPost, "/"+bucketName+"/"+objectName+"?uploadId="+initiated.UploadID,
+		int64(len(completeBody)), bytes.NewReader(completeBody), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqComplete)
+	require.Equal(t, http.StatusOK, result.Code)
+	var completed response.CompleteMultipartUploadResponse
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &completed))
+	require.Equal(t, expectedComposite, completed.ChecksumSHA256)
+
+	reqAttributes := utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/"+objectName+"?attributes", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	reqAttributes.Header.Set(consts.AmzObjectAttributes, "Checksum,ObjectParts")
+	result = reqTest(reqAttributes)
+	require.Equal(t, http.StatusOK, result.Code)
+	var resp response.GetObjectAttributesResponse
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Checksum)
+	require.Equal(t, expectedComposite, resp.Checksum.ChecksumSHA256)
+	require.Equal(t, "COMPOSITE", resp.Checksum.ChecksumType)
+	require.NotNil(t, resp.ObjectParts)
+	require.Equal(t, len(parts), resp.ObjectParts.PartsCount)
+	require.False(t, resp.ObjectParts.IsTruncated)
+	require.Len(t, resp.ObjectParts.Parts, len(parts))
+	for i, part := range resp.ObjectParts.Parts {
+		require.Equal(t, i+1, part.PartNumber)
+		require.Equal(t, int64(len(parts[i])), part.Size)
+		require.Equal(t, partChecksums[i], part.ChecksumSHA256)
+	}
+
+	// the parts are paged by the max parts and the part number marker
+	reqAttributes = utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/"+objectName+"?attributes", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	reqAttributes.Header.Set(consts.AmzObjectAttributes, "ObjectParts")
+	reqAttributes.Header.Set(consts.AmzMaxParts, "1")
+	result = reqTest(reqAttributes)
+	require.Equal(t, http.StatusOK, result.Code)
+	resp = response.GetObjectAttributesResponse{}
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &resp))
+	require.True(t, resp.ObjectParts.IsTruncated)
+	require.Equal(t, 1, resp.ObjectParts.NextPartNumberMarker)
+	require.Len(t, resp.ObjectParts.Parts, 1)
+
+	reqAttributes = utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/"+objectName+"?attributes", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	reqAttributes.Header.Set(consts.AmzObjectAttributes, "ObjectParts")
+	reqAttributes.Header.Set(consts.AmzMaxParts, "1")
+	reqAttributes.Header.Set(consts.AmzPartNumberMarker, "1")
+	result = reqTest(reqAttributes)
+	require.Equal(t, http.StatusOK, result.Code)
+	resp = response.GetObjectAttributesResponse{}
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &resp))
+	require.False(t, resp.ObjectParts.IsTruncated)
+	require.Len(t, resp.ObjectParts.Parts, 1)
+	require.Equal(t, 2, resp.ObjectParts.Parts[0].PartNumber)
+	require.Equal(t, partChecksums[1], resp.ObjectParts.Parts[0].ChecksumSHA256)
+}
+
+// midStreamFailingDAGService serves the root of an object with two blocks, the second one can't be read
+type midStreamFailingDAGService struct {
+	ipld.DAGService
+	root  cid.Cid
+	nodes map[cid.Cid]ipld.Node
+}
+
+func (m *midStreamFailingDAGService) Get(ctx context.Context, c cid.Cid) (ipld.Node, error) {
+	if c == m.root {
+		c = cid.Undef
+	}
+	if nd, ok := m.nodes[c]; ok {
+		return nd, nil
+	}
+	return nil, ipld.ErrNotFound{Cid: c}
+}
+
+func (m *midStreamFailingDAGService) GetMany(ctx context.Context, cids []cid.Cid) <-chan *ipld.NodeOption {
+	out := make(chan *ipld.NodeOption, len(cids))
+	defer close(out)
+	// the cids aren't in the order of the links, the found nodes are sent before the failure
+	var failure error
+	for _, c := range cids {
+		nd, err := m.Get(ctx, c)
+		if err != nil {
+			failure = err
+			continue
+		}
+		out <- &ipld.NodeOption{Node: nd}
+	}
+	if failure != nil {
+		out <- &ipld.NodeOption{Err: failure}
+	}
+	return out
+}
+
+func TestS3ApiServer_GetObjectMidStreamFailure(t *testing.T) {
+	bucketName := "testbucketmidstream"
+	objectName := "testobjectmidstream"
+	server := httptest.NewServer(router)
+	defer server.Close()
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+	// the first block is bigger than the buffer of the response, so that it's sent before the failure
+	firstData := bytes.Repeat([]byte("a"), 8<<10)
+	r1 := string(firstData) + "abcdefg"
+	reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+objectName, int64(len(r1)), bytes.NewReader([]byte(r1)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutObject).Code)
+	objInfo, err := storageSys.GetObjectInfo(context.TODO(), bucketName, objectName, "")
+	require.NoError(t, err)
+	root, err := cid.Decode(objInfo.Cid)
+	require.NoError(t, err)
+
+	// the object is read from a root of two blocks, the first one is sent before the second one fails
+	first := merkledag.NodeWithData(ft.FilePBData(firstData, uint64(len(firstData))))
+	second := merkledag.NodeWithData(ft.FilePBData([]byte("abcdefg"), 7))
+	rootNode := ft.EmptyFileNode()
+	fsNode, err := ft.FSNodeFromBytes(rootNode.Data())
+	require.NoError(t, err)
+	require.NoError(t, rootNode.AddNodeLink("", first))
+	fsNode.AddBlockSize(uint64(len(firstData)))
+	require.NoError(t, rootNode.AddNodeLink("", second))
+	fsNode.AddBlockSize(7)
+	data, err := fsNode.GetBytes()
+	require.NoError(t, err)
+	rootNode.SetData(data)
+	dagPool := storageSys.DagPool
+	storageSys.DagPool = &midStreamFailingDAGService{
+		DAGService: dagPool,
+		root:       root,
+		nodes:      map[cid.Cid]ipld.Node{cid.Undef: rootNode, first.Cid(): first},
+	}
+	defer func() {
+		storageSys.DagPool = dagPool
+	}()
+
+	client := server.Client()
+	// reused reports whether the request is sent on a kept-alive connection
+	reused := func(req *http.Request) bool {
+		var reused bool
+		trace := &httptrace.ClientTrace{GotConn: func(info httptrace.GotConnInfo) { reused = info.Reused }}
+		resp, err := client.Do(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+		require.NoError(t, err)
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		return reused
+	}
+	reused(utils.MustNewSignedV4Request(http.MethodGet, server.URL+"/status", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	require.True(t, reused(utils.MustNewSignedV4Request(http.MethodGet, server.URL+"/status", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)))
+
+	req := utils.MustNewSignedV4Request(http.MethodGet, server.URL+"/"+bucketName+"/"+objectName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Error(t, err, "the half body must not look complete")
+	require.Equal(t, string(firstData), string(body))
+
+	// the connection of the half response is closed, not reused
+	require.False(t, reused(utils.MustNewSignedV4Request(http.MethodGet, server.URL+"/status", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)))
+}
+
+func TestS3ApiServer_MultipartPartsCount(t *testing.T) {
+	bucketName := "testbucketpartscount"
+	objectName := "testobjectpartscount"
+
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(reqPutBucket)
+	require.Equal(t, http.StatusOK, result.Code)
+
+	reqNewUpload := utils.MustNewSignedV4Request(http.MethodPost, "/"+bucketName+"/"+objectName+"?uploads", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqNewUpload)
+	require.Equal(t, http.StatusOK, result.Code)
+	var initiated response.InitiateMultipartUploadResponse
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &initiated))
+
+	parts := [][]byte{bytes.Repeat([]byte("a"), consts.MinPartSize), []byte("1234567")}
+	var complete datatypes.CompleteMultipartUpload
+	for i, part := range parts {
+		reqPutPart := utils.MustNewSignedV4Request(http.MethodPut, fmt.Sprintf("/%s/%s?partNumber=%d&uploadId=%s", bucketName, objectName, i+1, initiated.UploadID),
+			int64(len(part)), bytes.NewReader(part), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result = reqTest(reqPutPart)
+		require.Equal(t, http.StatusOK, result.Code)
+		complete.Parts = append(complete.Parts, datatypes.CompletePart{PartNumber: i + 1, ETag: result.Header()[consts.ETag][0]})
+	}
+	completeBody, err := xml.Marshal(complete)
+	require.NoError(t, err)
+	reqComplete := utils.MustNewSignedV4Request(http.MethodPost, "/"+bucketName+"/"+objectName+"?uploadId="+initiated.UploadID,
+		int64(len(completeBody)), bytes.NewReader(completeBody), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqComplete)
+	require.Equal(t, http.StatusOK, result.Code)
+
+	// the multipart object reports its parts count
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req := utils.MustNewSignedV4Request(method, "/"+bucketName+"/"+objectName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result = reqTest(req)
+		require.Equal(t, http.StatusOK, result.Code, method)
+		require.Equal(t, []string{strconv.Itoa(len(parts))}, result.Header()[consts.AmzMpPartsCount], method)
+	}
+
+	// a part is addressed by its number
+	reqHeadPart := utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/"+objectName+"?partNumber=2", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqHeadPart)
+	require.Equal(t, http.StatusPartialContent, result.Code)
+	require.Equal(t, strconv.Itoa(len(parts[1])), result.Header().Get(consts.ContentLength))
+	require.Equal(t, fmt.Sprintf("bytes %d-%d/%d", len(parts[0]), len(parts[0])+len(parts[1])-1, len(parts[0])+len(parts[1])),
+		result.Header().Get(consts.ContentRange))
+	require.Equal(t, []string{strconv.Itoa(len(parts))}, result.Header()[consts.AmzMpPartsCount])
+
+	reqHeadPart = utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/"+objectName+"?partNumber=3", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqHeadPart)
+	require.Equal(t, http.StatusRequestedRangeNotSatisfiable, result.Code)
+
+	reqGetPart := utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/"+objectName+"?partNumber=1", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	reqGetPart.Header.Set(consts.Range, "bytes=0-1")
+	result = reqTest(reqGetPart)
+	require.Equal(t, http.StatusBadRequest, result.Code)
+
+	// a single part object doesn't report the parts count
+	r1 := "1234567"
+	reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/single", int64(len(r1)), bytes.NewReader([]byte(r1)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqPutObject)
+	require.Equal(t, http.StatusOK, result.Code)
+	reqGetObject := utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/single", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqGetObject)
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Empty(t, result.Header()[consts.AmzMpPartsCount])
+
+	reqGetPart = utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/single?partNumber=1", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqGetPart)
+	require.Equal(t, http.StatusPartialContent, result.Code)
+	require.Equal(t, r1, result.Body.String())
+	require.Equal(t, fmt.Sprintf("bytes 0-%d/%d", len(r1)-1, len(r1)), result.Header().Get(consts.ContentRange))
+}
+
+func TestS3ApiServer_CompleteMultipartUploadValidation(t *testing.T) {
+	bucketName := "testbucketcompleteparts"
+	objectName := "multipart"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+	reqNewUpload := utils.MustNewSignedV4Request(http.MethodPost, "/"+bucketName+"/"+objectName+"?uploads", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(reqNewUpload)
+	require.Equal(t, http.StatusOK, result.Code)
+	var initiated response.InitiateMultipartUploadResponse
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &initiated))
+
+	parts := [][]byte{bytes.Repeat([]byte("a"), consts.MinPartSize), []byte("1234567")}
+	var uploaded []datatypes.CompletePart
+	for i, part := range parts {
+		reqPutPart := utils.MustNewSignedV4Request(http.MethodPut, fmt.Sprintf("/%s/%s?partNumber=%d&uploadId=%s", bucketName, objectName, i+1, initiated.UploadID),
+			int64(len(part)), bytes.NewReader(part), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result = reqTest(reqPutPart)
+		require.Equal(t, http.StatusOK, result.Code)
+		uploaded = append(uploaded, datatypes.CompletePart{PartNumber: i + 1, ETag: result.Header()[consts.ETag][0]})
+	}
+	complete := func(parts ...datatypes.CompletePart) *httptest.ResponseRecorder {
+		completeBody, err := xml.Marshal(datatypes.CompleteMultipartUpload{Parts: parts})
+		require.NoError(t, err)
+		reqComplete := utils.MustNewSignedV4Request(http.MethodPost, "/"+bucketName+"/"+objectName+"?uploadId="+initiated.UploadID,
+			int64(len(completeBody)), bytes.NewReader(completeBody), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		return reqTest(reqComplete)
+	}
+	requireError := func(result *httptest.ResponseRecorder, code apierrors.ErrorCode) {
+		apiErr := apierrors.GetAPIError(code)
+		require.Equal(t, apiErr.HTTPStatusCode, result.Code)
+		require.Contains(t, result.Body.String(), "<Code>"+apiErr.Code+"</Code>")
+	}
+
+	mismatched := uploaded[1]
+	mismatched.ETag = fmt.Sprintf("%032x", 1)
+	requireError(complete(uploaded[0], mismatched), apierrors.ErrInvalidPart)
+	requireError(complete(uploaded[0], uploaded[1], datatypes.CompletePart{PartNumber: 3, ETag: uploaded[1].ETag}), apierrors.ErrInvalidPart)
+	requireError(complete(uploaded[1], uploaded[0]), apierrors.ErrInvalidPartOrder)
+	requireError(complete(uploaded[0], uploaded[0], uploaded[1]), apierrors.ErrInvalidPartOrder)
+
+	// the upload is kept by the rejected completions
+	require.Equal(t, http.StatusOK, complete(uploaded...).Code)
+	reqGetObject := utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/"+objectName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqGetObject)
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, strconv.Itoa(len(parts[0])+len(parts[1])), result.Header().Get(consts.ContentLength))
+}
+
+func TestS3ApiServer_PayloadHashPolicy(t *testing.T) {
+	bucketName := "testbucketpayloadhash"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+	defer authSys.SetPayloadHashPolicy(iam.PayloadHashAlways)
+
+	content := "1234567"
+	// putObject puts the content signed by the payload hash, a missing one if it's empty
+	putObject := func(payloadHash string) int {
+		req, err := utils.NewRequest(http.MethodPut, "/"+bucketName+"/obj", int64(len(content)), bytes.NewReader([]byte(content)))
+		require.NoError(t, err)
+		req.Header.Del(consts.AmzContentSha256)
+		if payloadHash != "" {
+			req.Header.Set(consts.AmzContentSha256, payloadHash)
+		}
+		require.NoError(t, utils.SignRequestV4(req, DefaultTestAccessKey, DefaultTestSecretKey, "s3"))
+		return reqTest(req).Code
+	}
+	sum := sha256.Sum256([]byte(content))
+	otherSum := sha256.Sum256([]byte("7654321"))
+	testCases := []struct {
+		policy      iam.PayloadHashPolicy
+		payloadHash string
+		expected    int
+	}{
+		{iam.PayloadHashAlways, hex.EncodeToString(sum[:]), http.StatusOK},
+		{iam.PayloadHashAlways, hex.EncodeToString(otherSum[:]), http.StatusBadRequest},
+		{iam.PayloadHashAlways, "UNSIGNED-PAYLOAD", http.StatusBadRequest},
+		// a missing hash is the hash of the empty payload
+		{iam.PayloadHashAlways, "", http.StatusBadRequest},
+		{iam.PayloadHashNeverForUnsigned, hex.EncodeToString(sum[:]), http.StatusOK},
+		{iam.PayloadHashNeverForUnsigned, hex.EncodeToString(otherSum[:]), http.StatusBadRequest},
+		{iam.PayloadHashNeverForUnsigned, "UNSIGNED-PAYLOAD", http.StatusOK},
+		{iam.PayloadHashNeverForUnsigned, "", http.StatusBadRequest},
+		{iam.PayloadHashWhenPresent, hex.EncodeToString(sum[:]), http.StatusOK},
+		{iam.PayloadHashWhenPresent, hex.EncodeToString(otherSum[:]), http.StatusBadRequest},
+		{iam.PayloadHashWhenPresent, "UNSIGNED-PAYLOAD", http.StatusOK},
+		{iam.PayloadHashWhenPresent, "", http.StatusOK},
+	}
+	for i, testCase := range testCases {
+		require.NoError(t, authSys.SetPayloadHashPolicy(testCase.policy))
+		require.Equal(t, testCase.expected, putObject(testCase.payloadHash), "case %d: policy %s, payload hash %q", i+1, testCase.policy, testCase.payloadHash)
+	}
+
+	// a request without a payload has nothing to verify
+	require.NoError(t, authSys.SetPayloadHashPolicy(iam.PayloadHashAlways))
+	req, err := utils.NewRequest(http.MethodGet, "/"+bucketName+"/obj", 0, nil)
+	require.NoError(t, err)
+	req.Header.Set(consts.AmzContentSha256, "UNSIGNED-PAYLOAD")
+	require.NoError(t, utils.SignRequestV4(req, DefaultTestAccessKey, DefaultTestSecretKey, "s3"))
+	result := reqTest(req)
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, content, result.Body.String())
+
+	require.Error(t, authSys.SetPayloadHashPolicy("sometimes"))
+}
+
+func TestS3ApiServer_ObjectVersions(t *testing.T) {
+	u := "/testobjectversions"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, u, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+	config := `<VersioningConfiguration><Status>Enabled</Status></VersioningConfiguration>`
+	reqPut := utils.MustNewSignedV4Request(http.MethodPut, u+"?versioning", int64(len(config)), strings.NewReader(config),
+		"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPut).Code)
+
+	// the mock pool serves the same block for every cid, the versions are told apart by their IDs
+	putObject := func() string {
+		content := "1234567"
+		req := utils.MustNewSignedV4Request(http.MethodPut, u+"/obj", int64(len(content)), strings.NewReader(content), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result := reqTest(req)
+		require.Equal(t, http.StatusOK, result.Code)
+		versionID := headerValue(result.Header(), consts.AmzVersionID)
+		require.NotEmpty(t, versionID)
+		return versionID
+	}
+	v1 := putObject()
+	v2 := putObject()
+	require.NotEqual(t, v1, v2)
+
+	reqGet := utils.MustNewSignedV4Request(http.MethodGet, u+"/obj?versionId="+v1, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(reqGet)
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, "1234567", result.Body.String())
+	require.Equal(t, v1, headerValue(result.Header(), consts.AmzVersionID))
+	reqGet = utils.MustNewSignedV4Request(http.MethodGet, u+"/obj?versionId=notaversion", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusBadRequest, reqTest(reqGet).Code)
+
+	// the delete without a version adds a delete marker
+	reqDelete := utils.MustNewSignedV4Request(http.MethodDelete, u+"/obj", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqDelete)
+	require.Equal(t, http.StatusNoContent, result.Code)
+	require.Equal(t, "true", headerValue(result.Header(), consts.AmzDeleteMarker))
+	marker := headerValue(result.Header(), consts.AmzVersionID)
+	require.NotEmpty(t, marker)
+	// the reads of the object or of the marker by its version ID tell they ran into the delete marker
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		result = reqTest(utils.MustNewSignedV4Request(method, u+"/obj", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+		require.Equal(t, http.StatusNotFound, result.Code, method)
+		require.Equal(t, "true", headerValue(result.Header(), consts.AmzDeleteMarker), method)
+		require.Equal(t, marker, headerValue(result.Header(), consts.AmzVersionID), method)
+		result = reqTest(utils.MustNewSignedV4Request(method, u+"/obj?versionId="+marker, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+		require.Equal(t, http.StatusMethodNotAllowed, result.Code, method)
+		require.Equal(t, "true", headerValue(result.Header(), consts.AmzDeleteMarker), method)
+		require.Equal(t, marker, headerValue(result.Header(), consts.AmzVersionID), method)
+		// a noncurrent version isn't a delete marker
+		result = reqTest(utils.MustNewSignedV4Request(method, u+"/obj?versionId="+v1, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+		require.Equal(t, http.StatusOK, result.Code, method)
+		require.Empty(t, headerValue(result.Header(), consts.AmzDeleteMarker), method)
+	}
+	// a missing object has no delete marker
+	result = reqTest(utils.MustNewSignedV4Request(http.MethodGet, u+"/missing", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	require.Equal(t, http.StatusNotFound, result.Code)
+	require.Empty(t, headerValue(result.Header(), consts.AmzDeleteMarker))
+
+	reqList := utils.MustNewSignedV4Request(http.MethodGet, u+"?versions", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqList)
+	require.Equal(t, http.StatusOK, result.Code)
+	body := result.Body.String()
+	require.Contains(t, body, "<ListVersionsResult")
+	require.Equal(t, 1, strings.Count(body, "<DeleteMarker>"))
+	require.Equal(t, 2, strings.Count(body, "<Version>"))
+	require.Less(t, strings.Index(body, marker), strings.Index(body, v2))
+	require.Less(t, strings.Index(body, v2), strings.Index(body, v1))
+
+	// removing the delete marker restores the object
+	reqDelete = utils.MustNewSignedV4Request(http.MethodDelete, u+"/obj?versionId="+marker, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNoContent, reqTest(reqDelete).Code)
+	reqGet = utils.MustNewSignedV4Request(http.MethodGet, u+"/obj", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqGet)
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, v2, headerValue(result.Header(), consts.AmzVersionID))
+}
+
+func TestS3ApiServer_HeadDeleteMarkerNotFound(t *testing.T) {
+	u := "/testheaddeletemarker"
+	require.Equal(t, http.StatusOK, reqTest(utils.MustNewSignedV4Request(http.MethodPut, u, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)).Code)
+	config := `<VersioningConfiguration><Status>Enabled</Status></VersioningConfiguration>`
+	reqPut := utils.MustNewSignedV4Request(http.MethodPut, u+"?versioning", int64(len(config)), strings.NewReader(config),
+		"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPut).Code)
+	content := "1234567"
+	reqPut = utils.MustNewSignedV4Request(http.MethodPut, u+"/obj", int64(len(content)), strings.NewReader(content), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPut).Code)
+	result := reqTest(utils.MustNewSignedV4Request(http.MethodDelete, u+"/obj", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	require.Equal(t, http.StatusNoContent, result.Code)
+	marker := headerValue(result.Header(), consts.AmzVersionID)
+	require.NotEmpty(t, marker)
+
+	notFoundRouter := mux.NewRouter()
+	NewS3Server(notFoundRouter, authSys, bmSys, storageSys, WithHeadDeleteMarkerNotFound())
+	reqNotFound := func(r *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		notFoundRouter.ServeHTTP(w, r)
+		return w
+	}
+	testCases := []struct {
+		method, versionID          string
+		expected, expectedNotFound int
+	}{
+		{http.MethodHead, "", http.StatusNotFound, http.StatusNotFound},
+		{http.MethodHead, marker, http.StatusMethodNotAllowed, http.StatusNotFound},
+		{http.MethodGet, "", http.StatusNotFound, http.StatusNotFound},
+		{http.MethodGet, marker, http.StatusMethodNotAllowed, http.StatusMethodNotAllowed},
+	}
+	for _, testCase := range testCases {
+		path := u + "/obj"
+		if testCase.versionID != "" {
+			path += "?versionId=" + testCase.versionID
+		}
+		for i, serve := range []func(r *http.Request) *httptest.ResponseRecorder{reqTest, reqNotFound} {
+			expected := testCase.expected
+			if i == 1 {
+				expected = testCase.expectedNotFound
+			}
+			result = serve(utils.MustNewSignedV4Request(testCase.method, path, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+			require.Equal(t, expected, result.Code, "%s %s", testCase.method, path)
+			require.Equal(t, "true", headerValue(result.Header(), consts.AmzDeleteMarker), "%s %s", testCase.method, path)
+			require.Equal(t, marker, headerValue(result.Header(), consts.AmzVersionID), "%s %s", testCase.method, path)
+		}
+	}
+}
+
+// headerValue returns the value of the header set as a map entry, its key may not be canonical
+func headerValue(header http.Header, key string) string {
+	if v := header[key]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func TestS3ApiServer_PutObjectChunkedTransfer(t *testing.T) {
+	bucketName := "testbucketchunked"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	if result := reqTest(reqPutBucket); result.Code != http.StatusOK {
+		t.Fatalf("put bucket failed %d", result.Code)
+	}
+	content := "1234567"
+	newChunkedRequest := func(object string) *http.Request {
+		req := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+object, int64(len(content)), bytes.NewReader([]byte(content)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		req.ContentLength = -1
+		req.Header.Del(consts.ContentLength)
+		return req
+	}
+
+	// a body of unknown size is only accepted with the chunked transfer encoding
+	if result := reqTest(newChunkedRequest("nolength")); result.Code != http.StatusLengthRequired {
+		t.Fatalf("a body without Content-Length must be rejected, got %d", result.Code)
+	}
+	req := newChunkedRequest("chunked")
+	req.TransferEncoding = []string{"chunked"}
+	result := reqTest(req)
+	if result.Code != http.StatusOK {
+		t.Fatalf("the chunked put failed %d: %v", result.Code, result.Body.String())
+	}
+
+	// the size is the size of the content read
+	result = reqTest(utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/chunked", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	if result.Code != http.StatusOK || headerValue(result.Header(), consts.ContentLength) != strconv.Itoa(len(content)) {
+		t.Fatalf("unexpected size %v of the chunked object, status %d", headerValue(result.Header(), consts.ContentLength), result.Code)
+	}
+	result = reqTest(utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/chunked", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	if result.Code != http.StatusOK || result.Body.String() != content {
+		t.Fatalf("unexpected content %v of the chunked object, status %d", result.Body.String(), result.Code)
+	}
+
+	// a body read past the limit is rejected and the object isn't stored
+	defer func(limit int64) { maxChunkedObjectSize = limit }(maxChunkedObjectSize)
+	maxChunkedObjectSize = int64(len(content)) - 1
+	req = newChunkedRequest("toolarge")
+	req.TransferEncoding = []string{"chunked"}
+	result = reqTest(req)
+	if result.Code != http.StatusBadRequest || !strings.Contains(result.Body.String(), "EntityTooLarge") {
+		t.Fatalf("a chunked body over the limit must be rejected, got %d: %v", result.Code, result.Body.String())
+	}
+	result = reqTest(utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/toolarge", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	if result.Code != http.StatusNotFound {
+		t.Fatalf("the object over the limit must not be stored, got %d", result.Code)
+	}
+}
+
+func TestS3ApiServer_UserDefinedMetadata(t *testing.T) {
+	bucketName := "testbucketusermeta"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	if result := reqTest(reqPutBucket); result.Code != http.StatusOK {
+		t.Fatalf("put bucket failed %d", result.Code)
+	}
+	content := "1234567"
+	putObject := func(object string, header http.Header) *httptest.ResponseRecorder {
+		req := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+object, int64(len(content)), bytes.NewReader([]byte(content)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		return reqTest(req)
+	}
+	copyObject := func(src, dst string, header http.Header) {
+		req := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+dst, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		req.Header.Set(consts.AmzCopySource, utils.EncodePath(bucketName+"/"+src))
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if result := reqTest(req); result.Code != http.StatusOK {
+			t.Fatalf("copy %v to %v failed %d: %v", src, dst, result.Code, result.Body.String())
+		}
+	}
+	userMetadata := func(method, object string) map[string]string {
+		result := reqTest(utils.MustNewSignedV4Request(method, "/"+bucketName+"/"+object, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+		if result.Code != http.StatusOK {
+			t.Fatalf("%v %v failed %d", method, object, result.Code)
+		}
+		meta := make(map[string]string)
+		for key := range result.Header() {
+			if strings.HasPrefix(strings.ToLower(key), "x-amz-meta-") {
+				meta[strings.ToLower(key)] = result.Header().Get(key)
+			}
+		}
+		return meta
+	}
+
+	// the keys are kept in lowercase whatever their case in the request
+	header := http.Header{"X-Amz-Meta-Filename": []string{"report.pdf"}, "x-amz-meta-Checksum": []string{"abc"}}
+	if result := putObject("obj", header); result.Code != http.StatusOK {
+		t.Fatalf("put object failed %d", result.Code)
+	}
+	expected := map[string]string{"x-amz-meta-filename": "report.pdf", "x-amz-meta-checksum": "abc"}
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		if meta := userMetadata(method, "obj"); !reflect.DeepEqual(meta, expected) {
+			t.Fatalf("unexpected user metadata %v of %v, want %v", meta, method, expected)
+		}
+	}
+
+	// the copy keeps the metadata of the source unless it's replaced
+	copyObject("obj", "copied", nil)
+	if meta := userMetadata(http.MethodHead, "copied"); !reflect.DeepEqual(meta, expected) {
+		t.Fatalf("unexpected user metadata %v of the copy, want %v", meta, expected)
+	}
+	copyObject("obj", "replaced", http.Header{consts.AmzMetadataDirective: []string{"REPLACE"}, "X-Amz-Meta-Owner": []string{"me"}})
+	if meta := userMetadata(http.MethodHead, "replaced"); !reflect.DeepEqual(meta, map[string]string{"x-amz-meta-owner": "me"}) {
+		t.Fatalf("unexpected replaced user metadata %v", meta)
+	}
+
+	// the user metadata is 2KB at most
+	large := http.Header{"X-Amz-Meta-Large": []string{strings.Repeat("a", consts.MaxUserMetadataSize)}}
+	if result := putObject("large", large); result.Code != http.StatusBadRequest || !strings.Contains(result.Body.String(), "MetadataTooLarge") {
+		t.Fatalf("the metadata larger than 2KB must be rejected, got %d: %v", result.Code, result.Body.String())
+	}
+}
+
+func TestS3ApiServer_ObjectLegalHold(t *testing.T) {
+	bucketName := "testbucketlegalhold"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	if result := reqTest(reqPutBucket); result.Code != http.StatusOK {
+		t.Fatalf("put bucket failed %d", result.Code)
+	}
+	content := "1234567"
+	reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/obj", int64(len(content)), bytes.NewReader([]byte(content)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	if result := reqTest(reqPutObject); result.Code != http.StatusOK {
+		t.Fatalf("put object failed %d", result.Code)
+	}
+	putLegalHold := func(status string) *httptest.ResponseRecorder {
+		body := []byte("<LegalHold><Status>" + status + "</Status></LegalHold>")
+		req := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/obj?legal-hold", int64(len(body)), bytes.NewReader(body), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		return reqTest(req)
+	}
+	getLegalHold := func() string {
+		result := reqTest(utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/obj?legal-hold", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+		if result.Code != http.StatusOK {
+			t.Fatalf("get legal hold failed %d", result.Code)
+		}
+		var legalHold response.ObjectLegalHold
+		if err := xml.Unmarshal(result.Body.Bytes(), &legalHold); err != nil {
+			t.Fatal(err)
+		}
+		return legalHold.Status
+	}
+	deleteObject := func() *httptest.ResponseRecorder {
+		return reqTest(utils.MustNewSignedV4Request(http.MethodDelete, "/"+bucketName+"/obj", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	}
+
+	if status := getLegalHold(); status != "OFF" {
+		t.Fatalf("unexpected legal hold %v of a new object", status)
+	}
+	if result := putLegalHold("MAYBE"); result.Code != http.StatusBadRequest {
+		t.Fatalf("the unknown status must be rejected, got %d", result.Code)
+	}
+	if result := putLegalHold("ON"); result.Code != http.StatusOK {
+		t.Fatalf("put legal hold failed %d: %v", result.Code, result.Body.String())
+	}
+	if status := getLegalHold(); status != "ON" {
+		t.Fatalf("unexpected legal hold %v", status)
+	}
+	result := reqTest(utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/obj", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	if result.Header().Get(consts.AmzObjectLockLegalHold) != "ON" {
+		t.Fatalf("the legal hold must be returned on HEAD, %v", result.Header())
+	}
+
+	// the legal-held object resists deletion, and becomes deletable after the hold clears
+	if result = deleteObject(); result.Code != http.StatusBadRequest {
+		t.Fatalf("the held object must not be deleted, got %d", result.Code)
+	}
+	if result = putLegalHold("OFF"); result.Code != http.StatusOK {
+		t.Fatalf("clear legal hold failed %d", result.Code)
+	}
+	if result = deleteObject(); result.Code != http.StatusNoContent {
+		t.Fatalf("delete object failed %d: %v", result.Code, result.Body.String())
+	}
+}
+
+func TestS3ApiServer_PutObjectThroughPool(t *testing.T) {
+	bucketName := "testbucketthroughpool"
+	const poolUser, poolPassword = "gateway", "gateway-password"
+	// the pool stores the blocks of its service account only, the others are denied
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	var mu sync.Mutex
+	stored := make(map[cid.Cid]blocks.Block)
+	m := mocks.NewMockDagPool(ctrl)
+	m.EXPECT().Add(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, blk blocks.Block, user, password string, pin bool) (bool, error) {
+			if user != poolUser || password != poolPassword {
+				return false, upolicy.AccessDenied
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			_, exists := stored[blk.Cid()]
+			stored[blk.Cid()] = blk
+			return exists, nil
+		}).AnyTimes()
+	m.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, c cid.Cid, user, password string) (blocks.Block, error) {
+			if user != poolUser || password != poolPassword {
+				return nil, upolicy.AccessDenied
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if blk, ok := stored[c]; ok {
+				return blk, nil
+			}
+			return nil, ipld.ErrNotFound{Cid: c}
+		}).AnyTimes()
+	m.EXPECT().Remove(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer()
+	proto.RegisterDagPoolServer(grpcServer, &server.DagPoolServer{DagPool: m})
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+	usePool := func(user, password string) func() {
+		poolClient, err := dagpoolcli.NewPoolClient(lis.Addr().String(), user, password, true)
+		require.NoError(t, err)
+		dagPool := storageSys.DagPool
+		storageSys.DagPool = merkledag.NewDAGService(dagpoolcli.NewBlockService(poolClient))
+		return func() {
+			storageSys.DagPool = dagPool
+			poolClient.Close(context.TODO())
+		}
+	}
+
+	// the startup check of the gateway tells the denied pool user apart
+	checkAccess := func(password string) error {
+		poolClient, err := dagpoolcli.NewPoolClient(lis.Addr().String(), poolUser, password, true)
+		require.NoError(t, err)
+		defer poolClient.Close(context.TODO())
+		return dagpoolcli.CheckAccess(context.TODO(), poolClient)
+	}
+	require.NoError(t, checkAccess(poolPassword))
+	require.ErrorIs(t, checkAccess("wrong"), dagpoolcli.ErrAccessDenied)
+
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+	putObject := func(content string) *httptest.ResponseRecorder {
+		req := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/obj", int64(len(content)), bytes.NewReader([]byte(content)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		return reqTest(req)
+	}
+
+	// the authenticated s3 request is served by the pool as the service account of the gateway
+	restore := usePool(poolUser, poolPassword)
+	content := "stored through the pool"
+	result := putObject(content)
+	require.Equal(t, http.StatusOK, result.Code, result.Body.String())
+	result = reqTest(utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/obj", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, content, result.Body.String())
+	restore()
+
+	// the pool denying the gateway isn't the fault of the client
+	restore = usePool(poolUser, "wrong")
+	defer restore()
+	result = putObject("denied by the pool")
+	require.Equal(t, http.StatusInternalServerError, result.Code)
+	require.Contains(t, result.Body.String(), "InternalError")
+	require.NotContains(t, result.Body.String(), "AccessDenied")
+}
+
+func TestS3ApiServer_PutObjectChecksum(t *testing.T) {
+	bucketName := "testbucketchecksum"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	if result := reqTest(reqPutBucket); result.Code != http.StatusOK {
+		t.Fatalf("put bucket failed %d", result.Code)
+	}
+	content := "1234567"
+	sha256Sum := sha256.Sum256([]byte(content))
+	crc32Sum := make([]byte, 4)
+	binary.BigEndian.PutUint32(crc32Sum, crc32.ChecksumIEEE([]byte(content)))
+	md5Sum := md5.Sum([]byte(content))
+	putObject := func(object string, header http.Header) *httptest.ResponseRecorder {
+		req, err := utils.NewRequest(http.MethodPut, "/"+bucketName+"/"+object, int64(len(content)), bytes.NewReader([]byte(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the Content-MD5 is signed
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if err = utils.SignRequestV4(req, DefaultTestAccessKey, DefaultTestSecretKey, "s3"); err != nil {
+			t.Fatal(err)
+		}
+		return reqTest(req)
+	}
+
+	testCases := []struct {
+		name   string
+		header http.Header
+		code   apierrors.ErrorCode
+	}{
+		{name: "sha256", header: http.Header{"X-Amz-Checksum-Sha256": {base64.StdEncoding.EncodeToString(sha256Sum[:])}}, code: apierrors.ErrNone},
+		{name: "crc32", header: http.Header{"X-Amz-Checksum-Crc32": {base64.StdEncoding.EncodeToString(crc32Sum)}}, code: apierrors.ErrNone},
+		{name: "md5", header: http.Header{consts.ContentMD5: {base64.StdEncoding.EncodeToString(md5Sum[:])}}, code: apierrors.ErrNone},
+		{name: "sha256mismatch", header: http.Header{"X-Amz-Checksum-Sha256": {base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))}}, code: apierrors.ErrBadDigest},
+		{name: "crc32mismatch", header: http.Header{"X-Amz-Checksum-Crc32": {"AAAAAA=="}}, code: apierrors.ErrBadDigest},
+		{name: "md5mismatch", header: http.Header{consts.ContentMD5: {base64.StdEncoding.EncodeToString(make([]byte, md5.Size))}}, code: apierrors.ErrBadDigest},
+		{name: "invalid", header: http.Header{"X-Amz-Checksum-Sha256": {"not a checksum"}}, code: apierrors.ErrInvalidDigest},
+		{name: "twochecksums", header: http.Header{
+			"X-Amz-Checksum-Sha256": {base64.StdEncoding.EncodeToString(sha256Sum[:])},
+			"X-Amz-Checksum-Crc32":  {base64.StdEncoding.EncodeToString(crc32Sum)},
+		}, code: apierrors.ErrInvalidRequest},
+	}
+	for _, tc := range testCases {
+		result := putObject(tc.name, tc.header)
+		if tc.code == apierrors.ErrNone {
+			if result.Code != http.StatusOK {
+				t.Fatalf("%v: the put failed %d: %v", tc.name, result.Code, result.Body.String())
+			}
+			continue
+		}
+		apiErr := apierrors.GetAPIError(tc.code)
+		if result.Code != apiErr.HTTPStatusCode || !strings.Contains(result.Body.String(), apiErr.Code) {
+			t.Fatalf("%v: expected %v, got %d: %v", tc.name, apiErr.Code, result.Code, result.Body.String())
+		}
+		// the rejected object isn't stored
+		result = reqTest(utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/"+tc.name, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+		if result.Code != http.StatusNotFound {
+			t.Fatalf("%v: the rejected object must not be stored, got %d", tc.name, result.Code)
+		}
+	}
+
+	// the checksum may be sent in the trailer of a chunked body
+	putTrailer := func(object, checksum string) *httptest.ResponseRecorder {
+		req := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+object, int64(len(content)), bytes.NewReader([]byte(content)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		req.ContentLength = -1
+		req.Header.Del(consts.ContentLength)
+		req.TransferEncoding = []string{"chunked"}
+		req.Header.Set(consts.AmzTrailer, consts.AmzChecksumCRC32)
+		req.Trailer = http.Header{http.CanonicalHeaderKey(consts.AmzChecksumCRC32): {checksum}}
+		return reqTest(req)
+	}
+	if result := putTrailer("trailer", base64.StdEncoding.EncodeToString(crc32Sum)); result.Code != http.StatusOK {
+		t.Fatalf("the put with a trailing checksum failed %d: %v", result.Code, result.Body.String())
+	}
+	if result := putTrailer("trailermismatch", "AAAAAA=="); result.Code != http.StatusBadRequest || !strings.Contains(result.Body.String(), "BadDigest") {
+		t.Fatalf("the trailing checksum must be verified, got %d: %v", result.Code, result.Body.String())
+	}
+}
+
+func TestS3ApiServer_PresignedV4(t *testing.T) {
+	bucketName := "testbucketpresigned"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+	content := "1234567"
+	newPresignedRequest := func(method, urlStr string, body io.ReadSeeker, size int64) *http.Request {
+		req, err := utils.NewRequest(method, urlStr, size, body)
+		require.NoError(t, err)
+		require.NoError(t, utils.PreSignRequestV4(req, DefaultTestAccessKey, DefaultTestSecretKey, "s3", time.Hour))
+		return req
+	}
+
+	// the presigned PUT signs the payload hash, the presigned GET reads the object back
+	reqPut := newPresignedRequest(http.MethodPut, "/"+bucketName+"/obj", strings.NewReader(content), int64(len(content)))
+	require.Empty(t, reqPut.Header.Get(consts.Authorization))
+	require.Equal(t, "AWS4-HMAC-SHA256", reqPut.URL.Query().Get(consts.AmzAlgorithm))
+	result := reqTest(reqPut)
+	require.Equal(t, http.StatusOK, result.Code, result.Body.String())
+	result = reqTest(newPresignedRequest(http.MethodGet, "/"+bucketName+"/obj", nil, 0))
+	require.Equal(t, http.StatusOK, result.Code, result.Body.String())
+	require.Equal(t, content, result.Body.String())
+
+	// a presigned PUT without the payload hash is signed as UNSIGNED-PAYLOAD, which the strict
+	// default policy accepts for the presigned requests only
+	reqUnsigned, err := utils.NewRequest(http.MethodPut, "/"+bucketName+"/unsigned", int64(len(content)), strings.NewReader(content))
+	require.NoError(t, err)
+	reqUnsigned.Header.Del(consts.AmzContentSha256)
+	require.NoError(t, utils.PreSignRequestV4(reqUnsigned, DefaultTestAccessKey, DefaultTestSecretKey, "s3", time.Hour))
+	require.Empty(t, reqUnsigned.URL.Query().Get(consts.AmzContentSha256))
+	result = reqTest(reqUnsigned)
+	require.Equal(t, http.StatusOK, result.Code, result.Body.String())
+	reqUnsigned, err = utils.NewRequest(http.MethodPut, "/"+bucketName+"/unsigned", int64(len(content)), strings.NewReader(content))
+	require.NoError(t, err)
+	reqUnsigned.Header.Set(consts.AmzContentSha256, "UNSIGNED-PAYLOAD")
+	require.NoError(t, utils.SignRequestV4(reqUnsigned, DefaultTestAccessKey, DefaultTestSecretKey, "s3"))
+	result = reqTest(reqUnsigned)
+	require.Equal(t, http.StatusBadRequest, result.Code, result.Body.String())
+	require.Contains(t, result.Body.String(), "UNSIGNED-PAYLOAD")
+
+	// the URL is bound to its method, path and query
+	reqGet := newPresignedRequest(http.MethodGet, "/"+bucketName+"/obj", nil, 0)
+	reqGet.URL.Path = "/" + bucketName + "/other"
+	require.Equal(t, http.StatusForbidden, reqTest(reqGet).Code)
+	reqGet = newPresignedRequest(http.MethodGet, "/"+bucketName+"/obj", nil, 0)
+	query := reqGet.URL.Query()
+	query.Set(consts.AmzExpires, "7200")
+	reqGet.URL.RawQuery = query.Encode()
+	require.Contains(t, reqTest(reqGet).Body.String(), "<Code>SignatureDoesNotMatch</Code>")
+
+	// the expired URL is rejected
+	reqGet = newPresignedRequest(http.MethodGet, "/"+bucketName+"/obj", nil, 0)
+	query = reqGet.URL.Query()
+	date, err := time.Parse("20060102T150405Z", query.Get(consts.AmzDate))
+	require.NoError(t, err)
+	query.Set(consts.AmzDate, date.Add(-2*time.Hour).Format("20060102T150405Z"))
+	reqGet.URL.RawQuery = query.Encode()
+	result = reqTest(reqGet)
+	require.Equal(t, http.StatusForbidden, result.Code)
+	require.Contains(t, result.Body.String(), "<Code>AccessDenied</Code>")
+	require.Contains(t, result.Body.String(), "Request has expired")
+
+	require.Error(t, utils.PreSignRequestV4(reqGet, DefaultTestAccessKey, DefaultTestSecretKey, "s3", 8*24*time.Hour))
+}
+
+func TestS3ApiServer_ListObjectsPrefixDelimiter(t *testing.T) {
+	bucketName := "testbucketlistdelimiter"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+	content := "1234567"
+	for _, object := range []string{"a", "a/b", "a/b/c", "b/d", "c"} {
+		reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+object, int64(len(content)), strings.NewReader(content), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		require.Equal(t, http.StatusOK, reqTest(reqPutObject).Code, object)
+	}
+
+	testCases := []struct {
+		prefix, delimiter string
+		keys, prefixes    []string
+	}{
+		{keys: []string{"a", "a/b", "a/b/c", "b/d", "c"}},
+		{delimiter: "/", keys: []string{"a", "c"}, prefixes: []string{"a/", "b/"}},
+		{prefix: "a/", delimiter: "/", keys: []string{"a/b"}, prefixes: []string{"a/b/"}},
+	}
+	for _, testCase := range testCases {
+		query := url.Values{}
+		if testCase.prefix != "" {
+			query.Set("prefix", testCase.prefix)
+		}
+		if testCase.delimiter != "" {
+			query.Set("delimiter", testCase.delimiter)
+		}
+		for _, v2 := range []bool{false, true} {
+			q := url.Values{}
+			for k, v := range query {
+				q[k] = v
+			}
+			if v2 {
+				q.Set("list-type", "2")
+			}
+			u := "/" + bucketName
+			if len(q) > 0 {
+				u += "?" + q.Encode()
+			}
+			result := reqTest(utils.MustNewSignedV4Request(http.MethodGet, u, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+			require.Equal(t, http.StatusOK, result.Code, u)
+			var contents []response.Object
+			var commonPrefixes []response.CommonPrefix
+			if v2 {
+				var list response.ListObjectsV2Response
+				require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &list))
+				contents, commonPrefixes = list.Contents, list.CommonPrefixes
+			} else {
+				var list response.ListObjectsResponse
+				require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &list))
+				contents, commonPrefixes = list.Contents, list.CommonPrefixes
+			}
+			var keys, prefixes []string
+			for _, o := range contents {
+				keys = append(keys, o.Key)
+			}
+			for _, p := range commonPrefixes {
+				prefixes = append(prefixes, p.Prefix)
+			}
+			require.Equal(t, testCase.keys, keys, u)
+			require.Equal(t, testCase.prefixes, prefixes, u)
+		}
+	}
+}
+
+func TestS3ApiServer_ListObjectsV2ContinuationDeletedKey(t *testing.T) {
+	bucketName := "testbucketlistcontinuation"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+	content := "1234567"
+	for _, object := range []string{"a", "b", "c", "d"} {
+		reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/"+object, int64(len(content)), strings.NewReader(content), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		require.Equal(t, http.StatusOK, reqTest(reqPutObject).Code, object)
+	}
+	list := func(token string) (keys []string, next string) {
+		query := url.Values{"list-type": {"2"}, "max-keys": {"2"}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		result := reqTest(utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"?"+query.Encode(), 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+		require.Equal(t, http.StatusOK, result.Code, result.Body.String())
+		var resp response.ListObjectsV2Response
+		require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &resp))
+		for _, o := range resp.Contents {
+			keys = append(keys, o.Key)
+		}
+		if resp.IsTruncated {
+			next = resp.NextContinuationToken
+		}
+		return keys, next
+	}
+
+	keys, token := list("")
+	require.Equal(t, []string{"a", "b"}, keys)
+	require.NotEmpty(t, token)
+	// the object the token resumes after is deleted between the pages
+	decoded, err := base64.StdEncoding.DecodeString(token)
+	require.NoError(t, err)
+	key, err := store.ParseContinuationToken(string(decoded))
+	require.NoError(t, err)
+	reqDelete := utils.MustNewSignedV4Request(http.MethodDelete, "/"+bucketName+"/"+key, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNoContent, reqTest(reqDelete).Code)
+	keys, token = list(token)
+	require.Equal(t, []string{"c", "d"}, keys)
+	require.Empty(t, token)
+
+	// a token which isn't base64, or wasn't returned by a listing, is rejected
+	for _, token := range []string{"not a token", base64.StdEncoding.EncodeToString([]byte("b"))} {
+		query := url.Values{"list-type": {"2"}, "continuation-token": {token}}
+		result := reqTest(utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"?"+query.Encode(), 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+		require.Equal(t, http.StatusBadRequest, result.Code, token)
+		require.Contains(t, result.Body.String(), "<Code>InvalidArgument</Code>", token)
+	}
+}
+
+func TestS3ApiServer_ObjectTagging(t *testing.T) {
+	bucketName := "testbucketobjecttagging"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	if result := reqTest(reqPutBucket); result.Code != http.StatusOK {
+		t.Fatalf("put bucket failed %d", result.Code)
+	}
+	content := "1234567"
+	reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/obj", int64(len(content)), bytes.NewReader([]byte(content)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	if result := reqTest(reqPutObject); result.Code != http.StatusOK {
+		t.Fatalf("put object failed %d", result.Code)
+	}
+	putTagging := func(body string) *httptest.ResponseRecorder {
+		req := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/obj?tagging", int64(len(body)), strings.NewReader(body), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		return reqTest(req)
+	}
+	getTagging := func() map[string]string {
+		result := reqTest(utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/obj?tagging", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+		if result.Code != http.StatusOK {
+			t.Fatalf("get tagging failed %d", result.Code)
+		}
+		tags := store.Tags{TagSet: &store.TagSet{IsObject: true}}
+		if err := xml.Unmarshal(result.Body.Bytes(), &tags); err != nil {
+			t.Fatal(err)
+		}
+		return tags.TagSet.TagMap
+	}
+
+	if tags := getTagging(); len(tags) != 0 {
+		t.Fatalf("unexpected tags %v of a new object", tags)
+	}
+	if result := putTagging(`<Tagging><TagSet><Tag><Key>project</Key><Value>filedag</Value></Tag><Tag><Key>env</Key><Value>test</Value></Tag></TagSet></Tagging>`); result.Code != http.StatusOK {
+		t.Fatalf("put tagging failed %d: %v", result.Code, result.Body.String())
+	}
+	if tags := getTagging(); !reflect.DeepEqual(tags, map[string]string{"project": "filedag", "env": "test"}) {
+		t.Fatalf("unexpected tags %v", tags)
+	}
+	result := reqTest(utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/obj", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	if headerValue(result.Header(), consts.AmzTagCount) != "2" {
+		t.Fatalf("the tag count must be returned on HEAD, %v", result.Header())
+	}
+	// the content is kept
+	result = reqTest(utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/obj", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	if result.Code != http.StatusOK || result.Body.String() != content {
+		t.Fatalf("the object must be kept by the tagging, %d %v", result.Code, result.Body.String())
+	}
+
+	// an object takes at most 10 tags
+	var tooMany strings.Builder
+	for i := 0; i < 11; i++ {
+		tooMany.WriteString(fmt.Sprintf("<Tag><Key>k%d</Key><Value>v</Value></Tag>", i))
+	}
+	result = putTagging("<Tagging><TagSet>" + tooMany.String() + "</TagSet></Tagging>")
+	if result.Code != http.StatusBadRequest || !strings.Contains(result.Body.String(), "InvalidTag") {
+		t.Fatalf("the tags beyond the limit must be rejected, %d %v", result.Code, result.Body.String())
+	}
+	if result = putTagging("<Tagging><TagSet>"); result.Code != http.StatusBadRequest {
+		t.Fatalf("the malformed tagging must be rejected, got %d", result.Code)
+	}
+	reqMissing := utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/missing?tagging", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	if result = reqTest(reqMissing); result.Code != http.StatusNotFound {
+		t.Fatalf("the tags of a missing object must not be found, got %d", result.Code)
+	}
+
+	reqDelete := utils.MustNewSignedV4Request(http.MethodDelete, "/"+bucketName+"/obj?tagging", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	if result = reqTest(reqDelete); result.Code != http.StatusNoContent {
+		t.Fatalf("delete tagging failed %d", result.Code)
+	}
+	if tags := getTagging(); len(tags) != 0 {
+		t.Fatalf("the tags must be deleted, got %v", tags)
+	}
+	// the object is still there
+	reqHead := utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName+"/obj", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	if result = reqTest(reqHead); result.Code != http.StatusOK {
+		t.Fatalf("the object must be kept by the deletion of the tags, got %d", result.Code)
+	}
+}
+
+func TestS3ApiServer_TouchObject(t *testing.T) {
+	bucketName := "testbuckettouch"
+	ctx := context.TODO()
+	require.Equal(t, http.StatusOK, reqTest(utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)).Code)
+	content := "1234567"
+	reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"/obj", int64(len(content)), bytes.NewReader([]byte(content)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutObject).Code)
+	stored, err := storageSys.GetObjectInfo(ctx, bucketName, "obj", "")
+	require.NoError(t, err)
+	lc := &store.Lifecycle{Rules: []store.LifecycleRule{{ID: "rule", Status: store.LifecycleEnabled, Expiration: &store.LifecycleExpiration{Days: 1}}}}
+	require.NoError(t, bmSys.UpdateBucketLifecycle(ctx, bucketName, lc))
+
+	time.Sleep(10 * time.Millisecond)
+	result := reqTest(utils.MustNewSignedV4Request(http.MethodPost, "/"+bucketName+"/obj?touch", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, `"`+stored.ETag+`"`, headerValue(result.Header(), consts.ETag))
+	touched, err := storageSys.GetObjectInfo(ctx, bucketName, "obj", "")
+	require.NoError(t, err)
+	require.True(t, touched.ModTime.After(stored.ModTime))
+	require.Equal(t, touched.ModTime.Format(http.TimeFormat), result.Header().Get(consts.LastModified))
+	expiry, _ := lc.PredictExpiry("obj", touched.ModTime, nil)
+	require.Equal(t, fmt.Sprintf(`expiry-date="%s", rule-id="rule"`, expiry.Format(http.TimeFormat)), result.Header().Get(consts.AmzExpiration))
+
+	result = reqTest(utils.MustNewSignedV4Request(http.MethodGet, "/"+bucketName+"/obj", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, content, result.Body.String())
+	require.Equal(t, `"`+stored.ETag+`"`, headerValue(result.Header(), consts.ETag))
+
+	result = reqTest(utils.MustNewSignedV4Request(http.MethodPost, "/"+bucketName+"/missing?touch", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+	require.Equal(t, apierrors.GetAPIError(apierrors.ErrNoSuchKey).HTTPStatusCode, result.Code)
+}
+
+func TestS3ApiServer_ObjectAcl(t *testing.T) {
+	bucketName := "testbucketobjectacl"
+	u := "/" + bucketName + "/obj"
+	require.Equal(t, http.StatusOK, reqTest(utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)).Code)
+	content := "1234567"
+	reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, u, int64(len(content)), bytes.NewReader([]byte(content)), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutObject).Code)
+	putACL := func(object, acl string) *httptest.ResponseRecorder {
+		req := utils.MustNewSignedV4Request(http.MethodPut, object+"?acl", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		req.Header.Set(consts.AmzACL, acl)
+		return reqTest(req)
+	}
+	getACL := func() []response.Grant {
+		result := reqTest(utils.MustNewSignedV4Request(http.MethodGet, u+"?acl", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+		require.Equal(t, http.StatusOK, result.Code, result.Body.String())
+		var resp response.AccessControlPolicy
+		require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &resp))
+		require.Equal(t, DefaultTestAccessKey, resp.Owner.ID)
+		return resp.AccessControlList.Grant
+	}
+
+	grants := getACL()
+	require.Len(t, grants, 1)
+	require.Equal(t, response.Permission("FULL_CONTROL"), grants[0].Permission)
+	// a private object is denied to the anonymous requests
+	require.Equal(t, http.StatusForbidden, reqTest(httptest.NewRequest(http.MethodGet, u, nil)).Code)
+
+	require.Equal(t, http.StatusOK, putACL(u, "public-read").Code)
+	grants = getACL()
+	require.Len(t, grants, 2)
+	require.Equal(t, allUsersURI, grants[1].Grantee.URI)
+	require.Equal(t, response.Permission("READ"), grants[1].Permission)
+	result := reqTest(httptest.NewRequest(http.MethodGet, u, nil))
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, content, result.Body.String())
+	// the ACL of the object grants no write nor the listing of the bucket
+	require.Equal(t, http.StatusForbidden, reqTest(httptest.NewRequest(http.MethodPut, u, strings.NewReader(content))).Code)
+	require.Equal(t, http.StatusForbidden, reqTest(httptest.NewRequest(http.MethodGet, "/"+bucketName, nil)).Code)
+
+	// an explicit deny of the bucket policy overrides the ACL
+	p := `{"Version":"2012-10-17","Statement":[` +
+		`{"Effect":"Allow","Principal":{"AWS":["` + DefaultTestAccessKey + `"]},"Action":["s3:*"],"Resource":["arn:aws:s3:::` + bucketName + `/*"]},` +
+		`{"Effect":"Deny","Principal":{"AWS":["` + auth.DefaultAnonymousPrincipal + `"]},"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::` + bucketName + `/*"]}]}`
+	reqPutPolicy := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"?policy", int64(len(p)), strings.NewReader(p),
+		"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNoContent, reqTest(reqPutPolicy).Code)
+	require.Equal(t, http.StatusForbidden, reqTest(httptest.NewRequest(http.MethodGet, u, nil)).Code)
+	// back to the default policy of the bucket
+	require.NoError(t, bmSys.UpdateBucketPolicy(context.TODO(), bucketName, policy.CreateUserBucketPolicy(bucketName, DefaultTestAccessKey)))
+	require.Equal(t, http.StatusOK, reqTest(httptest.NewRequest(http.MethodGet, u, nil)).Code)
+
+	require.Equal(t, http.StatusOK, putACL(u, "private").Code)
+	require.Equal(t, http.StatusForbidden, reqTest(httptest.NewRequest(http.MethodGet, u, nil)).Code)
+
+	// the public ACLs of the bucket grant the listing, and the writes of the objects for public-read-write
+	require.Equal(t, http.StatusOK, putACL("/"+bucketName, "public-read").Code)
+	require.Equal(t, http.StatusOK, reqTest(httptest.NewRequest(http.MethodGet, "/"+bucketName, nil)).Code)
+	require.Equal(t, http.StatusForbidden, reqTest(httptest.NewRequest(http.MethodPut, "/"+bucketName+"/anonymous", strings.NewReader(content))).Code)
+	require.Equal(t, http.StatusOK, putACL("/"+bucketName, "public-read-write").Code)
+	require.Equal(t, http.StatusOK, reqTest(httptest.NewRequest(http.MethodPut, "/"+bucketName+"/anonymous", strings.NewReader(content))).Code)
+	// the objects are read by their own ACLs
+	require.Equal(t, http.StatusForbidden, reqTest(httptest.NewRequest(http.MethodGet, u, nil)).Code)
+
+	result = putACL(u, "authenticated-read")
+	require.Equal(t, apierrors.GetAPIError(apierrors.ErrInvalidCannedACL).HTTPStatusCode, result.Code)
+	result = putACL("/"+bucketName+"/missing", "public-read")
+	require.Equal(t, apierrors.GetAPIError(apierrors.ErrNoSuchKey).HTTPStatusCode, result.Code)
+}
+
+func TestS3ApiServer_ObjectVersionAcl(t *testing.T) {
+	bucketName := "testbucketobjectversionacl"
+	u := "/" + bucketName + "/obj"
+	dagPool := storageSys.DagPool
+	storageSys.DagPool = mdtest.Mock()
+	defer func() {
+		storageSys.DagPool = dagPool
+	}()
+	require.Equal(t, http.StatusOK, reqTest(utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)).Code)
+	config := `<VersioningConfiguration><Status>Enabled</Status></VersioningConfiguration>`
+	reqPut := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"?versioning", int64(len(config)), strings.NewReader(config),
+		"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPut).Code)
+	putObject := func(content string) string {
+		result := reqTest(utils.MustNewSignedV4Request(http.MethodPut, u, int64(len(content)), strings.NewReader(content), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t))
+		require.Equal(t, http.StatusOK, result.Code)
+		versionID := headerValue(result.Header(), consts.AmzVersionID)
+		require.NotEmpty(t, versionID)
+		return versionID
+	}
+	oldVersion := putObject("old")
+	currentVersion := putObject("current")
+	req := utils.MustNewSignedV4Request(http.MethodPut, u+"?acl&versionId="+currentVersion, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	req.Header.Set(consts.AmzACL, "public-read")
+	require.Equal(t, http.StatusOK, reqTest(req).Code)
+
+	// the public ACL of the current version doesn't grant the reads of the private old version
+	result := reqTest(httptest.NewRequest(http.MethodGet, u, nil))
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, "current", result.Body.String())
+	require.Equal(t, http.StatusForbidden, reqTest(httptest.NewRequest(http.MethodGet, u+"?versionId="+oldVersion, nil)).Code)
+	require.Equal(t, http.StatusOK, reqTest(httptest.NewRequest(http.MethodGet, u+"?versionId="+currentVersion, nil)).Code)
+
+	// the public ACL of the old version grants its reads only
+	req = utils.MustNewSignedV4Request(http.MethodPut, u+"?acl&versionId="+oldVersion, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	req.Header.Set(consts.AmzACL, "public-read")
+	require.Equal(t, http.StatusOK, reqTest(req).Code)
+	req = utils.MustNewSignedV4Request(http.MethodPut, u+"?acl&versionId="+currentVersion, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	req.Header.Set(consts.AmzACL, "private")
+	require.Equal(t, http.StatusOK, reqTest(req).Code)
+	require.Equal(t, http.StatusForbidden, reqTest(httptest.NewRequest(http.MethodGet, u, nil)).Code)
+	result = reqTest(httptest.NewRequest(http.MethodGet, u+"?versionId="+oldVersion, nil))
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, "old", result.Body.String())
+}
+
+func TestS3ApiServer_GetObjectRanges(t *testing.T) {
+	bucketName := "testbucketranges"
+	u := "/" + bucketName + "/obj"
+	dagPool := storageSys.DagPool
+	storageSys.DagPool = mdtest.Mock()
+	defer func() {
+		storageSys.DagPool = dagPool
+	}()
+	require.Equal(t, http.StatusOK, reqTest(utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)).Code)
+	// the object spans many blocks of the dag
+	content := make([]byte, 600<<10)
+	for i := range content {
+		content[i] = byte(i * 7 % 251)
+	}
+	reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, u, int64(len(content)), bytes.NewReader(content), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	reqPutObject.Header.Set(consts.ContentType, "video/mp4")
+	result := reqTest(reqPutObject)
+	require.Equal(t, http.StatusOK, result.Code)
+	etag := result.Header().Get(consts.ETag)
+	size := int64(len(content))
+	getObject := func(rangeHeader, ifRange string) *httptest.ResponseRecorder {
+		req := utils.MustNewSignedV4Request(http.MethodGet, u, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		req.Header.Set(consts.Range, rangeHeader)
+		if ifRange != "" {
+			req.Header.Set(consts.IfRange, ifRange)
+		}
+		return reqTest(req)
+	}
+	requireFull := func(result *httptest.ResponseRecorder) {
+		require.Equal(t, http.StatusOK, result.Code)
+		require.Empty(t, result.Header().Get(consts.ContentRange))
+		require.Equal(t, content, result.Body.Bytes())
+	}
+
+	// two disjoint ranges are sent in a multipart/byteranges body
+	result = getObject("bytes=0-99,300000-300199", "")
+	require.Equal(t, http.StatusPartialContent, result.Code)
+	require.Equal(t, strconv.Itoa(result.Body.Len()), result.Header().Get(consts.ContentLength))
+	mediaType, params, err := mime.ParseMediaType(result.Header().Get(consts.ContentType))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/byteranges", mediaType)
+	mr := multipart.NewReader(result.Body, params["boundary"])
+	for _, want := range []struct{ start, end int64 }{{0, 99}, {300000, 300199}} {
+		part, err := mr.NextPart()
+		require.NoError(t, err)
+		require.Equal(t, "video/mp4", part.Header.Get(consts.ContentType))
+		require.Equal(t, fmt.Sprintf("bytes %d-%d/%d", want.start, want.end, size), part.Header.Get(consts.ContentRange))
+		data, err := ioutil.ReadAll(part)
+		require.NoError(t, err)
+		require.Equal(t, content[want.start:want.end+1], data)
+	}
+	_, err = mr.NextPart()
+	require.Equal(t, io.EOF, err)
+
+	// a single range, an open range and a suffix range, the ranges seek over the content the checksum is verified by
+	defer storageSys.SetVerifyChecksum(false)
+	for _, verify := range []bool{false, true} {
+		storageSys.SetVerifyChecksum(verify)
+		for _, tc := range []struct {
+			header     string
+			start, end int64
+		}{
+			{header: "bytes=100-199", start: 100, end: 199},
+			{header: "bytes=500000-", start: 500000, end: size - 1},
+			{header: "bytes=-100", start: size - 100, end: size - 1},
+			{header: "bytes=614000-700000", start: 614000, end: size - 1},
+		} {
+			result = getObject(tc.header, "")
+			require.Equal(t, http.StatusPartialContent, result.Code, tc.header)
+			require.Equal(t, fmt.Sprintf("bytes %d-%d/%d", tc.start, tc.end, size), result.Header().Get(consts.ContentRange))
+			require.Equal(t, "video/mp4", result.Header().Get(consts.ContentType))
+			require.Equal(t, content[tc.start:tc.end+1], result.Body.Bytes(), tc.header)
+		}
+	}
+
+	// If-Range sends the ranges only if the object is the same
+	modTime := result.Header().Get(consts.LastModified)
+	require.Equal(t, http.StatusPartialContent, getObject("bytes=0-99,200-299", etag).Code)
+	require.Equal(t, http.StatusPartialContent, getObject("bytes=0-99", modTime).Code)
+	requireFull(getObject("bytes=0-99,200-299", `"0123456789abcdef"`))
+	requireFull(getObject("bytes=0-99", "W/"+etag))
+	requireFull(getObject("bytes=0-99", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)))
+
+	// the degenerate and malformed ranges are ignored
+	for _, header := range []string{
+		"bytes=0-99,50-149",
+		"bytes=200-299,0-99",
+		"bytes=0-",
+		"bytes=99-0",
+		"bytes=a-b",
+		"items=0-99",
+	} {
+		requireFull(getObject(header, ""))
+	}
+
+	// the unsatisfiable ranges are dropped, the request fails if none is left
+	result = getObject("bytes=0-99,900000-900099", "")
+	require.Equal(t, http.StatusPartialContent, result.Code)
+	require.Equal(t, fmt.Sprintf("bytes 0-99/%d", size), result.Header().Get(consts.ContentRange))
+	result = getObject("bytes=900000-900099", "")
+	require.Equal(t, http.StatusRequestedRangeNotSatisfiable, result.Code)
+	require.Equal(t, fmt.Sprintf("bytes */%d", size), result.Header().Get(consts.ContentRange))
+	require.Contains(t, result.Body.String(), "InvalidRange")
+}
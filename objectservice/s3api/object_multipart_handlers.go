@@ -14,7 +14,6 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"sort"
 	"strconv"
 	"strings"
 )
@@ -52,6 +51,14 @@ func (s3a *s3ApiServer) NewMultipartUploadHandler(w http.ResponseWriter, r *http
 		response.WriteErrorResponse(w, r, apierrors.ErrInvalidRequest)
 		return
 	}
+	if !isValidWebsiteRedirectLocation(metadata) {
+		response.WriteErrorResponse(w, r, apierrors.ErrInvalidRedirectLocation)
+		return
+	}
+	if !isValidUserMetadataSize(metadata) {
+		response.WriteErrorResponse(w, r, apierrors.ErrMetadataTooLarge)
+		return
+	}
 
 	info, err := s3a.store.NewMultipartUpload(ctx, bucket, object, metadata)
 	if err != nil {
@@ -113,7 +120,7 @@ func (s3a *s3ApiServer) PutObjectPartHandler(w http.ResponseWriter, r *http.Requ
 	partIDString := r.Form.Get(consts.PartNumber)
 
 	partID, err := strconv.Atoi(partIDString)
-	if err != nil {
+	if err != nil || partID < 1 {
 		response.WriteErrorResponse(w, r, apierrors.ErrInvalidPart)
 		return
 	}
@@ -169,8 +176,9 @@ func (s3a *s3ApiServer) PutObjectPartHandler(w http.ResponseWriter, r *http.Requ
 			response.WriteErrorResponse(w, r, s3err)
 			return
 		}
-		if !iam.SkipContentSha256Cksum(r) {
-			sha256hex = iam.GetContentSha256Cksum(r, iam.ServiceS3)
+		if sha256hex, s3err = s3a.authSys.PayloadSha256(r); s3err != apierrors.ErrNone {
+			response.WriteErrorResponse(w, r, s3err)
+			return
 		}
 	}
 
@@ -186,6 +194,10 @@ func (s3a *s3ApiServer) PutObjectPartHandler(w http.ResponseWriter, r *http.Requ
 		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
 		return
 	}
+	if s3err := setContentChecksum(r, hashReader); s3err != apierrors.ErrNone {
+		response.WriteErrorResponse(w, r, s3err)
+		return
+	}
 
 	partInfo, err := s3a.store.PutObjectPart(ctx, bucket, object, uploadID, partID, hashReader, size, mi.MetaData)
 	if err != nil {
@@ -200,6 +212,7 @@ func (s3a *s3ApiServer) PutObjectPartHandler(w http.ResponseWriter, r *http.Requ
 	// clients expect the ETag header key to be literally "ETag" - not "Etag" (case-sensitive).
 	// Therefore, we have to set the ETag directly as map entry.
 	w.Header()[consts.ETag] = []string{"\"" + etag + "\""}
+	w.Header().Set(consts.AmzChecksumSHA256, partInfo.ChecksumSHA256)
 
 	response.WriteSuccessResponseHeadersOnly(w, r)
 }
@@ -253,10 +266,6 @@ func (s3a *s3ApiServer) CompleteMultipartUploadHandler(w http.ResponseWriter, r
 		response.WriteErrorResponse(w, r, apierrors.ErrMalformedXML)
 		return
 	}
-	if !sort.IsSorted(datatypes.CompletedParts(complMultipartUpload.Parts)) {
-		response.WriteErrorResponse(w, r, apierrors.ErrInvalidPartOrder)
-		return
-	}
 
 	if _, err = s3a.store.GetMultipartInfo(ctx, bucket, object, uploadID); err != nil {
 		response.WriteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
@@ -0,0 +1,230 @@
+package s3api
+
+import (
+	"fmt"
+	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
+	"github.com/filedag-project/filedag-storage/objectservice/consts"
+	"github.com/filedag-project/filedag-storage/objectservice/store"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRanges is the number of ranges above which the Range header is ignored, as many small ranges
+// would make the multipart body mostly headers
+const maxRanges = 100
+
+// byteRange is a range of the content of an object, the end offset is included
+type byteRange struct {
+	start int64
+	end   int64
+}
+
+func (br byteRange) length() int64 {
+	return br.end - br.start + 1
+}
+
+func (br byteRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", br.start, br.end, size)
+}
+
+// getObjectRanges returns the ranges of the object requested by the Range header of a GET request,
+// as per https://www.rfc-editor.org/rfc/rfc7233. No range is returned when the whole object is sent:
+// the header is unset, malformed, or degenerate, or the If-Range validator doesn't match the object.
+// The ranges are degenerate if they overlap, aren't ascending or are too many. ErrInvalidRange is
+// returned if none of the ranges is satisfiable.
+func getObjectRanges(r *http.Request, objInfo store.ObjectInfo) ([]byteRange, apierrors.ErrorCode) {
+	header := r.Header.Get(consts.Range)
+	if header == "" || !ifRangeMatch(r.Header.Get(consts.IfRange), objInfo) {
+		return nil, apierrors.ErrNone
+	}
+	ranges, ok := parseRanges(header, objInfo.Size)
+	if !ok {
+		return nil, apierrors.ErrNone
+	}
+	if len(ranges) == 0 {
+		if objInfo.Size == 0 {
+			// an empty object has no range to send, some clients send a Range with every request
+			return nil, apierrors.ErrNone
+		}
+		return nil, apierrors.ErrInvalidRange
+	}
+	if len(ranges) > maxRanges {
+		return nil, apierrors.ErrNone
+	}
+	for i := 1; i < len(ranges); i++ {
+		if ranges[i].start <= ranges[i-1].end {
+			return nil, apierrors.ErrNone
+		}
+	}
+	if len(ranges) == 1 && ranges[0].length() == objInfo.Size {
+		return nil, apierrors.ErrNone
+	}
+	return ranges, apierrors.ErrNone
+}
+
+// parseRanges parses the byte ranges of a Range header against the size of the object, the ranges
+// which aren't satisfiable are dropped. It isn't ok if the header is malformed.
+func parseRanges(header string, size int64) ([]byteRange, bool) {
+	const prefix = "bytes="
+	if !strings.HasPrefix(header, prefix) {
+		return nil, false
+	}
+	var ranges []byteRange
+	for _, spec := range strings.Split(header[len(prefix):], ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		i := strings.Index(spec, "-")
+		if i < 0 {
+			return nil, false
+		}
+		first, last := strings.TrimSpace(spec[:i]), strings.TrimSpace(spec[i+1:])
+		var br byteRange
+		if first == "" {
+			// the suffix range bytes=-N is the last N bytes
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n < 0 {
+				return nil, false
+			}
+			if n == 0 || size == 0 {
+				continue
+			}
+			if n > size {
+				n = size
+			}
+			br = byteRange{start: size - n, end: size - 1}
+		} else {
+			start, err := strconv.ParseInt(first, 10, 64)
+			if err != nil || start < 0 {
+				return nil, false
+			}
+			end := size - 1
+			if last != "" {
+				if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+					return nil, false
+				}
+				if end >= size {
+					end = size - 1
+				}
+			}
+			if start >= size {
+				continue
+			}
+			br = byteRange{start: start, end: end}
+		}
+		ranges = append(ranges, br)
+	}
+	return ranges, true
+}
+
+// ifRangeMatch returns true if the If-Range validator, an ETag or a date, matches the object.
+// The ETags are compared strongly, a weak ETag never matches.
+func ifRangeMatch(ifRange string, objInfo store.ObjectInfo) bool {
+	if ifRange == "" {
+		return true
+	}
+	if strings.HasPrefix(ifRange, "\"") {
+		return canonicalizeETag(ifRange) == canonicalizeETag(objInfo.ETag)
+	}
+	if strings.HasPrefix(ifRange, "W/") {
+		return false
+	}
+	t, err := http.ParseTime(ifRange)
+	if err != nil {
+		return false
+	}
+	return objInfo.ModTime.Truncate(time.Second).Equal(t)
+}
+
+// setRangeHeaders sets the headers of a partial content response, it returns the writer of the
+// multipart/byteranges body of the ranges if there are many. The content type of the object is
+// the type of each part.
+func setRangeHeaders(w http.ResponseWriter, ranges []byteRange, size int64) *multipart.Writer {
+	if len(ranges) == 1 {
+		w.Header().Set(consts.ContentLength, strconv.FormatInt(ranges[0].length(), 10))
+		w.Header().Set(consts.ContentRange, ranges[0].contentRange(size))
+		return nil
+	}
+	contentType := w.Header().Get(consts.ContentType)
+	mw := multipart.NewWriter(w)
+	// the length of the body is known ahead by writing the headers of the parts to a counter
+	counter := &countingWriter{}
+	cw := multipart.NewWriter(counter)
+	cw.SetBoundary(mw.Boundary())
+	for _, br := range ranges {
+		cw.CreatePart(rangePartHeader(br, contentType, size))
+		counter.n += br.length()
+	}
+	cw.Close()
+	w.Header().Set(consts.ContentLength, strconv.FormatInt(counter.n, 10))
+	w.Header().Set(consts.ContentType, "multipart/byteranges; boundary="+mw.Boundary())
+	return mw
+}
+
+// writeRanges copies the ranges of the object from its reader, into the multipart writer if there
+// are many. The ranges are ascending, the reader is seeked forward from a range to the next one.
+func writeRanges(w io.Writer, mw *multipart.Writer, reader io.Reader, ranges []byteRange, contentType string, size int64) (int64, error) {
+	var pos, written int64
+	for _, br := range ranges {
+		if err := skipTo(reader, pos, br.start); err != nil {
+			return written, err
+		}
+		dst := w
+		if mw != nil {
+			part, err := mw.CreatePart(rangePartHeader(br, contentType, size))
+			if err != nil {
+				return written, err
+			}
+			dst = part
+		}
+		n, err := io.CopyN(dst, reader, br.length())
+		written += n
+		if err != nil {
+			return written, err
+		}
+		pos = br.end + 1
+	}
+	if mw != nil {
+		return written, mw.Close()
+	}
+	return written, nil
+}
+
+// skipTo moves the reader from the offset pos to the offset, the dag reader seeks without reading
+// the blocks skipped
+func skipTo(reader io.Reader, pos, offset int64) error {
+	if offset == pos {
+		return nil
+	}
+	if seeker, ok := reader.(io.Seeker); ok {
+		_, err := seeker.Seek(offset, io.SeekStart)
+		return err
+	}
+	_, err := io.CopyN(ioutil.Discard, reader, offset-pos)
+	return err
+}
+
+func rangePartHeader(br byteRange, contentType string, size int64) textproto.MIMEHeader {
+	header := textproto.MIMEHeader{consts.ContentRange: {br.contentRange(size)}}
+	if contentType != "" {
+		header.Set(consts.ContentType, contentType)
+	}
+	return header
+}
+
+// countingWriter counts the bytes written to it
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
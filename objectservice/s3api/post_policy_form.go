@@ -0,0 +1,253 @@
+package s3api
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The operators of the POST policy conditions.
+const (
+	policyCondEqual         = "eq"
+	policyCondStartsWith    = "starts-with"
+	policyCondContentLength = "content-length-range"
+)
+
+// postPolicyCondition - a condition on a form field
+type postPolicyCondition struct {
+	Operator string
+	// Key the form field name, without the leading '$'
+	Key   string
+	Value string
+}
+
+// contentLengthRange - the min and max size of the uploaded file
+type contentLengthRange struct {
+	Min   int64
+	Max   int64
+	Valid bool // If content-length-range was part of the policy
+}
+
+// postPolicyForm - the policy of a browser POST upload
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-HTTPPOSTConstructPolicy.html
+type postPolicyForm struct {
+	Expiration time.Time
+	Conditions struct {
+		Policies           []postPolicyCondition
+		ContentLengthRange contentLengthRange
+	}
+}
+
+// parsePostPolicyForm - parse the JSON policy document of a POST upload
+func parsePostPolicyForm(policy string) (ppf postPolicyForm, err error) {
+	var rawPolicy struct {
+		Expiration string        `json:"expiration"`
+		Conditions []interface{} `json:"conditions"`
+	}
+	d := json.NewDecoder(strings.NewReader(policy))
+	d.UseNumber()
+	if err = d.Decode(&rawPolicy); err != nil {
+		return ppf, err
+	}
+
+	ppf.Expiration, err = time.Parse(time.RFC3339Nano, rawPolicy.Expiration)
+	if err != nil {
+		return ppf, err
+	}
+
+	for _, val := range rawPolicy.Conditions {
+		switch condt := val.(type) {
+		case map[string]interface{}: // {"key": "value"} is the short form of ["eq", "$key", "value"]
+			for k, v := range condt {
+				value, ok := v.(string)
+				if !ok {
+					return ppf, fmt.Errorf("unknown type %T of conditional field value %v found in POST policy form", v, v)
+				}
+				ppf.Conditions.Policies = append(ppf.Conditions.Policies, postPolicyCondition{
+					Operator: policyCondEqual,
+					Key:      strings.TrimPrefix(k, "$"),
+					Value:    value,
+				})
+			}
+		case []interface{}:
+			if len(condt) != 3 {
+				return ppf, fmt.Errorf("malformed conditional fields %v of POST policy form", condt)
+			}
+			operator, ok := condt[0].(string)
+			if !ok {
+				return ppf, fmt.Errorf("unknown type %T of operator %v found in POST policy form", condt[0], condt[0])
+			}
+			switch operator = strings.ToLower(operator); operator {
+			case policyCondEqual, policyCondStartsWith:
+				key, ok1 := condt[1].(string)
+				value, ok2 := condt[2].(string)
+				if !ok1 || !ok2 || !strings.HasPrefix(key, "$") {
+					return ppf, fmt.Errorf("malformed conditional fields %v of POST policy form", condt)
+				}
+				ppf.Conditions.Policies = append(ppf.Conditions.Policies, postPolicyCondition{
+					Operator: operator,
+					Key:      strings.TrimPrefix(key, "$"),
+					Value:    value,
+				})
+			case policyCondContentLength:
+				min, err := toInt64(condt[1])
+				if err != nil {
+					return ppf, err
+				}
+				max, err := toInt64(condt[2])
+				if err != nil {
+					return ppf, err
+				}
+				ppf.Conditions.ContentLengthRange = contentLengthRange{
+					Min:   min,
+					Max:   max,
+					Valid: true,
+				}
+			default:
+				return ppf, fmt.Errorf("unknown operator %s found in POST policy form", operator)
+			}
+		default:
+			return ppf, fmt.Errorf("unknown type %T of conditional field %v found in POST policy form", condt, condt)
+		}
+	}
+	return ppf, nil
+}
+
+func toInt64(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case json.Number:
+		return n.Int64()
+	case string:
+		return strconv.ParseInt(n, 10, 64)
+	}
+	return 0, fmt.Errorf("unknown type %T of number %v found in POST policy form", v, v)
+}
+
+// The form fields which don't need a policy condition.
+var postPolicyIgnoredFields = map[string]struct{}{
+	"Policy":          {},
+	"X-Amz-Signature": {},
+	"Signature":       {},
+	"Awsaccesskeyid":  {},
+	"File":            {},
+	// Bucket is not a form field, it's the bucket of the request.
+	"Bucket": {},
+}
+
+// extractPostPolicyFormValues - reads the fields of a POST upload form up to the file,
+// which must be the last field, and returns the fields and the file part.
+func extractPostPolicyFormValues(reader *multipart.Reader) (http.Header, *multipart.Part, apierrors.ErrorCode) {
+	formValues := make(http.Header)
+	remaining := int64(maxFormFieldsSize)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, nil, apierrors.ErrPOSTFileRequired
+		}
+		if err != nil {
+			return nil, nil, apierrors.ErrMalformedPOSTRequest
+		}
+		name := part.FormName()
+		if name == "" {
+			part.Close()
+			continue
+		}
+		if strings.EqualFold(name, "file") {
+			return formValues, part, apierrors.ErrNone
+		}
+		value, err := ioutil.ReadAll(io.LimitReader(part, remaining+1))
+		part.Close()
+		if err != nil {
+			return nil, nil, apierrors.ErrMalformedPOSTRequest
+		}
+		remaining -= int64(len(value))
+		if remaining < 0 {
+			return nil, nil, apierrors.ErrMalformedPOSTRequest
+		}
+		// Form field names are case-insensitive.
+		formValues.Add(http.CanonicalHeaderKey(name), string(value))
+	}
+}
+
+// spoolFormFile - copies at most maxSize+1 bytes of the uploaded file to a temporary file,
+// so that a size over maxSize is detected without reading the whole upload.
+func spoolFormFile(r io.Reader, maxSize int64) (*os.File, int64, error) {
+	f, err := ioutil.TempFile("", "post-policy-")
+	if err != nil {
+		return nil, 0, err
+	}
+	size, err := io.Copy(f, io.LimitReader(r, maxSize+1))
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, 0, err
+	}
+	return f, size, nil
+}
+
+// checkPostPolicy - checks the form values against the expiration and the
+// conditions of the policy. Like S3 each form field must appear in the conditions,
+// except the signature, the policy and the fields prefixed with x-ignore-.
+func checkPostPolicy(formValues http.Header, ppf postPolicyForm) apierrors.ErrorCode {
+	if !ppf.Expiration.After(time.Now().UTC()) {
+		return apierrors.ErrPolicyAlreadyExpired
+	}
+	for key := range formValues {
+		if _, ok := postPolicyIgnoredFields[key]; ok || strings.HasPrefix(key, "X-Ignore-") {
+			continue
+		}
+		covered := false
+		for _, cond := range ppf.Conditions.Policies {
+			if http.CanonicalHeaderKey(cond.Key) == key {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			log.Debugf("POST policy condition failed: form field %s is not in the policy", key)
+			return apierrors.ErrPostPolicyConditionInvalidFormat
+		}
+	}
+	for _, cond := range ppf.Conditions.Policies {
+		value := formValues.Get(cond.Key)
+		switch cond.Operator {
+		case policyCondEqual:
+			if value != cond.Value {
+				log.Debugf("POST policy condition failed: %s must be %q, got %q", cond.Key, cond.Value, value)
+				return apierrors.ErrPostPolicyConditionInvalidFormat
+			}
+		case policyCondStartsWith:
+			if !strings.HasPrefix(value, cond.Value) {
+				log.Debugf("POST policy condition failed: %s must start with %q, got %q", cond.Key, cond.Value, value)
+				return apierrors.ErrPostPolicyConditionInvalidFormat
+			}
+		}
+	}
+	return apierrors.ErrNone
+}
+
+// checkContentLengthRange - checks the size of the uploaded file against
+// the content-length-range condition of the policy.
+func checkContentLengthRange(size int64, lengthRange contentLengthRange) apierrors.ErrorCode {
+	if !lengthRange.Valid {
+		return apierrors.ErrNone
+	}
+	if size < lengthRange.Min {
+		return apierrors.ErrEntityTooSmall
+	}
+	if size > lengthRange.Max {
+		return apierrors.ErrEntityTooLarge
+	}
+	return apierrors.ErrNone
+}
@@ -0,0 +1,167 @@
+package s3api
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
+	"github.com/filedag-project/filedag-storage/objectservice/response"
+	"github.com/filedag-project/filedag-storage/objectservice/store"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRequestWaitTimeout how long a request waits for a slot before it's answered with SlowDown
+const DefaultRequestWaitTimeout = 10 * time.Second
+
+// BucketRequestLimiter limits the concurrent requests of the gateway and shares them fairly among the
+// buckets. A request is served at once while a slot is free, otherwise it waits in the queue of its bucket,
+// and a freed slot goes to the waiting bucket which has the fewest requests in flight for its weight.
+// So a burst on a bucket can fill the free slots, but it never keeps the other buckets from their share.
+type BucketRequestLimiter struct {
+	mu       sync.Mutex
+	capacity int
+	inflight int
+	buckets  map[string]*bucketRequests
+	// weight returns the weight of the bucket, DefaultRequestWeight if it fails
+	weight  func(ctx context.Context, bucket string) (int, error)
+	maxWait time.Duration
+}
+
+// bucketRequests the requests of a bucket which are in flight or waiting, it's dropped when there's none
+type bucketRequests struct {
+	weight   int
+	inflight int
+	waiting  []chan struct{}
+}
+
+// NewBucketRequestLimiter returns a limiter of capacity concurrent requests, a request waits for maxWait
+// at most. The weight of a bucket is read when a request of the bucket arrives.
+func NewBucketRequestLimiter(capacity int, maxWait time.Duration, weight func(ctx context.Context, bucket string) (int, error)) *BucketRequestLimiter {
+	return &BucketRequestLimiter{
+		capacity: capacity,
+		buckets:  make(map[string]*bucketRequests),
+		weight:   weight,
+		maxWait:  maxWait,
+	}
+}
+
+// Handler limits the requests of the buckets, the requests which aren't on a bucket
+// like the ListBuckets and the admin requests aren't limited.
+func (l *BucketRequestLimiter) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bucket := mux.Vars(r)["bucket"]
+		// the admin requests name the bucket in the query, the s3 requests in the first element of the path
+		if bucket == "" || (r.URL.Path != "/"+bucket && !strings.HasPrefix(r.URL.Path, "/"+bucket+"/")) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if err := l.Acquire(r.Context(), bucket); err != nil {
+			requestsThrottled.WithLabelValues(globalBucketLabels.label(bucket)).Inc()
+			response.WriteErrorResponse(w, r, apierrors.ErrSlowDown)
+			return
+		}
+		defer l.Release(bucket)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Acquire takes a slot for a request of the bucket, it waits for one until the context is done or
+// for maxWait at most. Release must be called with the bucket once the request is done.
+func (l *BucketRequestLimiter) Acquire(ctx context.Context, bucket string) error {
+	weight, err := l.weight(ctx, bucket)
+	if err != nil || weight <= 0 {
+		weight = store.DefaultRequestWeight
+	}
+
+	l.mu.Lock()
+	b, ok := l.buckets[bucket]
+	if !ok {
+		b = &bucketRequests{}
+		l.buckets[bucket] = b
+	}
+	b.weight = weight
+	// a free slot means nobody waits, the slots are handed to the waiting requests as soon as they're freed
+	if l.inflight < l.capacity {
+		l.inflight++
+		b.inflight++
+		l.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	b.waiting = append(b.waiting, ready)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(l.maxWait)
+	defer timer.Stop()
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timer.C:
+		err = context.DeadlineExceeded
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, c := range b.waiting {
+		if c == ready {
+			b.waiting = append(b.waiting[:i], b.waiting[i+1:]...)
+			l.dropIdle(bucket, b)
+			return err
+		}
+	}
+	// the slot was handed over meanwhile, pass it on
+	l.release(bucket, b)
+	return err
+}
+
+// Release frees the slot of a request of the bucket
+func (l *BucketRequestLimiter) Release(bucket string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if b, ok := l.buckets[bucket]; ok {
+		l.release(bucket, b)
+	}
+}
+
+func (l *BucketRequestLimiter) release(bucket string, b *bucketRequests) {
+	l.inflight--
+	b.inflight--
+	l.dropIdle(bucket, b)
+	for l.inflight < l.capacity {
+		next := l.nextBucket()
+		if next == nil {
+			return
+		}
+		ready := next.waiting[0]
+		next.waiting = next.waiting[1:]
+		next.inflight++
+		l.inflight++
+		close(ready)
+	}
+}
+
+// nextBucket returns the waiting bucket which has the fewest requests in flight for its weight,
+// nil if no request waits
+func (l *BucketRequestLimiter) nextBucket() *bucketRequests {
+	var next *bucketRequests
+	for _, b := range l.buckets {
+		if len(b.waiting) == 0 {
+			continue
+		}
+		// b.inflight/b.weight < next.inflight/next.weight
+		if next == nil || b.inflight*next.weight < next.inflight*b.weight {
+			next = b
+		}
+	}
+	return next
+}
+
+func (l *BucketRequestLimiter) dropIdle(bucket string, b *bucketRequests) {
+	if b.inflight == 0 && len(b.waiting) == 0 {
+		delete(l.buckets, bucket)
+	}
+}
@@ -0,0 +1,159 @@
+package s3api
+
+import (
+	"context"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBucketRequestLimiter_Flood(t *testing.T) {
+	const capacity = 4
+	limiter := NewBucketRequestLimiter(capacity, time.Minute, func(ctx context.Context, bucket string) (int, error) {
+		return 1, nil
+	})
+	// the requests of the hot bucket hang until they're let go one by one
+	started := make(chan string, 100)
+	letGo := make(chan struct{})
+	router := mux.NewRouter()
+	router.Methods(http.MethodGet).Path("/{bucket}/{object:.+}").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bucket := mux.Vars(r)["bucket"]
+		started <- bucket
+		if bucket == "hot" {
+			<-letGo
+		}
+	})
+	router.Use(limiter.Handler)
+	serve := func(bucket string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+bucket+"/obj", nil))
+		return w.Code
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer close(letGo)
+	// the flood takes every slot and queues many more requests
+	for i := 0; i < 3*capacity; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serve("hot")
+		}()
+	}
+	for i := 0; i < capacity; i++ {
+		if bucket := <-started; bucket != "hot" {
+			t.Fatalf("unexpected request of %v", bucket)
+		}
+	}
+	waitFor(t, func() bool {
+		limiter.mu.Lock()
+		defer limiter.mu.Unlock()
+		return len(limiter.buckets["hot"].waiting) == 2*capacity
+	})
+
+	done := make(chan int)
+	go func() {
+		done <- serve("cold")
+	}()
+	waitFor(t, func() bool {
+		limiter.mu.Lock()
+		defer limiter.mu.Unlock()
+		b, ok := limiter.buckets["cold"]
+		return ok && len(b.waiting) == 1
+	})
+	// the first freed slot goes to the other bucket, ahead of the queued requests of the flood
+	letGo <- struct{}{}
+	if bucket := <-started; bucket != "cold" {
+		t.Fatalf("the freed slot went to %v", bucket)
+	}
+	if code := <-done; code != http.StatusOK {
+		t.Fatalf("unexpected status %d", code)
+	}
+}
+
+func TestBucketRequestLimiter_Weights(t *testing.T) {
+	weights := map[string]int{"heavy": 3, "light": 1}
+	limiter := NewBucketRequestLimiter(4, time.Minute, func(ctx context.Context, bucket string) (int, error) {
+		return weights[bucket], nil
+	})
+	ctx := context.TODO()
+	for i := 0; i < 4; i++ {
+		if err := limiter.Acquire(ctx, "heavy"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var wg sync.WaitGroup
+	for _, bucket := range []string{"heavy", "light"} {
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(bucket string) {
+				defer wg.Done()
+				if err := limiter.Acquire(ctx, bucket); err != nil {
+					t.Error(err)
+				}
+			}(bucket)
+		}
+	}
+	waitFor(t, func() bool {
+		limiter.mu.Lock()
+		defer limiter.mu.Unlock()
+		return len(limiter.buckets["heavy"].waiting) == 4 && limiter.buckets["light"] != nil && len(limiter.buckets["light"].waiting) == 4
+	})
+	inflight := func(bucket string) int {
+		limiter.mu.Lock()
+		defer limiter.mu.Unlock()
+		return limiter.buckets[bucket].inflight
+	}
+	// the slots freed by the heavy bucket are shared 3:1 while both buckets wait
+	for i := 0; i < 3; i++ {
+		limiter.Release("heavy")
+		if heavy, light := inflight("heavy"), inflight("light"); heavy != 3 || light != 1 {
+			t.Fatalf("unexpected requests in flight, heavy %d light %d", heavy, light)
+		}
+	}
+	// the light bucket gets no more than its share even when it frees its slot
+	limiter.Release("light")
+	if heavy, light := inflight("heavy"), inflight("light"); heavy != 3 || light != 1 {
+		t.Fatalf("unexpected requests in flight, heavy %d light %d", heavy, light)
+	}
+
+	for i := 0; i < 8; i++ {
+		limiter.Release("heavy")
+		limiter.Release("light")
+	}
+	wg.Wait()
+}
+
+func TestBucketRequestLimiter_WaitTimeout(t *testing.T) {
+	limiter := NewBucketRequestLimiter(1, 10*time.Millisecond, func(ctx context.Context, bucket string) (int, error) {
+		return 1, nil
+	})
+	ctx := context.TODO()
+	if err := limiter.Acquire(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := limiter.Acquire(ctx, "b"); err != context.DeadlineExceeded {
+		t.Fatalf("the request must give up waiting, %v", err)
+	}
+	limiter.Release("a")
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	if limiter.inflight != 0 || len(limiter.buckets) != 0 {
+		t.Fatalf("the limiter must be idle, %d in flight and %d buckets", limiter.inflight, len(limiter.buckets))
+	}
+}
+
+// waitFor waits for the condition to hold for a second at most
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("the condition doesn't hold in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
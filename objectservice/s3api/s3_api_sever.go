@@ -1,6 +1,7 @@
 package s3api
 
 import (
+	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
 	"github.com/filedag-project/filedag-storage/objectservice/consts"
 	"github.com/filedag-project/filedag-storage/objectservice/iam"
 	"github.com/filedag-project/filedag-storage/objectservice/iam/set"
@@ -8,14 +9,128 @@ import (
 	"github.com/filedag-project/filedag-storage/objectservice/store"
 
 	"github.com/gorilla/mux"
+	logging "github.com/ipfs/go-log/v2"
 	"github.com/rs/cors"
 	"net/http"
+	"strconv"
+	"time"
 )
 
+// accessLog the access log of the requests, it can be turned off by raising the level of the access subsystem
+var accessLog = logging.Logger("access")
+
 type s3ApiServer struct {
 	authSys *iam.AuthSys
 	store   *store.StorageSys
 	bmSys   *store.BucketMetadataSys
+
+	// headDeleteMarkerNotFound answers the HEAD of a delete marker by its version ID with 404 instead of 405
+	headDeleteMarkerNotFound bool
+}
+
+// S3ServerOption configures the S3 server
+type S3ServerOption func(s3a *s3ApiServer)
+
+// WithHeadDeleteMarkerNotFound answers the HEAD of a delete marker by its version ID with 404 Not Found
+// like the HEAD of the object behind it, S3 answers 405 Method Not Allowed, which some clients take for
+// a failure instead of a missing version. The GET of the delete marker is still answered with 405.
+func WithHeadDeleteMarkerNotFound() S3ServerOption {
+	return func(s3a *s3ApiServer) {
+		s3a.headDeleteMarkerNotFound = true
+	}
+}
+
+// rejectedAPI an S3 API which isn't implemented, it's matched by the subresource query
+type rejectedAPI struct {
+	methods []string
+	queries []string
+	path    string
+}
+
+// rejectedBucketAPIs and rejectedObjectAPIs are answered with NotImplemented,
+// otherwise the catch-all bucket and object routes would serve them as another API.
+var (
+	rejectedBucketAPIs = []rejectedAPI{
+		{
+			methods: []string{http.MethodGet, http.MethodPut, http.MethodDelete},
+			queries: []string{"analytics", ""},
+		},
+		{
+			methods: []string{http.MethodGet, http.MethodPut, http.MethodDelete},
+			queries: []string{"metrics", ""},
+		},
+		{
+			methods: []string{http.MethodGet, http.MethodPut, http.MethodDelete},
+			queries: []string{"intelligent-tiering", ""},
+		},
+		{
+			methods: []string{http.MethodGet, http.MethodPut},
+			queries: []string{"logging", ""},
+		},
+		{
+			methods: []string{http.MethodGet, http.MethodPut},
+			queries: []string{"notification", ""},
+		},
+		{
+			methods: []string{http.MethodGet, http.MethodPut, http.MethodDelete},
+			queries: []string{"replication", ""},
+		},
+		{
+			methods: []string{http.MethodGet, http.MethodPut, http.MethodDelete},
+			queries: []string{"encryption", ""},
+		},
+		{
+			methods: []string{http.MethodGet, http.MethodPut},
+			queries: []string{"object-lock", ""},
+		},
+		{
+			methods: []string{http.MethodGet, http.MethodPut, http.MethodDelete},
+			queries: []string{"ownershipControls", ""},
+		},
+		{
+			methods: []string{http.MethodGet, http.MethodPut, http.MethodDelete},
+			queries: []string{"publicAccessBlock", ""},
+		},
+		{
+			methods: []string{http.MethodGet, http.MethodPut},
+			queries: []string{"requestPayment", ""},
+		},
+		{
+			methods: []string{http.MethodGet, http.MethodPut},
+			queries: []string{"accelerate", ""},
+		},
+		{
+			methods: []string{http.MethodGet},
+			queries: []string{"policyStatus", ""},
+		},
+	}
+	rejectedObjectAPIs = []rejectedAPI{
+		{
+			methods: []string{http.MethodGet, http.MethodPut},
+			queries: []string{"retention", ""},
+			path:    "/{object:.+}",
+		},
+		{
+			methods: []string{http.MethodGet},
+			queries: []string{"torrent", ""},
+			path:    "/{object:.+}",
+		},
+		{
+			methods: []string{http.MethodPost},
+			queries: []string{"restore", ""},
+			path:    "/{object:.+}",
+		},
+		{
+			methods: []string{http.MethodPost},
+			queries: []string{"select", ""},
+			path:    "/{object:.+}",
+		},
+	}
+)
+
+// notImplementedHandler answers the rejected APIs
+func notImplementedHandler(w http.ResponseWriter, r *http.Request) {
+	response.WriteErrorResponse(w, r, apierrors.ErrNotImplementedSubresource)
 }
 
 //registerS3Router Register APIs
@@ -29,19 +144,52 @@ func (s3a *s3ApiServer) registerS3Router(router *mux.Router) {
 	var routers []*mux.Router
 	routers = append(routers, apiRouter.PathPrefix("/{bucket}").Subrouter())
 
+	// the rejected APIs go first, the object ones before the bucket ones which match the object paths too
+	for _, bucket := range routers {
+		for _, rejected := range append(rejectedObjectAPIs, rejectedBucketAPIs...) {
+			for _, method := range rejected.methods {
+				route := bucket.Methods(method)
+				if rejected.path != "" {
+					route = route.Path(rejected.path)
+				}
+				route.HandlerFunc(notImplementedHandler).Queries(rejected.queries...)
+			}
+		}
+	}
+
 	for _, bucket := range routers {
 		// Object operations
 		//HeadObject
 		bucket.Methods(http.MethodHead).Path("/{object:.+}").HandlerFunc(s3a.HeadObjectHandler)
 
+		// AppendObject
+		bucket.Methods(http.MethodPost).Path("/{object:.+}").HandlerFunc(objectMetricsHandler("AppendObject", s3a.AppendObjectHandler)).Queries("append", "")
+		// TouchObject
+		bucket.Methods(http.MethodPost).Path("/{object:.+}").HandlerFunc(s3a.TouchObjectHandler).Queries("touch", "")
 		// NewMultipartUpload
 		bucket.Methods(http.MethodPost).Path("/{object:.+}").HandlerFunc(s3a.NewMultipartUploadHandler).Queries("uploads", "")
 		// CopyObjectPart
 		bucket.Methods(http.MethodPut).Path("/{object:.+}").HeadersRegexp("X-Amz-Copy-Source", ".*?(\\/|%2F).*?").HandlerFunc(s3a.CopyObjectPartHandler).Queries("partNumber", "{partNumber:[0-9]+}", "uploadId", "{uploadId:.*}")
 		// PutObjectPart
-		bucket.Methods(http.MethodPut).Path("/{object:.+}").HandlerFunc(s3a.PutObjectPartHandler).Queries("partNumber", "{partNumber:[0-9]+}", "uploadId", "{uploadId:.*}")
+		bucket.Methods(http.MethodPut).Path("/{object:.+}").HandlerFunc(objectMetricsHandler("PutObjectPart", s3a.PutObjectPartHandler)).Queries("partNumber", "{partNumber:[0-9]+}", "uploadId", "{uploadId:.*}")
 		// ListObjectParts
 		bucket.Methods(http.MethodGet).Path("/{object:.+}").HandlerFunc(s3a.ListObjectPartsHandler).Queries("uploadId", "{uploadId:.*}")
+		// GetObjectAttributes
+		bucket.Methods(http.MethodGet).Path("/{object:.+}").HandlerFunc(s3a.GetObjectAttributesHandler).Queries("attributes", "")
+		// PutObjectLegalHold
+		bucket.Methods(http.MethodPut).Path("/{object:.+}").HandlerFunc(s3a.PutObjectLegalHoldHandler).Queries("legal-hold", "")
+		// GetObjectLegalHold
+		bucket.Methods(http.MethodGet).Path("/{object:.+}").HandlerFunc(s3a.GetObjectLegalHoldHandler).Queries("legal-hold", "")
+		// PutObjectTagging
+		bucket.Methods(http.MethodPut).Path("/{object:.+}").HandlerFunc(s3a.PutObjectTaggingHandler).Queries("tagging", "")
+		// GetObjectTagging
+		bucket.Methods(http.MethodGet).Path("/{object:.+}").HandlerFunc(s3a.GetObjectTaggingHandler).Queries("tagging", "")
+		// DeleteObjectTagging
+		bucket.Methods(http.MethodDelete).Path("/{object:.+}").HandlerFunc(s3a.DeleteObjectTaggingHandler).Queries("tagging", "")
+		// PutObjectAcl
+		bucket.Methods(http.MethodPut).Path("/{object:.+}").HandlerFunc(s3a.PutObjectAclHandler).Queries("acl", "")
+		// GetObjectAcl
+		bucket.Methods(http.MethodGet).Path("/{object:.+}").HandlerFunc(s3a.GetObjectAclHandler).Queries("acl", "")
 		// ListMultipartUploads
 		bucket.Methods(http.MethodGet).HandlerFunc(s3a.ListMultipartUploadsHandler).Queries("uploads", "")
 		// CompleteMultipartUpload
@@ -51,20 +199,24 @@ func (s3a *s3ApiServer) registerS3Router(router *mux.Router) {
 
 		// ListObjectsV2
 		bucket.Methods(http.MethodGet).HandlerFunc(s3a.ListObjectsV2Handler).Queries("list-type", "2")
+		// ListObjectVersions
+		bucket.Methods(http.MethodGet).HandlerFunc(s3a.ListObjectVersionsHandler).Queries("versions", "")
 		// CopyObject
 		bucket.Methods(http.MethodPut).Path("/{object:.+}").HeadersRegexp("X-Amz-Copy-Source", ".*?(\\/|%2F).*?").HandlerFunc(s3a.CopyObjectHandler)
 		// GetObject
-		bucket.Methods(http.MethodGet).Path("/{object:.+}").HandlerFunc(s3a.GetObjectHandler)
+		bucket.Methods(http.MethodGet).Path("/{object:.+}").HandlerFunc(objectMetricsHandler("GetObject", s3a.GetObjectHandler))
 		// PutObject
-		bucket.Methods(http.MethodPut).Path("/{object:.+}").HandlerFunc(s3a.PutObjectHandler)
+		bucket.Methods(http.MethodPut).Path("/{object:.+}").HandlerFunc(objectMetricsHandler("PutObject", s3a.PutObjectHandler))
 		// DeleteObject
 		bucket.Methods(http.MethodDelete).Path("/{object:.+}").HandlerFunc(s3a.DeleteObjectHandler)
 		// DeleteMultipleObjects
 		bucket.Methods(http.MethodPost).HandlerFunc(s3a.DeleteMultipleObjectsHandler).Queries("delete", "")
+		// PostPolicy
+		bucket.Methods(http.MethodPost).HeadersRegexp(consts.ContentType, "multipart/form-data*").HandlerFunc(s3a.PostPolicyBucketHandler)
 
 		// Bucket operations
 		// GetBucketLocation
-		router.Methods(http.MethodGet).HandlerFunc(s3a.GetBucketLocationHandler).Queries("location", "")
+		bucket.Methods(http.MethodGet).HandlerFunc(s3a.GetBucketLocationHandler).Queries("location", "")
 
 		// PutBucketPolicy
 		bucket.Methods(http.MethodPut).HandlerFunc(s3a.PutBucketPolicyHandler).Queries("policy", "")
@@ -88,9 +240,48 @@ func (s3a *s3ApiServer) registerS3Router(router *mux.Router) {
 		// PutBucketTaggingHandler
 		bucket.Methods(http.MethodPut).HandlerFunc(s3a.PutBucketTaggingHandler).Queries("tagging", "")
 		// GetBucketTaggingHandler
-		router.Methods(http.MethodGet).HandlerFunc(s3a.GetBucketTaggingHandler).Queries("tagging", "")
+		bucket.Methods(http.MethodGet).HandlerFunc(s3a.GetBucketTaggingHandler).Queries("tagging", "")
 		// DeleteBucketTaggingHandler
-		router.Methods(http.MethodDelete).HandlerFunc(s3a.DeleteBucketTaggingHandler).Queries("tagging", "")
+		bucket.Methods(http.MethodDelete).HandlerFunc(s3a.DeleteBucketTaggingHandler).Queries("tagging", "")
+
+		// GetBucketVersioning
+		bucket.Methods(http.MethodGet).HandlerFunc(s3a.GetBucketVersioningHandler).Queries("versioning", "")
+		// PutBucketVersioning
+		bucket.Methods(http.MethodPut).HandlerFunc(s3a.PutBucketVersioningHandler).Queries("versioning", "")
+
+		// PutBucketWebsite
+		bucket.Methods(http.MethodPut).HandlerFunc(s3a.PutBucketWebsiteHandler).Queries("website", "")
+		// GetBucketWebsite
+		bucket.Methods(http.MethodGet).HandlerFunc(s3a.GetBucketWebsiteHandler).Queries("website", "")
+		// DeleteBucketWebsite
+		bucket.Methods(http.MethodDelete).HandlerFunc(s3a.DeleteBucketWebsiteHandler).Queries("website", "")
+
+		// PutBucketLifecycleConfiguration
+		bucket.Methods(http.MethodPut).HandlerFunc(s3a.PutBucketLifecycleHandler).Queries("lifecycle", "")
+		// GetBucketLifecycleConfiguration
+		bucket.Methods(http.MethodGet).HandlerFunc(s3a.GetBucketLifecycleHandler).Queries("lifecycle", "")
+		// DeleteBucketLifecycle
+		bucket.Methods(http.MethodDelete).HandlerFunc(s3a.DeleteBucketLifecycleHandler).Queries("lifecycle", "")
+
+		// PutBucketInventoryConfiguration
+		bucket.Methods(http.MethodPut).HandlerFunc(s3a.PutBucketInventoryConfigurationHandler).Queries("inventory", "", "id", "{id:.*}")
+		// GetBucketInventoryConfiguration
+		bucket.Methods(http.MethodGet).HandlerFunc(s3a.GetBucketInventoryConfigurationHandler).Queries("inventory", "", "id", "{id:.*}")
+		// ListBucketInventoryConfigurations
+		bucket.Methods(http.MethodGet).HandlerFunc(s3a.ListBucketInventoryConfigurationsHandler).Queries("inventory", "")
+		// DeleteBucketInventoryConfiguration
+		bucket.Methods(http.MethodDelete).HandlerFunc(s3a.DeleteBucketInventoryConfigurationHandler).Queries("inventory", "", "id", "{id:.*}")
+
+		// PutBucketOverwriteProtection, not an S3 API
+		bucket.Methods(http.MethodPut).HandlerFunc(s3a.PutBucketOverwriteProtectionHandler).Queries("overwrite-protection", "")
+		// GetBucketOverwriteProtection, not an S3 API
+		bucket.Methods(http.MethodGet).HandlerFunc(s3a.GetBucketOverwriteProtectionHandler).Queries("overwrite-protection", "")
+		// DeleteBucketOverwriteProtection, not an S3 API
+		bucket.Methods(http.MethodDelete).HandlerFunc(s3a.DeleteBucketOverwriteProtectionHandler).Queries("overwrite-protection", "")
+		// PutBucketKeyCase, not an S3 API
+		bucket.Methods(http.MethodPut).HandlerFunc(s3a.PutBucketKeyCaseHandler).Queries("key-case", "")
+		// GetBucketKeyCase, not an S3 API
+		bucket.Methods(http.MethodGet).HandlerFunc(s3a.GetBucketKeyCaseHandler).Queries("key-case", "")
 
 		// PutBucket
 		bucket.Methods(http.MethodPut).HandlerFunc(s3a.PutBucketHandler)
@@ -99,6 +290,8 @@ func (s3a *s3ApiServer) registerS3Router(router *mux.Router) {
 		// DeleteBucket
 		bucket.Methods(http.MethodDelete).HandlerFunc(s3a.DeleteBucketHandler)
 
+		// GetBucketUsage, not an S3 API
+		bucket.Methods(http.MethodGet).HandlerFunc(s3a.GetBucketUsageHandler).Queries("usage", "")
 		// ListObjectsV1
 		bucket.Methods(http.MethodGet).HandlerFunc(s3a.ListObjectsV1Handler)
 	}
@@ -118,20 +311,88 @@ func (s3a *s3ApiServer) registerSTSRouter(router *mux.Router) {
 }
 
 //NewS3Server Start a S3Server
-func NewS3Server(router *mux.Router, authSys *iam.AuthSys, bmSys *store.BucketMetadataSys, storageSys *store.StorageSys) {
+func NewS3Server(router *mux.Router, authSys *iam.AuthSys, bmSys *store.BucketMetadataSys, storageSys *store.StorageSys, opts ...S3ServerOption) {
 	s3server := &s3ApiServer{
 		authSys: authSys,
 		store:   storageSys,
 		bmSys:   bmSys,
 	}
+	for _, opt := range opts {
+		opt(s3server)
+	}
 	s3server.registerSTSRouter(router)
 	s3server.registerS3Router(router)
 
+	router.Use(accessLogHandler)
+	router.Use(s3server.dbAvailableHandler)
 	router.Use(iam.SetAuthHandler)
+	router.Use(s3server.bucketUsageHandler)
+}
+
+// unauthenticatedPrincipal the principal of the requests which fail the authentication or aren't authenticated
+const unauthenticatedPrincipal = "-"
+
+// accessLogHandler logs each request with the principal who sent it and counts the requests by the principal.
+// The principal is recorded when the handler authenticates the request, so that the anonymous requests are
+// attributed to the anonymous principal the same as they are evaluated by the policies.
+func accessLogHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx, id := iam.WithRequestIdentity(r.Context())
+		cw := &countingResponseWriter{ResponseWriter: w}
+		defer func() {
+			status := cw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			principal := id.Principal
+			if principal == "" {
+				principal = unauthenticatedPrincipal
+			}
+			requestsTotal.WithLabelValues(globalPrincipalLabels.label(principal), strconv.Itoa(status)).Inc()
+			accessLog.Infow("request", "principal", principal, "method", r.Method, "path", r.URL.Path,
+				"status", status, "sent", cw.n, "duration", time.Since(start))
+		}()
+		h.ServeHTTP(cw, r.WithContext(ctx))
+	})
+}
+
+// bucketUsageHandler counts the requests of the buckets with the bytes of their bodies,
+// the aborted responses are counted too.
+func (s3a *s3ApiServer) bucketUsageHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bucket := mux.Vars(r)["bucket"]
+		if bucket == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		body := &countingReadCloser{ReadCloser: r.Body}
+		r.Body = body
+		cw := &countingResponseWriter{ResponseWriter: w}
+		defer func() {
+			s3a.bmSys.RecordUsage(bucket, r.Method, body.n, cw.n)
+		}()
+		h.ServeHTTP(cw, r)
+	})
+}
+
+// dbAvailableHandler fails fast while the metadata db is unavailable,
+// instead of letting each request hang on it.
+func (s3a *s3ApiServer) dbAvailableHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s3a.store.Db.Available() {
+			response.WriteErrorResponse(w, r, apierrors.ErrServiceUnavailable)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
 }
 
 // CorsHandler handler for CORS (Cross Origin Resource Sharing)
-func CorsHandler(handler http.Handler) http.Handler {
+// The requests to a bucket with a CORS configuration are evaluated against its rules, the preflight requests
+// are answered by them and the other requests get the headers of the rule matching their origin and method.
+// Any origin is allowed to access the buckets without a CORS configuration and the service.
+func CorsHandler(handler http.Handler, bmSys *store.BucketMetadataSys) http.Handler {
 	commonS3Headers := []string{
 		consts.Date,
 		consts.ETag,
@@ -157,7 +418,7 @@ func CorsHandler(handler http.Handler) http.Handler {
 		"*",
 	}
 
-	return cors.New(cors.Options{
+	allowAll := cors.New(cors.Options{
 		AllowOriginFunc: func(origin string) bool {
 
 			return true
@@ -175,4 +436,27 @@ func CorsHandler(handler http.Handler) http.Handler {
 		ExposedHeaders:   commonS3Headers,
 		AllowCredentials: true,
 	}).Handler(handler)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get(consts.Origin)
+		bucket := corsBucket(r)
+		if origin == "" || bucket == "" {
+			allowAll.ServeHTTP(w, r)
+			return
+		}
+		cors, err := bmSys.GetCorsConfig(r.Context(), bucket)
+		if err != nil {
+			allowAll.ServeHTTP(w, r)
+			return
+		}
+		if isPreflight(r) {
+			writeCorsPreflight(w, r, cors)
+			return
+		}
+		w.Header().Add(consts.Vary, consts.Origin)
+		if rule := cors.MatchRule(origin, r.Method, nil); rule != nil {
+			setCorsHeaders(w, origin, rule)
+		}
+		handler.ServeHTTP(w, r)
+	})
 }
@@ -0,0 +1,203 @@
+package s3api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"github.com/filedag-project/filedag-storage/objectservice/iam/auth"
+	"github.com/filedag-project/filedag-storage/objectservice/response"
+	"github.com/filedag-project/filedag-storage/objectservice/utils"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// accessLogEntry an entry of the access log
+type accessLogEntry struct {
+	Logger    string `json:"logger"`
+	Msg       string `json:"msg"`
+	Principal string `json:"principal"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Status    int    `json:"status"`
+}
+
+func TestAccessLogHandler_Anonymous(t *testing.T) {
+	bucketName := "testbucketanonymous"
+	objectName := "testobjectanonymous"
+	u := "/" + bucketName + "/" + objectName
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+	content := "1234567"
+	reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, u, int64(len(content)), strings.NewReader(content), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutObject).Code)
+
+	// the anonymous requests are allowed to get the objects by the anonymous principal
+	p := `{"Version":"2012-10-17","Statement":[` +
+		`{"Effect":"Allow","Principal":{"AWS":["` + DefaultTestAccessKey + `"]},"Action":["s3:*"],"Resource":["arn:aws:s3:::` + bucketName + `/*"]},` +
+		`{"Effect":"Allow","Principal":{"AWS":["` + auth.DefaultAnonymousPrincipal + `"]},"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::` + bucketName + `/*"]}]}`
+	reqPutPolicy := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"?policy", int64(len(p)), strings.NewReader(p),
+		"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNoContent, reqTest(reqPutPolicy).Code)
+
+	require.NoError(t, logging.SetLogLevel("access", "info"))
+	pipe := logging.NewPipeReader()
+	entries := make(chan accessLogEntry, 100)
+	go func() {
+		defer close(entries)
+		scanner := bufio.NewScanner(pipe)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			var entry accessLogEntry
+			if json.Unmarshal(scanner.Bytes(), &entry) == nil && entry.Logger == "access" && strings.HasPrefix(entry.Path, "/"+bucketName) {
+				entries <- entry
+			}
+		}
+	}()
+	allowed := testutil.ToFloat64(requestsTotal.WithLabelValues(auth.DefaultAnonymousPrincipal, "200"))
+	denied := testutil.ToFloat64(requestsTotal.WithLabelValues(auth.DefaultAnonymousPrincipal, "403"))
+
+	result := reqTest(httptest.NewRequest(http.MethodGet, u, nil))
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, content, result.Body.String())
+	// the policy allows the anonymous principal to get the objects only
+	result = reqTest(httptest.NewRequest(http.MethodPut, u, bytes.NewReader([]byte(content))))
+	require.Equal(t, http.StatusForbidden, result.Code)
+	require.NoError(t, pipe.Close())
+
+	var logged []accessLogEntry
+	for entry := range entries {
+		logged = append(logged, entry)
+	}
+	require.Equal(t, []accessLogEntry{
+		{Logger: "access", Msg: "request", Principal: auth.DefaultAnonymousPrincipal, Method: http.MethodGet, Path: u, Status: http.StatusOK},
+		{Logger: "access", Msg: "request", Principal: auth.DefaultAnonymousPrincipal, Method: http.MethodPut, Path: u, Status: http.StatusForbidden},
+	}, logged)
+	require.Equal(t, allowed+1, testutil.ToFloat64(requestsTotal.WithLabelValues(auth.DefaultAnonymousPrincipal, "200")))
+	require.Equal(t, denied+1, testutil.ToFloat64(requestsTotal.WithLabelValues(auth.DefaultAnonymousPrincipal, "403")))
+}
+
+func TestS3ApiServer_AnonymousPrincipalUser(t *testing.T) {
+	ctx := context.TODO()
+	// a user can't be the anonymous principal
+	require.NoError(t, authSys.Iam.AddUser(ctx, "anonymoususer", "anonymoussecret"))
+	require.Error(t, authSys.SetAnonymousPrincipal(ctx, "anonymoususer"))
+	require.Equal(t, auth.DefaultAnonymousPrincipal, authSys.AnonymousPrincipal())
+
+	// the user policies never apply to the anonymous requests, even if a user of the principal shows up
+	require.NoError(t, authSys.SetAnonymousPrincipal(ctx, "anonymouslater"))
+	defer authSys.SetAnonymousPrincipal(ctx, auth.DefaultAnonymousPrincipal)
+	require.NoError(t, authSys.Iam.AddUser(ctx, "anonymouslater", "anonymoussecret"))
+	require.Equal(t, http.StatusForbidden, reqTest(httptest.NewRequest(http.MethodPut, "/testbucketanonymoususer", nil)).Code)
+	require.Equal(t, http.StatusForbidden, reqTest(httptest.NewRequest(http.MethodGet, "/", nil)).Code)
+}
+
+func TestS3ApiServer_NotImplementedSubresources(t *testing.T) {
+	bucketName := "testbucketsubresources"
+	u := "/" + bucketName + "/testobjectsubresources"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+	content := "1234567"
+	reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, u, int64(len(content)), strings.NewReader(content), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutObject).Code)
+
+	testCases := []struct {
+		method string
+		url    string
+		body   string
+	}{
+		{method: http.MethodGet, url: "/" + bucketName + "?analytics"},
+		{method: http.MethodPut, url: "/" + bucketName + "?intelligent-tiering&id=tiering", body: "<IntelligentTieringConfiguration/>"},
+		{method: http.MethodGet, url: "/" + bucketName + "?metrics"},
+		{method: http.MethodDelete, url: "/" + bucketName + "?replication"},
+		{method: http.MethodPut, url: "/" + bucketName + "?logging", body: "<BucketLoggingStatus/>"},
+		{method: http.MethodGet, url: u + "?retention"},
+		{method: http.MethodPut, url: u + "?retention", body: "<Retention/>"},
+		{method: http.MethodPost, url: u + "?select&select-type=2", body: "<SelectObjectContentRequest/>"},
+	}
+	for _, tc := range testCases {
+		req := utils.MustNewSignedV4Request(tc.method, tc.url, int64(len(tc.body)), strings.NewReader(tc.body), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		result := reqTest(req)
+		require.Equal(t, http.StatusNotImplemented, result.Code, tc.method+" "+tc.url)
+		var errResp response.APIErrorResponse
+		require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &errResp), tc.method+" "+tc.url)
+		require.Equal(t, "NotImplemented", errResp.Code, tc.method+" "+tc.url)
+	}
+
+	// the bucket is still there and the object isn't overwritten by the rejected PUT
+	reqHeadBucket := utils.MustNewSignedV4Request(http.MethodHead, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqHeadBucket).Code)
+	reqGetObject := utils.MustNewSignedV4Request(http.MethodGet, u, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(reqGetObject)
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, content, result.Body.String())
+}
+
+func TestS3ApiServer_AnonymousRequests(t *testing.T) {
+	bucketName := "testbucketanonymousrequests"
+	u := "/" + bucketName + "/obj"
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+	content := "1234567"
+	reqPutObject := utils.MustNewSignedV4Request(http.MethodPut, u, int64(len(content)), strings.NewReader(content), "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutObject).Code)
+	putPolicy := func(anonymousActions string) {
+		p := `{"Version":"2012-10-17","Statement":[` +
+			`{"Effect":"Allow","Principal":{"AWS":["` + DefaultTestAccessKey + `"]},"Action":["s3:*"],"Resource":["arn:aws:s3:::` + bucketName + `/*"]},` +
+			`{"Effect":"Allow","Principal":"*","Action":[` + anonymousActions + `],"Resource":["arn:aws:s3:::` + bucketName + `/*"]}]}`
+		req := utils.MustNewSignedV4Request(http.MethodPut, "/"+bucketName+"?policy", int64(len(p)), strings.NewReader(p),
+			"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		require.Equal(t, http.StatusNoContent, reqTest(req).Code)
+	}
+	putPolicy(`"s3:GetObject"`)
+
+	// the public grant allows the anonymous reads
+	result := reqTest(httptest.NewRequest(http.MethodGet, u, nil))
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, content, result.Body.String())
+	require.Equal(t, http.StatusOK, reqTest(httptest.NewRequest(http.MethodHead, u, nil)).Code)
+
+	// nothing else is granted to the anonymous requests
+	testCases := []struct {
+		method string
+		url    string
+		body   string
+	}{
+		{method: http.MethodDelete, url: u},
+		{method: http.MethodPut, url: u, body: content},
+		{method: http.MethodPost, url: u + "?uploads"},
+		{method: http.MethodPut, url: u + "?tagging", body: "<Tagging><TagSet></TagSet></Tagging>"},
+		{method: http.MethodGet, url: u + "?acl"},
+		{method: http.MethodGet, url: "/" + bucketName},
+		{method: http.MethodGet, url: "/" + bucketName + "?policy"},
+		{method: http.MethodDelete, url: "/" + bucketName},
+		{method: http.MethodPut, url: "/testbucketanonymouscreated"},
+		{method: http.MethodGet, url: "/"},
+	}
+	for _, tc := range testCases {
+		result = reqTest(httptest.NewRequest(tc.method, tc.url, strings.NewReader(tc.body)))
+		require.Equal(t, http.StatusForbidden, result.Code, tc.method+" "+tc.url)
+		var errResp response.APIErrorResponse
+		require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &errResp), tc.method+" "+tc.url)
+		require.Equal(t, "AccessDenied", errResp.Code, tc.method+" "+tc.url)
+	}
+
+	// a signed request is authenticated first, a bad signature isn't served as an anonymous request
+	reqGetObject := utils.MustNewSignedV4Request(http.MethodGet, u, 0, nil, "s3", DefaultTestAccessKey, "wrongsecret", t)
+	result = reqTest(reqGetObject)
+	require.Equal(t, http.StatusForbidden, result.Code)
+	var errResp response.APIErrorResponse
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &errResp))
+	require.Equal(t, "SignatureDoesNotMatch", errResp.Code)
+
+	// the anonymous deletes are allowed once they're granted explicitly
+	putPolicy(`"s3:GetObject","s3:DeleteObject"`)
+	require.Equal(t, http.StatusNoContent, reqTest(httptest.NewRequest(http.MethodDelete, u, nil)).Code)
+	require.Equal(t, http.StatusNotFound, reqTest(httptest.NewRequest(http.MethodGet, u, nil)).Code)
+}
@@ -0,0 +1,205 @@
+package s3api
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/apierrors"
+	"github.com/filedag-project/filedag-storage/objectservice/consts"
+	"github.com/filedag-project/filedag-storage/objectservice/iam"
+	"github.com/filedag-project/filedag-storage/objectservice/iam/s3action"
+	"github.com/filedag-project/filedag-storage/objectservice/response"
+	"github.com/filedag-project/filedag-storage/objectservice/store"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/s3utils"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// registerWebsiteRouter Register the website endpoint, the buckets are addressed by the path
+// like the S3 API, e.g. http://website-endpoint/bucket/dir/
+func (s3a *s3ApiServer) registerWebsiteRouter(router *mux.Router) {
+	router.Methods(http.MethodGet, http.MethodHead).Path("/{bucket}").HandlerFunc(s3a.WebsiteHandler)
+	router.Methods(http.MethodGet, http.MethodHead).Path("/{bucket}/{object:.*}").HandlerFunc(s3a.WebsiteHandler)
+}
+
+// NewWebsiteServer Start a server of the bucket websites. The website requests are anonymous,
+// only the objects the bucket policy allows everyone to get are served.
+func NewWebsiteServer(router *mux.Router, authSys *iam.AuthSys, bmSys *store.BucketMetadataSys, storageSys *store.StorageSys) {
+	s3server := &s3ApiServer{
+		authSys: authSys,
+		store:   storageSys,
+		bmSys:   bmSys,
+	}
+	s3server.registerWebsiteRouter(router)
+
+	router.Use(accessLogHandler)
+	router.Use(s3server.dbAvailableHandler)
+	router.Use(s3server.bucketUsageHandler)
+}
+
+// WebsiteHandler - GET and HEAD of a bucket website
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/WebsiteHosting.html
+//   - a request for the root or a key ending with '/' is served the index document of the directory,
+//     a key without the '/' whose index document exists is redirected to the directory.
+//   - the failed requests are served the error document with the status of the error.
+//   - the routing rules and the website redirect location of an object redirect the requests.
+func (s3a *s3ApiServer) WebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	bucket, object, err := getBucketAndObject(r)
+	if err != nil {
+		response.WriteWebsiteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	log.Infof("WebsiteHandler %s %s", bucket, object)
+	website, err := s3a.bmSys.GetWebsiteConfig(ctx, bucket)
+	if err != nil {
+		response.WriteWebsiteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	if to := website.RedirectAllRequestsTo; to != nil {
+		protocol := to.Protocol
+		if protocol == "" {
+			protocol = requestProtocol(r)
+		}
+		writeWebsiteRedirect(w, http.StatusMovedPermanently, protocol+"://"+to.HostName+"/"+object)
+		return
+	}
+	if rule := website.MatchRoutingRule(object, 0); rule != nil {
+		s3a.routingRedirect(w, r, bucket, object, rule)
+		return
+	}
+
+	key := object
+	if key == "" || strings.HasSuffix(key, "/") {
+		key += website.IndexDocument.Suffix
+	}
+	objInfo, s3Error := s3a.getWebsiteObjectInfo(ctx, r, bucket, key)
+	if s3Error == apierrors.ErrNoSuchKey && key == object {
+		// The key may be a directory, S3 redirects it to the directory if it has an index document
+		index := object + "/" + website.IndexDocument.Suffix
+		if _, s3Err := s3a.getWebsiteObjectInfo(ctx, r, bucket, index); s3Err == apierrors.ErrNone {
+			writeWebsiteRedirect(w, http.StatusFound, "/"+bucket+"/"+object+"/")
+			return
+		}
+	}
+	if s3Error != apierrors.ErrNone {
+		status := apierrors.GetAPIError(s3Error).HTTPStatusCode
+		if rule := website.MatchRoutingRule(object, status); rule != nil {
+			s3a.routingRedirect(w, r, bucket, object, rule)
+			return
+		}
+		s3a.writeWebsiteErrorDocument(w, r, bucket, website, s3Error)
+		return
+	}
+	if location := objInfo.WebsiteRedirectLocation; location != "" {
+		if strings.HasPrefix(location, "/") {
+			location = "/" + bucket + location
+		}
+		writeWebsiteRedirect(w, http.StatusMovedPermanently, location)
+		return
+	}
+	s3a.writeWebsiteObject(w, r, http.StatusOK, bucket, key)
+}
+
+// getWebsiteObjectInfo returns the object if everyone is allowed to get it
+func (s3a *s3ApiServer) getWebsiteObjectInfo(ctx context.Context, r *http.Request, bucket, object string) (store.ObjectInfo, apierrors.ErrorCode) {
+	if err := s3utils.CheckGetObjArgs(ctx, bucket, object); err != nil {
+		return store.ObjectInfo{}, apierrors.ToApiError(ctx, err)
+	}
+	_, _, s3Error := s3a.authSys.CheckRequestAuthTypeCredential(ctx, r, s3action.GetObjectAction, bucket, object)
+	if s3Error != apierrors.ErrNone {
+		return store.ObjectInfo{}, s3Error
+	}
+	objInfo, err := s3a.store.GetObjectInfo(ctx, bucket, object, "")
+	if err != nil {
+		return store.ObjectInfo{}, apierrors.ToApiError(ctx, err)
+	}
+	return objInfo, apierrors.ErrNone
+}
+
+// writeWebsiteErrorDocument serves the error document with the status of the error,
+// a page of the error is written if the bucket has no error document or it can't be read.
+func (s3a *s3ApiServer) writeWebsiteErrorDocument(w http.ResponseWriter, r *http.Request, bucket string, website *store.Website, s3Error apierrors.ErrorCode) {
+	apiError := apierrors.GetAPIError(s3Error)
+	if website.ErrorDocument == nil || apiError.HTTPStatusCode < 400 || apiError.HTTPStatusCode > 499 {
+		response.WriteWebsiteErrorResponse(w, r, s3Error)
+		return
+	}
+	if _, s3Err := s3a.getWebsiteObjectInfo(r.Context(), r, bucket, website.ErrorDocument.Key); s3Err != apierrors.ErrNone {
+		log.Warnw("the error document of the website can't be read", "bucket", bucket,
+			"key", website.ErrorDocument.Key, "error", apierrors.GetAPIError(s3Err).Code)
+		response.WriteWebsiteErrorResponse(w, r, s3Error)
+		return
+	}
+	s3a.writeWebsiteObject(w, r, apiError.HTTPStatusCode, bucket, website.ErrorDocument.Key)
+}
+
+// writeWebsiteObject writes the object with the status, the body is left out for HEAD
+func (s3a *s3ApiServer) writeWebsiteObject(w http.ResponseWriter, r *http.Request, statusCode int, bucket, object string) {
+	ctx := r.Context()
+	var objInfo store.ObjectInfo
+	var reader io.ReadCloser
+	var err error
+	if r.Method == http.MethodHead {
+		objInfo, err = s3a.store.GetObjectInfo(ctx, bucket, object, "")
+	} else {
+		objInfo, reader, err = s3a.store.GetObject(ctx, bucket, object, "", nil)
+	}
+	if err != nil {
+		log.Errorf("WebsiteHandler GetObject err:%v", err)
+		response.WriteWebsiteErrorResponse(w, r, apierrors.ToApiError(ctx, err))
+		return
+	}
+	s3a.setBucketCacheControl(ctx, bucket, &objInfo)
+	response.SetObjectHeaders(w, r, objInfo)
+	w.Header().Set(consts.ContentLength, strconv.FormatInt(objInfo.Size, 10))
+	w.WriteHeader(statusCode)
+	if reader == nil {
+		return
+	}
+	defer reader.Close()
+	if n, err := io.Copy(w, reader); err != nil {
+		// The status is sent, the response is aborted as GetObjectHandler does.
+		log.Errorw("WebsiteHandler the read failed mid-stream, abort the response", "bucket", bucket,
+			"object", object, "sent", n, "size", objInfo.Size, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// routingRedirect redirects the request for the object by the routing rule
+func (s3a *s3ApiServer) routingRedirect(w http.ResponseWriter, r *http.Request, bucket, object string, rule *store.RoutingRule) {
+	key := rule.RedirectKey(object)
+	location := "/" + bucket + "/" + key
+	if rule.Redirect.HostName != "" || rule.Redirect.Protocol != "" {
+		protocol, host := rule.Redirect.Protocol, rule.Redirect.HostName
+		if protocol == "" {
+			protocol = requestProtocol(r)
+		}
+		if host == "" {
+			host = r.Host
+		} else {
+			// The other host is a website of its own, the key is at the root of it
+			location = "/" + key
+		}
+		location = protocol + "://" + host + location
+	}
+	statusCode := rule.Redirect.HttpRedirectCode
+	if statusCode == 0 {
+		statusCode = http.StatusMovedPermanently
+	}
+	writeWebsiteRedirect(w, statusCode, location)
+}
+
+// requestProtocol returns the protocol the request is received by
+func requestProtocol(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+func writeWebsiteRedirect(w http.ResponseWriter, statusCode int, location string) {
+	w.Header().Set(consts.Location, location)
+	w.WriteHeader(statusCode)
+}
@@ -0,0 +1,148 @@
+package s3api
+
+import (
+	"bytes"
+	"encoding/xml"
+	"github.com/filedag-project/filedag-storage/objectservice/consts"
+	"github.com/filedag-project/filedag-storage/objectservice/store"
+	"github.com/filedag-project/filedag-storage/objectservice/utils"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// websiteReqTest sends an anonymous request to the website endpoint
+func websiteReqTest(method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	websiteRouter.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestS3ApiServer_BucketWebsiteHandler(t *testing.T) {
+	bucketName := "testbucketwebsite"
+	u := "/" + bucketName
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, u, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+
+	reqGet := utils.MustNewSignedV4Request(http.MethodGet, u+"?website", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result := reqTest(reqGet)
+	require.Equal(t, http.StatusNotFound, result.Code)
+	require.Contains(t, result.Body.String(), "NoSuchWebsiteConfiguration")
+
+	// neither IndexDocument nor RedirectAllRequestsTo
+	invalid := `<WebsiteConfiguration><ErrorDocument><Key>error.html</Key></ErrorDocument></WebsiteConfiguration>`
+	reqPut := utils.MustNewSignedV4Request(http.MethodPut, u+"?website", int64(len(invalid)), strings.NewReader(invalid),
+		"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusBadRequest, reqTest(reqPut).Code)
+
+	config := `<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument>` +
+		`<ErrorDocument><Key>error.html</Key></ErrorDocument>` +
+		`<RoutingRules><RoutingRule><Condition><KeyPrefixEquals>old/</KeyPrefixEquals></Condition>` +
+		`<Redirect><ReplaceKeyPrefixWith>new/</ReplaceKeyPrefixWith></Redirect></RoutingRule></RoutingRules></WebsiteConfiguration>`
+	reqPut = utils.MustNewSignedV4Request(http.MethodPut, u+"?website", int64(len(config)), strings.NewReader(config),
+		"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPut).Code)
+
+	reqGet = utils.MustNewSignedV4Request(http.MethodGet, u+"?website", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	result = reqTest(reqGet)
+	require.Equal(t, http.StatusOK, result.Code)
+	var website store.Website
+	require.NoError(t, xml.Unmarshal(result.Body.Bytes(), &website))
+	require.Equal(t, "index.html", website.IndexDocument.Suffix)
+	require.Equal(t, "error.html", website.ErrorDocument.Key)
+	require.Len(t, website.RoutingRules, 1)
+	require.Equal(t, "new/", website.RoutingRules[0].Redirect.ReplaceKeyPrefixWith)
+
+	reqDel := utils.MustNewSignedV4Request(http.MethodDelete, u+"?website", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNoContent, reqTest(reqDel).Code)
+	reqGet = utils.MustNewSignedV4Request(http.MethodGet, u+"?website", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNotFound, reqTest(reqGet).Code)
+}
+
+func TestS3ApiServer_WebsiteHandler(t *testing.T) {
+	bucketName := "testbucketwebsiteget"
+	u := "/" + bucketName
+	reqPutBucket := utils.MustNewSignedV4Request(http.MethodPut, u, 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPutBucket).Code)
+
+	// no website configuration
+	result := websiteReqTest(http.MethodGet, u+"/")
+	require.Equal(t, http.StatusNotFound, result.Code)
+	require.Contains(t, result.Body.String(), "NoSuchWebsiteConfiguration")
+	require.Equal(t, "text/html; charset=utf-8", result.Header().Get(consts.ContentType))
+
+	config := `<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument>` +
+		`<ErrorDocument><Key>error.html</Key></ErrorDocument>` +
+		`<RoutingRules><RoutingRule><Condition><KeyPrefixEquals>old/</KeyPrefixEquals></Condition>` +
+		`<Redirect><ReplaceKeyPrefixWith>new/</ReplaceKeyPrefixWith></Redirect></RoutingRule></RoutingRules></WebsiteConfiguration>`
+	reqPut := utils.MustNewSignedV4Request(http.MethodPut, u+"?website", int64(len(config)), strings.NewReader(config),
+		"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusOK, reqTest(reqPut).Code)
+
+	// the mock dag pool returns the same content for every object
+	content := "1234567"
+	for object, contentType := range map[string]string{
+		"index.html":      "text/html",
+		"docs/index.html": "text/html",
+		"error.html":      "text/html",
+		"style.css":       "text/css",
+	} {
+		req := utils.MustNewSignedV4Request(http.MethodPut, u+"/"+object, int64(len(content)), bytes.NewReader([]byte(content)),
+			"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+		req.Header.Set(consts.ContentType, contentType)
+		require.Equal(t, http.StatusOK, reqTest(req).Code, object)
+	}
+
+	// the bucket is private, the visitors are denied
+	result = websiteReqTest(http.MethodGet, u+"/")
+	require.Equal(t, http.StatusForbidden, result.Code)
+	require.Contains(t, result.Body.String(), "AccessDenied")
+
+	p := `{"Version":"2012-10-17","Statement":[` +
+		`{"Effect":"Allow","Principal":{"AWS":["` + DefaultTestAccessKey + `"]},"Action":["s3:*"],"Resource":["arn:aws:s3:::` + bucketName + `/*"]},` +
+		`{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::` + bucketName + `/*"]}]}`
+	reqPutPolicy := utils.MustNewSignedV4Request(http.MethodPut, u+"?policy", int64(len(p)), strings.NewReader(p),
+		"s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNoContent, reqTest(reqPutPolicy).Code)
+
+	// the index document of the directories
+	for _, path := range []string{u, u + "/", u + "/docs/"} {
+		result = websiteReqTest(http.MethodGet, path)
+		require.Equal(t, http.StatusOK, result.Code, path)
+		require.Equal(t, "text/html", result.Header().Get(consts.ContentType), path)
+		require.Equal(t, content, result.Body.String(), path)
+	}
+	result = websiteReqTest(http.MethodGet, u+"/style.css")
+	require.Equal(t, http.StatusOK, result.Code)
+	require.Equal(t, "text/css", result.Header().Get(consts.ContentType))
+
+	// a directory without the trailing slash is redirected
+	result = websiteReqTest(http.MethodGet, u+"/docs")
+	require.Equal(t, http.StatusFound, result.Code)
+	require.Equal(t, u+"/docs/", result.Header().Get(consts.Location))
+
+	// the error document
+	result = websiteReqTest(http.MethodGet, u+"/missing.html")
+	require.Equal(t, http.StatusNotFound, result.Code)
+	require.Equal(t, "text/html", result.Header().Get(consts.ContentType))
+	require.Equal(t, content, result.Body.String())
+	result = websiteReqTest(http.MethodHead, u+"/missing/")
+	require.Equal(t, http.StatusNotFound, result.Code)
+	require.Equal(t, "text/html", result.Header().Get(consts.ContentType))
+	require.Empty(t, result.Body.String())
+
+	// the routing rule
+	result = websiteReqTest(http.MethodGet, u+"/old/page.html")
+	require.Equal(t, http.StatusMovedPermanently, result.Code)
+	require.Equal(t, u+"/new/page.html", result.Header().Get(consts.Location))
+
+	// the error page is written when the error document is missing
+	reqDel := utils.MustNewSignedV4Request(http.MethodDelete, u+"/error.html", 0, nil, "s3", DefaultTestAccessKey, DefaultTestSecretKey, t)
+	require.Equal(t, http.StatusNoContent, reqTest(reqDel).Code)
+	result = websiteReqTest(http.MethodGet, u+"/missing.html")
+	require.Equal(t, http.StatusNotFound, result.Code)
+	require.Equal(t, "text/html; charset=utf-8", result.Header().Get(consts.ContentType))
+	require.Contains(t, result.Body.String(), "NoSuchKey")
+}
@@ -5,8 +5,7 @@ import (
 	"encoding/xml"
 	"github.com/filedag-project/filedag-storage/objectservice/iam/policy"
 	"github.com/filedag-project/filedag-storage/objectservice/lock"
-	"github.com/filedag-project/filedag-storage/objectservice/uleveldb"
-	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
 	"time"
 )
 
@@ -43,18 +42,36 @@ func (e BucketTaggingNotFound) Error() string {
 	return "No bucket tagging configuration found for bucket: " + e.Bucket
 }
 
+// IllegalLocationConstraint - the location of the bucket isn't the region of the server.
+type IllegalLocationConstraint struct {
+	Bucket   string
+	Location string
+	Region   string
+}
+
+func (e IllegalLocationConstraint) Error() string {
+	return "The location constraint " + e.Location + " of bucket " + e.Bucket + " is not the region " + e.Region
+}
+
 // BucketMetadataSys captures all bucket metadata for a given cluster.
 type BucketMetadataSys struct {
-	db          *uleveldb.ULevelDB
+	db          metadb.DB
 	nsLock      *lock.NsLockMap
 	emptyBucket func(ctx context.Context, bucket string) (bool, error)
+	usage       *bucketUsages
+	// region the region of the server, the buckets are created in it
+	region string
+	// defaultBucketLimit the number of buckets a user without a limit of its own can create, UnlimitedBuckets is unlimited
+	defaultBucketLimit int
 }
 
 // NewBucketMetadataSys - creates new policy system.
-func NewBucketMetadataSys(db *uleveldb.ULevelDB) *BucketMetadataSys {
+func NewBucketMetadataSys(db metadb.DB) *BucketMetadataSys {
 	return &BucketMetadataSys{
-		db:     db,
-		nsLock: lock.NewNSLock(),
+		db:                 db,
+		nsLock:             lock.NewNSLock(),
+		usage:              newBucketUsages(),
+		defaultBucketLimit: DefaultMaxBuckets,
 	}
 }
 
@@ -79,8 +96,38 @@ type BucketMetadata struct {
 	Owner   string
 	Created time.Time
 
-	PolicyConfig  *policy.Policy
-	TaggingConfig *Tags
+	PolicyConfig    *policy.Policy
+	TaggingConfig   *Tags
+	LifecycleConfig *Lifecycle
+	WebsiteConfig   *Website
+	CorsConfig      *Cors
+
+	// OverwriteProtectionConfig is the minimum interval between the overwrites of a key, nil if
+	// the bucket has no overwrite protection.
+	OverwriteProtectionConfig *OverwriteProtection
+
+	// CacheControl is the default Cache-Control of objects in the bucket
+	// which have no Cache-Control of their own.
+	CacheControl string
+
+	// Versioning is the versioning status of the bucket, Enabled or Suspended,
+	// empty if the versioning has never been enabled.
+	Versioning string
+
+	// InventoryConfigs are the inventory configurations of the bucket, the reports
+	// of the objects are written to their destination buckets on their schedules.
+	InventoryConfigs []Inventory
+
+	// CaseInsensitiveKeys is true if the keys in the bucket are lowercased for the storage
+	// and the lookup, so that the keys differing only in case name the same object.
+	CaseInsensitiveKeys bool
+
+	// RequestWeight is the weight of the bucket in the sharing of the concurrent requests
+	// of the gateway, 0 means the default weight.
+	RequestWeight int
+
+	// ACL is the canned ACL of the bucket, it's empty if the bucket is private.
+	ACL string
 }
 
 // NewBucketMetadata creates BucketMetadata with the supplied name and Created to Now.
@@ -100,10 +147,38 @@ func (sys *BucketMetadataSys) NewNSLock(bucket string) lock.RWLocker {
 	return sys.nsLock.NewNSLock("meta", bucket)
 }
 
+// GetBucketLockInfo returns the state of the namespace lock of the bucket, false if nobody holds or waits for it
+func (sys *BucketMetadataSys) GetBucketLockInfo(bucket string) (lock.LockInfo, bool) {
+	return sys.nsLock.GetLockInfo("meta", bucket)
+}
+
+// ForceUnlockBucket releases the namespace lock of the bucket which has been held for minHeld
+func (sys *BucketMetadataSys) ForceUnlockBucket(bucket string, minHeld time.Duration) (lock.LockInfo, error) {
+	return sys.nsLock.ForceUnlock("meta", bucket, minHeld)
+}
+
 func (sys *BucketMetadataSys) SetEmptyBucket(emptyBucket func(ctx context.Context, bucket string) (bool, error)) {
 	sys.emptyBucket = emptyBucket
 }
 
+// SetRegion sets the region of the server, a bucket can only be created in it. Any location is accepted if it's empty.
+func (sys *BucketMetadataSys) SetRegion(region string) {
+	sys.region = region
+}
+
+// GetBucketLocation returns the region of the bucket, the bucket created before the region of the server
+// was set is in the region of the server
+func (sys *BucketMetadataSys) GetBucketLocation(ctx context.Context, bucket string) (string, error) {
+	meta, err := sys.GetBucketMeta(ctx, bucket)
+	if err != nil {
+		return "", err
+	}
+	if meta.Region == "" {
+		return sys.region, nil
+	}
+	return meta.Region, nil
+}
+
 // setBucketMeta - sets a new metadata in-db
 func (sys *BucketMetadataSys) setBucketMeta(bucket string, meta *BucketMetadata) error {
 	return sys.db.Put(bucketPrefix+bucket, meta)
@@ -111,6 +186,25 @@ func (sys *BucketMetadataSys) setBucketMeta(bucket string, meta *BucketMetadata)
 
 // CreateBucket - create a new Bucket
 func (sys *BucketMetadataSys) CreateBucket(ctx context.Context, bucket, region, accessKey string) error {
+	if region == "" {
+		region = sys.region
+	}
+	if sys.region != "" && region != sys.region {
+		return IllegalLocationConstraint{Bucket: bucket, Location: region, Region: sys.region}
+	}
+	// the buckets of the user are counted and created under the lock of the user,
+	// so that the concurrent creations can't exceed the limit
+	userLk := sys.newUserNSLock(accessKey)
+	userLkctx, err := userLk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = userLkctx.Context()
+	defer userLk.Unlock(userLkctx.Cancel)
+	if err = sys.checkUserBucketLimit(ctx, accessKey); err != nil {
+		return err
+	}
+
 	lk := sys.NewNSLock(bucket)
 	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
 	if err != nil {
@@ -124,7 +218,7 @@ func (sys *BucketMetadataSys) CreateBucket(ctx context.Context, bucket, region,
 
 func (sys *BucketMetadataSys) getBucketMeta(bucket string) (meta BucketMetadata, err error) {
 	err = sys.db.Get(bucketPrefix+bucket, &meta)
-	if err == leveldb.ErrNotFound {
+	if err == metadb.ErrNotFound {
 		err = BucketNotFound{Bucket: bucket, Err: err}
 	}
 	return meta, err
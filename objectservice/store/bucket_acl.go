@@ -0,0 +1,68 @@
+package store
+
+import (
+	"context"
+)
+
+// The canned ACLs, a bucket or an object without an ACL is private
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/acl-overview.html#canned-acl
+const (
+	ACLPrivate         = "private"
+	ACLPublicRead      = "public-read"
+	ACLPublicReadWrite = "public-read-write"
+)
+
+// InvalidCannedACL - the ACL isn't one of the supported canned ACLs.
+type InvalidCannedACL struct {
+	ACL string
+}
+
+func (e InvalidCannedACL) Error() string {
+	return "The canned ACL " + e.ACL + " is not supported"
+}
+
+// checkCannedACL returns InvalidCannedACL if the ACL isn't a supported canned ACL
+func checkCannedACL(acl string) error {
+	switch acl {
+	case ACLPrivate, ACLPublicRead, ACLPublicReadWrite:
+		return nil
+	}
+	return InvalidCannedACL{ACL: acl}
+}
+
+// UpdateBucketACL sets the canned ACL of the bucket, InvalidCannedACL is returned if the ACL isn't supported
+func (sys *BucketMetadataSys) UpdateBucketACL(ctx context.Context, bucket, acl string) error {
+	if err := checkCannedACL(acl); err != nil {
+		return err
+	}
+	if acl == ACLPrivate {
+		acl = ""
+	}
+	lk := sys.NewNSLock(bucket)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	meta, err := sys.getBucketMeta(bucket)
+	if err != nil {
+		return err
+	}
+
+	meta.ACL = acl
+	return sys.setBucketMeta(bucket, &meta)
+}
+
+// GetBucketACL returns the canned ACL of the bucket, private if it has none
+func (sys *BucketMetadataSys) GetBucketACL(ctx context.Context, bucket string) (string, error) {
+	meta, err := sys.GetBucketMeta(ctx, bucket)
+	if err != nil {
+		return "", err
+	}
+	if meta.ACL == "" {
+		return ACLPrivate, nil
+	}
+	return meta.ACL, nil
+}
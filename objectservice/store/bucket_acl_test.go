@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestBucketMetadataSys_BucketACL(t *testing.T) {
+	db := openTestDB(t)
+	s := NewBucketMetadataSys(db)
+	ctx := context.TODO()
+	if err := s.CreateBucket(ctx, "bucket", "", "accessKey"); err != nil {
+		t.Fatal(err)
+	}
+	getACL := func() string {
+		acl, err := s.GetBucketACL(ctx, "bucket")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return acl
+	}
+	if acl := getACL(); acl != ACLPrivate {
+		t.Fatalf("a new bucket must be private, got %s", acl)
+	}
+	for _, acl := range []string{ACLPublicRead, ACLPublicReadWrite, ACLPrivate} {
+		if err := s.UpdateBucketACL(ctx, "bucket", acl); err != nil {
+			t.Fatal(err)
+		}
+		if got := getACL(); got != acl {
+			t.Fatalf("the acl %s is not %s", got, acl)
+		}
+	}
+	// the private ACL isn't stored
+	meta, err := s.GetBucketMeta(ctx, "bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.ACL != "" || meta.Owner != "accessKey" {
+		t.Fatalf("unexpected bucket meta %+v", meta)
+	}
+
+	if err = s.UpdateBucketACL(ctx, "bucket", ACLPublicRead); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.UpdateBucketACL(ctx, "bucket", "authenticated-read"); err != (InvalidCannedACL{ACL: "authenticated-read"}) {
+		t.Fatalf("the unsupported acl must be rejected, %v", err)
+	}
+	if acl := getACL(); acl != ACLPublicRead {
+		t.Fatalf("the acl %s is not kept", acl)
+	}
+	if err = s.UpdateBucketACL(ctx, "nobucket", ACLPublicRead); !errors.As(err, &BucketNotFound{}) {
+		t.Fatalf("the missing bucket must be reported, %v", err)
+	}
+	if _, err = s.GetBucketACL(ctx, "nobucket"); !errors.As(err, &BucketNotFound{}) {
+		t.Fatalf("the missing bucket must be reported, %v", err)
+	}
+}
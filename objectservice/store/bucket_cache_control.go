@@ -0,0 +1,34 @@
+package store
+
+import (
+	"context"
+)
+
+// UpdateBucketCacheControl sets the default Cache-Control of the bucket,
+// an empty value removes it.
+func (sys *BucketMetadataSys) UpdateBucketCacheControl(ctx context.Context, bucket string, cacheControl string) error {
+	lk := sys.NewNSLock(bucket)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	meta, err := sys.getBucketMeta(bucket)
+	if err != nil {
+		return err
+	}
+
+	meta.CacheControl = cacheControl
+	return sys.setBucketMeta(bucket, &meta)
+}
+
+// GetBucketCacheControl returns the default Cache-Control of the bucket
+func (sys *BucketMetadataSys) GetBucketCacheControl(ctx context.Context, bucket string) (string, error) {
+	meta, err := sys.GetBucketMeta(ctx, bucket)
+	if err != nil {
+		return "", err
+	}
+	return meta.CacheControl, nil
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"context"
+	"encoding/xml"
+	"golang.org/x/xerrors"
+	"strings"
+)
+
+const (
+	// maxCorsRules the number of the rules a CORS configuration can have
+	maxCorsRules = 100
+	// maxCorsRuleIDLength the length of the ID of a CORS rule
+	maxCorsRuleIDLength = 255
+)
+
+// BucketCorsNotFound - no bucket CORS configuration found.
+type BucketCorsNotFound struct {
+	Bucket string
+	Err    error
+}
+
+func (e BucketCorsNotFound) Error() string {
+	return "No bucket CORS configuration found for bucket: " + e.Bucket
+}
+
+// Cors - the cross-origin resource sharing configuration of a bucket
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketCors.html
+type Cors struct {
+	XMLName   xml.Name   `xml:"CORSConfiguration"`
+	CorsRules []CorsRule `xml:"CORSRule"`
+}
+
+// CorsRule - the origins and the methods allowed to access the bucket from a browser. The origins and the
+// allowed headers can contain one * wildcard, the headers in AllowedHeader are allowed in the preflight requests,
+// the headers in ExposeHeader can be read by the scripts of the browser.
+type CorsRule struct {
+	ID             string   `xml:"ID,omitempty"`
+	AllowedHeaders []string `xml:"AllowedHeader,omitempty"`
+	AllowedMethods []string `xml:"AllowedMethod"`
+	AllowedOrigins []string `xml:"AllowedOrigin"`
+	ExposeHeaders  []string `xml:"ExposeHeader,omitempty"`
+	MaxAgeSeconds  *int     `xml:"MaxAgeSeconds,omitempty"`
+}
+
+// Validate checks the configuration is one S3 accepts
+func (c *Cors) Validate() error {
+	if len(c.CorsRules) == 0 || len(c.CorsRules) > maxCorsRules {
+		return xerrors.Errorf("a CORS configuration must have 1 to %d rules", maxCorsRules)
+	}
+	for _, rule := range c.CorsRules {
+		if len(rule.ID) > maxCorsRuleIDLength {
+			return xerrors.Errorf("the ID of a CORS rule can't be longer than %d", maxCorsRuleIDLength)
+		}
+		if len(rule.AllowedMethods) == 0 || len(rule.AllowedOrigins) == 0 {
+			return xerrors.New("a CORS rule requires an AllowedMethod and an AllowedOrigin")
+		}
+		for _, method := range rule.AllowedMethods {
+			switch method {
+			case "GET", "PUT", "HEAD", "POST", "DELETE":
+			default:
+				return xerrors.Errorf("unsupported AllowedMethod %s", method)
+			}
+		}
+		for _, origin := range rule.AllowedOrigins {
+			if strings.Count(origin, "*") > 1 {
+				return xerrors.Errorf("the AllowedOrigin %s can't contain more than one wildcard", origin)
+			}
+		}
+		for _, header := range rule.AllowedHeaders {
+			if strings.Count(header, "*") > 1 {
+				return xerrors.Errorf("the AllowedHeader %s can't contain more than one wildcard", header)
+			}
+		}
+		if rule.MaxAgeSeconds != nil && *rule.MaxAgeSeconds < 0 {
+			return xerrors.New("the MaxAgeSeconds of a CORS rule can't be negative")
+		}
+	}
+	return nil
+}
+
+// MatchRule returns the first rule which allows the origin to send the method with the headers,
+// the headers are only given for the preflight requests.
+func (c *Cors) MatchRule(origin, method string, headers []string) *CorsRule {
+	for i, rule := range c.CorsRules {
+		if !matchAny(rule.AllowedOrigins, origin, false) {
+			continue
+		}
+		if !matchAny(rule.AllowedMethods, method, false) {
+			continue
+		}
+		allowed := true
+		for _, header := range headers {
+			if !matchAny(rule.AllowedHeaders, header, true) {
+				allowed = false
+				break
+			}
+		}
+		if allowed {
+			return &c.CorsRules[i]
+		}
+	}
+	return nil
+}
+
+// matchAny reports whether the value matches one of the patterns, a pattern can contain one * wildcard
+func matchAny(patterns []string, value string, ignoreCase bool) bool {
+	if ignoreCase {
+		value = strings.ToLower(value)
+	}
+	for _, pattern := range patterns {
+		if ignoreCase {
+			pattern = strings.ToLower(pattern)
+		}
+		i := strings.Index(pattern, "*")
+		if i < 0 {
+			if pattern == value {
+				return true
+			}
+			continue
+		}
+		prefix, suffix := pattern[:i], pattern[i+1:]
+		if len(value) >= len(prefix)+len(suffix) && strings.HasPrefix(value, prefix) && strings.HasSuffix(value, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateBucketCors update the CORS configuration of the bucket
+func (sys *BucketMetadataSys) UpdateBucketCors(ctx context.Context, bucket string, cors *Cors) error {
+	lk := sys.NewNSLock(bucket)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	meta, err := sys.getBucketMeta(bucket)
+	if err != nil {
+		return err
+	}
+
+	meta.CorsConfig = cors
+	return sys.setBucketMeta(bucket, &meta)
+}
+
+// DeleteBucketCors delete the CORS configuration of the bucket
+func (sys *BucketMetadataSys) DeleteBucketCors(ctx context.Context, bucket string) error {
+	return sys.UpdateBucketCors(ctx, bucket, nil)
+}
+
+// GetCorsConfig get the CORS configuration of the bucket
+func (sys *BucketMetadataSys) GetCorsConfig(ctx context.Context, bucket string) (*Cors, error) {
+	meta, err := sys.GetBucketMeta(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	if meta.CorsConfig == nil {
+		return nil, BucketCorsNotFound{Bucket: bucket}
+	}
+	return meta.CorsConfig, nil
+}
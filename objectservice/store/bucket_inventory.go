@@ -0,0 +1,514 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/filedag-project/filedag-storage/objectservice/consts"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	"golang.org/x/xerrors"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// InventoryFormatCSV the only format of the inventory reports, Parquet and ORC aren't supported
+	InventoryFormatCSV = "CSV"
+	// InventoryDaily the inventory is reported every day
+	InventoryDaily = "Daily"
+	// InventoryWeekly the inventory is reported every week
+	InventoryWeekly = "Weekly"
+	// InventoryVersionsAll every version of the objects is reported
+	InventoryVersionsAll = "All"
+	// InventoryVersionsCurrent the current objects are reported
+	InventoryVersionsCurrent = "Current"
+	// DefaultInventoryCheckInterval the default interval the due reports are checked in
+	DefaultInventoryCheckInterval = time.Hour
+
+	// maxInventoryConfigs the inventory configurations a bucket can have, like S3
+	maxInventoryConfigs = 1000
+	// maxInventoryFileRows the rows of a data file of a report, a report of more objects is split
+	maxInventoryFileRows = 100000
+	// inventoryListPage the objects listed at a time while a report is generated
+	inventoryListPage = 1000
+	// bucketARNPrefix the prefix of the ARN of a destination bucket
+	bucketARNPrefix = "arn:aws:s3:::"
+	// inventoryManifestVersion the version of the manifest format of S3
+	inventoryManifestVersion = "2016-11-30"
+)
+
+// inventoryFields the optional fields of the reports, each row starts with the bucket and the key
+var inventoryFields = map[string]func(o ObjectInfo) string{
+	"Size": func(o ObjectInfo) string {
+		if o.DeleteMarker {
+			return ""
+		}
+		return strconv.FormatInt(o.Size, 10)
+	},
+	"LastModifiedDate": func(o ObjectInfo) string {
+		return o.ModTime.UTC().Format(time.RFC3339)
+	},
+	"StorageClass": func(o ObjectInfo) string {
+		if o.DeleteMarker {
+			return ""
+		}
+		return consts.DefaultStorageClass
+	},
+	"ETag": func(o ObjectInfo) string {
+		return o.ETag
+	},
+	"IsMultipartUploaded": func(o ObjectInfo) string {
+		if o.DeleteMarker {
+			return ""
+		}
+		return strconv.FormatBool(len(o.Parts) > 0)
+	},
+	"ChecksumAlgorithm": func(o ObjectInfo) string {
+		if o.ChecksumSHA256 != "" {
+			return "SHA256"
+		}
+		return ""
+	},
+}
+
+// BucketInventoryNotFound - no bucket inventory configuration of the id found.
+type BucketInventoryNotFound struct {
+	Bucket string
+	ID     string
+}
+
+func (e BucketInventoryNotFound) Error() string {
+	return "No inventory configuration " + e.ID + " found for bucket: " + e.Bucket
+}
+
+// InvalidInventoryConfiguration - the inventory configuration can't be reported.
+type InvalidInventoryConfiguration struct {
+	Bucket string
+	ID     string
+	Err    error
+}
+
+func (e InvalidInventoryConfiguration) Error() string {
+	return fmt.Sprintf("Invalid inventory configuration %s of bucket %s: %v", e.ID, e.Bucket, e.Err)
+}
+
+// Inventory - an inventory configuration of a bucket, the objects of the bucket are listed
+// in a report written to the destination bucket on the schedule
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketInventoryConfiguration.html
+type Inventory struct {
+	XMLName                xml.Name             `xml:"InventoryConfiguration"`
+	ID                     string               `xml:"Id"`
+	IsEnabled              bool                 `xml:"IsEnabled"`
+	Destination            InventoryDestination `xml:"Destination"`
+	Filter                 *InventoryFilter     `xml:"Filter,omitempty"`
+	IncludedObjectVersions string               `xml:"IncludedObjectVersions"`
+	OptionalFields         []string             `xml:"OptionalFields>Field,omitempty"`
+	Schedule               InventorySchedule    `xml:"Schedule"`
+
+	// LastReport when the last report was generated, zero if there's none
+	LastReport time.Time `xml:"-"`
+}
+
+// InventoryDestination - where the reports are written
+type InventoryDestination struct {
+	S3BucketDestination InventoryBucketDestination `xml:"S3BucketDestination"`
+}
+
+// InventoryBucketDestination - the bucket and the key prefix of the reports
+type InventoryBucketDestination struct {
+	AccountID string `xml:"AccountId,omitempty"`
+	// Bucket the ARN of the destination bucket, arn:aws:s3:::bucket
+	Bucket string `xml:"Bucket"`
+	Format string `xml:"Format"`
+	Prefix string `xml:"Prefix,omitempty"`
+}
+
+// InventoryFilter - the prefix of the objects reported
+type InventoryFilter struct {
+	Prefix string `xml:"Prefix"`
+}
+
+// InventorySchedule - how often the reports are generated, Daily or Weekly
+type InventorySchedule struct {
+	Frequency string `xml:"Frequency"`
+}
+
+// Validate checks the configuration is one which can be reported
+func (inv *Inventory) Validate() error {
+	if inv.ID == "" {
+		return xerrors.New("Id is required")
+	}
+	if inv.Destination.S3BucketDestination.Format != InventoryFormatCSV {
+		return xerrors.Errorf("unsupported format %q, only %s is supported", inv.Destination.S3BucketDestination.Format, InventoryFormatCSV)
+	}
+	if inv.DestinationBucket() == "" {
+		return xerrors.Errorf("the destination bucket %q isn't an ARN of a bucket", inv.Destination.S3BucketDestination.Bucket)
+	}
+	if inv.IncludedObjectVersions != InventoryVersionsAll && inv.IncludedObjectVersions != InventoryVersionsCurrent {
+		return xerrors.Errorf("IncludedObjectVersions must be %s or %s", InventoryVersionsAll, InventoryVersionsCurrent)
+	}
+	if inv.Schedule.Frequency != InventoryDaily && inv.Schedule.Frequency != InventoryWeekly {
+		return xerrors.Errorf("Frequency must be %s or %s", InventoryDaily, InventoryWeekly)
+	}
+	seen := make(map[string]bool)
+	for _, field := range inv.OptionalFields {
+		if _, ok := inventoryFields[field]; !ok {
+			return xerrors.Errorf("unsupported optional field %q", field)
+		}
+		if seen[field] {
+			return xerrors.Errorf("duplicate optional field %q", field)
+		}
+		seen[field] = true
+	}
+	return nil
+}
+
+// DestinationBucket returns the name of the destination bucket, empty if the ARN is malformed
+func (inv *Inventory) DestinationBucket() string {
+	arn := inv.Destination.S3BucketDestination.Bucket
+	if !strings.HasPrefix(arn, bucketARNPrefix) || strings.Contains(arn[len(bucketARNPrefix):], "/") {
+		return ""
+	}
+	return arn[len(bucketARNPrefix):]
+}
+
+// interval returns the interval between the reports
+func (inv *Inventory) interval() time.Duration {
+	if inv.Schedule.Frequency == InventoryWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// due reports whether a report is due at now
+func (inv *Inventory) due(now time.Time) bool {
+	return inv.IsEnabled && !now.Before(inv.LastReport.Add(inv.interval()))
+}
+
+// header returns the columns of the report
+func (inv *Inventory) header() []string {
+	header := []string{"Bucket", "Key"}
+	if inv.IncludedObjectVersions == InventoryVersionsAll {
+		header = append(header, "VersionId", "IsLatest", "IsDeleteMarker")
+	}
+	return append(header, inv.OptionalFields...)
+}
+
+// row returns the columns of the object in the report, the key is URL-encoded like S3
+func (inv *Inventory) row(o ObjectInfo) []string {
+	row := []string{o.Bucket, url.QueryEscape(o.Name)}
+	if inv.IncludedObjectVersions == InventoryVersionsAll {
+		row = append(row, o.VersionID, strconv.FormatBool(o.IsLatest), strconv.FormatBool(o.DeleteMarker))
+	}
+	for _, field := range inv.OptionalFields {
+		row = append(row, inventoryFields[field](o))
+	}
+	return row
+}
+
+// reportPrefix returns the key prefix of the reports of the configuration of the bucket in the destination bucket
+func (inv *Inventory) reportPrefix(bucket string) string {
+	return path.Join(inv.Destination.S3BucketDestination.Prefix, bucket, inv.ID)
+}
+
+// PutBucketInventory adds the inventory configuration to the bucket or replaces the one of the same id,
+// the destination bucket must exist and have the same owner
+func (sys *BucketMetadataSys) PutBucketInventory(ctx context.Context, bucket string, inv *Inventory) error {
+	lk := sys.NewNSLock(bucket)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	meta, err := sys.getBucketMeta(bucket)
+	if err != nil {
+		return err
+	}
+	destination := inv.DestinationBucket()
+	if destination == bucket {
+		return InvalidInventoryConfiguration{Bucket: bucket, ID: inv.ID, Err: xerrors.New("the destination bucket is the source bucket")}
+	}
+	destMeta, err := sys.getBucketMeta(destination)
+	if err != nil {
+		return InvalidInventoryConfiguration{Bucket: bucket, ID: inv.ID, Err: err}
+	}
+	if destMeta.Owner != meta.Owner {
+		return InvalidInventoryConfiguration{Bucket: bucket, ID: inv.ID, Err: xerrors.New("the destination bucket has another owner")}
+	}
+
+	for i := range meta.InventoryConfigs {
+		if meta.InventoryConfigs[i].ID == inv.ID {
+			meta.InventoryConfigs[i] = *inv
+			return sys.setBucketMeta(bucket, &meta)
+		}
+	}
+	if len(meta.InventoryConfigs) >= maxInventoryConfigs {
+		return InvalidInventoryConfiguration{Bucket: bucket, ID: inv.ID, Err: xerrors.Errorf("a bucket has at most %d inventory configurations", maxInventoryConfigs)}
+	}
+	meta.InventoryConfigs = append(meta.InventoryConfigs, *inv)
+	return sys.setBucketMeta(bucket, &meta)
+}
+
+// GetBucketInventory returns the inventory configuration of the id of the bucket
+func (sys *BucketMetadataSys) GetBucketInventory(ctx context.Context, bucket, id string) (*Inventory, error) {
+	meta, err := sys.GetBucketMeta(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	for i := range meta.InventoryConfigs {
+		if meta.InventoryConfigs[i].ID == id {
+			return &meta.InventoryConfigs[i], nil
+		}
+	}
+	return nil, BucketInventoryNotFound{Bucket: bucket, ID: id}
+}
+
+// ListBucketInventories returns the inventory configurations of the bucket
+func (sys *BucketMetadataSys) ListBucketInventories(ctx context.Context, bucket string) ([]Inventory, error) {
+	meta, err := sys.GetBucketMeta(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	return meta.InventoryConfigs, nil
+}
+
+// DeleteBucketInventory removes the inventory configuration of the id from the bucket
+func (sys *BucketMetadataSys) DeleteBucketInventory(ctx context.Context, bucket, id string) error {
+	return sys.updateBucketInventory(ctx, bucket, id, func(meta *BucketMetadata, i int) {
+		meta.InventoryConfigs = append(meta.InventoryConfigs[:i], meta.InventoryConfigs[i+1:]...)
+	})
+}
+
+// setInventoryLastReport records when the last report of the inventory configuration was generated
+func (sys *BucketMetadataSys) setInventoryLastReport(ctx context.Context, bucket, id string, t time.Time) error {
+	return sys.updateBucketInventory(ctx, bucket, id, func(meta *BucketMetadata, i int) {
+		meta.InventoryConfigs[i].LastReport = t
+	})
+}
+
+// updateBucketInventory applies the update to the inventory configuration of the id under the bucket lock
+func (sys *BucketMetadataSys) updateBucketInventory(ctx context.Context, bucket, id string, update func(meta *BucketMetadata, i int)) error {
+	lk := sys.NewNSLock(bucket)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	meta, err := sys.getBucketMeta(bucket)
+	if err != nil {
+		return err
+	}
+	for i := range meta.InventoryConfigs {
+		if meta.InventoryConfigs[i].ID == id {
+			update(&meta, i)
+			return sys.setBucketMeta(bucket, &meta)
+		}
+	}
+	return BucketInventoryNotFound{Bucket: bucket, ID: id}
+}
+
+// InventorySys generates the inventory reports of the buckets on their schedules
+type InventorySys struct {
+	bmSys      *BucketMetadataSys
+	storageSys *StorageSys
+}
+
+// NewInventorySys returns the InventorySys reporting the objects of the storageSys
+func NewInventorySys(bmSys *BucketMetadataSys, storageSys *StorageSys) *InventorySys {
+	return &InventorySys{bmSys: bmSys, storageSys: storageSys}
+}
+
+// Run checks the due reports every interval until the ctx is done
+func (sys *InventorySys) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := sys.GenerateReports(ctx, time.Now().UTC()); err != nil {
+				log.Errorw("generate the inventory reports error", "error", err)
+			}
+		}
+	}
+}
+
+// GenerateReports generates the reports of the enabled inventory configurations which are due at now,
+// a failed report is logged and retried at the next check
+func (sys *InventorySys) GenerateReports(ctx context.Context, now time.Time) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	all, err := sys.bmSys.db.ReadAllChan(ctx, bucketPrefix, "")
+	if err != nil {
+		return err
+	}
+	var buckets []BucketMetadata
+	for entry := range all {
+		var meta BucketMetadata
+		if err = entry.UnmarshalValue(&meta); err != nil {
+			return err
+		}
+		if len(meta.InventoryConfigs) > 0 {
+			buckets = append(buckets, meta)
+		}
+	}
+	for _, meta := range buckets {
+		for i := range meta.InventoryConfigs {
+			inv := &meta.InventoryConfigs[i]
+			if !inv.due(now) {
+				continue
+			}
+			if err = sys.generateReport(ctx, meta.Name, inv, now); err != nil {
+				log.Errorw("generate the inventory report error", "bucket", meta.Name, "id", inv.ID, "error", err)
+				continue
+			}
+			err = sys.bmSys.setInventoryLastReport(ctx, meta.Name, inv.ID, now)
+			if err != nil && !xerrors.As(err, &BucketInventoryNotFound{}) && !xerrors.As(err, &BucketNotFound{}) {
+				log.Errorw("record the inventory report error", "bucket", meta.Name, "id", inv.ID, "error", err)
+			}
+		}
+	}
+	return nil
+}
+
+// inventoryManifest the manifest of a report, it lists the data files like the manifest.json of S3
+type inventoryManifest struct {
+	SourceBucket      string                  `json:"sourceBucket"`
+	DestinationBucket string                  `json:"destinationBucket"`
+	Version           string                  `json:"version"`
+	CreationTimestamp string                  `json:"creationTimestamp"`
+	FileFormat        string                  `json:"fileFormat"`
+	FileSchema        string                  `json:"fileSchema"`
+	Files             []inventoryManifestFile `json:"files"`
+}
+
+// inventoryManifestFile a data file of a report
+type inventoryManifestFile struct {
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+	MD5Checksum string `json:"MD5checksum"`
+}
+
+// generateReport writes the data files of the report and then its manifest, the report is
+// complete once the manifest <prefix>/<bucket>/<id>/<time>/manifest.json is written
+func (sys *InventorySys) generateReport(ctx context.Context, bucket string, inv *Inventory, now time.Time) error {
+	destination := inv.DestinationBucket()
+	timestamp := now.UTC().Format("2006-01-02T15-04Z")
+	prefix := inv.reportPrefix(bucket)
+	manifest := inventoryManifest{
+		SourceBucket:      bucket,
+		DestinationBucket: inv.Destination.S3BucketDestination.Bucket,
+		Version:           inventoryManifestVersion,
+		CreationTimestamp: strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10),
+		FileFormat:        InventoryFormatCSV,
+		FileSchema:        strings.Join(inv.header(), ", "),
+		Files:             make([]inventoryManifestFile, 0),
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	rows := 0
+	flush := func() error {
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+		if rows == 0 {
+			return nil
+		}
+		key := path.Join(prefix, "data", fmt.Sprintf("%s-%d.csv", timestamp, len(manifest.Files)))
+		sum := md5.Sum(buf.Bytes())
+		if err := sys.putReportObject(ctx, destination, key, buf.Bytes(), "text/csv"); err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, inventoryManifestFile{Key: key, Size: int64(buf.Len()), MD5Checksum: hex.EncodeToString(sum[:])})
+		buf.Reset()
+		rows = 0
+		return nil
+	}
+	add := func(o ObjectInfo) error {
+		if err := w.Write(inv.row(o)); err != nil {
+			return err
+		}
+		rows++
+		if rows >= maxInventoryFileRows {
+			return flush()
+		}
+		return nil
+	}
+
+	var filterPrefix string
+	if inv.Filter != nil {
+		filterPrefix = inv.Filter.Prefix
+	}
+	if inv.IncludedObjectVersions == InventoryVersionsAll {
+		keyMarker, versionIDMarker := "", ""
+		for {
+			lvi, err := sys.storageSys.ListObjectVersions(ctx, bucket, filterPrefix, keyMarker, versionIDMarker, "", inventoryListPage)
+			if err != nil {
+				return err
+			}
+			for _, o := range lvi.Objects {
+				if err = add(o); err != nil {
+					return err
+				}
+			}
+			if !lvi.IsTruncated {
+				break
+			}
+			keyMarker, versionIDMarker = lvi.NextMarker, lvi.NextVersionIDMarker
+		}
+	} else {
+		marker := ""
+		for {
+			loi, err := sys.storageSys.ListObjects(ctx, bucket, filterPrefix, marker, "", inventoryListPage)
+			if err != nil {
+				return err
+			}
+			for _, o := range loi.Objects {
+				if err = add(o); err != nil {
+					return err
+				}
+			}
+			if !loi.IsTruncated || len(loi.Objects) == 0 {
+				break
+			}
+			marker = loi.Objects[len(loi.Objects)-1].Name
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	return sys.putReportObject(ctx, destination, path.Join(prefix, timestamp, "manifest.json"), data, "application/json")
+}
+
+// putReportObject stores a file of a report in the destination bucket
+func (sys *InventorySys) putReportObject(ctx context.Context, bucket, object string, data []byte, contentType string) error {
+	size := int64(len(data))
+	reader, err := hash.NewReader(bytes.NewReader(data), size, "", "", size)
+	if err != nil {
+		return err
+	}
+	meta := map[string]string{strings.ToLower(consts.ContentType): contentType}
+	_, err = sys.storageSys.StoreObject(ctx, bucket, object, reader, size, meta)
+	return err
+}
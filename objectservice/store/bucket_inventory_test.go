@@ -0,0 +1,175 @@
+package store
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	mdtest "github.com/ipfs/go-merkledag/test"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInventorySys_GenerateReports(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.TODO()
+	s := NewStorageSys(ctx, mdtest.Mock(), db)
+	mbsys := NewBucketMetadataSys(db)
+	s.SetNewBucketNSLock(mbsys.NewNSLock)
+	s.SetHasBucket(mbsys.HasBucket)
+	for _, bucket := range []string{"source", "reports"} {
+		if err := mbsys.CreateBucket(ctx, bucket, "", "owner"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mbsys.CreateBucket(ctx, "others", "", "other"); err != nil {
+		t.Fatal(err)
+	}
+	objects := map[string]string{"a.txt": "a", "dir/b c.txt": "bb", "logs/c": "ccc"}
+	etags := make(map[string]string)
+	for object, content := range objects {
+		r, err := hash.NewReader(strings.NewReader(content), int64(len(content)), "", "", int64(len(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		oi, err := s.StoreObject(ctx, "source", object, r, int64(len(content)), map[string]string{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		etags[object] = oi.ETag
+	}
+	readObject := func(bucket, object string) []byte {
+		_, reader, err := s.GetObject(ctx, bucket, object, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader.Close()
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	listManifests := func() []string {
+		loi, err := s.ListObjects(ctx, "reports", "inventory/source/daily/", "", "", 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var manifests []string
+		for _, o := range loi.Objects {
+			if strings.HasSuffix(o.Name, "/manifest.json") {
+				manifests = append(manifests, o.Name)
+			}
+		}
+		return manifests
+	}
+
+	inv := &Inventory{
+		ID:                     "daily",
+		IsEnabled:              true,
+		Destination:            InventoryDestination{S3BucketDestination: InventoryBucketDestination{Bucket: "arn:aws:s3:::reports", Format: InventoryFormatCSV, Prefix: "inventory"}},
+		Filter:                 &InventoryFilter{Prefix: "logs/"},
+		IncludedObjectVersions: InventoryVersionsCurrent,
+		OptionalFields:         []string{"Size", "ETag"},
+		Schedule:               InventorySchedule{Frequency: InventoryDaily},
+	}
+	if err := inv.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	invalid := *inv
+	invalid.Destination.S3BucketDestination.Format = "Parquet"
+	if err := invalid.Validate(); err == nil {
+		t.Fatal("only the CSV format is supported")
+	}
+	invalid = *inv
+	invalid.OptionalFields = []string{"Size", "Size"}
+	if err := invalid.Validate(); err == nil {
+		t.Fatal("a field can't be reported twice")
+	}
+	// the reports are written to an existing bucket of the same owner
+	for _, destination := range []string{"source", "nobucket", "others"} {
+		invalid = *inv
+		invalid.Destination.S3BucketDestination.Bucket = bucketARNPrefix + destination
+		if err := mbsys.PutBucketInventory(ctx, "source", &invalid); !errors.As(err, &InvalidInventoryConfiguration{}) {
+			t.Fatalf("the destination bucket %v must be rejected, %v", destination, err)
+		}
+	}
+	if err := mbsys.PutBucketInventory(ctx, "source", inv); err != nil {
+		t.Fatal(err)
+	}
+	// the configuration is replaced by the one of the same id
+	inv.Filter = nil
+	if err := mbsys.PutBucketInventory(ctx, "source", inv); err != nil {
+		t.Fatal(err)
+	}
+	disabled := *inv
+	disabled.ID = "disabled"
+	disabled.IsEnabled = false
+	if err := mbsys.PutBucketInventory(ctx, "source", &disabled); err != nil {
+		t.Fatal(err)
+	}
+	invs, err := mbsys.ListBucketInventories(ctx, "source")
+	if err != nil || len(invs) != 2 || invs[0].Filter != nil {
+		t.Fatalf("unexpected inventory configurations %+v, %v", invs, err)
+	}
+
+	inventorySys := NewInventorySys(mbsys, s)
+	now := time.Date(2022, 6, 1, 1, 0, 0, 0, time.UTC)
+	if err = inventorySys.GenerateReports(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	manifests := listManifests()
+	if len(manifests) != 1 || manifests[0] != "inventory/source/daily/2022-06-01T01-00Z/manifest.json" {
+		t.Fatalf("unexpected manifests %v", manifests)
+	}
+	if loi, err := s.ListObjects(ctx, "reports", "inventory/source/disabled/", "", "", 1000); err != nil || len(loi.Objects) != 0 {
+		t.Fatalf("the disabled inventory isn't reported, %v", err)
+	}
+	var manifest inventoryManifest
+	if err = json.Unmarshal(readObject("reports", manifests[0]), &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.SourceBucket != "source" || manifest.FileSchema != "Bucket, Key, Size, ETag" || len(manifest.Files) != 1 {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+	rows, err := csv.NewReader(strings.NewReader(string(readObject("reports", manifest.Files[0].Key)))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{
+		{"source", "a.txt", "1", etags["a.txt"]},
+		{"source", "dir%2Fb+c.txt", "2", etags["dir/b c.txt"]},
+		{"source", "logs%2Fc", "3", etags["logs/c"]},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Fatalf("unexpected report %v, want %v", rows, expected)
+	}
+
+	// the next report is due a day later
+	if err = inventorySys.GenerateReports(ctx, now.Add(23*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if manifests = listManifests(); len(manifests) != 1 {
+		t.Fatalf("the report isn't due yet, %v", manifests)
+	}
+	if err = inventorySys.GenerateReports(ctx, now.Add(24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if manifests = listManifests(); len(manifests) != 2 {
+		t.Fatalf("the report is due, %v", manifests)
+	}
+
+	if err = mbsys.DeleteBucketInventory(ctx, "source", "daily"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = mbsys.GetBucketInventory(ctx, "source", "daily"); !errors.As(err, &BucketInventoryNotFound{}) {
+		t.Fatalf("the inventory configuration must be deleted, %v", err)
+	}
+	if err = mbsys.DeleteBucketInventory(ctx, "source", "daily"); !errors.As(err, &BucketInventoryNotFound{}) {
+		t.Fatalf("the inventory configuration is already deleted, %v", err)
+	}
+}
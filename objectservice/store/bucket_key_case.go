@@ -0,0 +1,114 @@
+package store
+
+import (
+	"context"
+	"encoding/xml"
+	"golang.org/x/xerrors"
+	"strings"
+)
+
+const (
+	// KeyCaseSensitive the keys are case-sensitive as in S3, the default
+	KeyCaseSensitive = "CaseSensitive"
+	// KeyCaseInsensitive the keys are lowercased for the storage and the lookup
+	KeyCaseInsensitive = "CaseInsensitive"
+)
+
+// KeyCase - the case sensitivity of the object keys in the bucket.
+// It isn't an S3 configuration, the keys of a bucket are case-sensitive unless it's put.
+//
+// The keys of a case-insensitive bucket are stored in lower case, File.txt and file.txt name
+// the same object. The case the client used isn't kept, the objects are listed by their
+// lowercased keys in the order of the lowercased keys, and the prefixes and markers of the
+// listings are lowercased too.
+type KeyCase struct {
+	XMLName xml.Name `xml:"KeyCaseConfiguration"`
+	Mode    string   `xml:"Mode"`
+}
+
+// Validate checks the mode is CaseSensitive or CaseInsensitive
+func (kc *KeyCase) Validate() error {
+	if kc.Mode != KeyCaseSensitive && kc.Mode != KeyCaseInsensitive {
+		return xerrors.Errorf("Mode must be %s or %s", KeyCaseSensitive, KeyCaseInsensitive)
+	}
+	return nil
+}
+
+// UpdateBucketKeyCase update the case sensitivity of the keys in the bucket, it only changes
+// while the bucket is empty, the keys stored can't be found if their case sensitivity changes.
+func (sys *BucketMetadataSys) UpdateBucketKeyCase(ctx context.Context, bucket string, kc *KeyCase) error {
+	lk := sys.NewNSLock(bucket)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	meta, err := sys.getBucketMeta(bucket)
+	if err != nil {
+		return err
+	}
+	caseInsensitive := kc.Mode == KeyCaseInsensitive
+	if meta.CaseInsensitiveKeys == caseInsensitive {
+		return nil
+	}
+	if sys.emptyBucket != nil {
+		if empty, err := sys.emptyBucket(ctx, bucket); err != nil {
+			return err
+		} else if !empty {
+			return ErrBucketNotEmpty
+		}
+	}
+
+	meta.CaseInsensitiveKeys = caseInsensitive
+	return sys.setBucketMeta(bucket, &meta)
+}
+
+// GetBucketKeyCase get the case sensitivity of the keys in the bucket
+func (sys *BucketMetadataSys) GetBucketKeyCase(ctx context.Context, bucket string) (*KeyCase, error) {
+	caseInsensitive, err := sys.IsCaseInsensitive(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	if caseInsensitive {
+		return &KeyCase{Mode: KeyCaseInsensitive}, nil
+	}
+	return &KeyCase{Mode: KeyCaseSensitive}, nil
+}
+
+// IsCaseInsensitive returns whether the keys in the bucket are case-insensitive
+func (sys *BucketMetadataSys) IsCaseInsensitive(ctx context.Context, bucket string) (bool, error) {
+	meta, err := sys.GetBucketMeta(ctx, bucket)
+	if err != nil {
+		return false, err
+	}
+	return meta.CaseInsensitiveKeys, nil
+}
+
+// SetCaseInsensitive sets the function returning whether the keys in a bucket are case-insensitive,
+// the keys are always case-sensitive if it isn't set
+func (s *StorageSys) SetCaseInsensitive(caseInsensitive func(ctx context.Context, bucket string) (bool, error)) {
+	s.caseInsensitive = caseInsensitive
+}
+
+// objectKey returns the key under which the object is stored, that's the lowercased key if the keys
+// in the bucket are case-insensitive. The prefixes and markers of the listings are normalized by it too.
+func (s *StorageSys) objectKey(ctx context.Context, bucket, object string) (string, error) {
+	if s.caseInsensitive == nil || object == "" {
+		return object, nil
+	}
+	caseInsensitive, err := s.caseInsensitive(ctx, bucket)
+	if err != nil || !caseInsensitive {
+		return object, err
+	}
+	return strings.ToLower(object), nil
+}
+
+// NormalizeLifecycle returns the lifecycle configuration of the bucket whose rule prefixes are normalized by
+// objectKey, so that they match the lowercased keys of a case-insensitive bucket
+func (s *StorageSys) NormalizeLifecycle(ctx context.Context, bucket string, lc *Lifecycle) (*Lifecycle, error) {
+	return lc.withPrefixes(func(prefix string) (string, error) {
+		return s.objectKey(ctx, bucket, prefix)
+	})
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	mdtest "github.com/ipfs/go-merkledag/test"
+	"strings"
+	"testing"
+)
+
+func TestStorageSys_KeyCase(t *testing.T) {
+	db := openTestDB(t)
+	s := NewStorageSys(context.TODO(), mdtest.Mock(), db)
+	mbsys := NewBucketMetadataSys(db)
+	mbsys.SetEmptyBucket(s.EmptyBucket)
+	s.SetNewBucketNSLock(mbsys.NewNSLock)
+	s.SetHasBucket(mbsys.HasBucket)
+	s.SetCaseInsensitive(mbsys.IsCaseInsensitive)
+	ctx := context.TODO()
+	storeObject := func(bucket, object, content string) {
+		r, err := hash.NewReader(strings.NewReader(content), int64(len(content)), "", "", int64(len(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = s.StoreObject(ctx, bucket, object, r, int64(len(content)), map[string]string{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	listKeys := func(bucket, prefix string) []string {
+		loi, err := s.ListObjects(ctx, bucket, prefix, "", "", 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var keys []string
+		for _, oi := range loi.Objects {
+			keys = append(keys, oi.Name)
+		}
+		return keys
+	}
+
+	// the keys differing in case are distinct objects by default
+	if err := mbsys.CreateBucket(ctx, "sensitive", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	storeObject("sensitive", "File.txt", "upper")
+	storeObject("sensitive", "file.txt", "lower")
+	if keys := listKeys("sensitive", ""); len(keys) != 2 || keys[0] != "File.txt" || keys[1] != "file.txt" {
+		t.Fatalf("expected the two objects, got %v", keys)
+	}
+	if err := mbsys.UpdateBucketKeyCase(ctx, "sensitive", &KeyCase{Mode: KeyCaseInsensitive}); err != ErrBucketNotEmpty {
+		t.Fatalf("the keys of a bucket holding objects must not change their case sensitivity, %v", err)
+	}
+
+	if err := mbsys.CreateBucket(ctx, "insensitive", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := mbsys.UpdateBucketKeyCase(ctx, "insensitive", &KeyCase{Mode: KeyCaseInsensitive}); err != nil {
+		t.Fatal(err)
+	}
+	storeObject("insensitive", "File.txt", "upper")
+	storeObject("insensitive", "file.txt", "lower")
+	if keys := listKeys("insensitive", "FILE"); len(keys) != 1 || keys[0] != "file.txt" {
+		t.Fatalf("expected one object listed by its lowercased key, got %v", keys)
+	}
+	oi, err := s.GetObjectInfo(ctx, "insensitive", "FILE.TXT", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oi.Name != "file.txt" || oi.Size != int64(len("lower")) {
+		t.Fatalf("expected the object of the last put, got %v of %d bytes", oi.Name, oi.Size)
+	}
+	if _, err = s.CopyObject(ctx, "insensitive", "FiLe.TxT", "", "insensitive", "Copy.txt", map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.GetObjectInfo(ctx, "insensitive", "copy.txt", ""); err != nil {
+		t.Fatalf("the copy must be found by the lowercased key, %v", err)
+	}
+	if _, err = s.DeleteObject(ctx, "insensitive", "File.TXT", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.GetObjectInfo(ctx, "insensitive", "file.txt", ""); err != ErrObjectNotFound {
+		t.Fatalf("the object must be deleted, %v", err)
+	}
+}
@@ -0,0 +1,285 @@
+package store
+
+import (
+	"context"
+	"encoding/xml"
+	"golang.org/x/xerrors"
+	"strings"
+	"time"
+)
+
+const (
+	// LifecycleEnabled - the status of an enabled lifecycle rule
+	LifecycleEnabled = "Enabled"
+	// LifecycleDisabled - the status of a disabled lifecycle rule
+	LifecycleDisabled = "Disabled"
+
+	// maxLifecycleRules the rules a lifecycle configuration can have, like S3
+	maxLifecycleRules = 1000
+	// maxLifecycleRuleIDLength the longest ID of a rule
+	maxLifecycleRuleIDLength = 255
+)
+
+// BucketLifecycleNotFound - no bucket lifecycle found.
+type BucketLifecycleNotFound struct {
+	Bucket string
+	Err    error
+}
+
+func (e BucketLifecycleNotFound) Error() string {
+	return "No bucket lifecycle configuration found for bucket: " + e.Bucket
+}
+
+// Lifecycle - the lifecycle configuration of a bucket
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketLifecycleConfiguration.html
+type Lifecycle struct {
+	XMLName xml.Name        `xml:"LifecycleConfiguration"`
+	Rules   []LifecycleRule `xml:"Rule"`
+}
+
+// LifecycleRule - a lifecycle rule
+type LifecycleRule struct {
+	ID     string `xml:"ID,omitempty"`
+	Status string `xml:"Status"`
+	// Prefix is the deprecated rule prefix, Filter.Prefix is used instead when set
+	Prefix     string               `xml:"Prefix,omitempty"`
+	Filter     *LifecycleFilter     `xml:"Filter,omitempty"`
+	Expiration *LifecycleExpiration `xml:"Expiration,omitempty"`
+}
+
+// LifecycleFilter - the objects a lifecycle rule applies to, the ones of the key prefix, the ones
+// with the tag or the ones matching all the conditions of And. It has one of them at most.
+type LifecycleFilter struct {
+	Prefix string              `xml:"Prefix,omitempty"`
+	Tag    *Tag                `xml:"Tag,omitempty"`
+	And    *LifecycleFilterAnd `xml:"And,omitempty"`
+}
+
+// LifecycleFilterAnd - the key prefix and the tags an object must all have
+type LifecycleFilterAnd struct {
+	Prefix string `xml:"Prefix,omitempty"`
+	Tags   []Tag  `xml:"Tag,omitempty"`
+}
+
+// LifecycleExpiration - when the objects expire, either Days after they are
+// created or at Date
+type LifecycleExpiration struct {
+	Days int        `xml:"Days,omitempty"`
+	Date *time.Time `xml:"Date,omitempty"`
+}
+
+// Validate checks the configuration only has expiration rules which can be applied
+func (lc *Lifecycle) Validate() error {
+	if len(lc.Rules) == 0 || len(lc.Rules) > maxLifecycleRules {
+		return xerrors.Errorf("a lifecycle configuration has 1 to %d rules", maxLifecycleRules)
+	}
+	ids := make(map[string]bool)
+	for _, rule := range lc.Rules {
+		if len(rule.ID) > maxLifecycleRuleIDLength {
+			return xerrors.Errorf("the ID %q is longer than %d characters", rule.ID, maxLifecycleRuleIDLength)
+		}
+		if rule.ID != "" {
+			if ids[rule.ID] {
+				return xerrors.Errorf("duplicate rule ID %q", rule.ID)
+			}
+			ids[rule.ID] = true
+		}
+		if err := rule.validate(); err != nil {
+			return xerrors.Errorf("rule %q: %w", rule.ID, err)
+		}
+	}
+	return nil
+}
+
+func (r LifecycleRule) validate() error {
+	if r.Status != LifecycleEnabled && r.Status != LifecycleDisabled {
+		return xerrors.Errorf("Status must be %s or %s", LifecycleEnabled, LifecycleDisabled)
+	}
+	if r.Expiration == nil {
+		return xerrors.New("Expiration is required, the other actions aren't supported")
+	}
+	switch {
+	case r.Expiration.Date != nil && r.Expiration.Days != 0:
+		return xerrors.New("Expiration has either Days or Date")
+	case r.Expiration.Date != nil:
+		if !r.Expiration.Date.Equal(r.Expiration.Date.UTC().Truncate(24 * time.Hour)) {
+			return xerrors.New("the Date of Expiration must be at midnight UTC")
+		}
+	case r.Expiration.Days <= 0:
+		return xerrors.New("the Days of Expiration must be a positive integer")
+	}
+	if r.Filter == nil {
+		return nil
+	}
+	if r.Prefix != "" {
+		return xerrors.New("Prefix and Filter can't be both set")
+	}
+	conditions := 0
+	if r.Filter.Prefix != "" {
+		conditions++
+	}
+	if r.Filter.Tag != nil {
+		conditions++
+		if r.Filter.Tag.Key == "" {
+			return xerrors.New("the Key of a Tag is required")
+		}
+	}
+	if r.Filter.And != nil {
+		conditions++
+		for _, tag := range r.Filter.And.Tags {
+			if tag.Key == "" {
+				return xerrors.New("the Key of a Tag is required")
+			}
+		}
+	}
+	if conditions > 1 {
+		return xerrors.New("Filter has one of Prefix, Tag and And at most")
+	}
+	return nil
+}
+
+// prefix returns the key prefix the rule applies to
+func (r LifecycleRule) prefix() string {
+	if r.Filter != nil {
+		if r.Filter.And != nil {
+			return r.Filter.And.Prefix
+		}
+		return r.Filter.Prefix
+	}
+	return r.Prefix
+}
+
+// withPrefixes returns a copy of the configuration whose rule prefixes are mapped by key
+func (lc *Lifecycle) withPrefixes(key func(prefix string) (string, error)) (*Lifecycle, error) {
+	out := &Lifecycle{XMLName: lc.XMLName, Rules: make([]LifecycleRule, len(lc.Rules))}
+	for i, rule := range lc.Rules {
+		var err error
+		if rule.Prefix, err = key(rule.Prefix); err != nil {
+			return nil, err
+		}
+		if rule.Filter != nil {
+			filter := *rule.Filter
+			if filter.Prefix, err = key(filter.Prefix); err != nil {
+				return nil, err
+			}
+			if filter.And != nil {
+				and := *filter.And
+				if and.Prefix, err = key(and.Prefix); err != nil {
+					return nil, err
+				}
+				filter.And = &and
+			}
+			rule.Filter = &filter
+		}
+		out.Rules[i] = rule
+	}
+	return out, nil
+}
+
+// matches reports whether the rule applies to the object of the tags
+func (r LifecycleRule) matches(object string, tags map[string]string) bool {
+	if !strings.HasPrefix(object, r.prefix()) {
+		return false
+	}
+	if r.Filter == nil {
+		return true
+	}
+	var want []Tag
+	if r.Filter.Tag != nil {
+		want = append(want, *r.Filter.Tag)
+	}
+	if r.Filter.And != nil {
+		want = append(want, r.Filter.And.Tags...)
+	}
+	for _, tag := range want {
+		if v, ok := tags[tag.Key]; !ok || v != tag.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// ExpectedExpiryTime calculates the expiry of an object created at modTime
+// which expires after days. Like S3 the result is rounded to the next midnight UTC.
+func ExpectedExpiryTime(modTime time.Time, days int) time.Time {
+	if days == 0 {
+		return modTime
+	}
+	t := modTime.UTC().Add(time.Duration(days+1) * 24 * time.Hour)
+	return t.Truncate(24 * time.Hour)
+}
+
+// PredictExpiry returns when the object of the tags expires and the id of the rule which expires it,
+// the earliest one wins if several rules apply. A zero time means the object never expires.
+func (lc *Lifecycle) PredictExpiry(object string, modTime time.Time, tags map[string]string) (expiry time.Time, ruleID string) {
+	if lc == nil {
+		return
+	}
+	for _, rule := range lc.Rules {
+		if rule.Status != LifecycleEnabled || rule.Expiration == nil {
+			continue
+		}
+		if !rule.matches(object, tags) {
+			continue
+		}
+		var t time.Time
+		switch {
+		case rule.Expiration.Date != nil:
+			t = rule.Expiration.Date.UTC()
+		case rule.Expiration.Days > 0:
+			t = ExpectedExpiryTime(modTime, rule.Expiration.Days)
+		default:
+			continue
+		}
+		if expiry.IsZero() || t.Before(expiry) {
+			expiry, ruleID = t, rule.ID
+		}
+	}
+	return
+}
+
+// expired reports whether the object has expired at now
+func (lc *Lifecycle) expired(oi ObjectInfo, now time.Time) bool {
+	expiry, _ := lc.PredictExpiry(oi.Name, oi.ModTime, oi.Tags)
+	return !expiry.IsZero() && !now.Before(expiry)
+}
+
+// UpdateBucketLifecycle update the lifecycle configuration of the bucket
+func (sys *BucketMetadataSys) UpdateBucketLifecycle(ctx context.Context, bucket string, lc *Lifecycle) error {
+	lk := sys.NewNSLock(bucket)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	meta, err := sys.getBucketMeta(bucket)
+	if err != nil {
+		return err
+	}
+
+	meta.LifecycleConfig = lc
+	return sys.setBucketMeta(bucket, &meta)
+}
+
+// DeleteBucketLifecycle delete the lifecycle configuration of the bucket
+func (sys *BucketMetadataSys) DeleteBucketLifecycle(ctx context.Context, bucket string) error {
+	return sys.UpdateBucketLifecycle(ctx, bucket, nil)
+}
+
+// GetLifecycleConfig get the lifecycle configuration of the bucket
+func (sys *BucketMetadataSys) GetLifecycleConfig(ctx context.Context, bucket string) (*Lifecycle, error) {
+	meta, err := sys.GetBucketMeta(ctx, bucket)
+	if err != nil {
+		switch err.(type) {
+		case BucketNotFound:
+			return nil, BucketLifecycleNotFound{Bucket: bucket}
+		}
+		return nil, err
+	}
+	if meta.LifecycleConfig == nil {
+		return nil, BucketLifecycleNotFound{Bucket: bucket}
+	}
+	return meta.LifecycleConfig, nil
+}
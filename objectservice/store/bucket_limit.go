@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"github.com/filedag-project/filedag-storage/objectservice/lock"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
+	"golang.org/x/xerrors"
+)
+
+const (
+	bucketLimitPrefix = "bucket-limit/"
+
+	// userNSLockVolume the volume of the namespace locks of the users, a bucket name can't have an underscore
+	// so that it doesn't collide with the locks of the buckets
+	userNSLockVolume = "_users"
+
+	// DefaultMaxBuckets the number of buckets a user can create unless the limit of the user is set,
+	// it's the default quota of S3
+	DefaultMaxBuckets = 100
+
+	// UnlimitedBuckets the bucket limit which lets the user create any number of buckets
+	UnlimitedBuckets = -1
+)
+
+// TooManyBuckets - the user has as many buckets as the limit of the user.
+type TooManyBuckets struct {
+	AccessKey string
+	Limit     int
+}
+
+func (e TooManyBuckets) Error() string {
+	return fmt.Sprintf("The user %s can not create more than %d buckets", e.AccessKey, e.Limit)
+}
+
+// InvalidBucketLimit - the bucket limit of the user is negative and isn't UnlimitedBuckets.
+type InvalidBucketLimit struct {
+	AccessKey string
+	Limit     int
+}
+
+func (e InvalidBucketLimit) Error() string {
+	return fmt.Sprintf("The bucket limit %d of user %s is invalid", e.Limit, e.AccessKey)
+}
+
+func isValidBucketLimit(limit int) bool {
+	return limit >= 0 || limit == UnlimitedBuckets
+}
+
+// SetDefaultBucketLimit sets the number of buckets a user can create unless the limit of the user is set,
+// UnlimitedBuckets lets the users create any number of buckets.
+func (sys *BucketMetadataSys) SetDefaultBucketLimit(limit int) error {
+	if !isValidBucketLimit(limit) {
+		return InvalidBucketLimit{Limit: limit}
+	}
+	sys.defaultBucketLimit = limit
+	return nil
+}
+
+// newUserNSLock the namespace lock of the buckets of the user, it's held across counting and creating a bucket
+func (sys *BucketMetadataSys) newUserNSLock(accessKey string) lock.RWLocker {
+	return sys.nsLock.NewNSLock(userNSLockVolume, accessKey)
+}
+
+// UpdateUserBucketLimit sets the number of buckets the user can create, UnlimitedBuckets lets the user create
+// any number of buckets. The buckets the user already has are kept if they are more than the limit.
+func (sys *BucketMetadataSys) UpdateUserBucketLimit(ctx context.Context, accessKey string, limit int) error {
+	if !isValidBucketLimit(limit) {
+		return InvalidBucketLimit{AccessKey: accessKey, Limit: limit}
+	}
+	lk := sys.newUserNSLock(accessKey)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	defer lk.Unlock(lkctx.Cancel)
+
+	return sys.db.Put(bucketLimitPrefix+accessKey, limit)
+}
+
+// ResetUserBucketLimit removes the bucket limit of the user, the user gets the default limit.
+func (sys *BucketMetadataSys) ResetUserBucketLimit(ctx context.Context, accessKey string) error {
+	lk := sys.newUserNSLock(accessKey)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	defer lk.Unlock(lkctx.Cancel)
+
+	return sys.db.Delete(bucketLimitPrefix + accessKey)
+}
+
+// GetUserBucketLimit returns the number of buckets the user can create, UnlimitedBuckets if it's unlimited
+func (sys *BucketMetadataSys) GetUserBucketLimit(ctx context.Context, accessKey string) (int, error) {
+	lk := sys.newUserNSLock(accessKey)
+	lkctx, err := lk.GetRLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return 0, err
+	}
+	defer lk.RUnlock(lkctx.Cancel)
+
+	return sys.getUserBucketLimit(accessKey)
+}
+
+func (sys *BucketMetadataSys) getUserBucketLimit(accessKey string) (int, error) {
+	var limit int
+	err := sys.db.Get(bucketLimitPrefix+accessKey, &limit)
+	if xerrors.Is(err, metadb.ErrNotFound) {
+		return sys.defaultBucketLimit, nil
+	}
+	return limit, err
+}
+
+// checkUserBucketLimit returns TooManyBuckets if the user can't create one more bucket,
+// the caller holds the namespace lock of the user.
+func (sys *BucketMetadataSys) checkUserBucketLimit(ctx context.Context, accessKey string) error {
+	limit, err := sys.getUserBucketLimit(accessKey)
+	if err != nil || limit == UnlimitedBuckets {
+		return err
+	}
+	buckets, err := sys.GetAllBucketsOfUser(ctx, accessKey)
+	if err != nil {
+		return err
+	}
+	if len(buckets) >= limit {
+		return TooManyBuckets{AccessKey: accessKey, Limit: limit}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBucketMetadataSys_BucketLimit(t *testing.T) {
+	db := openTestDB(t)
+	s := NewBucketMetadataSys(db)
+	ctx := context.TODO()
+	if limit, err := s.GetUserBucketLimit(ctx, "user"); err != nil || limit != DefaultMaxBuckets {
+		t.Fatalf("the default limit must be %d, got %d, %v", DefaultMaxBuckets, limit, err)
+	}
+	if err := s.UpdateUserBucketLimit(ctx, "user", -2); err != (InvalidBucketLimit{AccessKey: "user", Limit: -2}) {
+		t.Fatalf("a negative limit must be rejected, %v", err)
+	}
+	if err := s.UpdateUserBucketLimit(ctx, "user", 2); err != nil {
+		t.Fatal(err)
+	}
+	// the buckets of the other users don't count
+	if err := s.CreateBucket(ctx, "other", "", "other"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := s.CreateBucket(ctx, fmt.Sprintf("bucket%d", i), "", "user"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.CreateBucket(ctx, "bucket2", "", "user"); err != (TooManyBuckets{AccessKey: "user", Limit: 2}) {
+		t.Fatalf("the bucket beyond the limit must be rejected, %v", err)
+	}
+	if s.HasBucket(ctx, "bucket2") {
+		t.Fatal("the rejected bucket must not be created")
+	}
+
+	// the concurrent creations don't exceed the raised limit
+	if err := s.UpdateUserBucketLimit(ctx, "user", 4); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = s.CreateBucket(ctx, fmt.Sprintf("concurrent%d", i), "", "user")
+		}(i)
+	}
+	wg.Wait()
+	created := 0
+	for _, err := range errs {
+		if err == nil {
+			created++
+		} else if _, ok := err.(TooManyBuckets); !ok {
+			t.Fatal(err)
+		}
+	}
+	if created != 2 {
+		t.Fatalf("%d buckets are created up to the limit 4", created)
+	}
+
+	// the user limit doesn't collide with a bucket named after the lock of the users
+	if err := s.CreateBucket(ctx, "user", "", "other"); err != nil {
+		t.Fatal(err)
+	}
+	bktlk := s.NewNSLock("user")
+	bktlkCtx, err := bktlk.GetLock(ctx, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.UpdateUserBucketLimit(ctx, "user", 2); err != nil {
+		t.Fatal(err)
+	}
+	bktlk.Unlock(bktlkCtx.Cancel)
+
+	// the limit 0 lets the user create no bucket
+	if err = s.UpdateUserBucketLimit(ctx, "user", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.CreateBucket(ctx, "bucket2", "", "user"); err != (TooManyBuckets{AccessKey: "user", Limit: 0}) {
+		t.Fatalf("no bucket must be created with the limit 0, %v", err)
+	}
+	// the reset gets the default limit back
+	if err = s.SetDefaultBucketLimit(UnlimitedBuckets); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.ResetUserBucketLimit(ctx, "user"); err != nil {
+		t.Fatal(err)
+	}
+	if limit, err := s.GetUserBucketLimit(ctx, "user"); err != nil || limit != UnlimitedBuckets {
+		t.Fatalf("the limit must be reset to the unlimited default, got %d, %v", limit, err)
+	}
+	if err = s.CreateBucket(ctx, "bucket2", "", "user"); err != nil {
+		t.Fatal(err)
+	}
+	// the user can be unlimited whatever the default
+	if err = s.SetDefaultBucketLimit(1); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.UpdateUserBucketLimit(ctx, "user", UnlimitedBuckets); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.CreateBucket(ctx, "bucket3", "", "user"); err != nil {
+		t.Fatal(err)
+	}
+}
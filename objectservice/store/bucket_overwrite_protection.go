@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"golang.org/x/xerrors"
+	"time"
+)
+
+// maxOverwriteProtectionInterval the longest window, the protection is a safety valve
+// against the clients overwriting a key in a loop, not a retention
+const maxOverwriteProtectionInterval = 24 * time.Hour
+
+// BucketOverwriteProtectionNotFound - no bucket overwrite protection configuration found.
+type BucketOverwriteProtectionNotFound struct {
+	Bucket string
+	Err    error
+}
+
+func (e BucketOverwriteProtectionNotFound) Error() string {
+	return "No bucket overwrite protection configuration found for bucket: " + e.Bucket
+}
+
+// ObjectOverwriteTooSoon - the object is overwritten within the overwrite protection window of the bucket.
+type ObjectOverwriteTooSoon struct {
+	Bucket     string
+	Object     string
+	RetryAfter time.Duration
+}
+
+func (e ObjectOverwriteTooSoon) Error() string {
+	return fmt.Sprintf("The object %s/%s can't be overwritten for another %v", e.Bucket, e.Object, e.RetryAfter)
+}
+
+// OverwriteProtection - the minimum interval between the overwrites of a key in the bucket.
+// It isn't an S3 configuration, a bucket has none unless it's put.
+type OverwriteProtection struct {
+	XMLName                xml.Name `xml:"OverwriteProtectionConfiguration"`
+	MinimumIntervalSeconds int      `xml:"MinimumIntervalSeconds"`
+}
+
+// Validate checks the interval is within (0, 24h]
+func (op *OverwriteProtection) Validate() error {
+	if op.MinimumIntervalSeconds <= 0 {
+		return xerrors.New("MinimumIntervalSeconds must be positive")
+	}
+	if op.Interval() > maxOverwriteProtectionInterval {
+		return xerrors.Errorf("MinimumIntervalSeconds must be at most %d", int(maxOverwriteProtectionInterval.Seconds()))
+	}
+	return nil
+}
+
+// Interval returns the minimum interval between the overwrites
+func (op *OverwriteProtection) Interval() time.Duration {
+	return time.Duration(op.MinimumIntervalSeconds) * time.Second
+}
+
+// UpdateBucketOverwriteProtection update the overwrite protection configuration of the bucket
+func (sys *BucketMetadataSys) UpdateBucketOverwriteProtection(ctx context.Context, bucket string, op *OverwriteProtection) error {
+	lk := sys.NewNSLock(bucket)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	meta, err := sys.getBucketMeta(bucket)
+	if err != nil {
+		return err
+	}
+
+	meta.OverwriteProtectionConfig = op
+	return sys.setBucketMeta(bucket, &meta)
+}
+
+// DeleteBucketOverwriteProtection delete the overwrite protection configuration of the bucket
+func (sys *BucketMetadataSys) DeleteBucketOverwriteProtection(ctx context.Context, bucket string) error {
+	return sys.UpdateBucketOverwriteProtection(ctx, bucket, nil)
+}
+
+// GetOverwriteProtectionConfig get the overwrite protection configuration of the bucket
+func (sys *BucketMetadataSys) GetOverwriteProtectionConfig(ctx context.Context, bucket string) (*OverwriteProtection, error) {
+	meta, err := sys.GetBucketMeta(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	if meta.OverwriteProtectionConfig == nil {
+		return nil, BucketOverwriteProtectionNotFound{Bucket: bucket}
+	}
+	return meta.OverwriteProtectionConfig, nil
+}
+
+// GetOverwriteInterval returns the minimum interval between the overwrites of a key
+// in the bucket, 0 if the bucket has no overwrite protection
+func (sys *BucketMetadataSys) GetOverwriteInterval(ctx context.Context, bucket string) (time.Duration, error) {
+	meta, err := sys.GetBucketMeta(ctx, bucket)
+	if err != nil {
+		return 0, err
+	}
+	if meta.OverwriteProtectionConfig == nil {
+		return 0, nil
+	}
+	return meta.OverwriteProtectionConfig.Interval(), nil
+}
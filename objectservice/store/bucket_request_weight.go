@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	// DefaultRequestWeight the weight of the buckets which have none of their own
+	DefaultRequestWeight = 1
+	// MaxRequestWeight the largest weight, a bucket of it gets up to 100 times the share of a default bucket
+	MaxRequestWeight = 100
+)
+
+// InvalidRequestWeight - the request weight of the bucket is out of range.
+type InvalidRequestWeight struct {
+	Bucket string
+	Weight int
+}
+
+func (e InvalidRequestWeight) Error() string {
+	return fmt.Sprintf("The request weight %d of bucket %s is not between 1 and %d", e.Weight, e.Bucket, MaxRequestWeight)
+}
+
+// UpdateBucketRequestWeight sets the weight of the bucket in the sharing of the concurrent requests
+// of the gateway, 0 resets it to the default weight.
+func (sys *BucketMetadataSys) UpdateBucketRequestWeight(ctx context.Context, bucket string, weight int) error {
+	if weight < 0 || weight > MaxRequestWeight {
+		return InvalidRequestWeight{Bucket: bucket, Weight: weight}
+	}
+	lk := sys.NewNSLock(bucket)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	meta, err := sys.getBucketMeta(bucket)
+	if err != nil {
+		return err
+	}
+
+	meta.RequestWeight = weight
+	return sys.setBucketMeta(bucket, &meta)
+}
+
+// GetBucketRequestWeight returns the weight of the bucket in the sharing of the concurrent requests
+func (sys *BucketMetadataSys) GetBucketRequestWeight(ctx context.Context, bucket string) (int, error) {
+	meta, err := sys.GetBucketMeta(ctx, bucket)
+	if err != nil {
+		return 0, err
+	}
+	if meta.RequestWeight == 0 {
+		return DefaultRequestWeight, nil
+	}
+	return meta.RequestWeight, nil
+}
@@ -2,8 +2,93 @@ package store
 
 import (
 	"context"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"unicode/utf8"
 )
 
+// The limits of the tags as per
+// https://docs.aws.amazon.com/AmazonS3/latest/userguide/CostAllocTagging.html
+const (
+	maxTagKeyLength   = 128
+	maxTagValueLength = 256
+	maxBucketTagCount = 50
+	maxObjectTagCount = 10
+)
+
+// InvalidTag - the tag set breaks the limits of the tags.
+type InvalidTag struct {
+	Reason string
+}
+
+func (e InvalidTag) Error() string {
+	return "The tag provided was not a valid tag: " + e.Reason
+}
+
+// Tag is a key value pair of the tag set
+type Tag struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+// MarshalXML encodes the tags as the Tag elements of the TagSet, sorted by the key
+func (tags TagSet) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(tags.TagMap))
+	for key := range tags.TagMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := e.EncodeElement(Tag{Key: key, Value: tags.TagMap[key]}, xml.StartElement{Name: xml.Name{Local: "Tag"}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// UnmarshalXML decodes the Tag elements of the TagSet, InvalidTag is returned if the tags break the limits
+func (tags *TagSet) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var tagSet struct {
+		Tags []Tag `xml:"Tag"`
+	}
+	if err := d.DecodeElement(&tagSet, &start); err != nil {
+		return err
+	}
+	tags.TagMap = make(map[string]string, len(tagSet.Tags))
+	for _, tag := range tagSet.Tags {
+		if _, ok := tags.TagMap[tag.Key]; ok {
+			return InvalidTag{Reason: fmt.Sprintf("the key %q is duplicated", tag.Key)}
+		}
+		tags.TagMap[tag.Key] = tag.Value
+	}
+	maxTags := maxBucketTagCount
+	if tags.IsObject {
+		maxTags = maxObjectTagCount
+	}
+	return checkTags(tags.TagMap, maxTags)
+}
+
+// checkTags returns InvalidTag if the tags are more than maxTags or any key or value is too long
+func checkTags(tagMap map[string]string, maxTags int) error {
+	if len(tagMap) > maxTags {
+		return InvalidTag{Reason: fmt.Sprintf("the tags are more than %d", maxTags)}
+	}
+	for key, value := range tagMap {
+		if !utf8.ValidString(key) || key == "" || utf8.RuneCountInString(key) > maxTagKeyLength {
+			return InvalidTag{Reason: fmt.Sprintf("the key %q isn't 1 to %d characters", key, maxTagKeyLength)}
+		}
+		if !utf8.ValidString(value) || utf8.RuneCountInString(value) > maxTagValueLength {
+			return InvalidTag{Reason: fmt.Sprintf("the value of the key %q is longer than %d characters", key, maxTagValueLength)}
+		}
+	}
+	return nil
+}
+
+// UpdateBucketTagging update the tags of the bucket
 func (sys *BucketMetadataSys) UpdateBucketTagging(ctx context.Context, bucket string, tags *Tags) error {
 	lk := sys.NewNSLock(bucket)
 	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
@@ -22,17 +107,15 @@ func (sys *BucketMetadataSys) UpdateBucketTagging(ctx context.Context, bucket st
 	return sys.setBucketMeta(bucket, &meta)
 }
 
+// DeleteBucketTagging delete the tags of the bucket
 func (sys *BucketMetadataSys) DeleteBucketTagging(ctx context.Context, bucket string) error {
-	return sys.UpdateBucketPolicy(ctx, bucket, nil)
+	return sys.UpdateBucketTagging(ctx, bucket, nil)
 }
 
+// GetTaggingConfig get the tags of the bucket, BucketTaggingNotFound is returned if it has none
 func (sys *BucketMetadataSys) GetTaggingConfig(ctx context.Context, bucket string) (*Tags, error) {
 	meta, err := sys.GetBucketMeta(ctx, bucket)
 	if err != nil {
-		switch err.(type) {
-		case BucketNotFound:
-			return nil, BucketTaggingNotFound{Bucket: bucket}
-		}
 		return nil, err
 	}
 	if meta.TaggingConfig == nil {
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"github.com/filedag-project/filedag-storage/objectservice/iam/policy"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBucketMetadataSys_BucketTagging(t *testing.T) {
+	db := openTestDB(t)
+	s := NewBucketMetadataSys(db)
+	ctx := context.TODO()
+	if err := s.CreateBucket(ctx, "bucket", "", "accessKey"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetTaggingConfig(ctx, "bucket"); err != (BucketTaggingNotFound{Bucket: "bucket"}) {
+		t.Fatalf("a bucket without tags must have no tag set, %v", err)
+	}
+	if _, err := s.GetTaggingConfig(ctx, "nobucket"); !errors.As(err, &BucketNotFound{}) {
+		t.Fatalf("the missing bucket must be reported, %v", err)
+	}
+
+	body := `<Tagging><TagSet><Tag><Key>project</Key><Value>filedag</Value></Tag><Tag><Key>env</Key><Value></Value></Tag></TagSet></Tagging>`
+	tags := &Tags{TagSet: &TagSet{}}
+	if err := xml.Unmarshal([]byte(body), tags); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateBucketTagging(ctx, "bucket", tags); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.GetTaggingConfig(ctx, "bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"project": "filedag", "env": ""}; !reflect.DeepEqual(got.TagSet.TagMap, want) {
+		t.Fatalf("the tags %v are not %v", got.TagSet.TagMap, want)
+	}
+	// the tags are encoded in the order of the keys
+	data, err := xml.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<Tagging><TagSet><Tag><Key>env</Key><Value></Value></Tag><Tag><Key>project</Key><Value>filedag</Value></Tag></TagSet></Tagging>`; string(data) != want {
+		t.Fatalf("the tags are encoded as %s", data)
+	}
+
+	// deleting the tags leaves the policy of the bucket
+	p, err := s.GetPolicyConfig(ctx, "bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.DeleteBucketTagging(ctx, "bucket"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.GetTaggingConfig(ctx, "bucket"); err != (BucketTaggingNotFound{Bucket: "bucket"}) {
+		t.Fatalf("the tags must be deleted, %v", err)
+	}
+	var kept *policy.Policy
+	if kept, err = s.GetPolicyConfig(ctx, "bucket"); err != nil {
+		t.Fatalf("the policy must be kept, %v", err)
+	}
+	if !reflect.DeepEqual(p, kept) {
+		t.Fatalf("the policy %v changed to %v", p, kept)
+	}
+}
+
+func TestTagSet_UnmarshalXML(t *testing.T) {
+	tagSet := func(n int, key, value string) string {
+		var sb strings.Builder
+		sb.WriteString("<Tagging><TagSet>")
+		for i := 0; i < n; i++ {
+			sb.WriteString("<Tag><Key>" + key + strings.Repeat("k", i) + "</Key><Value>" + value + "</Value></Tag>")
+		}
+		sb.WriteString("</TagSet></Tagging>")
+		return sb.String()
+	}
+	testCases := []struct {
+		name     string
+		body     string
+		isObject bool
+		invalid  bool
+	}{
+		{name: "empty", body: "<Tagging><TagSet></TagSet></Tagging>"},
+		{name: "50 bucket tags", body: tagSet(50, "k", "v")},
+		{name: "51 bucket tags", body: tagSet(51, "k", "v"), invalid: true},
+		{name: "10 object tags", body: tagSet(10, "k", "v"), isObject: true},
+		{name: "11 object tags", body: tagSet(11, "k", "v"), isObject: true, invalid: true},
+		{name: "empty key", body: tagSet(1, "", "v"), invalid: true},
+		{name: "128 characters key", body: tagSet(1, strings.Repeat("世", 128), "v")},
+		{name: "129 characters key", body: tagSet(1, strings.Repeat("a", 129), "v"), invalid: true},
+		{name: "256 characters value", body: tagSet(1, "k", strings.Repeat("世", 256))},
+		{name: "257 characters value", body: tagSet(1, "k", strings.Repeat("a", 257)), invalid: true},
+		{name: "duplicated key", body: "<Tagging><TagSet><Tag><Key>k</Key><Value>1</Value></Tag><Tag><Key>k</Key><Value>2</Value></Tag></TagSet></Tagging>", invalid: true},
+	}
+	for _, tc := range testCases {
+		tags := &Tags{TagSet: &TagSet{IsObject: tc.isObject}}
+		err := xml.Unmarshal([]byte(tc.body), tags)
+		if _, ok := err.(InvalidTag); ok != tc.invalid {
+			t.Fatalf("%s: unexpected error %v", tc.name, err)
+		}
+		if !tc.invalid && err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+	}
+}
@@ -12,15 +12,12 @@ import (
 )
 
 func TestBucketMetadataSys_BucketMetadata(t *testing.T) {
-	db, err := uleveldb.OpenDb(t.TempDir())
-	if err != nil {
-		return
-	}
+	db := openTestDB(t)
 	s := NewBucketMetadataSys(db)
 	s.SetEmptyBucket(func(ctx context.Context, bucket string) (bool, error) {
 		return true, nil
 	})
-	err = s.setBucketMeta("bucket", &BucketMetadata{
+	err := s.setBucketMeta("bucket", &BucketMetadata{
 		Name:          "bucket",
 		Region:        "region",
 		Created:       time.Now(),
@@ -44,13 +41,10 @@ func TestBucketMetadataSys_BucketMetadata(t *testing.T) {
 	fmt.Println(ok)
 }
 func TestBucketMetadataSys_GetPolicyConfig(t *testing.T) {
-	db, err := uleveldb.OpenDb(t.TempDir())
-	if err != nil {
-		return
-	}
+	db := openTestDB(t)
 	s := NewBucketMetadataSys(db)
 	c, _ := condition.NewStringEqualsFunc("", condition.S3Prefix.ToKey(), "object.txt")
-	err = s.setBucketMeta("bucket", &BucketMetadata{
+	err := s.setBucketMeta("bucket", &BucketMetadata{
 		Name:    "bucket",
 		Region:  "region",
 		Created: time.Now(),
@@ -78,3 +72,62 @@ func TestBucketMetadataSys_GetPolicyConfig(t *testing.T) {
 	}
 	fmt.Println(p)
 }
+
+func TestBucketMetadataSys_BucketUsage(t *testing.T) {
+	dir := t.TempDir()
+	db, err := uleveldb.OpenDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.TODO()
+	s := NewBucketMetadataSys(db)
+	if err = s.CreateBucket(ctx, "usagebucket", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	hour := time.Date(2022, 10, 1, 8, 0, 0, 0, time.UTC)
+	// 2 PUTs of 100 bytes in the first hour, 3 GETs of 50 bytes in the second one
+	s.recordUsageAt(hour.Add(10*time.Minute), "usagebucket", "PUT", 100, 0)
+	s.recordUsageAt(hour.Add(20*time.Minute), "usagebucket", "PUT", 100, 0)
+	if err = s.FlushUsage(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		s.recordUsageAt(hour.Add(90*time.Minute), "usagebucket", "GET", 0, 50)
+	}
+	// the usage of a missing bucket is dropped
+	s.recordUsageAt(hour, "nosuchbucket", "GET", 0, 50)
+	if err = s.FlushUsage(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// the usage survives a restart
+	db.Close()
+	db, err = uleveldb.OpenDb(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	s = NewBucketMetadataSys(db)
+	s.recordUsageAt(hour.Add(100*time.Minute), "usagebucket", "GET", 0, 50)
+
+	testCases := []struct {
+		bucket   string
+		from, to time.Time
+		// expected output.
+		expected BucketUsage
+	}{
+		{"usagebucket", hour, hour.Add(2 * time.Hour), BucketUsage{Requests: map[string]uint64{"PUT": 2, "GET": 4}, BytesIn: 200, BytesOut: 200}},
+		{"usagebucket", hour, hour.Add(time.Hour), BucketUsage{Requests: map[string]uint64{"PUT": 2}, BytesIn: 200}},
+		{"usagebucket", hour.Add(30 * time.Minute), hour.Add(61 * time.Minute), BucketUsage{Requests: map[string]uint64{"PUT": 2, "GET": 4}, BytesIn: 200, BytesOut: 200}},
+		{"usagebucket", hour.Add(2 * time.Hour), hour.Add(3 * time.Hour), BucketUsage{Requests: map[string]uint64{}}},
+		{"nosuchbucket", hour, hour.Add(2 * time.Hour), BucketUsage{Requests: map[string]uint64{}}},
+	}
+	for i, testCase := range testCases {
+		usage, err := s.GetBucketUsage(ctx, testCase.bucket, testCase.from, testCase.to)
+		if err != nil {
+			t.Fatalf("Case %d: %v", i+1, err)
+		}
+		if fmt.Sprint(usage) != fmt.Sprint(testCase.expected) {
+			t.Fatalf("Case %d: Expected the usage %v, but instead found %v", i+1, testCase.expected, usage)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
+	"golang.org/x/xerrors"
+	"sync"
+	"time"
+)
+
+const (
+	bucketUsagePrefixFormat = "bktUsage/%s/"
+	bucketUsageKeyFormat    = "bktUsage/%s/%s"
+	// the usage is counted by the hour
+	bucketUsageHourFormat = "2006010215"
+
+	// DefaultUsageFlushInterval the default interval the usage counters are persisted in
+	DefaultUsageFlushInterval = time.Minute
+)
+
+// BucketUsage the requests and the transferred bytes of a bucket over a period
+type BucketUsage struct {
+	// Requests the number of requests by http method
+	Requests map[string]uint64 `json:"requests"`
+	// BytesIn the bytes of the request bodies
+	BytesIn uint64 `json:"bytesIn"`
+	// BytesOut the bytes of the response bodies
+	BytesOut uint64 `json:"bytesOut"`
+}
+
+func (u *BucketUsage) add(o *BucketUsage) {
+	if u.Requests == nil {
+		u.Requests = make(map[string]uint64)
+	}
+	for reqType, n := range o.Requests {
+		u.Requests[reqType] += n
+	}
+	u.BytesIn += o.BytesIn
+	u.BytesOut += o.BytesOut
+}
+
+// bucketUsages the usage counted since the last flush by bucket and hour
+type bucketUsages struct {
+	mu      sync.Mutex
+	pending map[string]map[string]*BucketUsage
+	// flushMu serializes the read-modify-write of the persisted usage
+	flushMu sync.Mutex
+}
+
+func newBucketUsages() *bucketUsages {
+	return &bucketUsages{pending: make(map[string]map[string]*BucketUsage)}
+}
+
+func getBucketUsageKey(bucket string, hour time.Time) string {
+	return fmt.Sprintf(bucketUsageKeyFormat, bucket, hour.UTC().Format(bucketUsageHourFormat))
+}
+
+// RecordUsage counts a request of the bucket with the bytes of its request and response bodies,
+// the counters are kept in memory until FlushUsage persists them.
+func (sys *BucketMetadataSys) RecordUsage(bucket, reqType string, bytesIn, bytesOut int64) {
+	sys.recordUsageAt(time.Now(), bucket, reqType, bytesIn, bytesOut)
+}
+
+func (sys *BucketMetadataSys) recordUsageAt(t time.Time, bucket, reqType string, bytesIn, bytesOut int64) {
+	hour := t.UTC().Format(bucketUsageHourFormat)
+	sys.usage.mu.Lock()
+	defer sys.usage.mu.Unlock()
+	hours, ok := sys.usage.pending[bucket]
+	if !ok {
+		hours = make(map[string]*BucketUsage)
+		sys.usage.pending[bucket] = hours
+	}
+	usage, ok := hours[hour]
+	if !ok {
+		usage = &BucketUsage{Requests: make(map[string]uint64)}
+		hours[hour] = usage
+	}
+	usage.Requests[reqType]++
+	usage.BytesIn += uint64(bytesIn)
+	usage.BytesOut += uint64(bytesOut)
+}
+
+// FlushUsage adds the usage counted in memory to the persisted usage, the usage of the buckets
+// which don't exist is dropped. The usage which fails to be persisted is kept for the next flush.
+func (sys *BucketMetadataSys) FlushUsage(ctx context.Context) error {
+	sys.usage.flushMu.Lock()
+	defer sys.usage.flushMu.Unlock()
+	sys.usage.mu.Lock()
+	pending := sys.usage.pending
+	sys.usage.pending = make(map[string]map[string]*BucketUsage)
+	sys.usage.mu.Unlock()
+
+	var err error
+	for bucket, hours := range pending {
+		if err != nil {
+			sys.restoreUsage(bucket, hours)
+			continue
+		}
+		if !sys.HasBucket(ctx, bucket) {
+			continue
+		}
+		batch := sys.db.NewBatch()
+		for hour, usage := range hours {
+			key := fmt.Sprintf(bucketUsageKeyFormat, bucket, hour)
+			var persisted BucketUsage
+			if err = sys.db.Get(key, &persisted); err != nil && !xerrors.Is(err, metadb.ErrNotFound) {
+				break
+			}
+			persisted.add(usage)
+			if err = batch.Put(key, persisted); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = sys.db.Write(batch)
+		}
+		if err != nil {
+			sys.restoreUsage(bucket, hours)
+		}
+	}
+	return err
+}
+
+// restoreUsage adds back the usage which isn't persisted
+func (sys *BucketMetadataSys) restoreUsage(bucket string, hours map[string]*BucketUsage) {
+	sys.usage.mu.Lock()
+	defer sys.usage.mu.Unlock()
+	current, ok := sys.usage.pending[bucket]
+	if !ok {
+		sys.usage.pending[bucket] = hours
+		return
+	}
+	for hour, usage := range hours {
+		if u, ok := current[hour]; ok {
+			u.add(usage)
+		} else {
+			current[hour] = usage
+		}
+	}
+}
+
+// RunUsageFlush flushes the usage every interval until the ctx is done, the usage is flushed a last time then
+func (sys *BucketMetadataSys) RunUsageFlush(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := sys.FlushUsage(context.Background()); err != nil {
+				log.Errorw("flush the bucket usage error", "error", err)
+			}
+			return
+		case <-ticker.C:
+			if err := sys.FlushUsage(ctx); err != nil {
+				log.Errorw("flush the bucket usage error", "error", err)
+			}
+		}
+	}
+}
+
+// GetBucketUsage returns the usage of the bucket in the hours from the hour of from until to,
+// the usage in memory is flushed first.
+func (sys *BucketMetadataSys) GetBucketUsage(ctx context.Context, bucket string, from, to time.Time) (BucketUsage, error) {
+	usage := BucketUsage{Requests: make(map[string]uint64)}
+	if err := sys.FlushUsage(ctx); err != nil {
+		return usage, err
+	}
+	start := getBucketUsageKey(bucket, from.Truncate(time.Hour))
+	limit := getBucketUsageKey(bucket, to)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	all, err := sys.db.ReadAllChan(ctx, fmt.Sprintf(bucketUsagePrefixFormat, bucket), "")
+	if err != nil {
+		return usage, err
+	}
+	for entry := range all {
+		if entry.Key < start {
+			continue
+		}
+		if entry.Key > limit || (entry.Key == limit && to.Truncate(time.Hour).Equal(to)) {
+			break
+		}
+		var hourUsage BucketUsage
+		if err = entry.UnmarshalValue(&hourUsage); err != nil {
+			return usage, err
+		}
+		usage.add(&hourUsage)
+	}
+	return usage, nil
+}
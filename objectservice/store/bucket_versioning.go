@@ -0,0 +1,55 @@
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	// VersioningEnabled every store of an object adds a new version of it
+	VersioningEnabled = "Enabled"
+	// VersioningSuspended the stores of an object replace its null version, the other versions are kept
+	VersioningSuspended = "Suspended"
+)
+
+// InvalidVersioningStatus - the versioning status is neither Enabled nor Suspended.
+type InvalidVersioningStatus struct {
+	Bucket string
+	Status string
+}
+
+func (e InvalidVersioningStatus) Error() string {
+	return fmt.Sprintf("The versioning status %q of bucket %s is neither %s nor %s", e.Status, e.Bucket, VersioningEnabled, VersioningSuspended)
+}
+
+// UpdateBucketVersioning sets the versioning status of the bucket, Enabled or Suspended. The versioning of
+// a bucket can't be turned off once it's enabled, it can only be suspended.
+func (sys *BucketMetadataSys) UpdateBucketVersioning(ctx context.Context, bucket string, status string) error {
+	if status != VersioningEnabled && status != VersioningSuspended {
+		return InvalidVersioningStatus{Bucket: bucket, Status: status}
+	}
+	lk := sys.NewNSLock(bucket)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	meta, err := sys.getBucketMeta(bucket)
+	if err != nil {
+		return err
+	}
+
+	meta.Versioning = status
+	return sys.setBucketMeta(bucket, &meta)
+}
+
+// GetBucketVersioning returns the versioning status of the bucket, empty if it has never been enabled
+func (sys *BucketMetadataSys) GetBucketVersioning(ctx context.Context, bucket string) (string, error) {
+	meta, err := sys.GetBucketMeta(ctx, bucket)
+	if err != nil {
+		return "", err
+	}
+	return meta.Versioning, nil
+}
@@ -0,0 +1,183 @@
+package store
+
+import (
+	"context"
+	"encoding/xml"
+	"golang.org/x/xerrors"
+	"strings"
+)
+
+// BucketWebsiteNotFound - no bucket website configuration found.
+type BucketWebsiteNotFound struct {
+	Bucket string
+	Err    error
+}
+
+func (e BucketWebsiteNotFound) Error() string {
+	return "No bucket website configuration found for bucket: " + e.Bucket
+}
+
+// Website - the static website configuration of a bucket
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutBucketWebsite.html
+type Website struct {
+	XMLName               xml.Name               `xml:"WebsiteConfiguration"`
+	IndexDocument         *IndexDocument         `xml:"IndexDocument,omitempty"`
+	ErrorDocument         *ErrorDocument         `xml:"ErrorDocument,omitempty"`
+	RedirectAllRequestsTo *RedirectAllRequestsTo `xml:"RedirectAllRequestsTo,omitempty"`
+	RoutingRules          []RoutingRule          `xml:"RoutingRules>RoutingRule,omitempty"`
+}
+
+// IndexDocument - the suffix appended to the requests for a directory, e.g. index.html
+type IndexDocument struct {
+	Suffix string `xml:"Suffix"`
+}
+
+// ErrorDocument - the object returned when a 4XX error occurs
+type ErrorDocument struct {
+	Key string `xml:"Key"`
+}
+
+// RedirectAllRequestsTo - every request of the website is redirected to the host
+type RedirectAllRequestsTo struct {
+	HostName string `xml:"HostName"`
+	Protocol string `xml:"Protocol,omitempty"`
+}
+
+// RoutingRule - redirects the requests which meet the Condition, all requests are
+// redirected if there is no Condition
+type RoutingRule struct {
+	Condition *RoutingRuleCondition `xml:"Condition,omitempty"`
+	Redirect  RoutingRuleRedirect   `xml:"Redirect"`
+}
+
+// RoutingRuleCondition - the key prefix and the error code a routing rule applies to,
+// a rule with an error code applies to the requests which fail with the code
+type RoutingRuleCondition struct {
+	KeyPrefixEquals             string `xml:"KeyPrefixEquals,omitempty"`
+	HttpErrorCodeReturnedEquals int    `xml:"HttpErrorCodeReturnedEquals,omitempty"`
+}
+
+// RoutingRuleRedirect - where a request is redirected to, the host of the request is kept
+// if HostName is empty. At most one of ReplaceKeyPrefixWith and ReplaceKeyWith is set.
+type RoutingRuleRedirect struct {
+	HostName             string `xml:"HostName,omitempty"`
+	Protocol             string `xml:"Protocol,omitempty"`
+	ReplaceKeyPrefixWith string `xml:"ReplaceKeyPrefixWith,omitempty"`
+	ReplaceKeyWith       string `xml:"ReplaceKeyWith,omitempty"`
+	HttpRedirectCode     int    `xml:"HttpRedirectCode,omitempty"`
+}
+
+// Validate checks the configuration is one S3 accepts
+func (ws *Website) Validate() error {
+	if ws.RedirectAllRequestsTo != nil {
+		if ws.RedirectAllRequestsTo.HostName == "" {
+			return xerrors.New("RedirectAllRequestsTo requires a HostName")
+		}
+		if ws.IndexDocument != nil || ws.ErrorDocument != nil || len(ws.RoutingRules) != 0 {
+			return xerrors.New("RedirectAllRequestsTo can't be combined with the other elements")
+		}
+		return validProtocol(ws.RedirectAllRequestsTo.Protocol)
+	}
+	if ws.IndexDocument == nil || ws.IndexDocument.Suffix == "" {
+		return xerrors.New("IndexDocument or RedirectAllRequestsTo is required")
+	}
+	if strings.Contains(ws.IndexDocument.Suffix, "/") {
+		return xerrors.New("the IndexDocument Suffix can't contain a slash")
+	}
+	if ws.ErrorDocument != nil && ws.ErrorDocument.Key == "" {
+		return xerrors.New("the ErrorDocument requires a Key")
+	}
+	for _, rule := range ws.RoutingRules {
+		redirect := rule.Redirect
+		if redirect.ReplaceKeyPrefixWith != "" && redirect.ReplaceKeyWith != "" {
+			return xerrors.New("ReplaceKeyPrefixWith and ReplaceKeyWith can't be both set")
+		}
+		if redirect.HttpRedirectCode != 0 && (redirect.HttpRedirectCode < 300 || redirect.HttpRedirectCode > 399) {
+			return xerrors.Errorf("invalid HttpRedirectCode %d", redirect.HttpRedirectCode)
+		}
+		if err := validProtocol(redirect.Protocol); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validProtocol(protocol string) error {
+	switch protocol {
+	case "", "http", "https":
+		return nil
+	}
+	return xerrors.Errorf("invalid Protocol %s", protocol)
+}
+
+// MatchRoutingRule returns the first routing rule which applies to the key, the rules with an
+// error code are only matched against the failed requests whose status is httpCode.
+// Pass 0 as the httpCode before the object is looked up.
+func (ws *Website) MatchRoutingRule(key string, httpCode int) *RoutingRule {
+	for i, rule := range ws.RoutingRules {
+		if rule.Condition == nil {
+			if httpCode == 0 {
+				return &ws.RoutingRules[i]
+			}
+			continue
+		}
+		if rule.Condition.HttpErrorCodeReturnedEquals != httpCode {
+			continue
+		}
+		if strings.HasPrefix(key, rule.Condition.KeyPrefixEquals) {
+			return &ws.RoutingRules[i]
+		}
+	}
+	return nil
+}
+
+// RedirectKey returns the key the request for key is redirected to
+func (r *RoutingRule) RedirectKey(key string) string {
+	switch {
+	case r.Redirect.ReplaceKeyWith != "":
+		return r.Redirect.ReplaceKeyWith
+	case r.Redirect.ReplaceKeyPrefixWith != "":
+		prefix := ""
+		if r.Condition != nil {
+			prefix = r.Condition.KeyPrefixEquals
+		}
+		return r.Redirect.ReplaceKeyPrefixWith + strings.TrimPrefix(key, prefix)
+	}
+	return key
+}
+
+// UpdateBucketWebsite update the website configuration of the bucket
+func (sys *BucketMetadataSys) UpdateBucketWebsite(ctx context.Context, bucket string, ws *Website) error {
+	lk := sys.NewNSLock(bucket)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	meta, err := sys.getBucketMeta(bucket)
+	if err != nil {
+		return err
+	}
+
+	meta.WebsiteConfig = ws
+	return sys.setBucketMeta(bucket, &meta)
+}
+
+// DeleteBucketWebsite delete the website configuration of the bucket
+func (sys *BucketMetadataSys) DeleteBucketWebsite(ctx context.Context, bucket string) error {
+	return sys.UpdateBucketWebsite(ctx, bucket, nil)
+}
+
+// GetWebsiteConfig get the website configuration of the bucket
+func (sys *BucketMetadataSys) GetWebsiteConfig(ctx context.Context, bucket string) (*Website, error) {
+	meta, err := sys.GetBucketMeta(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	if meta.WebsiteConfig == nil {
+		return nil, BucketWebsiteNotFound{Bucket: bucket}
+	}
+	return meta.WebsiteConfig, nil
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// DefaultLifecycleCheckInterval the default interval the expired objects are looked for in
+	DefaultLifecycleCheckInterval = time.Hour
+
+	// lifecycleListPage the objects listed at a time while the expired objects are looked for
+	lifecycleListPage = 1000
+)
+
+// LifecycleSys deletes the objects of the buckets which have expired by the expiration rules
+// of the lifecycle configurations of their buckets
+type LifecycleSys struct {
+	bmSys      *BucketMetadataSys
+	storageSys *StorageSys
+}
+
+// NewLifecycleSys returns the LifecycleSys expiring the objects of the storageSys
+func NewLifecycleSys(bmSys *BucketMetadataSys, storageSys *StorageSys) *LifecycleSys {
+	return &LifecycleSys{bmSys: bmSys, storageSys: storageSys}
+}
+
+// Run looks for the expired objects every interval until the ctx is done
+func (sys *LifecycleSys) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := sys.ExpireObjects(ctx, time.Now().UTC()); err != nil {
+				log.Errorw("expire the objects error", "error", err)
+			}
+		}
+	}
+}
+
+// ExpireObjects deletes the current objects which have expired at now, an object of a versioned bucket
+// is hidden behind a delete marker like S3. An object which fails to be deleted, under a legal hold
+// for example, is logged and retried at the next check.
+func (sys *LifecycleSys) ExpireObjects(ctx context.Context, now time.Time) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	all, err := sys.bmSys.db.ReadAllChan(ctx, bucketPrefix, "")
+	if err != nil {
+		return err
+	}
+	var buckets []BucketMetadata
+	for entry := range all {
+		var meta BucketMetadata
+		if err = entry.UnmarshalValue(&meta); err != nil {
+			return err
+		}
+		if meta.LifecycleConfig != nil {
+			buckets = append(buckets, meta)
+		}
+	}
+	for _, meta := range buckets {
+		if err = sys.expireBucket(ctx, meta.Name, meta.LifecycleConfig, now); err != nil {
+			log.Errorw("expire the objects of the bucket error", "bucket", meta.Name, "error", err)
+		}
+	}
+	return nil
+}
+
+// expireBucket deletes the objects of the bucket which have expired at now by the lifecycle configuration
+func (sys *LifecycleSys) expireBucket(ctx context.Context, bucket string, lc *Lifecycle, now time.Time) error {
+	lc, err := sys.storageSys.NormalizeLifecycle(ctx, bucket, lc)
+	if err != nil {
+		return err
+	}
+	marker := ""
+	for {
+		loi, err := sys.storageSys.ListObjects(ctx, bucket, "", marker, "", lifecycleListPage)
+		if err != nil {
+			return err
+		}
+		for _, o := range loi.Objects {
+			if !lc.expired(o, now) {
+				continue
+			}
+			expired := func(oi ObjectInfo) bool {
+				return lc.expired(oi, now)
+			}
+			if err = sys.storageSys.expireObject(ctx, bucket, o.Name, expired); err != nil {
+				log.Errorw("expire the object error", "bucket", bucket, "object", o.Name, "error", err)
+			}
+		}
+		if !loi.IsTruncated || len(loi.Objects) == 0 {
+			return nil
+		}
+		marker = loi.Objects[len(loi.Objects)-1].Name
+	}
+}
+
+// expireObject deletes the current object if it's still expired under the object lock, it may have
+// been overwritten or deleted since it was listed
+func (s *StorageSys) expireObject(ctx context.Context, bucket, object string, expired func(oi ObjectInfo) bool) error {
+	lk := s.NewNSLock(bucket, object)
+	lkctx, err := lk.GetLock(ctx, deleteOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	oi, err := s.getObjectInfo(ctx, bucket, object)
+	if err == ErrObjectNotFound {
+		return nil
+	}
+	if err != nil || !expired(oi) {
+		return err
+	}
+	_, err = s.deleteObject(ctx, bucket, object, "")
+	return err
+}
@@ -0,0 +1,212 @@
+package store
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	mdtest "github.com/ipfs/go-merkledag/test"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLifecycleSys_ExpireObjects(t *testing.T) {
+	db := openTestDB(t)
+	s := NewStorageSys(context.TODO(), mdtest.Mock(), db)
+	mbsys := NewBucketMetadataSys(db)
+	s.SetNewBucketNSLock(mbsys.NewNSLock)
+	s.SetHasBucket(mbsys.HasBucket)
+	s.SetVersioning(mbsys.GetBucketVersioning)
+	ctx := context.TODO()
+	for _, bucket := range []string{"testbucket", "versioned", "nolifecycle"} {
+		if err := mbsys.CreateBucket(ctx, bucket, "", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mbsys.UpdateBucketVersioning(ctx, "versioned", VersioningEnabled); err != nil {
+		t.Fatal(err)
+	}
+	storeObject := func(bucket, object string) ObjectInfo {
+		r, err := hash.NewReader(strings.NewReader(object), int64(len(object)), "", "", int64(len(object)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		oi, err := s.StoreObject(ctx, bucket, object, r, int64(len(object)), map[string]string{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return oi
+	}
+	exists := func(bucket, object string) bool {
+		_, err := s.GetObjectInfo(ctx, bucket, object, "")
+		if err != nil && err != ErrObjectNotFound {
+			t.Fatal(err)
+		}
+		return err == nil
+	}
+
+	lc := &Lifecycle{Rules: []LifecycleRule{
+		{ID: "logs", Status: LifecycleEnabled, Filter: &LifecycleFilter{Prefix: "logs/"}, Expiration: &LifecycleExpiration{Days: 1}},
+		{ID: "temp", Status: LifecycleEnabled, Filter: &LifecycleFilter{Tag: &Tag{Key: "temp", Value: "true"}}, Expiration: &LifecycleExpiration{Days: 1}},
+		{ID: "disabled", Status: LifecycleDisabled, Prefix: "data/", Expiration: &LifecycleExpiration{Days: 1}},
+	}}
+	if err := lc.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, bucket := range []string{"testbucket", "versioned"} {
+		if err := mbsys.UpdateBucketLifecycle(ctx, bucket, lc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, bucket := range []string{"testbucket", "versioned", "nolifecycle"} {
+		storeObject(bucket, "logs/a")
+		storeObject(bucket, "data/b")
+		storeObject(bucket, "data/c")
+		if _, err := s.PutObjectTags(ctx, bucket, "data/c", "", map[string]string{"temp": "true"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	storeObject("testbucket", "logs/held")
+	if _, err := s.PutObjectLegalHold(ctx, "testbucket", "logs/held", "", true); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing has expired yet
+	sys := NewLifecycleSys(mbsys, s)
+	if err := sys.ExpireObjects(ctx, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	if !exists("testbucket", "logs/a") || !exists("testbucket", "data/c") {
+		t.Fatal("the objects must not expire before their expiry")
+	}
+
+	if err := sys.ExpireObjects(ctx, time.Now().UTC().Add(48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		bucket, object string
+		exists         bool
+	}{
+		{bucket: "testbucket", object: "logs/a"},
+		{bucket: "testbucket", object: "data/b", exists: true},
+		{bucket: "testbucket", object: "data/c"},
+		// the object under a legal hold isn't deleted
+		{bucket: "testbucket", object: "logs/held", exists: true},
+		{bucket: "versioned", object: "logs/a"},
+		{bucket: "versioned", object: "data/b", exists: true},
+		{bucket: "versioned", object: "data/c"},
+		{bucket: "nolifecycle", object: "logs/a", exists: true},
+		{bucket: "nolifecycle", object: "data/c", exists: true},
+	} {
+		if got := exists(tc.bucket, tc.object); got != tc.exists {
+			t.Fatalf("%s/%s exists %v after the expiration", tc.bucket, tc.object, got)
+		}
+	}
+	// the expired object of a versioned bucket is hidden behind a delete marker
+	lvi, err := s.ListObjectVersions(ctx, "versioned", "logs/a", "", "", "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lvi.Objects) != 2 || !lvi.Objects[0].DeleteMarker || lvi.Objects[1].DeleteMarker {
+		t.Fatalf("unexpected versions %v of the expired object", lvi.Objects)
+	}
+
+	// an object overwritten after it's listed isn't deleted
+	storeObject("testbucket", "logs/a")
+	if err = s.expireObject(ctx, "testbucket", "logs/a", func(oi ObjectInfo) bool { return lc.expired(oi, time.Now().UTC()) }); err != nil {
+		t.Fatal(err)
+	}
+	if !exists("testbucket", "logs/a") {
+		t.Fatal("the object which hasn't expired must be kept")
+	}
+}
+
+func TestLifecycleSys_ExpireCaseInsensitiveObjects(t *testing.T) {
+	db := openTestDB(t)
+	s := NewStorageSys(context.TODO(), mdtest.Mock(), db)
+	mbsys := NewBucketMetadataSys(db)
+	mbsys.SetEmptyBucket(s.EmptyBucket)
+	s.SetNewBucketNSLock(mbsys.NewNSLock)
+	s.SetHasBucket(mbsys.HasBucket)
+	s.SetCaseInsensitive(mbsys.IsCaseInsensitive)
+	ctx := context.TODO()
+	if err := mbsys.CreateBucket(ctx, "testbucket", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := mbsys.UpdateBucketKeyCase(ctx, "testbucket", &KeyCase{Mode: KeyCaseInsensitive}); err != nil {
+		t.Fatal(err)
+	}
+	// the prefixes of the rules match the lowercased keys whatever their case
+	lc := &Lifecycle{Rules: []LifecycleRule{
+		{ID: "logs", Status: LifecycleEnabled, Filter: &LifecycleFilter{Prefix: "Logs/"}, Expiration: &LifecycleExpiration{Days: 1}},
+		{ID: "temp", Status: LifecycleEnabled, Prefix: "TEMP/", Expiration: &LifecycleExpiration{Days: 1}},
+		{ID: "data", Status: LifecycleEnabled, Filter: &LifecycleFilter{And: &LifecycleFilterAnd{Prefix: "Data/"}}, Expiration: &LifecycleExpiration{Days: 1}},
+	}}
+	if err := mbsys.UpdateBucketLifecycle(ctx, "testbucket", lc); err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []string{"LOGS/a", "temp/b", "data/c", "other/d"} {
+		r, err := hash.NewReader(strings.NewReader(object), int64(len(object)), "", "", int64(len(object)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = s.StoreObject(ctx, "testbucket", object, r, int64(len(object)), map[string]string{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := NewLifecycleSys(mbsys, s).ExpireObjects(ctx, time.Now().UTC().Add(48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	loi, err := s.ListObjects(ctx, "testbucket", "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loi.Objects) != 1 || loi.Objects[0].Name != "other/d" {
+		t.Fatalf("unexpected objects %v after the expiration", loi.Objects)
+	}
+	// the configuration of the bucket keeps the case of the prefixes
+	if got, err := mbsys.GetLifecycleConfig(ctx, "testbucket"); err != nil || got.Rules[0].Filter.Prefix != "Logs/" {
+		t.Fatalf("unexpected lifecycle %v, %v", got, err)
+	}
+}
+
+func TestLifecycle_Validate(t *testing.T) {
+	date := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	notMidnight := date.Add(time.Hour)
+	rule := func(update func(r *LifecycleRule)) *Lifecycle {
+		r := LifecycleRule{ID: "rule", Status: LifecycleEnabled, Expiration: &LifecycleExpiration{Days: 1}}
+		update(&r)
+		return &Lifecycle{Rules: []LifecycleRule{r}}
+	}
+	testCases := []struct {
+		name  string
+		lc    *Lifecycle
+		valid bool
+	}{
+		{name: "days", lc: rule(func(r *LifecycleRule) {}), valid: true},
+		{name: "date", lc: rule(func(r *LifecycleRule) { r.Expiration = &LifecycleExpiration{Date: &date} }), valid: true},
+		{name: "and filter", lc: rule(func(r *LifecycleRule) {
+			r.Filter = &LifecycleFilter{And: &LifecycleFilterAnd{Prefix: "logs/", Tags: []Tag{{Key: "k", Value: "v"}}}}
+		}), valid: true},
+		{name: "no rules", lc: &Lifecycle{}},
+		{name: "unknown status", lc: rule(func(r *LifecycleRule) { r.Status = "On" })},
+		{name: "no expiration", lc: rule(func(r *LifecycleRule) { r.Expiration = nil })},
+		{name: "no days", lc: rule(func(r *LifecycleRule) { r.Expiration = &LifecycleExpiration{} })},
+		{name: "days and date", lc: rule(func(r *LifecycleRule) { r.Expiration = &LifecycleExpiration{Days: 1, Date: &date} })},
+		{name: "date not at midnight", lc: rule(func(r *LifecycleRule) { r.Expiration = &LifecycleExpiration{Date: &notMidnight} })},
+		{name: "prefix and filter", lc: rule(func(r *LifecycleRule) { r.Prefix = "a"; r.Filter = &LifecycleFilter{Prefix: "b"} })},
+		{name: "prefix and tag filter", lc: rule(func(r *LifecycleRule) {
+			r.Filter = &LifecycleFilter{Prefix: "b", Tag: &Tag{Key: "k"}}
+		})},
+		{name: "tag without key", lc: rule(func(r *LifecycleRule) { r.Filter = &LifecycleFilter{Tag: &Tag{Value: "v"}} })},
+		{name: "long id", lc: rule(func(r *LifecycleRule) { r.ID = strings.Repeat("i", 256) })},
+		{name: "duplicate id", lc: &Lifecycle{Rules: []LifecycleRule{
+			{ID: "rule", Status: LifecycleEnabled, Expiration: &LifecycleExpiration{Days: 1}},
+			{ID: "rule", Status: LifecycleEnabled, Expiration: &LifecycleExpiration{Days: 2}},
+		}}},
+	}
+	for _, tc := range testCases {
+		if err := tc.lc.Validate(); (err == nil) != tc.valid {
+			t.Fatalf("%s: unexpected validation %v", tc.name, err)
+		}
+	}
+}
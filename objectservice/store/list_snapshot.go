@@ -0,0 +1,118 @@
+package store
+
+import (
+	"fmt"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
+	"github.com/google/uuid"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// listSnapshotTTL how long a truncated listing keeps its snapshot for the next page
+	listSnapshotTTL = 5 * time.Minute
+	// maxListSnapshots the max number of snapshots kept at the same time
+	maxListSnapshots = 1024
+)
+
+// InvalidContinuationToken - the continuation token wasn't returned by ListObjectsV2.
+type InvalidContinuationToken struct {
+	Token string
+}
+
+func (e InvalidContinuationToken) Error() string {
+	return fmt.Sprintf("The continuation token %q is invalid", e.Token)
+}
+
+// newContinuationToken the token of the next page, made of the id of the listing and the key the page starts after
+func newContinuationToken(listID, marker string) string {
+	return listID + "/" + marker
+}
+
+// parseContinuationToken returns the id of the listing and the marker of the token
+func parseContinuationToken(token string) (listID, marker string, err error) {
+	i := strings.Index(token, "/")
+	if i < 0 {
+		return "", "", InvalidContinuationToken{Token: token}
+	}
+	if _, err = uuid.Parse(token[:i]); err != nil {
+		return "", "", InvalidContinuationToken{Token: token}
+	}
+	return token[:i], token[i+1:], nil
+}
+
+// ParseContinuationToken returns the key the page of the continuation token starts after
+func ParseContinuationToken(token string) (string, error) {
+	_, marker, err := parseContinuationToken(token)
+	return marker, err
+}
+
+type listSnapshot struct {
+	snap    metadb.Snapshot
+	expires time.Time
+
+	// the listing and the page the snapshot is kept for
+	bucket    string
+	prefix    string
+	delimiter string
+	marker    string
+}
+
+// listSnapshots keeps the db snapshots of the unfinished ListObjectsV2 listings,
+// keyed by the random id of the listing carried in its continuation tokens.
+type listSnapshots struct {
+	mu    sync.Mutex
+	snaps map[string]*listSnapshot
+}
+
+func newListSnapshots() *listSnapshots {
+	return &listSnapshots{snaps: make(map[string]*listSnapshot)}
+}
+
+// take removes and returns the snapshot kept by the listing for the page, or nil.
+// The snapshot is left in place if the page isn't the one the listing goes on with.
+func (l *listSnapshots) take(listID, bucket, prefix, delimiter, marker string) metadb.Snapshot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.releaseExpired()
+	ls, ok := l.snaps[listID]
+	if !ok || ls.bucket != bucket || ls.prefix != prefix || ls.delimiter != delimiter || ls.marker != marker {
+		return nil
+	}
+	delete(l.snaps, listID)
+	return ls.snap
+}
+
+// keep keeps the snapshot of the listing for the next page, it's released instead when too many are kept
+func (l *listSnapshots) keep(listID, bucket, prefix, delimiter, marker string, snap metadb.Snapshot) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.releaseExpired()
+	if old, ok := l.snaps[listID]; ok {
+		old.snap.Release()
+		delete(l.snaps, listID)
+	}
+	if len(l.snaps) >= maxListSnapshots {
+		snap.Release()
+		return
+	}
+	l.snaps[listID] = &listSnapshot{
+		snap:      snap,
+		expires:   time.Now().Add(listSnapshotTTL),
+		bucket:    bucket,
+		prefix:    prefix,
+		delimiter: delimiter,
+		marker:    marker,
+	}
+}
+
+func (l *listSnapshots) releaseExpired() {
+	now := time.Now()
+	for key, ls := range l.snaps {
+		if now.After(ls.expires) {
+			ls.snap.Release()
+			delete(l.snaps, key)
+		}
+	}
+}
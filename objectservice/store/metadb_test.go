@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb/memdb"
+	"github.com/filedag-project/filedag-storage/objectservice/uleveldb"
+	ipld "github.com/ipfs/go-ipld-format"
+	mdtest "github.com/ipfs/go-merkledag/test"
+	"testing"
+)
+
+// testMetaBackend the backend of the db of the store tests
+var testMetaBackend = metadb.BackendLevelDB
+
+func openTestDB(t testing.TB) metadb.DB {
+	if testMetaBackend == metadb.BackendMemory {
+		return memdb.New()
+	}
+	db, err := uleveldb.OpenDb(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// newTestStorageSys returns a StorageSys on a mock dag service and a new db, with the bucket testbucket
+func newTestStorageSys(t testing.TB) (*StorageSys, *BucketMetadataSys) {
+	return newTestStorageSysWithDAG(t, mdtest.Mock())
+}
+
+// newTestStorageSysWithDAG returns a StorageSys on the dag service and a new db, with the bucket testbucket
+func newTestStorageSysWithDAG(t testing.TB, dagServ ipld.DAGService) (*StorageSys, *BucketMetadataSys) {
+	db := openTestDB(t)
+	t.Cleanup(func() { db.Close() })
+	s := NewStorageSys(context.TODO(), dagServ, db)
+	mbsys := NewBucketMetadataSys(db)
+	if err := mbsys.CreateBucket(context.TODO(), "testbucket", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	s.SetNewBucketNSLock(mbsys.NewNSLock)
+	s.SetHasBucket(mbsys.HasBucket)
+	s.SetVersioning(mbsys.GetBucketVersioning)
+	return s, mbsys
+}
+
+// TestStore_MemoryBackend runs the store tests against the in-memory backend,
+// the tests which restart the db on the same directory are left out.
+func TestStore_MemoryBackend(t *testing.T) {
+	testMetaBackend = metadb.BackendMemory
+	defer func() {
+		testMetaBackend = metadb.BackendLevelDB
+	}()
+	for _, test := range []struct {
+		name string
+		fn   func(t *testing.T)
+	}{
+		{name: "BucketMetadata", fn: TestBucketMetadataSys_BucketMetadata},
+		{name: "GetPolicyConfig", fn: TestBucketMetadataSys_GetPolicyConfig},
+		{name: "Object", fn: TestStorageSys_Object},
+		{name: "StoreObjectUnknownSize", fn: TestStorageSys_StoreObjectUnknownSize},
+		{name: "ObjectChecksum", fn: TestStorageSys_ObjectChecksum},
+		{name: "ObjectFilter", fn: TestStorageSys_ObjectFilter},
+		{name: "ObjectInfoCache", fn: TestStorageSys_ObjectInfoCache},
+		{name: "SoftDelete", fn: TestStorageSys_SoftDelete},
+		{name: "InterruptedPut", fn: TestStorageSys_InterruptedPut},
+		{name: "ListObjectsSnapshot", fn: TestStorageSys_ListObjectsSnapshot},
+		{name: "ListObjectsDelimiter", fn: TestStorageSys_ListObjectsDelimiter},
+		{name: "ListObjectsPagination", fn: TestStorageSys_ListObjectsPagination},
+		{name: "ObjectVersions", fn: TestStorageSys_ObjectVersions},
+		{name: "ListObjectVersions", fn: TestStorageSys_ListObjectVersions},
+		{name: "CopyObject", fn: TestStorageSys_CopyObject},
+		{name: "DeleteObjectsPartialFailure", fn: TestStorageSys_DeleteObjectsPartialFailure},
+		{name: "AppendObject", fn: TestStorageSys_AppendObject},
+		{name: "CompleteMultiPartUploadByReference", fn: TestStorageSys_CompleteMultiPartUploadByReference},
+		{name: "ObjectInfoSchemaVersion", fn: TestObjectInfo_SchemaVersion},
+		{name: "UserDefinedMetadata", fn: TestStorageSys_UserDefinedMetadata},
+		{name: "ObjectLegalHold", fn: TestStorageSys_ObjectLegalHold},
+		{name: "StoreObjectBadDigest", fn: TestStorageSys_StoreObjectBadDigest},
+		{name: "KeyCase", fn: TestStorageSys_KeyCase},
+		{name: "ListObjectsV2DeletedToken", fn: TestStorageSys_ListObjectsV2DeletedToken},
+		{name: "ListObjectsV2Interleaved", fn: TestStorageSys_ListObjectsV2Interleaved},
+		{name: "BucketTagging", fn: TestBucketMetadataSys_BucketTagging},
+		{name: "BucketLimit", fn: TestBucketMetadataSys_BucketLimit},
+		{name: "ObjectTags", fn: TestStorageSys_ObjectTags},
+		{name: "LifecycleExpireObjects", fn: TestLifecycleSys_ExpireObjects},
+		{name: "TouchObject", fn: TestStorageSys_TouchObject},
+		{name: "BucketACL", fn: TestBucketMetadataSys_BucketACL},
+		{name: "ObjectACL", fn: TestStorageSys_ObjectACL},
+	} {
+		t.Run(test.name, test.fn)
+	}
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
+	"golang.org/x/xerrors"
+	"time"
+)
+
+const allUploadBucketsPrefix = "uploadObj/"
+
+// SetStaleUploadExpiry sets how long a multipart upload can stay uncompleted after it's initiated, the expired
+// uploads are aborted by the object GC which removes their parts. 0 keeps them until they are aborted.
+// It must be called before the StorageSys is used.
+func (s *StorageSys) SetStaleUploadExpiry(expiry time.Duration) {
+	s.staleUploadExpiry = expiry
+}
+
+// purgeStaleUploads aborts the multipart uploads initiated before the expiry
+func (s *StorageSys) purgeStaleUploads(ctx context.Context) error {
+	if s.staleUploadExpiry <= 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.gcTimeout)
+	defer cancel()
+
+	all, err := s.Db.ReadAllChan(ctx, allUploadBucketsPrefix, "")
+	if err != nil {
+		return err
+	}
+	for entry := range all {
+		var mi MultipartInfo
+		if err = entry.UnmarshalValue(&mi); err != nil {
+			return err
+		}
+		if time.Since(mi.Initiated) < s.staleUploadExpiry {
+			continue
+		}
+		// the upload may have been completed or aborted since it was listed
+		err = s.abortMultipartUpload(ctx, mi.Bucket, mi.Object, mi.UploadID)
+		if err != nil && !xerrors.Is(err, metadb.ErrNotFound) {
+			log.Errorw("abort stale upload error", "bucket", mi.Bucket, "object", mi.Object, "uploadID", mi.UploadID, "error", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/datatypes"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	"golang.org/x/xerrors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStorageSys_OrphanedParts(t *testing.T) {
+	s, _ := newTestStorageSys(t)
+	ctx := context.TODO()
+	putPart := func(uploadID string, partID int, content string) (objectPartInfo, error) {
+		r, err := hash.NewReader(strings.NewReader(content), int64(len(content)), "", "", int64(len(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.PutObjectPart(ctx, "testbucket", "obj", uploadID, partID, r, int64(len(content)), map[string]string{})
+	}
+	deleteMarks := func() int {
+		all, err := s.Db.ReadAllChan(ctx, allDeletePrefixFormat, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for range all {
+			n++
+		}
+		return n
+	}
+
+	// the part uploaded again replaces the part of the number
+	mi, err := s.NewMultipartUpload(ctx, "testbucket", "obj", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = putPart(mi.UploadID, 1, "123456"); err != nil {
+		t.Fatal(err)
+	}
+	pi, err := putPart(mi.UploadID, 1, "654321")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := deleteMarks(); n != 1 {
+		t.Fatalf("expected the dag of the replaced part to be marked to delete, but %d dags are marked", n)
+	}
+	if _, err = s.CompleteMultiPartUpload(ctx, "testbucket", "obj", mi.UploadID, []datatypes.CompletePart{{PartNumber: 1, ETag: pi.ETag}}); err != nil {
+		t.Fatal(err)
+	}
+	_, reader, err := s.GetObject(ctx, "testbucket", "obj", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadAll(reader)
+	reader.Close()
+	if err != nil || string(content) != "654321" {
+		t.Fatalf("expected the content of the last upload of the part, but got %q, err %v", content, err)
+	}
+
+	// the part uploaded to a completed upload is marked to delete
+	if _, err = putPart(mi.UploadID, 2, "123456"); err == nil {
+		t.Fatal("expected the part of the completed upload to be rejected")
+	}
+	if n := deleteMarks(); n != 2 {
+		t.Fatalf("expected the dag of the rejected part to be marked to delete, but %d dags are marked", n)
+	}
+
+	// the uncompleted upload is aborted after the expiry
+	stale, err := s.NewMultipartUpload(ctx, "testbucket", "obj", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = putPart(stale.UploadID, 1, "123456"); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.purgeStaleUploads(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s.SetStaleUploadExpiry(time.Hour)
+	if err = s.purgeStaleUploads(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.GetMultipartInfo(ctx, "testbucket", "obj", stale.UploadID); err != nil {
+		t.Fatalf("expected the upload within the expiry to be kept, but got %v", err)
+	}
+	s.SetStaleUploadExpiry(time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	if err = s.purgeStaleUploads(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.getMultipartInfo(ctx, "testbucket", "obj", stale.UploadID); !xerrors.Is(err, metadb.ErrNotFound) {
+		t.Fatalf("expected the expired upload to be aborted, but got %v", err)
+	}
+	if n := deleteMarks(); n != 3 {
+		t.Fatalf("expected the dag of the part of the expired upload to be marked to delete, but %d dags are marked", n)
+	}
+
+	// the uploaded part which isn't listed is marked to delete with the completion, like the overwritten object
+	s.minPartSize = 1
+	mi, err = s.NewMultipartUpload(ctx, "testbucket", "obj", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listed []datatypes.CompletePart
+	for i, content := range []string{"part 1", "part 2", "part 3"} {
+		pi, err := putPart(mi.UploadID, i+1, content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i != 1 {
+			listed = append(listed, datatypes.CompletePart{PartNumber: i + 1, ETag: pi.ETag})
+		}
+	}
+	if _, err = s.CompleteMultiPartUpload(ctx, "testbucket", "obj", mi.UploadID, listed); err != nil {
+		t.Fatal(err)
+	}
+	if n := deleteMarks(); n != 5 {
+		t.Fatalf("expected the dags of the unlisted part and of the overwritten object to be marked to delete, but %d dags are marked", n)
+	}
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"context"
+)
+
+// PutObjectACL sets the canned ACL of the object version, the version is the current object if the version ID
+// is empty. Only the metadata record of the version is rewritten, the dag of the object isn't touched.
+// InvalidCannedACL is returned if the ACL isn't supported.
+func (s *StorageSys) PutObjectACL(ctx context.Context, bucket, object, versionID, acl string) (ObjectInfo, error) {
+	if err := checkCannedACL(acl); err != nil {
+		return ObjectInfo{}, err
+	}
+	if acl == ACLPrivate {
+		acl = ""
+	}
+	object, err := s.objectKey(ctx, bucket, object)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	lk := s.NewNSLock(bucket, object)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	return s.updateObjectVersion(ctx, bucket, object, versionID, func(oi *ObjectInfo) {
+		oi.ACL = acl
+	})
+}
+
+// GetObjectACL returns the canned ACL of the object version and its version ID, the version is the current
+// object if the version ID is empty. The ACL is private if the version has none.
+func (s *StorageSys) GetObjectACL(ctx context.Context, bucket, object, versionID string) (string, string, error) {
+	oi, err := s.GetObjectInfo(ctx, bucket, object, versionID)
+	if err != nil {
+		return "", "", err
+	}
+	if oi.ACL == "" {
+		return ACLPrivate, oi.VersionID, nil
+	}
+	return oi.ACL, oi.VersionID, nil
+}
+
+// GetObjectVersionACL returns the canned ACL of the object version, the current object if the version ID
+// is empty, it's the ACL checked by the authorization of the object reads
+func (s *StorageSys) GetObjectVersionACL(ctx context.Context, bucket, object, versionID string) (string, error) {
+	acl, _, err := s.GetObjectACL(ctx, bucket, object, versionID)
+	return acl, err
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	"strings"
+	"testing"
+)
+
+func TestStorageSys_ObjectACL(t *testing.T) {
+	s, mbsys := newTestStorageSys(t)
+	ctx := context.TODO()
+	storeObject := func(content string) ObjectInfo {
+		r, err := hash.NewReader(strings.NewReader(content), int64(len(content)), "", "", int64(len(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		oi, err := s.StoreObject(ctx, "testbucket", "obj", r, int64(len(content)), map[string]string{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return oi
+	}
+	getACL := func(versionID string) string {
+		acl, _, err := s.GetObjectACL(ctx, "testbucket", "obj", versionID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return acl
+	}
+
+	stored := storeObject("acl")
+	if acl := getACL(""); acl != ACLPrivate {
+		t.Fatalf("a new object must be private, got %s", acl)
+	}
+	if _, err := s.PutObjectACL(ctx, "testbucket", "obj", "", ACLPublicRead); err != nil {
+		t.Fatal(err)
+	}
+	if acl, err := s.GetObjectVersionACL(ctx, "testbucket", "obj", ""); err != nil || acl != ACLPublicRead {
+		t.Fatalf("the acl %s is not %s, %v", acl, ACLPublicRead, err)
+	}
+	// the ACL is only in the metadata record, the dag of the object is the same
+	oi, err := s.GetObjectInfo(ctx, "testbucket", "obj", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oi.Cid != stored.Cid || oi.ETag != stored.ETag {
+		t.Fatalf("the object %v changed by the acl", oi)
+	}
+	if _, err = s.PutObjectACL(ctx, "testbucket", "obj", "", "bucket-owner-read"); err != (InvalidCannedACL{ACL: "bucket-owner-read"}) {
+		t.Fatalf("the unsupported acl must be rejected, %v", err)
+	}
+	if acl := getACL(""); acl != ACLPublicRead {
+		t.Fatalf("the acl %s is not kept", acl)
+	}
+
+	// an overwrite is private, the ACL of a noncurrent version is put by its version ID
+	if err = mbsys.UpdateBucketVersioning(ctx, "testbucket", VersioningEnabled); err != nil {
+		t.Fatal(err)
+	}
+	v1 := storeObject("v1")
+	storeObject("v2")
+	if acl := getACL(""); acl != ACLPrivate {
+		t.Fatalf("the overwrite must be private, got %s", acl)
+	}
+	if _, err = s.PutObjectACL(ctx, "testbucket", "obj", v1.VersionID, ACLPublicReadWrite); err != nil {
+		t.Fatal(err)
+	}
+	if acl := getACL(v1.VersionID); acl != ACLPublicReadWrite {
+		t.Fatalf("the acl of the noncurrent version is %s", acl)
+	}
+	if acl, err := s.GetObjectVersionACL(ctx, "testbucket", "obj", ""); err != nil || acl != ACLPrivate {
+		t.Fatalf("the acl %s of the current version is not private, %v", acl, err)
+	}
+	if acl, err := s.GetObjectVersionACL(ctx, "testbucket", "obj", v1.VersionID); err != nil || acl != ACLPublicReadWrite {
+		t.Fatalf("the acl %s checked for the noncurrent version is not %s, %v", acl, ACLPublicReadWrite, err)
+	}
+	if _, err = s.PutObjectACL(ctx, "testbucket", "obj", "nosuchversion", ACLPublicRead); err != ErrObjectVersionNotFound {
+		t.Fatalf("the missing version must be reported, %v", err)
+	}
+	if _, err = s.GetObjectVersionACL(ctx, "testbucket", "missing", ""); err != ErrObjectNotFound {
+		t.Fatalf("the missing object must be reported, %v", err)
+	}
+}
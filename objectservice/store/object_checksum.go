@@ -0,0 +1,91 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+)
+
+// ErrChecksumMismatch the content read from the dag doesn't match the checksum of the object
+var ErrChecksumMismatch = errors.New("the object content does not match its checksum")
+
+// SetVerifyChecksum sets whether the content of an object is verified against its sha256
+// checksum when it's read to the end, a mismatch is returned as ErrChecksumMismatch by the last read.
+func (s *StorageSys) SetVerifyChecksum(verify bool) {
+	s.verifyChecksum = verify
+}
+
+// newChecksumReader returns the reader which computes the sha256 checksum of the content read through it
+func newChecksumReader(reader io.Reader) (io.ReadCloser, hash.Hash) {
+	h := sha256.New()
+	return ioutil.NopCloser(io.TeeReader(reader, h)), h
+}
+
+// encodeChecksum returns the base64 encoded checksum like the x-amz-checksum-sha256 header
+func encodeChecksum(h hash.Hash) string {
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// checksumReader verifies the checksum of the object when the whole content is read,
+// the blocks of the dag are verified by their cids, but not the order they are assembled in.
+// A seek which skips or rereads some content turns the verification off, a range read is sent unverified.
+type checksumReader struct {
+	io.ReadCloser
+	// hash is nil once the content isn't read in order from its start
+	hash     hash.Hash
+	expected string
+	// read the length of the content hashed
+	read int64
+}
+
+func newVerifyingReader(reader io.ReadCloser, expected string) io.ReadCloser {
+	return &checksumReader{ReadCloser: reader, hash: sha256.New(), expected: expected}
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if r.hash == nil {
+		return n, err
+	}
+	r.hash.Write(p[:n])
+	r.read += int64(n)
+	if err == io.EOF && encodeChecksum(r.hash) != r.expected {
+		return n, ErrChecksumMismatch
+	}
+	return n, err
+}
+
+// Seek seeks the content if the reader of the content can seek
+func (r *checksumReader) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := r.ReadCloser.(io.Seeker)
+	if !ok {
+		return 0, errors.New("the reader of the content can't seek")
+	}
+	pos, err := seeker.Seek(offset, whence)
+	if err != nil || pos != r.read {
+		r.hash = nil
+	}
+	return pos, err
+}
+
+// CompositeChecksum returns the checksum of a multipart object like S3 computes it, the base64 encoded
+// sha256 of the concatenated sha256 of the parts followed by the number of parts. It's empty for
+// the other objects and if the checksum of a part isn't known.
+func (o *ObjectInfo) CompositeChecksum() string {
+	if len(o.Parts) == 0 {
+		return ""
+	}
+	h := sha256.New()
+	for _, part := range o.Parts {
+		sum, err := base64.StdEncoding.DecodeString(part.ChecksumSHA256)
+		if err != nil || len(sum) != sha256.Size {
+			return ""
+		}
+		h.Write(sum)
+	}
+	return fmt.Sprintf("%s-%d", encodeChecksum(h), len(o.Parts))
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/ipfs/go-merkledag"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestStorageSys_ObjectChecksum(t *testing.T) {
+	s, _ := newTestStorageSys(t)
+	ctx := context.TODO()
+
+	// two chunks of the same size with different content
+	data := append(bytes.Repeat([]byte("a"), chunkSize), bytes.Repeat([]byte("b"), chunkSize)...)
+	r, err := hash.NewReader(bytes.NewReader(data), int64(len(data)), "", "", int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oi, err := s.StoreObject(ctx, "testbucket", "obj", r, int64(len(data)), map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	if expected := base64.StdEncoding.EncodeToString(sum[:]); oi.ChecksumSHA256 != expected {
+		t.Fatalf("expected the checksum %v, but instead found %v", expected, oi.ChecksumSHA256)
+	}
+	readObject := func() ([]byte, error) {
+		_, reader, err := s.GetObject(ctx, "testbucket", "obj", "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader.Close()
+		return ioutil.ReadAll(reader)
+	}
+	s.SetVerifyChecksum(true)
+	if got, err := readObject(); err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("expected the object to be read and verified, err:%v", err)
+	}
+
+	// a range read to the end seeks over the start of the content, it isn't verified
+	_, reader, err := s.GetObject(ctx, "testbucket", "obj", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seeker, ok := reader.(io.Seeker)
+	if !ok {
+		t.Fatalf("expected the verifying reader to seek")
+	}
+	if _, err = seeker.Seek(int64(chunkSize+1), io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(reader)
+	reader.Close()
+	if err != nil || !bytes.Equal(got, data[chunkSize+1:]) {
+		t.Fatalf("expected the range to the end to be read, err:%v", err)
+	}
+
+	// reassemble the object with the links of the root in the wrong order,
+	// every block still matches its cid
+	rootCid, err := cid.Decode(oi.Cid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := s.DagPool.Get(ctx, rootCid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	swapped := root.Copy().(*merkledag.ProtoNode)
+	links := swapped.Links()
+	if len(links) != 2 {
+		t.Fatalf("expected 2 links, but instead found %d", len(links))
+	}
+	swapped.SetLinks([]*ipld.Link{links[1], links[0]})
+	if err = s.DagPool.Add(ctx, swapped); err != nil {
+		t.Fatal(err)
+	}
+	oi.Cid = swapped.Cid().String()
+	if err = s.putObjectInfo("testbucket", "obj", oi); err != nil {
+		t.Fatal(err)
+	}
+
+	s.SetVerifyChecksum(false)
+	got, err = readObject()
+	if err != nil {
+		t.Fatalf("expected the blocks to pass their cid check, err:%v", err)
+	}
+	if bytes.Equal(got, data) {
+		t.Fatalf("expected the reassembled content to differ")
+	}
+	s.SetVerifyChecksum(true)
+	if _, err = readObject(); err != ErrChecksumMismatch {
+		t.Fatalf("expected the error %v, but instead found %v", ErrChecksumMismatch, err)
+	}
+}
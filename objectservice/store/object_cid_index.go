@@ -0,0 +1,185 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
+	"golang.org/x/xerrors"
+)
+
+const (
+	cidRefKeyFormat              = "cidref/%s/%s/%s"
+	allCidRefPrefixFormat        = "cidref/%s/"
+	cidVersionRefKeyFormat       = "cidver/%s/%s/%s/%s"
+	allCidVersionRefPrefixFormat = "cidver/%s/"
+	cidTrashRefKeyFormat         = "cidtrash/%s/%s/%s"
+	allCidTrashRefPrefixFormat   = "cidtrash/%s/"
+	allObjectBucketsPrefix       = "obj/"
+	allVersionsBucketsPrefix     = "objver/"
+	// cidIndexBuiltKey marks that the objects, the noncurrent versions and the trashed objects
+	// stored before the index covered them are indexed
+	cidIndexBuiltKey = "cidref-built-v2"
+)
+
+// ObjectRef a key of an object referencing a root cid
+type ObjectRef struct {
+	Bucket string
+	Object string
+	// VersionID the version ID of a noncurrent version, empty for the current object and the trashed objects
+	VersionID string
+	// Trashed indicates if the object is deleted in the soft delete mode and kept in the trash
+	Trashed bool
+}
+
+func getCidRefKey(root, bucket, object string) string {
+	return fmt.Sprintf(cidRefKeyFormat, root, bucket, object)
+}
+
+func getCidVersionRefKey(root, bucket, object, versionID string) string {
+	return fmt.Sprintf(cidVersionRefKeyFormat, root, bucket, object, versionID)
+}
+
+func getCidTrashRefKey(root, bucket, object string) string {
+	return fmt.Sprintf(cidTrashRefKeyFormat, root, bucket, object)
+}
+
+// batchPutCidRef adds the reference of the object to its root cid to the batch
+func batchPutCidRef(batch metadb.Batch, root, bucket, object string) error {
+	if root == "" {
+		return nil
+	}
+	return batch.Put(getCidRefKey(root, bucket, object), ObjectRef{Bucket: bucket, Object: object})
+}
+
+// batchDeleteCidRef adds the removal of the reference of the object to its root cid to the batch,
+// it must be added before the reference of the new root of the object, which may be the same
+func batchDeleteCidRef(batch metadb.Batch, root, bucket, object string) {
+	if root == "" {
+		return
+	}
+	batch.Delete(getCidRefKey(root, bucket, object))
+}
+
+// batchPutCidVersionRefs adds the references of the noncurrent versions of the object to their root cids
+// to the batch in place of the ones of the old versions, a delete marker has none
+func batchPutCidVersionRefs(batch metadb.Batch, bucket, object string, old, versions []ObjectInfo) error {
+	for _, v := range old {
+		if v.Cid != "" {
+			batch.Delete(getCidVersionRefKey(v.Cid, bucket, object, v.VersionID))
+		}
+	}
+	for _, v := range versions {
+		if v.Cid == "" {
+			continue
+		}
+		ref := ObjectRef{Bucket: bucket, Object: object, VersionID: v.VersionID}
+		if err := batch.Put(getCidVersionRefKey(v.Cid, bucket, object, v.VersionID), ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batchPutCidTrashRef adds the reference of the trashed object to its root cid to the batch
+func batchPutCidTrashRef(batch metadb.Batch, root, bucket, object string) error {
+	if root == "" {
+		return nil
+	}
+	return batch.Put(getCidTrashRefKey(root, bucket, object), ObjectRef{Bucket: bucket, Object: object, Trashed: true})
+}
+
+// batchDeleteCidTrashRef adds the removal of the reference of the trashed object to its root cid to the batch,
+// it must be added before the reference of the object trashed next in the same key
+func batchDeleteCidTrashRef(batch metadb.Batch, root, bucket, object string) {
+	if root == "" {
+		return
+	}
+	batch.Delete(getCidTrashRefKey(root, bucket, object))
+}
+
+// ListObjectsByCid lists the keys of the objects whose content is the dag of the root cid, the current
+// objects, then the noncurrent versions and the trashed objects. The content of an object is stored once
+// for all the keys and the versions of the same content.
+func (s *StorageSys) ListObjectsByCid(ctx context.Context, root string) ([]ObjectRef, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	refs := make([]ObjectRef, 0)
+	for _, prefix := range []string{allCidRefPrefixFormat, allCidVersionRefPrefixFormat, allCidTrashRefPrefixFormat} {
+		all, err := s.Db.ReadAllChan(ctx, fmt.Sprintf(prefix, root), "")
+		if err != nil {
+			return nil, err
+		}
+		for entry := range all {
+			var ref ObjectRef
+			if err = entry.UnmarshalValue(&ref); err != nil {
+				return nil, err
+			}
+			refs = append(refs, ref)
+		}
+	}
+	return refs, nil
+}
+
+// buildCidIndex indexes the objects, the noncurrent versions and the trashed objects stored before
+// the cid index covered them, it runs once before the StorageSys is used
+func (s *StorageSys) buildCidIndex(ctx context.Context) error {
+	var built bool
+	err := s.Db.Get(cidIndexBuiltKey, &built)
+	if err == nil && built {
+		return nil
+	}
+	if err != nil && !xerrors.Is(err, metadb.ErrNotFound) {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batch := s.Db.NewBatch()
+	all, err := s.Db.ReadAllChan(ctx, allObjectBucketsPrefix, "")
+	if err != nil {
+		return err
+	}
+	for entry := range all {
+		var o ObjectInfo
+		if err = entry.UnmarshalValue(&o); err != nil {
+			return err
+		}
+		if err = batchPutCidRef(batch, o.Cid, o.Bucket, o.Name); err != nil {
+			return err
+		}
+	}
+	all, err = s.Db.ReadAllChan(ctx, allVersionsBucketsPrefix, "")
+	if err != nil {
+		return err
+	}
+	for entry := range all {
+		var ov objectVersions
+		if err = entry.UnmarshalValue(&ov); err != nil {
+			return err
+		}
+		if len(ov.Versions) == 0 {
+			continue
+		}
+		if err = batchPutCidVersionRefs(batch, ov.Versions[0].Bucket, ov.Versions[0].Name, nil, ov.Versions); err != nil {
+			return err
+		}
+	}
+	all, err = s.Db.ReadAllChan(ctx, allTrashBucketsPrefix, "")
+	if err != nil {
+		return err
+	}
+	for entry := range all {
+		var t TrashedObject
+		if err = entry.UnmarshalValue(&t); err != nil {
+			return err
+		}
+		if err = batchPutCidTrashRef(batch, t.Object.Cid, t.Object.Bucket, t.Object.Name); err != nil {
+			return err
+		}
+	}
+	if err = batch.Put(cidIndexBuiltKey, true); err != nil {
+		return err
+	}
+	return s.Db.Write(batch)
+}
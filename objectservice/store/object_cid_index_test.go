@@ -0,0 +1,125 @@
+package store
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	mdtest "github.com/ipfs/go-merkledag/test"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStorageSys_ListObjectsByCid(t *testing.T) {
+	dagServ := mdtest.Mock()
+	s, mbsys := newTestStorageSysWithDAG(t, dagServ)
+	mbsys.CreateBucket(context.TODO(), "otherbucket", "", "")
+	ctx := context.TODO()
+	storeObject := func(bucket, object, content string) ObjectInfo {
+		r, err := hash.NewReader(strings.NewReader(content), int64(len(content)), "", "", int64(len(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		oi, err := s.StoreObject(ctx, bucket, object, r, int64(len(content)), map[string]string{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return oi
+	}
+	lookup := func(s *StorageSys, root string) string {
+		refs, err := s.ListObjectsByCid(ctx, root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys := make([]string, 0, len(refs))
+		for _, ref := range refs {
+			key := ref.Bucket + "/" + ref.Object
+			if ref.VersionID != "" {
+				key += "?versionId=" + ref.VersionID
+			}
+			if ref.Trashed {
+				key += " (trashed)"
+			}
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return strings.Join(keys, ",")
+	}
+
+	// the same content under several keys is stored as one dag
+	stored := storeObject("testbucket", "a", "123456")
+	storeObject("testbucket", "dir/b", "123456")
+	storeObject("otherbucket", "c", "123456")
+	other := storeObject("testbucket", "d", "654321")
+	if got := lookup(s, stored.Cid); got != "otherbucket/c,testbucket/a,testbucket/dir/b" {
+		t.Fatalf("unexpected objects of the cid %v", got)
+	}
+	if got := lookup(s, other.Cid); got != "testbucket/d" {
+		t.Fatalf("unexpected objects of the cid %v", got)
+	}
+
+	// the overwritten and the deleted objects no longer reference the cid
+	storeObject("testbucket", "a", "654321")
+	if _, err := s.DeleteObject(ctx, "otherbucket", "c", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := lookup(s, stored.Cid); got != "testbucket/dir/b" {
+		t.Fatalf("unexpected objects of the cid after the overwrite and the delete %v", got)
+	}
+	if got := lookup(s, other.Cid); got != "testbucket/a,testbucket/d" {
+		t.Fatalf("unexpected objects of the cid after the overwrite %v", got)
+	}
+
+	// the noncurrent versions and the trashed objects reference the cid too
+	if err := mbsys.CreateBucket(ctx, "versioned", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := mbsys.UpdateBucketVersioning(ctx, "versioned", VersioningEnabled); err != nil {
+		t.Fatal(err)
+	}
+	first := storeObject("versioned", "v", "123456")
+	storeObject("versioned", "v", "654321")
+	s.SetTrashRetention(time.Hour)
+	if _, err := s.DeleteObject(ctx, "testbucket", "dir/b", ""); err != nil {
+		t.Fatal(err)
+	}
+	storedRefs := "testbucket/dir/b (trashed),versioned/v?versionId=" + first.VersionID
+	if got := lookup(s, stored.Cid); got != storedRefs {
+		t.Fatalf("unexpected objects of the cid with the versions and the trash %v", got)
+	}
+	if got := lookup(s, other.Cid); got != "testbucket/a,testbucket/d,versioned/v" {
+		t.Fatalf("unexpected objects of the cid with the versions %v", got)
+	}
+
+	// the objects stored before the index existed are indexed by the next start
+	all, err := s.Db.ReadAllChan(ctx, "cid", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for entry := range all {
+		if err = s.Db.Delete(entry.Key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := lookup(s, other.Cid); got != "" {
+		t.Fatalf("expected the index to be removed, but found %v", got)
+	}
+	restarted := NewStorageSys(context.TODO(), dagServ, s.Db)
+	if got := lookup(restarted, other.Cid); got != "testbucket/a,testbucket/d,versioned/v" {
+		t.Fatalf("unexpected objects of the cid after the index is built %v", got)
+	}
+	if got := lookup(restarted, stored.Cid); got != storedRefs {
+		t.Fatalf("unexpected objects of the cid after the index is built %v", got)
+	}
+
+	// the removed version and the restored object are indexed as they are
+	if _, err = s.DeleteObject(ctx, "versioned", "v", first.VersionID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.UndeleteObject(ctx, "testbucket", "dir/b"); err != nil {
+		t.Fatal(err)
+	}
+	if got := lookup(s, stored.Cid); got != "testbucket/dir/b" {
+		t.Fatalf("unexpected objects of the cid after the version is removed and the object restored %v", got)
+	}
+}
@@ -0,0 +1,143 @@
+package store
+
+import (
+	"context"
+	dagpoolcli "github.com/filedag-project/filedag-storage/dag/pool/client"
+	"github.com/ipfs/go-cid"
+)
+
+// CopyObject copies the object to the destination without reading its content, the copy is an object info
+// pointing at the dag of the source with its ETag and checksum, the dag is referenced once more for it.
+// meta is the metadata of the copy like the one of StoreObject, the caller passes the metadata of the source
+// to keep it, the tags of the source are kept too. The version of the source is copied if srcVersionID isn't empty,
+// the current object otherwise. Copying an unversioned object onto itself only updates its metadata.
+func (s *StorageSys) CopyObject(ctx context.Context, srcBucket, srcObject, srcVersionID, dstBucket, dstObject string, meta map[string]string) (ObjectInfo, error) {
+	srcObject, err := s.objectKey(ctx, srcBucket, srcObject)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	dstObject, err = s.objectKey(ctx, dstBucket, dstObject)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	bktlk := s.newBucketNSLock(dstBucket)
+	bktlkCtx, err := bktlk.GetRLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	ctx = bktlkCtx.Context()
+	defer bktlk.RUnlock(bktlkCtx.Cancel)
+
+	if !s.hasBucket(ctx, dstBucket) {
+		return ObjectInfo{}, BucketNotFound{Bucket: dstBucket}
+	}
+	if srcBucket != dstBucket && !s.hasBucket(ctx, srcBucket) {
+		return ObjectInfo{}, BucketNotFound{Bucket: srcBucket}
+	}
+	if err = s.checkOverwrite(ctx, dstBucket, dstObject); err != nil {
+		return ObjectInfo{}, err
+	}
+	status, err := s.getVersioning(ctx, dstBucket)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	// a copy onto itself in a versioned bucket adds a version, which owns a reference of the dag
+	if srcBucket == dstBucket && srcObject == dstObject && srcVersionID == "" && status == "" {
+		return s.replaceObjectMetadata(ctx, dstBucket, dstObject, meta)
+	}
+
+	srcInfo, root, err := s.referenceObject(ctx, srcBucket, srcObject, srcVersionID)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		// release the reference taken for the copy
+		if e := s.markObjetToDelete(root); e != nil {
+			log.Errorw("mark Objet to delete error", "bucket", dstBucket, "object", dstObject, "cid", root.String(), "error", e)
+		}
+	}()
+	objInfo := newObjectInfo(dstBucket, dstObject, srcInfo.ETag, srcInfo.Cid, srcInfo.ChecksumSHA256, srcInfo.Size, meta)
+	objInfo.Parts = srcInfo.Parts
+	objInfo.Tags = srcInfo.Tags
+
+	lk := s.NewNSLock(dstBucket, dstObject)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	if err = s.checkOverwrite(ctx, dstBucket, dstObject); err != nil {
+		return ObjectInfo{}, err
+	}
+	batch := s.Db.NewBatch()
+	if err = s.batchPutLatestVersion(ctx, batch, dstBucket, dstObject, &objInfo); err != nil {
+		return ObjectInfo{}, err
+	}
+	if err = s.writeObjectBatch(dstBucket, dstObject, batch); err != nil {
+		return ObjectInfo{}, err
+	}
+	s.objectFilters.added(dstBucket, dstObject)
+	return objInfo, nil
+}
+
+// referenceObject returns the info of the version of the object, the current object if the version ID is empty,
+// and its root after its dag is referenced once more, the object lock is held meanwhile so that the dag isn't
+// released by a concurrent delete
+func (s *StorageSys) referenceObject(ctx context.Context, bucket, object, versionID string) (ObjectInfo, cid.Cid, error) {
+	lk := s.NewNSLock(bucket, object)
+	lkctx, err := lk.GetRLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return ObjectInfo{}, cid.Undef, err
+	}
+	ctx = lkctx.Context()
+	defer lk.RUnlock(lkctx.Cancel)
+
+	oi, err := s.getObjectVersion(ctx, bucket, object, versionID)
+	if err != nil {
+		return ObjectInfo{}, cid.Undef, err
+	}
+	root, err := cid.Decode(oi.Cid)
+	if err != nil {
+		return ObjectInfo{}, cid.Undef, err
+	}
+	if err = dagpoolcli.ReferenceDAG(ctx, s.DagPool, root); err != nil {
+		return ObjectInfo{}, cid.Undef, err
+	}
+	return oi, root, nil
+}
+
+// replaceObjectMetadata replaces the metadata of the object by updating its object info only,
+// the dag isn't referenced again nor the old one released as they are the same
+func (s *StorageSys) replaceObjectMetadata(ctx context.Context, bucket, object string, meta map[string]string) (ObjectInfo, error) {
+	lk := s.NewNSLock(bucket, object)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	oi, err := s.getObjectInfo(ctx, bucket, object)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if err = s.checkOverwrite(ctx, bucket, object); err != nil {
+		return ObjectInfo{}, err
+	}
+	objInfo := newObjectInfo(bucket, object, oi.ETag, oi.Cid, oi.ChecksumSHA256, oi.Size, meta)
+	objInfo.Parts = oi.Parts
+	objInfo.Tags = oi.Tags
+	batch := s.Db.NewBatch()
+	if err = batchPutObjectInfo(batch, bucket, object, objInfo); err != nil {
+		return ObjectInfo{}, err
+	}
+	if err = s.writeObjectBatch(bucket, object, batch); err != nil {
+		return ObjectInfo{}, err
+	}
+	return objInfo, nil
+}
@@ -0,0 +1,151 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"github.com/filedag-project/filedag-storage/objectservice/consts"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestStorageSys_CopyObject(t *testing.T) {
+	s, mbsys := newTestStorageSys(t)
+	mbsys.CreateBucket(context.TODO(), "otherbucket", "", "")
+	ctx := context.TODO()
+	readObject := func(bucket, object string) string {
+		_, reader, err := s.GetObject(ctx, bucket, object, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader.Close()
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	deleteMarks := func() int {
+		all, err := s.Db.ReadAllChan(ctx, allDeletePrefixFormat, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for range all {
+			n++
+		}
+		return n
+	}
+
+	content := "hello copy"
+	r, err := hash.NewReader(strings.NewReader(content), int64(len(content)), "", "", int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := s.StoreObject(ctx, "testbucket", "src", r, int64(len(content)), map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meta := map[string]string{strings.ToLower(consts.ContentType): "text/plain"}
+	dst, err := s.CopyObject(ctx, "testbucket", "src", "", "otherbucket", "dst", meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst.Cid != src.Cid || dst.ETag != src.ETag || dst.ChecksumSHA256 != src.ChecksumSHA256 || dst.ContentType != "text/plain" {
+		t.Fatalf("the copy must share the content of the source, got %+v", dst)
+	}
+	if got := readObject("otherbucket", "dst"); got != content {
+		t.Fatalf("unexpected content %v of the copy", got)
+	}
+	if _, err = s.CopyObject(ctx, "testbucket", "nosuchobject", "", "otherbucket", "dst", meta); err != ErrObjectNotFound {
+		t.Fatalf("the missing source must not be copied, %v", err)
+	}
+	if _, err = s.CopyObject(ctx, "testbucket", "src", "", "nosuchbucket", "dst", meta); err == nil {
+		t.Fatal("the copy to a missing bucket must fail")
+	}
+
+	// the copy onto itself only replaces the metadata, no dag is released
+	self, err := s.CopyObject(ctx, "testbucket", "src", "", "testbucket", "src", meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if self.Cid != src.Cid || self.ContentType != "text/plain" || deleteMarks() != 0 {
+		t.Fatalf("unexpected object info %+v after the metadata is replaced", self)
+	}
+	// every copy owns a reference of the dag, deleting one releases its own reference only
+	if _, err = s.DeleteObject(ctx, "otherbucket", "dst", ""); err != nil {
+		t.Fatal(err)
+	}
+	if deleteMarks() != 1 {
+		t.Fatal("the reference of the deleted copy must be released")
+	}
+	if got := readObject("testbucket", "src"); got != content {
+		t.Fatalf("unexpected content %v of the source", got)
+	}
+
+	// the copy onto itself adds a version in a versioned bucket
+	if err = mbsys.UpdateBucketVersioning(ctx, "testbucket", VersioningEnabled); err != nil {
+		t.Fatal(err)
+	}
+	if self, err = s.CopyObject(ctx, "testbucket", "src", "", "testbucket", "src", map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if self.VersionID == "" || self.Cid != src.Cid {
+		t.Fatalf("unexpected version %+v of the copy", self)
+	}
+	loi, err := s.ListObjectVersions(ctx, "testbucket", "src", "", "", "", 1000)
+	if err != nil || len(loi.Objects) != 2 {
+		t.Fatalf("the copy must be a new version, %v", err)
+	}
+
+	// a noncurrent version is copied by its version ID
+	newContent := "hello again"
+	if r, err = hash.NewReader(strings.NewReader(newContent), int64(len(newContent)), "", "", int64(len(newContent))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.StoreObject(ctx, "testbucket", "src", r, int64(len(newContent)), map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, versionID := range []string{self.VersionID, NullVersionID} {
+		old, err := s.CopyObject(ctx, "testbucket", "src", versionID, "otherbucket", "old", meta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if old.Cid != src.Cid {
+			t.Fatalf("the copy of the version %s must share the content of the version, got %+v", versionID, old)
+		}
+		if got := readObject("otherbucket", "old"); got != content {
+			t.Fatalf("unexpected content %v of the copy of the version %s", got, versionID)
+		}
+	}
+	if got := readObject("testbucket", "src"); got != newContent {
+		t.Fatalf("unexpected content %v of the source", got)
+	}
+	if _, err = s.CopyObject(ctx, "testbucket", "src", mustGetUUID(), "otherbucket", "old", meta); err != ErrObjectVersionNotFound {
+		t.Fatalf("expected ErrObjectVersionNotFound, got %v", err)
+	}
+
+	// the reference taken for a copy which isn't stored is released
+	marks := deleteMarks()
+	s.Db = &failingBatchDB{DB: s.Db}
+	if _, err = s.CopyObject(ctx, "testbucket", "src", "", "otherbucket", "failed", meta); err != errBatchFailed {
+		t.Fatalf("the copy must fail with the batch error, %v", err)
+	}
+	if deleteMarks() != marks+1 {
+		t.Fatal("the reference of the failed copy must be released")
+	}
+}
+
+// failingBatchDB fails the batch writes, the single writes succeed
+type failingBatchDB struct {
+	metadb.DB
+}
+
+var errBatchFailed = errors.New("batch failed")
+
+func (db *failingBatchDB) Write(metadb.Batch) error {
+	return errBatchFailed
+}
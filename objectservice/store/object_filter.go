@@ -0,0 +1,194 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// minFilterCapacity the min number of keys a bucket filter is sized for
+	minFilterCapacity = 1024
+	// filterFalsePositiveRate the false positive rate of a bucket filter at its capacity
+	filterFalsePositiveRate = 0.01
+)
+
+// bloomFilter is a bloom filter of object names, sized for a capacity
+// and a false positive rate. It's not safe for concurrent use.
+type bloomFilter struct {
+	bits    []uint64
+	m       uint64
+	k       uint64
+	cap     int
+	added   int
+	deleted int
+}
+
+func newBloomFilter(capacity int, fpRate float64) *bloomFilter {
+	if capacity < minFilterCapacity {
+		capacity = minFilterCapacity
+	}
+	m := uint64(math.Ceil(-float64(capacity) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Round(float64(m) / float64(capacity) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &bloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+		cap:  capacity,
+	}
+}
+
+// bloomHashes returns the two hashes of the key combined to the k locations, see
+// "Less Hashing, Same Performance: Building a Better Bloom Filter".
+func bloomHashes(key string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h1 := h.Sum64()
+	h2 := h1>>33 | h1<<31
+	return h1, h2 | 1
+}
+
+func (bf *bloomFilter) add(key string) {
+	h1, h2 := bloomHashes(key)
+	for i := uint64(0); i < bf.k; i++ {
+		loc := (h1 + i*h2) % bf.m
+		bf.bits[loc/64] |= 1 << (loc % 64)
+	}
+	bf.added++
+}
+
+func (bf *bloomFilter) has(key string) bool {
+	h1, h2 := bloomHashes(key)
+	for i := uint64(0); i < bf.k; i++ {
+		loc := (h1 + i*h2) % bf.m
+		if bf.bits[loc/64]&(1<<(loc%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// stale reports whether the filter has too many keys or deleted keys,
+// so that its false positive rate is higher than expected.
+func (bf *bloomFilter) stale() bool {
+	return bf.added > bf.cap || bf.deleted > bf.cap/2
+}
+
+// bucketFilter is the filter of the object names of a bucket.
+// It answers the lookups only once all the objects in the db are added to it.
+type bucketFilter struct {
+	bf    *bloomFilter
+	ready bool
+}
+
+// objectFilters keeps a bloom filter of the object names of each bucket, so that
+// looking up an object which doesn't exist doesn't read the db. The filter of
+// a bucket is built in the background on the first lookup, and is rebuilt when
+// it has too many keys or deleted keys. A key the filter may have is always read
+// from the db, so a false positive only costs the db read.
+type objectFilters struct {
+	mu      sync.Mutex
+	filters map[string]*bucketFilter
+	// build builds the filter of a bucket
+	build func(bucket string, add func(object string)) error
+
+	// negatives the number of lookups answered without reading the db
+	negatives uint64
+}
+
+func newObjectFilters(build func(bucket string, add func(object string)) error) *objectFilters {
+	return &objectFilters{
+		filters: make(map[string]*bucketFilter),
+		build:   build,
+	}
+}
+
+// mayContain returns false if the object is definitely not in the bucket
+func (f *objectFilters) mayContain(bucket, object string) bool {
+	f.mu.Lock()
+	bktFilter, ok := f.filters[bucket]
+	if !ok {
+		bktFilter = f.startBuild(bucket, 0)
+	}
+	if !bktFilter.ready || bktFilter.bf.has(object) {
+		f.mu.Unlock()
+		return true
+	}
+	f.mu.Unlock()
+	atomic.AddUint64(&f.negatives, 1)
+	return false
+}
+
+// added must be called after the object is written to the db
+func (f *objectFilters) added(bucket, object string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if bktFilter, ok := f.filters[bucket]; ok {
+		bktFilter.bf.add(object)
+		if bktFilter.ready && bktFilter.bf.stale() {
+			f.startBuild(bucket, bktFilter.bf.added)
+		}
+	}
+}
+
+// deleted must be called after the object is deleted from the db
+func (f *objectFilters) deleted(bucket, object string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if bktFilter, ok := f.filters[bucket]; ok {
+		bktFilter.bf.deleted++
+		if bktFilter.ready && bktFilter.bf.stale() {
+			f.startBuild(bucket, bktFilter.bf.added-bktFilter.bf.deleted)
+		}
+	}
+}
+
+// startBuild replaces the filter of the bucket with an empty one, which is ready
+// once all the objects in the db are added to it. The objects written meanwhile
+// are added to it too, so none is missed. The caller must hold f.mu.
+func (f *objectFilters) startBuild(bucket string, expected int) *bucketFilter {
+	bktFilter := &bucketFilter{bf: newBloomFilter(2*expected, filterFalsePositiveRate)}
+	f.filters[bucket] = bktFilter
+	go func() {
+		err := f.build(bucket, func(object string) {
+			f.mu.Lock()
+			bktFilter.bf.add(object)
+			f.mu.Unlock()
+		})
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.filters[bucket] != bktFilter {
+			return
+		}
+		if err != nil {
+			log.Warnw("build the object filter error", "bucket", bucket, "error", err)
+			delete(f.filters, bucket)
+			return
+		}
+		bktFilter.ready = true
+		if bktFilter.bf.stale() {
+			f.startBuild(bucket, bktFilter.bf.added)
+		}
+	}()
+	return bktFilter
+}
+
+// buildObjectFilter adds the names of all the objects of the bucket in the db
+func (s *StorageSys) buildObjectFilter(bucket string, add func(object string)) error {
+	prefix := fmt.Sprintf(allObjectPrefixFormat, bucket, "")
+	all, err := s.Db.ReadAllChan(context.Background(), prefix, "")
+	if err != nil {
+		return err
+	}
+	for entry := range all {
+		add(strings.TrimPrefix(entry.Key, prefix))
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newFilterTestStorageSys(t testing.TB) *StorageSys {
+	s, _ := newTestStorageSys(t)
+	return s
+}
+
+// waitObjectFilter triggers the build of the filter of the bucket and waits until it's ready
+func waitObjectFilter(t testing.TB, s *StorageSys, bucket string) {
+	s.objectFilters.mayContain(bucket, "")
+	for i := 0; i < 100; i++ {
+		s.objectFilters.mu.Lock()
+		bktFilter, ok := s.objectFilters.filters[bucket]
+		ready := ok && bktFilter.ready
+		s.objectFilters.mu.Unlock()
+		if ready {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("the object filter of %s is not ready", bucket)
+}
+
+func TestStorageSys_ObjectFilter(t *testing.T) {
+	s := newFilterTestStorageSys(t)
+	ctx := context.TODO()
+	bucket := "testbucket"
+	// the objects in the db before the filter is built
+	for i := 0; i < 2000; i++ {
+		if err := s.Db.Put(getObjectKey(bucket, fmt.Sprintf("old%d", i)), ObjectInfo{Bucket: bucket, Name: fmt.Sprintf("old%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitObjectFilter(t, s, bucket)
+	for i := 0; i < 2000; i++ {
+		if _, err := s.GetObjectInfo(ctx, bucket, fmt.Sprintf("old%d", i), ""); err != nil {
+			t.Fatalf("GetObjectInfo old%d err:%v", i, err)
+		}
+	}
+
+	r, err := hash.NewReader(bytes.NewReader([]byte("123456")), 6, "", "", 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.StoreObject(ctx, bucket, "new", r, 6, map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.GetObjectInfo(ctx, bucket, "new", ""); err != nil {
+		t.Fatalf("GetObjectInfo err:%v", err)
+	}
+
+	negatives := atomic.LoadUint64(&s.objectFilters.negatives)
+	for i := 0; i < 1000; i++ {
+		if _, err = s.GetObjectInfo(ctx, bucket, fmt.Sprintf("missing%d", i), ""); err != ErrObjectNotFound {
+			t.Fatalf("expected ErrObjectNotFound, but instead found %v", err)
+		}
+	}
+	// the false positives are read from the db
+	if n := atomic.LoadUint64(&s.objectFilters.negatives) - negatives; n < 900 {
+		t.Fatalf("expected most of the misses to skip the db, only %d did", n)
+	}
+
+	// a deleted object stays in the filter, the db read finds it's gone
+	if _, err = s.DeleteObject(ctx, bucket, "new", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.GetObjectInfo(ctx, bucket, "new", ""); err != ErrObjectNotFound {
+		t.Fatalf("expected ErrObjectNotFound, but instead found %v", err)
+	}
+}
+
+func TestObjectFilters_Rebuild(t *testing.T) {
+	var builds int32
+	objects := []string{"a", "b"}
+	f := newObjectFilters(func(bucket string, add func(object string)) error {
+		atomic.AddInt32(&builds, 1)
+		for _, object := range objects {
+			add(object)
+		}
+		return nil
+	})
+	isReady := func() bool {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.filters["bkt"].ready
+	}
+	f.mayContain("bkt", "a")
+	for i := 0; i < 100 && !isReady(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !isReady() || f.mayContain("bkt", "c") {
+		t.Fatalf("expected the filter to be ready and not to have c")
+	}
+	// more deletes than half of the capacity make the filter stale
+	for i := 0; i <= minFilterCapacity/2; i++ {
+		f.deleted("bkt", "a")
+	}
+	if isReady() {
+		t.Fatalf("expected the filter to be rebuilt")
+	}
+	for i := 0; i < 100 && !isReady(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&builds); n != 2 {
+		t.Fatalf("expected 2 builds, but instead found %d", n)
+	}
+}
+
+func BenchmarkStorageSys_GetObjectInfoMiss(b *testing.B) {
+	s := newFilterTestStorageSys(b)
+	ctx := context.TODO()
+	bucket := "testbucket"
+	for i := 0; i < 10000; i++ {
+		if err := s.Db.Put(getObjectKey(bucket, fmt.Sprintf("obj%d", i)), ObjectInfo{Bucket: bucket, Name: fmt.Sprintf("obj%d", i)}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	waitObjectFilter(b, s, bucket)
+
+	// nine of ten lookups miss
+	b.Run("filter", func(b *testing.B) {
+		negatives := atomic.LoadUint64(&s.objectFilters.negatives)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			object := fmt.Sprintf("missing%d", i)
+			if i%10 == 0 {
+				object = fmt.Sprintf("obj%d", i%10000)
+			}
+			s.getObjectInfo(ctx, bucket, object)
+		}
+		dbReads := uint64(b.N) - (atomic.LoadUint64(&s.objectFilters.negatives) - negatives)
+		b.ReportMetric(float64(dbReads)/float64(b.N), "db-reads/op")
+	})
+	b.Run("db", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			object := fmt.Sprintf("missing%d", i)
+			if i%10 == 0 {
+				object = fmt.Sprintf("obj%d", i%10000)
+			}
+			var meta ObjectInfo
+			s.Db.Get(getObjectKey(bucket, object), &meta)
+		}
+		b.ReportMetric(1, "db-reads/op")
+	})
+}
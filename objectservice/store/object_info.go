@@ -1,10 +1,31 @@
 package store
 
 import (
+	"github.com/filedag-project/filedag-storage/objectservice/consts"
 	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	"strings"
 	"time"
 )
 
+// The schema versions of ObjectInfo. The records are encoded by msgpack as maps keyed by
+// the names of the fields, so that the compatibility policy is:
+//   - A new field is additive, it decodes to its zero value from an older record. A field is
+//     never renamed or retyped, a field which is no longer used is left in the struct.
+//   - An older binary skips the fields it doesn't know when it decodes a newer record, it drops
+//     them if it rewrites the record.
+//   - When the zero value isn't the sensible default of a new field, the schema version is bumped
+//     and upgrade sets the default in the older records when they are read.
+//   - A record is always written with ObjectInfoSchemaVersion.
+const (
+	// objectInfoV1 the records written before the schema version was added, SchemaVersion is 0 in them
+	objectInfoV1 = 1
+	// objectInfoV2 adds SchemaVersion, the objects of v1 are unversioned so each record is the latest version
+	objectInfoV2 = 2
+
+	// ObjectInfoSchemaVersion the schema version of the written records
+	ObjectInfoSchemaVersion = objectInfoV2
+)
+
 // ObjectInfo - represents object metadata.
 //
 //	{
@@ -20,12 +41,18 @@ import (
 //		DeleteMarker = {bool} false
 //		ContentType = {string} "application/x-msdownload"
 //		ContentEncoding = {string} ""
+//		CacheControl = {string} ""
+//		ContentDisposition = {string} ""
+//		ContentLanguage = {string} ""
 //		Expires = {time.Time} 0001-01-01 00:00:00 +0000
 //		Parts = {[]ObjectPartInfo} nil
 //		AccTime = {time.Time} 0001-01-01 00:00:00 +0000
 //		SuccessorModTime = {time.Time} 0001-01-01 00:00:00 +0000
 //	}
 type ObjectInfo struct {
+	// SchemaVersion the schema version of the record, 0 means objectInfoV1
+	SchemaVersion int
+
 	// Name of the bucket.
 	Bucket string
 
@@ -46,6 +73,11 @@ type ObjectInfo struct {
 
 	// ipfs key
 	Cid string
+
+	// Base64 encoded sha256 checksum of the whole object content, it's empty
+	// for the objects completed by multipart uploads or appended to.
+	ChecksumSHA256 string
+
 	// Version ID of this object.
 	VersionID string
 
@@ -65,9 +97,42 @@ type ObjectInfo struct {
 	// by the Content-Type header field.
 	ContentEncoding string
 
+	// Specifies caching behavior along the request/reply chain.
+	CacheControl string
+
+	// Specifies presentational information for the object, such as
+	// the file name of the download.
+	ContentDisposition string
+
+	// The language the content is in.
+	ContentLanguage string
+
 	// Date and time at which the object is no longer able to be cached
 	Expires time.Time
 
+	// The location the object is redirected to by the static website hosting,
+	// a path of the bucket starting with '/' or an http(s) URL.
+	WebsiteRedirectLocation string
+
+	// The user-defined metadata of the object, the x-amz-meta- headers it was
+	// stored with by their lowercase names. It's nil if the object has none.
+	UserDefined map[string]string
+
+	// The tags of the object, they are changed without rewriting the dag of the object.
+	// It's nil if the object has none.
+	Tags map[string]string
+
+	// The canned ACL of the object, it's empty if the object is private.
+	ACL string
+
+	// LegalHold indicates if the object is under a legal hold, it can't be
+	// deleted or overwritten until the hold is removed.
+	LegalHold bool
+
+	// The parts of the object completed by a multipart upload, the root of the object
+	// links the dag of every part in order. It's empty for the other objects.
+	Parts []ObjectPartInfo
+
 	// Date and time when the object was last accessed.
 	AccTime time.Time
 
@@ -75,6 +140,76 @@ type ObjectInfo struct {
 	SuccessorModTime time.Time
 }
 
+// upgrade sets the defaults of the fields added after the schema version of the record
+func (o *ObjectInfo) upgrade() {
+	if o.SchemaVersion < objectInfoV1 {
+		o.SchemaVersion = objectInfoV1
+	}
+	if o.SchemaVersion < objectInfoV2 {
+		o.IsLatest = true
+		o.SchemaVersion = objectInfoV2
+	}
+}
+
+// userDefinedMetadata returns the user-defined metadata among the metadata of a request,
+// the entries of the keys with the x-amz-meta- prefix, nil if there's none
+func userDefinedMetadata(meta map[string]string) map[string]string {
+	prefix := strings.ToLower(consts.AmzUserMetadataPrefix)
+	var userDefined map[string]string
+	for k, v := range meta {
+		k = strings.ToLower(k)
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		if userDefined == nil {
+			userDefined = make(map[string]string)
+		}
+		userDefined[k] = v
+	}
+	return userDefined
+}
+
+// ObjectPartInfo - a part of an object completed by a multipart upload
+type ObjectPartInfo struct {
+	Number int
+	Size   int64
+	ETag   string
+	// ChecksumSHA256 the base64 encoded sha256 of the part content, empty for the parts
+	// uploaded before the part checksums were kept
+	ChecksumSHA256 string
+	// Cid the root of the part dag, empty for the parts completed before the part cids were kept
+	Cid string
+}
+
+// ObjectPart returns the part of the number, an object which isn't completed
+// by a multipart upload has the single part 1 of its content
+func (o *ObjectInfo) ObjectPart(partNumber int) (ObjectPartInfo, error) {
+	if len(o.Parts) == 0 {
+		if partNumber != 1 {
+			return ObjectPartInfo{}, InvalidPartNumber{PartNumber: partNumber}
+		}
+		return ObjectPartInfo{Number: 1, Size: o.Size, ETag: o.ETag, ChecksumSHA256: o.ChecksumSHA256}, nil
+	}
+	for _, part := range o.Parts {
+		if part.Number == partNumber {
+			return part, nil
+		}
+	}
+	return ObjectPartInfo{}, InvalidPartNumber{PartNumber: partNumber}
+}
+
+// PartOffset returns the offset of the part in the object, the part must be one of the object
+func (o *ObjectInfo) PartOffset(partNumber int) int64 {
+	var offset int64
+	for _, part := range o.Parts {
+		if part.Number == partNumber {
+			break
+		}
+		offset += part.Size
+	}
+	return offset
+}
+
 // objectPartInfo Info of each part kept in the multipart metadata
 // file after CompleteMultipartUpload() is called.
 type objectPartInfo struct {
@@ -83,6 +218,8 @@ type objectPartInfo struct {
 	Number  int       `json:"number"`
 	Size    int64     `json:"size"`
 	ModTime time.Time `json:"mod_time"`
+	// ChecksumSHA256 the base64 encoded sha256 of the part content
+	ChecksumSHA256 string `json:"checksum_sha256,omitempty"`
 }
 
 type MultipartInfo struct {
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// defaultObjectInfoCacheTTL how long an object info is served from the cache
+	defaultObjectInfoCacheTTL = 2 * time.Second
+	// maxObjectInfoCacheEntries the max number of the object infos in the cache
+	maxObjectInfoCacheEntries = 10000
+)
+
+type objectInfoCacheEntry struct {
+	info    ObjectInfo
+	expires time.Time
+}
+
+// objectInfoCache caches the object infos read from the db for a short ttl, so that the
+// bursts of HEAD and conditional GET requests of the hot objects don't read the db each time.
+// The object infos written or deleted by the StorageSys are invalidated right away, the ttl
+// bounds how stale an entry can be otherwise. A nil cache caches nothing.
+type objectInfoCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*objectInfoCacheEntry
+	// gen is bumped by each invalidation, an object info read from the db before
+	// an invalidation isn't cached, it may be the one which was just replaced
+	gen uint64
+	now func() time.Time
+}
+
+func newObjectInfoCache(ttl time.Duration) *objectInfoCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &objectInfoCache{
+		ttl:     ttl,
+		entries: make(map[string]*objectInfoCacheEntry),
+		now:     time.Now,
+	}
+}
+
+// get returns the cached object info
+func (c *objectInfoCache) get(bucket, object string) (ObjectInfo, bool) {
+	if c == nil {
+		return ObjectInfo{}, false
+	}
+	key := getObjectKey(bucket, object)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return ObjectInfo{}, false
+	}
+	if !c.now().Before(e.expires) {
+		delete(c.entries, key)
+		return ObjectInfo{}, false
+	}
+	return copyObjectInfo(e.info), true
+}
+
+// generation returns the generation to pass to put for an object info read from the db after it
+func (c *objectInfoCache) generation() uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// put caches the object info read from the db at the generation, unless something was invalidated since
+func (c *objectInfoCache) put(bucket, object string, info ObjectInfo, gen uint64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	now := c.now()
+	if len(c.entries) >= maxObjectInfoCacheEntries {
+		c.evict(now)
+	}
+	c.entries[getObjectKey(bucket, object)] = &objectInfoCacheEntry{
+		info:    copyObjectInfo(info),
+		expires: now.Add(c.ttl),
+	}
+}
+
+// invalidate removes the object info, it's called after the object info is written or deleted
+func (c *objectInfoCache) invalidate(bucket, object string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	delete(c.entries, getObjectKey(bucket, object))
+}
+
+// evict removes the expired entries, or some entries if none expired
+func (c *objectInfoCache) evict(now time.Time) {
+	for key, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, key)
+		}
+	}
+	for key := range c.entries {
+		if len(c.entries) < maxObjectInfoCacheEntries {
+			return
+		}
+		delete(c.entries, key)
+	}
+}
+
+// copyObjectInfo copies the object info, so that the cached one isn't changed by the callers
+func copyObjectInfo(info ObjectInfo) ObjectInfo {
+	if info.Parts != nil {
+		info.Parts = append([]ObjectPartInfo(nil), info.Parts...)
+	}
+	return info
+}
+
+// SetObjectInfoCacheTTL sets how long the object infos read from the db are cached, 0 disables the cache.
+// It must be called before the StorageSys is used.
+func (s *StorageSys) SetObjectInfoCacheTTL(ttl time.Duration) {
+	s.objectInfoCache = newObjectInfoCache(ttl)
+}
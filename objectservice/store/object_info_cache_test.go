@@ -0,0 +1,146 @@
+package store
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	mdtest "github.com/ipfs/go-merkledag/test"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingDB counts the reads of the db
+type countingDB struct {
+	metadb.DB
+	gets uint64
+}
+
+func (db *countingDB) Get(key string, value interface{}) error {
+	atomic.AddUint64(&db.gets, 1)
+	return db.DB.Get(key, value)
+}
+
+func newCacheTestStorageSys(t testing.TB) (*StorageSys, *countingDB) {
+	db := &countingDB{DB: openTestDB(t)}
+	t.Cleanup(func() { db.Close() })
+	s := NewStorageSys(context.TODO(), mdtest.Mock(), db)
+	mbsys := NewBucketMetadataSys(db)
+	mbsys.CreateBucket(context.TODO(), "testbucket", "", "")
+	s.SetNewBucketNSLock(mbsys.NewNSLock)
+	s.SetHasBucket(mbsys.HasBucket)
+	return s, db
+}
+
+func TestStorageSys_ObjectInfoCache(t *testing.T) {
+	s, db := newCacheTestStorageSys(t)
+	ctx := context.TODO()
+	now := time.Now()
+	s.objectInfoCache.now = func() time.Time { return now }
+	storeObject := func(content string) ObjectInfo {
+		r, err := hash.NewReader(strings.NewReader(content), int64(len(content)), "", "", int64(len(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		oi, err := s.StoreObject(ctx, "testbucket", "obj", r, int64(len(content)), map[string]string{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return oi
+	}
+	getObjectInfo := func() (ObjectInfo, uint64) {
+		gets := atomic.LoadUint64(&db.gets)
+		oi, err := s.GetObjectInfo(ctx, "testbucket", "obj", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return oi, atomic.LoadUint64(&db.gets) - gets
+	}
+
+	first := storeObject("123456")
+	if _, reads := getObjectInfo(); reads != 1 {
+		t.Fatalf("expected the first read to read the db, but it read it %d times", reads)
+	}
+	for i := 0; i < 10; i++ {
+		oi, reads := getObjectInfo()
+		if reads != 0 {
+			t.Fatalf("expected the cached object info, but the db was read %d times", reads)
+		}
+		if oi.ETag != first.ETag {
+			t.Fatalf("expected the ETag %s, but instead found %s", first.ETag, oi.ETag)
+		}
+	}
+
+	// the overwrite is seen right away
+	second := storeObject("1234567")
+	oi, reads := getObjectInfo()
+	if reads != 1 || oi.ETag != second.ETag || oi.Size != 7 {
+		t.Fatalf("expected the overwritten object info from the db, but got %+v after %d db reads", oi, reads)
+	}
+	if _, reads = getObjectInfo(); reads != 0 {
+		t.Fatalf("expected the cached object info, but the db was read %d times", reads)
+	}
+
+	// the cached object info expires
+	now = now.Add(defaultObjectInfoCacheTTL)
+	if _, reads = getObjectInfo(); reads != 1 {
+		t.Fatalf("expected the expired object info to be read from the db, but it was read %d times", reads)
+	}
+
+	// the callers can't change the cached object info
+	oi, _ = getObjectInfo()
+	oi.ETag = "changed"
+	if oi, _ = getObjectInfo(); oi.ETag != second.ETag {
+		t.Fatalf("expected the ETag %s, but instead found %s", second.ETag, oi.ETag)
+	}
+
+	if _, err := s.DeleteObject(ctx, "testbucket", "obj", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetObjectInfo(ctx, "testbucket", "obj", ""); err != ErrObjectNotFound {
+		t.Fatalf("expected %v after the delete, but instead found %v", ErrObjectNotFound, err)
+	}
+
+	// an object info read before an invalidation isn't cached
+	gen := s.objectInfoCache.generation()
+	s.objectInfoCache.invalidate("testbucket", "other")
+	s.objectInfoCache.put("testbucket", "obj", first, gen)
+	if _, ok := s.objectInfoCache.get("testbucket", "obj"); ok {
+		t.Fatal("expected the object info read before the invalidation not to be cached")
+	}
+}
+
+func BenchmarkStorageSys_HeadObject(b *testing.B) {
+	for _, bm := range []struct {
+		name string
+		ttl  time.Duration
+	}{
+		{name: "cache", ttl: defaultObjectInfoCacheTTL},
+		{name: "nocache", ttl: 0},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			s, db := newCacheTestStorageSys(b)
+			s.SetObjectInfoCacheTTL(bm.ttl)
+			ctx := context.TODO()
+			content := "123456"
+			r, err := hash.NewReader(strings.NewReader(content), int64(len(content)), "", "", int64(len(content)))
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err = s.StoreObject(ctx, "testbucket", "obj", r, int64(len(content)), map[string]string{}); err != nil {
+				b.Fatal(err)
+			}
+			gets := atomic.LoadUint64(&db.gets)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := s.GetObjectInfo(ctx, "testbucket", "obj", ""); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.ReportMetric(float64(atomic.LoadUint64(&db.gets)-gets)/float64(b.N), "db-reads/op")
+		})
+	}
+}
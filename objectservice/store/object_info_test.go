@@ -0,0 +1,122 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	"testing"
+	"time"
+)
+
+// objectInfoV1Record a record of objectInfoV1, it has no SchemaVersion and IsLatest wasn't set by the v1 writers
+type objectInfoV1Record struct {
+	Bucket      string
+	Name        string
+	ModTime     time.Time
+	Size        int64
+	ETag        string
+	Cid         string
+	ContentType string
+}
+
+// objectInfoV3Record a record of a newer binary, with a field this binary doesn't know
+type objectInfoV3Record struct {
+	SchemaVersion int
+	Bucket        string
+	Name          string
+	Size          int64
+	IsLatest      bool
+	RetainUntil   time.Time
+}
+
+func TestObjectInfo_SchemaVersion(t *testing.T) {
+	s, _ := newTestStorageSys(t)
+	ctx := context.TODO()
+
+	v1 := objectInfoV1Record{
+		Bucket:      "testbucket",
+		Name:        "v1",
+		ModTime:     time.Date(2022, 3, 18, 10, 54, 43, 0, time.UTC),
+		Size:        11604147,
+		ETag:        "a6b0b7ddb4630832ed47821af59aa125",
+		Cid:         "QmRP168AQEN9vz8vnjWdEWiiJbNt4BZ5cB81qSRL5FQfGt",
+		ContentType: "application/x-msdownload",
+	}
+	if err := s.Db.Put(getObjectKey(v1.Bucket, v1.Name), v1); err != nil {
+		t.Fatal(err)
+	}
+	oi, err := s.GetObjectInfo(ctx, v1.Bucket, v1.Name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oi.SchemaVersion != ObjectInfoSchemaVersion || !oi.IsLatest {
+		t.Fatalf("expected the v1 record to be upgraded, but instead found version %d and IsLatest %v", oi.SchemaVersion, oi.IsLatest)
+	}
+	if oi.Name != v1.Name || !oi.ModTime.Equal(v1.ModTime) || oi.Size != v1.Size || oi.ETag != v1.ETag ||
+		oi.Cid != v1.Cid || oi.ContentType != v1.ContentType {
+		t.Fatalf("expected the fields of the v1 record to be kept, but instead found %+v", oi)
+	}
+	if oi.ChecksumSHA256 != "" || !oi.Expires.IsZero() {
+		t.Fatalf("expected the fields added after v1 to be zero, but instead found %+v", oi)
+	}
+
+	v3 := objectInfoV3Record{
+		SchemaVersion: ObjectInfoSchemaVersion + 1,
+		Bucket:        "testbucket",
+		Name:          "v3",
+		Size:          6,
+		RetainUntil:   time.Now(),
+	}
+	if err = s.Db.Put(getObjectKey(v3.Bucket, v3.Name), v3); err != nil {
+		t.Fatal(err)
+	}
+	loi, err := s.ListObjects(ctx, "testbucket", "", "", "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loi.Objects) != 2 {
+		t.Fatalf("expected 2 objects, but instead found %d", len(loi.Objects))
+	}
+	if oi = loi.Objects[1]; oi.Name != v3.Name || oi.Size != v3.Size || oi.SchemaVersion != v3.SchemaVersion || oi.IsLatest {
+		t.Fatalf("expected the newer record to be decoded as it is, but instead found %+v", oi)
+	}
+
+	r, err := hash.NewReader(bytes.NewReader([]byte("123456")), 6, "", "", 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.StoreObject(ctx, "testbucket", "new", r, 6, map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	var written ObjectInfo
+	if err = s.Db.Get(getObjectKey("testbucket", "new"), &written); err != nil {
+		t.Fatal(err)
+	}
+	if written.SchemaVersion != ObjectInfoSchemaVersion {
+		t.Fatalf("expected the record to be written with version %d, but instead found %d", ObjectInfoSchemaVersion, written.SchemaVersion)
+	}
+}
+
+func TestStorageSys_UserDefinedMetadata(t *testing.T) {
+	s, _ := newTestStorageSys(t)
+	ctx := context.TODO()
+	r, err := hash.NewReader(bytes.NewReader([]byte("123456")), 6, "", "", 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := map[string]string{"content-type": "text/plain", "X-Amz-Meta-Filename": "a.txt", "x-amz-meta-empty": ""}
+	if _, err = s.StoreObject(ctx, "testbucket", "obj", r, 6, meta); err != nil {
+		t.Fatal(err)
+	}
+	oi, err := s.GetObjectInfo(ctx, "testbucket", "obj", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only the x-amz-meta- entries are user-defined, by their lowercase keys
+	if len(oi.UserDefined) != 2 || oi.UserDefined["x-amz-meta-filename"] != "a.txt" {
+		t.Fatalf("unexpected user-defined metadata %v", oi.UserDefined)
+	}
+	if v, ok := oi.UserDefined["x-amz-meta-empty"]; !ok || v != "" {
+		t.Fatalf("the empty value must be kept, %v", oi.UserDefined)
+	}
+}
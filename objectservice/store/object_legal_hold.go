@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+// ObjectUnderLegalHold - the object version is under a legal hold, it can't be deleted
+// or overwritten until the hold is removed.
+type ObjectUnderLegalHold struct {
+	Bucket    string
+	Object    string
+	VersionID string
+}
+
+func (e ObjectUnderLegalHold) Error() string {
+	if e.VersionID != "" {
+		return fmt.Sprintf("The version %s of object %s/%s is under a legal hold", e.VersionID, e.Bucket, e.Object)
+	}
+	return fmt.Sprintf("The object %s/%s is under a legal hold", e.Bucket, e.Object)
+}
+
+// PutObjectLegalHold turns the legal hold of the object version on or off, the version is the current object
+// if the version ID is empty. ErrVersionIsDeleteMarker is returned for a delete marker, it has nothing to hold.
+func (s *StorageSys) PutObjectLegalHold(ctx context.Context, bucket, object, versionID string, on bool) (ObjectInfo, error) {
+	object, err := s.objectKey(ctx, bucket, object)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	lk := s.NewNSLock(bucket, object)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	return s.updateObjectVersion(ctx, bucket, object, versionID, func(oi *ObjectInfo) {
+		oi.LegalHold = on
+	})
+}
+
+// checkLegalHold returns ObjectUnderLegalHold if a version under legal hold would be replaced by a new
+// latest version of the object, that's the current object of an unversioned bucket or the null version
+// of a bucket whose versioning is suspended. The versions are kept when the versioning is enabled.
+func (s *StorageSys) checkLegalHold(ctx context.Context, bucket, object string) error {
+	status, err := s.getVersioning(ctx, bucket)
+	if err != nil || status == VersioningEnabled {
+		return err
+	}
+	oi, err := s.getObjectInfo(ctx, bucket, object)
+	if err != nil && err != ErrObjectNotFound {
+		return err
+	}
+	if err == nil && oi.LegalHold && (status == "" || matchVersion(oi.VersionID, NullVersionID)) {
+		return ObjectUnderLegalHold{Bucket: bucket, Object: object}
+	}
+	if status != VersioningSuspended {
+		return nil
+	}
+	versions, err := s.getObjectVersions(bucket, object)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if v.VersionID == NullVersionID && v.LegalHold {
+			return ObjectUnderLegalHold{Bucket: bucket, Object: object, VersionID: NullVersionID}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	"strings"
+	"testing"
+)
+
+func TestStorageSys_ObjectLegalHold(t *testing.T) {
+	s, mbsys := newTestStorageSys(t)
+	ctx := context.TODO()
+	storeObject := func(content string) (ObjectInfo, error) {
+		r, err := hash.NewReader(strings.NewReader(content), int64(len(content)), "", "", int64(len(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.StoreObject(ctx, "testbucket", "obj", r, int64(len(content)), map[string]string{})
+	}
+
+	if _, err := storeObject("held"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.PutObjectLegalHold(ctx, "testbucket", "obj", "", true); err != nil {
+		t.Fatal(err)
+	}
+	oi, err := s.GetObjectInfo(ctx, "testbucket", "obj", "")
+	if err != nil || !oi.LegalHold {
+		t.Fatalf("the object must be under legal hold, %v", err)
+	}
+	// the held object resists deletion and overwrites
+	if _, err = s.DeleteObject(ctx, "testbucket", "obj", ""); err != (ObjectUnderLegalHold{Bucket: "testbucket", Object: "obj"}) {
+		t.Fatalf("the held object must not be deleted, %v", err)
+	}
+	if _, err = storeObject("overwrite"); err != (ObjectUnderLegalHold{Bucket: "testbucket", Object: "obj"}) {
+		t.Fatalf("the held object must not be overwritten, %v", err)
+	}
+	if _, err = s.CopyObject(ctx, "testbucket", "obj", "", "testbucket", "obj", map[string]string{}); err == nil {
+		t.Fatal("the held object must not be replaced by a copy")
+	}
+
+	// the object becomes deletable after the hold clears
+	if _, err = s.PutObjectLegalHold(ctx, "testbucket", "obj", "", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.DeleteObject(ctx, "testbucket", "obj", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.GetObjectInfo(ctx, "testbucket", "obj", ""); err != ErrObjectNotFound {
+		t.Fatalf("the object must be deleted, %v", err)
+	}
+
+	// a held version of a versioned bucket can be hidden behind a delete marker but not removed
+	if err = mbsys.UpdateBucketVersioning(ctx, "testbucket", VersioningEnabled); err != nil {
+		t.Fatal(err)
+	}
+	v1, err := storeObject("v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.PutObjectLegalHold(ctx, "testbucket", "obj", v1.VersionID, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = storeObject("v2"); err != nil {
+		t.Fatalf("a new version must be stored beside the held one, %v", err)
+	}
+	if _, err = s.DeleteObject(ctx, "testbucket", "obj", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.DeleteObject(ctx, "testbucket", "obj", v1.VersionID); err == nil {
+		t.Fatal("the held version must not be removed")
+	}
+	// nor purged with the versions of the bucket
+	if err = s.CleanObjectsInBucket(ctx, "testbucket"); err != (ObjectUnderLegalHold{Bucket: "testbucket", Object: "obj", VersionID: v1.VersionID}) {
+		t.Fatalf("the held version must not be purged, %v", err)
+	}
+	if _, err = s.GetObjectInfo(ctx, "testbucket", "obj", v1.VersionID); err != nil {
+		t.Fatalf("the held version must be kept, %v", err)
+	}
+	if _, err = s.PutObjectLegalHold(ctx, "testbucket", "obj", v1.VersionID, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.DeleteObject(ctx, "testbucket", "obj", v1.VersionID); err != nil {
+		t.Fatal(err)
+	}
+}
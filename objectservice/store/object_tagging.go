@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+)
+
+// PutObjectTags replaces the tags of the object version, the version is the current object if the version ID
+// is empty, nil tags remove them. Only the metadata record of the version is rewritten, the dag of the object
+// isn't touched. InvalidTag is returned if the tags break the limits of the object tags.
+func (s *StorageSys) PutObjectTags(ctx context.Context, bucket, object, versionID string, tags map[string]string) (ObjectInfo, error) {
+	if err := checkTags(tags, maxObjectTagCount); err != nil {
+		return ObjectInfo{}, err
+	}
+	if len(tags) == 0 {
+		tags = nil
+	}
+	object, err := s.objectKey(ctx, bucket, object)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	lk := s.NewNSLock(bucket, object)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	return s.updateObjectVersion(ctx, bucket, object, versionID, func(oi *ObjectInfo) {
+		oi.Tags = tags
+	})
+}
+
+// GetObjectTags returns the tags of the object version and its version ID, the version is the current
+// object if the version ID is empty. The tags are nil if the version has none.
+func (s *StorageSys) GetObjectTags(ctx context.Context, bucket, object, versionID string) (map[string]string, string, error) {
+	oi, err := s.GetObjectInfo(ctx, bucket, object, versionID)
+	if err != nil {
+		return nil, "", err
+	}
+	return oi.Tags, oi.VersionID, nil
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStorageSys_ObjectTags(t *testing.T) {
+	s, mbsys := newTestStorageSys(t)
+	ctx := context.TODO()
+	storeObject := func(content string) ObjectInfo {
+		r, err := hash.NewReader(strings.NewReader(content), int64(len(content)), "", "", int64(len(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		oi, err := s.StoreObject(ctx, "testbucket", "obj", r, int64(len(content)), map[string]string{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return oi
+	}
+	getTags := func(versionID string) map[string]string {
+		tags, _, err := s.GetObjectTags(ctx, "testbucket", "obj", versionID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tags
+	}
+
+	stored := storeObject("tagged")
+	if tags := getTags(""); tags != nil {
+		t.Fatalf("a new object has no tags, got %v", tags)
+	}
+	tags := map[string]string{"project": "filedag", "env": "test"}
+	if _, err := s.PutObjectTags(ctx, "testbucket", "obj", "", tags); err != nil {
+		t.Fatal(err)
+	}
+	if got := getTags(""); !reflect.DeepEqual(got, tags) {
+		t.Fatalf("the tags %v are not %v", got, tags)
+	}
+	// the tags are only in the metadata record, the dag of the object is the same
+	oi, err := s.GetObjectInfo(ctx, "testbucket", "obj", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oi.Cid != stored.Cid || oi.ETag != stored.ETag || !reflect.DeepEqual(oi.Tags, tags) {
+		t.Fatalf("the object %v changed by the tags", oi)
+	}
+
+	// the tags breaking the limits are rejected and the stored tags are kept
+	tooMany := make(map[string]string)
+	for _, key := range strings.Split("a b c d e f g h i j k", " ") {
+		tooMany[key] = "v"
+	}
+	for _, invalid := range []map[string]string{tooMany, {"": "v"}, {strings.Repeat("k", 129): "v"}, {"k": strings.Repeat("v", 257)}} {
+		if _, err = s.PutObjectTags(ctx, "testbucket", "obj", "", invalid); err == nil {
+			t.Fatal("the invalid tags must be rejected")
+		} else if _, ok := err.(InvalidTag); !ok {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	if got := getTags(""); !reflect.DeepEqual(got, tags) {
+		t.Fatalf("the tags %v are not kept", got)
+	}
+
+	// an overwrite drops the tags, the tags of a noncurrent version are put by its version ID
+	if err = mbsys.UpdateBucketVersioning(ctx, "testbucket", VersioningEnabled); err != nil {
+		t.Fatal(err)
+	}
+	v1 := storeObject("v1")
+	v2 := storeObject("v2")
+	if got := getTags(""); got != nil {
+		t.Fatalf("the overwrite must drop the tags, got %v", got)
+	}
+	if _, err = s.PutObjectTags(ctx, "testbucket", "obj", v1.VersionID, map[string]string{"version": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := getTags(v1.VersionID); !reflect.DeepEqual(got, map[string]string{"version": "1"}) {
+		t.Fatalf("the tags of the noncurrent version are %v", got)
+	}
+	if got := getTags(v2.VersionID); got != nil {
+		t.Fatalf("the tags of the current version are %v", got)
+	}
+	if _, err = s.PutObjectTags(ctx, "testbucket", "obj", "nosuchversion", tags); err != ErrObjectVersionNotFound {
+		t.Fatalf("the missing version must be reported, %v", err)
+	}
+
+	// nil tags remove them
+	if _, err = s.PutObjectTags(ctx, "testbucket", "obj", v1.VersionID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := getTags(v1.VersionID); got != nil {
+		t.Fatalf("the tags must be removed, got %v", got)
+	}
+}
@@ -0,0 +1,26 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// TouchObject sets the last modified time of the current object to now without rewriting it, the dag,
+// the ETag and the metadata are kept. The lifecycle expiry of the object is counted from the new time.
+func (s *StorageSys) TouchObject(ctx context.Context, bucket, object string) (ObjectInfo, error) {
+	object, err := s.objectKey(ctx, bucket, object)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	lk := s.NewNSLock(bucket, object)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	return s.updateObjectVersion(ctx, bucket, object, "", func(oi *ObjectInfo) {
+		oi.ModTime = time.Now().UTC()
+	})
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStorageSys_TouchObject(t *testing.T) {
+	s, _ := newTestStorageSys(t)
+	ctx := context.TODO()
+	content := "touched"
+	r, err := hash.NewReader(strings.NewReader(content), int64(len(content)), "", "", int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := s.StoreObject(ctx, "testbucket", "obj", r, int64(len(content)), map[string]string{"x-amz-meta-key": "value"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.PutObjectTags(ctx, "testbucket", "obj", "", map[string]string{"k": "v"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the object was stored days ago
+	lk := s.NewNSLock("testbucket", "obj")
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err = s.updateObjectVersion(lkctx.Context(), "testbucket", "obj", "", func(oi *ObjectInfo) {
+		oi.ModTime = oi.ModTime.Add(-72 * time.Hour)
+	})
+	lk.Unlock(lkctx.Cancel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lc := &Lifecycle{Rules: []LifecycleRule{{ID: "rule", Status: LifecycleEnabled, Expiration: &LifecycleExpiration{Days: 1}}}}
+	if !lc.expired(stored, time.Now().UTC()) {
+		t.Fatal("the object stored days ago must expire")
+	}
+
+	touched, err := s.TouchObject(ctx, "testbucket", "obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !touched.ModTime.After(stored.ModTime) {
+		t.Fatalf("the modification time %v isn't after %v", touched.ModTime, stored.ModTime)
+	}
+	oi, reader, err := s.GetObject(ctx, "testbucket", "obj", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Fatalf("the content %q changed", data)
+	}
+	if !oi.ModTime.Equal(touched.ModTime) || oi.ETag != stored.ETag || oi.Cid != stored.Cid {
+		t.Fatalf("unexpected object info %+v after touching %+v", oi, stored)
+	}
+	if oi.UserDefined["x-amz-meta-key"] != "value" || oi.Tags["k"] != "v" {
+		t.Fatalf("the metadata %v or the tags %v are lost", oi.UserDefined, oi.Tags)
+	}
+
+	// the lifecycle expiry is counted from the touch
+	if lc.expired(oi, time.Now().UTC()) {
+		t.Fatal("the touched object must not expire")
+	}
+
+	if _, err = s.TouchObject(ctx, "testbucket", "missing"); err != ErrObjectNotFound {
+		t.Fatalf("touch a missing object err: %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+	"time"
+)
+
+const (
+	trashKeyFormat        = "trash/%s/%s"
+	allTrashPrefixFormat  = "trash/%s/%s"
+	allTrashBucketsPrefix = "trash/"
+)
+
+var ErrTrashedObjectNotFound = errors.New("trashed object not found")
+var ErrObjectAlreadyExists = errors.New("object already exists")
+
+// TrashedObject - an object deleted in the soft delete mode, it can be restored until
+// the trash retention has passed since it was deleted
+type TrashedObject struct {
+	Object    ObjectInfo
+	DeletedAt time.Time
+}
+
+func getTrashKey(bucket, object string) string {
+	return fmt.Sprintf(trashKeyFormat, bucket, object)
+}
+
+// SetTrashRetention enables the soft delete mode, a deleted object is kept in the trash for the retention
+// before its dag is removed, 0 removes the deleted objects right away. The objects already in the trash
+// expire by the current retention. It must be called before the StorageSys is used.
+func (s *StorageSys) SetTrashRetention(retention time.Duration) {
+	s.trashRetention = retention
+}
+
+// trashObject adds the move of the object info to the trash to the batch, the caller holds the object lock.
+// The object deleted before in the same key is removed for good.
+func (s *StorageSys) trashObject(batch metadb.Batch, bucket, object string, meta ObjectInfo) error {
+	key := getTrashKey(bucket, object)
+	var old TrashedObject
+	err := s.Db.Get(key, &old)
+	if err != nil && !xerrors.Is(err, metadb.ErrNotFound) {
+		return err
+	}
+	batchDeleteCidTrashRef(batch, old.Object.Cid, bucket, object)
+	if err = batch.Put(key, TrashedObject{Object: meta, DeletedAt: time.Now().UTC()}); err != nil {
+		return err
+	}
+	if err = batchPutCidTrashRef(batch, meta.Cid, bucket, object); err != nil {
+		return err
+	}
+	if old.Object.Cid != "" && old.Object.Cid != meta.Cid {
+		return batchMarkTrashedObjectToDelete(batch, old)
+	}
+	return nil
+}
+
+// batchMarkTrashedObjectToDelete adds the delete mark of the dag of the trashed object to the batch
+func batchMarkTrashedObjectToDelete(batch metadb.Batch, trashed TrashedObject) error {
+	c, err := cid.Decode(trashed.Object.Cid)
+	if err != nil {
+		log.Warnw("decode cid error", "cid", trashed.Object.Cid)
+		return nil
+	}
+	return batchMarkObjetToDelete(batch, c)
+}
+
+// ListTrash lists the deleted objects of the bucket whose keys begin with the prefix
+func (s *StorageSys) ListTrash(ctx context.Context, bucket, prefix string) ([]TrashedObject, error) {
+	prefix, err := s.objectKey(ctx, bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	all, err := s.Db.ReadAllChan(ctx, fmt.Sprintf(allTrashPrefixFormat, bucket, prefix), "")
+	if err != nil {
+		return nil, err
+	}
+	trashed := make([]TrashedObject, 0)
+	for entry := range all {
+		var t TrashedObject
+		if err = entry.UnmarshalValue(&t); err != nil {
+			return nil, err
+		}
+		t.Object.upgrade()
+		trashed = append(trashed, t)
+	}
+	return trashed, nil
+}
+
+// UndeleteObject restores the deleted object from the trash, it fails with ErrObjectAlreadyExists
+// if an object was stored in the same key since.
+func (s *StorageSys) UndeleteObject(ctx context.Context, bucket, object string) (ObjectInfo, error) {
+	object, err := s.objectKey(ctx, bucket, object)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	bktlk := s.newBucketNSLock(bucket)
+	bktlkCtx, err := bktlk.GetRLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	ctx = bktlkCtx.Context()
+	defer bktlk.RUnlock(bktlkCtx.Cancel)
+
+	if !s.hasBucket(ctx, bucket) {
+		return ObjectInfo{}, BucketNotFound{Bucket: bucket}
+	}
+
+	lk := s.NewNSLock(bucket, object)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	key := getTrashKey(bucket, object)
+	var trashed TrashedObject
+	if err = s.Db.Get(key, &trashed); err != nil {
+		if xerrors.Is(err, metadb.ErrNotFound) {
+			return ObjectInfo{}, ErrTrashedObjectNotFound
+		}
+		return ObjectInfo{}, err
+	}
+	exists, err := s.objectExists(ctx, bucket, object)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if exists {
+		return ObjectInfo{}, ErrObjectAlreadyExists
+	}
+	trashed.Object.upgrade()
+	batch := s.Db.NewBatch()
+	if err = batchPutObjectInfo(batch, bucket, object, trashed.Object); err != nil {
+		return ObjectInfo{}, err
+	}
+	batch.Delete(key)
+	batchDeleteCidTrashRef(batch, trashed.Object.Cid, bucket, object)
+	if err = s.writeObjectBatch(bucket, object, batch); err != nil {
+		return ObjectInfo{}, err
+	}
+	s.objectFilters.added(bucket, object)
+	return trashed.Object, nil
+}
+
+// purgeTrash removes the objects which have been in the trash for the retention, their dags are
+// removed by the object GC
+func (s *StorageSys) purgeTrash(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.gcTimeout)
+	defer cancel()
+
+	all, err := s.Db.ReadAllChan(ctx, allTrashBucketsPrefix, "")
+	if err != nil {
+		return err
+	}
+	for entry := range all {
+		var t TrashedObject
+		if err = entry.UnmarshalValue(&t); err != nil {
+			return err
+		}
+		if time.Since(t.DeletedAt) < s.trashRetention {
+			continue
+		}
+		if err = s.purgeTrashedObject(ctx, t.Object.Bucket, t.Object.Name); err != nil {
+			log.Errorw("purge trashed object error", "bucket", t.Object.Bucket, "object", t.Object.Name, "error", err)
+		}
+	}
+	return nil
+}
+
+// purgeTrashedObject removes the trashed object if it's still expired, it may have been restored
+// or deleted again since it was listed
+func (s *StorageSys) purgeTrashedObject(ctx context.Context, bucket, object string) error {
+	lk := s.NewNSLock(bucket, object)
+	lkctx, err := lk.GetLock(ctx, deleteOperationTimeout)
+	if err != nil {
+		return err
+	}
+	defer lk.Unlock(lkctx.Cancel)
+
+	key := getTrashKey(bucket, object)
+	var t TrashedObject
+	if err = s.Db.Get(key, &t); err != nil {
+		if xerrors.Is(err, metadb.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if time.Since(t.DeletedAt) < s.trashRetention {
+		return nil
+	}
+	batch := s.Db.NewBatch()
+	batch.Delete(key)
+	batchDeleteCidTrashRef(batch, t.Object.Cid, bucket, object)
+	if err = batchMarkTrashedObjectToDelete(batch, t); err != nil {
+		return err
+	}
+	return s.Db.Write(batch)
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStorageSys_SoftDelete(t *testing.T) {
+	s, _ := newTestStorageSys(t)
+	s.SetTrashRetention(time.Hour)
+	ctx := context.TODO()
+	storeObject := func(content string) ObjectInfo {
+		r, err := hash.NewReader(strings.NewReader(content), int64(len(content)), "", "", int64(len(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		oi, err := s.StoreObject(ctx, "testbucket", "obj", r, int64(len(content)), map[string]string{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return oi
+	}
+	deleteMarks := func() int {
+		all, err := s.Db.ReadAllChan(ctx, allDeletePrefixFormat, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for range all {
+			n++
+		}
+		return n
+	}
+
+	// delete then undelete within the retention
+	stored := storeObject("123456")
+	if _, err := s.DeleteObject(ctx, "testbucket", "obj", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetObjectInfo(ctx, "testbucket", "obj", ""); err != ErrObjectNotFound {
+		t.Fatalf("expected %v after the delete, but instead found %v", ErrObjectNotFound, err)
+	}
+	if n := deleteMarks(); n != 0 {
+		t.Fatalf("expected the dag of the trashed object to be kept, but %d dags are marked to delete", n)
+	}
+	trashed, err := s.ListTrash(ctx, "testbucket", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trashed) != 1 || trashed[0].Object.Name != "obj" || trashed[0].Object.ETag != stored.ETag {
+		t.Fatalf("unexpected trash %+v", trashed)
+	}
+	if err = s.purgeTrash(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.UndeleteObject(ctx, "testbucket", "obj"); err != nil {
+		t.Fatalf("undelete within the retention err: %v", err)
+	}
+	_, reader, err := s.GetObject(ctx, "testbucket", "obj", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadAll(reader)
+	reader.Close()
+	if err != nil || string(content) != "123456" {
+		t.Fatalf("expected the restored content 123456, but got %q, err %v", content, err)
+	}
+	if trashed, _ = s.ListTrash(ctx, "testbucket", ""); len(trashed) != 0 {
+		t.Fatalf("expected the restored object to leave the trash, but found %+v", trashed)
+	}
+
+	// the object stored since isn't overwritten
+	if _, err = s.DeleteObject(ctx, "testbucket", "obj", ""); err != nil {
+		t.Fatal(err)
+	}
+	storeObject("1234567")
+	if _, err = s.UndeleteObject(ctx, "testbucket", "obj"); err != ErrObjectAlreadyExists {
+		t.Fatalf("expected %v, but instead found %v", ErrObjectAlreadyExists, err)
+	}
+
+	// the object is removed for good after the retention
+	if _, err = s.DeleteObject(ctx, "testbucket", "obj", ""); err != nil {
+		t.Fatal(err)
+	}
+	// the dag of the object deleted before in the same key is removed
+	if n := deleteMarks(); n != 1 {
+		t.Fatalf("expected the replaced trashed object to be marked to delete, but %d dags are marked", n)
+	}
+	s.SetTrashRetention(time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	if err = s.purgeTrash(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.UndeleteObject(ctx, "testbucket", "obj"); err != ErrTrashedObjectNotFound {
+		t.Fatalf("expected %v after the retention, but instead found %v", ErrTrashedObjectNotFound, err)
+	}
+	if n := deleteMarks(); n != 2 {
+		t.Fatalf("expected the purged object to be marked to delete, but %d dags are marked", n)
+	}
+}
@@ -0,0 +1,570 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+	"strings"
+	"time"
+)
+
+// The current object of a key is kept as the other objects in obj/, so that the listings and the lookups of
+// the objects ignore the versions. The other versions of the key, noncurrent versions and delete markers, are
+// kept newest first in a single record of objver/. The first of them is the latest version of the key if it's
+// a delete marker, the key has no current object then.
+const (
+	versionsKeyFormat       = "objver/%s/%s"
+	allVersionsPrefixFormat = "objver/%s/%s"
+
+	// NullVersionID the version ID of an object stored while the versioning of the bucket isn't enabled
+	NullVersionID = "null"
+)
+
+var ErrObjectVersionNotFound = errors.New("object version not found")
+var ErrVersionIsDeleteMarker = errors.New("the object version is a delete marker")
+
+// AppendVersionedObject - the object of a versioned bucket can't be appended to, the appended object reuses
+// the blocks of the object and can't be a new version beside it.
+type AppendVersionedObject struct {
+	Bucket string
+	Object string
+}
+
+func (e AppendVersionedObject) Error() string {
+	return fmt.Sprintf("The object %s/%s of a versioned bucket can't be appended to", e.Bucket, e.Object)
+}
+
+// objectVersions the versions of an object other than its current object, newest first
+type objectVersions struct {
+	Versions []ObjectInfo
+}
+
+func getVersionsKey(bucket, object string) string {
+	return fmt.Sprintf(versionsKeyFormat, bucket, object)
+}
+
+// SetVersioning sets the function returning the versioning status of a bucket,
+// the objects are never versioned if it isn't set
+func (s *StorageSys) SetVersioning(versioning func(ctx context.Context, bucket string) (string, error)) {
+	s.versioning = versioning
+}
+
+func (s *StorageSys) getVersioning(ctx context.Context, bucket string) (string, error) {
+	if s.versioning == nil {
+		return "", nil
+	}
+	return s.versioning(ctx, bucket)
+}
+
+// newVersionID returns the version ID of an object stored in the bucket of the versioning status
+func newVersionID(status string) string {
+	if status == VersioningEnabled {
+		return mustGetUUID()
+	}
+	return NullVersionID
+}
+
+// matchVersion reports whether the version ID is the requested one, the objects stored before
+// the versioning was enabled have no version ID and are the null version
+func matchVersion(versionID, requested string) bool {
+	return versionID == requested || (versionID == "" && requested == NullVersionID)
+}
+
+// getObjectVersions returns the versions of the object other than its current object
+func (s *StorageSys) getObjectVersions(bucket, object string) ([]ObjectInfo, error) {
+	var ov objectVersions
+	if err := s.Db.Get(getVersionsKey(bucket, object), &ov); err != nil {
+		if xerrors.Is(err, metadb.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for i := range ov.Versions {
+		ov.Versions[i].upgrade()
+	}
+	return ov.Versions, nil
+}
+
+// batchPutObjectVersions adds the write of the versions of the object other than its current object to the batch,
+// the references of the versions to their root cids are updated along. The caller holds the object lock.
+func (s *StorageSys) batchPutObjectVersions(batch metadb.Batch, bucket, object string, versions []ObjectInfo) error {
+	old, err := s.getObjectVersions(bucket, object)
+	if err != nil {
+		return err
+	}
+	if err = batchPutCidVersionRefs(batch, bucket, object, old, versions); err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		batch.Delete(getVersionsKey(bucket, object))
+		return nil
+	}
+	for i := range versions {
+		versions[i].SchemaVersion = ObjectInfoSchemaVersion
+		versions[i].IsLatest = false
+	}
+	return batch.Put(getVersionsKey(bucket, object), objectVersions{Versions: versions})
+}
+
+// batchMarkVersionToDelete adds the delete mark of the dag of the version to the batch, a delete marker has none
+func batchMarkVersionToDelete(batch metadb.Batch, version ObjectInfo) error {
+	if version.DeleteMarker {
+		return nil
+	}
+	c, err := cid.Decode(version.Cid)
+	if err != nil {
+		log.Warnw("decode cid error", "cid", version.Cid)
+		return nil
+	}
+	return batchMarkObjetToDelete(batch, c)
+}
+
+// batchArchiveObject adds the move of the current object to the noncurrent versions to the batch, the caller
+// holds the object lock and adds the new latest version. When the versioning is suspended the null version is
+// replaced by the new latest version, its data is marked to delete. archived is false if there's no current object.
+func (s *StorageSys) batchArchiveObject(ctx context.Context, batch metadb.Batch, bucket, object, status string) (versions []ObjectInfo, archived bool, err error) {
+	old, err := s.getObjectVersions(bucket, object)
+	if err != nil {
+		return nil, false, err
+	}
+	oi, err := s.getObjectInfo(ctx, bucket, object)
+	if err != nil && err != ErrObjectNotFound {
+		return nil, false, err
+	}
+	if err == nil {
+		archived = true
+		batchDeleteCidRef(batch, oi.Cid, bucket, object)
+		batch.Delete(getObjectKey(bucket, object))
+		if oi.VersionID == "" {
+			oi.VersionID = NullVersionID
+		}
+		oi.SuccessorModTime = time.Now().UTC()
+		old = append([]ObjectInfo{oi}, old...)
+	}
+	versions = make([]ObjectInfo, 0, len(old)+1)
+	for _, v := range old {
+		if status == VersioningSuspended && v.VersionID == NullVersionID {
+			if err = batchMarkVersionToDelete(batch, v); err != nil {
+				return nil, false, err
+			}
+			continue
+		}
+		versions = append(versions, v)
+	}
+	return versions, archived, nil
+}
+
+// batchPutLatestVersion adds the write of the object info as the current object to the batch, the caller holds
+// the object lock. The object replaced is kept as a noncurrent version if the bucket is versioned, otherwise
+// its data is marked to delete. The version ID of the object info is set by the versioning of the bucket.
+func (s *StorageSys) batchPutLatestVersion(ctx context.Context, batch metadb.Batch, bucket, object string, objInfo *ObjectInfo) error {
+	status, err := s.getVersioning(ctx, bucket)
+	if err != nil {
+		return err
+	}
+	if status == "" {
+		objInfo.VersionID = ""
+		if err = s.checkAndDeleteObjectData(ctx, batch, bucket, object); err != nil {
+			return err
+		}
+		return batchPutObjectInfo(batch, bucket, object, *objInfo)
+	}
+	versions, _, err := s.batchArchiveObject(ctx, batch, bucket, object, status)
+	if err != nil {
+		return err
+	}
+	if err = s.batchPutObjectVersions(batch, bucket, object, versions); err != nil {
+		return err
+	}
+	objInfo.VersionID = newVersionID(status)
+	return batchPutObjectInfo(batch, bucket, object, *objInfo)
+}
+
+// getObjectVersion returns the version of the object, the current object if the version ID is empty.
+// ErrVersionIsDeleteMarker is returned for a delete marker, it has no content or metadata to read.
+func (s *StorageSys) getObjectVersion(ctx context.Context, bucket, object, versionID string) (ObjectInfo, error) {
+	if versionID == "" {
+		return s.getObjectInfo(ctx, bucket, object)
+	}
+	oi, err := s.getObjectInfo(ctx, bucket, object)
+	if err != nil && err != ErrObjectNotFound {
+		return ObjectInfo{}, err
+	}
+	current := err == nil
+	if current && matchVersion(oi.VersionID, versionID) {
+		return oi, nil
+	}
+	versions, err := s.getObjectVersions(bucket, object)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	for i, v := range versions {
+		if !matchVersion(v.VersionID, versionID) {
+			continue
+		}
+		if v.DeleteMarker {
+			return ObjectInfo{}, ErrVersionIsDeleteMarker
+		}
+		v.IsLatest = i == 0 && !current
+		return v, nil
+	}
+	return ObjectInfo{}, ErrObjectVersionNotFound
+}
+
+// GetDeleteMarker returns the delete marker a read of the object version ran into, the latest version of the
+// object if the version ID is empty. ok is false if the version isn't a delete marker.
+func (s *StorageSys) GetDeleteMarker(ctx context.Context, bucket, object, versionID string) (marker ObjectInfo, ok bool, err error) {
+	object, err = s.objectKey(ctx, bucket, object)
+	if err != nil {
+		return ObjectInfo{}, false, err
+	}
+	lk := s.NewNSLock(bucket, object)
+	lkctx, err := lk.GetRLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return ObjectInfo{}, false, err
+	}
+	ctx = lkctx.Context()
+	defer lk.RUnlock(lkctx.Cancel)
+
+	_, err = s.getObjectInfo(ctx, bucket, object)
+	if err != nil && err != ErrObjectNotFound {
+		return ObjectInfo{}, false, err
+	}
+	current := err == nil
+	if current && versionID == "" {
+		return ObjectInfo{}, false, nil
+	}
+	versions, err := s.getObjectVersions(bucket, object)
+	if err != nil {
+		return ObjectInfo{}, false, err
+	}
+	for i, v := range versions {
+		if (versionID == "" && i == 0) || (versionID != "" && matchVersion(v.VersionID, versionID)) {
+			if !v.DeleteMarker {
+				return ObjectInfo{}, false, nil
+			}
+			v.IsLatest = i == 0 && !current
+			return v, true, nil
+		}
+	}
+	return ObjectInfo{}, false, nil
+}
+
+// updateObjectVersion updates the metadata of the object version, the current object if the version ID
+// is empty, and returns the version updated. The dag of the object isn't touched. ErrVersionIsDeleteMarker
+// is returned for a delete marker, it has no metadata to update. The caller holds the object lock.
+func (s *StorageSys) updateObjectVersion(ctx context.Context, bucket, object, versionID string, update func(oi *ObjectInfo)) (ObjectInfo, error) {
+	oi, err := s.getObjectInfo(ctx, bucket, object)
+	if err != nil && (err != ErrObjectNotFound || versionID == "") {
+		return ObjectInfo{}, err
+	}
+	batch := s.Db.NewBatch()
+	if err == nil && (versionID == "" || matchVersion(oi.VersionID, versionID)) {
+		update(&oi)
+		if err = batchPutObjectInfo(batch, bucket, object, oi); err != nil {
+			return ObjectInfo{}, err
+		}
+		return oi, s.writeObjectBatch(bucket, object, batch)
+	}
+
+	versions, err := s.getObjectVersions(bucket, object)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	for i, v := range versions {
+		if !matchVersion(v.VersionID, versionID) {
+			continue
+		}
+		if v.DeleteMarker {
+			return ObjectInfo{}, ErrVersionIsDeleteMarker
+		}
+		update(&versions[i])
+		if err = s.batchPutObjectVersions(batch, bucket, object, versions); err != nil {
+			return ObjectInfo{}, err
+		}
+		return versions[i], s.writeObjectBatch(bucket, object, batch)
+	}
+	return ObjectInfo{}, ErrObjectVersionNotFound
+}
+
+// deleteObjectMarker hides the object behind a delete marker which becomes its latest version, the current
+// object is kept as a noncurrent version. The caller holds the object lock.
+func (s *StorageSys) deleteObjectMarker(ctx context.Context, bucket, object, status string) (ObjectInfo, error) {
+	batch := s.Db.NewBatch()
+	versions, archived, err := s.batchArchiveObject(ctx, batch, bucket, object, status)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	marker := ObjectInfo{
+		Bucket:       bucket,
+		Name:         object,
+		ModTime:      time.Now().UTC(),
+		VersionID:    newVersionID(status),
+		DeleteMarker: true,
+	}
+	if err = s.batchPutObjectVersions(batch, bucket, object, append([]ObjectInfo{marker}, versions...)); err != nil {
+		return ObjectInfo{}, err
+	}
+	if err = s.writeObjectBatch(bucket, object, batch); err != nil {
+		return ObjectInfo{}, err
+	}
+	if archived {
+		s.objectFilters.deleted(bucket, object)
+	}
+	marker.IsLatest = true
+	return marker, nil
+}
+
+// deleteObjectVersion removes the version of the object for good, the data of the version is marked to delete.
+// When the latest version is removed the newest remaining version becomes the latest, it's the current object
+// unless it's a delete marker. The caller holds the object lock.
+func (s *StorageSys) deleteObjectVersion(ctx context.Context, bucket, object, versionID string) (ObjectInfo, error) {
+	versions, err := s.getObjectVersions(bucket, object)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	oi, err := s.getObjectInfo(ctx, bucket, object)
+	if err != nil && err != ErrObjectNotFound {
+		return ObjectInfo{}, err
+	}
+	current := err == nil
+
+	batch := s.Db.NewBatch()
+	var deleted ObjectInfo
+	if current && matchVersion(oi.VersionID, versionID) {
+		deleted = oi
+		batchDeleteCidRef(batch, oi.Cid, bucket, object)
+		batch.Delete(getObjectKey(bucket, object))
+		current = false
+	} else {
+		i := 0
+		for ; i < len(versions) && !matchVersion(versions[i].VersionID, versionID); i++ {
+		}
+		if i == len(versions) {
+			return ObjectInfo{}, ErrObjectVersionNotFound
+		}
+		deleted = versions[i]
+		versions = append(versions[:i:i], versions[i+1:]...)
+	}
+	if deleted.LegalHold {
+		return ObjectInfo{}, ObjectUnderLegalHold{Bucket: bucket, Object: object, VersionID: versionID}
+	}
+	if err = batchMarkVersionToDelete(batch, deleted); err != nil {
+		return ObjectInfo{}, err
+	}
+	promoted := !current && len(versions) > 0 && !versions[0].DeleteMarker
+	if promoted {
+		latest := versions[0]
+		versions = versions[1:]
+		latest.IsLatest = true
+		if err = batchPutObjectInfo(batch, bucket, object, latest); err != nil {
+			return ObjectInfo{}, err
+		}
+	}
+	if err = s.batchPutObjectVersions(batch, bucket, object, versions); err != nil {
+		return ObjectInfo{}, err
+	}
+	if err = s.writeObjectBatch(bucket, object, batch); err != nil {
+		return ObjectInfo{}, err
+	}
+	if promoted {
+		s.objectFilters.added(bucket, object)
+	} else if !current {
+		s.objectFilters.deleted(bucket, object)
+	}
+	return deleted, nil
+}
+
+// purgeObjectVersions removes the noncurrent versions and the delete markers of the object,
+// their data is marked to delete. ObjectUnderLegalHold is returned and nothing is removed if
+// a version is under a legal hold.
+func (s *StorageSys) purgeObjectVersions(ctx context.Context, bucket, object string) error {
+	lk := s.NewNSLock(bucket, object)
+	lkctx, err := lk.GetLock(ctx, deleteOperationTimeout)
+	if err != nil {
+		return err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	versions, err := s.getObjectVersions(bucket, object)
+	if err != nil {
+		return err
+	}
+	batch := s.Db.NewBatch()
+	for _, v := range versions {
+		if v.LegalHold {
+			return ObjectUnderLegalHold{Bucket: bucket, Object: object, VersionID: v.VersionID}
+		}
+		if err = batchMarkVersionToDelete(batch, v); err != nil {
+			return err
+		}
+	}
+	if err = s.batchPutObjectVersions(batch, bucket, object, nil); err != nil {
+		return err
+	}
+	return s.writeObjectBatch(bucket, object, batch)
+}
+
+// ListObjectVersionsInfo - container for list object versions.
+type ListObjectVersionsInfo struct {
+	// Indicates whether the returned list is truncated, the listing goes on
+	// with NextMarker and NextVersionIDMarker as the markers.
+	IsTruncated bool
+
+	// The key of the last version or the last common prefix listed when the list is truncated.
+	NextMarker string
+
+	// The version ID of the last version listed when the list is truncated,
+	// empty if the list ends with a common prefix.
+	NextVersionIDMarker string
+
+	// List of the versions, newest first for each key, including the delete markers.
+	Objects []ObjectInfo
+
+	// List of prefixes for this request.
+	Prefixes []string
+}
+
+// ListObjectVersions lists the versions of the objects in the key order, the versions of a key newest first.
+// The listing resumes after the version of the key marker and version ID marker, or after every version of
+// the key marker if the version ID marker is empty. Like ListObjects, the keys sharing the part up to the
+// delimiter after the prefix are rolled up into a common prefix, which counts as a version against the max keys.
+func (s *StorageSys) ListObjectVersions(ctx context.Context, bucket, prefix, keyMarker, versionIDMarker, delimiter string, maxKeys int) (loi ListObjectVersionsInfo, err error) {
+	prefix, err = s.objectKey(ctx, bucket, prefix)
+	if err != nil {
+		return loi, err
+	}
+	keyMarker, err = s.objectKey(ctx, bucket, keyMarker)
+	if err != nil {
+		return loi, err
+	}
+	if maxKeys == 0 {
+		return loi, nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	snap, err := s.Db.GetSnapshot()
+	if err != nil {
+		return loi, err
+	}
+	defer snap.Release()
+
+	objSeekKey, verSeekKey := "", ""
+	if keyMarker != "" && versionIDMarker == "" {
+		objSeekKey = getObjectKey(bucket, keyMarker)
+		verSeekKey = getVersionsKey(bucket, keyMarker)
+	}
+	objects, err := snap.ReadAllChan(ctx, fmt.Sprintf(allObjectPrefixFormat, bucket, prefix), objSeekKey)
+	if err != nil {
+		return loi, err
+	}
+	versions, err := snap.ReadAllChan(ctx, fmt.Sprintf(allVersionsPrefixFormat, bucket, prefix), verSeekKey)
+	if err != nil {
+		return loi, err
+	}
+	objectsKey := fmt.Sprintf(allObjectPrefixFormat, bucket, "")
+	versionsKey := fmt.Sprintf(allVersionsPrefixFormat, bucket, "")
+	obj, objOk := <-objects
+	ver, verOk := <-versions
+
+	// the listing resumed after a common prefix skips its keys
+	commonPrefix := keyMarker
+	index := 0
+	lastName, lastVersionID := "", ""
+	for objOk || verOk {
+		var objName, verName string
+		if objOk {
+			objName = strings.TrimPrefix(obj.Key, objectsKey)
+		}
+		if verOk {
+			verName = strings.TrimPrefix(ver.Key, versionsKey)
+		}
+		name := objName
+		if !objOk || (verOk && verName < objName) {
+			name = verName
+		}
+		var objEntry, verEntry *metadb.Entry
+		if objOk && objName == name {
+			objEntry = obj
+			obj, objOk = <-objects
+		}
+		if verOk && verName == name {
+			verEntry = ver
+			ver, verOk = <-versions
+		}
+		if name < keyMarker || (name == keyMarker && versionIDMarker == "") {
+			continue
+		}
+		if delimiter != "" {
+			if i := strings.Index(name[len(prefix):], delimiter); i >= 0 {
+				if p := name[:len(prefix)+i+len(delimiter)]; p != commonPrefix {
+					if index == maxKeys {
+						loi.IsTruncated = true
+						break
+					}
+					commonPrefix = p
+					index++
+					loi.Prefixes = append(loi.Prefixes, p)
+					lastName, lastVersionID = p, ""
+				}
+				continue
+			}
+		}
+
+		var all []ObjectInfo
+		if objEntry != nil {
+			var o ObjectInfo
+			if err = objEntry.UnmarshalValue(&o); err != nil {
+				return loi, err
+			}
+			o.upgrade()
+			all = append(all, o)
+		}
+		if verEntry != nil {
+			var ov objectVersions
+			if err = verEntry.UnmarshalValue(&ov); err != nil {
+				return loi, err
+			}
+			for _, v := range ov.Versions {
+				v.upgrade()
+				all = append(all, v)
+			}
+		}
+		for i := range all {
+			all[i].IsLatest = i == 0
+		}
+		if name == keyMarker {
+			// the listing resumes after the version ID marker
+			for i, v := range all {
+				if matchVersion(v.VersionID, versionIDMarker) {
+					all = all[i+1:]
+					break
+				}
+			}
+		}
+		for _, v := range all {
+			if index == maxKeys {
+				loi.IsTruncated = true
+				break
+			}
+			if v.VersionID == "" {
+				v.VersionID = NullVersionID
+			}
+			index++
+			loi.Objects = append(loi.Objects, v)
+			lastName, lastVersionID = v.Name, v.VersionID
+		}
+		if loi.IsTruncated {
+			break
+		}
+	}
+	if loi.IsTruncated {
+		loi.NextMarker = lastName
+		loi.NextVersionIDMarker = lastVersionID
+	}
+	return loi, nil
+}
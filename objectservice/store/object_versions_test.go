@@ -0,0 +1,234 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestStorageSys_ObjectVersions(t *testing.T) {
+	s, mbsys := newTestStorageSys(t)
+	ctx := context.TODO()
+	storeObject := func(object, content string) ObjectInfo {
+		r, err := hash.NewReader(strings.NewReader(content), int64(len(content)), "", "", int64(len(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		oi, err := s.StoreObject(ctx, "testbucket", object, r, int64(len(content)), map[string]string{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return oi
+	}
+	readObject := func(object, versionID string) string {
+		_, reader, err := s.GetObject(ctx, "testbucket", object, versionID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader.Close()
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	listVersions := func(object string) []ObjectInfo {
+		loi, err := s.ListObjectVersions(ctx, "testbucket", object, "", "", "", 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return loi.Objects
+	}
+	deleteMarks := func() int {
+		all, err := s.Db.ReadAllChan(ctx, allDeletePrefixFormat, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for range all {
+			n++
+		}
+		return n
+	}
+
+	// the object stored before the versioning is enabled is the null version
+	if oi := storeObject("obj", "v0"); oi.VersionID != "" {
+		t.Fatalf("unexpected version ID %v of an unversioned object", oi.VersionID)
+	}
+	if err := mbsys.UpdateBucketVersioning(ctx, "testbucket", VersioningEnabled); err != nil {
+		t.Fatal(err)
+	}
+	v1 := storeObject("obj", "v1")
+	v2 := storeObject("obj", "v2")
+	if v1.VersionID == "" || v1.VersionID == NullVersionID || v1.VersionID == v2.VersionID {
+		t.Fatalf("unexpected version IDs %v and %v", v1.VersionID, v2.VersionID)
+	}
+	if deleteMarks() != 0 {
+		t.Fatal("the data of the versions must be kept")
+	}
+	if got := readObject("obj", ""); got != "v2" {
+		t.Fatalf("unexpected current content %v", got)
+	}
+	for versionID, content := range map[string]string{v1.VersionID: "v1", v2.VersionID: "v2", NullVersionID: "v0"} {
+		if got := readObject("obj", versionID); got != content {
+			t.Fatalf("unexpected content %v of the version %v", got, versionID)
+		}
+	}
+	oi, err := s.GetObjectInfo(ctx, "testbucket", "obj", v1.VersionID)
+	if err != nil || oi.IsLatest {
+		t.Fatalf("the noncurrent version must be found and not the latest, %v", err)
+	}
+	versions := listVersions("obj")
+	if len(versions) != 3 || versions[0].VersionID != v2.VersionID || !versions[0].IsLatest ||
+		versions[1].VersionID != v1.VersionID || versions[1].IsLatest || versions[2].VersionID != NullVersionID {
+		t.Fatalf("unexpected versions %+v", versions)
+	}
+
+	// the delete without a version hides the object behind a delete marker
+	marker, err := s.DeleteObject(ctx, "testbucket", "obj", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !marker.DeleteMarker || marker.VersionID == "" {
+		t.Fatalf("unexpected delete marker %+v", marker)
+	}
+	if _, err = s.GetObjectInfo(ctx, "testbucket", "obj", ""); err != ErrObjectNotFound {
+		t.Fatalf("the deleted object must not be found, %v", err)
+	}
+	if _, err = s.GetObjectInfo(ctx, "testbucket", "obj", marker.VersionID); err != ErrVersionIsDeleteMarker {
+		t.Fatalf("the delete marker has no info, %v", err)
+	}
+	for _, versionID := range []string{"", marker.VersionID} {
+		if got, ok, err := s.GetDeleteMarker(ctx, "testbucket", "obj", versionID); err != nil || !ok || got.VersionID != marker.VersionID || !got.IsLatest {
+			t.Fatalf("the read of the version %q must run into the delete marker, %+v %v", versionID, got, err)
+		}
+	}
+	if _, ok, err := s.GetDeleteMarker(ctx, "testbucket", "obj", v2.VersionID); err != nil || ok {
+		t.Fatalf("the noncurrent version isn't a delete marker, %v", err)
+	}
+	if got := readObject("obj", v2.VersionID); got != "v2" {
+		t.Fatalf("unexpected content %v of the deleted version", got)
+	}
+	versions = listVersions("obj")
+	if len(versions) != 4 || !versions[0].DeleteMarker || !versions[0].IsLatest || versions[1].IsLatest {
+		t.Fatalf("unexpected versions %+v", versions)
+	}
+	if empty, err := s.EmptyBucket(ctx, "testbucket"); err != nil || empty {
+		t.Fatalf("the bucket with versions isn't empty, %v", err)
+	}
+
+	// removing the delete marker restores the object
+	if _, err = s.DeleteObject(ctx, "testbucket", "obj", marker.VersionID); err != nil {
+		t.Fatal(err)
+	}
+	if got := readObject("obj", ""); got != "v2" {
+		t.Fatalf("unexpected restored content %v", got)
+	}
+	// removing the current version makes the previous one current, its data is marked to delete
+	if _, err = s.DeleteObject(ctx, "testbucket", "obj", v2.VersionID); err != nil {
+		t.Fatal(err)
+	}
+	if got := readObject("obj", ""); got != "v1" {
+		t.Fatalf("unexpected current content %v", got)
+	}
+	if deleteMarks() != 1 {
+		t.Fatal("the data of the removed version must be marked to delete")
+	}
+	if _, err = s.DeleteObject(ctx, "testbucket", "obj", v2.VersionID); !errors.Is(err, ErrObjectVersionNotFound) {
+		t.Fatalf("the removed version must not be found, %v", err)
+	}
+	r, err := hash.NewReader(strings.NewReader("a"), 1, "", "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.AppendObject(ctx, "testbucket", "obj", r, 1, map[string]string{}); !errors.As(err, &AppendVersionedObject{}) {
+		t.Fatalf("a versioned object can't be appended to, %v", err)
+	}
+
+	// the stores of a suspended bucket replace the null version
+	if err = mbsys.UpdateBucketVersioning(ctx, "testbucket", VersioningSuspended); err != nil {
+		t.Fatal(err)
+	}
+	if oi = storeObject("obj", "s1"); oi.VersionID != NullVersionID {
+		t.Fatalf("unexpected version ID %v in a suspended bucket", oi.VersionID)
+	}
+	storeObject("obj", "s2")
+	versions = listVersions("obj")
+	if len(versions) != 2 || versions[0].VersionID != NullVersionID || versions[1].VersionID != v1.VersionID {
+		t.Fatalf("unexpected versions %+v", versions)
+	}
+	if got := readObject("obj", NullVersionID); got != "s2" {
+		t.Fatalf("unexpected content %v of the null version", got)
+	}
+	// v0 and s1 are replaced
+	if deleteMarks() != 3 {
+		t.Fatal("the data of the replaced null versions must be marked to delete")
+	}
+
+	// every version is gone with the objects of the bucket
+	if err = s.CleanObjectsInBucket(ctx, "testbucket"); err != nil {
+		t.Fatal(err)
+	}
+	if empty, err := s.EmptyBucket(ctx, "testbucket"); err != nil || !empty {
+		t.Fatalf("the bucket must be empty, %v", err)
+	}
+	if deleteMarks() != 5 {
+		t.Fatal("the data of all the versions must be marked to delete")
+	}
+}
+
+func TestStorageSys_ListObjectVersions(t *testing.T) {
+	s, mbsys := newTestStorageSys(t)
+	ctx := context.TODO()
+	if err := mbsys.UpdateBucketVersioning(ctx, "testbucket", VersioningEnabled); err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []string{"a", "a", "b", "dir/c", "dir/d", "e", "e"} {
+		r, err := hash.NewReader(strings.NewReader(object), int64(len(object)), "", "", int64(len(object)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = s.StoreObject(ctx, "testbucket", object, r, int64(len(object)), map[string]string{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// "b" is only a delete marker and a noncurrent version
+	if _, err := s.DeleteObject(ctx, "testbucket", "b", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// a page of one version at a time goes through every version, newest first for each key
+	var got []string
+	keyMarker, versionIDMarker := "", ""
+	for {
+		loi, err := s.ListObjectVersions(ctx, "testbucket", "", keyMarker, versionIDMarker, "/", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(loi.Objects)+len(loi.Prefixes) != 1 {
+			t.Fatalf("unexpected page %+v", loi)
+		}
+		for _, o := range loi.Objects {
+			entry := o.Name
+			if o.DeleteMarker {
+				entry += "(marker)"
+			}
+			if o.IsLatest {
+				entry += "(latest)"
+			}
+			got = append(got, entry)
+		}
+		got = append(got, loi.Prefixes...)
+		if !loi.IsTruncated {
+			break
+		}
+		keyMarker, versionIDMarker = loi.NextMarker, loi.NextVersionIDMarker
+	}
+	want := "a(latest),a,b(marker)(latest),b,dir/,e(latest),e"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("unexpected versions %v, want %v", strings.Join(got, ","), want)
+	}
+}
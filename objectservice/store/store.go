@@ -2,6 +2,8 @@ package store
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -10,7 +12,7 @@ import (
 	"github.com/filedag-project/filedag-storage/objectservice/consts"
 	"github.com/filedag-project/filedag-storage/objectservice/datatypes"
 	"github.com/filedag-project/filedag-storage/objectservice/lock"
-	"github.com/filedag-project/filedag-storage/objectservice/uleveldb"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
 	"github.com/filedag-project/filedag-storage/objectservice/utils"
 	"github.com/filedag-project/filedag-storage/objectservice/utils/etag"
 	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
@@ -25,9 +27,9 @@ import (
 	pool "github.com/libp2p/go-buffer-pool"
 	"github.com/shirou/gopsutil/cpu"
 	"github.com/shirou/gopsutil/mem"
-	"github.com/syndtr/goleveldb/leveldb"
 	"golang.org/x/xerrors"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"regexp"
 	"strings"
@@ -65,27 +67,54 @@ var ErrBucketNotEmpty = errors.New("bucket not empty")
 
 // StorageSys store sys
 type StorageSys struct {
-	Db              *uleveldb.ULevelDB
+	Db              metadb.DB
 	DagPool         ipld.DAGService
 	CidBuilder      cid.Builder
 	nsLock          *lock.NsLockMap
 	newBucketNSLock func(bucket string) lock.RWLocker
 	hasBucket       func(ctx context.Context, bucket string) bool
+	// overwriteInterval returns the overwrite protection window of the bucket, 0 if it has none
+	overwriteInterval func(ctx context.Context, bucket string) (time.Duration, error)
+	// versioning returns the versioning status of the bucket, empty if it has never been enabled
+	versioning func(ctx context.Context, bucket string) (string, error)
+	// caseInsensitive returns whether the keys in the bucket are case-insensitive
+	caseInsensitive func(ctx context.Context, bucket string) (bool, error)
+	listSnapshots   *listSnapshots
+	objectFilters   *objectFilters
+	objectInfoCache *objectInfoCache
+	// verifyChecksum verifies the checksum of the objects which are read to the end
+	verifyChecksum bool
+	// maxParts the max part number and number of parts of a multipart upload
+	maxParts int
+	// minPartSize the min size of the parts except the last one of a multipart upload
+	minPartSize int64
+	// trashRetention how long a deleted object is kept in the trash, 0 if the soft delete is disabled
+	trashRetention time.Duration
+	// staleUploadExpiry how long a multipart upload can stay uncompleted, 0 if it never expires
+	staleUploadExpiry time.Duration
 
 	gcPeriod  time.Duration
 	gcTimeout time.Duration
 }
 
 // NewStorageSys new a storage sys
-func NewStorageSys(ctx context.Context, dagService ipld.DAGService, db *uleveldb.ULevelDB) *StorageSys {
+func NewStorageSys(ctx context.Context, dagService ipld.DAGService, db metadb.DB) *StorageSys {
 	cidBuilder, _ := merkledag.PrefixForCidVersion(0)
 	s := &StorageSys{
-		Db:         db,
-		DagPool:    dagService,
-		CidBuilder: cidBuilder,
-		nsLock:     lock.NewNSLock(),
-		gcPeriod:   15 * time.Minute,
-		gcTimeout:  30 * time.Minute,
+		Db:              db,
+		DagPool:         dagService,
+		CidBuilder:      cidBuilder,
+		nsLock:          lock.NewNSLock(),
+		listSnapshots:   newListSnapshots(),
+		objectInfoCache: newObjectInfoCache(defaultObjectInfoCacheTTL),
+		maxParts:        consts.MaxPartID,
+		minPartSize:     consts.MinPartSize,
+		gcPeriod:        15 * time.Minute,
+		gcTimeout:       30 * time.Minute,
+	}
+	s.objectFilters = newObjectFilters(s.buildObjectFilter)
+	if err := s.buildCidIndex(ctx); err != nil {
+		log.Errorw("build the cid index error", "error", err)
 	}
 	go func() {
 		s.processObjectGC(ctx)
@@ -110,6 +139,16 @@ func (s *StorageSys) NewNSLock(bucket string, objects ...string) lock.RWLocker {
 	return s.nsLock.NewNSLock(bucket, objects...)
 }
 
+// GetObjectLockInfo returns the state of the namespace lock of the object, false if nobody holds or waits for it
+func (s *StorageSys) GetObjectLockInfo(bucket, object string) (lock.LockInfo, bool) {
+	return s.nsLock.GetLockInfo(bucket, object)
+}
+
+// ForceUnlockObject releases the namespace lock of the object which has been held for minHeld
+func (s *StorageSys) ForceUnlockObject(bucket, object string, minHeld time.Duration) (lock.LockInfo, error) {
+	return s.nsLock.ForceUnlock(bucket, object, minHeld)
+}
+
 func (s *StorageSys) SetNewBucketNSLock(newBucketNSLock func(bucket string) lock.RWLocker) {
 	s.newBucketNSLock = newBucketNSLock
 }
@@ -118,9 +157,52 @@ func (s *StorageSys) SetHasBucket(hasBucket func(ctx context.Context, bucket str
 	s.hasBucket = hasBucket
 }
 
+// SetOverwriteInterval sets the function returning the overwrite protection window of a bucket,
+// the objects are overwritten at any time if it isn't set
+func (s *StorageSys) SetOverwriteInterval(overwriteInterval func(ctx context.Context, bucket string) (time.Duration, error)) {
+	s.overwriteInterval = overwriteInterval
+}
+
+// SetMaxParts sets the max part number and number of parts of a multipart upload, it can't be
+// above the S3 limit of 10000. It must be called before the StorageSys is used.
+func (s *StorageSys) SetMaxParts(maxParts int) error {
+	if maxParts < 1 || maxParts > consts.MaxPartID {
+		return fmt.Errorf("the max parts %d isn't between 1 and %d", maxParts, consts.MaxPartID)
+	}
+	s.maxParts = maxParts
+	return nil
+}
+
+// checkOverwrite returns ObjectOverwriteTooSoon if the object was stored within
+// the overwrite protection window of the bucket, ObjectUnderLegalHold if the object
+// replaced is under a legal hold
+func (s *StorageSys) checkOverwrite(ctx context.Context, bucket, object string) error {
+	if err := s.checkLegalHold(ctx, bucket, object); err != nil {
+		return err
+	}
+	if s.overwriteInterval == nil {
+		return nil
+	}
+	interval, err := s.overwriteInterval(ctx, bucket)
+	if err != nil || interval == 0 {
+		return err
+	}
+	oldObjInfo, err := s.getObjectInfo(ctx, bucket, object)
+	if err == ErrObjectNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if elapsed := time.Since(oldObjInfo.ModTime); elapsed < interval {
+		return ObjectOverwriteTooSoon{Bucket: bucket, Object: object, RetryAfter: interval - elapsed}
+	}
+	return nil
+}
+
 func (s *StorageSys) store(ctx context.Context, reader io.ReadCloser, size int64) (cid.Cid, error) {
 	data := io.Reader(reader)
-	if size > bigFileThreshold {
+	// a content of unknown size may be big
+	if size > bigFileThreshold || size < 0 {
 		// We use 2 buffers, so we always have a full buffer of input.
 		bufA := pool.Get(chunkSize)
 		bufB := pool.Get(chunkSize)
@@ -134,33 +216,82 @@ func (s *StorageSys) store(ctx context.Context, reader io.ReadCloser, size int64
 			log.Infof("readahead.NewReaderBuffer failed, error: %v", err)
 		}
 	}
-	node, err := dagpoolcli.BalanceNode(data, s.DagPool, s.CidBuilder)
+	// the content is verified while it's read, the nodes stored before it fails are removed
+	added := &addedNodes{DAGService: s.DagPool}
+	node, err := dagpoolcli.BalanceNode(data, added, s.CidBuilder)
+	if err == nil {
+		err = ctx.Err()
+	}
 	if err != nil {
+		added.removeAll()
 		return cid.Undef, err
 	}
-	select {
-	case <-ctx.Done():
-		return cid.Undef, ctx.Err()
-	default:
-	}
 	return node.Cid(), nil
 }
 
-func (s *StorageSys) checkAndDeleteObjectData(ctx context.Context, bucket, object string) {
+// addedNodes records the nodes added through it, every Add references a node once more in the pool
+type addedNodes struct {
+	ipld.DAGService
+	cids []cid.Cid
+}
+
+func (a *addedNodes) Add(ctx context.Context, nd ipld.Node) error {
+	if err := a.DAGService.Add(ctx, nd); err != nil {
+		return err
+	}
+	a.cids = append(a.cids, nd.Cid())
+	return nil
+}
+
+func (a *addedNodes) AddMany(ctx context.Context, nds []ipld.Node) error {
+	for _, nd := range nds {
+		if err := a.Add(ctx, nd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeAll releases the references of the nodes added, it goes on when the request is canceled
+func (a *addedNodes) removeAll() {
+	for _, c := range a.cids {
+		if err := a.DAGService.Remove(context.Background(), c); err != nil {
+			log.Errorw("remove the node of a failed store error", "cid", c.String(), "error", err)
+		}
+	}
+}
+
+// checkAndDeleteObjectData adds the delete mark of the data of the object which is replaced to the batch
+func (s *StorageSys) checkAndDeleteObjectData(ctx context.Context, batch metadb.Batch, bucket, object string) error {
 	if oldObjInfo, err := s.getObjectInfo(ctx, bucket, object); err == nil {
+		batchDeleteCidRef(batch, oldObjInfo.Cid, bucket, object)
 		c, err := cid.Decode(oldObjInfo.Cid)
 		if err != nil {
 			log.Warnw("decode cid error", "cid", oldObjInfo.ETag)
-		} else {
-			if err = s.markObjetToDelete(c); err != nil {
-				log.Errorw("mark Objet to delete error", "bucket", bucket, "object", object, "cid", oldObjInfo.ETag, "error", err)
-			}
+			return nil
 		}
+		return batchMarkObjetToDelete(batch, c)
 	}
+	return nil
 }
 
-// StoreObject store object
+// StoreObject store object, the size is -1 if it's unknown until the content is read
 func (s *StorageSys) StoreObject(ctx context.Context, bucket, object string, reader *hash.Reader, size int64, meta map[string]string) (ObjectInfo, error) {
+	return s.storeObject(ctx, bucket, object, reader, size, meta, false)
+}
+
+// StoreObjectIfNotExists stores the object only if it doesn't exist, like a PUT with If-None-Match: *,
+// PreConditionFailed is returned if it exists. The existence is checked again under the object lock,
+// so that of the concurrent creations of the object only one succeeds.
+func (s *StorageSys) StoreObjectIfNotExists(ctx context.Context, bucket, object string, reader *hash.Reader, size int64, meta map[string]string) (ObjectInfo, error) {
+	return s.storeObject(ctx, bucket, object, reader, size, meta, true)
+}
+
+func (s *StorageSys) storeObject(ctx context.Context, bucket, object string, reader *hash.Reader, size int64, meta map[string]string, ifNotExists bool) (ObjectInfo, error) {
+	object, err := s.objectKey(ctx, bucket, object)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
 	bktlk := s.newBucketNSLock(bucket)
 	bktlkCtx, err := bktlk.GetRLock(ctx, globalOperationTimeout)
 	if err != nil {
@@ -172,26 +303,97 @@ func (s *StorageSys) StoreObject(ctx context.Context, bucket, object string, rea
 	if !s.hasBucket(ctx, bucket) {
 		return ObjectInfo{}, BucketNotFound{Bucket: bucket}
 	}
+	// fail before the content is stored, the existence is checked again under the lock
+	if ifNotExists {
+		if exists, err := s.objectExists(ctx, bucket, object); err != nil {
+			return ObjectInfo{}, err
+		} else if exists {
+			return ObjectInfo{}, PreConditionFailed{}
+		}
+	}
+	if err = s.checkOverwrite(ctx, bucket, object); err != nil {
+		return ObjectInfo{}, err
+	}
+	// every store of a versioned object adds a version with a dag of its own
+	status, err := s.getVersioning(ctx, bucket)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if status == "" {
+		if objInfo, ok, err := s.storeSameContent(ctx, bucket, object, reader, size, meta); ok || err != nil {
+			return objInfo, err
+		}
+	}
 
-	root, err := s.store(ctx, reader, size)
+	data, checksum := newChecksumReader(reader)
+	root, err := s.store(ctx, data, size)
 	if err != nil {
 		return ObjectInfo{}, err
 	}
+	// the size of a chunked transfer without a Content-Length is known once it's stored
+	if size < 0 {
+		size = reader.BytesRead()
+	}
+
+	objInfo := newObjectInfo(bucket, object, reader.ETag().String(), root.String(), encodeChecksum(checksum), size, meta)
+
+	lk := s.NewNSLock(bucket, object)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
 
+	if ifNotExists {
+		var exists bool
+		exists, err = s.objectExists(ctx, bucket, object)
+		if err == nil && exists {
+			err = PreConditionFailed{}
+		}
+	}
+	if err == nil {
+		err = s.checkOverwrite(ctx, bucket, object)
+	}
+	if err != nil {
+		if e := s.markObjetToDelete(root); e != nil {
+			log.Errorw("mark Objet to delete error", "bucket", bucket, "object", object, "cid", root.String(), "error", e)
+		}
+		return ObjectInfo{}, err
+	}
+	// the old data is marked to delete or kept as a version with the write of the object info,
+	// a crash never leaves one without the other
+	batch := s.Db.NewBatch()
+	if err = s.batchPutLatestVersion(ctx, batch, bucket, object, &objInfo); err != nil {
+		return ObjectInfo{}, err
+	}
+	if err = s.writeObjectBatch(bucket, object, batch); err != nil {
+		return ObjectInfo{}, err
+	}
+	s.objectFilters.added(bucket, object)
+	return objInfo, nil
+}
+
+// newObjectInfo returns the info of the object stored from a single request
+func newObjectInfo(bucket, object, etag, root, checksum string, size int64, meta map[string]string) ObjectInfo {
 	objInfo := ObjectInfo{
-		Bucket:           bucket,
-		Name:             object,
-		ModTime:          time.Now().UTC(),
-		Size:             size,
-		IsDir:            false,
-		ETag:             reader.ETag().String(),
-		Cid:              root.String(),
-		VersionID:        "",
-		IsLatest:         true,
-		DeleteMarker:     false,
-		ContentType:      meta[strings.ToLower(consts.ContentType)],
-		ContentEncoding:  meta[strings.ToLower(consts.ContentEncoding)],
-		SuccessorModTime: time.Now().UTC(),
+		Bucket:             bucket,
+		Name:               object,
+		ModTime:            time.Now().UTC(),
+		Size:               size,
+		IsDir:              false,
+		ETag:               etag,
+		Cid:                root,
+		ChecksumSHA256:     checksum,
+		VersionID:          "",
+		IsLatest:           true,
+		DeleteMarker:       false,
+		ContentType:        meta[strings.ToLower(consts.ContentType)],
+		ContentEncoding:    meta[strings.ToLower(consts.ContentEncoding)],
+		CacheControl:       meta[strings.ToLower(consts.CacheControl)],
+		ContentDisposition: meta[strings.ToLower(consts.ContentDisposition)],
+		ContentLanguage:    meta[strings.ToLower(consts.ContentLanguage)],
+		SuccessorModTime:   time.Now().UTC(),
 	}
 	// Update expires
 	if exp, ok := meta[strings.ToLower(consts.Expires)]; ok {
@@ -199,6 +401,89 @@ func (s *StorageSys) StoreObject(ctx context.Context, bucket, object string, rea
 			objInfo.Expires = t.UTC()
 		}
 	}
+	objInfo.WebsiteRedirectLocation = meta[strings.ToLower(consts.AmzWebsiteRedirectLocation)]
+	objInfo.UserDefined = userDefinedMetadata(meta)
+	return objInfo
+}
+
+// sameContent reports whether the checksum declared by the request is the checksum of the content of the object.
+// Only an object stored by a single request is compared, the ETag of a multipart object isn't the md5 of its content.
+func sameContent(oi ObjectInfo, reader *hash.Reader, size int64) bool {
+	if oi.Cid == "" || oi.Size != size || len(oi.Parts) != 0 {
+		return false
+	}
+	if sha := reader.SHA256(); len(sha) > 0 {
+		return oi.ChecksumSHA256 != "" && base64.StdEncoding.EncodeToString(sha) == oi.ChecksumSHA256
+	}
+	if sum := reader.MD5(); len(sum) > 0 {
+		return hex.EncodeToString(sum) == oi.ETag
+	}
+	return false
+}
+
+// storeSameContent overwrites the object with its own content by updating the object info only, neither the
+// dag is added again nor the old one removed. ok is false if the content isn't known to be the same before it's
+// read, the reader is left unread then and the content must be stored as usual: the blocks of a stored content
+// are referenced once more and the old root must be marked to delete to release them, even if it's the same root.
+func (s *StorageSys) storeSameContent(ctx context.Context, bucket, object string, reader *hash.Reader, size int64, meta map[string]string) (objInfo ObjectInfo, ok bool, err error) {
+	if oi, err := s.getObjectInfo(ctx, bucket, object); err != nil || !sameContent(oi, reader, size) {
+		return ObjectInfo{}, false, nil
+	}
+	// the object must not change while its content is read, it would have to be stored then
+	lk := s.NewNSLock(bucket, object)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return ObjectInfo{}, true, err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	oi, err := s.getObjectInfo(ctx, bucket, object)
+	if err != nil || !sameContent(oi, reader, size) {
+		return ObjectInfo{}, false, nil
+	}
+	if err = s.checkOverwrite(ctx, bucket, object); err != nil {
+		return ObjectInfo{}, true, err
+	}
+	// the reader verifies the declared checksum at the end of the content
+	if _, err = io.Copy(ioutil.Discard, reader); err != nil {
+		return ObjectInfo{}, true, err
+	}
+	objInfo = newObjectInfo(bucket, object, oi.ETag, oi.Cid, oi.ChecksumSHA256, size, meta)
+	batch := s.Db.NewBatch()
+	if err = batchPutObjectInfo(batch, bucket, object, objInfo); err != nil {
+		return ObjectInfo{}, true, err
+	}
+	if err = s.writeObjectBatch(bucket, object, batch); err != nil {
+		return ObjectInfo{}, true, err
+	}
+	return objInfo, true, nil
+}
+
+// AppendObject appends the content to the object, it's created if it doesn't exist.
+// Only the blocks of the appended content and a new root are added to the dag pool,
+// the blocks of the object are reused.
+func (s *StorageSys) AppendObject(ctx context.Context, bucket, object string, reader *hash.Reader, size int64, meta map[string]string) (ObjectInfo, error) {
+	object, err := s.objectKey(ctx, bucket, object)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	bktlk := s.newBucketNSLock(bucket)
+	bktlkCtx, err := bktlk.GetRLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	ctx = bktlkCtx.Context()
+	defer bktlk.RUnlock(bktlkCtx.Cancel)
+
+	if !s.hasBucket(ctx, bucket) {
+		return ObjectInfo{}, BucketNotFound{Bucket: bucket}
+	}
+	if status, err := s.getVersioning(ctx, bucket); err != nil {
+		return ObjectInfo{}, err
+	} else if status != "" {
+		return ObjectInfo{}, AppendVersionedObject{Bucket: bucket, Object: object}
+	}
 
 	lk := s.NewNSLock(bucket, object)
 	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
@@ -208,18 +493,122 @@ func (s *StorageSys) StoreObject(ctx context.Context, bucket, object string, rea
 	ctx = lkctx.Context()
 	defer lk.Unlock(lkctx.Cancel)
 
-	// Has old file?
-	s.checkAndDeleteObjectData(ctx, bucket, object)
+	oldObjInfo, err := s.getObjectInfo(ctx, bucket, object)
+	if err != nil && err != ErrObjectNotFound {
+		return ObjectInfo{}, err
+	}
+	exists := err == nil
+	if exists && oldObjInfo.LegalHold {
+		return ObjectInfo{}, ObjectUnderLegalHold{Bucket: bucket, Object: object}
+	}
 
-	err = s.Db.Put(getObjectKey(bucket, object), objInfo)
+	data, checksum := newChecksumReader(reader)
+	appended, err := s.store(ctx, data, size)
 	if err != nil {
 		return ObjectInfo{}, err
 	}
+	if !exists {
+		objInfo := ObjectInfo{
+			Bucket:             bucket,
+			Name:               object,
+			ModTime:            time.Now().UTC(),
+			Size:               size,
+			ETag:               reader.ETag().String(),
+			Cid:                appended.String(),
+			ChecksumSHA256:     encodeChecksum(checksum),
+			IsLatest:           true,
+			ContentType:        meta[strings.ToLower(consts.ContentType)],
+			ContentEncoding:    meta[strings.ToLower(consts.ContentEncoding)],
+			CacheControl:       meta[strings.ToLower(consts.CacheControl)],
+			ContentDisposition: meta[strings.ToLower(consts.ContentDisposition)],
+			ContentLanguage:    meta[strings.ToLower(consts.ContentLanguage)],
+			SuccessorModTime:   time.Now().UTC(),
+		}
+		if exp, ok := meta[strings.ToLower(consts.Expires)]; ok {
+			if t, e := time.Parse(http.TimeFormat, exp); e == nil {
+				objInfo.Expires = t.UTC()
+			}
+		}
+		objInfo.WebsiteRedirectLocation = meta[strings.ToLower(consts.AmzWebsiteRedirectLocation)]
+		objInfo.UserDefined = userDefinedMetadata(meta)
+		if err = s.putObjectInfo(bucket, object, objInfo); err != nil {
+			return ObjectInfo{}, err
+		}
+		s.objectFilters.added(bucket, object)
+		return objInfo, nil
+	}
+
+	oldRoot, err := cid.Decode(oldObjInfo.Cid)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	root, replaced, err := dagpoolcli.AppendNode(ctx, s.DagPool, s.CidBuilder, oldRoot, appended)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	objInfo := oldObjInfo
+	objInfo.Size += size
+	objInfo.ETag = appendETag(oldObjInfo.ETag, reader.ETag())
+	objInfo.Cid = root.Cid().String()
+	// the checksum of the whole content can't be extended by the appended content
+	objInfo.ChecksumSHA256 = ""
+	// the new root doesn't link the parts of a multipart object any more
+	objInfo.Parts = nil
+	objInfo.ModTime = time.Now().UTC()
+	objInfo.SuccessorModTime = objInfo.ModTime
+	batch := s.Db.NewBatch()
+	batchDeleteCidRef(batch, oldObjInfo.Cid, bucket, object)
+	if err = batchPutObjectInfo(batch, bucket, object, objInfo); err != nil {
+		return ObjectInfo{}, err
+	}
+	if err = s.writeObjectBatch(bucket, object, batch); err != nil {
+		return ObjectInfo{}, err
+	}
+	if replaced {
+		// the blocks of the old root are linked by the new root, only the old root node is gone
+		if err = s.DagPool.Remove(ctx, oldRoot); err != nil {
+			log.Warnw("remove the replaced root error", "bucket", bucket, "object", object, "cid", oldRoot, "error", err)
+		}
+	}
 	return objInfo, nil
 }
 
-// GetObject Get object
-func (s *StorageSys) GetObject(ctx context.Context, bucket, object string) (ObjectInfo, io.ReadCloser, error) {
+// appendETag returns the ETag of an object after the content with the ETag appended is appended to it.
+// Like a multipart ETag it's not the MD5 of the content, it ends with the number of the appended contents.
+func appendETag(old string, appended etag.ETag) string {
+	oldETag, err := etag.Parse(old)
+	if err != nil {
+		oldETag = etag.ETag(old)
+	}
+	parts := 1
+	if oldETag.IsMultipart() {
+		parts = oldETag.Parts()
+	}
+	h := md5.New()
+	h.Write(oldETag)
+	h.Write(appended)
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(h.Sum(nil)), parts+1)
+}
+
+// CheckPreconditionFn returns true if the precondition of the request failed,
+// the object is not read then.
+type CheckPreconditionFn func(ObjectInfo) bool
+
+// PreConditionFailed - the precondition of the request failed.
+type PreConditionFailed struct{}
+
+func (e PreConditionFailed) Error() string {
+	return "At least one of the pre-conditions you specified did not hold"
+}
+
+// GetObject returns the object info and the reader of the object content, of the version if
+// the version ID isn't empty. When checkPrecondFn is not nil it's called with the object info
+// before the dag is touched, if the precondition fails PreConditionFailed is returned.
+func (s *StorageSys) GetObject(ctx context.Context, bucket, object, versionID string, checkPrecondFn CheckPreconditionFn) (ObjectInfo, io.ReadCloser, error) {
+	object, err := s.objectKey(ctx, bucket, object)
+	if err != nil {
+		return ObjectInfo{}, nil, err
+	}
 	lk := s.NewNSLock(bucket, object)
 	lkctx, err := lk.GetRLock(ctx, globalOperationTimeout)
 	if err != nil {
@@ -228,10 +617,13 @@ func (s *StorageSys) GetObject(ctx context.Context, bucket, object string) (Obje
 	ctx = lkctx.Context()
 	defer lk.RUnlock(lkctx.Cancel)
 
-	meta, err := s.getObjectInfo(ctx, bucket, object)
+	meta, err := s.getObjectVersion(ctx, bucket, object, versionID)
 	if err != nil {
 		return ObjectInfo{}, nil, err
 	}
+	if checkPrecondFn != nil && checkPrecondFn(meta) {
+		return meta, nil, PreConditionFailed{}
+	}
 	meatCid, err := cid.Decode(meta.Cid)
 	if err != nil {
 		return ObjectInfo{}, nil, err
@@ -244,20 +636,154 @@ func (s *StorageSys) GetObject(ctx context.Context, bucket, object string) (Obje
 	if err != nil {
 		return ObjectInfo{}, nil, err
 	}
+	if s.verifyChecksum && meta.ChecksumSHA256 != "" {
+		return meta, newVerifyingReader(reader, meta.ChecksumSHA256), nil
+	}
 	return meta, reader, nil
 }
 
+// InvalidPartNumber - the object has no part of the number.
+type InvalidPartNumber struct {
+	PartNumber int
+}
+
+func (e InvalidPartNumber) Error() string {
+	return fmt.Sprintf("The requested partnumber %d is not satisfiable", e.PartNumber)
+}
+
+// TooManyParts - the part number or the number of parts of a multipart upload is above the max parts.
+type TooManyParts struct {
+	Parts    int
+	MaxParts int
+}
+
+func (e TooManyParts) Error() string {
+	return fmt.Sprintf("The multipart upload has %d parts, more than the max parts %d", e.Parts, e.MaxParts)
+}
+
+// GetObjectPart returns the object info, the part info and the reader of the content of the part.
+// An object which isn't completed by a multipart upload has a single part, its content.
+// The version ID and checkPrecondFn are used like GetObject does.
+func (s *StorageSys) GetObjectPart(ctx context.Context, bucket, object, versionID string, partNumber int, checkPrecondFn CheckPreconditionFn) (ObjectInfo, ObjectPartInfo, io.ReadCloser, error) {
+	object, err := s.objectKey(ctx, bucket, object)
+	if err != nil {
+		return ObjectInfo{}, ObjectPartInfo{}, nil, err
+	}
+	lk := s.NewNSLock(bucket, object)
+	lkctx, err := lk.GetRLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return ObjectInfo{}, ObjectPartInfo{}, nil, err
+	}
+	ctx = lkctx.Context()
+	defer lk.RUnlock(lkctx.Cancel)
+
+	meta, err := s.getObjectVersion(ctx, bucket, object, versionID)
+	if err != nil {
+		return ObjectInfo{}, ObjectPartInfo{}, nil, err
+	}
+	part, err := meta.ObjectPart(partNumber)
+	if err != nil {
+		return ObjectInfo{}, ObjectPartInfo{}, nil, err
+	}
+	if checkPrecondFn != nil && checkPrecondFn(meta) {
+		return meta, part, nil, PreConditionFailed{}
+	}
+	// the root of the object doesn't link the part dags one by one, a part is read from its own dag
+	rootCid := meta.Cid
+	if part.Cid != "" {
+		rootCid = part.Cid
+	}
+	root, err := cid.Decode(rootCid)
+	if err != nil {
+		return ObjectInfo{}, ObjectPartInfo{}, nil, err
+	}
+	dagNode, err := s.DagPool.Get(ctx, root)
+	if err != nil {
+		return ObjectInfo{}, ObjectPartInfo{}, nil, err
+	}
+	reader, err := ufsio.NewDagReader(ctx, dagNode, s.DagPool)
+	if err != nil {
+		return ObjectInfo{}, ObjectPartInfo{}, nil, err
+	}
+	if part.Cid == "" && len(meta.Parts) != 0 {
+		// the parts completed before the part cids were kept are read at their offset in the object
+		if _, err = reader.Seek(meta.PartOffset(partNumber), io.SeekStart); err != nil {
+			reader.Close()
+			return ObjectInfo{}, ObjectPartInfo{}, nil, err
+		}
+		return meta, part, partReader{Reader: io.LimitReader(reader, part.Size), Closer: reader}, nil
+	}
+	return meta, part, reader, nil
+}
+
+// partReader reads a part of the object content and closes the reader of the whole content
+type partReader struct {
+	io.Reader
+	io.Closer
+}
+
 func (s *StorageSys) getObjectInfo(ctx context.Context, bucket, object string) (meta ObjectInfo, err error) {
+	// the object is definitely not in the bucket, skip the db read
+	if !s.objectFilters.mayContain(bucket, object) {
+		return meta, ErrObjectNotFound
+	}
+	if cached, ok := s.objectInfoCache.get(bucket, object); ok {
+		return cached, nil
+	}
+	gen := s.objectInfoCache.generation()
 	err = s.Db.Get(getObjectKey(bucket, object), &meta)
 	if err != nil {
-		if xerrors.Is(err, leveldb.ErrNotFound) {
+		if xerrors.Is(err, metadb.ErrNotFound) {
 			return meta, ErrObjectNotFound
 		}
+		return
 	}
+	meta.upgrade()
+	s.objectInfoCache.put(bucket, object, meta, gen)
 	return
 }
 
-func (s *StorageSys) GetObjectInfo(ctx context.Context, bucket, object string) (meta ObjectInfo, err error) {
+// putObjectInfo writes the object info with the current schema version
+func (s *StorageSys) putObjectInfo(bucket, object string, objInfo ObjectInfo) error {
+	batch := s.Db.NewBatch()
+	if err := batchPutObjectInfo(batch, bucket, object, objInfo); err != nil {
+		return err
+	}
+	return s.writeObjectBatch(bucket, object, batch)
+}
+
+// batchPutObjectInfo adds the write of the object info with the current schema version
+// and the reference of the object to its root cid to the batch
+func batchPutObjectInfo(batch metadb.Batch, bucket, object string, objInfo ObjectInfo) error {
+	objInfo.SchemaVersion = ObjectInfoSchemaVersion
+	if err := batch.Put(getObjectKey(bucket, object), objInfo); err != nil {
+		return err
+	}
+	return batchPutCidRef(batch, objInfo.Cid, bucket, object)
+}
+
+// writeObjectBatch applies all the metadata writes of an operation on the object at once,
+// so that a crash leaves either all or none of them
+func (s *StorageSys) writeObjectBatch(bucket, object string, batch metadb.Batch) error {
+	defer s.objectInfoCache.invalidate(bucket, object)
+	return s.Db.Write(batch)
+}
+
+// objectExists returns whether the object exists, the caller holds the object lock if it has to be accurate
+func (s *StorageSys) objectExists(ctx context.Context, bucket, object string) (bool, error) {
+	_, err := s.getObjectInfo(ctx, bucket, object)
+	if err == ErrObjectNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GetObjectInfo returns the info of the object, of the version if the version ID isn't empty
+func (s *StorageSys) GetObjectInfo(ctx context.Context, bucket, object, versionID string) (meta ObjectInfo, err error) {
+	object, err = s.objectKey(ctx, bucket, object)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
 	lk := s.NewNSLock(bucket, object)
 	lkctx, err := lk.GetRLock(ctx, globalOperationTimeout)
 	if err != nil {
@@ -266,42 +792,93 @@ func (s *StorageSys) GetObjectInfo(ctx context.Context, bucket, object string) (
 	ctx = lkctx.Context()
 	defer lk.RUnlock(lkctx.Cancel)
 
-	return s.getObjectInfo(ctx, bucket, object)
+	return s.getObjectVersion(ctx, bucket, object, versionID)
 }
 
-// DeleteObject delete object
-func (s *StorageSys) DeleteObject(ctx context.Context, bucket, object string) error {
+// DeleteObject deletes the object and returns the info of the object deleted. In a versioned bucket the object
+// is hidden behind a delete marker, which is returned, and is kept as a noncurrent version. A version is removed
+// for good when the version ID isn't empty. ObjectUnderLegalHold is returned if the object removed is under a legal hold.
+func (s *StorageSys) DeleteObject(ctx context.Context, bucket, object, versionID string) (ObjectInfo, error) {
+	object, err := s.objectKey(ctx, bucket, object)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
 	lk := s.NewNSLock(bucket, object)
 	lkctx, err := lk.GetLock(ctx, deleteOperationTimeout)
 	if err != nil {
-		return err
+		return ObjectInfo{}, err
 	}
 	ctx = lkctx.Context()
 	defer lk.Unlock(lkctx.Cancel)
 
+	return s.deleteObject(ctx, bucket, object, versionID)
+}
+
+// deleteObject deletes the object like DeleteObject, the caller holds the object lock
+func (s *StorageSys) deleteObject(ctx context.Context, bucket, object, versionID string) (ObjectInfo, error) {
+	status, err := s.getVersioning(ctx, bucket)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if status != "" || (versionID != "" && versionID != NullVersionID) {
+		if versionID != "" {
+			return s.deleteObjectVersion(ctx, bucket, object, versionID)
+		}
+		// the null version is removed by the delete marker when the versioning is suspended
+		if err = s.checkLegalHold(ctx, bucket, object); err != nil {
+			return ObjectInfo{}, err
+		}
+		return s.deleteObjectMarker(ctx, bucket, object, status)
+	}
+
 	meta, err := s.getObjectInfo(ctx, bucket, object)
 	if err != nil {
-		return err
+		return ObjectInfo{}, err
+	}
+	if meta.LegalHold {
+		return ObjectInfo{}, ObjectUnderLegalHold{Bucket: bucket, Object: object}
 	}
 	cid, err := cid.Decode(meta.Cid)
 	if err != nil {
-		return err
+		return ObjectInfo{}, err
 	}
 
-	if err = s.Db.Delete(getObjectKey(bucket, object)); err != nil {
-		return err
+	// the object info is moved to the trash in the soft delete mode, otherwise its data is marked to delete
+	batch := s.Db.NewBatch()
+	if s.trashRetention > 0 {
+		err = s.trashObject(batch, bucket, object, meta)
+	} else {
+		err = batchMarkObjetToDelete(batch, cid)
 	}
-
-	if err = s.markObjetToDelete(cid); err != nil {
-		log.Errorw("mark Objet to delete error", "bucket", bucket, "object", object, "cid", meta.ETag, "error", err)
+	if err != nil {
+		return ObjectInfo{}, err
 	}
-	return nil
+	batch.Delete(getObjectKey(bucket, object))
+	batchDeleteCidRef(batch, meta.Cid, bucket, object)
+	if err = s.writeObjectBatch(bucket, object, batch); err != nil {
+		return ObjectInfo{}, err
+	}
+	s.objectFilters.deleted(bucket, object)
+	return meta, nil
 }
 
+// CleanObjectsInBucket deletes all the objects of the bucket with all their versions
 func (s *StorageSys) CleanObjectsInBucket(ctx context.Context, bucket string) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	// the noncurrent versions go first, so that none of them becomes the current object
+	versionsKey := fmt.Sprintf(allVersionsPrefixFormat, bucket, "")
+	versions, err := s.Db.ReadAllChan(ctx, versionsKey, "")
+	if err != nil {
+		return err
+	}
+	for entry := range versions {
+		if err = s.purgeObjectVersions(ctx, bucket, strings.TrimPrefix(entry.Key, versionsKey)); err != nil {
+			return err
+		}
+	}
+
 	prefixKey := fmt.Sprintf(allObjectPrefixFormat, bucket, "")
 	all, err := s.Db.ReadAllChan(ctx, prefixKey, "")
 	if err != nil {
@@ -312,7 +889,12 @@ func (s *StorageSys) CleanObjectsInBucket(ctx context.Context, bucket string) er
 		if err = entry.UnmarshalValue(&o); err != nil {
 			return err
 		}
-		if err = s.DeleteObject(ctx, bucket, o.Name); err != nil {
+		o.upgrade()
+		versionID := o.VersionID
+		if versionID == "" {
+			versionID = NullVersionID
+		}
+		if _, err = s.DeleteObject(ctx, bucket, o.Name, versionID); err != nil {
 			return err
 		}
 	}
@@ -342,8 +924,30 @@ type ListObjectsInfo struct {
 }
 
 // ListObjects list user object
+//
+// The listing starts strictly after the marker, and NextMarker is the key of the last object
+// or common prefix of a truncated page, so the pages never overlap.
+//
+// A page reads a snapshot of the db, so objects put or deleted while it runs don't show
+// up or vanish halfway. Each page takes a fresh snapshot, so a client listing again with
+// a marker sees its own writes. Only the continuation tokens of ListObjectsV2 resume on
+// the snapshot of the previous page.
 // TODO use more params
 func (s *StorageSys) ListObjects(ctx context.Context, bucket string, prefix string, marker string, delimiter string, maxKeys int) (loi ListObjectsInfo, err error) {
+	return s.listObjects(ctx, bucket, prefix, marker, delimiter, maxKeys, nil)
+}
+
+// listObjects lists the objects after the marker on the snapshot, which stays the caller's.
+// A fresh snapshot is read and released if snap is nil.
+func (s *StorageSys) listObjects(ctx context.Context, bucket string, prefix string, marker string, delimiter string, maxKeys int, snap metadb.Snapshot) (loi ListObjectsInfo, err error) {
+	prefix, err = s.objectKey(ctx, bucket, prefix)
+	if err != nil {
+		return loi, err
+	}
+	marker, err = s.objectKey(ctx, bucket, marker)
+	if err != nil {
+		return loi, err
+	}
 	if maxKeys == 0 {
 		return loi, nil
 	}
@@ -356,26 +960,61 @@ func (s *StorageSys) ListObjects(ctx context.Context, bucket string, prefix stri
 		// itself is the prefix and max-keys=1 in such scenarios
 		// we can simply verify locally if such an object exists
 		// to avoid the need for ListObjects().
-		objInfo, err := s.GetObjectInfo(ctx, bucket, prefix)
+		objInfo, err := s.GetObjectInfo(ctx, bucket, prefix, "")
 		if err == nil {
 			loi.Objects = append(loi.Objects, objInfo)
 			return loi, nil
 		}
 	}
 
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
 	seekKey := ""
 	if marker != "" {
-		seekKey = fmt.Sprintf(allObjectSeekKeyFormat, bucket, marker)
+		if strings.HasPrefix(marker, prefix) {
+			// the listing resumes after the marker
+			seekKey = fmt.Sprintf(allObjectSeekKeyFormat, bucket, marker)
+		} else if marker > prefix {
+			// every key of the prefix is before the marker
+			return loi, nil
+		}
+		// else every key of the prefix is after the marker
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if snap == nil {
+		if snap, err = s.Db.GetSnapshot(); err != nil {
+			return loi, err
+		}
+		defer snap.Release()
 	}
 	prefixKey := fmt.Sprintf(allObjectPrefixFormat, bucket, prefix)
-	all, err := s.Db.ReadAllChan(ctx, prefixKey, seekKey)
+	all, err := snap.ReadAllChan(ctx, prefixKey, seekKey)
 	if err != nil {
 		return loi, err
 	}
+	bucketKey := fmt.Sprintf(allObjectPrefixFormat, bucket, "")
+	// the keys sharing the part up to the delimiter after the prefix are rolled up into one common prefix,
+	// which counts as a key against the max keys. The listing resumed after a common prefix skips its keys.
+	commonPrefix := marker
 	index := 0
+	last := ""
 	for entry := range all {
+		name := strings.TrimPrefix(entry.Key, bucketKey)
+		if delimiter != "" {
+			if i := strings.Index(name[len(prefix):], delimiter); i >= 0 {
+				if p := name[:len(prefix)+i+len(delimiter)]; p != commonPrefix {
+					if index == maxKeys {
+						loi.IsTruncated = true
+						break
+					}
+					commonPrefix = p
+					index++
+					loi.Prefixes = append(loi.Prefixes, p)
+					last = p
+				}
+				continue
+			}
+		}
 		if index == maxKeys {
 			loi.IsTruncated = true
 			break
@@ -384,22 +1023,33 @@ func (s *StorageSys) ListObjects(ctx context.Context, bucket string, prefix stri
 		if err = entry.UnmarshalValue(&o); err != nil {
 			return loi, err
 		}
+		o.upgrade()
 		index++
 		loi.Objects = append(loi.Objects, o)
+		// the next page seeks past the key rather than the name stored in the object info
+		last = name
 	}
 	if loi.IsTruncated {
-		loi.NextMarker = loi.Objects[len(loi.Objects)-1].Name
+		loi.NextMarker = last
 	}
 
 	return loi, nil
 }
 
+// EmptyBucket returns whether the bucket has neither objects nor versions of the objects
 func (s *StorageSys) EmptyBucket(ctx context.Context, bucket string) (bool, error) {
-	loi, err := s.ListObjects(ctx, bucket, "", "", "", 1)
-	if err != nil {
-		return false, err
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for _, prefix := range []string{allObjectPrefixFormat, allVersionsPrefixFormat} {
+		all, err := s.Db.ReadAllChan(ctx, fmt.Sprintf(prefix, bucket, ""), "")
+		if err != nil {
+			return false, err
+		}
+		if _, ok := <-all; ok {
+			return false, nil
+		}
 	}
-	return len(loi.Objects) == 0, nil
+	return true, nil
 }
 
 // ListObjectsV2Info - container for list objects version 2.
@@ -427,19 +1077,63 @@ type ListObjectsV2Info struct {
 }
 
 // ListObjectsV2 list objects
+//
+// When the result is truncated the snapshot of the page is kept for listSnapshotTTL, and
+// the request for the next page (same bucket, prefix and delimiter, with NextContinuationToken as the
+// token) goes on reading it. So a whole pagination sequence sees the objects as they were
+// when its first page was listed, no key is skipped or returned twice, and the writes made
+// between the pages show up in the next listing, not in the pages left. A listing started
+// after a key with StartAfter reads a fresh snapshot. Once the snapshot is gone (expired,
+// or too many listings in flight) the next page falls back to a fresh snapshot.
+//
+// The continuation token is opaque to the client. It carries a random id minted for the
+// listing, which the snapshot is kept by, so listings of the same prefix running at the same
+// time never read or release each other's snapshot. A snapshot is taken by the first request
+// with the token: the token replayed later, or with another prefix or delimiter, reads a fresh
+// snapshot under a new id. See ParseContinuationToken.
+//
+// The token also carries the key the next page starts after, not a position in the listing,
+// so it stays valid when the bucket changes between the pages: the page resumed on a fresh
+// snapshot starts at the first key after the token, whether the key of the token still exists
+// or not. The keys put or deleted after the token show up or vanish then, the keys before it
+// are never returned again.
 func (s *StorageSys) ListObjectsV2(ctx context.Context, bucket string, prefix string, continuationToken string, delimiter string, maxKeys int, owner bool, startAfter string) (ListObjectsV2Info, error) {
-	marker := continuationToken
-	if marker == "" {
-		marker = startAfter
+	listID, marker := "", startAfter
+	var snap metadb.Snapshot
+	if continuationToken != "" {
+		var err error
+		if listID, marker, err = parseContinuationToken(continuationToken); err != nil {
+			return ListObjectsV2Info{}, err
+		}
+		snap = s.listSnapshots.take(listID, bucket, prefix, delimiter, marker)
+	}
+	if snap == nil {
+		var err error
+		if snap, err = s.Db.GetSnapshot(); err != nil {
+			return ListObjectsV2Info{}, err
+		}
+		listID = mustGetUUID()
 	}
-	loi, err := s.ListObjects(ctx, bucket, prefix, marker, delimiter, maxKeys)
+	keepSnap := false
+	defer func() {
+		if !keepSnap {
+			snap.Release()
+		}
+	}()
+	loi, err := s.listObjects(ctx, bucket, prefix, marker, delimiter, maxKeys, snap)
 	if err != nil {
 		return ListObjectsV2Info{}, err
 	}
+	nextToken := ""
+	if loi.IsTruncated {
+		s.listSnapshots.keep(listID, bucket, prefix, delimiter, loi.NextMarker, snap)
+		keepSnap = true
+		nextToken = newContinuationToken(listID, loi.NextMarker)
+	}
 	listV2Info := ListObjectsV2Info{
 		IsTruncated:           loi.IsTruncated,
 		ContinuationToken:     continuationToken,
-		NextContinuationToken: loi.NextMarker,
+		NextContinuationToken: nextToken,
 		Objects:               loi.Objects,
 		Prefixes:              loi.Prefixes,
 	}
@@ -457,6 +1151,10 @@ func mustGetUUID() string {
 }
 
 func (s *StorageSys) NewMultipartUpload(ctx context.Context, bucket string, object string, meta map[string]string) (MultipartInfo, error) {
+	object, err := s.objectKey(ctx, bucket, object)
+	if err != nil {
+		return MultipartInfo{}, err
+	}
 	bktlk := s.newBucketNSLock(bucket)
 	bktlkCtx, err := bktlk.GetRLock(ctx, globalOperationTimeout)
 	if err != nil {
@@ -487,6 +1185,10 @@ func (s *StorageSys) NewMultipartUpload(ctx context.Context, bucket string, obje
 }
 
 func (s *StorageSys) GetMultipartInfo(ctx context.Context, bucket string, object string, uploadID string) (MultipartInfo, error) {
+	object, err := s.objectKey(ctx, bucket, object)
+	if err != nil {
+		return MultipartInfo{}, err
+	}
 	bktlk := s.newBucketNSLock(bucket)
 	bktlkCtx, err := bktlk.GetRLock(ctx, globalOperationTimeout)
 	if err != nil {
@@ -512,7 +1214,18 @@ func (s *StorageSys) getMultipartInfo(ctx context.Context, bucket string, object
 	return info, err
 }
 
+// PutObjectPart stores the part like StoreObject stores an object, the body is streamed into
+// the dag by the balanced builder, with readahead for the large parts, so the memory used
+// doesn't grow with the size of the part.
 func (s *StorageSys) PutObjectPart(ctx context.Context, bucket string, object string, uploadID string, partID int, reader *hash.Reader, size int64, meta map[string]string) (pi objectPartInfo, err error) {
+	object, err = s.objectKey(ctx, bucket, object)
+	if err != nil {
+		return pi, err
+	}
+	// fail before the content is stored
+	if partID > s.maxParts {
+		return pi, TooManyParts{Parts: partID, MaxParts: s.maxParts}
+	}
 	bktlk := s.newBucketNSLock(bucket)
 	bktlkCtx, err := bktlk.GetRLock(ctx, globalOperationTimeout)
 	if err != nil {
@@ -521,17 +1234,19 @@ func (s *StorageSys) PutObjectPart(ctx context.Context, bucket string, object st
 	ctx = bktlkCtx.Context()
 	defer bktlk.RUnlock(bktlkCtx.Cancel)
 
-	root, err := s.store(ctx, reader, size)
+	data, checksum := newChecksumReader(reader)
+	root, err := s.store(ctx, data, size)
 	if err != nil {
 		return pi, err
 	}
 
 	partInfo := objectPartInfo{
-		Number:  partID,
-		ETag:    reader.ETag().String(),
-		Cid:     root.String(),
-		Size:    size,
-		ModTime: time.Now().UTC(),
+		Number:         partID,
+		ETag:           reader.ETag().String(),
+		Cid:            root.String(),
+		Size:           size,
+		ModTime:        time.Now().UTC(),
+		ChecksumSHA256: encodeChecksum(checksum),
 	}
 
 	uploadIDLock := s.NewNSLock(bucket, lock.PathJoin(object, uploadID))
@@ -544,21 +1259,36 @@ func (s *StorageSys) PutObjectPart(ctx context.Context, bucket string, object st
 
 	mi, err := s.getMultipartInfo(ctx, bucket, object, uploadID)
 	if err != nil {
+		// the upload is aborted or completed, the part is referenced by nothing
+		if e := s.markObjetToDelete(root); e != nil {
+			log.Errorw("mark Objet to delete error", "bucket", bucket, "object", object, "cid", root.String(), "error", e)
+		}
 		return pi, err
 	}
 
-	mi.Parts = append(mi.Parts, partInfo)
-	err = s.Db.Put(getUploadKey(bucket, object, uploadID), mi)
-	if err != nil {
+	// the part uploaded again replaces the part of the number, its dag is marked to delete with the write
+	batch := s.Db.NewBatch()
+	if i := objectPartIndex(mi.Parts, partID); i >= 0 {
+		c, err := cid.Decode(mi.Parts[i].Cid)
+		if err != nil {
+			return pi, err
+		}
+		if err = batchMarkObjetToDelete(batch, c); err != nil {
+			return pi, err
+		}
+		mi.Parts[i] = partInfo
+	} else {
+		mi.Parts = append(mi.Parts, partInfo)
+	}
+	if err = batch.Put(getUploadKey(bucket, object, uploadID), mi); err != nil {
+		return pi, err
+	}
+	if err = s.Db.Write(batch); err != nil {
 		return pi, err
 	}
 	return partInfo, nil
 }
 
-func (s *StorageSys) removeMultipartInfo(ctx context.Context, bucket string, object string, uploadID string) error {
-	return s.Db.Delete(getUploadKey(bucket, object, uploadID))
-}
-
 // objectPartIndex - returns the index of matching object part number.
 func objectPartIndex(parts []objectPartInfo, partNumber int) int {
 	for i, part := range parts {
@@ -577,7 +1307,22 @@ func canonicalizeETag(etag string) string {
 	return etagRegex.ReplaceAllString(etag, "$1")
 }
 
+// CompleteMultiPartUpload links the DAGs of the parts by a new root, the data of the parts
+// is not read or stored again, only the linking nodes are added. The parts must be listed in
+// ascending order of the part number and their ETags must match the uploaded parts.
 func (s *StorageSys) CompleteMultiPartUpload(ctx context.Context, bucket string, object string, uploadID string, parts []datatypes.CompletePart) (oi ObjectInfo, err error) {
+	object, err = s.objectKey(ctx, bucket, object)
+	if err != nil {
+		return oi, err
+	}
+	if len(parts) > s.maxParts {
+		return oi, TooManyParts{Parts: len(parts), MaxParts: s.maxParts}
+	}
+	for i := 1; i < len(parts); i++ {
+		if parts[i].PartNumber <= parts[i-1].PartNumber {
+			return oi, s3utils.InvalidPartOrder{PartNumber: parts[i].PartNumber}
+		}
+	}
 	bktlk := s.newBucketNSLock(bucket)
 	bktlkCtx, err := bktlk.GetRLock(ctx, globalOperationTimeout)
 	if err != nil {
@@ -603,9 +1348,9 @@ func (s *StorageSys) CompleteMultiPartUpload(ctx context.Context, bucket string,
 		return oi, err
 	}
 
-	var objectSize int64
-	var links []dagpoolcli.LinkInfo
-	for i, part := range parts {
+	// every listed part must be uploaded before the sizes of the parts are checked
+	gotParts := make([]objectPartInfo, 0, len(parts))
+	for _, part := range parts {
 		partIndex := objectPartIndex(mi.Parts, part.PartNumber)
 		if partIndex < 0 {
 			invp := s3utils.InvalidPart{
@@ -617,18 +1362,26 @@ func (s *StorageSys) CompleteMultiPartUpload(ctx context.Context, bucket string,
 		gotPart := mi.Parts[partIndex]
 
 		// ensure that part ETag is canonicalized to strip off extraneous quotes
-		part.ETag = canonicalizeETag(part.ETag)
-		if gotPart.ETag != part.ETag {
+		if gotPart.ETag != canonicalizeETag(part.ETag) {
 			invp := s3utils.InvalidPart{
 				PartNumber: part.PartNumber,
 				ExpETag:    gotPart.ETag,
-				GotETag:    part.ETag,
+				GotETag:    canonicalizeETag(part.ETag),
 			}
 			return oi, invp
 		}
+		gotParts = append(gotParts, gotPart)
+	}
+
+	var objectSize int64
+	var links []dagpoolcli.LinkInfo
+	objParts := make([]ObjectPartInfo, 0, len(parts))
+	for i, part := range parts {
+		gotPart := gotParts[i]
+		part.ETag = gotPart.ETag
 
 		// All parts except the last part has to be at least 5MB.
-		if (i < len(parts)-1) && !(gotPart.Size >= consts.MinPartSize) {
+		if (i < len(parts)-1) && !(gotPart.Size >= s.minPartSize) {
 			return oi, s3utils.PartTooSmall{
 				PartNumber: part.PartNumber,
 				PartSize:   gotPart.Size,
@@ -638,6 +1391,7 @@ func (s *StorageSys) CompleteMultiPartUpload(ctx context.Context, bucket string,
 
 		// Save for total object size.
 		objectSize += gotPart.Size
+		objParts = append(objParts, ObjectPartInfo{Number: part.PartNumber, Size: gotPart.Size, ETag: gotPart.ETag, ChecksumSHA256: gotPart.ChecksumSHA256, Cid: gotPart.Cid})
 
 		c, err := cid.Decode(gotPart.Cid)
 		if err != nil {
@@ -649,25 +1403,43 @@ func (s *StorageSys) CompleteMultiPartUpload(ctx context.Context, bucket string,
 		}
 		links = append(links, linkInfo)
 	}
+
+	// the root is built under the lock, so that no root linking the parts of the upload
+	// is left if the overwrite is rejected
+	lk := s.NewNSLock(bucket, object)
+	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	ctx = lkctx.Context()
+	defer lk.Unlock(lkctx.Cancel)
+
+	if err = s.checkOverwrite(ctx, bucket, object); err != nil {
+		return oi, err
+	}
 	root, err := dagpoolcli.BuildDataCidByLinks(ctx, s.DagPool, s.CidBuilder, links)
 	if err != nil {
 		return oi, err
 	}
 	etag := ComputeCompleteMultipartMD5(parts)
 	objInfo := ObjectInfo{
-		Bucket:           bucket,
-		Name:             object,
-		ModTime:          time.Now().UTC(),
-		Size:             objectSize,
-		IsDir:            false,
-		ETag:             etag,
-		Cid:              root.String(),
-		VersionID:        "",
-		IsLatest:         true,
-		DeleteMarker:     false,
-		ContentType:      mi.MetaData[strings.ToLower(consts.ContentType)],
-		ContentEncoding:  mi.MetaData[strings.ToLower(consts.ContentEncoding)],
-		SuccessorModTime: time.Now().UTC(),
+		Bucket:             bucket,
+		Name:               object,
+		ModTime:            time.Now().UTC(),
+		Size:               objectSize,
+		IsDir:              false,
+		ETag:               etag,
+		Cid:                root.String(),
+		VersionID:          "",
+		IsLatest:           true,
+		DeleteMarker:       false,
+		ContentType:        mi.MetaData[strings.ToLower(consts.ContentType)],
+		ContentEncoding:    mi.MetaData[strings.ToLower(consts.ContentEncoding)],
+		CacheControl:       mi.MetaData[strings.ToLower(consts.CacheControl)],
+		ContentDisposition: mi.MetaData[strings.ToLower(consts.ContentDisposition)],
+		ContentLanguage:    mi.MetaData[strings.ToLower(consts.ContentLanguage)],
+		Parts:              objParts,
+		SuccessorModTime:   time.Now().UTC(),
 	}
 	// Update expires
 	if exp, ok := mi.MetaData[strings.ToLower(consts.Expires)]; ok {
@@ -675,32 +1447,40 @@ func (s *StorageSys) CompleteMultiPartUpload(ctx context.Context, bucket string,
 			objInfo.Expires = t.UTC()
 		}
 	}
+	objInfo.WebsiteRedirectLocation = mi.MetaData[strings.ToLower(consts.AmzWebsiteRedirectLocation)]
+	objInfo.UserDefined = userDefinedMetadata(mi.MetaData)
 
-	lk := s.NewNSLock(bucket, object)
-	lkctx, err := lk.GetLock(ctx, globalOperationTimeout)
-	if err != nil {
+	// the old data is marked to delete or kept as a version and the MultipartInfo is removed with the write of the object info,
+	// the uploaded parts which aren't listed are referenced by nothing then and are marked to delete with it
+	batch := s.Db.NewBatch()
+	if err = s.batchPutLatestVersion(ctx, batch, bucket, object, &objInfo); err != nil {
 		return ObjectInfo{}, err
 	}
-	ctx = lkctx.Context()
-	defer lk.Unlock(lkctx.Cancel)
-
-	// Has old file?
-	s.checkAndDeleteObjectData(ctx, bucket, object)
-
-	err = s.Db.Put(getObjectKey(bucket, object), objInfo)
-	if err != nil {
-		return ObjectInfo{}, err
+	for _, part := range mi.Parts {
+		if objectPartIndex(gotParts, part.Number) >= 0 {
+			continue
+		}
+		c, err := cid.Decode(part.Cid)
+		if err != nil {
+			return ObjectInfo{}, err
+		}
+		if err = batchMarkObjetToDelete(batch, c); err != nil {
+			return ObjectInfo{}, err
+		}
 	}
-
-	// remove MultipartInfo
-	err = s.removeMultipartInfo(ctx, bucket, object, uploadID)
-	if err != nil {
-		log.Errorw("remove MultipartInfo error", "bucket", bucket, "object", object, "uploadID", uploadID, "error", err)
+	batch.Delete(getUploadKey(bucket, object, uploadID))
+	if err = s.writeObjectBatch(bucket, object, batch); err != nil {
+		return ObjectInfo{}, err
 	}
+	s.objectFilters.added(bucket, object)
 	return objInfo, nil
 }
 
 func (s *StorageSys) AbortMultipartUpload(ctx context.Context, bucket string, object string, uploadID string) error {
+	object, err := s.objectKey(ctx, bucket, object)
+	if err != nil {
+		return err
+	}
 	bktlk := s.newBucketNSLock(bucket)
 	bktlkCtx, err := bktlk.GetRLock(ctx, globalOperationTimeout)
 	if err != nil {
@@ -712,7 +1492,11 @@ func (s *StorageSys) AbortMultipartUpload(ctx context.Context, bucket string, ob
 	if !s.hasBucket(ctx, bucket) {
 		return BucketNotFound{Bucket: bucket}
 	}
+	return s.abortMultipartUpload(ctx, bucket, object, uploadID)
+}
 
+// abortMultipartUpload removes the upload, the dags of its parts are removed by the object GC
+func (s *StorageSys) abortMultipartUpload(ctx context.Context, bucket string, object string, uploadID string) error {
 	uploadIDLock := s.NewNSLock(bucket, lock.PathJoin(object, uploadID))
 	ulkctx, err := uploadIDLock.GetLock(ctx, globalOperationTimeout)
 	if err != nil {
@@ -726,23 +1510,19 @@ func (s *StorageSys) AbortMultipartUpload(ctx context.Context, bucket string, ob
 		return err
 	}
 
+	// the parts are marked to delete with the removal of the MultipartInfo
+	batch := s.Db.NewBatch()
 	for _, part := range mi.Parts {
 		c, err := cid.Decode(part.Cid)
 		if err != nil {
 			return err
 		}
-
-		if err = s.markObjetToDelete(c); err != nil {
-			log.Errorw("mark Objet to delete error", "bucket", bucket, "object", object, "cid", part.ETag, "error", err)
+		if err = batchMarkObjetToDelete(batch, c); err != nil {
+			return err
 		}
 	}
-
-	// remove MultipartInfo
-	err = s.removeMultipartInfo(ctx, bucket, object, uploadID)
-	if err != nil {
-		log.Errorw("remove MultipartInfo error", "bucket", bucket, "object", object, "uploadID", uploadID, "error", err)
-	}
-	return nil
+	batch.Delete(getUploadKey(bucket, object, uploadID))
+	return s.Db.Write(batch)
 }
 
 // ListPartsInfo - represents list of all parts.
@@ -781,6 +1561,10 @@ type ListPartsInfo struct {
 }
 
 func (s *StorageSys) ListObjectParts(ctx context.Context, bucket, object, uploadID string, partNumberMarker, maxParts int) (result ListPartsInfo, err error) {
+	object, err = s.objectKey(ctx, bucket, object)
+	if err != nil {
+		return result, err
+	}
 	bktlk := s.newBucketNSLock(bucket)
 	bktlkCtx, err := bktlk.GetRLock(ctx, globalOperationTimeout)
 	if err != nil {
@@ -910,6 +1694,14 @@ type ListMultipartsInfo struct {
 }
 
 func (s *StorageSys) ListMultipartUploads(ctx context.Context, bucket, prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (result ListMultipartsInfo, err error) {
+	prefix, err = s.objectKey(ctx, bucket, prefix)
+	if err != nil {
+		return result, err
+	}
+	keyMarker, err = s.objectKey(ctx, bucket, keyMarker)
+	if err != nil {
+		return result, err
+	}
 	bktlk := s.newBucketNSLock(bucket)
 	bktlkCtx, err := bktlk.GetRLock(ctx, globalOperationTimeout)
 	if err != nil {
@@ -968,6 +1760,11 @@ func (s *StorageSys) markObjetToDelete(c cid.Cid) error {
 	return s.Db.Put(newDelObjectKey(), c.String())
 }
 
+// batchMarkObjetToDelete adds the delete mark of the dag to the batch
+func batchMarkObjetToDelete(batch metadb.Batch, c cid.Cid) error {
+	return batch.Put(newDelObjectKey(), c.String())
+}
+
 func (s *StorageSys) deleteObjets(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, s.gcTimeout)
 	defer cancel()
@@ -990,8 +1787,10 @@ func (s *StorageSys) deleteObjets(ctx context.Context) error {
 			continue
 		}
 		if err = dagpoolcli.RemoveDAG(ctx, s.DagPool, c); err != nil {
+			// the dag pool may be partially degraded, keep the mark to retry in the next round
+			// and go on with the other objects
 			log.Errorw("remove DAG error", "cid", c.String(), "error", err)
-			break
+			continue
 		}
 		if err = s.Db.Delete(entry.Key); err != nil {
 			return err
@@ -1011,6 +1810,12 @@ func (s *StorageSys) processObjectGC(ctx context.Context) {
 		case <-timer.C:
 			if checkSystemIdle() {
 				log.Debug("starting object GC...")
+				if err := s.purgeTrash(ctx); err != nil {
+					log.Errorf("purge trash err: %v", err)
+				}
+				if err := s.purgeStaleUploads(ctx); err != nil {
+					log.Errorf("purge stale uploads err: %v", err)
+				}
 				if err := s.deleteObjets(ctx); err != nil {
 					log.Errorf("object GC err: %v", err)
 				}
@@ -3,27 +3,43 @@ package store
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/filedag-project/filedag-storage/dag/pool/client"
-	"github.com/filedag-project/filedag-storage/objectservice/uleveldb"
+	"github.com/filedag-project/filedag-storage/objectservice/consts"
+	"github.com/filedag-project/filedag-storage/objectservice/datatypes"
+	"github.com/filedag-project/filedag-storage/objectservice/lock"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
+	"github.com/filedag-project/filedag-storage/objectservice/utils/hash"
+	blocks "github.com/ipfs/go-block-format"
 	"github.com/ipfs/go-blockservice"
+	"github.com/ipfs/go-cid"
 	offline "github.com/ipfs/go-ipfs-exchange-offline"
+	ipld "github.com/ipfs/go-ipld-format"
 	"github.com/ipfs/go-merkledag"
+	mdtest "github.com/ipfs/go-merkledag/test"
+	ft "github.com/ipfs/go-unixfs"
+	"io"
 	"io/ioutil"
+	"runtime"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestStorageSys_Object(t *testing.T) {
 	poolCli, done := client.NewMockPoolClient(t)
 	defer done()
-	db, _ := uleveldb.OpenDb(t.TempDir())
 	dagServ := merkledag.NewDAGService(blockservice.New(poolCli, offline.Exchange(poolCli)))
-	s := NewStorageSys(context.TODO(), dagServ, db)
-	mbsys := NewBucketMetadataSys(db)
-	mbsys.CreateBucket(context.TODO(), "testbucket", "", "")
-	s.SetNewBucketNSLock(mbsys.NewNSLock)
-	s.SetHasBucket(mbsys.HasBucket)
-	r := ioutil.NopCloser(bytes.NewReader([]byte("123456")))
+	s, _ := newTestStorageSysWithDAG(t, dagServ)
+	r, err := hash.NewReader(bytes.NewReader([]byte("123456")), 6, "", "", 6)
+	if err != nil {
+		t.Fatal(err)
+	}
 	ctx := context.TODO()
 	object, err := s.StoreObject(ctx, "testbucket", "testobject", r, 6, map[string]string{})
 	if err != nil {
@@ -31,7 +47,7 @@ func TestStorageSys_Object(t *testing.T) {
 		return
 	}
 	fmt.Printf("object:%v", object)
-	getObject, i, err := s.GetObject(ctx, "testbucket", "testobject")
+	getObject, i, err := s.GetObject(ctx, "testbucket", "testobject", "", nil)
 	if err != nil {
 		fmt.Println(err)
 		return
@@ -40,3 +56,1325 @@ func TestStorageSys_Object(t *testing.T) {
 	all, _ := ioutil.ReadAll(i)
 	fmt.Println(string(all))
 }
+
+// TestStorageSys_StoreObjectUnknownSize stores a content of unknown size like a chunked transfer,
+// the size of the object is the size of the content read
+func TestStorageSys_StoreObjectUnknownSize(t *testing.T) {
+	s, _ := newTestStorageSys(t)
+	ctx := context.TODO()
+	content := bytes.Repeat([]byte("chunked"), 1000)
+	r, err := hash.NewReader(bytes.NewReader(content), -1, "", "", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oi, err := s.StoreObject(ctx, "testbucket", "testobject", r, -1, map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := md5.Sum(content)
+	if oi.Size != int64(len(content)) || oi.ETag != hex.EncodeToString(sum[:]) {
+		t.Fatalf("unexpected size %d and ETag %v", oi.Size, oi.ETag)
+	}
+	_, reader, err := s.GetObject(ctx, "testbucket", "testobject", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if data, err := ioutil.ReadAll(reader); err != nil || !bytes.Equal(data, content) {
+		t.Fatalf("unexpected content of %d bytes, %v", len(data), err)
+	}
+}
+
+// recordingDAGService records the nodes added to the dag service
+type recordingDAGService struct {
+	ipld.DAGService
+	added []cid.Cid
+}
+
+func (d *recordingDAGService) Add(ctx context.Context, nd ipld.Node) error {
+	d.added = append(d.added, nd.Cid())
+	return d.DAGService.Add(ctx, nd)
+}
+
+func TestStorageSys_StoreObjectBadDigest(t *testing.T) {
+	dagServ := &recordingDAGService{DAGService: mdtest.Mock()}
+	s, _ := newTestStorageSysWithDAG(t, dagServ)
+	ctx := context.TODO()
+	// the content spans several blocks, they are stored before the digest is known
+	content := bytes.Repeat([]byte("a"), 3<<20)
+	sum := md5.Sum([]byte("another content"))
+	r, err := hash.NewReader(bytes.NewReader(content), int64(len(content)), hex.EncodeToString(sum[:]), "", int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.StoreObject(ctx, "testbucket", "testobject", r, int64(len(content)), map[string]string{}); !errors.As(err, &hash.BadDigest{}) {
+		t.Fatalf("the content must be rejected, %v", err)
+	}
+	if _, err = s.GetObjectInfo(ctx, "testbucket", "testobject", ""); err != ErrObjectNotFound {
+		t.Fatalf("the rejected object must not be stored, %v", err)
+	}
+	if len(dagServ.added) == 0 {
+		t.Fatal("the blocks must be stored while the content is read")
+	}
+	// nothing is left in the pool
+	for _, c := range dagServ.added {
+		if _, err = dagServ.Get(ctx, c); !ipld.IsNotFound(err) {
+			t.Fatalf("the node %v of the rejected content is kept, %v", c, err)
+		}
+	}
+}
+
+func TestStorageSys_ListObjectsSnapshot(t *testing.T) {
+	poolCli, done := client.NewMockPoolClient(t)
+	defer done()
+	dagServ := merkledag.NewDAGService(blockservice.New(poolCli, offline.Exchange(poolCli)))
+	s, _ := newTestStorageSysWithDAG(t, dagServ)
+	ctx := context.TODO()
+	putObject := func(object string) {
+		r, err := hash.NewReader(bytes.NewReader([]byte("123456")), 6, "", "", 6)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = s.StoreObject(ctx, "testbucket", object, r, 6, map[string]string{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var want []string
+	for i := 0; i < 10; i++ {
+		object := fmt.Sprintf("obj%02d", i)
+		putObject(object)
+		want = append(want, object)
+	}
+
+	var got []string
+	token := ""
+	for page := 0; ; page++ {
+		info, err := s.ListObjectsV2(ctx, "testbucket", "obj", token, "", 3, false, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, o := range info.Objects {
+			got = append(got, o.Name)
+		}
+		if !info.IsTruncated {
+			break
+		}
+		token = info.NextContinuationToken
+		// writes between the pages must not change the listing sequence
+		putObject(fmt.Sprintf("obj%02d-new", page*3+4))
+		if deleted := page*3 + 5; deleted < 10 {
+			if _, err = s.DeleteObject(ctx, "testbucket", fmt.Sprintf("obj%02d", deleted), ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("Expected listing %v, got %v", want, got)
+	}
+
+	// a new listing sees the writes
+	loi, err := s.ListObjects(ctx, "testbucket", "obj", "", "", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loi.Objects) != 11 {
+		t.Fatalf("Expected 11 objects, got %d", len(loi.Objects))
+	}
+
+	// the pages of ListObjects read fresh snapshots, a client listing with a marker sees its own writes
+	loi, err = s.ListObjects(ctx, "testbucket", "obj", "", "", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	putObject("obj99")
+	loi, err = s.ListObjects(ctx, "testbucket", "obj", loi.NextMarker, "", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := loi.Objects[len(loi.Objects)-1].Name; last != "obj99" {
+		t.Fatalf("Expected the page after the marker to end with the new object, got %v", last)
+	}
+}
+
+func TestStorageSys_ListObjectsMarker(t *testing.T) {
+	s, _ := newTestStorageSys(t)
+	ctx := context.TODO()
+	for _, object := range []string{"a/1", "b/1", "b/2", "b/3", "c/1"} {
+		r, err := hash.NewReader(bytes.NewReader([]byte("123456")), 6, "", "", 6)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = s.StoreObject(ctx, "testbucket", object, r, 6, map[string]string{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		prefix   string
+		marker   string
+		expected []string
+	}{
+		{name: "no marker", prefix: "b/", expected: []string{"b/1", "b/2", "b/3"}},
+		{name: "marker before the prefix", prefix: "b/", marker: "a/1", expected: []string{"b/1", "b/2", "b/3"}},
+		{name: "marker before the prefix, not an object", prefix: "b/", marker: "a/zzz", expected: []string{"b/1", "b/2", "b/3"}},
+		{name: "marker is a prefix of the prefix", prefix: "b/", marker: "b", expected: []string{"b/1", "b/2", "b/3"}},
+		{name: "marker within the prefix", prefix: "b/", marker: "b/1", expected: []string{"b/2", "b/3"}},
+		{name: "marker within the prefix, not an object", prefix: "b/", marker: "b/15", expected: []string{"b/2", "b/3"}},
+		{name: "marker is the prefix", prefix: "b/", marker: "b/", expected: []string{"b/1", "b/2", "b/3"}},
+		{name: "marker is the last key of the prefix", prefix: "b/", marker: "b/3", expected: nil},
+		{name: "marker after the prefix", prefix: "b/", marker: "c/1", expected: nil},
+		{name: "marker after the prefix, not an object", prefix: "b/", marker: "b0", expected: nil},
+		{name: "marker without a prefix", prefix: "", marker: "b/2", expected: []string{"b/3", "c/1"}},
+		{name: "marker after every key", prefix: "", marker: "d", expected: nil},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			loi, err := s.ListObjects(ctx, "testbucket", testCase.prefix, testCase.marker, "", 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, o := range loi.Objects {
+				got = append(got, o.Name)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(testCase.expected) {
+				t.Fatalf("expected %v, but instead found %v", testCase.expected, got)
+			}
+			if loi.IsTruncated {
+				t.Fatal("expected the listing not to be truncated")
+			}
+		})
+	}
+}
+
+// failingDAGService fails to read the dags of the failing cids, like blocks on a degraded dag node
+type failingDAGService struct {
+	ipld.DAGService
+	failing map[cid.Cid]bool
+}
+
+func (f *failingDAGService) Get(ctx context.Context, c cid.Cid) (ipld.Node, error) {
+	if f.failing[c] {
+		return nil, errors.New("dag node is unavailable")
+	}
+	return f.DAGService.Get(ctx, c)
+}
+
+func TestStorageSys_DeleteObjectsPartialFailure(t *testing.T) {
+	poolCli, done := client.NewMockPoolClient(t)
+	defer done()
+	db := openTestDB(t)
+	dagServ := &failingDAGService{
+		DAGService: merkledag.NewDAGService(blockservice.New(poolCli, offline.Exchange(poolCli))),
+		failing:    make(map[cid.Cid]bool),
+	}
+	s := NewStorageSys(context.TODO(), dagServ, db)
+
+	// half of the dags are on the failing node
+	for i := 0; i < 10; i++ {
+		c := blocks.NewBlock([]byte(fmt.Sprintf("object%d", i))).Cid()
+		if i%2 == 0 {
+			dagServ.failing[c] = true
+		}
+		if err := s.markObjetToDelete(c); err != nil {
+			t.Fatalf("markObjetToDelete err: %v", err)
+		}
+	}
+	if err := s.deleteObjets(context.TODO()); err != nil {
+		t.Fatalf("deleteObjets err: %v", err)
+	}
+
+	// only the failed dags are kept to retry
+	all, err := db.ReadAllChan(context.TODO(), allDeletePrefixFormat, "")
+	if err != nil {
+		t.Fatalf("ReadAllChan err: %v", err)
+	}
+	remaining := 0
+	for entry := range all {
+		var root string
+		if err = entry.UnmarshalValue(&root); err != nil {
+			t.Fatalf("UnmarshalValue err: %v", err)
+		}
+		c, err := cid.Decode(root)
+		if err != nil {
+			t.Fatalf("decode cid err: %v", err)
+		}
+		if !dagServ.failing[c] {
+			t.Fatalf("the dag %s should be removed", c)
+		}
+		remaining++
+	}
+	if remaining != len(dagServ.failing) {
+		t.Fatalf("expected %d dags to retry, but instead found %d", len(dagServ.failing), remaining)
+	}
+}
+
+// addCountingDAGService counts the nodes added to the dag
+type addCountingDAGService struct {
+	ipld.DAGService
+	added int
+}
+
+func (c *addCountingDAGService) Add(ctx context.Context, nd ipld.Node) error {
+	c.added++
+	return c.DAGService.Add(ctx, nd)
+}
+
+func (c *addCountingDAGService) AddMany(ctx context.Context, nds []ipld.Node) error {
+	c.added += len(nds)
+	return c.DAGService.AddMany(ctx, nds)
+}
+
+func TestStorageSys_AppendObject(t *testing.T) {
+	dagServ := &addCountingDAGService{DAGService: mdtest.Mock()}
+	s, _ := newTestStorageSysWithDAG(t, dagServ)
+	ctx := context.TODO()
+	appendObject := func(data []byte) ObjectInfo {
+		r, err := hash.NewReader(bytes.NewReader(data), int64(len(data)), "", "", int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		oi, err := s.AppendObject(ctx, "testbucket", "log", r, int64(len(data)), map[string]string{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return oi
+	}
+	checkContent := func(want []byte) {
+		_, reader, err := s.GetObject(ctx, "testbucket", "log", "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("expected %d bytes of content, but instead found %d bytes", len(want), len(got))
+		}
+	}
+
+	// 3 chunks and their root
+	data := bytes.Repeat([]byte("0123456789abcdef"), 3*chunkSize/16)
+	oi := appendObject(data)
+	if dagServ.added != 4 {
+		t.Fatalf("expected 4 nodes added, but instead found %d", dagServ.added)
+	}
+	firstRoot, _ := cid.Decode(oi.Cid)
+
+	// 2 chunks, their root and the new root of the object
+	dagServ.added = 0
+	more := bytes.Repeat([]byte("fedcba9876543210"), 3*chunkSize/32)
+	oi = appendObject(more)
+	if dagServ.added != 4 {
+		t.Fatalf("expected 4 nodes added, but instead found %d", dagServ.added)
+	}
+	if oi.Size != int64(len(data)+len(more)) {
+		t.Fatalf("expected the size %d, but instead found %d", len(data)+len(more), oi.Size)
+	}
+	if !strings.HasSuffix(oi.ETag, "-2") {
+		t.Fatalf("expected the ETag of 2 appended contents, but instead found %s", oi.ETag)
+	}
+	data = append(data, more...)
+	checkContent(data)
+	// the links of the first root are linked by the new root
+	if _, err := dagServ.Get(ctx, firstRoot); err == nil {
+		t.Fatalf("expected the replaced root to be removed")
+	}
+
+	// a small content is a single node, the new root links it
+	dagServ.added = 0
+	oi = appendObject([]byte("tail"))
+	if dagServ.added != 2 {
+		t.Fatalf("expected 2 nodes added, but instead found %d", dagServ.added)
+	}
+	if !strings.HasSuffix(oi.ETag, "-3") {
+		t.Fatalf("expected the ETag of 3 appended contents, but instead found %s", oi.ETag)
+	}
+	checkContent(append(data, []byte("tail")...))
+}
+
+func TestStorageSys_CompleteMultiPartUploadByReference(t *testing.T) {
+	dagServ := &addCountingDAGService{DAGService: mdtest.Mock()}
+	s, _ := newTestStorageSysWithDAG(t, dagServ)
+	ctx := context.TODO()
+
+	mi, err := s.NewMultipartUpload(ctx, "testbucket", "big", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 40 parts of 100 chunks, every chunk links the same leaf so that the 4000MiB
+	// upload doesn't need 4000MiB of memory
+	const partCount, chunksPerPart = 40, 100
+	leaf := merkledag.NodeWithData(ft.FilePBData(bytes.Repeat([]byte("a"), chunkSize), uint64(chunkSize)))
+	if err = dagServ.Add(ctx, leaf); err != nil {
+		t.Fatal(err)
+	}
+	leafLink, err := ipld.MakeLink(leaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var completeParts []datatypes.CompletePart
+	for i := 1; i <= partCount; i++ {
+		od, err := client.NewUnixfsNodeFromDag(ft.EmptyFileNode())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < chunksPerPart; j++ {
+			if err = od.AddChild(leafLink, uint64(chunkSize)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		part, err := od.Commit()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = dagServ.Add(ctx, part); err != nil {
+			t.Fatal(err)
+		}
+		etag := fmt.Sprintf("%032x", i)
+		mi.Parts = append(mi.Parts, objectPartInfo{
+			ETag:   etag,
+			Cid:    part.Cid().String(),
+			Number: i,
+			Size:   int64(chunksPerPart * chunkSize),
+		})
+		completeParts = append(completeParts, datatypes.CompletePart{PartNumber: i, ETag: etag})
+	}
+	if err = s.Db.Put(getUploadKey("testbucket", "big", mi.UploadID), mi); err != nil {
+		t.Fatal(err)
+	}
+
+	dagServ.added = 0
+	oi, err := s.CompleteMultiPartUpload(ctx, "testbucket", "big", mi.UploadID, completeParts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only the root linking the parts is added
+	if dagServ.added != 1 {
+		t.Fatalf("expected 1 node added, but instead found %d", dagServ.added)
+	}
+	if want := int64(partCount * chunksPerPart * chunkSize); oi.Size != want {
+		t.Fatalf("expected the size %d, but instead found %d", want, oi.Size)
+	}
+	root, err := cid.Decode(oi.Cid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nd, err := dagServ.Get(ctx, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nd.Links()) != partCount {
+		t.Fatalf("expected the root to link %d parts, but instead found %d", partCount, len(nd.Links()))
+	}
+	if size := len(nd.RawData()); size > 4096 {
+		t.Fatalf("expected a small root, but instead found %d bytes", size)
+	}
+	for i, link := range nd.Links() {
+		if link.Cid.String() != mi.Parts[i].Cid {
+			t.Fatalf("expected the link %d to be the part %s, but instead found %s", i, mi.Parts[i].Cid, link.Cid)
+		}
+	}
+}
+
+// discardingDAGService counts the added bytes and drops the nodes, so that
+// the memory of a test storing a large content is the memory of the store path
+type discardingDAGService struct {
+	ipld.DAGService
+	addedBytes int
+}
+
+func (d *discardingDAGService) Add(ctx context.Context, nd ipld.Node) error {
+	d.addedBytes += len(nd.RawData())
+	return nil
+}
+
+func (d *discardingDAGService) AddMany(ctx context.Context, nds []ipld.Node) error {
+	for _, nd := range nds {
+		d.addedBytes += len(nd.RawData())
+	}
+	return nil
+}
+
+// heapSamplingReader generates the content and samples the live heap every 16 chunks it reads
+type heapSamplingReader struct {
+	remaining int64
+	sinceLast int
+	maxHeap   uint64
+}
+
+func (r *heapSamplingReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	for i := range p {
+		p[i] = byte(i)
+	}
+	r.remaining -= int64(len(p))
+	r.sinceLast += len(p)
+	if r.sinceLast >= 16*chunkSize {
+		r.sinceLast = 0
+		// the garbage of the other tests isn't counted
+		runtime.GC()
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+		if ms.HeapAlloc > r.maxHeap {
+			r.maxHeap = ms.HeapAlloc
+		}
+	}
+	return len(p), nil
+}
+
+func TestStorageSys_PutObjectPartStreaming(t *testing.T) {
+	dagServ := &discardingDAGService{DAGService: mdtest.Mock()}
+	s, _ := newTestStorageSysWithDAG(t, dagServ)
+	ctx := context.TODO()
+
+	mi, err := s.NewMultipartUpload(ctx, "testbucket", "big", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// larger than bigFileThreshold, the part is read ahead
+	const partSize = 2 * bigFileThreshold
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	body := &heapSamplingReader{remaining: partSize}
+	r, err := hash.NewReader(body, partSize, "", "", partSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pi, err := s.PutObjectPart(ctx, "testbucket", "big", mi.UploadID, 1, r, partSize, mi.MetaData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pi.Size != partSize {
+		t.Fatalf("expected the size %d, but instead found %d", partSize, pi.Size)
+	}
+	if dagServ.addedBytes < partSize {
+		t.Fatalf("expected at least %d bytes added, but instead found %d", partSize, dagServ.addedBytes)
+	}
+	// a few chunks in flight, never the whole part
+	if grown := int64(body.maxHeap) - int64(before.HeapAlloc); grown > partSize/8 {
+		t.Fatalf("expected the heap to stay bounded, but it grew %d bytes for a part of %d bytes", grown, partSize)
+	}
+}
+
+func TestStorageSys_MaxParts(t *testing.T) {
+	s, _ := newTestStorageSys(t)
+	ctx := context.TODO()
+	for _, maxParts := range []int{0, consts.MaxPartID + 1} {
+		if err := s.SetMaxParts(maxParts); err == nil {
+			t.Fatalf("expected the max parts %d to be rejected", maxParts)
+		}
+	}
+	if err := s.SetMaxParts(2); err != nil {
+		t.Fatal(err)
+	}
+
+	mi, err := s.NewMultipartUpload(ctx, "testbucket", "obj", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts []datatypes.CompletePart
+	for partID := 1; partID <= 3; partID++ {
+		r, err := hash.NewReader(strings.NewReader("123456"), 6, "", "", 6)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pi, err := s.PutObjectPart(ctx, "testbucket", "obj", mi.UploadID, partID, r, 6, mi.MetaData)
+		if partID > 2 {
+			if _, ok := err.(TooManyParts); !ok {
+				t.Fatalf("expected the part %d above the max parts to be rejected, but instead found %v", partID, err)
+			}
+			parts = append(parts, datatypes.CompletePart{PartNumber: partID, ETag: "123456"})
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts = append(parts, datatypes.CompletePart{PartNumber: partID, ETag: pi.ETag})
+	}
+	if _, err = s.CompleteMultiPartUpload(ctx, "testbucket", "obj", mi.UploadID, parts); err == nil {
+		t.Fatal("expected the upload of more parts than the max parts not to be completed")
+	} else if _, ok := err.(TooManyParts); !ok {
+		t.Fatalf("expected %T, but instead found %v", TooManyParts{}, err)
+	}
+	if _, err = s.CompleteMultiPartUpload(ctx, "testbucket", "obj", mi.UploadID, parts[:1]); err != nil {
+		t.Fatalf("expected the upload within the max parts to be completed, but got %v", err)
+	}
+}
+
+func TestStorageSys_GetObjectPart(t *testing.T) {
+	s, _ := newTestStorageSys(t)
+	ctx := context.TODO()
+
+	mi, err := s.NewMultipartUpload(ctx, "testbucket", "multipart", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := [][]byte{bytes.Repeat([]byte("a"), consts.MinPartSize), []byte("the last part")}
+	var completeParts []datatypes.CompletePart
+	for i, content := range contents {
+		r, err := hash.NewReader(bytes.NewReader(content), int64(len(content)), "", "", int64(len(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pi, err := s.PutObjectPart(ctx, "testbucket", "multipart", mi.UploadID, i+1, r, int64(len(content)), map[string]string{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		completeParts = append(completeParts, datatypes.CompletePart{PartNumber: i + 1, ETag: pi.ETag})
+	}
+	oi, err := s.CompleteMultiPartUpload(ctx, "testbucket", "multipart", mi.UploadID, completeParts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(oi.Parts) != len(contents) {
+		t.Fatalf("expected %d parts, but instead found %d", len(contents), len(oi.Parts))
+	}
+	oi, err = s.GetObjectInfo(ctx, "testbucket", "multipart", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(oi.Parts) != len(contents) {
+		t.Fatalf("expected %d stored parts, but instead found %d", len(contents), len(oi.Parts))
+	}
+	var offset int64
+	for i, content := range contents {
+		_, part, reader, err := s.GetObjectPart(ctx, "testbucket", "multipart", "", i+1, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, content) {
+			t.Fatalf("part %d: expected %d bytes of the part, but instead found %d bytes", i+1, len(content), len(got))
+		}
+		if part.Size != int64(len(content)) || part.ETag != completeParts[i].ETag {
+			t.Fatalf("part %d: unexpected part info %+v", i+1, part)
+		}
+		if off := oi.PartOffset(i + 1); off != offset {
+			t.Fatalf("part %d: expected the offset %d, but instead found %d", i+1, offset, off)
+		}
+		offset += part.Size
+	}
+	if _, _, _, err = s.GetObjectPart(ctx, "testbucket", "multipart", "", len(contents)+1, nil); !errors.As(err, &InvalidPartNumber{}) {
+		t.Fatalf("expected InvalidPartNumber, but instead found %v", err)
+	}
+
+	// an object which isn't completed by a multipart upload has the single part of its content
+	r, err := hash.NewReader(bytes.NewReader([]byte("123456")), 6, "", "", 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.StoreObject(ctx, "testbucket", "single", r, 6, map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	oi, part, reader, err := s.GetObjectPart(ctx, "testbucket", "single", "", 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(oi.Parts) != 0 || part.Size != 6 || string(got) != "123456" {
+		t.Fatalf("unexpected single part %+v of %d parts, content %q", part, len(oi.Parts), got)
+	}
+	if _, _, _, err = s.GetObjectPart(ctx, "testbucket", "single", "", 2, nil); !errors.As(err, &InvalidPartNumber{}) {
+		t.Fatalf("expected InvalidPartNumber, but instead found %v", err)
+	}
+}
+
+func TestStorageSys_GetObjectPartOfLinkedParts(t *testing.T) {
+	s, _ := newTestStorageSys(t)
+	s.minPartSize = 1
+	ctx := context.TODO()
+
+	testCases := []struct {
+		name  string
+		parts int
+	}{
+		// the root of a 1-part object is the dag of the part
+		{name: "one part", parts: 1},
+		// the parts of more than a node links are linked by the nested nodes
+		{name: "more parts than a node links", parts: 1025},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mi, err := s.NewMultipartUpload(ctx, "testbucket", "multipart", map[string]string{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			contents := make([][]byte, tc.parts)
+			var completeParts []datatypes.CompletePart
+			for i := range contents {
+				contents[i] = []byte(fmt.Sprintf("the content of part %d", i+1))
+				r, err := hash.NewReader(bytes.NewReader(contents[i]), int64(len(contents[i])), "", "", int64(len(contents[i])))
+				if err != nil {
+					t.Fatal(err)
+				}
+				pi, err := s.PutObjectPart(ctx, "testbucket", "multipart", mi.UploadID, i+1, r, int64(len(contents[i])), map[string]string{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				completeParts = append(completeParts, datatypes.CompletePart{PartNumber: i + 1, ETag: pi.ETag})
+			}
+			if _, err = s.CompleteMultiPartUpload(ctx, "testbucket", "multipart", mi.UploadID, completeParts); err != nil {
+				t.Fatal(err)
+			}
+			for _, partNumber := range []int{1, tc.parts / 2, tc.parts} {
+				if partNumber == 0 {
+					continue
+				}
+				_, _, reader, err := s.GetObjectPart(ctx, "testbucket", "multipart", "", partNumber, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := ioutil.ReadAll(reader)
+				reader.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, contents[partNumber-1]) {
+					t.Fatalf("part %d: expected %q, but instead found %q", partNumber, contents[partNumber-1], got)
+				}
+			}
+		})
+	}
+}
+
+func TestStorageSys_OverwriteProtection(t *testing.T) {
+	s, mbsys := newTestStorageSys(t)
+	s.SetOverwriteInterval(mbsys.GetOverwriteInterval)
+	ctx := context.TODO()
+	storeObject := func(object, content string) error {
+		r, err := hash.NewReader(strings.NewReader(content), int64(len(content)), "", "", int64(len(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = s.StoreObject(ctx, "testbucket", object, r, int64(len(content)), map[string]string{})
+		return err
+	}
+	// the overwrite protection is opt-in
+	if err := storeObject("obj", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := storeObject("obj", "2"); err != nil {
+		t.Fatalf("expected the overwrite without protection to succeed, but instead found %v", err)
+	}
+
+	if err := mbsys.UpdateBucketOverwriteProtection(ctx, "testbucket", &OverwriteProtection{MinimumIntervalSeconds: 60}); err != nil {
+		t.Fatal(err)
+	}
+	oi, err := s.GetObjectInfo(ctx, "testbucket", "obj", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = storeObject("obj", "3"); !errors.As(err, &ObjectOverwriteTooSoon{}) {
+		t.Fatalf("expected ObjectOverwriteTooSoon, but instead found %v", err)
+	}
+	if got, err := s.GetObjectInfo(ctx, "testbucket", "obj", ""); err != nil || got.ETag != oi.ETag {
+		t.Fatalf("expected the rejected overwrite to keep the object, but instead found %+v, %v", got, err)
+	}
+	// a new key isn't an overwrite
+	if err = storeObject("other", "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the window has elapsed
+	oi.ModTime = oi.ModTime.Add(-time.Minute)
+	if err = s.putObjectInfo("testbucket", "obj", oi); err != nil {
+		t.Fatal(err)
+	}
+	if err = storeObject("obj", "4"); err != nil {
+		t.Fatalf("expected the overwrite after the window to succeed, but instead found %v", err)
+	}
+
+	// a completed multipart upload is an overwrite too, the upload is kept so that it can be completed later
+	mi, err := s.NewMultipartUpload(ctx, "testbucket", "obj", map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := hash.NewReader(strings.NewReader("5"), 1, "", "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pi, err := s.PutObjectPart(ctx, "testbucket", "obj", mi.UploadID, 1, r, 1, map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := []datatypes.CompletePart{{PartNumber: 1, ETag: pi.ETag}}
+	if _, err = s.CompleteMultiPartUpload(ctx, "testbucket", "obj", mi.UploadID, parts); !errors.As(err, &ObjectOverwriteTooSoon{}) {
+		t.Fatalf("expected ObjectOverwriteTooSoon, but instead found %v", err)
+	}
+	if _, err = s.GetMultipartInfo(ctx, "testbucket", "obj", mi.UploadID); err != nil {
+		t.Fatalf("expected the upload to be kept, but instead found %v", err)
+	}
+
+	if err = mbsys.DeleteBucketOverwriteProtection(ctx, "testbucket"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.CompleteMultiPartUpload(ctx, "testbucket", "obj", mi.UploadID, parts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStorageSys_ForceUnlockObject(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+	s := NewStorageSys(context.TODO(), mdtest.Mock(), db)
+	ctx := context.TODO()
+	if _, err := s.ForceUnlockObject("testbucket", "obj", 0); !errors.As(err, &lock.LockNotHeld{}) {
+		t.Fatalf("force unlock a free lock, expected LockNotHeld, got %v", err)
+	}
+
+	// an operation which never unlocks
+	leaked := s.NewNSLock("testbucket", "obj")
+	if _, err := leaked.GetLock(ctx, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	waited := make(chan error, 1)
+	waiter := s.NewNSLock("testbucket", "obj")
+	go func() {
+		_, err := waiter.GetLock(ctx, 10*time.Second)
+		waited <- err
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		info, locked := s.GetObjectLockInfo("testbucket", "obj")
+		if !locked || !info.WriteLock || info.Holders != 1 {
+			t.Fatalf("unexpected lock info %+v, locked %v", info, locked)
+		}
+		if info.Waiters == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the waiter didn't wait for the lock")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// a lock held for less than minHeld isn't released
+	if _, err := s.ForceUnlockObject("testbucket", "obj", time.Hour); !errors.As(err, &lock.LockNotStale{}) {
+		t.Fatalf("expected LockNotStale, got %v", err)
+	}
+	select {
+	case err := <-waited:
+		t.Fatalf("the waiter took the lock before it was released: %v", err)
+	default:
+	}
+
+	info, err := s.ForceUnlockObject("testbucket", "obj", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Holders != 1 || info.Waiters != 1 {
+		t.Fatalf("unexpected released lock info %+v", info)
+	}
+	select {
+	case err = <-waited:
+		if err != nil {
+			t.Fatalf("the waiter failed to take the lock: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the waiter is still blocked after the lock was force released")
+	}
+	info, locked := s.GetObjectLockInfo("testbucket", "obj")
+	if !locked || info.Holders != 1 || info.Waiters != 0 {
+		t.Fatalf("unexpected lock info of the waiter %+v, locked %v", info, locked)
+	}
+
+	// the late unlock of the leaked holder doesn't release the lock of the waiter
+	leaked.Unlock(nil)
+	if info, locked = s.GetObjectLockInfo("testbucket", "obj"); !locked || info.Holders != 1 {
+		t.Fatalf("the late unlock released the lock of the waiter, lock info %+v, locked %v", info, locked)
+	}
+	if _, err = s.NewNSLock("testbucket", "obj").GetLock(ctx, 100*time.Millisecond); err == nil {
+		t.Fatal("the lock of the waiter was taken by another operation")
+	}
+	waiter.Unlock(nil)
+	if _, locked = s.GetObjectLockInfo("testbucket", "obj"); locked {
+		t.Fatal("the lock is still held after the waiter unlocked it")
+	}
+	leaked.Unlock(nil)
+	if _, err = s.NewNSLock("testbucket", "obj").GetLock(ctx, time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// crashingDB fails all the metadata writes after the first writes, like a crash in the middle of an operation
+type crashingDB struct {
+	metadb.DB
+	// writes the writes left before the crash, negative if it doesn't crash
+	writes int
+}
+
+var errCrashed = errors.New("crashed")
+
+func (db *crashingDB) write() error {
+	if db.writes == 0 {
+		return errCrashed
+	}
+	db.writes--
+	return nil
+}
+
+func (db *crashingDB) Put(key string, value interface{}) error {
+	if err := db.write(); err != nil {
+		return err
+	}
+	return db.DB.Put(key, value)
+}
+
+func (db *crashingDB) Delete(key string) error {
+	if err := db.write(); err != nil {
+		return err
+	}
+	return db.DB.Delete(key)
+}
+
+func (db *crashingDB) Write(b metadb.Batch) error {
+	if err := db.write(); err != nil {
+		return err
+	}
+	return db.DB.Write(b)
+}
+
+func TestStorageSys_InterruptedPut(t *testing.T) {
+	ctx := context.TODO()
+	for crashAfter := 0; crashAfter < 3; crashAfter++ {
+		db := &crashingDB{DB: openTestDB(t), writes: -1}
+		s := NewStorageSys(ctx, mdtest.Mock(), db)
+		s.SetObjectInfoCacheTTL(0)
+		mbsys := NewBucketMetadataSys(db)
+		mbsys.CreateBucket(ctx, "testbucket", "", "")
+		s.SetNewBucketNSLock(mbsys.NewNSLock)
+		s.SetHasBucket(mbsys.HasBucket)
+		storeObject := func(content string) (ObjectInfo, error) {
+			r, err := hash.NewReader(strings.NewReader(content), int64(len(content)), "", "", int64(len(content)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s.StoreObject(ctx, "testbucket", "obj", r, int64(len(content)), map[string]string{})
+		}
+		prior, err := storeObject("123456")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		db.writes = crashAfter
+		_, err = storeObject("1234567")
+		db.writes = -1
+
+		oi, e := s.GetObjectInfo(ctx, "testbucket", "obj", "")
+		if e != nil {
+			t.Fatalf("crash after %d writes: the object is lost: %v", crashAfter, e)
+		}
+		all, e := db.ReadAllChan(ctx, allDeletePrefixFormat, "")
+		if e != nil {
+			t.Fatal(e)
+		}
+		var marked []string
+		for entry := range all {
+			var root string
+			if e = entry.UnmarshalValue(&root); e != nil {
+				t.Fatal(e)
+			}
+			marked = append(marked, root)
+		}
+		switch {
+		case oi.Cid == prior.Cid:
+			// the prior state, the prior data isn't marked to delete
+			if err == nil || len(marked) != 0 {
+				t.Fatalf("crash after %d writes: the put err %v left the prior object with the dags %v marked to delete", crashAfter, err, marked)
+			}
+		default:
+			// the new state, the prior data is marked to delete
+			if err != nil || len(marked) != 1 || marked[0] != prior.Cid {
+				t.Fatalf("crash after %d writes: the put err %v left the new object with the dags %v marked to delete", crashAfter, err, marked)
+			}
+		}
+	}
+}
+
+func TestStorageSys_IdenticalOverwrite(t *testing.T) {
+	dagServ := &addCountingDAGService{DAGService: mdtest.Mock()}
+	s, _ := newTestStorageSysWithDAG(t, dagServ)
+	ctx := context.TODO()
+	storeObject := func(content, md5Hex, sha256Hex string) (ObjectInfo, error) {
+		r, err := hash.NewReader(strings.NewReader(content), int64(len(content)), md5Hex, sha256Hex, int64(len(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.StoreObject(ctx, "testbucket", "obj", r, int64(len(content)), map[string]string{"content-type": "text/plain"})
+	}
+	deleteMarks := func() int {
+		all, err := s.Db.ReadAllChan(ctx, allDeletePrefixFormat, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for range all {
+			n++
+		}
+		return n
+	}
+	content := "123456"
+	sha := sha256.Sum256([]byte(content))
+	sum := md5.Sum([]byte(content))
+	shaHex, md5Hex := hex.EncodeToString(sha[:]), hex.EncodeToString(sum[:])
+
+	stored, err := storeObject(content, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	added := dagServ.added
+	for _, declared := range [][2]string{{"", shaHex}, {md5Hex, ""}} {
+		time.Sleep(time.Millisecond)
+		oi, err := storeObject(content, declared[0], declared[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dagServ.added != added {
+			t.Fatalf("expected the identical content not to be stored again, but %d nodes were added", dagServ.added-added)
+		}
+		if n := deleteMarks(); n != 0 {
+			t.Fatalf("expected the dag of the identical content to be kept, but %d dags are marked to delete", n)
+		}
+		if oi.Cid != stored.Cid || oi.ETag != stored.ETag || oi.ChecksumSHA256 != stored.ChecksumSHA256 {
+			t.Fatalf("expected the content of %+v, but got %+v", stored, oi)
+		}
+		if !oi.ModTime.After(stored.ModTime) || oi.ContentType != "text/plain" {
+			t.Fatalf("expected the metadata to be updated, but got %+v", oi)
+		}
+	}
+	_, reader, err := s.GetObject(ctx, "testbucket", "obj", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(reader)
+	reader.Close()
+	if err != nil || string(got) != content {
+		t.Fatalf("expected the content %q, but got %q, err %v", content, got, err)
+	}
+
+	// a content not matching the declared checksum isn't accepted
+	if _, err = storeObject("654321", "", shaHex); err == nil {
+		t.Fatal("expected the sha256 mismatch to fail the store")
+	}
+	// the identical content without a declared checksum is stored again, the old dag is released
+	if _, err = storeObject(content, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if dagServ.added == added || deleteMarks() != 1 {
+		t.Fatalf("expected the content to be stored again and the old dag marked to delete, added %d nodes, %d marks", dagServ.added-added, deleteMarks())
+	}
+}
+
+func TestStorageSys_ListObjectsPagination(t *testing.T) {
+	s, _ := newTestStorageSys(t)
+	ctx := context.TODO()
+	// the keys are prefixes of the keys after them, e.g. 1, 1/, 10, 100 and 1000,
+	// so the markers of the pages are prefixes of the keys of the next pages
+	const objects = 5000
+	want := make(map[string]int)
+	for i := 0; len(want) < objects; i++ {
+		for _, object := range []string{strconv.Itoa(i), strconv.Itoa(i) + "/"} {
+			if len(want) == objects || (i%7 != 0 && strings.HasSuffix(object, "/")) {
+				continue
+			}
+			r, err := hash.NewReader(bytes.NewReader([]byte("123456")), 6, "", "", 6)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = s.StoreObject(ctx, "testbucket", object, r, 6, map[string]string{}); err != nil {
+				t.Fatal(err)
+			}
+			want[object] = 0
+		}
+	}
+
+	for _, maxKeys := range []int{1000, 999} {
+		got := make(map[string]int)
+		marker := ""
+		pages := 0
+		for {
+			loi, err := s.ListObjects(ctx, "testbucket", "", marker, "", maxKeys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pages++
+			if len(loi.Objects) > maxKeys {
+				t.Fatalf("the page has %d objects, more than the max keys %d", len(loi.Objects), maxKeys)
+			}
+			for _, o := range loi.Objects {
+				if o.Name <= marker {
+					t.Fatalf("the object %s of the page is not after the marker %s", o.Name, marker)
+				}
+				got[o.Name]++
+			}
+			if !loi.IsTruncated {
+				break
+			}
+			if loi.NextMarker != loi.Objects[len(loi.Objects)-1].Name {
+				t.Fatalf("the next marker %s is not the last key %s of the page", loi.NextMarker, loi.Objects[len(loi.Objects)-1].Name)
+			}
+			marker = loi.NextMarker
+		}
+		if expected := (objects + maxKeys - 1) / maxKeys; pages != expected {
+			t.Fatalf("expected %d pages of %d keys, but instead found %d", expected, maxKeys, pages)
+		}
+		for object := range want {
+			if got[object] != 1 {
+				t.Fatalf("the object %s is listed %d times by the pages of %d keys", object, got[object], maxKeys)
+			}
+		}
+		if len(got) != objects {
+			t.Fatalf("expected %d objects, but instead found %d", objects, len(got))
+		}
+	}
+}
+
+func TestStorageSys_ListObjectsDelimiter(t *testing.T) {
+	db := openTestDB(t)
+	s := NewStorageSys(context.TODO(), mdtest.Mock(), db)
+	mbsys := NewBucketMetadataSys(db)
+	mbsys.CreateBucket(context.TODO(), "testbucket", "", "")
+	// the keys of the bucket whose name starts with the listed one are never listed
+	mbsys.CreateBucket(context.TODO(), "testbucket2", "", "")
+	s.SetNewBucketNSLock(mbsys.NewNSLock)
+	s.SetHasBucket(mbsys.HasBucket)
+	ctx := context.TODO()
+	storeObjects := func(bucket string, objects ...string) {
+		for _, object := range objects {
+			r, err := hash.NewReader(strings.NewReader("1"), 1, "", "", 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = s.StoreObject(ctx, bucket, object, r, 1, map[string]string{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	storeObjects("testbucket", "a", "a/", "a/b", "a/b/c", "a/d", "b/e", "c", "d|e", "d|f|g")
+	storeObjects("testbucket2", "0", "a/0", "z")
+	list := func(prefix, marker, delimiter string, maxKeys int) (objects, prefixes string, loi ListObjectsInfo) {
+		loi, err := s.ListObjects(ctx, "testbucket", prefix, marker, delimiter, maxKeys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, 0, len(loi.Objects))
+		for _, o := range loi.Objects {
+			names = append(names, o.Name)
+		}
+		return strings.Join(names, ","), strings.Join(loi.Prefixes, ","), loi
+	}
+
+	testCases := []struct {
+		prefix, marker, delimiter string
+		maxKeys                   int
+		objects, prefixes         string
+		truncated                 bool
+		nextMarker                string
+	}{
+		// no prefix and no delimiter lists every key of the bucket flat
+		{maxKeys: 100, objects: "a,a/,a/b,a/b/c,a/d,b/e,c,d|e,d|f|g"},
+		// the key equal to a prefix is rolled up into it
+		{delimiter: "/", maxKeys: 100, objects: "a,c,d|e,d|f|g", prefixes: "a/,b/"},
+		// the nested delimiters are rolled up to the first one after the prefix
+		{prefix: "a/", delimiter: "/", maxKeys: 100, objects: "a/,a/b,a/d", prefixes: "a/b/"},
+		{prefix: "a/b", delimiter: "/", maxKeys: 100, objects: "a/b", prefixes: "a/b/"},
+		// a delimiter other than /
+		{delimiter: "|", maxKeys: 100, objects: "a,a/,a/b,a/b/c,a/d,b/e,c", prefixes: "d|"},
+		{prefix: "d|", delimiter: "|", maxKeys: 100, objects: "d|e", prefixes: "d|f|"},
+		// a common prefix counts as one key
+		{delimiter: "/", maxKeys: 2, objects: "a", prefixes: "a/", truncated: true, nextMarker: "a/"},
+		// the listing resumed after a common prefix skips its keys
+		{marker: "a/", delimiter: "/", maxKeys: 2, prefixes: "b/", objects: "c", truncated: true, nextMarker: "c"},
+		{marker: "c", delimiter: "/", maxKeys: 2, objects: "d|e,d|f|g"},
+		// no delimiter lists every key
+		{prefix: "a/", maxKeys: 100, objects: "a/,a/b,a/b/c,a/d"},
+		// a prefix matching no key
+		{prefix: "e", delimiter: "/", maxKeys: 100},
+	}
+	for i, testCase := range testCases {
+		objects, prefixes, loi := list(testCase.prefix, testCase.marker, testCase.delimiter, testCase.maxKeys)
+		if objects != testCase.objects || prefixes != testCase.prefixes {
+			t.Fatalf("case %d: expected the objects %q and the prefixes %q, but got %q and %q", i+1, testCase.objects, testCase.prefixes, objects, prefixes)
+		}
+		if loi.IsTruncated != testCase.truncated || loi.NextMarker != testCase.nextMarker {
+			t.Fatalf("case %d: expected truncated %v with the next marker %q, but got %v and %q", i+1, testCase.truncated, testCase.nextMarker, loi.IsTruncated, loi.NextMarker)
+		}
+	}
+}
+
+func TestStorageSys_ListObjectsV2DeletedToken(t *testing.T) {
+	s, _ := newTestStorageSys(t)
+	ctx := context.TODO()
+	putObject := func(object string) {
+		r, err := hash.NewReader(strings.NewReader("1"), 1, "", "", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = s.StoreObject(ctx, "testbucket", object, r, 1, map[string]string{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, object := range []string{"a", "b", "c", "d", "e"} {
+		putObject(object)
+	}
+	list := func(token string) ([]string, string) {
+		info, err := s.ListObjectsV2(ctx, "testbucket", "", token, "", 2, false, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, o := range info.Objects {
+			names = append(names, o.Name)
+		}
+		return names, info.NextContinuationToken
+	}
+
+	names, token := list("")
+	if fmt.Sprint(names) != "[a b]" || tokenMarker(t, token) != "b" {
+		t.Fatalf("unexpected first page %v with the token %q", names, token)
+	}
+	// the key of the token is deleted and a key is put after it
+	if _, err := s.DeleteObject(ctx, "testbucket", tokenMarker(t, token), ""); err != nil {
+		t.Fatal(err)
+	}
+	putObject("bb")
+
+	// the next page reads the snapshot of the first one
+	names, next := list(token)
+	if fmt.Sprint(names) != "[c d]" || tokenMarker(t, next) != "d" {
+		t.Fatalf("unexpected page %v with the token %q read from the snapshot", names, next)
+	}
+
+	// the snapshot is taken by the page read, the page requested again starts after the
+	// deleted key on the current objects
+	names, next = list(token)
+	if fmt.Sprint(names) != "[bb c]" || tokenMarker(t, next) != "c" {
+		t.Fatalf("unexpected page %v with the token %q resumed after the deleted key", names, next)
+	}
+	names, next = list(next)
+	if fmt.Sprint(names) != "[d e]" || next != "" {
+		t.Fatalf("unexpected last page %v with the token %q", names, next)
+	}
+}
+
+// tokenMarker returns the key the page of the continuation token starts after
+func tokenMarker(t *testing.T, token string) string {
+	if token == "" {
+		return ""
+	}
+	marker, err := ParseContinuationToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return marker
+}
+
+func TestStorageSys_ListObjectsV2Interleaved(t *testing.T) {
+	s, _ := newTestStorageSys(t)
+	ctx := context.TODO()
+	putObject := func(object string) {
+		r, err := hash.NewReader(strings.NewReader("1"), 1, "", "", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = s.StoreObject(ctx, "testbucket", object, r, 1, map[string]string{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, object := range []string{"a", "b", "c", "d", "e", "f"} {
+		putObject(object)
+	}
+	list := func(token string) ([]string, string) {
+		info, err := s.ListObjectsV2(ctx, "testbucket", "", token, "", 2, false, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, o := range info.Objects {
+			names = append(names, o.Name)
+		}
+		return names, info.NextContinuationToken
+	}
+
+	// two listings of the same prefix reach the same key on different snapshots
+	first, token1 := list("")
+	putObject("bb")
+	putObject("cc")
+	second, token2 := list("")
+	if token1 == token2 {
+		t.Fatalf("the listings share the token %q", token1)
+	}
+	if _, err := s.DeleteObject(ctx, "testbucket", "d", ""); err != nil {
+		t.Fatal(err)
+	}
+	putObject("ee")
+
+	// the pages of the listings are interleaved, each one goes on reading its own snapshot
+	for token1 != "" || token2 != "" {
+		var names []string
+		if token1 != "" {
+			names, token1 = list(token1)
+			first = append(first, names...)
+		}
+		if token2 != "" {
+			names, token2 = list(token2)
+			second = append(second, names...)
+		}
+		putObject(fmt.Sprintf("z%d", len(first)))
+	}
+	if fmt.Sprint(first) != "[a b c d e f]" {
+		t.Fatalf("unexpected first listing %v", first)
+	}
+	if fmt.Sprint(second) != "[a b bb c cc d e f]" {
+		t.Fatalf("unexpected second listing %v", second)
+	}
+
+	// a replayed token doesn't take the snapshot of the listing, which goes on with its own
+	_, token1 = list("")
+	names, next := list(token1)
+	if fmt.Sprint(names) != "[bb c]" {
+		t.Fatalf("unexpected page %v", names)
+	}
+	putObject("ca")
+	if names, _ = list(token1); fmt.Sprint(names) != "[bb c]" {
+		t.Fatalf("unexpected page %v of the replayed token", names)
+	}
+	if names, _ = list(next); fmt.Sprint(names) != "[cc e]" {
+		t.Fatalf("unexpected page %v after the replayed token", names)
+	}
+
+	// the token used with another delimiter reads a fresh snapshot and leaves the listing its own
+	_, token1 = list("")
+	putObject("ba")
+	info, err := s.ListObjectsV2(ctx, "testbucket", "", token1, "/", 2, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Objects[0].Name != "ba" {
+		t.Fatalf("unexpected page %v with another delimiter", info.Objects)
+	}
+	if names, _ = list(token1); fmt.Sprint(names) != "[bb c]" {
+		t.Fatalf("unexpected page %v of the listing", names)
+	}
+
+	if _, err = s.ListObjectsV2(ctx, "testbucket", "", "b", "", 2, false, ""); !errors.As(err, &InvalidContinuationToken{}) {
+		t.Fatalf("expected InvalidContinuationToken, got %v", err)
+	}
+}
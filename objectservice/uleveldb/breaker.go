@@ -0,0 +1,178 @@
+package uleveldb
+
+import (
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"sync"
+	"time"
+)
+
+// OperationTimedOut - the db operation didn't finish in time,
+// the db may be only transiently slow (compaction, slow disk).
+// The outcome of a timed out write is unknown: leveldb can't cancel it,
+// so it goes on in the background and may still be applied. The caller
+// must not assume that the write didn't happen.
+type OperationTimedOut struct{}
+
+func (e OperationTimedOut) Error() string {
+	return "leveldb operation timed out"
+}
+
+// ServiceUnavailable - the db failed too many times in a row,
+// operations are refused without touching the db until the cooldown passes.
+type ServiceUnavailable struct{}
+
+func (e ServiceUnavailable) Error() string {
+	return "leveldb is unavailable"
+}
+
+// breaker is a circuit breaker around the db operations
+type breaker struct {
+	// timeout of a single operation, zero disables the breaker
+	timeout time.Duration
+	// the number of consecutive failures to open the breaker
+	maxFailures int
+	// how long the breaker stays open
+	cooldown time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// SetBreaker sets the timeout of each db operation and opens a circuit breaker
+// after maxFailures consecutive timeouts or failures, so that the operations fail
+// fast during cooldown instead of hanging. A zero timeout disables the breaker.
+// A write which times out isn't cancelled, see OperationTimedOut.
+func (l *ULevelDB) SetBreaker(timeout time.Duration, maxFailures int, cooldown time.Duration) {
+	if maxFailures < 1 {
+		maxFailures = 1
+	}
+	l.breaker = &breaker{
+		timeout:     timeout,
+		maxFailures: maxFailures,
+		cooldown:    cooldown,
+	}
+}
+
+// Available returns false while the breaker is open
+func (l *ULevelDB) Available() bool {
+	b := l.breaker
+	if b == nil || b.timeout <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures < b.maxFailures || !time.Now().Before(b.openUntil)
+}
+
+// do runs the db operation under the breaker
+func (l *ULevelDB) do(op func() error) error {
+	b := l.breaker
+	if b == nil || b.timeout <= 0 {
+		return op()
+	}
+	if !b.allow() {
+		return ServiceUnavailable{}
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- op()
+	}()
+	timer := time.NewTimer(b.timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		b.report(isHardFailure(err))
+		return err
+	case <-timer.C:
+		// the operation goes on in the background, a write may still be applied later,
+		// so the timeout of a write is ambiguous: the request fails but its change may be stored.
+		log.Warnf("leveldb operation timed out after %v", b.timeout)
+		b.report(true)
+		return OperationTimedOut{}
+	}
+}
+
+// acquire runs the db operation acquiring a resource under the breaker like do does.
+// The resource acquired after the operation timed out is released, as nobody is left to use it.
+func (l *ULevelDB) acquire(op func() (util.Releaser, error)) (util.Releaser, error) {
+	var (
+		mu        sync.Mutex
+		res       util.Releaser
+		abandoned bool
+	)
+	err := l.do(func() error {
+		r, err := op()
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if abandoned {
+			r.Release()
+			return nil
+		}
+		res = r
+		return nil
+	})
+	if err != nil {
+		mu.Lock()
+		defer mu.Unlock()
+		abandoned = true
+		if res != nil {
+			// acquired while the operation was timing out
+			res.Release()
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+// releaseFunc releases a resource by calling itself
+type releaseFunc func()
+
+func (f releaseFunc) Release() {
+	f()
+}
+
+// isHardFailure returns whether the error means the db is broken,
+// not found is a normal result.
+func isHardFailure(err error) bool {
+	return err != nil && err != leveldb.ErrNotFound
+}
+
+// allow returns whether an operation may go to the db. After the cooldown
+// a single operation is let through to probe the db.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.maxFailures {
+		return true
+	}
+	if time.Now().Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// report records the result of an operation
+func (b *breaker) report(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.maxFailures {
+		if b.failures == b.maxFailures {
+			log.Errorf("leveldb failed %v times in a row, refuse operations for %v", b.failures, b.cooldown)
+		}
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
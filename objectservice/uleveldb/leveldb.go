@@ -2,6 +2,7 @@ package uleveldb
 
 import (
 	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
@@ -9,14 +10,17 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/syndtr/goleveldb/leveldb/util"
 	"github.com/vmihailenco/msgpack/v5"
-	"go.uber.org/zap/buffer"
+	"golang.org/x/xerrors"
 )
 
 var log = logging.Logger("leveldb")
 
+var _ metadb.DB = (*ULevelDB)(nil)
+
 //ULevelDB level db store key-struct
 type ULevelDB struct {
-	DB *leveldb.DB
+	DB      *leveldb.DB
+	breaker *breaker
 }
 
 // OpenDb open a db client
@@ -48,14 +52,23 @@ func (l *ULevelDB) Put(key string, value interface{}) error {
 		log.Errorf("marshal error%v", err)
 		return err
 	}
-	return l.DB.Put([]byte(key), result, nil)
+	return l.do(func() error {
+		return l.DB.Put([]byte(key), result, nil)
+	})
 }
 
 // Get
 // * @param {string} key
 // * @param {interface{}} value
 func (l *ULevelDB) Get(key string, value interface{}) error {
-	get, err := l.DB.Get([]byte(key), nil)
+	var get []byte
+	err := l.do(func() (err error) {
+		get, err = l.DB.Get([]byte(key), nil)
+		return err
+	})
+	if err == leveldb.ErrNotFound {
+		return metadb.ErrNotFound
+	}
 	if err != nil {
 		return err
 	}
@@ -66,52 +79,95 @@ func (l *ULevelDB) Get(key string, value interface{}) error {
 // * @param {string} key
 // * @param {interface{}} value
 func (l *ULevelDB) Delete(key string) error {
-	return l.DB.Delete([]byte(key), nil)
+	return l.do(func() error {
+		return l.DB.Delete([]byte(key), nil)
+	})
 }
 
-// NewIterator /**
+// NewIterator creates the iterator under the breaker, the iterator returns the error
+// of the breaker if it isn't created
 func (l *ULevelDB) NewIterator(slice *util.Range, ro *opt.ReadOptions) iterator.Iterator {
-	return l.DB.NewIterator(slice, ro)
+	iter, err := l.acquire(func() (util.Releaser, error) {
+		return l.DB.NewIterator(slice, ro), nil
+	})
+	if err != nil {
+		return iterator.NewEmptyIterator(err)
+	}
+	return iter.(iterator.Iterator)
+}
+
+//ReadAllChan read all key value
+func (l *ULevelDB) ReadAllChan(ctx context.Context, prefix string, seekKey string) (<-chan *metadb.Entry, error) {
+	return l.readAllChan(ctx, func() iterator.Iterator {
+		return l.DB.NewIterator(prefixRange(prefix), nil)
+	}, seekKey)
 }
 
-type entry struct {
-	Key   string
-	Value []byte
+// readAllChan reads the iterator as metadb.ReadIterator does, the iterator is created
+// and seeks its first key under the breaker
+func (l *ULevelDB) readAllChan(ctx context.Context, newIterator func() iterator.Iterator, seekKey string) (<-chan *metadb.Entry, error) {
+	var all <-chan *metadb.Entry
+	ctx, cancel := context.WithCancel(ctx)
+	_, err := l.acquire(func() (util.Releaser, error) {
+		all = metadb.ReadIterator(ctx, newIterator(), seekKey)
+		// the iterator of a timed out read is released by the cancel
+		return releaseFunc(cancel), nil
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return all, nil
+}
+
+func prefixRange(prefix string) *util.Range {
+	if prefix == "" {
+		return nil
+	}
+	return util.BytesPrefix([]byte(prefix))
 }
 
-func (e *entry) UnmarshalValue(value interface{}) error {
-	return msgpack.Unmarshal(e.Value, value)
+//Batch a batch of key-struct writes, it is applied to db atomically
+type Batch struct {
+	batch leveldb.Batch
 }
 
-//ReadAllChan read all key value
-func (l *ULevelDB) ReadAllChan(ctx context.Context, prefix string, seekKey string) (<-chan *entry, error) {
-	ch := make(chan *entry)
-	var slice *util.Range
-	if prefix != "" {
-		slice = util.BytesPrefix([]byte(prefix))
+// NewBatch new a write batch
+func (l *ULevelDB) NewBatch() metadb.Batch {
+	return &Batch{}
+}
+
+// Put
+// * @param {string} key
+// * @param {interface{}} value
+func (b *Batch) Put(key string, value interface{}) error {
+	result, err := msgpack.Marshal(value)
+	if err != nil {
+		log.Errorf("marshal error%v", err)
+		return err
 	}
-	iter := l.NewIterator(slice, nil)
-	if seekKey != "" {
-		iter.Seek([]byte(seekKey))
+	b.batch.Put([]byte(key), result)
+	return nil
+}
+
+// Delete
+// * @param {string} key
+func (b *Batch) Delete(key string) {
+	b.batch.Delete([]byte(key))
+}
+
+// Len the number of writes in the batch
+func (b *Batch) Len() int {
+	return b.batch.Len()
+}
+
+//Write apply the batch of NewBatch to db
+func (l *ULevelDB) Write(b metadb.Batch) error {
+	batch, ok := b.(*Batch)
+	if !ok {
+		return xerrors.Errorf("leveldb can't write the batch %T", b)
 	}
-	go func() {
-		defer func() {
-			iter.Release()
-			close(ch)
-		}()
-		for iter.Next() {
-			key := string(iter.Key())
-			buf := buffer.Buffer{}
-			buf.Write(iter.Value())
-			select {
-			case <-ctx.Done():
-				return
-			case ch <- &entry{
-				Key:   key,
-				Value: buf.Bytes(),
-			}:
-			}
-		}
-	}()
-	return ch, nil
+	return l.do(func() error {
+		return l.DB.Write(&batch.batch, nil)
+	})
 }
@@ -0,0 +1,38 @@
+package uleveldb
+
+import (
+	"context"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// Snapshot a frozen view of the db, writes made after the snapshot is taken are not visible through it
+type Snapshot struct {
+	db   *ULevelDB
+	snap *leveldb.Snapshot
+}
+
+// GetSnapshot take a snapshot of the current db state under the breaker, the snapshot must be released after use
+func (l *ULevelDB) GetSnapshot() (metadb.Snapshot, error) {
+	snap, err := l.acquire(func() (util.Releaser, error) {
+		return l.DB.GetSnapshot()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{db: l, snap: snap.(*leveldb.Snapshot)}, nil
+}
+
+// ReadAllChan same as ULevelDB.ReadAllChan, but reads the snapshot
+func (s *Snapshot) ReadAllChan(ctx context.Context, prefix string, seekKey string) (<-chan *metadb.Entry, error) {
+	return s.db.readAllChan(ctx, func() iterator.Iterator {
+		return s.snap.NewIterator(prefixRange(prefix), nil)
+	}, seekKey)
+}
+
+// Release release the snapshot, it's ok to release a snapshot more than once
+func (s *Snapshot) Release() {
+	s.snap.Release()
+}
@@ -1,8 +1,15 @@
 package uleveldb
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"github.com/filedag-project/filedag-storage/objectservice/metadb"
+	"github.com/filedag-project/filedag-storage/objectservice/store"
+	mdtest "github.com/ipfs/go-merkledag/test"
+	"github.com/syndtr/goleveldb/leveldb/util"
 	"testing"
+	"time"
 )
 
 func TestULeveldb(t *testing.T) {
@@ -22,3 +29,98 @@ func TestULeveldb(t *testing.T) {
 	}
 	fmt.Println(a)
 }
+
+func TestULeveldbBreaker(t *testing.T) {
+	db, err := OpenDb(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	timeout, cooldown := 50*time.Millisecond, 300*time.Millisecond
+	db.SetBreaker(timeout, 2, cooldown)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := store.NewStorageSys(ctx, mdtest.Mock(), db)
+
+	// not found is not a failure of the db
+	var a int
+	for i := 0; i < 3; i++ {
+		if err = db.Get("not_exist", &a); err != metadb.ErrNotFound {
+			t.Fatalf("expect not found, got %v", err)
+		}
+	}
+	if !db.Available() {
+		t.Fatal("expect db available")
+	}
+
+	// inject latency, the slow operations time out, the iterator or the snapshot
+	// acquired after the timeout is released
+	released := make(chan struct{})
+	slowAcquire := func() (util.Releaser, error) {
+		time.Sleep(4 * timeout)
+		return releaseFunc(func() { close(released) }), nil
+	}
+	if _, err = db.acquire(slowAcquire); !errors.As(err, &OperationTimedOut{}) {
+		t.Fatalf("expect timeout, got %v", err)
+	}
+	select {
+	case <-released:
+	case <-time.After(8 * timeout):
+		t.Fatal("expect the resource acquired after the timeout released")
+	}
+	slowOp := func() error {
+		time.Sleep(4 * timeout)
+		return nil
+	}
+	if err = db.do(slowOp); !errors.As(err, &OperationTimedOut{}) {
+		t.Fatalf("expect timeout, got %v", err)
+	}
+	if db.Available() {
+		t.Fatal("expect db unavailable")
+	}
+
+	// the listings fail fast too, they neither take a snapshot nor create an iterator
+	start := time.Now()
+	if _, err = s.ListObjects(ctx, "bucket", "", "", "", 100); !errors.As(err, &ServiceUnavailable{}) {
+		t.Fatalf("expect the listing unavailable, got %v", err)
+	}
+	if _, err = s.ListObjectsV2(ctx, "bucket", "", "", "", 100, false, ""); !errors.As(err, &ServiceUnavailable{}) {
+		t.Fatalf("expect the listing unavailable, got %v", err)
+	}
+	if _, err = db.ReadAllChan(ctx, "", ""); !errors.As(err, &ServiceUnavailable{}) {
+		t.Fatalf("expect unavailable, got %v", err)
+	}
+	iter := db.NewIterator(nil, nil)
+	if iter.First() || !errors.As(iter.Error(), &ServiceUnavailable{}) {
+		t.Fatalf("expect an empty iterator unavailable, got %v", iter.Error())
+	}
+	iter.Release()
+	if time.Since(start) >= timeout {
+		t.Fatalf("expect fail fast, took %v", time.Since(start))
+	}
+
+	// the breaker is open, requests fail fast
+	start = time.Now()
+	err = db.Put("a", 10)
+	if !errors.As(err, &ServiceUnavailable{}) {
+		t.Fatalf("expect unavailable, got %v", err)
+	}
+	if time.Since(start) >= timeout {
+		t.Fatalf("expect fail fast, took %v", time.Since(start))
+	}
+
+	// after the cooldown the db is probed and the breaker is closed again
+	time.Sleep(cooldown)
+	if err = db.Put("a", 10); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Get("a", &a); err != nil || a != 10 {
+		t.Fatalf("expect 10, got %v %v", a, err)
+	}
+	if !db.Available() {
+		t.Fatal("expect db available")
+	}
+	if _, err = s.ListObjects(ctx, "bucket", "", "", "", 100); err != nil {
+		t.Fatal(err)
+	}
+}
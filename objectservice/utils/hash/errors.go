@@ -22,6 +22,27 @@ func (e BadDigest) Error() string {
 	return "Bad digest: Expected " + e.ExpectedMD5 + " does not match calculated " + e.CalculatedMD5
 }
 
+// ChecksumMismatch - the x-amz-checksum- value of the request did not match what we received.
+type ChecksumMismatch struct {
+	Algorithm  string
+	Expected   string
+	Calculated string
+}
+
+func (e ChecksumMismatch) Error() string {
+	return "Bad " + e.Algorithm + " checksum: Expected " + e.Expected + " does not match calculated " + e.Calculated
+}
+
+// InvalidChecksum - the x-amz-checksum- value of the request isn't a checksum of its algorithm.
+type InvalidChecksum struct {
+	Algorithm string
+	Value     string
+}
+
+func (e InvalidChecksum) Error() string {
+	return "Invalid " + e.Algorithm + " checksum " + e.Value
+}
+
 // ErrSizeMismatch error size mismatch
 type ErrSizeMismatch struct {
 	Want int64
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"github.com/filedag-project/filedag-storage/objectservice/utils/etag"
 	"hash"
+	"hash/crc32"
 	"io"
 )
 
@@ -28,8 +29,19 @@ type Reader struct {
 	contentSHA256 []byte
 	checksum      etag.ETag
 	sha256        hash.Hash
+
+	// the x-amz-checksum- value verified at the end of the content, if it's set
+	checksumAlgorithm string
+	checksumHash      hash.Hash
+	expectedChecksum  func() string
 }
 
+// Checksum algorithms of the x-amz-checksum- values
+const (
+	ChecksumCRC32  = "CRC32"
+	ChecksumSHA256 = "SHA256"
+)
+
 // NewReader returns a new Reader that wraps src and computes
 // MD5 checksum of everything it reads as ETag.
 //
@@ -122,6 +134,9 @@ func (r *Reader) Read(p []byte) (int, error) {
 	if r.sha256 != nil {
 		r.sha256.Write(p[:n])
 	}
+	if r.checksumHash != nil {
+		r.checksumHash.Write(p[:n])
+	}
 
 	if err == io.EOF { // Verify content SHA256, if set.
 		if r.sha256 != nil {
@@ -132,6 +147,13 @@ func (r *Reader) Read(p []byte) (int, error) {
 				}
 			}
 		}
+		// Verify the x-amz-checksum- value, if set.
+		if r.checksumHash != nil {
+			sum := base64.StdEncoding.EncodeToString(r.checksumHash.Sum(nil))
+			if expected := r.expectedChecksum(); sum != expected {
+				return n, ChecksumMismatch{Algorithm: r.checksumAlgorithm, Expected: expected, Calculated: sum}
+			}
+		}
 	}
 	if err != nil && err != io.EOF {
 		if v, ok := err.(etag.VerifyError); ok {
@@ -144,11 +166,54 @@ func (r *Reader) Read(p []byte) (int, error) {
 	return n, err
 }
 
+// SetChecksum sets the x-amz-checksum- value the content is verified against once it's read to the end,
+// the last Read returns ChecksumMismatch if it doesn't match. expected returns the base64 encoded checksum
+// of the algorithm, it's called at the end of the content so that it can return the value of a trailer.
+func (r *Reader) SetChecksum(algorithm string, expected func() string) error {
+	if r.bytesRead > 0 {
+		return errors.New("h: already read from h reader")
+	}
+	h, err := newChecksumHash(algorithm)
+	if err != nil {
+		return err
+	}
+	r.checksumAlgorithm = algorithm
+	r.checksumHash = h
+	r.expectedChecksum = expected
+	return nil
+}
+
+// CheckChecksum returns InvalidChecksum if the value isn't a base64 encoded checksum of the algorithm
+func CheckChecksum(algorithm, value string) error {
+	h, err := newChecksumHash(algorithm)
+	if err != nil {
+		return err
+	}
+	if sum, err := base64.StdEncoding.DecodeString(value); err != nil || len(sum) != h.Size() {
+		return InvalidChecksum{Algorithm: algorithm, Value: value}
+	}
+	return nil
+}
+
+func newChecksumHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case ChecksumCRC32:
+		return crc32.NewIEEE(), nil
+	case ChecksumSHA256:
+		return newSHA256(), nil
+	}
+	return nil, InvalidChecksum{Algorithm: algorithm}
+}
+
 // Size returns the absolute number of bytes the Reader
 // will return during reading. It returns -1 for unlimited
 // data.
 func (r *Reader) Size() int64 { return r.size }
 
+// BytesRead returns the number of bytes read so far, it's the size
+// of the data once the Reader is read to the end.
+func (r *Reader) BytesRead() int64 { return r.bytesRead }
+
 // ActualSize returns the pre-modified size of the object.
 // DecompressedSize - For compressed objects.
 func (r *Reader) ActualSize() int64 { return r.actualSize }
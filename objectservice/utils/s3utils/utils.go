@@ -106,6 +106,16 @@ func (e InvalidPart) Error() string {
 		e.PartNumber, e.ExpETag, e.GotETag)
 }
 
+// InvalidPartOrder the parts of the completed upload aren't listed in ascending order of the part number,
+// or a part number is listed twice
+type InvalidPartOrder struct {
+	PartNumber int
+}
+
+func (e InvalidPartOrder) Error() string {
+	return fmt.Sprintf("The list of parts was not in ascending order, got the part number %d", e.PartNumber)
+}
+
 // PartTooSmall - error if part size is less than 5MB.
 type PartTooSmall struct {
 	PartSize   int64
@@ -124,6 +134,13 @@ func (e PartTooBig) Error() string {
 	return "Part size bigger than the allowed limit"
 }
 
+// ObjectTooLarge returned if the body of an object of unknown size is bigger than the allowed limit.
+type ObjectTooLarge struct{}
+
+func (e ObjectTooLarge) Error() string {
+	return "Object size bigger than the allowed limit"
+}
+
 // We support '.' with bucket names but we fallback to using path
 // style requests instead for such buckets.
 var (
@@ -7,12 +7,14 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"fmt"
 	"github.com/filedag-project/filedag-storage/objectservice/consts"
 	"github.com/filedag-project/filedag-storage/objectservice/iam/auth"
 	"io"
 	"net/http"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -31,6 +33,7 @@ const (
 	signV4Algorithm = "AWS4-HMAC-SHA256"
 	iso8601Format   = "20060102T150405Z"
 	yyyymmdd        = "20060102"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
 )
 
 type ServiceType string
@@ -133,6 +136,50 @@ func SignRequestV4(req *http.Request, accessKey, secretKey string, st ServiceTyp
 	return nil
 }
 
+// maxPresignExpires the longest validity of a presigned URL, 7 days
+const maxPresignExpires = 7 * 24 * time.Hour
+
+// PreSignRequestV4 presigns the request by the query parameters of Signature V4, the URL of the request
+// is the presigned URL, valid for the expires. The URL signs the host header only, and the payload hash of
+// the x-amz-content-sha256 header set by NewRequest, the payload is UNSIGNED-PAYLOAD if the header isn't set.
+//   - http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-query-string-auth.html
+func PreSignRequestV4(req *http.Request, accessKey, secretKey string, st ServiceType, expires time.Duration) error {
+	if expires < time.Second || expires > maxPresignExpires {
+		return fmt.Errorf("the expires %v isn't between 1s and %v", expires, maxPresignExpires)
+	}
+	hashedPayload := req.Header.Get(consts.AmzContentSha256)
+	if hashedPayload == "" {
+		hashedPayload = unsignedPayload
+	}
+	currTime := time.Now().UTC()
+	region := consts.DefaultRegion
+	scope := strings.Join([]string{
+		currTime.Format(yyyymmdd),
+		region,
+		string(st),
+		"aws4_request",
+	}, "/")
+
+	query := req.URL.Query()
+	query.Set(consts.AmzAlgorithm, signV4Algorithm)
+	query.Set(consts.AmzCredential, accessKey+"/"+scope)
+	query.Set(consts.AmzDate, currTime.Format(iso8601Format))
+	query.Set(consts.AmzExpires, strconv.Itoa(int(expires/time.Second)))
+	query.Set(consts.AmzSignedHeaders, "host")
+	if hashedPayload != unsignedPayload {
+		query.Set(consts.AmzContentSha256, hashedPayload)
+	}
+	query.Del(consts.AmzSignature)
+
+	headerMap := http.Header{"host": []string{req.URL.Host}}
+	canonicalRequest := GetCanonicalRequest(headerMap, hashedPayload, query.Encode(), req.URL.Path, req.Method)
+	stringToSign := GetStringToSign(canonicalRequest, currTime, scope)
+	signingKey := GetSigningKey(secretKey, currTime, region, string(st))
+	query.Set(consts.AmzSignature, GetSignature(signingKey, stringToSign))
+	req.URL.RawQuery = query.Encode()
+	return nil
+}
+
 // if object matches reserved string, no need to encode them
 var reservedObjectNames = regexp.MustCompile("^[a-zA-Z0-9-_.~/]+$")
 